@@ -28,6 +28,7 @@
 	"github.com/rancher/rancher/pkg/agent/clean/adunmigration"
 	"github.com/rancher/rancher/pkg/agent/cluster"
 	"github.com/rancher/rancher/pkg/agent/rancher"
+	"github.com/rancher/rancher/pkg/agent/tunnel"
 	"github.com/rancher/rancher/pkg/controllers/managementuser/cavalidator"
 	"github.com/rancher/rancher/pkg/features"
 	"github.com/rancher/rancher/pkg/logserver"
@@ -119,21 +120,11 @@ func run(ctx context.Context) error {
 		return err
 	}
 	writeCertsOnly := os.Getenv("CATTLE_WRITE_CERT_ONLY") == "true"
-	bytes, err := json.Marshal(params)
-	if err != nil {
-		return err
-	}
-
 	token, server, err := getTokenAndURL()
 	if err != nil {
 		return err
 	}
 
-	headers := http.Header{
-		Token:  {token},
-		Params: {base64.StdEncoding.EncodeToString(bytes)},
-	}
-
 	serverURL, err := url.Parse(server)
 	if err != nil {
 		return err
@@ -275,19 +266,32 @@ func run(ctx context.Context) error {
 		return nil
 	}
 
-	go func() {
-		log.Println(http.ListenAndServe("localhost:6060", nil))
-	}()
+	endpoints, err := tunnel.ParseEndpoints(server, cluster.CAChecksum(), cluster.FailoverEndpoints())
+	if err != nil {
+		return err
+	}
+	// the primary endpoint was verified above, only the failover endpoints are verified against their checksums
+	endpoints[0].CAChecksum = ""
+
+	policy, err := watchTunnelDialPolicy(ctx)
+	if err != nil {
+		return err
+	}
 
-	for {
-		wsURL := fmt.Sprintf("wss://%s/v3/connect", serverURL.Host)
-		if !isConnect() {
-			wsURL += "/register"
+	connector := tunnel.NewConnector(endpoints, tunnel.DefaultBackoff(), func(ctx context.Context, endpoint tunnel.Endpoint, status tunnel.Status, established func()) error {
+		dialer, err := endpoint.Dialer(ctx)
+		if err != nil {
+			return err
+		}
+		headers, err := tunnelHeaders(token, params, status)
+		if err != nil {
+			return err
 		}
 
+		wsURL := endpoint.ConnectURL(!isConnect())
 		logrus.Infof("Connecting to %s with token starting with %s", wsURL, token[:len(token)/2])
 		logrus.Tracef("Connecting to %s with token %s", wsURL, token)
-		remotedialer.ClientConnect(ctx, wsURL, headers, nil, func(proto, address string) bool {
+		return remotedialer.ConnectToProxyWithDialer(ctx, wsURL, headers, func(proto, address string) bool {
 			switch proto {
 			case "tcp":
 				return true
@@ -297,9 +301,81 @@ func run(ctx context.Context) error {
 				return address == "//./pipe/docker_engine"
 			}
 			return false
-		}, onConnect)
-		time.Sleep(5 * time.Second)
+		}, dialer, policy.Dialer(), func(ctx context.Context, session *remotedialer.Session) error {
+			established()
+			return onConnect(ctx, session)
+		})
+	})
+
+	go serveHealth(ctx, connector)
+	go func() {
+		log.Println(http.ListenAndServe("localhost:6060", nil))
+	}()
+
+	connector.Run(ctx)
+	return ctx.Err()
+}
+
+// serveHealth serves the status of the connection to Rancher at /healthz on a dedicated listener, so that probes can
+// reach it independently of the pprof listener.
+func serveHealth(ctx context.Context, connector *tunnel.Connector) {
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", connector)
+	server := &http.Server{
+		Addr:              cluster.HealthAddress(),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logrus.Errorf("Failed to serve the agent health endpoint on %s: %v", server.Addr, err)
+	}
+}
+
+// watchTunnelDialPolicy returns the policy restricting the destinations Rancher can dial through the tunnel, which is
+// kept in sync with the policy Rancher pushes to the cluster.
+func watchTunnelDialPolicy(ctx context.Context) (*tunnel.Policy, error) {
+	apiServer, err := cluster.APIServerAddress()
+	if err != nil {
+		return nil, err
+	}
+	k8s, err := cluster.Client()
+	if err != nil {
+		return nil, err
+	}
+
+	policy := tunnel.NewPolicy(apiServer)
+	if err := policy.Watch(ctx, k8s); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// tunnelHeaders returns the headers of the tunnel connection. The status of the connection is added to the params so
+// Rancher can report which endpoint the agent is connected to.
+func tunnelHeaders(token string, params map[string]interface{}, status tunnel.Status) (http.Header, error) {
+	tunnelParams := make(map[string]interface{}, len(params)+1)
+	for k, v := range params {
+		tunnelParams[k] = v
 	}
+	tunnelParams["tunnel"] = map[string]interface{}{
+		"endpoint":      status.Endpoint,
+		"endpointIndex": status.EndpointIndex,
+		"endpointCount": status.EndpointCount,
+		"failures":      status.Failures,
+	}
+
+	bytes, err := json.Marshal(tunnelParams)
+	if err != nil {
+		return nil, err
+	}
+	return http.Header{
+		Token:  {token},
+		Params: {base64.StdEncoding.EncodeToString(bytes)},
+	}, nil
 }
 
 func exitCertWriter(ctx context.Context) {
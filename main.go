@@ -12,6 +12,7 @@
 	"github.com/ehazlett/simplelog"
 	_ "github.com/rancher/norman/controller"
 	"github.com/rancher/norman/pkg/kwrapper/k8s"
+	"github.com/rancher/rancher/pkg/auth/audit"
 	"github.com/rancher/rancher/pkg/data/management"
 	"github.com/rancher/rancher/pkg/logserver"
 	"github.com/rancher/rancher/pkg/rancher"
@@ -29,6 +30,7 @@
 func main() {
 	management.RegisterPasswordResetCommand()
 	management.RegisterEnsureDefaultAdminCommand()
+	audit.RegisterVerifyChainCommand()
 	if reexec.Init() {
 		return
 	}
@@ -147,6 +149,89 @@ func main() {
 			EnvVar:      "AUDIT_LOG_ENABLED",
 			Destination: &config.AuditLogEnabled,
 		},
+		cli.StringFlag{
+			Name:        "audit-log-syslog-address",
+			EnvVar:      "AUDIT_LOG_SYSLOG_ADDRESS",
+			Usage:       "Address (host:port) of a syslog server to also send audit logs to using RFC5424 over TCP",
+			Destination: &config.AuditLogSyslogAddress,
+		},
+		cli.BoolFlag{
+			Name:        "audit-log-syslog-tls",
+			EnvVar:      "AUDIT_LOG_SYSLOG_TLS",
+			Usage:       "Use TLS when connecting to the audit log syslog server",
+			Destination: &config.AuditLogSyslogTLS,
+		},
+		cli.StringFlag{
+			Name:        "audit-log-syslog-ca-cert",
+			EnvVar:      "AUDIT_LOG_SYSLOG_CA_CERT",
+			Usage:       "Path to a PEM encoded CA bundle used to verify the audit log syslog server, defaults to the system roots",
+			Destination: &config.AuditLogSyslogCACert,
+		},
+		cli.StringFlag{
+			Name:        "audit-log-webhook-url",
+			EnvVar:      "AUDIT_LOG_WEBHOOK_URL",
+			Usage:       "URL of an HTTP endpoint to also send batches of audit logs to as a JSON array",
+			Destination: &config.AuditLogWebhookURL,
+		},
+		cli.StringFlag{
+			Name:        "audit-log-webhook-ca-cert",
+			EnvVar:      "AUDIT_LOG_WEBHOOK_CA_CERT",
+			Usage:       "Path to a PEM encoded CA bundle used to verify the audit log webhook, defaults to the system roots",
+			Destination: &config.AuditLogWebhookCACert,
+		},
+		cli.StringFlag{
+			Name:        "audit-log-webhook-authorization",
+			EnvVar:      "AUDIT_LOG_WEBHOOK_AUTHORIZATION",
+			Usage:       "Value of the Authorization header sent to the audit log webhook",
+			Destination: &config.AuditLogWebhookAuthorization,
+		},
+		cli.StringFlag{
+			Name:        "audit-log-webhook-buffer-path",
+			EnvVar:      "AUDIT_LOG_WEBHOOK_BUFFER_PATH",
+			Value:       "/var/log/auditlog/rancher-api-audit-webhook.buffer",
+			Usage:       "File used to buffer audit logs which could not be delivered to the webhook",
+			Destination: &config.AuditLogWebhookBufferPath,
+		},
+		cli.StringFlag{
+			Name:        "audit-log-webhook-rejected-path",
+			EnvVar:      "AUDIT_LOG_WEBHOOK_REJECTED_PATH",
+			Value:       "/var/log/auditlog/rancher-api-audit-webhook.rejected",
+			Usage:       "File used to keep audit logs the webhook rejected, which are not resent",
+			Destination: &config.AuditLogWebhookRejectedPath,
+		},
+		cli.IntFlag{
+			Name:        "audit-log-queue-size",
+			EnvVar:      "AUDIT_LOG_QUEUE_SIZE",
+			Value:       10000,
+			Usage:       "Number of audit logs which can wait to be written in the background, 0 writes audit logs while handling the request",
+			Destination: &config.AuditLogQueueSize,
+		},
+		cli.IntFlag{
+			Name:        "audit-log-workers",
+			EnvVar:      "AUDIT_LOG_WORKERS",
+			Value:       1,
+			Usage:       "Number of workers writing queued audit logs, audit logs may be written out of order with more than 1 worker",
+			Destination: &config.AuditLogWorkers,
+		},
+		cli.StringFlag{
+			Name:        "audit-log-overflow-policy",
+			EnvVar:      "AUDIT_LOG_OVERFLOW_POLICY",
+			Value:       "block",
+			Usage:       "What to do when the audit log queue is full: block - wait for room in the queue, drop-oldest - drop the oldest queued log, drop - drop the new log",
+			Destination: &config.AuditLogOverflowPolicy,
+		},
+		cli.BoolFlag{
+			Name:        "audit-log-chain",
+			EnvVar:      "AUDIT_LOG_CHAIN_ENABLED",
+			Usage:       "Add a sequence number and an HMAC chaining each audit log to the previous one, so that tampering can be detected with verify-audit-log",
+			Destination: &config.AuditLogChainEnabled,
+		},
+		cli.StringSliceFlag{
+			Name:   "audit-log-trusted-proxies",
+			EnvVar: "AUDIT_LOG_TRUSTED_PROXIES",
+			Usage:  "IP addresses or CIDRs of the proxies in front of Rancher, the source IP of audit logs of requests received from them is taken from the X-Forwarded-For or X-Real-IP headers",
+			Value:  &config.AuditLogTrustedProxies,
+		},
 		cli.StringFlag{
 			Name:        "profile-listen-address",
 			Value:       "127.0.0.1:6060",
@@ -0,0 +1,99 @@
+// Package admissionpolicy builds and applies the validating admission policies Rancher installs in the local cluster,
+// for the admission rules which need the authorizer of the kube-apiserver.
+package admissionpolicy
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/rancher/wrangler/v3/pkg/apply"
+	"github.com/sirupsen/logrus"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/ptr"
+)
+
+// applyBackoff is the backoff between attempts to apply a policy, which are retried until they succeed.
+var applyBackoff = wait.Backoff{
+	Duration: 5 * time.Second,
+	Factor:   2,
+	Cap:      5 * time.Minute,
+	Steps:    math.MaxInt32,
+}
+
+// Policy is a validating admission policy on the creation and update of a resource, bound to deny the requests which
+// fail its validations.
+type Policy struct {
+	// Name is the name of the policy and of its binding.
+	Name string
+	// Resource is the resource the policy validates.
+	Resource schema.GroupVersionResource
+	// Variables are the variables the validations can use.
+	Variables []admissionv1.Variable
+	// Validations are the validations of the policy, a request is denied if any of them fails.
+	Validations []admissionv1.Validation
+}
+
+// Objects returns the validating admission policy and its binding.
+func (p Policy) Objects() []runtime.Object {
+	policy := &admissionv1.ValidatingAdmissionPolicy{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: admissionv1.SchemeGroupVersion.String(),
+			Kind:       "ValidatingAdmissionPolicy",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: p.Name,
+		},
+		Spec: admissionv1.ValidatingAdmissionPolicySpec{
+			FailurePolicy: ptr.To(admissionv1.Fail),
+			MatchConstraints: &admissionv1.MatchResources{
+				ResourceRules: []admissionv1.NamedRuleWithOperations{{
+					RuleWithOperations: admissionv1.RuleWithOperations{
+						Operations: []admissionv1.OperationType{admissionv1.Create, admissionv1.Update},
+						Rule: admissionv1.Rule{
+							APIGroups:   []string{p.Resource.Group},
+							APIVersions: []string{p.Resource.Version},
+							Resources:   []string{p.Resource.Resource},
+						},
+					},
+				}},
+			},
+			Variables:   p.Variables,
+			Validations: p.Validations,
+		},
+	}
+	binding := &admissionv1.ValidatingAdmissionPolicyBinding{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: admissionv1.SchemeGroupVersion.String(),
+			Kind:       "ValidatingAdmissionPolicyBinding",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: p.Name,
+		},
+		Spec: admissionv1.ValidatingAdmissionPolicyBindingSpec{
+			PolicyName:        p.Name,
+			ValidationActions: []admissionv1.ValidationAction{admissionv1.Deny},
+		},
+	}
+
+	return []runtime.Object{policy, binding}
+}
+
+// Apply applies the policy and its binding in the background with the given set ID. A failure, for example while the
+// local cluster doesn't serve validating admission policies, is logged and retried with a backoff until it succeeds or
+// ctx is done, so that it doesn't prevent Rancher from starting.
+func Apply(ctx context.Context, apply apply.Apply, setID string, policy Policy) {
+	go func() {
+		_ = wait.ExponentialBackoffWithContext(ctx, applyBackoff, func(context.Context) (bool, error) {
+			if err := apply.WithSetID(setID).WithDynamicLookup().ApplyObjects(policy.Objects()...); err != nil {
+				logrus.Errorf("Failed to apply the validating admission policy %s, retrying: %v", policy.Name, err)
+				return false, nil
+			}
+			return true, nil
+		})
+	}()
+}
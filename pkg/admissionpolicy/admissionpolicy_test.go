@@ -0,0 +1,43 @@
+package admissionpolicy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestPolicyObjects(t *testing.T) {
+	p := Policy{
+		Name:     "rancher-test",
+		Resource: schema.GroupVersionResource{Group: "management.cattle.io", Version: "v3", Resource: "oidcclients"},
+		Variables: []admissionv1.Variable{
+			{Name: "name", Expression: "object.metadata.name"},
+		},
+		Validations: []admissionv1.Validation{
+			{Expression: "variables.name != 'forbidden'"},
+		},
+	}
+
+	objs := p.Objects()
+	require.Len(t, objs, 2)
+	policy := objs[0].(*admissionv1.ValidatingAdmissionPolicy)
+	binding := objs[1].(*admissionv1.ValidatingAdmissionPolicyBinding)
+
+	assert.Equal(t, "rancher-test", policy.Name)
+	assert.Equal(t, admissionv1.Fail, *policy.Spec.FailurePolicy)
+	require.Len(t, policy.Spec.MatchConstraints.ResourceRules, 1)
+	rule := policy.Spec.MatchConstraints.ResourceRules[0]
+	assert.Equal(t, []admissionv1.OperationType{admissionv1.Create, admissionv1.Update}, rule.Operations)
+	assert.Equal(t, []string{"management.cattle.io"}, rule.APIGroups)
+	assert.Equal(t, []string{"v3"}, rule.APIVersions)
+	assert.Equal(t, []string{"oidcclients"}, rule.Resources)
+	assert.Equal(t, p.Variables, policy.Spec.Variables)
+	assert.Equal(t, p.Validations, policy.Spec.Validations)
+
+	assert.Equal(t, "rancher-test", binding.Name)
+	assert.Equal(t, policy.Name, binding.Spec.PolicyName)
+	assert.Equal(t, []admissionv1.ValidationAction{admissionv1.Deny}, binding.Spec.ValidationActions)
+}
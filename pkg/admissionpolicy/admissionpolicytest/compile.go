@@ -0,0 +1,44 @@
+// Package admissionpolicytest compiles validating admission policies in tests, the same way the kube-apiserver does.
+package admissionpolicytest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/apiserver/pkg/admission/plugin/cel"
+	"k8s.io/apiserver/pkg/admission/plugin/policy/validating"
+	"k8s.io/apiserver/pkg/cel/environment"
+)
+
+// Compile compiles the policy the same way the kube-apiserver does, failing the test on compilation errors.
+func Compile(t *testing.T, policy *admissionv1.ValidatingAdmissionPolicy) validating.Validator {
+	t.Helper()
+
+	env, err := cel.NewCompositionEnv(cel.VariablesTypeName, environment.MustBaseEnvSet(environment.DefaultCompatibilityVersion(), true))
+	require.NoError(t, err)
+	compiler := cel.NewCompositedCompilerFromTemplate(env)
+	vars := cel.OptionalVariableDeclarations{HasAuthorizer: true, StrictCost: true}
+
+	variables := make([]cel.NamedExpressionAccessor, len(policy.Spec.Variables))
+	for i, v := range policy.Spec.Variables {
+		variables[i] = &validating.Variable{Name: v.Name, Expression: v.Expression}
+	}
+	compiler.CompileAndStoreVariables(variables, vars, environment.StoredExpressions)
+
+	validations := make([]cel.ExpressionAccessor, len(policy.Spec.Validations))
+	messages := make([]cel.ExpressionAccessor, len(policy.Spec.Validations))
+	for i, v := range policy.Spec.Validations {
+		validations[i] = &validating.ValidationCondition{Expression: v.Expression, Message: v.Message, Reason: v.Reason}
+		messages[i] = &validating.MessageExpressionCondition{MessageExpression: v.MessageExpression}
+	}
+	validationFilter := compiler.CompileCondition(validations, vars, environment.StoredExpressions)
+	messageFilter := compiler.CompileCondition(messages, cel.OptionalVariableDeclarations{StrictCost: true}, environment.StoredExpressions)
+	for _, filter := range []cel.ConditionEvaluator{validationFilter, messageFilter} {
+		for _, compilationErr := range filter.CompilationErrors() {
+			require.NoError(t, compilationErr)
+		}
+	}
+
+	return validating.NewValidator(validationFilter, nil, compiler.CompileCondition(nil, vars, environment.StoredExpressions), messageFilter, policy.Spec.FailurePolicy)
+}
@@ -26,6 +26,10 @@
 
 	kubernetesServiceHostKey = "KUBERNETES_SERVICE_HOST"
 	kubernetesServicePortKey = "KUBERNETES_SERVICE_PORT"
+
+	// defaultHealthAddress is the conventional port of health probes, reachable from outside of the pod unlike the
+	// pprof listener on localhost.
+	defaultHealthAddress = ":8081"
 )
 
 func Namespace() (string, error) {
@@ -49,16 +53,36 @@ func CAChecksum() string {
 	return os.Getenv("CATTLE_CA_CHECKSUM")
 }
 
+// FailoverEndpoints returns the Rancher endpoints the agent fails over to when the primary server URL is unreachable,
+// as a comma separated list of URLs, each optionally followed by the checksum of its CA certificates as fragment.
+// Rancher sets it from the agent-failover-endpoints setting unless it is set in the agent env vars of the cluster.
+func FailoverEndpoints() string {
+	return os.Getenv("CATTLE_SERVER_FAILOVER_ENDPOINTS")
+}
+
+// HealthAddress returns the address the agent serves the health of its connection to Rancher on, at /healthz.
+func HealthAddress() string {
+	if addr := os.Getenv("CATTLE_AGENT_HEALTH_ADDRESS"); addr != "" {
+		return addr
+	}
+	return defaultHealthAddress
+}
+
 func CAStrictVerify() bool {
 	return strings.ToLower(os.Getenv("STRICT_VERIFY")) == "true"
 }
 
-func getTokenFromAPI() ([]byte, []byte, error) {
+// Client returns a client of the cluster the agent runs in.
+func Client() (kubernetes.Interface, error) {
 	cfg, err := kubeconfig.GetNonInteractiveClientConfig("").ClientConfig()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	k8s, err := kubernetes.NewForConfig(cfg)
+	return kubernetes.NewForConfig(cfg)
+}
+
+func getTokenFromAPI() ([]byte, []byte, error) {
+	k8s, err := Client()
 	if err != nil {
 		return nil, nil, err
 	}
@@ -91,24 +115,33 @@ func Params() (map[string]interface{}, error) {
 		return nil, errors.Wrapf(err, "looking up %s/%s ca/token", namespace.System, "cattle")
 	}
 
-	kubernetesServiceHost, err := getenv(kubernetesServiceHostKey)
-	if err != nil {
-		return nil, err
-	}
-	kubernetesServicePort, err := getenv(kubernetesServicePortKey)
+	address, err := APIServerAddress()
 	if err != nil {
 		return nil, err
 	}
 
 	return map[string]interface{}{
 		"cluster": map[string]interface{}{
-			"address": fmt.Sprintf("%s:%s", kubernetesServiceHost, kubernetesServicePort),
+			"address": address,
 			"token":   strings.TrimSpace(string(token)),
 			"caCert":  base64.StdEncoding.EncodeToString(caData),
 		},
 	}, nil
 }
 
+// APIServerAddress returns the address of the kube-apiserver Rancher connects to through the tunnel.
+func APIServerAddress() (string, error) {
+	kubernetesServiceHost, err := getenv(kubernetesServiceHostKey)
+	if err != nil {
+		return "", err
+	}
+	kubernetesServicePort, err := getenv(kubernetesServicePortKey)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s:%s", kubernetesServiceHost, kubernetesServicePort), nil
+}
+
 func getenv(env string) (string, error) {
 	value := os.Getenv(env)
 	if value == "" {
@@ -0,0 +1,59 @@
+package tunnel
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Backoff computes exponentially growing delays between connection attempts. A random part of each delay is removed
+// so that agents disconnected at the same time, for example by a restart of Rancher, don't reconnect at the same time.
+type Backoff struct {
+	// Initial is the delay before the first retry.
+	Initial time.Duration
+	// Max is the maximum delay.
+	Max time.Duration
+	// Factor multiplies the delay after each attempt.
+	Factor float64
+	// Jitter is the fraction of each delay that is randomized, between 0 and 1.
+	Jitter float64
+
+	attempts int
+	rand     func() float64
+}
+
+// DefaultBackoff returns the backoff used between connection attempts of the agent. Most of each delay is randomized,
+// so that the agents of all clusters spread their first attempts over several seconds after Rancher restarts.
+func DefaultBackoff() *Backoff {
+	return &Backoff{
+		Initial: 10 * time.Second,
+		Max:     2 * time.Minute,
+		Factor:  2,
+		Jitter:  0.8,
+	}
+}
+
+// Next returns the delay before the next attempt and increases the delay of the following attempts.
+func (b *Backoff) Next() time.Duration {
+	delay := float64(b.Initial)
+	for i := 0; i < b.attempts && delay < float64(b.Max); i++ {
+		delay *= b.Factor
+	}
+	if delay >= float64(b.Max) {
+		delay = float64(b.Max)
+	} else {
+		b.attempts++
+	}
+
+	random := rand.Float64
+	if b.rand != nil {
+		random = b.rand
+	}
+	delay -= delay * b.Jitter * random()
+
+	return time.Duration(delay)
+}
+
+// Reset resets the delay to the initial delay.
+func (b *Backoff) Reset() {
+	b.attempts = 0
+}
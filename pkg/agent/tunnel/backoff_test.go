@@ -0,0 +1,42 @@
+package tunnel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	b := &Backoff{
+		Initial: time.Second,
+		Max:     10 * time.Second,
+		Factor:  2,
+		Jitter:  0.5,
+		rand:    func() float64 { return 0 },
+	}
+
+	var delays []time.Duration
+	for i := 0; i < 6; i++ {
+		delays = append(delays, b.Next())
+	}
+	assert.Equal(t, []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second,
+	}, delays)
+
+	b.Reset()
+	assert.Equal(t, time.Second, b.Next())
+}
+
+func TestBackoffJitter(t *testing.T) {
+	b := &Backoff{
+		Initial: 4 * time.Second,
+		Max:     time.Minute,
+		Factor:  2,
+		Jitter:  0.5,
+		rand:    func() float64 { return 1 },
+	}
+
+	assert.Equal(t, 2*time.Second, b.Next())
+	assert.Equal(t, 4*time.Second, b.Next())
+}
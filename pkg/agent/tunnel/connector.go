@@ -0,0 +1,166 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// State is the state of the connection of the agent to Rancher.
+type State string
+
+const (
+	StateConnecting   State = "Connecting"
+	StateConnected    State = "Connected"
+	StateDisconnected State = "Disconnected"
+)
+
+// Status is the status of the connection of the agent to Rancher.
+type Status struct {
+	State State `json:"state"`
+	// Endpoint is the endpoint the agent is connected or connecting to.
+	Endpoint string `json:"endpoint"`
+	// EndpointIndex is the position of Endpoint in the list of endpoints, 0 being the primary endpoint.
+	EndpointIndex int `json:"endpointIndex"`
+	// EndpointCount is the number of endpoints.
+	EndpointCount int `json:"endpointCount"`
+	// Failures is the number of consecutive failed connection attempts.
+	Failures int `json:"failures"`
+	// LastError is the error of the last failed connection attempt.
+	LastError string `json:"lastError,omitempty"`
+	// Since is the time of the last state change.
+	Since time.Time `json:"since"`
+}
+
+// stableConnectionDuration is how long a connection must stay established before the backoff is reset, so that agents
+// whose connection is accepted and dropped right away, for example by an overloaded Rancher, keep backing off.
+const stableConnectionDuration = time.Minute
+
+// ConnectFunc connects to an endpoint and blocks until the connection is closed. It must call onConnect once the
+// connection is established.
+type ConnectFunc func(ctx context.Context, endpoint Endpoint, status Status, onConnect func()) error
+
+// Connector keeps the agent connected to one of its Rancher endpoints. The endpoints are tried in order with
+// backoff between attempts. The primary endpoint is tried first again whenever an established connection is lost, and
+// the backoff is reset if the connection was established for long enough.
+type Connector struct {
+	endpoints []Endpoint
+	backoff   *Backoff
+	connect   ConnectFunc
+	now       func() time.Time
+	after     func(time.Duration) <-chan time.Time
+
+	lock   sync.RWMutex
+	status Status
+}
+
+// NewConnector returns a connector to the given endpoints, which must not be empty.
+func NewConnector(endpoints []Endpoint, backoff *Backoff, connect ConnectFunc) *Connector {
+	return &Connector{
+		endpoints: endpoints,
+		backoff:   backoff,
+		connect:   connect,
+		now:       time.Now,
+		after:     time.After,
+		status: Status{
+			State:         StateDisconnected,
+			EndpointCount: len(endpoints),
+		},
+	}
+}
+
+// Run connects to the endpoints until the context is done.
+func (c *Connector) Run(ctx context.Context) {
+	index := 0
+	for ctx.Err() == nil {
+		endpoint := c.endpoints[index]
+		status := c.update(func(s *Status) {
+			s.State = StateConnecting
+			s.Endpoint = endpoint.String()
+			s.EndpointIndex = index
+		})
+
+		var connectedAt atomic.Pointer[time.Time]
+		err := c.connect(ctx, endpoint, status, func() {
+			now := c.now()
+			connectedAt.Store(&now)
+			c.update(func(s *Status) {
+				s.State = StateConnected
+				s.Failures = 0
+				s.LastError = ""
+			})
+			if index > 0 {
+				logrus.Warnf("Connected to failover Rancher endpoint %s", endpoint)
+			}
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		if established := connectedAt.Load(); established != nil {
+			// fail back to the primary endpoint, but still back off so that all agents don't reconnect at once
+			if c.now().Sub(*established) >= stableConnectionDuration {
+				c.backoff.Reset()
+			}
+			index = 0
+			c.update(func(s *Status) {
+				s.State = StateDisconnected
+				if err != nil {
+					s.LastError = err.Error()
+				}
+			})
+		} else {
+			index = (index + 1) % len(c.endpoints)
+			c.update(func(s *Status) {
+				s.State = StateDisconnected
+				s.Failures++
+				if err != nil {
+					s.LastError = err.Error()
+				}
+			})
+		}
+
+		delay := c.backoff.Next()
+		logrus.Infof("Connecting to %s in %s", c.endpoints[index], delay.Round(time.Millisecond))
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.after(delay):
+		}
+	}
+}
+
+func (c *Connector) update(f func(*Status)) Status {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	previous := c.status.State
+	f(&c.status)
+	if c.status.State != previous {
+		c.status.Since = c.now()
+	}
+	return c.status
+}
+
+// Status returns the current status of the connection.
+func (c *Connector) Status() Status {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.status
+}
+
+// ServeHTTP reports the status of the connection. It responds with 503 if the agent is not connected.
+func (c *Connector) ServeHTTP(rw http.ResponseWriter, _ *http.Request) {
+	status := c.Status()
+	rw.Header().Set("Content-Type", "application/json")
+	if status.State != StateConnected {
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(rw).Encode(status); err != nil {
+		logrus.Debugf("Failed to write tunnel status: %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectorRun(t *testing.T) {
+	endpoints, err := ParseEndpoints("https://rancher.example.com", "", "https://rancher-dr.example.com")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the primary endpoint fails twice, then the connection to the failover endpoint is established and lost right
+	// away, and the connection to the primary endpoint is established and lost after an hour
+	now := time.Now()
+	var attempts []Status
+	connector := NewConnector(endpoints, &Backoff{Initial: time.Second, Max: time.Minute, Factor: 2, rand: func() float64 { return 0 }},
+		func(ctx context.Context, endpoint Endpoint, status Status, onConnect func()) error {
+			attempts = append(attempts, status)
+			switch len(attempts) {
+			case 1, 3:
+				return errors.New("connection refused")
+			case 2:
+				return errors.New("no route to host")
+			case 4:
+				onConnect()
+				return errors.New("connection reset")
+			case 5:
+				onConnect()
+				now = now.Add(time.Hour)
+				return errors.New("EOF")
+			default:
+				cancel()
+				return ctx.Err()
+			}
+		})
+	connector.now = func() time.Time { return now }
+	var delays []time.Duration
+	connector.after = func(d time.Duration) <-chan time.Time {
+		delays = append(delays, d)
+		c := make(chan time.Time, 1)
+		c <- time.Time{}
+		return c
+	}
+
+	connector.Run(ctx)
+
+	require.Len(t, attempts, 6)
+	var endpointIndexes, failures []int
+	for _, attempt := range attempts {
+		assert.Equal(t, StateConnecting, attempt.State)
+		endpointIndexes = append(endpointIndexes, attempt.EndpointIndex)
+		failures = append(failures, attempt.Failures)
+	}
+	assert.Equal(t, []int{0, 1, 0, 1, 0, 0}, endpointIndexes)
+	assert.Equal(t, []int{0, 1, 2, 3, 0, 0}, failures)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, time.Second}, delays)
+	assert.Equal(t, "EOF", connector.Status().LastError)
+}
+
+func TestConnectorServeHTTP(t *testing.T) {
+	endpoints, err := ParseEndpoints("https://rancher.example.com", "", "")
+	require.NoError(t, err)
+	connector := NewConnector(endpoints, DefaultBackoff(), nil)
+
+	rw := httptest.NewRecorder()
+	connector.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rw.Code)
+	assert.Contains(t, rw.Body.String(), `"state":"Disconnected"`)
+
+	connector.update(func(s *Status) {
+		s.State = StateConnected
+		s.Endpoint = "https://rancher.example.com"
+	})
+	rw = httptest.NewRecorder()
+	connector.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Contains(t, rw.Body.String(), `"endpoint":"https://rancher.example.com"`)
+}
@@ -0,0 +1,147 @@
+package tunnel
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rancher/remotedialer"
+)
+
+// Endpoint is a Rancher server the agent can connect to.
+type Endpoint struct {
+	URL *url.URL
+	// CAChecksum is the sha256 checksum of the CA certificates served by the endpoint. The endpoint is verified
+	// against the system trust store if it is empty.
+	CAChecksum string
+}
+
+// ParseEndpoints returns the primary endpoint followed by the failover endpoints. The failover endpoints are a comma
+// separated list of URLs, each optionally followed by the checksum of its CA certificates as fragment, for example
+// https://rancher-dr.example.com#<checksum>.
+func ParseEndpoints(primary, primaryCAChecksum, failover string) ([]Endpoint, error) {
+	primaryURL, err := parseURL(primary)
+	if err != nil {
+		return nil, err
+	}
+	endpoints := []Endpoint{{URL: primaryURL, CAChecksum: primaryCAChecksum}}
+
+	for _, entry := range strings.Split(failover, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		u, err := parseURL(entry)
+		if err != nil {
+			return nil, err
+		}
+		checksum := u.Fragment
+		u.Fragment = ""
+		endpoints = append(endpoints, Endpoint{URL: u, CAChecksum: checksum})
+	}
+
+	return endpoints, nil
+}
+
+func parseURL(s string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Rancher endpoint %q: %w", s, err)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid Rancher endpoint %q: must be an https URL", s)
+	}
+	return u, nil
+}
+
+// String returns the URL of the endpoint.
+func (e Endpoint) String() string {
+	return e.URL.String()
+}
+
+// ConnectURL returns the websocket URL of the tunnel of the endpoint.
+func (e Endpoint) ConnectURL(register bool) string {
+	wsURL := fmt.Sprintf("wss://%s/v3/connect", e.URL.Host)
+	if register {
+		wsURL += "/register"
+	}
+	return wsURL
+}
+
+// Dialer returns the websocket dialer to connect to the endpoint. If the endpoint has a CA checksum, the CA
+// certificates are downloaded from the endpoint and trusted if they match the checksum. It returns nil if the
+// endpoint is verified against the system trust store.
+func (e Endpoint) Dialer(ctx context.Context) (*websocket.Dialer, error) {
+	if e.CAChecksum == "" {
+		return nil, nil
+	}
+
+	cacerts, err := e.fetchCACerts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if checksum := caChecksum(cacerts); checksum != e.CAChecksum {
+		return nil, fmt.Errorf("checksum %s of the CA certificates of %s does not match the configured checksum %s", checksum, e, e.CAChecksum)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(cacerts) {
+		return nil, fmt.Errorf("unable to parse the CA certificates of %s", e)
+	}
+
+	return &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: remotedialer.HandshakeTimeOut,
+		TLSClientConfig: &tls.Config{
+			RootCAs: pool,
+		},
+	}, nil
+}
+
+// fetchCACerts downloads the CA certificates of the endpoint. The connection can't be verified yet; the certificates
+// are verified against the checksum instead.
+func (e Endpoint) fetchCACerts(ctx context.Context) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.URL.JoinPath("/cacerts").String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	insecureClient := &http.Client{
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true,
+			},
+		},
+	}
+	resp, err := insecureClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the CA certificates of %s: %w", e, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to get the CA certificates of %s: %s", e, resp.Status)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// caChecksum returns the checksum of CA certificates the same way Rancher computes the CA checksum of its agents.
+func caChecksum(cacerts []byte) string {
+	ca := string(cacerts)
+	if !strings.HasSuffix(ca, "\n") {
+		ca += "\n"
+	}
+	digest := sha256.Sum256([]byte(ca))
+	return hex.EncodeToString(digest[:])
+}
@@ -0,0 +1,100 @@
+package tunnel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEndpoints(t *testing.T) {
+	tests := []struct {
+		name      string
+		primary   string
+		checksum  string
+		failover  string
+		want      []string
+		checksums []string
+		wantErr   string
+	}{
+		{
+			name:      "primary only",
+			primary:   "https://rancher.example.com",
+			checksum:  "abc",
+			want:      []string{"https://rancher.example.com"},
+			checksums: []string{"abc"},
+		},
+		{
+			name:      "failover endpoints",
+			primary:   "https://rancher.example.com",
+			failover:  "https://rancher-dr.example.com#def, https://10.0.0.1:8443",
+			want:      []string{"https://rancher.example.com", "https://rancher-dr.example.com", "https://10.0.0.1:8443"},
+			checksums: []string{"", "def", ""},
+		},
+		{
+			name:     "http endpoint",
+			primary:  "https://rancher.example.com",
+			failover: "http://rancher-dr.example.com",
+			wantErr:  "must be an https URL",
+		},
+		{
+			name:    "missing primary",
+			wantErr: "must be an https URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoints, err := ParseEndpoints(tt.primary, tt.checksum, tt.failover)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			var urls, checksums []string
+			for _, endpoint := range endpoints {
+				urls = append(urls, endpoint.String())
+				checksums = append(checksums, endpoint.CAChecksum)
+			}
+			assert.Equal(t, tt.want, urls)
+			assert.Equal(t, tt.checksums, checksums)
+		})
+	}
+}
+
+func TestConnectURL(t *testing.T) {
+	endpoints, err := ParseEndpoints("https://rancher.example.com:8443/path", "", "")
+	require.NoError(t, err)
+
+	assert.Equal(t, "wss://rancher.example.com:8443/v3/connect", endpoints[0].ConnectURL(false))
+	assert.Equal(t, "wss://rancher.example.com:8443/v3/connect/register", endpoints[0].ConnectURL(true))
+}
+
+func TestDialer(t *testing.T) {
+	const cacerts = "not a certificate"
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/cacerts" {
+			rw.Write([]byte(cacerts))
+		}
+	}))
+	defer server.Close()
+
+	endpoints, err := ParseEndpoints(server.URL, "", "")
+	require.NoError(t, err)
+	endpoint := endpoints[0]
+
+	dialer, err := endpoint.Dialer(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, dialer, "endpoints without checksum use the default dialer")
+
+	endpoint.CAChecksum = "abc"
+	_, err = endpoint.Dialer(context.Background())
+	assert.ErrorContains(t, err, "does not match the configured checksum abc")
+
+	endpoint.CAChecksum = caChecksum([]byte(cacerts))
+	_, err = endpoint.Dialer(context.Background())
+	assert.ErrorContains(t, err, "unable to parse the CA certificates")
+}
@@ -0,0 +1,221 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/namespace"
+	"github.com/rancher/remotedialer"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// PolicyConfigMapName is the name of the ConfigMap in the cattle-system namespace Rancher pushes the tunnel dial
+	// policy of the cluster to.
+	PolicyConfigMapName = "cattle-tunnel-dial-policy"
+	// PolicyKey is the key of the JSON encoded v3.TunnelDialPolicy in the ConfigMap.
+	PolicyKey = "policy"
+	// NoPolicyKey is set to "true" in the ConfigMap by Rancher once the policy of the cluster is removed. A ConfigMap
+	// with neither key only allows the kube-apiserver.
+	NoPolicyKey = "no-policy"
+
+	// deniedPrefix starts the error returned to Rancher for denied dials. remotedialer only forwards the first 100
+	// bytes of the error, so it must stay short.
+	deniedPrefix = "tunnel dial policy denied"
+)
+
+// IsDenied returns whether the error of a tunneled connection was caused by the tunnel dial policy of the agent.
+func IsDenied(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), deniedPrefix)
+}
+
+// Policy restricts the destinations Rancher can dial through the tunnel. The kube-apiserver is always allowed, and all
+// destinations are allowed as long as the cluster has no policy. Destinations are matched by IP address only: dials to
+// host names, other than the address of the kube-apiserver, are denied without resolving them, as the agent can't
+// tell which address Rancher meant to reach.
+type Policy struct {
+	apiServer string
+
+	lock         sync.RWMutex
+	enabled      bool
+	destinations []destination
+}
+
+type destination struct {
+	network *net.IPNet
+	ports   []int32
+}
+
+// NewPolicy returns a policy allowing all destinations until it is updated. apiServer is the host:port address of the
+// kube-apiserver.
+func NewPolicy(apiServer string) *Policy {
+	return &Policy{
+		apiServer: apiServer,
+	}
+}
+
+// Update replaces the allowed destinations. A nil policy allows all destinations. Invalid destinations are skipped
+// and returned as error, the valid ones are still applied.
+func (p *Policy) Update(policy *v3.TunnelDialPolicy) error {
+	var (
+		destinations []destination
+		errs         []error
+	)
+	if policy != nil {
+		for _, allowed := range policy.AllowedDestinations {
+			_, network, err := net.ParseCIDR(allowed.CIDR)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid cidr %s: %w", allowed.CIDR, err))
+				continue
+			}
+			destinations = append(destinations, destination{
+				network: network,
+				ports:   allowed.Ports,
+			})
+		}
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.enabled = policy != nil
+	p.destinations = destinations
+
+	return errors.Join(errs...)
+}
+
+// Allowed returns whether Rancher can dial the address. Only tcp dials are restricted, other protocols are left to
+// the connect authorizer of the agent.
+func (p *Policy) Allowed(proto, address string) bool {
+	if proto != "tcp" || address == p.apiServer {
+		return true
+	}
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	if !p.enabled {
+		return true
+	}
+
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	port, err := strconv.ParseInt(portStr, 10, 32)
+	if ip == nil || err != nil {
+		return false
+	}
+
+	for _, d := range p.destinations {
+		if d.network.Contains(ip) && (len(d.ports) == 0 || containsPort(d.ports, int32(port))) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsPort(ports []int32, port int32) bool {
+	for _, p := range ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
+
+// Dialer returns the dialer of the tunnel sessions, which refuses to dial destinations that aren't allowed. The error
+// is sent back to Rancher, which reports the denied dial.
+func (p *Policy) Dialer() remotedialer.Dialer {
+	dialer := &net.Dialer{}
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		if !p.Allowed(network, address) {
+			logrus.Warnf("Denied dial to %s %s by the tunnel dial policy of the cluster", network, address)
+			return nil, fmt.Errorf("%s %s %s", deniedPrefix, network, address)
+		}
+		return dialer.DialContext(ctx, network, address)
+	}
+}
+
+// Watch keeps the policy in sync with the policy ConfigMap of the cluster until the context is done. It returns once
+// the current policy is applied.
+func (p *Policy) Watch(ctx context.Context, k8s kubernetes.Interface) error {
+	listWatch := cache.NewFilteredListWatchFromClient(k8s.CoreV1().RESTClient(), "configmaps", namespace.System, func(options *metav1.ListOptions) {
+		options.FieldSelector = fields.OneTermEqualSelector("metadata.name", PolicyConfigMapName).String()
+	})
+	informer := cache.NewSharedIndexInformer(listWatch, &corev1.ConfigMap{}, 0, cache.Indexers{})
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			p.onConfigMap(obj.(*corev1.ConfigMap))
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			p.onConfigMap(obj.(*corev1.ConfigMap))
+		},
+		DeleteFunc: func(interface{}) {
+			p.onConfigMapDeleted()
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	go informer.Run(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return fmt.Errorf("failed to sync the tunnel dial policy")
+	}
+	return nil
+}
+
+// onConfigMap applies the policy of the ConfigMap. Rancher replaces the policy key with the no policy marker, rather
+// than deleting the ConfigMap, once the policy of the cluster is removed. A ConfigMap without either only allows the
+// kube-apiserver, so that removing the policy key in the cluster doesn't lift the policy.
+func (p *Policy) onConfigMap(cm *corev1.ConfigMap) {
+	var policy *v3.TunnelDialPolicy
+	if data, ok := cm.Data[PolicyKey]; ok {
+		policy = &v3.TunnelDialPolicy{}
+		if err := json.Unmarshal([]byte(data), policy); err != nil {
+			// deny everything but the kube-apiserver rather than allowing everything
+			logrus.Errorf("Failed to parse the tunnel dial policy, only the kube-apiserver can be dialed: %v", err)
+			policy = &v3.TunnelDialPolicy{}
+		}
+	} else if cm.Data[NoPolicyKey] != "true" {
+		logrus.Errorf("Tunnel dial policy ConfigMap %s/%s has no policy, only the kube-apiserver can be dialed", namespace.System, PolicyConfigMapName)
+		policy = &v3.TunnelDialPolicy{}
+	}
+
+	if err := p.Update(policy); err != nil {
+		logrus.Errorf("Failed to apply the tunnel dial policy: %v", err)
+	}
+	if policy == nil {
+		logrus.Info("Tunnel dial policy removed, all destinations can be dialed")
+	} else {
+		logrus.Infof("Tunnel dial policy updated, %d destinations can be dialed in addition to the kube-apiserver", len(policy.AllowedDestinations))
+	}
+}
+
+// onConfigMapDeleted only allows the kube-apiserver if the ConfigMap of a configured policy is deleted, as Rancher
+// never deletes it. Otherwise, deleting the ConfigMap in the cluster would lift the policy.
+func (p *Policy) onConfigMapDeleted() {
+	p.lock.RLock()
+	enabled := p.enabled
+	p.lock.RUnlock()
+	if !enabled {
+		return
+	}
+
+	logrus.Errorf("Tunnel dial policy ConfigMap %s/%s was deleted, only the kube-apiserver can be dialed", namespace.System, PolicyConfigMapName)
+	if err := p.Update(&v3.TunnelDialPolicy{}); err != nil {
+		logrus.Errorf("Failed to apply the tunnel dial policy: %v", err)
+	}
+}
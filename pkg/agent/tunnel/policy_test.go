@@ -0,0 +1,85 @@
+package tunnel
+
+import (
+	"context"
+	"testing"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestPolicyAllowed(t *testing.T) {
+	policy := NewPolicy("10.43.0.1:443")
+
+	assert.True(t, policy.Allowed("tcp", "192.168.0.10:22"), "all destinations are allowed without policy")
+
+	err := policy.Update(&v3.TunnelDialPolicy{
+		AllowedDestinations: []v3.TunnelDialDestination{
+			{CIDR: "10.42.0.0/16", Ports: []int32{9090, 9091}},
+			{CIDR: "192.168.1.0/24"},
+			{CIDR: "192.168.2.0"},
+		},
+	})
+	assert.ErrorContains(t, err, "invalid cidr 192.168.2.0")
+
+	tests := []struct {
+		proto   string
+		address string
+		want    bool
+	}{
+		{"tcp", "10.43.0.1:443", true},
+		{"tcp", "10.42.3.4:9090", true},
+		{"tcp", "10.42.3.4:22", false},
+		{"tcp", "192.168.1.20:22", true},
+		{"tcp", "192.168.2.20:22", false},
+		{"tcp", "rancher.example.com:443", false},
+		{"tcp", "10.42.3.4", false},
+		{"unix", "/var/run/docker.sock", true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, policy.Allowed(tt.proto, tt.address), "%s %s", tt.proto, tt.address)
+	}
+
+	require.NoError(t, policy.Update(nil))
+	assert.True(t, policy.Allowed("tcp", "10.42.3.4:22"))
+}
+
+func TestPolicyDialer(t *testing.T) {
+	policy := NewPolicy("10.43.0.1:443")
+	require.NoError(t, policy.Update(&v3.TunnelDialPolicy{}))
+
+	_, err := policy.Dialer()(context.Background(), "tcp", "10.42.3.4:22")
+	assert.EqualError(t, err, "tunnel dial policy denied tcp 10.42.3.4:22")
+	assert.True(t, IsDenied(err))
+}
+
+func TestPolicyConfigMap(t *testing.T) {
+	policy := NewPolicy("10.43.0.1:443")
+
+	// deleting the ConfigMap without a policy configured keeps allowing all destinations
+	policy.onConfigMapDeleted()
+	assert.True(t, policy.Allowed("tcp", "10.42.3.4:22"))
+
+	policy.onConfigMap(&corev1.ConfigMap{Data: map[string]string{PolicyKey: `{"allowedDestinations":[{"cidr":"10.42.0.0/16","ports":[9090]}]}`}})
+	assert.True(t, policy.Allowed("tcp", "10.42.3.4:9090"))
+	assert.False(t, policy.Allowed("tcp", "10.42.3.4:22"))
+
+	// Rancher replaces the policy key with the no policy marker once the policy is removed
+	policy.onConfigMap(&corev1.ConfigMap{Data: map[string]string{NoPolicyKey: "true"}})
+	assert.True(t, policy.Allowed("tcp", "10.42.3.4:22"))
+
+	policy.onConfigMap(&corev1.ConfigMap{})
+	assert.False(t, policy.Allowed("tcp", "10.42.3.4:22"), "ConfigMaps without policy nor marker only allow the kube-apiserver")
+	assert.True(t, policy.Allowed("tcp", "10.43.0.1:443"))
+
+	policy.onConfigMap(&corev1.ConfigMap{Data: map[string]string{PolicyKey: "{"}})
+	assert.False(t, policy.Allowed("tcp", "10.42.3.4:9090"), "invalid policies only allow the kube-apiserver")
+	assert.True(t, policy.Allowed("tcp", "10.43.0.1:443"))
+
+	policy.onConfigMap(&corev1.ConfigMap{Data: map[string]string{PolicyKey: `{"allowedDestinations":[{"cidr":"10.42.0.0/16"}]}`}})
+	policy.onConfigMapDeleted()
+	assert.False(t, policy.Allowed("tcp", "10.42.3.4:22"), "deleting the ConfigMap of a policy only allows the kube-apiserver")
+	assert.True(t, policy.Allowed("tcp", "10.43.0.1:443"))
+}
@@ -6,12 +6,14 @@
 	"github.com/rancher/norman/httperror"
 	"github.com/rancher/norman/types"
 	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/providers/common"
 	"github.com/rancher/rancher/pkg/auth/requests"
 	"github.com/rancher/rancher/pkg/auth/tokens"
 	mgmtclient "github.com/rancher/rancher/pkg/client/generated/management/v3"
 	"github.com/rancher/rancher/pkg/clustermanager"
 	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
@@ -66,6 +68,10 @@ func (a ActionHandler) ensureToken(apiContext *types.APIContext) (string, error)
 
 // createTokenInput will create the input for a new kubeconfig token with the default TTL.
 func (a ActionHandler) createTokenInput(apiContext *types.APIContext) (user.TokenInput, error) {
+	if userInfo, ok := request.UserFrom(apiContext.Request.Context()); ok && common.IsScopedTokenRequest(userInfo) {
+		return user.TokenInput{}, httperror.NewAPIError(httperror.PermissionDenied, "kubeconfigs can't be generated with a scoped token")
+	}
+
 	userName := a.UserMgr.GetUser(apiContext)
 	tokenNamePrefix := fmt.Sprintf("kubeconfig-%s", userName)
 
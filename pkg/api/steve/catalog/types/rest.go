@@ -9,6 +9,7 @@
   - ChartInstall: Represents a Helm chart installation request.
   - ChartInstallAction: Describes the configuration for an installation action.
   - ChartInfo: Contains detailed information about a Helm chart.
+  - ChartVerification: Describes the result of the verification of the signature of a Helm chart.
   - ChartUninstallAction: Describes the configuration for an uninstallation action.
   - ChartUpgradeAction: Describes the configuration for an upgrade action.
   - ChartUpgrade: Represents a Helm chart upgrade request.
@@ -53,6 +54,18 @@ type ChartInfo struct {
 	Values    v3.MapStringInterface `json:"values,omitempty"`
 	Questions v3.MapStringInterface `json:"questions,omitempty"`
 	Chart     v3.MapStringInterface `json:"chart,omitempty"`
+	// Verification is the result of the verification of the signature of the chart, if its repository verifies the
+	// signatures of its charts.
+	Verification *ChartVerification `json:"verification,omitempty"`
+}
+
+// ChartVerification represents the result of the verification of the signature of a chart
+type ChartVerification struct {
+	// Status is either verified, unsigned, invalid or failed, or empty if the chart hasn't been verified yet.
+	Status   string `json:"status,omitempty"`
+	Signer   string `json:"signer,omitempty"`
+	Message  string `json:"message,omitempty"`
+	Required bool   `json:"required,omitempty"`
 }
 
 // ChartUninstallAction represents the input received when uninstalling a chart
@@ -12,6 +12,7 @@
 	"github.com/rancher/rancher/pkg/auth/requests"
 	"github.com/rancher/rancher/pkg/clusterrouter"
 	normanv3 "github.com/rancher/rancher/pkg/schemas/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/sessionrecording"
 	"github.com/rancher/rancher/pkg/settings"
 	"github.com/rancher/rancher/pkg/types/config"
 	"github.com/rancher/rancher/pkg/wrangler"
@@ -33,6 +34,7 @@ func Register(ctx context.Context, server *steve.Server, wrangler *wrangler.Cont
 		namespace:       "cattle-system",
 		impersonator:    podimpersonation.New("shell", server.ClientFactory, time.Hour, settings.FullShellImage),
 		clusterRegistry: server.ClusterRegistry,
+		recorder:        sessionrecording.NewRecorder(wrangler.Core.Secret()),
 	}
 	sc, err := config.NewScaledContext(*wrangler.RESTConfig, nil)
 	if err != nil {
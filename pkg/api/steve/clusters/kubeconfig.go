@@ -6,7 +6,9 @@
 	"net/url"
 	"strings"
 
+	"github.com/rancher/apiserver/pkg/apierror"
 	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/rancher/pkg/auth/providers/common"
 	"github.com/rancher/rancher/pkg/auth/requests"
 	"github.com/rancher/rancher/pkg/auth/tokens"
 	"github.com/rancher/rancher/pkg/features"
@@ -41,6 +43,10 @@ func (k kubeconfigDownload) ServeHTTP(rw http.ResponseWriter, req *http.Request)
 	}
 	var tokenKey string
 	var err error
+	if common.IsScopedTokenRequest(userName) {
+		apiRequest.WriteError(apierror.NewAPIError(validation.PermissionDenied, "kubeconfigs can't be generated with a scoped token"))
+		return
+	}
 	generateToken := strings.EqualFold(settings.KubeconfigGenerateToken.Get(), "true")
 	if generateToken {
 		tokenKey, err = k.ensureToken(userName.GetName(), req)
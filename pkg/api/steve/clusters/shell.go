@@ -7,10 +7,13 @@
 	"strings"
 	"time"
 
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/rancher/pkg/sessionrecording"
 	"github.com/rancher/rancher/pkg/settings"
 	"github.com/rancher/steve/pkg/podimpersonation"
 	"github.com/rancher/steve/pkg/stores/proxy"
 	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apiserver/pkg/authentication/user"
@@ -25,6 +28,7 @@ type shell struct {
 	impersonator    *podimpersonation.PodImpersonation
 	cg              proxy.ClientGetter
 	clusterRegistry string
+	recorder        *sessionrecording.Recorder
 }
 
 func (s *shell) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
@@ -53,10 +57,41 @@ func (s *shell) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		defer cancel()
 		_ = client.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{})
 	}()
-	s.proxyRequest(rw, req, pod, client)
+
+	recording, err := s.startRecording(req, user)
+	if err != nil {
+		// the error could reveal the configuration of the session recording backend
+		logrus.Errorf("Failed to start recording the shell of %s: %v", user.GetName(), err)
+		http.Error(rw, "failed to start recording the shell", http.StatusInternalServerError)
+		return
+	}
+	defer func() {
+		if err := recording.Close(); err != nil {
+			logrus.Errorf("Failed to save the recording of the shell of %s: %v", user.GetName(), err)
+		}
+	}()
+	if recording != nil {
+		rw.Header().Set(sessionrecording.Header, recording.ID)
+	}
+
+	s.proxyRequest(rw, req, pod, client, recording)
+}
+
+// startRecording starts recording the shell if session recording is enabled. The shell is refused if it can't be
+// recorded.
+func (s *shell) startRecording(req *http.Request, user user.Info) (*sessionrecording.Recording, error) {
+	var cluster string
+	if apiRequest := types.GetAPIContext(req.Context()); apiRequest != nil {
+		cluster = apiRequest.Name
+	}
+	return s.recorder.Start(req.Context(), sessionrecording.Metadata{
+		Kind:    sessionrecording.KindShell,
+		User:    user.GetName(),
+		Cluster: cluster,
+	}, 80, 24)
 }
 
-func (s *shell) proxyRequest(rw http.ResponseWriter, req *http.Request, pod *v1.Pod, client kubernetes.Interface) {
+func (s *shell) proxyRequest(rw http.ResponseWriter, req *http.Request, pod *v1.Pod, client kubernetes.Interface, recording *sessionrecording.Recording) {
 	attachURL := client.CoreV1().RESTClient().
 		Get().
 		Namespace(pod.Namespace).
@@ -87,7 +122,7 @@ func (s *shell) proxyRequest(rw http.ResponseWriter, req *http.Request, pod *v1.
 			delete(req.Header, "Authorization")
 			delete(req.Header, "Cookie")
 		},
-		Transport:     httpClient.Transport,
+		Transport:     sessionrecording.Transport(httpClient.Transport, recording),
 		FlushInterval: time.Millisecond * 100,
 	}
 
@@ -7,6 +7,7 @@
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -18,14 +19,19 @@
 	"github.com/rancher/apiserver/pkg/types"
 	"github.com/rancher/rancher/pkg/capr"
 	capicontrollers "github.com/rancher/rancher/pkg/generated/controllers/cluster.x-k8s.io/v1beta1"
+	"github.com/rancher/rancher/pkg/sessionrecording"
 	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
 	"golang.org/x/crypto/ssh"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/endpoints/request"
 )
 
 type sshClient struct {
 	secrets  corecontrollers.SecretClient
 	machines capicontrollers.MachineClient
+	recorder *sessionrecording.Recorder
 }
 
 var upgrader = websocket.Upgrader{
@@ -65,17 +71,37 @@ func (s *sshClient) shell(apiRequest *types.APIRequest) error {
 	ctx, cancel := context.WithCancel(apiRequest.Context())
 	defer cancel()
 
-	req := apiRequest.Request.WithContext(ctx)
-	conn, err := upgrader.Upgrade(apiRequest.Response, req, nil)
+	machineInfo, err := s.getSSHKey(apiRequest.Namespace, apiRequest.Name)
 	if err != nil {
 		return err
 	}
 
-	defer conn.Close()
-	machineInfo, err := s.getSSHKey(apiRequest.Namespace, apiRequest.Name)
+	recording, err := s.startRecording(apiRequest, machineInfo)
+	if errors.Is(err, validation.Unauthorized) {
+		return err
+	} else if err != nil {
+		// the error could reveal the configuration of the session recording backend
+		logrus.Errorf("Failed to start recording the SSH session to machine %s/%s: %v", apiRequest.Namespace, apiRequest.Name, err)
+		return errors.New("failed to start recording the SSH session")
+	}
+	defer func() {
+		if err := recording.Close(); err != nil {
+			logrus.Errorf("Failed to save the recording of the SSH session to machine %s/%s: %v", apiRequest.Namespace, apiRequest.Name, err)
+		}
+	}()
+	responseHeader := http.Header{}
+	if recording != nil {
+		responseHeader.Set(sessionrecording.Header, recording.ID)
+		// the upgrader writes its own headers to the hijacked connection, which the audit log doesn't see
+		apiRequest.Response.Header().Set(sessionrecording.Header, recording.ID)
+	}
+
+	req := apiRequest.Request.WithContext(ctx)
+	conn, err := upgrader.Upgrade(apiRequest.Response, req, responseHeader)
 	if err != nil {
 		return err
 	}
+	defer conn.Close()
 
 	signer, err := ssh.ParsePrivateKey(machineInfo.IDRSA)
 	if err != nil {
@@ -121,7 +147,7 @@ func (s *sshClient) shell(apiRequest *types.APIRequest) error {
 	go func() {
 		defer cancel()
 		defer conn.Close()
-		io.Copy(&writer{conn: conn}, stdOut)
+		io.Copy(&writer{conn: conn, recording: recording}, stdOut)
 	}()
 
 	for {
@@ -138,6 +164,7 @@ func (s *sshClient) shell(apiRequest *types.APIRequest) error {
 			if err != nil {
 				return err
 			}
+			recording.Input(data)
 			if _, err := stdIn.Write(data); err != nil {
 				return err
 			}
@@ -150,6 +177,7 @@ func (s *sshClient) shell(apiRequest *types.APIRequest) error {
 			if err := json.Unmarshal(data, resize); err != nil {
 				return err
 			}
+			recording.Resize(resize.Width, resize.Height)
 			if err := session.WindowChange(resize.Height, resize.Width); err != nil {
 				return err
 			}
@@ -157,6 +185,21 @@ func (s *sshClient) shell(apiRequest *types.APIRequest) error {
 	}
 }
 
+// startRecording starts recording the SSH session if session recording is enabled. The session is refused if it can't
+// be recorded.
+func (s *sshClient) startRecording(apiRequest *types.APIRequest, machineInfo *machineInfo) (*sessionrecording.Recording, error) {
+	user, ok := request.UserFrom(apiRequest.Context())
+	if !ok {
+		return nil, validation.Unauthorized
+	}
+	return s.recorder.Start(apiRequest.Context(), sessionrecording.Metadata{
+		Kind:    sessionrecording.KindSSH,
+		User:    user.GetName(),
+		Cluster: machineInfo.ClusterName,
+		Machine: apiRequest.Namespace + "/" + apiRequest.Name,
+	}, 80, 20)
+}
+
 type resizeRequest struct {
 	Height int
 	Width  int
@@ -166,6 +209,8 @@ type machineInfo struct {
 	IDRSA    []byte
 	IDRSAPub []byte
 	Driver   machineConfig
+	// ClusterName is the name of the cluster of the machine, it isn't part of the machine config.
+	ClusterName string `json:"-"`
 }
 
 type machineConfig struct {
@@ -176,11 +221,13 @@ type machineConfig struct {
 }
 
 func (s *sshClient) getSSHKey(machineNamespace, machineName string) (*machineInfo, error) {
-	result := &machineInfo{}
 	machine, err := s.machines.Get(machineNamespace, machineName, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
+	result := &machineInfo{
+		ClusterName: machine.Spec.ClusterName,
+	}
 
 	secretName := capr.MachineStateSecretName(machine.Spec.InfrastructureRef.Name)
 	secret, err := s.secrets.Get(machineNamespace, secretName, metav1.GetOptions{})
@@ -224,10 +271,12 @@ func (s *sshClient) getSSHKey(machineNamespace, machineName string) (*machineInf
 }
 
 type writer struct {
-	conn *websocket.Conn
+	conn      *websocket.Conn
+	recording *sessionrecording.Recording
 }
 
 func (w *writer) Write(buf []byte) (int, error) {
+	w.recording.Output(buf)
 	data := []byte("1" + base64.StdEncoding.EncodeToString(buf))
 	m, err := w.conn.NextWriter(websocket.TextMessage)
 	if err != nil {
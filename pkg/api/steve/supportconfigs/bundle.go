@@ -0,0 +1,534 @@
+package supportconfigs
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	lassometrics "github.com/rancher/lasso/pkg/metrics"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/audit"
+	"github.com/rancher/rancher/pkg/auth/util"
+	"github.com/rancher/rancher/pkg/clustermanager"
+	"github.com/rancher/rancher/pkg/features"
+	capicontrollers "github.com/rancher/rancher/pkg/generated/controllers/cluster.x-k8s.io/v1beta1"
+	mgmtcontrollers "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
+	rkecontrollers "github.com/rancher/rancher/pkg/generated/controllers/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/peermanager"
+	"github.com/rancher/rancher/pkg/types/config"
+	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+	authzv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	authv1 "k8s.io/client-go/kubernetes/typed/authorization/v1"
+)
+
+const (
+	// BundleEndpoint The endpoint that the support bundle is accessible at - used for routing
+	BundleEndpoint = "/v1/generateSupportBundle"
+	// bundleResource is the management.cattle.io resource users need to get to generate support bundles, which is
+	// granted by the support-bundles-generate global role.
+	bundleResource  = "supportbundles"
+	gzipContentType = "application/gzip"
+
+	provisioningLogConfigMap = "provisioning-log"
+	provisioningLogKey       = "log"
+
+	agentNamespace       = "cattle-system"
+	agentLabelSelector   = "app=cattle-cluster-agent"
+	defaultAgentLogLines = 500
+	maxAgentLogLines     = 5000
+	// agentLogTimeout is the deadline to pull the cluster agent logs of a single cluster, and agentLogsTimeout the
+	// overall deadline to pull the cluster agent logs of all clusters.
+	agentLogTimeout  = 30 * time.Second
+	agentLogsTimeout = 2 * time.Minute
+	// agentLogConcurrency is the number of clusters to pull cluster agent logs from at the same time.
+	agentLogConcurrency = 10
+)
+
+// BundleHandler implements http.Handler - and serves support bundles (gzipped tar file which contains diagnostics of
+// rancher and the clusters it manages). Anything sensitive is redacted with the rules of the audit log.
+type BundleHandler struct {
+	Settings             mgmtcontrollers.SettingClient
+	Features             mgmtcontrollers.FeatureClient
+	Clusters             mgmtcontrollers.ClusterClient
+	Machines             capicontrollers.MachineClient
+	ControlPlanes        rkecontrollers.RKEControlPlaneClient
+	ConfigMaps           corecontrollers.ConfigMapClient
+	SubjectAccessReviews authv1.SubjectAccessReviewInterface
+	// PeerManager is nil when rancher runs in single server mode.
+	PeerManager peermanager.PeerManager
+	// ClusterClient returns a client of a downstream cluster, connected through the tunnel of its cluster agent.
+	ClusterClient func(clusterName string) (kubernetes.Interface, error)
+
+	controllerExecutions prometheus.Collector
+	now                  func() time.Time
+}
+
+// NewBundleHandler creates a support bundle handler using the clients defined in scaledContext
+func NewBundleHandler(scaledContext *config.ScaledContext, clusterManager *clustermanager.Manager) *BundleHandler {
+	return &BundleHandler{
+		Settings:             scaledContext.Wrangler.Mgmt.Setting(),
+		Features:             scaledContext.Wrangler.Mgmt.Feature(),
+		Clusters:             scaledContext.Wrangler.Mgmt.Cluster(),
+		Machines:             scaledContext.Wrangler.CAPI.Machine(),
+		ControlPlanes:        scaledContext.Wrangler.RKE.RKEControlPlane(),
+		ConfigMaps:           scaledContext.Wrangler.Core.ConfigMap(),
+		SubjectAccessReviews: scaledContext.K8sClient.AuthorizationV1().SubjectAccessReviews(),
+		PeerManager:          scaledContext.PeerManager,
+		ClusterClient: func(clusterName string) (kubernetes.Interface, error) {
+			// don't wait for disconnected clusters to reconnect, their logs are skipped instead
+			userContext, err := clusterManager.UserContextNoControllersReconnecting(clusterName, false)
+			if err != nil {
+				return nil, err
+			}
+			return userContext.K8sClient, nil
+		},
+		controllerExecutions: lassometrics.TotalControllerExecutions,
+		now:                  time.Now,
+	}
+}
+
+// bundleOptions are the options of a support bundle, set with query parameters.
+type bundleOptions struct {
+	// agentLogs is set to pull the logs of the cluster agents of downstream clusters through the tunnel.
+	agentLogs bool
+	// agentLogLines is the number of lines of the cluster agent logs to include, from the end of the logs.
+	agentLogLines int64
+	// clusters are the clusters to pull cluster agent logs from, all clusters if empty.
+	clusters map[string]bool
+}
+
+func bundleOptionsFromRequest(r *http.Request) (bundleOptions, error) {
+	query := r.URL.Query()
+	opts := bundleOptions{
+		agentLogs:     query.Get("agentLogs") == "true",
+		agentLogLines: defaultAgentLogLines,
+		clusters:      map[string]bool{},
+	}
+	if lines := query.Get("agentLogLines"); lines != "" {
+		n, err := strconv.ParseInt(lines, 10, 64)
+		if err != nil || n <= 0 || n > maxAgentLogLines {
+			return opts, fmt.Errorf("agentLogLines must be a number between 1 and %d", maxAgentLogLines)
+		}
+		opts.agentLogLines = n
+	}
+	for _, cluster := range query["cluster"] {
+		opts.clusters[cluster] = true
+	}
+	return opts, nil
+}
+
+// ServeHTTP implements http.Handler - attempts to authorize the user. Returns a gzipped tar of diagnostics if the user
+// can get support bundles.
+func (h *BundleHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	authorized, err := authorizeRequest(h.SubjectAccessReviews, request, &authzv1.ResourceAttributes{
+		Group:    "management.cattle.io",
+		Resource: bundleResource,
+		Verb:     "get",
+	})
+	if err != nil {
+		logrus.Errorf("[%s] Failed to authorize user with error: %s", logPrefix, err.Error())
+	}
+	if !authorized {
+		util.ReturnHTTPError(writer, request, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	opts, err := bundleOptionsFromRequest(request)
+	if err != nil {
+		util.ReturnHTTPError(writer, request, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	now := h.now().UTC()
+	writer.Header().Set("Content-Type", gzipContentType)
+	writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"supportbundle_rancher_%s.tar.gz\"", now.Format("20060102T150405Z")))
+
+	// the bundle is streamed as it is collected, so a failure to write it can only be logged once the response started
+	logrus.Infof("[%s] Generating support bundle", logPrefix)
+	if err := h.generateSupportBundle(request.Context(), writer, opts, now); err != nil {
+		logrus.Warnf("[%s] Failed to write support bundle to the http response writer: %v", logPrefix, err)
+		return
+	}
+	logrus.Infof("[%s] Done Generating support bundle", logPrefix)
+}
+
+// bundle writes the files of a support bundle to a gzipped tar as they are collected. Failing to collect some
+// diagnostics doesn't fail the bundle, the errors are written to errors.txt instead. It is safe for concurrent use.
+type bundle struct {
+	mu      sync.Mutex
+	gz      *gzip.Writer
+	tw      *tar.Writer
+	modTime time.Time
+	// err is the first error writing the bundle, nothing is written after it.
+	err    error
+	errors []string
+}
+
+func newBundle(w io.Writer, modTime time.Time) *bundle {
+	gz := gzip.NewWriter(w)
+	return &bundle{
+		gz:      gz,
+		tw:      tar.NewWriter(gz),
+		modTime: modTime,
+	}
+}
+
+func (b *bundle) addFile(name string, data []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.writeFile(name, data)
+}
+
+// writeFile writes a file to the tar, the caller must hold the lock.
+func (b *bundle) writeFile(name string, data []byte) {
+	if b.err != nil {
+		return
+	}
+	hdr := &tar.Header{
+		Name:    path.Join("supportbundle", name),
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: b.modTime,
+	}
+	if err := b.tw.WriteHeader(hdr); err != nil {
+		b.err = err
+		return
+	}
+	if _, err := b.tw.Write(data); err != nil {
+		b.err = err
+	}
+}
+
+// addLog adds a log to the bundle, after redacting the sensitive values within it.
+func (b *bundle) addLog(name string, log []byte) {
+	b.addFile(name, []byte(audit.RedactText(string(log))))
+}
+
+// addJSON adds obj to the bundle as a json file, after redacting it as if it was served at uri.
+func (b *bundle) addJSON(name, uri string, obj any) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		b.addError("failed to marshal %s: %v", name, err)
+		return
+	}
+	redactable := map[string]any{}
+	if err := json.Unmarshal(data, &redactable); err != nil {
+		b.addError("failed to unmarshal %s: %v", name, err)
+		return
+	}
+	if err := audit.RedactObject(uri, redactable); err != nil {
+		b.addError("failed to redact %s: %v", name, err)
+		return
+	}
+	data, err = json.MarshalIndent(redactable, "", "  ")
+	if err != nil {
+		b.addError("failed to marshal %s: %v", name, err)
+		return
+	}
+	b.addFile(name, data)
+}
+
+func (b *bundle) addError(format string, args ...any) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.errors = append(b.errors, fmt.Sprintf(format, args...))
+}
+
+// close writes errors.txt and closes the tar, it returns the first error writing the bundle.
+func (b *bundle) close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.errors) > 0 {
+		b.writeFile("errors.txt", []byte(strings.Join(b.errors, "\n")+"\n"))
+	}
+	if b.err != nil {
+		return b.err
+	}
+	if err := b.tw.Close(); err != nil {
+		return err
+	}
+	return b.gz.Close()
+}
+
+// generateSupportBundle writes the support bundle to w as a gzipped tar, streaming the files as they are collected.
+func (h *BundleHandler) generateSupportBundle(ctx context.Context, w io.Writer, opts bundleOptions, now time.Time) error {
+	b := newBundle(w, now)
+
+	h.collectSettings(b)
+	h.collectFeatures(b)
+	h.collectPeers(b)
+	h.collectControllerErrors(b)
+	clusters := h.collectClusters(b)
+	h.collectMachines(b)
+	h.collectControlPlanes(b)
+	if opts.agentLogs {
+		h.collectAllAgentLogs(ctx, b, clusters, opts)
+	}
+
+	return b.close()
+}
+
+func (h *BundleHandler) collectSettings(b *bundle) {
+	settingList, err := h.Settings.List(metav1.ListOptions{})
+	if err != nil {
+		b.addError("failed to list settings: %v", err)
+		return
+	}
+	// settings are keyed by name so that the values of sensitive settings are redacted
+	settings := map[string]any{}
+	for _, setting := range settingList.Items {
+		settings[setting.Name] = map[string]any{
+			"value":   setting.Value,
+			"default": setting.Default,
+			"source":  setting.Source,
+		}
+	}
+	b.addJSON("rancher/settings.json", "/v3/settings", settings)
+}
+
+func (h *BundleHandler) collectFeatures(b *bundle) {
+	featureList, err := h.Features.List(metav1.ListOptions{})
+	if err != nil {
+		b.addError("failed to list features: %v", err)
+		return
+	}
+	featureStates := map[string]any{}
+	for i := range featureList.Items {
+		feature := &featureList.Items[i]
+		featureStates[feature.Name] = map[string]any{
+			"enabled":     features.IsEnabled(feature),
+			"value":       feature.Spec.Value,
+			"default":     feature.Status.Default,
+			"lockedValue": feature.Status.LockedValue,
+			"dynamic":     feature.Status.Dynamic,
+		}
+	}
+	b.addJSON("rancher/features.json", "/v3/features", featureStates)
+}
+
+func (h *BundleHandler) collectPeers(b *bundle) {
+	state := map[string]any{
+		"clustered": h.PeerManager != nil,
+	}
+	if h.PeerManager != nil {
+		peers := h.PeerManager.Peers()
+		state["selfID"] = peers.SelfID
+		state["peers"] = peers.IDs
+		state["ready"] = peers.Ready
+		state["leader"] = peers.Leader
+	}
+	b.addJSON("rancher/peers.json", "/v3/peers", state)
+}
+
+// controllerErrors is the number of times a controller handler failed since rancher started.
+type controllerErrors struct {
+	Context    string `json:"context,omitempty"`
+	Controller string `json:"controller"`
+	Handler    string `json:"handler"`
+	Errors     int64  `json:"errors"`
+}
+
+// collectControllerErrors adds the handler error counts of the controllers, which are only counted when prometheus
+// metrics are enabled.
+func (h *BundleHandler) collectControllerErrors(b *bundle) {
+	metrics := make(chan prometheus.Metric)
+	go func() {
+		h.controllerExecutions.Collect(metrics)
+		close(metrics)
+	}()
+
+	errorCounts := []controllerErrors{}
+	for metric := range metrics {
+		m := &dto.Metric{}
+		if err := metric.Write(m); err != nil {
+			b.addError("failed to read controller metric: %v", err)
+			continue
+		}
+		labels := map[string]string{}
+		for _, label := range m.GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+		if labels["has_error"] != "true" || m.GetCounter().GetValue() == 0 {
+			continue
+		}
+		errorCounts = append(errorCounts, controllerErrors{
+			Context:    labels["ctx"],
+			Controller: labels["controller_name"],
+			Handler:    labels["handler_name"],
+			Errors:     int64(m.GetCounter().GetValue()),
+		})
+	}
+	sort.Slice(errorCounts, func(i, j int) bool {
+		if errorCounts[i].Errors != errorCounts[j].Errors {
+			return errorCounts[i].Errors > errorCounts[j].Errors
+		}
+		if errorCounts[i].Controller != errorCounts[j].Controller {
+			return errorCounts[i].Controller < errorCounts[j].Controller
+		}
+		return errorCounts[i].Handler < errorCounts[j].Handler
+	})
+	b.addJSON("rancher/controller-errors.json", "/v3/controllererrors", map[string]any{"data": errorCounts})
+}
+
+// collectClusters adds the status of every management cluster and its provisioning log, and returns the clusters.
+func (h *BundleHandler) collectClusters(b *bundle) []v3.Cluster {
+	clusterList, err := h.Clusters.List(metav1.ListOptions{})
+	if err != nil {
+		b.addError("failed to list clusters: %v", err)
+		return nil
+	}
+	for i := range clusterList.Items {
+		cluster := &clusterList.Items[i]
+		name := path.Join("clusters", cluster.Name, "cluster.json")
+		// the applied and failed specs are copies of the spec, which isn't needed to diagnose the cluster
+		summary, err := summarize(cluster, "appliedSpec", "failedSpec")
+		if err != nil {
+			b.addError("failed to summarize cluster %s: %v", cluster.Name, err)
+		} else {
+			b.addJSON(name, "/v3/clusters/"+cluster.Name, summary)
+		}
+
+		cm, err := h.ConfigMaps.Get(cluster.Name, provisioningLogConfigMap, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			b.addError("failed to get the provisioning log of cluster %s: %v", cluster.Name, err)
+			continue
+		}
+		if log := cm.Data[provisioningLogKey]; log != "" {
+			b.addLog(path.Join("clusters", cluster.Name, "provisioning.log"), []byte(log))
+		}
+	}
+	return clusterList.Items
+}
+
+func (h *BundleHandler) collectMachines(b *bundle) {
+	machineList, err := h.Machines.List(metav1.NamespaceAll, metav1.ListOptions{})
+	if err != nil {
+		b.addError("failed to list machines: %v", err)
+		return
+	}
+	machines := []map[string]any{}
+	for i := range machineList.Items {
+		machine := &machineList.Items[i]
+		summary, err := summarize(machine)
+		if err != nil {
+			b.addError("failed to summarize machine %s/%s: %v", machine.Namespace, machine.Name, err)
+			continue
+		}
+		summary["clusterName"] = machine.Spec.ClusterName
+		machines = append(machines, summary)
+	}
+	b.addJSON("provisioning/machines.json", "/v1/cluster.x-k8s.io.machines", map[string]any{"data": machines})
+}
+
+func (h *BundleHandler) collectControlPlanes(b *bundle) {
+	controlPlaneList, err := h.ControlPlanes.List(metav1.NamespaceAll, metav1.ListOptions{})
+	if err != nil {
+		b.addError("failed to list RKE control planes: %v", err)
+		return
+	}
+	controlPlanes := []map[string]any{}
+	for i := range controlPlaneList.Items {
+		controlPlane := &controlPlaneList.Items[i]
+		summary, err := summarize(controlPlane)
+		if err != nil {
+			b.addError("failed to summarize RKE control plane %s/%s: %v", controlPlane.Namespace, controlPlane.Name, err)
+			continue
+		}
+		controlPlanes = append(controlPlanes, summary)
+	}
+	b.addJSON("provisioning/rkecontrolplanes.json", "/v1/rke.cattle.io.rkecontrolplanes", map[string]any{"data": controlPlanes})
+}
+
+// collectAllAgentLogs adds the cluster agent logs of the clusters selected by opts, pulled from several clusters at the
+// same time. Clusters whose logs couldn't be pulled before the overall deadline are skipped.
+func (h *BundleHandler) collectAllAgentLogs(ctx context.Context, b *bundle, clusters []v3.Cluster, opts bundleOptions) {
+	ctx, cancel := context.WithTimeout(ctx, agentLogsTimeout)
+	defer cancel()
+
+	var g errgroup.Group
+	g.SetLimit(agentLogConcurrency)
+	for _, cluster := range clusters {
+		if len(opts.clusters) > 0 && !opts.clusters[cluster.Name] {
+			continue
+		}
+		g.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				b.addError("skipped the cluster agent logs of cluster %s: %v", cluster.Name, err)
+				return nil
+			}
+			h.collectAgentLogs(ctx, b, cluster, opts.agentLogLines)
+			return nil
+		})
+	}
+	_ = g.Wait()
+}
+
+// collectAgentLogs adds the last lines of the logs of the cluster agent pods of cluster, pulled through the tunnel.
+func (h *BundleHandler) collectAgentLogs(ctx context.Context, b *bundle, cluster v3.Cluster, lines int64) {
+	if !v3.ClusterConditionReady.IsTrue(&cluster) {
+		b.addError("skipped the cluster agent logs of cluster %s: cluster is not ready", cluster.Name)
+		return
+	}
+	client, err := h.ClusterClient(cluster.Name)
+	if err != nil {
+		b.addError("failed to connect to cluster %s: %v", cluster.Name, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, agentLogTimeout)
+	defer cancel()
+
+	pods, err := client.CoreV1().Pods(agentNamespace).List(ctx, metav1.ListOptions{LabelSelector: agentLabelSelector})
+	if err != nil {
+		b.addError("failed to list the cluster agent pods of cluster %s: %v", cluster.Name, err)
+		return
+	}
+	for _, pod := range pods.Items {
+		log, err := client.CoreV1().Pods(agentNamespace).GetLogs(pod.Name, &corev1.PodLogOptions{TailLines: &lines}).DoRaw(ctx)
+		if err != nil {
+			b.addError("failed to get the logs of cluster agent pod %s of cluster %s: %v", pod.Name, cluster.Name, err)
+			continue
+		}
+		b.addLog(path.Join("clusters", cluster.Name, "cluster-agent", pod.Name+".log"), log)
+	}
+}
+
+// summarize returns the metadata and status of obj, which is enough to diagnose it without including its spec. The
+// given status fields are dropped.
+func summarize(obj runtime.Object, dropStatusFields ...string) (map[string]any, error) {
+	data, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	metadata, _ := data["metadata"].(map[string]any)
+	delete(metadata, "managedFields")
+	status, _ := data["status"].(map[string]any)
+	for _, field := range dropStatusFields {
+		delete(status, field)
+	}
+	return map[string]any{
+		"metadata": metadata,
+		"status":   status,
+	}, nil
+}
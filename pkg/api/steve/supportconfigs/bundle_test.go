@@ -0,0 +1,312 @@
+package supportconfigs
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/peermanager"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	authv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+type fakePeerManager struct {
+	peermanager.PeerManager
+	peers peermanager.Peers
+}
+
+func (f *fakePeerManager) Peers() peermanager.Peers {
+	return f.peers
+}
+
+func newBundleHandler(t *testing.T, authorized bool) *BundleHandler {
+	ctrl := gomock.NewController(t)
+
+	sarClient := k8sfake.NewSimpleClientset()
+	sarClient.PrependReactor("create", "subjectaccessreviews",
+		func(action k8stesting.Action) (bool, runtime.Object, error) {
+			ret := action.(k8stesting.CreateAction).GetObject().(*authv1.SubjectAccessReview)
+			ret.Status.Allowed = authorized && ret.Spec.ResourceAttributes.Resource == bundleResource
+			return true, ret, nil
+		},
+	)
+
+	settings := fake.NewMockNonNamespacedClientInterface[*v3.Setting, *v3.SettingList](ctrl)
+	settings.EXPECT().List(gomock.Any()).Return(&v3.SettingList{Items: []v3.Setting{
+		{ObjectMeta: metav1.ObjectMeta{Name: "server-url"}, Value: "https://rancher.example.com", Source: "db"},
+		{ObjectMeta: metav1.ObjectMeta{Name: "auth-token-max-ttl-minutes"}, Value: "0", Default: "0", Source: "default"},
+	}}, nil).AnyTimes()
+
+	enabled := true
+	features := fake.NewMockNonNamespacedClientInterface[*v3.Feature, *v3.FeatureList](ctrl)
+	features.EXPECT().List(gomock.Any()).Return(&v3.FeatureList{Items: []v3.Feature{
+		{ObjectMeta: metav1.ObjectMeta{Name: "fleet"}, Spec: v3.FeatureSpec{Value: &enabled}},
+	}}, nil).AnyTimes()
+
+	clusters := fake.NewMockNonNamespacedClientInterface[*v3.Cluster, *v3.ClusterList](ctrl)
+	ready := v3.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "c-ready"},
+		Status: v3.ClusterStatus{
+			Conditions:  []v3.ClusterCondition{{Type: "Ready", Status: v1.ConditionTrue}},
+			AppliedSpec: v3.ClusterSpec{DisplayName: "applied"},
+		},
+	}
+	notReady := v3.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "c-notready"},
+		Status: v3.ClusterStatus{
+			Conditions: []v3.ClusterCondition{{Type: "Ready", Status: v1.ConditionFalse}},
+		},
+	}
+	clusters.EXPECT().List(gomock.Any()).Return(&v3.ClusterList{Items: []v3.Cluster{ready, notReady}}, nil).AnyTimes()
+
+	configMaps := fake.NewMockClientInterface[*v1.ConfigMap, *v1.ConfigMapList](ctrl)
+	configMaps.EXPECT().Get("c-ready", provisioningLogConfigMap, gomock.Any()).Return(&v1.ConfigMap{
+		Data: map[string]string{provisioningLogKey: "[INFO ] registering with --token abcdef\n[INFO ] provisioning done\n"},
+	}, nil).AnyTimes()
+	configMaps.EXPECT().Get("c-notready", provisioningLogConfigMap, gomock.Any()).Return(nil,
+		apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, provisioningLogConfigMap)).AnyTimes()
+
+	machines := fake.NewMockClientInterface[*capi.Machine, *capi.MachineList](ctrl)
+	machines.EXPECT().List(metav1.NamespaceAll, gomock.Any()).Return(&capi.MachineList{Items: []capi.Machine{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "m-1", Namespace: "fleet-default"},
+			Spec:       capi.MachineSpec{ClusterName: "prov"},
+			Status:     capi.MachineStatus{Phase: "Running"},
+		},
+	}}, nil).AnyTimes()
+
+	controlPlanes := fake.NewMockClientInterface[*rkev1.RKEControlPlane, *rkev1.RKEControlPlaneList](ctrl)
+	controlPlanes.EXPECT().List(metav1.NamespaceAll, gomock.Any()).Return(&rkev1.RKEControlPlaneList{}, nil).AnyTimes()
+
+	executions := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "total_handler_execution"},
+		[]string{"ctx", "controller_name", "handler_name", "has_error"})
+	executions.WithLabelValues("", "clusters", "cluster-deploy", "true").Add(3)
+	executions.WithLabelValues("", "clusters", "cluster-deploy", "false").Add(10)
+	executions.WithLabelValues("", "nodes", "node-sync", "false").Add(5)
+
+	agentClient := k8sfake.NewSimpleClientset(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cattle-cluster-agent-abcde",
+			Namespace: agentNamespace,
+			Labels:    map[string]string{"app": "cattle-cluster-agent"},
+		},
+	})
+
+	return &BundleHandler{
+		Settings:             settings,
+		Features:             features,
+		Clusters:             clusters,
+		Machines:             machines,
+		ControlPlanes:        controlPlanes,
+		ConfigMaps:           configMaps,
+		SubjectAccessReviews: sarClient.AuthorizationV1().SubjectAccessReviews(),
+		PeerManager: &fakePeerManager{peers: peermanager.Peers{
+			SelfID: "10.0.0.1",
+			IDs:    []string{"10.0.0.2"},
+			Ready:  true,
+			Leader: true,
+		}},
+		ClusterClient: func(clusterName string) (kubernetes.Interface, error) {
+			require.Equal(t, "c-ready", clusterName)
+			return agentClient, nil
+		},
+		controllerExecutions: executions,
+		now: func() time.Time {
+			return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		},
+	}
+}
+
+// readBundle returns the files of a gzipped tar support bundle, by name relative to the bundle directory.
+func readBundle(t *testing.T, r io.Reader) map[string]string {
+	t.Helper()
+	gz, err := gzip.NewReader(r)
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+
+	files := map[string]string{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		files[hdr.Name] = string(data)
+	}
+	return files
+}
+
+func TestSupportBundle(t *testing.T) {
+	tests := []struct {
+		name             string
+		query            string
+		authorized       bool
+		expectedHTTPCode int
+		expectedFiles    []string
+	}{
+		{
+			name:             "unauthorized",
+			expectedHTTPCode: http.StatusForbidden,
+		},
+		{
+			name:             "invalid agent log lines",
+			query:            "?agentLogs=true&agentLogLines=-1",
+			authorized:       true,
+			expectedHTTPCode: http.StatusBadRequest,
+		},
+		{
+			name:             "without agent logs",
+			authorized:       true,
+			expectedHTTPCode: http.StatusOK,
+			expectedFiles: []string{
+				"supportbundle/clusters/c-notready/cluster.json",
+				"supportbundle/clusters/c-ready/cluster.json",
+				"supportbundle/clusters/c-ready/provisioning.log",
+				"supportbundle/provisioning/machines.json",
+				"supportbundle/provisioning/rkecontrolplanes.json",
+				"supportbundle/rancher/controller-errors.json",
+				"supportbundle/rancher/features.json",
+				"supportbundle/rancher/peers.json",
+				"supportbundle/rancher/settings.json",
+			},
+		},
+		{
+			name:             "with agent logs",
+			query:            "?agentLogs=true&agentLogLines=100",
+			authorized:       true,
+			expectedHTTPCode: http.StatusOK,
+			expectedFiles: []string{
+				"supportbundle/clusters/c-notready/cluster.json",
+				"supportbundle/clusters/c-ready/cluster-agent/cattle-cluster-agent-abcde.log",
+				"supportbundle/clusters/c-ready/cluster.json",
+				"supportbundle/clusters/c-ready/provisioning.log",
+				"supportbundle/errors.txt",
+				"supportbundle/provisioning/machines.json",
+				"supportbundle/provisioning/rkecontrolplanes.json",
+				"supportbundle/rancher/controller-errors.json",
+				"supportbundle/rancher/features.json",
+				"supportbundle/rancher/peers.json",
+				"supportbundle/rancher/settings.json",
+			},
+		},
+		{
+			name:             "with agent logs of a not ready cluster",
+			query:            "?agentLogs=true&cluster=c-notready",
+			authorized:       true,
+			expectedHTTPCode: http.StatusOK,
+			expectedFiles: []string{
+				"supportbundle/clusters/c-notready/cluster.json",
+				"supportbundle/clusters/c-ready/cluster.json",
+				"supportbundle/clusters/c-ready/provisioning.log",
+				"supportbundle/errors.txt",
+				"supportbundle/provisioning/machines.json",
+				"supportbundle/provisioning/rkecontrolplanes.json",
+				"supportbundle/rancher/controller-errors.json",
+				"supportbundle/rancher/features.json",
+				"supportbundle/rancher/peers.json",
+				"supportbundle/rancher/settings.json",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h := newBundleHandler(t, test.authorized)
+			req := httptest.NewRequest(http.MethodGet, BundleEndpoint+test.query, nil)
+			req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{
+				Name:   "foo",
+				Groups: []string{"foogroup"},
+			}))
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+			require.Equal(t, test.expectedHTTPCode, rr.Code)
+			if test.expectedHTTPCode != http.StatusOK {
+				return
+			}
+
+			assert.Equal(t, gzipContentType, rr.Header().Get("Content-Type"))
+			assert.Equal(t, `attachment; filename="supportbundle_rancher_20240501T120000Z.tar.gz"`, rr.Header().Get("Content-Disposition"))
+			files := readBundle(t, rr.Body)
+			var names []string
+			for name := range files {
+				names = append(names, name)
+			}
+			assert.ElementsMatch(t, test.expectedFiles, names)
+		})
+	}
+}
+
+func TestSupportBundleContent(t *testing.T) {
+	h := newBundleHandler(t, true)
+	req := httptest.NewRequest(http.MethodGet, BundleEndpoint+"?agentLogs=true", nil)
+	req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{Name: "foo"}))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	files := readBundle(t, rr.Body)
+
+	var settings map[string]any
+	require.NoError(t, json.Unmarshal([]byte(files["supportbundle/rancher/settings.json"]), &settings))
+	assert.Equal(t, map[string]any{"value": "https://rancher.example.com", "default": "", "source": "db"}, settings["server-url"])
+	assert.Equal(t, "[redacted]", settings["auth-token-max-ttl-minutes"])
+
+	var features map[string]map[string]any
+	require.NoError(t, json.Unmarshal([]byte(files["supportbundle/rancher/features.json"]), &features))
+	assert.Equal(t, true, features["fleet"]["enabled"])
+
+	var peers map[string]any
+	require.NoError(t, json.Unmarshal([]byte(files["supportbundle/rancher/peers.json"]), &peers))
+	assert.Equal(t, map[string]any{
+		"clustered": true,
+		"selfID":    "10.0.0.1",
+		"peers":     []any{"10.0.0.2"},
+		"ready":     true,
+		"leader":    true,
+	}, peers)
+
+	var errorCounts struct {
+		Data []controllerErrors `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(files["supportbundle/rancher/controller-errors.json"]), &errorCounts))
+	assert.Equal(t, []controllerErrors{{Controller: "clusters", Handler: "cluster-deploy", Errors: 3}}, errorCounts.Data)
+
+	var cluster map[string]map[string]any
+	require.NoError(t, json.Unmarshal([]byte(files["supportbundle/clusters/c-ready/cluster.json"]), &cluster))
+	assert.Equal(t, "c-ready", cluster["metadata"]["name"])
+	assert.NotContains(t, cluster["status"], "appliedSpec")
+	assert.Contains(t, cluster["status"], "conditions")
+
+	var machines struct {
+		Data []map[string]any `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(files["supportbundle/provisioning/machines.json"]), &machines))
+	require.Len(t, machines.Data, 1)
+	assert.Equal(t, "prov", machines.Data[0]["clusterName"])
+
+	assert.Equal(t, "[INFO ] registering with --token [redacted]\n[INFO ] provisioning done\n", files["supportbundle/clusters/c-ready/provisioning.log"])
+	assert.Equal(t, "fake logs", files["supportbundle/clusters/c-ready/cluster-agent/cattle-cluster-agent-abcde.log"])
+	assert.Equal(t, "skipped the cluster agent logs of cluster c-notready: cluster is not ready\n", files["supportbundle/errors.txt"])
+}
@@ -15,7 +15,17 @@
 	FilterActionDeny    FilterAction = "deny"
 )
 
-// Filter provides values used to filter out audit logs.
+// Filter provides values used to filter out audit logs. A filter only matches a log when every non-empty field
+// matches, while a field matches when any of its values match. For example, the Filter:
+//
+//	Filter {
+//	    Action: Allow,
+//	    ExcludeGroups: ["system:masters"],
+//	    ResponseCodes: ["403"],
+//	    Resources: ["secrets"],
+//	}
+//
+// would allow logs of denied requests to secrets made by anyone outside the "system:masters" group.
 type Filter struct {
 	// Action defines what happens
 	Action FilterAction `json:"action,omitempty"`
@@ -29,6 +39,42 @@ type Filter struct {
 	//
 	// would allow logs sent to "/foo/some/endpoint" but not "/foo" or "/foobar".
 	RequestURI string `json:"requestURI,omitempty"`
+
+	// Users is a list of regular expressions matched against the name of the user who made the request.
+	Users []string `json:"users,omitempty"`
+
+	// ExcludeUsers is a list of regular expressions matched against the name of the user who made the request. The
+	// filter does not match when any of them match.
+	ExcludeUsers []string `json:"excludeUsers,omitempty"`
+
+	// Groups is a list of regular expressions matched against each group of the user who made the request.
+	Groups []string `json:"groups,omitempty"`
+
+	// ExcludeGroups is a list of regular expressions matched against each group of the user who made the request.
+	// The filter does not match when any of them match any group.
+	ExcludeGroups []string `json:"excludeGroups,omitempty"`
+
+	// Methods is a list of HTTP methods (GET, POST, etc) matched case insensitively against the request method.
+	Methods []string `json:"methods,omitempty"`
+
+	// ResponseCodes is a list of response status codes or status classes matched against the response code, for
+	// example "403" or "5xx".
+	ResponseCodes []string `json:"responseCodes,omitempty"`
+
+	// APIGroups is a list of api groups matched against the group of the requested resource. Use "" for the core
+	// group. Norman (/v3) requests do not have a group.
+	APIGroups []string `json:"apiGroups,omitempty"`
+
+	// Resources is a list of resource names matched against the requested resource. Both the plural resource name
+	// ("secrets") and the singular steve type name ("secret") are accepted.
+	Resources []string `json:"resources,omitempty"`
+
+	// Namespaces is a list of namespaces matched against the namespace of the requested resource.
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// SourceIPs is a list of IP addresses or CIDRs matched against the source IP of the request. The source IP of
+	// requests received from a trusted proxy is taken from their X-Forwarded-For or X-Real-IP headers.
+	SourceIPs []string `json:"sourceIPs,omitempty"`
 }
 
 type Redaction struct {
@@ -163,6 +209,11 @@ type AuditPolicySpec struct {
 	// A request to the "/foo" endpoint will log both the request and response bodies, but a request to "/bar" will
 	// only log the request body.
 	Verbosity LogVerbosity `json:"verbosity,omitempty"`
+
+	// Sinks lists the names of the audit log sinks this policy applies to. When empty, the policy applies to every
+	// sink. This allows, for example, a policy denying noisy logs on a remote syslog sink while keeping them in the
+	// local log file.
+	Sinks []string `json:"sinks,omitempty"`
 }
 
 type AuditPolicyStatus struct {
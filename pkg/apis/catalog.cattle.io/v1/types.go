@@ -110,6 +110,23 @@ type RepoSpec struct {
 	// Defaults to false, which keeps the SameOrigin check enabled. Setting this to true is not recommended
 	// in production environments due to the security implications.
 	DisableSameOriginCheck bool `json:"disableSameOriginCheck,omitempty"`
+
+	// Verification configures the verification of the signatures of the charts of the Helm repository.
+	// Charts of HTTP Helm repositories are verified with their Helm provenance files and charts of OCI Helm
+	// repositories with their cosign or notation signatures. Charts of Git Helm repositories can't be signed.
+	Verification *ChartVerification `json:"verification,omitempty"`
+}
+
+// ChartVerification configures the keys trusted to sign the charts of a Helm repository.
+type ChartVerification struct {
+	// TrustedKeysSecret is the secret holding the keys trusted to sign the charts of the Helm repository.
+	// The "keyring" key holds the PGP public keys verifying Helm provenance files, the "cosign.pub" key the
+	// PEM encoded public keys verifying cosign signatures and the "notation.crt" key the PEM encoded
+	// certificates verifying notation signatures.
+	TrustedKeysSecret *SecretReference `json:"trustedKeysSecret,omitempty"`
+
+	// Required refuses to install or upgrade charts of the Helm repository which aren't signed by a trusted key.
+	Required bool `json:"required,omitempty"`
 }
 
 type RepoCondition string
@@ -3,6 +3,7 @@
 
 import (
 	apiv3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -80,6 +81,35 @@ type TokenSpec struct {
 	// enabled token.
 	// +optional
 	Enabled *bool `json:"enabled,omitempty"`
+	// Scope restricts what the token can be used for. The default (`null`)
+	// indicates an unrestricted token, carrying the full permissions of the
+	// user. The scope is immutable and scoped tokens can't be used to
+	// create tokens or kubeconfigs.
+	// +optional
+	Scope *TokenScope `json:"scope,omitempty"`
+}
+
+// TokenScope restricts where and how a token can be used. The restrictions
+// are applied on top of the permissions of the user owning the token.
+type TokenScope struct {
+	// ClusterIDs is the list of clusters the token can access. The Rancher
+	// management API is only accessible if the list contains the "local"
+	// cluster. An empty list allows all clusters.
+	// +optional
+	// +listType=set
+	ClusterIDs []string `json:"clusterIDs,omitempty"`
+	// Namespaces is the list of namespaces the token can access in the
+	// allowed clusters. Cluster-scoped resources and the Rancher management
+	// API are not accessible, except for API discovery. An empty list allows
+	// all namespaces.
+	// +optional
+	// +listType=set
+	Namespaces []string `json:"namespaces,omitempty"`
+	// ReadOnly restricts the token to requests which do not change anything,
+	// i.e. get, list and watch. Interactive sessions such as exec, attach
+	// and shells are not allowed either.
+	// +optional
+	ReadOnly bool `json:"readOnly,omitempty"`
 }
 
 // TokenPrincipal contains the data about the user principal owning the token.
@@ -334,3 +364,178 @@ type SelfUser struct {
 type SelfUserStatus struct {
 	UserID string `json:"userID,omitempty"`
 }
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// EffectivePermissionsReview is used to query the permissions a user or group is granted across Rancher,
+// or who is allowed to perform an action.
+type EffectivePermissionsReview struct {
+	metav1.TypeMeta `json:",inline"`
+	// Standard object metadata; More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#metadata.
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	// Spec is the subject or action to review.
+	// +optional
+	Spec EffectivePermissionsReviewSpec `json:"spec,omitempty"`
+	// Status is the result of the review.
+	// +optional
+	Status EffectivePermissionsReviewStatus `json:"status,omitempty"`
+}
+
+// EffectivePermissionsReviewSpec contains the subject or action to review.
+// The permissions of the requesting user are reviewed if none of the fields are set.
+type EffectivePermissionsReviewSpec struct {
+	// UserName is the name of the user whose permissions are reviewed,
+	// including the permissions granted to the groups they are a member of.
+	// +optional
+	UserName string `json:"userName,omitempty"`
+	// GroupPrincipalName is the name of the group principal whose permissions are reviewed.
+	// +optional
+	GroupPrincipalName string `json:"groupPrincipalName,omitempty"`
+	// ResourceAttributes describes an action to find the users and groups allowed to perform.
+	// Cannot be combined with UserName or GroupPrincipalName.
+	// +optional
+	ResourceAttributes *PermissionResourceAttributes `json:"resourceAttributes,omitempty"`
+}
+
+// PermissionResourceAttributes describes an action on a resource.
+type PermissionResourceAttributes struct {
+	// ClusterName is the name of the cluster the action is performed in.
+	// Only global permissions are reviewed if it is not set.
+	// +optional
+	ClusterName string `json:"clusterName,omitempty"`
+	// ProjectName is the name of the project the action is performed in, in the format <cluster>:<project>.
+	// It is looked up from Namespace in the local cluster if not set.
+	// +optional
+	ProjectName string `json:"projectName,omitempty"`
+	// Namespace is the namespace the action is performed in.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// Verb is the kubernetes verb of the action, such as get, list or create.
+	Verb string `json:"verb"`
+	// Group is the API group of the resource.
+	// +optional
+	Group string `json:"group,omitempty"`
+	// Resource is the resource the action is performed on.
+	Resource string `json:"resource"`
+	// Subresource is the subresource the action is performed on.
+	// +optional
+	Subresource string `json:"subresource,omitempty"`
+	// Name is the name of the resource the action is performed on.
+	// +optional
+	Name string `json:"name,omitempty"`
+}
+
+// EffectivePermissionsReviewStatus contains the result of an EffectivePermissionsReview.
+type EffectivePermissionsReviewStatus struct {
+	// GroupPrincipalNames are the groups of the reviewed user whose permissions are included.
+	// +optional
+	GroupPrincipalNames []string `json:"groupPrincipalNames,omitempty"`
+	// GlobalRules are the rules granted by global roles.
+	// +optional
+	GlobalRules []EffectiveRule `json:"globalRules,omitempty"`
+	// Clusters are the rules granted in each cluster and its projects.
+	// +optional
+	Clusters []ClusterPermissions `json:"clusters,omitempty"`
+	// Subjects are the users and groups allowed to perform the action given in ResourceAttributes.
+	// +optional
+	Subjects []PermissionSubject `json:"subjects,omitempty"`
+}
+
+// ClusterPermissions are the rules granted in a cluster.
+type ClusterPermissions struct {
+	// ClusterName is the name of the cluster.
+	ClusterName string `json:"clusterName"`
+	// Rules are the rules granted in the whole cluster.
+	// +optional
+	Rules []EffectiveRule `json:"rules,omitempty"`
+	// Projects are the rules granted in the namespaces of each project of the cluster.
+	// +optional
+	Projects []ProjectPermissions `json:"projects,omitempty"`
+}
+
+// ProjectPermissions are the rules granted in the namespaces of a project.
+type ProjectPermissions struct {
+	// ProjectName is the name of the project, in the format <cluster>:<project>.
+	ProjectName string `json:"projectName"`
+	// Rules are the rules granted in the project.
+	// +optional
+	Rules []EffectiveRule `json:"rules,omitempty"`
+}
+
+// EffectiveRule is a rule along with the binding that granted it.
+type EffectiveRule struct {
+	// Rule is the granted rule.
+	Rule rbacv1.PolicyRule `json:"rule"`
+	// Namespace is the namespace of the local cluster the rule is granted in, for global rules only granted in
+	// some namespaces. The rule is granted in all namespaces if it is not set.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// GrantedBy is the binding that granted the rule.
+	GrantedBy PermissionGrant `json:"grantedBy"`
+}
+
+// PermissionGrant identifies the binding and roles granting a permission.
+type PermissionGrant struct {
+	// Kind is the kind of the binding, one of GlobalRoleBinding, ClusterRoleTemplateBinding or ProjectRoleTemplateBinding.
+	Kind string `json:"kind"`
+	// Namespace is the namespace of the binding.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// Name is the name of the binding.
+	Name string `json:"name"`
+	// GroupPrincipalName is set when the binding grants the permission to a group rather than to a user.
+	// +optional
+	GroupPrincipalName string `json:"groupPrincipalName,omitempty"`
+	// GlobalRoleName is the name of the global role granting the permission, for global role bindings.
+	// +optional
+	GlobalRoleName string `json:"globalRoleName,omitempty"`
+	// RoleTemplateNames is the chain of role templates the permission was inherited through,
+	// starting with the role template of the binding and ending with the role template defining the rule.
+	// +optional
+	RoleTemplateNames []string `json:"roleTemplateNames,omitempty"`
+}
+
+// PermissionSubject is a user or group allowed to perform an action.
+type PermissionSubject struct {
+	// Kind is either User or Group.
+	Kind string `json:"kind"`
+	// Name is the name of the user or group principal.
+	Name string `json:"name"`
+	// GrantedBy is the binding that allows the subject to perform the action.
+	GrantedBy PermissionGrant `json:"grantedBy"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AccessRequestDecision is used to approve or deny an AccessRequest as the requesting user.
+type AccessRequestDecision struct {
+	metav1.TypeMeta `json:",inline"`
+	// Standard object metadata; More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#metadata.
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	// Spec is the decision on the access request.
+	Spec AccessRequestDecisionSpec `json:"spec"`
+	// Status is the result of the decision.
+	// +optional
+	Status AccessRequestDecisionStatus `json:"status,omitempty"`
+}
+
+// AccessRequestDecisionSpec contains the decision on an access request.
+type AccessRequestDecisionSpec struct {
+	// AccessRequestName is the name of the access request being decided.
+	AccessRequestName string `json:"accessRequestName"`
+	// Decision is either Approved or Denied.
+	Decision string `json:"decision"`
+}
+
+// AccessRequestDecisionStatus contains the result of an AccessRequestDecision.
+type AccessRequestDecisionStatus struct {
+	// ApproverName is the name of the user the decision was recorded for.
+	// +optional
+	ApproverName string `json:"approverName,omitempty"`
+}
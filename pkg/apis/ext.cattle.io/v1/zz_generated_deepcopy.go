@@ -26,6 +26,271 @@
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessRequestDecision) DeepCopyInto(out *AccessRequestDecision) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	out.Status = in.Status
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessRequestDecision.
+func (in *AccessRequestDecision) DeepCopy() *AccessRequestDecision {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessRequestDecision)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AccessRequestDecision) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessRequestDecisionList) DeepCopyInto(out *AccessRequestDecisionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AccessRequestDecision, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessRequestDecisionList.
+func (in *AccessRequestDecisionList) DeepCopy() *AccessRequestDecisionList {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessRequestDecisionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AccessRequestDecisionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessRequestDecisionSpec) DeepCopyInto(out *AccessRequestDecisionSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessRequestDecisionSpec.
+func (in *AccessRequestDecisionSpec) DeepCopy() *AccessRequestDecisionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessRequestDecisionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessRequestDecisionStatus) DeepCopyInto(out *AccessRequestDecisionStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessRequestDecisionStatus.
+func (in *AccessRequestDecisionStatus) DeepCopy() *AccessRequestDecisionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessRequestDecisionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterPermissions) DeepCopyInto(out *ClusterPermissions) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]EffectiveRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Projects != nil {
+		in, out := &in.Projects, &out.Projects
+		*out = make([]ProjectPermissions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterPermissions.
+func (in *ClusterPermissions) DeepCopy() *ClusterPermissions {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterPermissions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EffectivePermissionsReview) DeepCopyInto(out *EffectivePermissionsReview) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EffectivePermissionsReview.
+func (in *EffectivePermissionsReview) DeepCopy() *EffectivePermissionsReview {
+	if in == nil {
+		return nil
+	}
+	out := new(EffectivePermissionsReview)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EffectivePermissionsReview) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EffectivePermissionsReviewList) DeepCopyInto(out *EffectivePermissionsReviewList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]EffectivePermissionsReview, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EffectivePermissionsReviewList.
+func (in *EffectivePermissionsReviewList) DeepCopy() *EffectivePermissionsReviewList {
+	if in == nil {
+		return nil
+	}
+	out := new(EffectivePermissionsReviewList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EffectivePermissionsReviewList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EffectivePermissionsReviewSpec) DeepCopyInto(out *EffectivePermissionsReviewSpec) {
+	*out = *in
+	if in.ResourceAttributes != nil {
+		in, out := &in.ResourceAttributes, &out.ResourceAttributes
+		*out = new(PermissionResourceAttributes)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EffectivePermissionsReviewSpec.
+func (in *EffectivePermissionsReviewSpec) DeepCopy() *EffectivePermissionsReviewSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EffectivePermissionsReviewSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EffectivePermissionsReviewStatus) DeepCopyInto(out *EffectivePermissionsReviewStatus) {
+	*out = *in
+	if in.GroupPrincipalNames != nil {
+		in, out := &in.GroupPrincipalNames, &out.GroupPrincipalNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.GlobalRules != nil {
+		in, out := &in.GlobalRules, &out.GlobalRules
+		*out = make([]EffectiveRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]ClusterPermissions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Subjects != nil {
+		in, out := &in.Subjects, &out.Subjects
+		*out = make([]PermissionSubject, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EffectivePermissionsReviewStatus.
+func (in *EffectivePermissionsReviewStatus) DeepCopy() *EffectivePermissionsReviewStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EffectivePermissionsReviewStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EffectiveRule) DeepCopyInto(out *EffectiveRule) {
+	*out = *in
+	in.Rule.DeepCopyInto(&out.Rule)
+	in.GrantedBy.DeepCopyInto(&out.GrantedBy)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EffectiveRule.
+func (in *EffectiveRule) DeepCopy() *EffectiveRule {
+	if in == nil {
+		return nil
+	}
+	out := new(EffectiveRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GroupMembershipRefreshRequest) DeepCopyInto(out *GroupMembershipRefreshRequest) {
 	*out = *in
@@ -336,6 +601,83 @@ func (in *PasswordChangeRequestStatus) DeepCopy() *PasswordChangeRequestStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PermissionGrant) DeepCopyInto(out *PermissionGrant) {
+	*out = *in
+	if in.RoleTemplateNames != nil {
+		in, out := &in.RoleTemplateNames, &out.RoleTemplateNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PermissionGrant.
+func (in *PermissionGrant) DeepCopy() *PermissionGrant {
+	if in == nil {
+		return nil
+	}
+	out := new(PermissionGrant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PermissionResourceAttributes) DeepCopyInto(out *PermissionResourceAttributes) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PermissionResourceAttributes.
+func (in *PermissionResourceAttributes) DeepCopy() *PermissionResourceAttributes {
+	if in == nil {
+		return nil
+	}
+	out := new(PermissionResourceAttributes)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PermissionSubject) DeepCopyInto(out *PermissionSubject) {
+	*out = *in
+	in.GrantedBy.DeepCopyInto(&out.GrantedBy)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PermissionSubject.
+func (in *PermissionSubject) DeepCopy() *PermissionSubject {
+	if in == nil {
+		return nil
+	}
+	out := new(PermissionSubject)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProjectPermissions) DeepCopyInto(out *ProjectPermissions) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]EffectiveRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProjectPermissions.
+func (in *ProjectPermissions) DeepCopy() *ProjectPermissions {
+	if in == nil {
+		return nil
+	}
+	out := new(ProjectPermissions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SelfUser) DeepCopyInto(out *SelfUser) {
 	*out = *in
@@ -496,6 +838,32 @@ func (in *TokenPrincipal) DeepCopy() *TokenPrincipal {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TokenScope) DeepCopyInto(out *TokenScope) {
+	*out = *in
+	if in.ClusterIDs != nil {
+		in, out := &in.ClusterIDs, &out.ClusterIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TokenScope.
+func (in *TokenScope) DeepCopy() *TokenScope {
+	if in == nil {
+		return nil
+	}
+	out := new(TokenScope)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TokenSpec) DeepCopyInto(out *TokenSpec) {
 	*out = *in
@@ -505,6 +873,11 @@ func (in *TokenSpec) DeepCopyInto(out *TokenSpec) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.Scope != nil {
+		in, out := &in.Scope, &out.Scope
+		*out = new(TokenScope)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
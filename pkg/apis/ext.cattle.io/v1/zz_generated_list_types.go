@@ -27,6 +27,40 @@
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
+// AccessRequestDecisionList is a list of AccessRequestDecision resources
+type AccessRequestDecisionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []AccessRequestDecision `json:"items"`
+}
+
+func NewAccessRequestDecision(namespace, name string, obj AccessRequestDecision) *AccessRequestDecision {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("AccessRequestDecision").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// EffectivePermissionsReviewList is a list of EffectivePermissionsReview resources
+type EffectivePermissionsReviewList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []EffectivePermissionsReview `json:"items"`
+}
+
+func NewEffectivePermissionsReview(namespace, name string, obj EffectivePermissionsReview) *EffectivePermissionsReview {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("EffectivePermissionsReview").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
 // GroupMembershipRefreshRequestList is a list of GroupMembershipRefreshRequest resources
 type GroupMembershipRefreshRequestList struct {
 	metav1.TypeMeta `json:",inline"`
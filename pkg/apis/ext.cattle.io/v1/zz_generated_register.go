@@ -29,6 +29,8 @@
 )
 
 var (
+	AccessRequestDecisionResourceName         = "accessrequestdecisions"
+	EffectivePermissionsReviewResourceName    = "effectivepermissionsreviews"
 	GroupMembershipRefreshRequestResourceName = "groupmembershiprefreshrequests"
 	KubeconfigResourceName                    = "kubeconfigs"
 	PasswordChangeRequestResourceName         = "passwordchangerequests"
@@ -58,6 +60,10 @@ func Resource(resource string) schema.GroupResource {
 // Adds the list of known types to Scheme.
 func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
+		&AccessRequestDecision{},
+		&AccessRequestDecisionList{},
+		&EffectivePermissionsReview{},
+		&EffectivePermissionsReviewList{},
 		&GroupMembershipRefreshRequest{},
 		&GroupMembershipRefreshRequestList{},
 		&Kubeconfig{},
@@ -0,0 +1,122 @@
+package v3
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AccessRequestDecision is the decision of an approver on an access request.
+// +kubebuilder:validation:Enum=Approved;Denied
+type AccessRequestDecision string
+
+// AccessRequestState is the state of an access request.
+type AccessRequestState string
+
+const (
+	AccessRequestApproved AccessRequestDecision = "Approved"
+	AccessRequestDenied   AccessRequestDecision = "Denied"
+
+	AccessRequestStatePending AccessRequestState = "Pending"
+	AccessRequestStateDenied  AccessRequestState = "Denied"
+	AccessRequestStateActive  AccessRequestState = "Active"
+	AccessRequestStateExpired AccessRequestState = "Expired"
+	AccessRequestStateFailed  AccessRequestState = "Failed"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="User",type="string",JSONPath=".spec.userName"
+// +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".spec.clusterName"
+// +kubebuilder:printcolumn:name="Project",type="string",JSONPath=".spec.projectName"
+// +kubebuilder:printcolumn:name="Role Template",type="string",JSONPath=".spec.roleTemplateName"
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Expires At",type="string",JSONPath=".status.expiresAt"
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:scope=Cluster
+
+// AccessRequest is a request of a user for time-limited access to a cluster or project through a role template.
+// Once an approver grants the request, a ClusterRoleTemplateBinding or ProjectRoleTemplateBinding expiring after the
+// requested duration is created for the user.
+type AccessRequest struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Standard object metadata; More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#metadata.
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec is the access being requested. Immutable.
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="spec is immutable"
+	Spec AccessRequestSpec `json:"spec"`
+
+	// Status is the decision on the request and the most recently observed state of the access it granted.
+	// +optional
+	Status AccessRequestStatus `json:"status,omitempty"`
+}
+
+// AccessRequestSpec is the access being requested.
+type AccessRequestSpec struct {
+	// UserName is the name of the user access is requested for. It must be the user creating the request.
+	// +kubebuilder:validation:Required
+	UserName string `json:"userName"`
+
+	// ClusterName is the name of the cluster access is requested to.
+	// +kubebuilder:validation:Required
+	ClusterName string `json:"clusterName"`
+
+	// ProjectName is the name of the project access is requested to, in the format <cluster>:<project>.
+	// Access is requested to the cluster if it is not set.
+	// +optional
+	ProjectName string `json:"projectName,omitempty"`
+
+	// RoleTemplateName is the name of the role template that defines the requested permissions.
+	// +kubebuilder:validation:Required
+	RoleTemplateName string `json:"roleTemplateName"`
+
+	// Duration is how long access is granted for once the request is approved.
+	// +kubebuilder:validation:Required
+	Duration metav1.Duration `json:"duration"`
+
+	// Reason is the justification for the request, such as an incident or ticket reference.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+}
+
+// AccessRequestStatus is the decision on an access request and the most recently observed state of the access it granted.
+type AccessRequestStatus struct {
+	// Decision is either Approved or Denied. It is set by creating an AccessRequestDecision of the ext.cattle.io API,
+	// which requires the approve verb on the request and, to approve it, the permission to create the binding.
+	// +optional
+	Decision AccessRequestDecision `json:"decision,omitempty"`
+
+	// RequesterName is the name of the user who created the request.
+	// +optional
+	RequesterName string `json:"requesterName,omitempty"`
+
+	// ApproverName is the name of the user who decided the request, as authenticated by the AccessRequestDecision.
+	// +optional
+	ApproverName string `json:"approverName,omitempty"`
+
+	// State is the state of the request, one of Pending, Denied, Active, Expired or Failed.
+	// +optional
+	State AccessRequestState `json:"state,omitempty"`
+
+	// Message describes why the request is in its state.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// BindingNamespace is the namespace of the role template binding granting the access.
+	// +optional
+	BindingNamespace string `json:"bindingNamespace,omitempty"`
+
+	// BindingName is the name of the role template binding granting the access.
+	// +optional
+	BindingName string `json:"bindingName,omitempty"`
+
+	// GrantedAt is the time at which the access was granted.
+	// +optional
+	GrantedAt *metav1.Time `json:"grantedAt,omitempty"`
+
+	// ExpiresAt is the time at which the granted access expires.
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+}
@@ -405,6 +405,12 @@ type ProjectRoleTemplateBinding struct {
 	// +kubebuilder:validation:Required
 	RoleTemplateName string `json:"roleTemplateName" norman:"required,noupdate,type=reference[roleTemplate]"`
 
+	// ExpiresAt is the time after which the binding is deleted, revoking the permissions it grants in the project.
+	// The binding does not expire if it is not set.
+	// +optional
+	// +nullable
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+
 	// ServiceAccount is the name of the service account bound as a subject. Immutable.
 	// Deprecated.
 	// +optional
@@ -459,6 +465,12 @@ type ClusterRoleTemplateBinding struct {
 	// +kubebuilder:validation:Required
 	RoleTemplateName string `json:"roleTemplateName" norman:"required,noupdate,type=reference[roleTemplate]"`
 
+	// ExpiresAt is the time after which the binding is deleted, revoking the permissions it grants in the cluster.
+	// The binding does not expire if it is not set.
+	// +optional
+	// +nullable
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+
 	// Status is the most recently observed status of the ClusterRoleTemplateBinding. BEWARE. This is read from and written to by __two__ controllers.
 	// +optional
 	Status ClusterRoleTemplateBindingStatus `json:"status,omitempty"`
@@ -71,6 +71,9 @@ func init() {
 	ClusterConditionHarvesterCloudProviderConfigMigrated condition.Cond = "HarvesterCloudProviderConfigMigrated"
 	ClusterConditionACISecretsMigrated                   condition.Cond = "ACISecretsMigrated"
 	ClusterConditionRKESecretsMigrated                   condition.Cond = "RKESecretsMigrated"
+	// ClusterConditionAgentTunnel is true when the cluster agent is connected to its primary Rancher endpoint and
+	// false when it failed over to another endpoint
+	ClusterConditionAgentTunnel condition.Cond = "AgentTunnel"
 
 	ClusterDriverImported = "imported"
 	ClusterDriverLocal    = "local"
@@ -164,6 +167,9 @@ type ClusterSpec struct {
 	ClusterTemplateAnswers              Answer                      `json:"answers,omitempty"`
 	ClusterTemplateQuestions            []Question                  `json:"questions,omitempty" norman:"nocreate,noupdate"`
 	FleetWorkspaceName                  string                      `json:"fleetWorkspaceName,omitempty"`
+	// TunnelDialPolicy restricts the destinations Rancher can dial through the tunnel of the cluster agent. All
+	// destinations are allowed if it is not set.
+	TunnelDialPolicy *TunnelDialPolicy `json:"tunnelDialPolicy,omitempty"`
 }
 
 type Answer struct {
@@ -177,6 +183,22 @@ func (a *Answer) ObjClusterName() string {
 	return a.ClusterName
 }
 
+// TunnelDialPolicy is the list of destinations Rancher can dial through the tunnel of the cluster agent. The
+// kube-apiserver of the cluster is always allowed. Destinations are matched by IP address only, dials to host names
+// are denied rather than resolved, as a name can resolve to any address.
+type TunnelDialPolicy struct {
+	// AllowedDestinations are the destinations allowed in addition to the kube-apiserver.
+	AllowedDestinations []TunnelDialDestination `json:"allowedDestinations,omitempty"`
+}
+
+// TunnelDialDestination is a range of addresses and the ports Rancher can dial on them.
+type TunnelDialDestination struct {
+	// CIDR is the range of addresses of the destination, for example 10.43.0.0/16.
+	CIDR string `json:"cidr"`
+	// Ports are the ports allowed on the addresses. All ports are allowed if empty.
+	Ports []int32 `json:"ports,omitempty"`
+}
+
 type ImportedConfig struct {
 	KubeConfig         string `json:"kubeConfig" norman:"type=password"`
 	PrivateRegistryURL string `json:"privateRegistryURL,omitempty"`
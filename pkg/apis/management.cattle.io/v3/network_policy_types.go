@@ -4,12 +4,51 @@
 	"strings"
 
 	"github.com/rancher/norman/types"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type ProjectNetworkPolicySpec struct {
 	ProjectName string `json:"projectName,omitempty" norman:"required,type=reference[project]"`
 	Description string `json:"description"`
+	// Ingress rules allow traffic from their peers to the namespaces of the project, in addition to the traffic from
+	// the project itself and the system project that is always allowed.
+	Ingress []ProjectNetworkPolicyRule `json:"ingress,omitempty"`
+	// Egress rules restrict the traffic from the namespaces of the project to their peers, the project itself, the
+	// system project, the nodes, the kube-apiserver and the node-local DNS cache. Egress traffic is not restricted if
+	// there are no egress rules.
+	Egress []ProjectNetworkPolicyRule `json:"egress,omitempty"`
+}
+
+// ProjectNetworkPolicyRule allows traffic between the namespaces of a project and a set of peers.
+type ProjectNetworkPolicyRule struct {
+	// Peers are the sources or destinations of the traffic. Traffic from or to anywhere is allowed if empty.
+	Peers []ProjectNetworkPolicyPeer `json:"peers,omitempty"`
+	// Ports restrict the traffic to the given ports. Traffic on all ports is allowed if empty.
+	Ports []ProjectNetworkPolicyPort `json:"ports,omitempty"`
+}
+
+// ProjectNetworkPolicyPeer is a project, namespace or CIDR. Exactly one of ProjectName, NamespaceName and CIDR must
+// be set.
+type ProjectNetworkPolicyPeer struct {
+	// ProjectName is a project of the same cluster, in the format <cluster>:<project>.
+	ProjectName string `json:"projectName,omitempty"`
+	// NamespaceName is a namespace of the same cluster.
+	NamespaceName string `json:"namespaceName,omitempty"`
+	// CIDR is an IP block.
+	CIDR string `json:"cidr,omitempty"`
+	// Except are IP blocks of the CIDR that are excluded.
+	Except []string `json:"except,omitempty"`
+}
+
+// ProjectNetworkPolicyPort is a port or a range of ports.
+type ProjectNetworkPolicyPort struct {
+	// Protocol is one of TCP, UDP or SCTP. Defaults to TCP.
+	Protocol string `json:"protocol,omitempty" norman:"type=enum,options=TCP|UDP|SCTP,default=TCP"`
+	// Port is the port, or the first port of the range if EndPort is set.
+	Port int32 `json:"port"`
+	// EndPort is the last port of the range.
+	EndPort int32 `json:"endPort,omitempty"`
 }
 
 func (p *ProjectNetworkPolicySpec) ObjClusterName() string {
@@ -19,7 +58,37 @@ func (p *ProjectNetworkPolicySpec) ObjClusterName() string {
 	return ""
 }
 
+const (
+	// ProjectNetworkPolicyConditionRulesValid is true if the rules of the policy are valid. The rules of an invalid
+	// policy are not programmed in the namespaces of the project.
+	ProjectNetworkPolicyConditionRulesValid = "RulesValid"
+)
+
 type ProjectNetworkPolicyStatus struct {
+	// Conditions are a set of indicators about aspects of the policy.
+	// +optional
+	Conditions []ProjectNetworkPolicyCondition `json:"conditions,omitempty"`
+}
+
+// ProjectNetworkPolicyCondition is the status of an aspect of the policy.
+type ProjectNetworkPolicyCondition struct {
+	// Type of the condition.
+	Type string `json:"type"`
+
+	// Status of the condition, one of True, False, Unknown.
+	Status v1.ConditionStatus `json:"status"`
+
+	// Last time the condition transitioned from one status to another.
+	// +optional
+	LastTransitionTime string `json:"lastTransitionTime,omitempty"`
+
+	// The reason for the condition's last transition.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Human-readable message indicating details about last transition.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // +genclient
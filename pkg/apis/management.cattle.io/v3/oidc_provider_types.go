@@ -58,6 +58,10 @@ type OIDCClientSpec struct {
 	// These URIs must be registered and used during the authentication flow.
 	// +optional
 	RedirectURIs []string `json:"redirectURIs"`
+	// PostLogoutRedirectURIs defines the allowed URIs the user can be
+	// redirected to after logging out through the end_session endpoint.
+	// +optional
+	PostLogoutRedirectURIs []string `json:"postLogoutRedirectURIs,omitempty"`
 	// TokenExpirationSeconds specifies the duration (in seconds) before
 	// an access token and ID token expire.
 	// +kubebuilder:validation:Minimum=1
@@ -66,4 +70,29 @@ type OIDCClientSpec struct {
 	// a refresh token remains valid before expiration.
 	// +kubebuilder:validation:Minimum=1
 	RefreshTokenExpirationSeconds int64 `json:"refreshTokenExpirationSeconds"`
+	// ClientCredentials enables the client_credentials grant for the OIDC client,
+	// issuing access tokens for the bound identity without a human user.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="has(self.userName) != has(self.principalID)",message="Exactly one of userName or principalID must be set."
+	ClientCredentials *OIDCClientCredentials `json:"clientCredentials,omitempty"`
+}
+
+// OIDCClientCredentials binds the identity access tokens are issued for in the
+// client_credentials grant.
+type OIDCClientCredentials struct {
+	// UserName is the name of the Rancher user the access tokens are issued for.
+	// It can only be set by that user or by a user allowed to impersonate them.
+	// +optional
+	UserName string `json:"userName,omitempty"`
+	// PrincipalID is the ID of a dedicated principal the access tokens are
+	// issued for, when the client doesn't act as a Rancher user. The subject
+	// of the access tokens is client:<client name>:<principal ID>, so that it
+	// never matches a Rancher user or the principal of another client.
+	// +optional
+	PrincipalID string `json:"principalID,omitempty"`
+	// AllowedScopes are the scopes the client can request. Access tokens are
+	// issued with all of them when the client doesn't request any scope.
+	// +kubebuilder:validation:items:Enum=openid;profile;offline_access
+	// +optional
+	AllowedScopes []string `json:"allowedScopes,omitempty"`
 }
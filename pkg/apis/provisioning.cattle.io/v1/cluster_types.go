@@ -127,6 +127,12 @@ type RKEConfig struct {
 	// +optional
 	ETCDSnapshotRestore *rkev1.ETCDSnapshotRestore `json:"etcdSnapshotRestore,omitempty"`
 
+	// ETCDSnapshotVerify is the configuration for the etcd snapshot
+	// verification operation.
+	// +nullable
+	// +optional
+	ETCDSnapshotVerify *rkev1.ETCDSnapshotVerify `json:"etcdSnapshotVerify,omitempty"`
+
 	// RotateCertificates is the configuration for the certificate rotation
 	// operation.
 	// +nullable
@@ -290,6 +290,11 @@ func (in *RKEConfig) DeepCopyInto(out *RKEConfig) {
 		*out = new(rkecattleiov1.ETCDSnapshotRestore)
 		**out = **in
 	}
+	if in.ETCDSnapshotVerify != nil {
+		in, out := &in.ETCDSnapshotVerify, &out.ETCDSnapshotVerify
+		*out = new(rkecattleiov1.ETCDSnapshotVerify)
+		**out = **in
+	}
 	if in.RotateCertificates != nil {
 		in, out := &in.RotateCertificates, &out.RotateCertificates
 		*out = new(rkecattleiov1.RotateCertificates)
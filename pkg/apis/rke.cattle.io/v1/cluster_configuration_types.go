@@ -109,6 +109,104 @@ type ClusterUpgradeStrategy struct {
 	// worker nodes, during both upgrades and machine rollouts.
 	// +optional
 	WorkerDrainOptions DrainOptions `json:"workerDrainOptions,omitempty"`
+
+	// MaintenanceWindows are the recurring windows during which plan
+	// changes that require a drain or a restart of the distribution are
+	// delivered to machines. Such changes are held until one of the windows
+	// is open, unless the rke.cattle.io/maintenance-window-override
+	// annotation is set on the cluster.
+	// If no window is specified, changes are delivered immediately.
+	// +optional
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+
+	// Canary configures a canary stage for plan changes. The changes are
+	// first delivered to the canary machines of each tier (etcd, control
+	// plane, worker), and are only delivered to the remaining machines of
+	// the tier once the canary machines are healthy for the soak time.
+	// If no canary is specified, changes are delivered to all machines of
+	// a tier according to its concurrency.
+	// +optional
+	Canary *CanaryRollout `json:"canary,omitempty"`
+
+	// UpgradeRollback configures an etcd snapshot which is taken before
+	// every Kubernetes minor version upgrade, and optionally the automatic
+	// rollback to it if the upgrade fails.
+	// If no rollback is specified, upgrades are not preceded by a snapshot.
+	// +optional
+	UpgradeRollback *UpgradeRollback `json:"upgradeRollback,omitempty"`
+}
+
+// CanaryRollout contains the canary configuration for plan changes.
+type CanaryRollout struct {
+	// Machines is the number of machines per tier which receive plan
+	// changes first. It is ignored if a MachineSelector is specified.
+	// The default value is 1.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Machines int `json:"machines,omitempty"`
+
+	// MachineSelector selects the canary machines by their labels. Tiers
+	// without any selected machine are not held.
+	// +optional
+	MachineSelector *metav1.LabelSelector `json:"machineSelector,omitempty"`
+
+	// SoakTime is how long the canary machines of a tier must stay healthy
+	// after their probes passed, before the remaining machines of the tier
+	// receive the plan changes, e.g. 15m.
+	// +optional
+	SoakTime metav1.Duration `json:"soakTime,omitempty"`
+
+	// UnhealthyThreshold is how long the probes of a canary machine can
+	// stay unhealthy after its plan was delivered before the rollout is
+	// halted. A halted rollout resumes once the canary machines become
+	// healthy or receive a new plan.
+	// The default value is 10m.
+	// +optional
+	UnhealthyThreshold metav1.Duration `json:"unhealthyThreshold,omitempty"`
+}
+
+// MaintenanceWindow is a recurring window of time during which disruptive
+// plan changes can be delivered to machines.
+type MaintenanceWindow struct {
+	// Schedule is a standard cron expression (minute, hour, day of month,
+	// month, day of week) for the start of the window.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:XValidation:rule="self.matches('^(@(annually|yearly|monthly|weekly|daily|midnight|hourly)|@every [0-9a-z.]+|[0-9A-Za-z*/,?-]+( +[0-9A-Za-z*/,?-]+){4})$')",message="Schedule must be a standard cron expression with five fields."
+	Schedule string `json:"schedule"`
+
+	// Duration is how long the window stays open after it starts, e.g. 4h.
+	Duration metav1.Duration `json:"duration"`
+
+	// TimeZone is the IANA time zone the schedule is evaluated in, e.g.
+	// Europe/Berlin. The default value is UTC.
+	// +kubebuilder:validation:XValidation:rule="self == '' || self.matches('^[A-Za-z][A-Za-z0-9_+-]*(/[A-Za-z0-9_+-]+)*$')",message="TimeZone must be an IANA time zone name."
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// UpgradeRollback contains the configuration for the etcd snapshot taken
+// before Kubernetes minor version upgrades.
+type UpgradeRollback struct {
+	// AutoRollback enables the restore of the pre-upgrade etcd snapshot,
+	// along with the Kubernetes version in use before the upgrade, once the
+	// failure threshold is reached during the upgrade.
+	// +optional
+	AutoRollback bool `json:"autoRollback,omitempty"`
+
+	// FailureThreshold is the number of machines whose upgraded plan
+	// failed to apply, or whose probes did not become healthy within the
+	// unhealthy timeout, after which the upgrade is considered failed.
+	// The default value is 1.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	FailureThreshold int `json:"failureThreshold,omitempty"`
+
+	// UnhealthyTimeout is how long the probes of a machine can stay
+	// unhealthy after its upgraded plan was applied before it counts
+	// towards the failure threshold.
+	// The default value is 10m.
+	// +optional
+	UnhealthyTimeout metav1.Duration `json:"unhealthyTimeout,omitempty"`
 }
 
 // DrainOptions contains the drain configuration for a machine pool.
@@ -133,8 +231,10 @@ type DrainOptions struct {
 	// +optional
 	IgnoreDaemonSets *bool `json:"ignoreDaemonSets,omitempty"`
 
-	// IgnoreErrors Ignore errors occurred between drain nodes in group
-	// NOTE: currently unimplemented
+	// IgnoreErrors instructs the drain operation to be considered done
+	// even if pods could not be evicted from the node, so that the plan
+	// change of the node can proceed. The pods which blocked the eviction
+	// are still reported on the machine.
 	// +optional
 	IgnoreErrors bool `json:"ignoreErrors,omitempty"`
 
@@ -187,6 +287,67 @@ type DrainHook struct {
 	// +nullable
 	// +optional
 	Annotation string `json:"annotation,omitempty"`
+
+	// Webhook is an HTTP(S) endpoint that is called before the planner
+	// continues to drain the specific node (pre-drain) or after the node
+	// was updated (post-drain). Once the endpoint approves, the annotation
+	// is populated on the machine-plan secret by Rancher. If no annotation
+	// is specified, one is derived from the endpoint URL.
+	// +nullable
+	// +optional
+	Webhook *DrainHookWebhook `json:"webhook,omitempty"`
+}
+
+// DrainHookFailurePolicy determines how a drain hook webhook is handled if
+// it cannot be called or does not answer in time.
+type DrainHookFailurePolicy string
+
+const (
+	// DrainHookFailurePolicyFail retries the webhook until it answers.
+	DrainHookFailurePolicyFail DrainHookFailurePolicy = "Fail"
+	// DrainHookFailurePolicyIgnore considers the hook approved.
+	DrainHookFailurePolicyIgnore DrainHookFailurePolicy = "Ignore"
+)
+
+type DrainHookWebhook struct {
+	// URL is the HTTP(S) endpoint that receives a POST request with a JSON
+	// payload describing the node, the cluster and the drain phase. The
+	// endpoint answers with a JSON payload whose decision is "Approve",
+	// "Reject" or "Retry", with an optional "retryAfterSeconds" and
+	// "message".
+	// +kubebuilder:validation:Pattern=`^https?://`
+	URL string `json:"url"`
+
+	// CABundle is a PEM encoded CA bundle used to verify the certificate
+	// of the endpoint. If empty, the system trust roots are used.
+	// +nullable
+	// +optional
+	CABundle string `json:"caBundle,omitempty"`
+
+	// AuthSecretName is the name of a secret of type
+	// "rke.cattle.io/drain-hook-auth" in the namespace of the cluster used
+	// to authenticate to the endpoint. The value of its "token" key is sent
+	// as a bearer token, otherwise its "username" and "password" keys are
+	// used for basic authentication.
+	// +nullable
+	// +optional
+	AuthSecretName string `json:"authSecretName,omitempty"`
+
+	// TimeoutSeconds is the timeout of a single call to the endpoint.
+	// Calls are made in the background, so a slow endpoint doesn't hold up
+	// other machines. Defaults to 10 seconds.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=300
+	// +optional
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+
+	// FailurePolicy determines how the hook is handled if the endpoint
+	// cannot be called, does not answer within the timeout or returns an
+	// invalid response. "Fail" retries the call, "Ignore" considers the
+	// hook approved. Defaults to "Fail".
+	// +kubebuilder:validation:Enum=Fail;Ignore
+	// +optional
+	FailurePolicy DrainHookFailurePolicy `json:"failurePolicy,omitempty"`
 }
 
 type RKESystemConfig struct {
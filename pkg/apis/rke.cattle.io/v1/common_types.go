@@ -13,6 +13,12 @@
 	PasswordAuthConfigSecretKey      = "password"
 	AuthAuthConfigSecretKey          = "auth"
 	IdentityTokenAuthConfigSecretKey = "identityToken"
+
+	// DrainHookAuthSecretType is the type of the secrets that drain hook webhooks authenticate with. Secrets of other
+	// types are never sent to a webhook endpoint.
+	DrainHookAuthSecretType = "rke.cattle.io/drain-hook-auth"
+
+	TokenDrainHookAuthSecretKey = "token"
 )
 
 type GenericMap struct {
@@ -71,6 +77,11 @@ type ETCDSnapshotCreate struct {
 	// snapshot.
 	// +optional
 	Generation int `json:"generation,omitempty"`
+
+	// Name is the base name of the snapshots. The default name of the
+	// distribution is used if empty.
+	// +optional
+	Name string `json:"name,omitempty"`
 }
 
 type ETCDSnapshotRestore struct {
@@ -90,6 +101,41 @@ type ETCDSnapshotRestore struct {
 	// +nullable
 	// +optional
 	RestoreRKEConfig string `json:"restoreRKEConfig,omitempty"`
+
+	// ClusterName is the name of the cluster (cluster.provisioning.cattle.io)
+	// the snapshot was taken from. It must be set to restore an S3 snapshot
+	// of another cluster in the same namespace, for example to clone a
+	// cluster or to recover it into a newly provisioned one. Setting it
+	// requires permission to get the snapshot and to update that cluster.
+	// The tokens adopted from that cluster are rotated after the restore.
+	// +nullable
+	// +optional
+	ClusterName string `json:"clusterName,omitempty"`
+
+	// ScrubSecrets deletes the Secrets of workloads outside of system
+	// namespaces once a snapshot of another cluster has been restored.
+	// +optional
+	ScrubSecrets bool `json:"scrubSecrets,omitempty"`
+}
+
+type ETCDSnapshotVerify struct {
+	// Name refers to the name of the associated etcdsnapshot object.
+	// +nullable
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Generation is the current generation for which an etcd snapshot
+	// verification operation has been requested.
+	// Changing the Generation is the only thing required to verify a
+	// snapshot again.
+	// +optional
+	Generation int `json:"generation,omitempty"`
+
+	// TestRestore indicates whether the snapshot is also restored into a
+	// scratch single-member etcd, which is started and queried on the node
+	// performing the verification.
+	// +optional
+	TestRestore bool `json:"testRestore,omitempty"`
 }
 
 type RotateCertificates struct {
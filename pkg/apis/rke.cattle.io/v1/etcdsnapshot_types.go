@@ -141,4 +141,87 @@ type ETCDSnapshotFile struct {
 type ETCDSnapshotStatus struct {
 	// This field is currently unused but retained for backward compatibility or future use.
 	Missing bool `json:"missing"`
+
+	// Policy is the name of the ETCDSnapshotPolicy in the namespace of the snapshot which manages its retention, if any.
+	// +optional
+	Policy string `json:"policy,omitempty"`
+
+	// RetentionTiers is the list of retention tiers of the policy which keep the snapshot, e.g. "hourly" or "daily".
+	// +nullable
+	// +optional
+	RetentionTiers []string `json:"retentionTiers,omitempty"`
+
+	// Expired is true if no retention tier of the policy keeps the snapshot anymore, in which case the snapshot is deleted.
+	// +optional
+	Expired bool `json:"expired,omitempty"`
+
+	// Verification is the result of the last verification of the snapshot.
+	// +optional
+	Verification *ETCDSnapshotVerification `json:"verification,omitempty"`
+}
+
+// ETCDSnapshotVerification is the result of a verification of a snapshot file.
+type ETCDSnapshotVerification struct {
+	// Generation is the generation of the etcd snapshot verify operation which produced this result.
+	// +optional
+	Generation int `json:"generation,omitempty"`
+
+	// Verified is true if the snapshot file could be opened as an etcd database and restored.
+	Verified bool `json:"verified"`
+
+	// Message details why the verification failed, if it did.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// VerifiedAt is the time at which the verification was completed.
+	// +optional
+	VerifiedAt *metav1.Time `json:"verifiedAt,omitempty"`
+
+	// NodeName is the name of the downstream node on which the snapshot was verified.
+	// +optional
+	NodeName string `json:"nodeName,omitempty"`
+
+	// Checksum is the sha256 checksum of the snapshot file. A snapshot whose checksum changes between two
+	// verifications fails verification.
+	// +optional
+	Checksum string `json:"checksum,omitempty"`
+
+	// Hash is the hash of the etcd keyspace stored in the snapshot.
+	// +optional
+	Hash int64 `json:"hash,omitempty"`
+
+	// Revision is the etcd revision at which the snapshot was taken.
+	// +optional
+	Revision int64 `json:"revision,omitempty"`
+
+	// TotalKeys is the number of keys stored in the snapshot, including all their revisions.
+	// +optional
+	TotalKeys int64 `json:"totalKeys,omitempty"`
+
+	// TotalSize is the size of the etcd database stored in the snapshot in bytes.
+	// +optional
+	TotalSize int64 `json:"totalSize,omitempty"`
+
+	// TestRestore is the result of the restore of the snapshot into a scratch etcd, if it was requested.
+	// +optional
+	TestRestore *ETCDSnapshotTestRestore `json:"testRestore,omitempty"`
+}
+
+// ETCDSnapshotTestRestore is the result of the restore of a snapshot into a scratch single-member etcd.
+type ETCDSnapshotTestRestore struct {
+	// ClusterID is the ID of the etcd cluster created from the snapshot.
+	// +optional
+	ClusterID string `json:"clusterID,omitempty"`
+
+	// MemberID is the ID of the etcd member started from the snapshot.
+	// +optional
+	MemberID string `json:"memberID,omitempty"`
+
+	// Revision is the etcd revision reported by the member started from the snapshot.
+	// +optional
+	Revision int64 `json:"revision,omitempty"`
+
+	// Keys is the number of keys served by the member started from the snapshot.
+	// +optional
+	Keys int64 `json:"keys,omitempty"`
 }
@@ -0,0 +1,123 @@
+package v1
+
+import (
+	"github.com/rancher/wrangler/v3/pkg/genericcondition"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ETCDSnapshotPolicyOperation is an operation before which an etcd snapshot policy takes a snapshot.
+type ETCDSnapshotPolicyOperation string
+
+const (
+	ETCDSnapshotPolicyOperationKubernetesUpgrade     ETCDSnapshotPolicyOperation = "KubernetesUpgrade"
+	ETCDSnapshotPolicyOperationCertificateRotation   ETCDSnapshotPolicyOperation = "CertificateRotation"
+	ETCDSnapshotPolicyOperationEncryptionKeyRotation ETCDSnapshotPolicyOperation = "EncryptionKeyRotation"
+
+	// ETCDSnapshotPolicyOperationSchedule is not an operation performed on the cluster, it denotes the snapshots taken
+	// by the planner on the schedule of a policy. It cannot be used in the BeforeOperations of a policy.
+	ETCDSnapshotPolicyOperationSchedule ETCDSnapshotPolicyOperation = "Schedule"
+)
+
+// +genclient
+// +kubebuilder:resource:path=etcdsnapshotpolicies,scope=Namespaced
+// +kubebuilder:subresource:status
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ETCDSnapshotPolicy triggers etcd snapshots of a cluster from Rancher on a schedule and before risky operations, copies
+// them to additional S3 targets, and prunes them according to its retention tiers.
+type ETCDSnapshotPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec defines the desired state of the ETCDSnapshotPolicy.
+	// +optional
+	Spec ETCDSnapshotPolicySpec `json:"spec,omitempty"`
+
+	// Status contains information about the current state of the ETCDSnapshotPolicy.
+	// +optional
+	Status ETCDSnapshotPolicyStatus `json:"status,omitempty"`
+}
+
+// ETCDSnapshotPolicySpec defines the desired state of an ETCDSnapshotPolicy.
+type ETCDSnapshotPolicySpec struct {
+	// ClusterName is the name of the cluster (cluster.provisioning.cattle.io) in the namespace of the policy.
+	// +kubebuilder:validation:MinLength=1
+	ClusterName string `json:"clusterName"`
+
+	// Schedule is a cron expression in UTC on which Rancher takes an etcd snapshot of the cluster, for example
+	// "0 */6 * * *". Snapshots are not scheduled if empty. This is independent of the snapshot schedule of the nodes
+	// configured by the etcd snapshotScheduleCron of the cluster, and of the on-demand snapshots requested by the
+	// etcdSnapshotCreate of the cluster.
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+
+	// Suspend stops scheduling snapshots. Snapshots before operations are still taken and the retention is still applied.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// BeforeOperations is the list of operations before which the planner takes an etcd snapshot of the cluster. The
+	// Kubernetes minor version upgrades of a cluster with an upgradeRollback are already preceded by its pre-upgrade
+	// snapshot, no additional snapshot is taken for them.
+	// +kubebuilder:validation:items:Enum=KubernetesUpgrade;CertificateRotation;EncryptionKeyRotation
+	// +nullable
+	// +optional
+	BeforeOperations []ETCDSnapshotPolicyOperation `json:"beforeOperations,omitempty"`
+
+	// Retention defines how many of the scheduled snapshots are kept. If no tier is set, the snapshots are not pruned.
+	// +optional
+	Retention ETCDSnapshotPolicyRetention `json:"retention,omitempty"`
+
+	// S3Targets is the list of additional S3 targets to which the snapshots taken for the policy, on its schedule or
+	// before its operations, are copied in addition to the S3 target of the etcd configuration of the cluster. Other
+	// snapshots of the cluster are not copied. The snapshot is taken once, and the file it
+	// produced on each etcd node is uploaded to every target. The copies are not recorded as etcd snapshots of the
+	// cluster and are not pruned by the retention, they are expected to be expired by the lifecycle rules of the
+	// buckets.
+	// +nullable
+	// +optional
+	S3Targets []ETCDSnapshotS3 `json:"s3Targets,omitempty"`
+}
+
+// ETCDSnapshotPolicyRetention defines the retention tiers of the scheduled snapshots. For each tier, the newest
+// snapshot of each of the last hours, days or weeks which have a snapshot is kept. The tiers are applied per node and
+// storage location, and the newest snapshot of each node and storage location is always kept.
+type ETCDSnapshotPolicyRetention struct {
+	// Hourly is the number of hours for which the newest snapshot of the hour is kept.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Hourly int `json:"hourly,omitempty"`
+
+	// Daily is the number of days for which the newest snapshot of the day is kept.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Daily int `json:"daily,omitempty"`
+
+	// Weekly is the number of weeks for which the newest snapshot of the week is kept.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Weekly int `json:"weekly,omitempty"`
+}
+
+// ETCDSnapshotPolicyStatus describes the observed state of an ETCDSnapshotPolicy.
+type ETCDSnapshotPolicyStatus struct {
+	// ObservedGeneration is the most recent generation observed for this ETCDSnapshotPolicy.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastScheduleTime is the last time a snapshot was requested on the schedule. The snapshot is taken by the planner
+	// once no other etcd snapshot operation is in progress on the cluster.
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// NextScheduleTime is the next time a snapshot is requested on the schedule.
+	// +optional
+	NextScheduleTime *metav1.Time `json:"nextScheduleTime,omitempty"`
+
+	// RetainedSnapshots is the number of snapshots kept by the retention tiers.
+	// +optional
+	RetainedSnapshots int `json:"retainedSnapshots,omitempty"`
+
+	// Conditions is a representation of the current state of the policy.
+	// +optional
+	Conditions []genericcondition.GenericCondition `json:"conditions,omitempty"`
+}
@@ -31,6 +31,12 @@ type RKEControlPlaneSpec struct {
 	// +optional
 	ETCDSnapshotRestore *ETCDSnapshotRestore `json:"etcdSnapshotRestore,omitempty"`
 
+	// ETCDSnapshotVerify is the configuration for the etcd snapshot
+	// verification operation.
+	// +nullable
+	// +optional
+	ETCDSnapshotVerify *ETCDSnapshotVerify `json:"etcdSnapshotVerify,omitempty"`
+
 	// RotateCertificates is the configuration for the certificate rotation
 	// operation.
 	// +nullable
@@ -133,6 +139,17 @@ type RKEControlPlaneStatus struct {
 	// +optional
 	ETCDSnapshotCreatePhase ETCDSnapshotPhase `json:"etcdSnapshotCreatePhase,omitempty"`
 
+	// ETCDSnapshotVerify is the state for which the last etcd snapshot
+	// verify operation was completed.
+	// +optional
+	ETCDSnapshotVerify *ETCDSnapshotVerify `json:"etcdSnapshotVerify,omitempty"`
+
+	// ETCDSnapshotVerifyPhase is the phase the etcd snapshot verify
+	// operation is currently executing.
+	// +kubebuilder:validation:Enum=Started;Finished;Failed
+	// +optional
+	ETCDSnapshotVerifyPhase ETCDSnapshotPhase `json:"etcdSnapshotVerifyPhase,omitempty"`
+
 	// ConfigGeneration is the current generation of the configuration for a
 	// given cluster.
 	// Changing this value (which is done automatically during an etcd restore) will trigger a reconciliation loop
@@ -149,6 +166,151 @@ type RKEControlPlaneStatus struct {
 	// established for the cluster.
 	// +optional
 	AgentConnected bool `json:"agentConnected,omitempty"`
+
+	// PlanDryRun is the summary of the plan changes computed while the
+	// rke.cattle.io/plan-dry-run annotation is set on the controlplane.
+	// Changes to the plans of machines are not delivered while a dry-run is
+	// requested, only the initial plans of new machines are.
+	// +optional
+	PlanDryRun *PlanDryRun `json:"planDryRun,omitempty"`
+
+	// UpgradeRollback is the state of the last Kubernetes minor version
+	// upgrade which was preceded by an etcd snapshot.
+	// +optional
+	UpgradeRollback *UpgradeRollbackStatus `json:"upgradeRollback,omitempty"`
+
+	// ETCDSnapshotPolicySnapshot is the state of the last etcd snapshot
+	// taken before an operation or on a schedule as requested by an
+	// ETCDSnapshotPolicy of the cluster.
+	// +optional
+	ETCDSnapshotPolicySnapshot *ETCDSnapshotPolicySnapshot `json:"etcdSnapshotPolicySnapshot,omitempty"`
+
+	// ETCDSnapshotPolicySchedules is the schedule time of the last
+	// scheduled etcd snapshot taken for each ETCDSnapshotPolicy of the
+	// cluster, by policy name.
+	// +optional
+	ETCDSnapshotPolicySchedules map[string]string `json:"etcdSnapshotPolicySchedules,omitempty"`
+}
+
+// ETCDSnapshotPolicySnapshot is the state of an etcd snapshot taken before an operation or on a schedule.
+type ETCDSnapshotPolicySnapshot struct {
+	// Operation is the operation before which the snapshot is taken, or
+	// Schedule for a scheduled snapshot.
+	// +kubebuilder:validation:Enum=Schedule;KubernetesUpgrade;CertificateRotation;EncryptionKeyRotation
+	Operation ETCDSnapshotPolicyOperation `json:"operation"`
+
+	// Key identifies the requested operation, e.g. the Kubernetes version of an upgrade or the generation of a rotation,
+	// or the schedule time of a scheduled snapshot.
+	Key string `json:"key"`
+
+	// Policy is the name of the ETCDSnapshotPolicy of a scheduled snapshot.
+	// +optional
+	Policy string `json:"policy,omitempty"`
+
+	// Name is the base name of the snapshots.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Phase is the phase of the snapshot.
+	// +kubebuilder:validation:Enum=Started;RestartCluster;Finished;Failed
+	// +optional
+	Phase ETCDSnapshotPhase `json:"phase,omitempty"`
+
+	// LastFailureTime is the last time the snapshot failed. A failed
+	// snapshot before an operation is retried after a delay.
+	// +optional
+	LastFailureTime *metav1.Time `json:"lastFailureTime,omitempty"`
+}
+
+// UpgradeRollbackStatus is the state of a Kubernetes minor version upgrade
+// which was preceded by an etcd snapshot.
+type UpgradeRollbackStatus struct {
+	// FromKubernetesVersion is the Kubernetes version in use before the
+	// upgrade.
+	FromKubernetesVersion string `json:"fromKubernetesVersion"`
+
+	// ToKubernetesVersion is the Kubernetes version being upgraded to.
+	ToKubernetesVersion string `json:"toKubernetesVersion"`
+
+	// PreUpgradeSpec is the base64 encoded, gzipped spec of the
+	// RKEControlPlane which was applied before the upgrade.
+	// +optional
+	PreUpgradeSpec string `json:"preUpgradeSpec,omitempty"`
+
+	// SnapshotName is the name of the etcdsnapshot object of the
+	// pre-upgrade etcd snapshot.
+	// +optional
+	SnapshotName string `json:"snapshotName,omitempty"`
+
+	// Phase is the phase of the upgrade.
+	// +kubebuilder:validation:Enum=Snapshot;SnapshotRestartCluster;SnapshotFailed;Upgrading;Failed;RollbackRequested;RolledBack;Completed
+	// +optional
+	Phase UpgradeRollbackPhase `json:"phase,omitempty"`
+
+	// UpgradeStartTime is the time the upgraded plans started to be
+	// delivered to the machines.
+	// +optional
+	UpgradeStartTime *metav1.Time `json:"upgradeStartTime,omitempty"`
+
+	// FailedMachines are the names of the machines which counted towards
+	// the failure threshold.
+	// +optional
+	FailedMachines []string `json:"failedMachines,omitempty"`
+
+	// SnapshotRetry is the value of the upgrade snapshot retry annotation
+	// the pre-upgrade etcd snapshot was last retried for.
+	// +optional
+	SnapshotRetry string `json:"snapshotRetry,omitempty"`
+}
+
+// PlanDryRun is the summary of the plans the planner would deliver for the
+// current spec of the RKEControlPlane.
+type PlanDryRun struct {
+	// ObservedGeneration is the generation of the RKEControlPlane the
+	// dry-run was computed for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Machines are the summaries of every machine whose plan would change.
+	// +optional
+	Machines []MachinePlanChange `json:"machines,omitempty"`
+}
+
+// MachinePlanChange summarizes how the plan of a machine would change.
+type MachinePlanChange struct {
+	// MachineName is the name of the CAPI machine.
+	MachineName string `json:"machineName"`
+
+	// Initial denotes that the machine does not have a plan yet and
+	// receives its first plan, which is not held by the dry-run.
+	// +optional
+	Initial bool `json:"initial,omitempty"`
+
+	// Files are the paths of the files which would be added, removed or
+	// changed.
+	// +optional
+	Files []string `json:"files,omitempty"`
+
+	// Args are the names of the distribution configuration arguments which
+	// would be added, removed or changed. Values are omitted as they can
+	// contain secrets.
+	// +optional
+	Args []string `json:"args,omitempty"`
+
+	// InstructionsChanged denotes that the instructions or probes of the
+	// plan would change.
+	// +optional
+	InstructionsChanged bool `json:"instructionsChanged,omitempty"`
+
+	// Drain denotes that the machine would be drained before the new plan
+	// is delivered, if draining is enabled in the upgrade strategy.
+	// +optional
+	Drain bool `json:"drain,omitempty"`
+
+	// Minor denotes that the change is minor and would be delivered
+	// immediately, regardless of the upgrade strategy concurrency.
+	// +optional
+	Minor bool `json:"minor,omitempty"`
 }
 
 // +genclient
@@ -0,0 +1,39 @@
+package v1
+
+// UpgradeRollbackPhase is a representation of the current phase of a Kubernetes minor version upgrade protected by a
+// pre-upgrade etcd snapshot.
+type UpgradeRollbackPhase string
+
+const (
+	// UpgradeRollbackPhaseSnapshot is the first state the RKEControlPlane is assigned when a Kubernetes minor version
+	// upgrade is requested, while the etcd snapshot is taken with the Kubernetes version in use before the upgrade.
+	UpgradeRollbackPhaseSnapshot = UpgradeRollbackPhase("Snapshot")
+
+	// UpgradeRollbackPhaseSnapshotRestartCluster is the state assigned to the RKEControlPlane when the etcd nodes are
+	// restarted after the pre-upgrade etcd snapshot was taken.
+	UpgradeRollbackPhaseSnapshotRestartCluster = UpgradeRollbackPhase("SnapshotRestartCluster")
+
+	// UpgradeRollbackPhaseSnapshotFailed is the state assigned to the RKEControlPlane when the pre-upgrade etcd snapshot
+	// could not be taken. The upgrade is not started in this state, until the snapshot is retried or skipped with the
+	// upgrade snapshot retry or skip annotations.
+	UpgradeRollbackPhaseSnapshotFailed = UpgradeRollbackPhase("SnapshotFailed")
+
+	// UpgradeRollbackPhaseUpgrading is the state assigned to the RKEControlPlane once the pre-upgrade etcd snapshot is
+	// available and the upgraded plans are delivered to the machines.
+	UpgradeRollbackPhaseUpgrading = UpgradeRollbackPhase("Upgrading")
+
+	// UpgradeRollbackPhaseFailed is the state assigned to the RKEControlPlane when the failure threshold was reached
+	// during the upgrade and automatic rollback is not enabled.
+	UpgradeRollbackPhaseFailed = UpgradeRollbackPhase("Failed")
+
+	// UpgradeRollbackPhaseRollbackRequested is the state assigned to the RKEControlPlane when the failure threshold was
+	// reached during the upgrade and the restore of the pre-upgrade etcd snapshot is requested on the cluster.
+	UpgradeRollbackPhaseRollbackRequested = UpgradeRollbackPhase("RollbackRequested")
+
+	// UpgradeRollbackPhaseRolledBack is the state assigned to the RKEControlPlane once the pre-upgrade etcd snapshot
+	// was restored along with the Kubernetes version in use before the upgrade.
+	UpgradeRollbackPhaseRolledBack = UpgradeRollbackPhase("RolledBack")
+
+	// UpgradeRollbackPhaseCompleted is the state assigned to the RKEControlPlane once the upgrade was applied.
+	UpgradeRollbackPhaseCompleted = UpgradeRollbackPhase("Completed")
+)
@@ -29,6 +29,29 @@
 	v1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryRollout) DeepCopyInto(out *CanaryRollout) {
+	*out = *in
+	if in.MachineSelector != nil {
+		in, out := &in.MachineSelector, &out.MachineSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	out.SoakTime = in.SoakTime
+	out.UnhealthyThreshold = in.UnhealthyThreshold
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryRollout.
+func (in *CanaryRollout) DeepCopy() *CanaryRollout {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryRollout)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterConfiguration) DeepCopyInto(out *ClusterConfiguration) {
 	*out = *in
@@ -83,6 +106,21 @@ func (in *ClusterUpgradeStrategy) DeepCopyInto(out *ClusterUpgradeStrategy) {
 	*out = *in
 	in.ControlPlaneDrainOptions.DeepCopyInto(&out.ControlPlaneDrainOptions)
 	in.WorkerDrainOptions.DeepCopyInto(&out.WorkerDrainOptions)
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]MaintenanceWindow, len(*in))
+		copy(*out, *in)
+	}
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(CanaryRollout)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UpgradeRollback != nil {
+		in, out := &in.UpgradeRollback, &out.UpgradeRollback
+		*out = new(UpgradeRollback)
+		**out = **in
+	}
 	return
 }
 
@@ -218,6 +256,11 @@ func (in *DataDirectories) DeepCopy() *DataDirectories {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DrainHook) DeepCopyInto(out *DrainHook) {
 	*out = *in
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(DrainHookWebhook)
+		**out = **in
+	}
 	return
 }
 
@@ -231,6 +274,22 @@ func (in *DrainHook) DeepCopy() *DrainHook {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DrainHookWebhook) DeepCopyInto(out *DrainHookWebhook) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DrainHookWebhook.
+func (in *DrainHookWebhook) DeepCopy() *DrainHookWebhook {
+	if in == nil {
+		return nil
+	}
+	out := new(DrainHookWebhook)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DrainOptions) DeepCopyInto(out *DrainOptions) {
 	*out = *in
@@ -242,12 +301,16 @@ func (in *DrainOptions) DeepCopyInto(out *DrainOptions) {
 	if in.PreDrainHooks != nil {
 		in, out := &in.PreDrainHooks, &out.PreDrainHooks
 		*out = make([]DrainHook, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.PostDrainHooks != nil {
 		in, out := &in.PostDrainHooks, &out.PostDrainHooks
 		*out = make([]DrainHook, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	return
 }
@@ -290,7 +353,7 @@ func (in *ETCDSnapshot) DeepCopyInto(out *ETCDSnapshot) {
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	out.Spec = in.Spec
 	in.SnapshotFile.DeepCopyInto(&out.SnapshotFile)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -386,6 +449,159 @@ func (in *ETCDSnapshotList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ETCDSnapshotPolicy) DeepCopyInto(out *ETCDSnapshotPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ETCDSnapshotPolicy.
+func (in *ETCDSnapshotPolicy) DeepCopy() *ETCDSnapshotPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ETCDSnapshotPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ETCDSnapshotPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ETCDSnapshotPolicyList) DeepCopyInto(out *ETCDSnapshotPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ETCDSnapshotPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ETCDSnapshotPolicyList.
+func (in *ETCDSnapshotPolicyList) DeepCopy() *ETCDSnapshotPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(ETCDSnapshotPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ETCDSnapshotPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ETCDSnapshotPolicyRetention) DeepCopyInto(out *ETCDSnapshotPolicyRetention) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ETCDSnapshotPolicyRetention.
+func (in *ETCDSnapshotPolicyRetention) DeepCopy() *ETCDSnapshotPolicyRetention {
+	if in == nil {
+		return nil
+	}
+	out := new(ETCDSnapshotPolicyRetention)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ETCDSnapshotPolicySnapshot) DeepCopyInto(out *ETCDSnapshotPolicySnapshot) {
+	*out = *in
+	if in.LastFailureTime != nil {
+		in, out := &in.LastFailureTime, &out.LastFailureTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ETCDSnapshotPolicySnapshot.
+func (in *ETCDSnapshotPolicySnapshot) DeepCopy() *ETCDSnapshotPolicySnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(ETCDSnapshotPolicySnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ETCDSnapshotPolicySpec) DeepCopyInto(out *ETCDSnapshotPolicySpec) {
+	*out = *in
+	if in.BeforeOperations != nil {
+		in, out := &in.BeforeOperations, &out.BeforeOperations
+		*out = make([]ETCDSnapshotPolicyOperation, len(*in))
+		copy(*out, *in)
+	}
+	out.Retention = in.Retention
+	if in.S3Targets != nil {
+		in, out := &in.S3Targets, &out.S3Targets
+		*out = make([]ETCDSnapshotS3, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ETCDSnapshotPolicySpec.
+func (in *ETCDSnapshotPolicySpec) DeepCopy() *ETCDSnapshotPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ETCDSnapshotPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ETCDSnapshotPolicyStatus) DeepCopyInto(out *ETCDSnapshotPolicyStatus) {
+	*out = *in
+	if in.LastScheduleTime != nil {
+		in, out := &in.LastScheduleTime, &out.LastScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextScheduleTime != nil {
+		in, out := &in.NextScheduleTime, &out.NextScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]genericcondition.GenericCondition, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ETCDSnapshotPolicyStatus.
+func (in *ETCDSnapshotPolicyStatus) DeepCopy() *ETCDSnapshotPolicyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ETCDSnapshotPolicyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ETCDSnapshotRestore) DeepCopyInto(out *ETCDSnapshotRestore) {
 	*out = *in
@@ -437,6 +653,16 @@ func (in *ETCDSnapshotSpec) DeepCopy() *ETCDSnapshotSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ETCDSnapshotStatus) DeepCopyInto(out *ETCDSnapshotStatus) {
 	*out = *in
+	if in.RetentionTiers != nil {
+		in, out := &in.RetentionTiers, &out.RetentionTiers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = new(ETCDSnapshotVerification)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -450,6 +676,63 @@ func (in *ETCDSnapshotStatus) DeepCopy() *ETCDSnapshotStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ETCDSnapshotTestRestore) DeepCopyInto(out *ETCDSnapshotTestRestore) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ETCDSnapshotTestRestore.
+func (in *ETCDSnapshotTestRestore) DeepCopy() *ETCDSnapshotTestRestore {
+	if in == nil {
+		return nil
+	}
+	out := new(ETCDSnapshotTestRestore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ETCDSnapshotVerification) DeepCopyInto(out *ETCDSnapshotVerification) {
+	*out = *in
+	if in.VerifiedAt != nil {
+		in, out := &in.VerifiedAt, &out.VerifiedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.TestRestore != nil {
+		in, out := &in.TestRestore, &out.TestRestore
+		*out = new(ETCDSnapshotTestRestore)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ETCDSnapshotVerification.
+func (in *ETCDSnapshotVerification) DeepCopy() *ETCDSnapshotVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(ETCDSnapshotVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ETCDSnapshotVerify) DeepCopyInto(out *ETCDSnapshotVerify) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ETCDSnapshotVerify.
+func (in *ETCDSnapshotVerify) DeepCopy() *ETCDSnapshotVerify {
+	if in == nil {
+		return nil
+	}
+	out := new(ETCDSnapshotVerify)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EnvVar) DeepCopyInto(out *EnvVar) {
 	*out = *in
@@ -529,6 +812,49 @@ func (in *LocalClusterAuthEndpoint) DeepCopy() *LocalClusterAuthEndpoint {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachinePlanChange) DeepCopyInto(out *MachinePlanChange) {
+	*out = *in
+	if in.Files != nil {
+		in, out := &in.Files, &out.Files
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachinePlanChange.
+func (in *MachinePlanChange) DeepCopy() *MachinePlanChange {
+	if in == nil {
+		return nil
+	}
+	out := new(MachinePlanChange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+	out.Duration = in.Duration
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Mirror) DeepCopyInto(out *Mirror) {
 	*out = *in
@@ -573,6 +899,29 @@ func (in *Networking) DeepCopy() *Networking {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlanDryRun) DeepCopyInto(out *PlanDryRun) {
+	*out = *in
+	if in.Machines != nil {
+		in, out := &in.Machines, &out.Machines
+		*out = make([]MachinePlanChange, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlanDryRun.
+func (in *PlanDryRun) DeepCopy() *PlanDryRun {
+	if in == nil {
+		return nil
+	}
+	out := new(PlanDryRun)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProvisioningFileSource) DeepCopyInto(out *ProvisioningFileSource) {
 	*out = *in
@@ -980,6 +1329,11 @@ func (in *RKEControlPlaneSpec) DeepCopyInto(out *RKEControlPlaneSpec) {
 		*out = new(ETCDSnapshotRestore)
 		**out = **in
 	}
+	if in.ETCDSnapshotVerify != nil {
+		in, out := &in.ETCDSnapshotVerify, &out.ETCDSnapshotVerify
+		*out = new(ETCDSnapshotVerify)
+		**out = **in
+	}
 	if in.RotateCertificates != nil {
 		in, out := &in.RotateCertificates, &out.RotateCertificates
 		*out = new(RotateCertificates)
@@ -1031,6 +1385,33 @@ func (in *RKEControlPlaneStatus) DeepCopyInto(out *RKEControlPlaneStatus) {
 		*out = new(ETCDSnapshotCreate)
 		**out = **in
 	}
+	if in.ETCDSnapshotVerify != nil {
+		in, out := &in.ETCDSnapshotVerify, &out.ETCDSnapshotVerify
+		*out = new(ETCDSnapshotVerify)
+		**out = **in
+	}
+	if in.PlanDryRun != nil {
+		in, out := &in.PlanDryRun, &out.PlanDryRun
+		*out = new(PlanDryRun)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UpgradeRollback != nil {
+		in, out := &in.UpgradeRollback, &out.UpgradeRollback
+		*out = new(UpgradeRollbackStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ETCDSnapshotPolicySnapshot != nil {
+		in, out := &in.ETCDSnapshotPolicySnapshot, &out.ETCDSnapshotPolicySnapshot
+		*out = new(ETCDSnapshotPolicySnapshot)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ETCDSnapshotPolicySchedules != nil {
+		in, out := &in.ETCDSnapshotPolicySchedules, &out.ETCDSnapshotPolicySchedules
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -1207,3 +1588,45 @@ func (in *RotateEncryptionKeys) DeepCopy() *RotateEncryptionKeys {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeRollback) DeepCopyInto(out *UpgradeRollback) {
+	*out = *in
+	out.UnhealthyTimeout = in.UnhealthyTimeout
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeRollback.
+func (in *UpgradeRollback) DeepCopy() *UpgradeRollback {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeRollback)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeRollbackStatus) DeepCopyInto(out *UpgradeRollbackStatus) {
+	*out = *in
+	if in.UpgradeStartTime != nil {
+		in, out := &in.UpgradeStartTime, &out.UpgradeStartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.FailedMachines != nil {
+		in, out := &in.FailedMachines, &out.FailedMachines
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeRollbackStatus.
+func (in *UpgradeRollbackStatus) DeepCopy() *UpgradeRollbackStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeRollbackStatus)
+	in.DeepCopyInto(out)
+	return out
+}
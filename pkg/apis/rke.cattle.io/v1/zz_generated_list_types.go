@@ -60,6 +60,23 @@ func NewETCDSnapshot(namespace, name string, obj ETCDSnapshot) *ETCDSnapshot {
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
+// ETCDSnapshotPolicyList is a list of ETCDSnapshotPolicy resources
+type ETCDSnapshotPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []ETCDSnapshotPolicy `json:"items"`
+}
+
+func NewETCDSnapshotPolicy(namespace, name string, obj ETCDSnapshotPolicy) *ETCDSnapshotPolicy {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("ETCDSnapshotPolicy").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
 // RKEBootstrapList is a list of RKEBootstrap resources
 type RKEBootstrapList struct {
 	metav1.TypeMeta `json:",inline"`
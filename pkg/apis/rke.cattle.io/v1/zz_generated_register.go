@@ -30,6 +30,7 @@
 var (
 	CustomMachineResourceName        = "custommachines"
 	ETCDSnapshotResourceName         = "etcdsnapshots"
+	ETCDSnapshotPolicyResourceName   = "etcdsnapshotpolicies"
 	RKEBootstrapResourceName         = "rkebootstraps"
 	RKEBootstrapTemplateResourceName = "rkebootstraptemplates"
 	RKEClusterResourceName           = "rkeclusters"
@@ -61,6 +62,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&CustomMachineList{},
 		&ETCDSnapshot{},
 		&ETCDSnapshotList{},
+		&ETCDSnapshotPolicy{},
+		&ETCDSnapshotPolicyList{},
 		&RKEBootstrap{},
 		&RKEBootstrapList{},
 		&RKEBootstrapTemplate{},
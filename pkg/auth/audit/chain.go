@@ -0,0 +1,249 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// ChainKeySecretName is the name of the secret in the system namespace holding the keys used to chain audit logs.
+	// Each data entry of the secret is a key, the entry name being the key ID.
+	ChainKeySecretName = "rancher-audit-log-chain-keys"
+
+	// ChainActiveKeyAnnotation names the data entry of the chain key secret used to chain new logs. It may be omitted
+	// when the secret only holds one key.
+	ChainActiveKeyAnnotation = "auditlog.cattle.io/active-key"
+
+	chainEventStart       = "start"
+	chainEventKeyRotation = "keyRotation"
+)
+
+// chainField is appended to every chained log. It is always the last field of the JSON object so that the verifier
+// can recover the exact bytes which were signed.
+var chainField = []byte(`,"chain":`)
+
+// chainInfo is written to each chained log under the "chain" field.
+type chainInfo struct {
+	// ID identifies the chain so that logs from multiple chains (restarts, replicas) can be told apart.
+	ID string `json:"id"`
+
+	// Seq is the position of the log in the chain, starting at 0 with a start event.
+	Seq uint64 `json:"seq"`
+
+	// Key is the ID of the key used to compute MAC.
+	Key string `json:"key"`
+
+	// MAC is the hex encoded HMAC-SHA256 of the chain ID, the previous MAC, the sequence number and the log.
+	MAC string `json:"mac"`
+}
+
+// chainEvent is a log written by the chain itself rather than by a request.
+type chainEvent struct {
+	ChainEvent  string `json:"chainEvent"`
+	Timestamp   string `json:"timestamp"`
+	PreviousKey string `json:"previousKey,omitempty"`
+	NextKey     string `json:"nextKey,omitempty"`
+}
+
+// chainMAC computes the MAC linking a log to the log before it.
+func chainMAC(key []byte, chainID string, prevMAC string, seq uint64, line []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(chainID))
+	mac.Write([]byte{'\n'})
+	mac.Write([]byte(prevMAC))
+	mac.Write([]byte{'\n'})
+	mac.Write([]byte(strconv.FormatUint(seq, 10)))
+	mac.Write([]byte{'\n'})
+	mac.Write(line)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// appendChainInfo adds the chain field to the end of the given JSON object.
+func appendChainInfo(line []byte, info chainInfo) ([]byte, error) {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal chain info: %w", err)
+	}
+
+	line = bytes.TrimSuffix(line, []byte{'}'})
+
+	out := make([]byte, 0, len(line)+len(chainField)+len(data)+2)
+	out = append(out, line...)
+	if len(line) > 1 {
+		out = append(out, chainField...)
+	} else {
+		// the log was an empty object, so there is no preceding field to separate from
+		out = append(out, chainField[1:]...)
+	}
+	out = append(out, data...)
+	out = append(out, '}', '\n')
+
+	return out, nil
+}
+
+// splitChainInfo reverses appendChainInfo, returning the original log and its chain info.
+func splitChainInfo(line []byte) ([]byte, chainInfo, bool) {
+	var info chainInfo
+
+	i := bytes.LastIndex(line, chainField[1:])
+	if i < 0 || !bytes.HasSuffix(line, []byte{'}'}) {
+		return nil, info, false
+	}
+
+	if err := json.Unmarshal(line[i+len(chainField)-1:len(line)-1], &info); err != nil {
+		return nil, info, false
+	}
+
+	original := make([]byte, 0, i+1)
+	if i > 0 && line[i-1] == ',' {
+		original = append(original, line[:i-1]...)
+	} else {
+		original = append(original, line[:i]...)
+	}
+	original = append(original, '}')
+
+	return original, info, true
+}
+
+// chainSink links every log written to the wrapped Sink to the previous one with a sequence number and HMAC, so that
+// removed, reordered or modified logs can be detected by the verifier.
+type chainSink struct {
+	Sink
+
+	mu      sync.Mutex
+	id      string
+	next    uint64
+	prevMAC string
+	started bool
+
+	keyID string
+	key   []byte
+
+	now func() time.Time
+}
+
+func newChainSink(sink Sink) *chainSink {
+	return &chainSink{
+		Sink: sink,
+		id:   newChainID(),
+		now:  time.Now,
+	}
+}
+
+func newChainID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+func (s *chainSink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.key == nil {
+		return 0, fmt.Errorf("no audit log chain key has been set")
+	}
+
+	if !s.started {
+		if err := s.writeEvent(chainEvent{ChainEvent: chainEventStart}); err != nil {
+			return 0, err
+		}
+		s.started = true
+	}
+
+	if err := s.writeChained(bytes.TrimRight(p, "\n")); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// setKey changes the key used for new logs. If the chain has already started, the change is recorded with a key
+// rotation event signed by the previous key.
+func (s *chainSink) setKey(id string, key []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.keyID == id && bytes.Equal(s.key, key) {
+		return nil
+	}
+
+	if s.started {
+		if err := s.writeEvent(chainEvent{ChainEvent: chainEventKeyRotation, PreviousKey: s.keyID, NextKey: id}); err != nil {
+			return fmt.Errorf("failed to record key rotation: %w", err)
+		}
+	}
+
+	s.keyID = id
+	s.key = bytes.Clone(key)
+
+	return nil
+}
+
+func (s *chainSink) writeEvent(event chainEvent) error {
+	event.Timestamp = s.now().UTC().Format(time.RFC3339)
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal chain event: %w", err)
+	}
+
+	if event.ChainEvent == chainEventStart {
+		s.next = 0
+		s.prevMAC = ""
+	}
+
+	return s.writeChained(data)
+}
+
+// writeChained must be called with the lock held. The chain advances even if the write fails, which leaves a gap for
+// the verifier to report.
+func (s *chainSink) writeChained(line []byte) error {
+	seq := s.next
+	mac := chainMAC(s.key, s.id, s.prevMAC, seq, line)
+
+	out, err := appendChainInfo(line, chainInfo{
+		ID:  s.id,
+		Seq: seq,
+		Key: s.keyID,
+		MAC: mac,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.next = seq + 1
+	s.prevMAC = mac
+
+	if _, err := s.Sink.Write(out); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *chainSink) Start(ctx context.Context) {
+	if starter, ok := s.Sink.(starter); ok {
+		starter.Start(ctx)
+	}
+}
+
+func (s *chainSink) Close() error {
+	if closer, ok := s.Sink.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/docker/docker/pkg/reexec"
+	"github.com/urfave/cli"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// RegisterVerifyChainCommand registers the verify-audit-log command, which checks chained audit log files for gaps,
+// reordering and modification.
+func RegisterVerifyChainCommand() {
+	reexec.Register("/usr/bin/verify-audit-log", verifyChain)
+	reexec.Register("verify-audit-log", verifyChain)
+}
+
+func verifyChain() {
+	app := cli.NewApp()
+	app.Usage = "verify chained audit log files"
+	app.ArgsUsage = "FILE..."
+	app.Description = "Verify the chained audit log files given, in the order they were written. Keys are read from the " +
+		ChainKeySecretName + " secret unless given with --key."
+	app.Flags = []cli.Flag{
+		cli.StringSliceFlag{
+			Name:  "key",
+			Usage: "Key used to verify the logs as ID=PATH, where PATH is a file holding the raw key. May be repeated",
+		},
+		cli.StringFlag{
+			Name:  "namespace",
+			Value: "cattle-system",
+			Usage: "Namespace of the key secret",
+		},
+	}
+
+	app.Action = func(c *cli.Context) error {
+		if c.NArg() == 0 {
+			return fmt.Errorf("at least one audit log file is required")
+		}
+
+		keys, err := verifyChainKeys(c)
+		if err != nil {
+			return err
+		}
+
+		verifier := NewChainVerifier(keys)
+		for _, path := range c.Args() {
+			if err := verifyChainFile(verifier, path); err != nil {
+				return err
+			}
+		}
+
+		for _, issue := range verifier.Issues {
+			fmt.Fprintln(os.Stdout, issue.String())
+		}
+
+		fmt.Fprintf(os.Stdout, "Verified %d logs in %d chains, found %d issues\n", verifier.Records, verifier.Chains(), len(verifier.Issues))
+		if len(verifier.Issues) > 0 {
+			return cli.NewExitError("", 1)
+		}
+
+		return nil
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		if msg := err.Error(); msg != "" {
+			fmt.Fprintln(os.Stderr, msg)
+		}
+		os.Exit(1)
+	}
+}
+
+func verifyChainFile(verifier *ChainVerifier, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open '%s': %w", path, err)
+	}
+	defer f.Close()
+
+	return verifier.Verify(path, f)
+}
+
+func verifyChainKeys(c *cli.Context) (map[string][]byte, error) {
+	keys := map[string][]byte{}
+
+	for _, k := range c.StringSlice("key") {
+		id, path, ok := strings.Cut(k, "=")
+		if !ok || id == "" || path == "" {
+			return nil, fmt.Errorf("invalid key '%s', expected ID=PATH", k)
+		}
+
+		key, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key '%s': %w", id, err)
+		}
+		keys[id] = key
+	}
+
+	if len(keys) > 0 {
+		return keys, nil
+	}
+
+	kubeConfigPath := os.ExpandEnv("$HOME/.kube/config")
+	if _, err := os.Stat(kubeConfigPath); err != nil {
+		kubeConfigPath = ""
+	}
+
+	conf, err := clientcmd.BuildConfigFromFlags("", kubeConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get kubeconfig: %w", err)
+	}
+
+	client, err := kubernetes.NewForConfig(conf)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get kubernetes client: %w", err)
+	}
+
+	secret, err := client.CoreV1().Secrets(c.String("namespace")).Get(context.TODO(), ChainKeySecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get chain key secret: %w", err)
+	}
+
+	keys, _, err = ChainKeysFromSecret(secret)
+	return keys, err
+}
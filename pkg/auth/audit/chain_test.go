@@ -0,0 +1,121 @@
+package audit
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testChainKeys = map[string][]byte{
+	"key-1": []byte("0123456789abcdef0123456789abcdef"),
+	"key-2": []byte("fedcba9876543210fedcba9876543210"),
+}
+
+func writeChainedLogs(t *testing.T, uris ...string) []string {
+	out := &bytes.Buffer{}
+
+	w, err := NewWriter(out, WriterOptions{
+		DisableDefaultPolicies: true,
+		EnableChain:            true,
+	})
+	require.NoError(t, err)
+
+	assert.Error(t, w.Write(&log{RequestURI: "/before-key"}))
+
+	require.NoError(t, w.SetChainKey("key-1", testChainKeys["key-1"]))
+
+	for i, uri := range uris {
+		if i == len(uris)/2 {
+			require.NoError(t, w.SetChainKey("key-2", testChainKeys["key-2"]))
+		}
+
+		require.NoError(t, w.Write(&log{RequestURI: uri}))
+	}
+
+	return strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+}
+
+func verifyLines(t *testing.T, lines []string) []string {
+	verifier := NewChainVerifier(testChainKeys)
+	require.NoError(t, verifier.Verify("audit.log", strings.NewReader(strings.Join(lines, "\n"))))
+
+	var messages []string
+	for _, issue := range verifier.Issues {
+		messages = append(messages, issue.String())
+	}
+
+	return messages
+}
+
+func TestChainAppendSplit(t *testing.T) {
+	for _, line := range []string{`{}`, `{"a":1}`, `{"a":{"chain":"x"},"b":[1,2]}`} {
+		chained, err := appendChainInfo([]byte(line), chainInfo{ID: "id", Seq: 1, Key: "key", MAC: "mac"})
+		require.NoError(t, err)
+
+		original, info, ok := splitChainInfo(bytes.TrimSuffix(chained, []byte{'\n'}))
+		require.True(t, ok)
+		assert.Equal(t, line, string(original))
+		assert.Equal(t, chainInfo{ID: "id", Seq: 1, Key: "key", MAC: "mac"}, info)
+	}
+}
+
+func TestChainVerify(t *testing.T) {
+	lines := writeChainedLogs(t, "/a", "/b", "/c", "/d")
+
+	// start event, 4 logs and a key rotation event
+	require.Len(t, lines, 6)
+	assert.Contains(t, lines[0], `"chainEvent":"start"`)
+	assert.Contains(t, lines[3], `"chainEvent":"keyRotation"`)
+
+	assert.Empty(t, verifyLines(t, lines))
+}
+
+func TestChainVerifyModified(t *testing.T) {
+	lines := writeChainedLogs(t, "/a", "/b", "/c", "/d")
+	lines[2] = strings.Replace(lines[2], "/b", "/x", 1)
+
+	issues := verifyLines(t, lines)
+	require.Len(t, issues, 1)
+	assert.Contains(t, issues[0], "audit.log:3:")
+	assert.Contains(t, issues[0], "sequence 2 was modified")
+}
+
+func TestChainVerifyRemoved(t *testing.T) {
+	lines := writeChainedLogs(t, "/a", "/b", "/c", "/d")
+	lines = append(lines[:1], lines[3:]...)
+
+	issues := verifyLines(t, lines)
+	require.Len(t, issues, 1)
+	assert.Contains(t, issues[0], "missing 2 logs between sequence 1 and 3")
+}
+
+func TestChainVerifyReordered(t *testing.T) {
+	lines := writeChainedLogs(t, "/a", "/b", "/c", "/d")
+	lines[1], lines[2] = lines[2], lines[1]
+
+	issues := verifyLines(t, lines)
+	require.Len(t, issues, 2)
+	assert.Contains(t, issues[0], "missing 1 logs between sequence 1 and 2")
+	assert.Contains(t, issues[1], "sequence 1 is out of order or duplicated, expected 3")
+}
+
+func TestChainVerifyWrongKey(t *testing.T) {
+	lines := writeChainedLogs(t, "/a", "/b", "/c", "/d")
+
+	verifier := NewChainVerifier(map[string][]byte{
+		"key-1": testChainKeys["key-2"],
+		"key-2": testChainKeys["key-2"],
+	})
+	require.NoError(t, verifier.Verify("audit.log", strings.NewReader(strings.Join(lines, "\n"))))
+
+	// every log signed by key-1 fails, including the rotation event
+	assert.Len(t, verifier.Issues, 4)
+}
+
+func TestChainVerifyUnchained(t *testing.T) {
+	issues := verifyLines(t, []string{`{"requestURI":"/a"}`})
+	assert.Equal(t, []string{"audit.log:1: log is not chained"}, issues)
+}
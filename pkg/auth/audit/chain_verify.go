@@ -0,0 +1,179 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ChainIssue describes a problem found while verifying chained audit logs.
+type ChainIssue struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (i ChainIssue) String() string {
+	return fmt.Sprintf("%s:%d: %s", i.File, i.Line, i.Message)
+}
+
+type verifiedChain struct {
+	next    uint64
+	prevMAC string
+	keyID   string
+}
+
+// ChainVerifier checks chained audit logs for gaps, reordering and modification. Files must be verified in the order
+// they were written, since chains continue across rotated log files.
+type ChainVerifier struct {
+	keys   map[string][]byte
+	chains map[string]*verifiedChain
+
+	Issues  []ChainIssue
+	Records int
+}
+
+// NewChainVerifier creates a verifier using the given keys, indexed by key ID.
+func NewChainVerifier(keys map[string][]byte) *ChainVerifier {
+	return &ChainVerifier{
+		keys:   keys,
+		chains: make(map[string]*verifiedChain),
+	}
+}
+
+// Chains returns the number of distinct chains seen so far.
+func (v *ChainVerifier) Chains() int {
+	return len(v.chains)
+}
+
+// Verify reads every log from r, recording any issues found. An error is only returned if r cannot be read.
+func (v *ChainVerifier) Verify(name string, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		v.Records++
+		v.verifyLine(name, lineNumber, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read '%s': %w", name, err)
+	}
+
+	return nil
+}
+
+func (v *ChainVerifier) verifyLine(name string, lineNumber int, line []byte) {
+	issue := func(format string, args ...any) {
+		v.Issues = append(v.Issues, ChainIssue{
+			File:    name,
+			Line:    lineNumber,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	original, info, ok := splitChainInfo(line)
+	if !ok {
+		issue("log is not chained")
+		return
+	}
+
+	var event chainEvent
+	_ = json.Unmarshal(original, &event)
+
+	chain := v.chains[info.ID]
+
+	switch {
+	case event.ChainEvent == chainEventStart:
+		if chain != nil {
+			issue("chain %s was restarted", info.ID)
+		}
+
+		chain = &verifiedChain{}
+		v.chains[info.ID] = chain
+	case chain == nil:
+		// The start of the chain may be in a log file which was rotated away. Without it the first log seen can't be
+		// verified, but every log after it can.
+		issue("chain %s begins at sequence %d without a start event, earlier logs are missing", info.ID, info.Seq)
+
+		v.chains[info.ID] = &verifiedChain{
+			next:    info.Seq + 1,
+			prevMAC: info.MAC,
+			keyID:   v.nextKey(event, info.Key),
+		}
+		return
+	}
+
+	switch {
+	case info.Seq < chain.next:
+		// Don't rewind the chain, so that the logs following the expected sequence still verify.
+		issue("chain %s sequence %d is out of order or duplicated, expected %d", info.ID, info.Seq, chain.next)
+		return
+	case info.Seq > chain.next:
+		issue("chain %s is missing %d logs between sequence %d and %d", info.ID, info.Seq-chain.next, chain.next, info.Seq)
+	case chain.keyID != "" && info.Key != chain.keyID:
+		issue("chain %s sequence %d was signed with key '%s', expected '%s'", info.ID, info.Seq, info.Key, chain.keyID)
+	default:
+		key, ok := v.keys[info.Key]
+		if !ok {
+			issue("chain %s sequence %d was signed with unknown key '%s'", info.ID, info.Seq, info.Key)
+			break
+		}
+
+		expected := chainMAC(key, info.ID, chain.prevMAC, info.Seq, original)
+		if !hmac.Equal([]byte(expected), []byte(info.MAC)) {
+			issue("chain %s sequence %d was modified", info.ID, info.Seq)
+		}
+	}
+
+	// Continue from this log whatever the outcome so each problem is only reported once.
+	chain.next = info.Seq + 1
+	chain.prevMAC = info.MAC
+	chain.keyID = v.nextKey(event, info.Key)
+}
+
+// nextKey returns the key ID expected for the log following one signed with keyID.
+func (v *ChainVerifier) nextKey(event chainEvent, keyID string) string {
+	if event.ChainEvent == chainEventKeyRotation {
+		return event.NextKey
+	}
+
+	return keyID
+}
+
+// ChainKeysFromSecret returns every key held in the chain key secret indexed by key ID, and the ID of the active key.
+func ChainKeysFromSecret(secret *corev1.Secret) (map[string][]byte, string, error) {
+	if len(secret.Data) == 0 {
+		return nil, "", fmt.Errorf("secret %s/%s has no keys", secret.Namespace, secret.Name)
+	}
+
+	active := secret.Annotations[ChainActiveKeyAnnotation]
+	if active == "" {
+		if len(secret.Data) > 1 {
+			return nil, "", fmt.Errorf("secret %s/%s has multiple keys but no '%s' annotation", secret.Namespace, secret.Name, ChainActiveKeyAnnotation)
+		}
+
+		for id := range secret.Data {
+			active = id
+		}
+	}
+
+	if len(secret.Data[active]) == 0 {
+		return nil, "", fmt.Errorf("secret %s/%s has no key '%s'", secret.Namespace, secret.Name, active)
+	}
+
+	return secret.Data, active, nil
+}
@@ -26,7 +26,8 @@
 )
 
 var (
-	defaultRegex = ".*([pP]assword|[Kk]ube[Cc]onfig|[Tt]oken).*"
+	sensitiveKeys = "[pP]assword|[Kk]ube[Cc]onfig|[Tt]oken"
+	defaultRegex  = ".*(" + sensitiveKeys + ").*"
 
 	defaultMu        sync.Mutex
 	defaultRedactors []Redactor
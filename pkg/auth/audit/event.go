@@ -0,0 +1,57 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/rancher/rancher/pkg/auth/audit/event"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+)
+
+// SetEventWriter sets the writer events recorded with event.Record are written to. Events are subject to the same
+// policies, redactions and verbosity as logs of API requests. Events are dropped while no writer is set.
+func SetEventWriter(w *Writer) {
+	if w == nil {
+		event.SetRecorder(nil)
+		return
+	}
+
+	event.SetRecorder(func(e event.Event) error {
+		return writeEvent(w, e)
+	})
+}
+
+func writeEvent(w *Writer, e event.Event) error {
+	var body []byte
+	if e.Body != nil {
+		var err error
+		if body, err = json.Marshal(e.Body); err != nil {
+			return fmt.Errorf("failed to marshal audit event body: %w", err)
+		}
+	}
+
+	var user *User
+	if e.UserName != "" {
+		user = &User{Name: e.UserName}
+	}
+
+	timestamp := time.Now().Format(time.RFC3339)
+
+	return w.Write(&log{
+		AuditID:      k8stypes.UID(uuid.NewRandom().String()),
+		RequestURI:   e.RequestURI,
+		User:         user,
+		Method:       e.Method,
+		ResponseCode: http.StatusOK,
+
+		RequestTimestamp:  timestamp,
+		ResponseTimestamp: timestamp,
+
+		RequestHeader: http.Header{"Content-Type": []string{contentTypeJSON}},
+
+		rawRequestBody: body,
+	})
+}
@@ -0,0 +1,52 @@
+// Package event records actions taken by Rancher itself, rather than through an API request, in the audit log. It is
+// separate from the audit package so that controllers the audit package depends on can record events.
+package event
+
+import (
+	"sync"
+)
+
+var (
+	mu       sync.RWMutex
+	recorder Recorder
+)
+
+// Event is an action taken by Rancher itself, such as a controller granting or revoking access, which should still
+// appear in the audit log.
+type Event struct {
+	// Method is the HTTP method matching the action, e.g. POST for a created object or DELETE for a deleted one.
+	Method string
+
+	// RequestURI is the API path of the object the action was taken on.
+	RequestURI string
+
+	// UserName is the name of the user on whose behalf the action was taken, if any.
+	UserName string
+
+	// Body is logged as the request body, it must be marshalable to a JSON object.
+	Body any
+}
+
+// Recorder writes an event to the audit log.
+type Recorder func(Event) error
+
+// SetRecorder sets the recorder events are written with. Events are dropped while no recorder is set, which is the
+// case when audit logging is disabled.
+func SetRecorder(r Recorder) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	recorder = r
+}
+
+// Record writes event to the audit log.
+func Record(event Event) error {
+	mu.RLock()
+	r := recorder
+	mu.RUnlock()
+
+	if r == nil {
+		return nil
+	}
+	return r(event)
+}
@@ -0,0 +1,43 @@
+package audit
+
+import (
+	"net/http"
+	"testing"
+
+	auditlogv1 "github.com/rancher/rancher/pkg/apis/auditlog.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/auth/audit/event"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordEvent(t *testing.T) {
+	t.Cleanup(func() { SetEventWriter(nil) })
+
+	e := event.Event{
+		Method:     http.MethodDelete,
+		RequestURI: "/apis/management.cattle.io/v3/namespaces/c-abc/clusterroletemplatebindings/crtb-xyz",
+		UserName:   "u-approver",
+		Body:       map[string]any{"reason": "expired"},
+	}
+
+	// Events are dropped while there is no writer.
+	require.NoError(t, event.Record(e))
+
+	logs, w := setup(t, WriterOptions{
+		DefaultPolicyLevel:     auditlogv1.LevelRequest,
+		DisableDefaultPolicies: true,
+	})
+	SetEventWriter(w)
+
+	require.NoError(t, event.Record(e))
+	require.Len(t, logs.logs, 1)
+
+	got := logs.logs[0]
+	assert.NotEmpty(t, got.AuditID)
+	assert.NotEmpty(t, got.RequestTimestamp)
+	assert.Equal(t, e.Method, got.Method)
+	assert.Equal(t, e.RequestURI, got.RequestURI)
+	assert.Equal(t, &User{Name: "u-approver"}, got.User)
+	assert.Equal(t, http.StatusOK, got.ResponseCode)
+	assert.Equal(t, map[string]any{"reason": "expired"}, got.RequestBody)
+}
@@ -2,7 +2,11 @@
 
 import (
 	"fmt"
+	"net/netip"
 	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 
 	auditlogv1 "github.com/rancher/rancher/pkg/apis/auditlog.cattle.io/v1"
 )
@@ -10,6 +14,17 @@
 type Filter struct {
 	action auditlogv1.FilterAction
 	uri    *regexp.Regexp
+
+	users         []*regexp.Regexp
+	excludeUsers  []*regexp.Regexp
+	groups        []*regexp.Regexp
+	excludeGroups []*regexp.Regexp
+	methods       []string
+	responseCodes []string
+	apiGroups     []string
+	resources     []string
+	namespaces    []string
+	sourceIPs     []netip.Prefix
 }
 
 func NewFilter(filter auditlogv1.Filter) (*Filter, error) {
@@ -18,16 +33,167 @@ func NewFilter(filter auditlogv1.Filter) (*Filter, error) {
 		return nil, fmt.Errorf("failed to compile regex '%s': %w", filter.RequestURI, err)
 	}
 
-	return &Filter{
-		action: filter.Action,
-		uri:    compiled,
-	}, nil
+	f := &Filter{
+		action:     filter.Action,
+		uri:        compiled,
+		apiGroups:  filter.APIGroups,
+		resources:  filter.Resources,
+		namespaces: filter.Namespaces,
+	}
+
+	if f.users, err = compileRegexes(filter.Users); err != nil {
+		return nil, fmt.Errorf("invalid users: %w", err)
+	}
+
+	if f.excludeUsers, err = compileRegexes(filter.ExcludeUsers); err != nil {
+		return nil, fmt.Errorf("invalid excludeUsers: %w", err)
+	}
+
+	if f.groups, err = compileRegexes(filter.Groups); err != nil {
+		return nil, fmt.Errorf("invalid groups: %w", err)
+	}
+
+	if f.excludeGroups, err = compileRegexes(filter.ExcludeGroups); err != nil {
+		return nil, fmt.Errorf("invalid excludeGroups: %w", err)
+	}
+
+	for _, m := range filter.Methods {
+		f.methods = append(f.methods, strings.ToUpper(m))
+	}
+
+	for _, c := range filter.ResponseCodes {
+		if !validResponseCode(c) {
+			return nil, fmt.Errorf("invalid response code '%s': must be a status code or class like '4xx'", c)
+		}
+		f.responseCodes = append(f.responseCodes, strings.ToLower(c))
+	}
+
+	for _, ip := range filter.SourceIPs {
+		prefix, err := parsePrefix(ip)
+		if err != nil {
+			return nil, fmt.Errorf("invalid source ip '%s': %w", ip, err)
+		}
+		f.sourceIPs = append(f.sourceIPs, prefix)
+	}
+
+	return f, nil
 }
 
 func (m *Filter) Allowed(log *log) bool {
-	if m.uri.MatchString(log.RequestURI) {
+	if m.matches(log) {
 		return m.action == auditlogv1.FilterActionAllow
 	}
 
 	return false
 }
+
+func (m *Filter) matches(log *log) bool {
+	if !m.uri.MatchString(log.RequestURI) {
+		return false
+	}
+
+	var user User
+	if log.User != nil {
+		user = *log.User
+	}
+
+	if len(m.users) > 0 && !matchesAny(user.Name, m.users) {
+		return false
+	}
+
+	if len(m.excludeUsers) > 0 && matchesAny(user.Name, m.excludeUsers) {
+		return false
+	}
+
+	if len(m.groups) > 0 && !slices.ContainsFunc(user.Group, func(g string) bool { return matchesAny(g, m.groups) }) {
+		return false
+	}
+
+	if len(m.excludeGroups) > 0 && slices.ContainsFunc(user.Group, func(g string) bool { return matchesAny(g, m.excludeGroups) }) {
+		return false
+	}
+
+	if len(m.methods) > 0 && !slices.Contains(m.methods, strings.ToUpper(log.Method)) {
+		return false
+	}
+
+	if len(m.responseCodes) > 0 && !slices.ContainsFunc(m.responseCodes, func(c string) bool { return responseCodeMatches(c, log.ResponseCode) }) {
+		return false
+	}
+
+	if len(m.sourceIPs) > 0 && !m.sourceIPMatches(log) {
+		return false
+	}
+
+	if len(m.apiGroups) == 0 && len(m.resources) == 0 && len(m.namespaces) == 0 {
+		return true
+	}
+
+	info := parseResourceInfo(log.RequestURI)
+
+	if len(m.apiGroups) > 0 && !slices.Contains(m.apiGroups, info.APIGroup) {
+		return false
+	}
+
+	if len(m.resources) > 0 && !slices.ContainsFunc(m.resources, func(r string) bool { return resourceNameMatches(r, info.Resource) }) {
+		return false
+	}
+
+	if len(m.namespaces) > 0 && !slices.Contains(m.namespaces, info.Namespace) {
+		return false
+	}
+
+	return true
+}
+
+// sourceIPMatches matches the client the request was sent from, or the peer it was received from if the client is not
+// known.
+func (m *Filter) sourceIPMatches(log *log) bool {
+	source := log.SourceIP
+	if source == "" {
+		source = log.RemoteAddr
+	}
+
+	addr, ok := parseAddr(source)
+	if !ok {
+		return false
+	}
+
+	return slices.ContainsFunc(m.sourceIPs, func(p netip.Prefix) bool { return p.Contains(addr) })
+}
+
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		return netip.ParsePrefix(s)
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// validResponseCode checks for either a 3 digit status code or a status class ("4xx").
+func validResponseCode(code string) bool {
+	code = strings.ToLower(code)
+	if len(code) != 3 || code[0] < '1' || code[0] > '5' {
+		return false
+	}
+
+	if code[1:] == "xx" {
+		return true
+	}
+
+	_, err := strconv.Atoi(code)
+	return err == nil
+}
+
+func responseCodeMatches(expected string, actual int) bool {
+	if strings.HasSuffix(expected, "xx") {
+		return actual/100 == int(expected[0]-'0')
+	}
+
+	return expected == strconv.Itoa(actual)
+}
@@ -1,6 +1,7 @@
 package audit
 
 import (
+	"net/http"
 	"regexp"
 	"testing"
 
@@ -60,3 +61,208 @@ type testCase struct {
 		})
 	}
 }
+
+func TestNewFilter(t *testing.T) {
+	type testCase struct {
+		Name    string
+		Filter  auditlogv1.Filter
+		log     log
+		Allowed bool
+	}
+
+	nonAdminDeniedSecrets := auditlogv1.Filter{
+		Action:        auditlogv1.FilterActionAllow,
+		ExcludeGroups: []string{"^system:masters$"},
+		ResponseCodes: []string{"403"},
+		Resources:     []string{"secrets"},
+	}
+
+	cases := []testCase{
+		{
+			Name:   "Non Admin Denied Secret",
+			Filter: nonAdminDeniedSecrets,
+			log: log{
+				RequestURI:   "/k8s/clusters/c-12345/api/v1/namespaces/default/secrets/my-secret",
+				User:         &User{Name: "u-abcde", Group: []string{"system:authenticated"}},
+				ResponseCode: 403,
+			},
+			Allowed: true,
+		},
+		{
+			Name:   "Admin Denied Secret",
+			Filter: nonAdminDeniedSecrets,
+			log: log{
+				RequestURI:   "/k8s/clusters/c-12345/api/v1/namespaces/default/secrets/my-secret",
+				User:         &User{Name: "admin", Group: []string{"system:authenticated", "system:masters"}},
+				ResponseCode: 403,
+			},
+			Allowed: false,
+		},
+		{
+			Name:   "Non Admin Allowed Secret",
+			Filter: nonAdminDeniedSecrets,
+			log: log{
+				RequestURI:   "/v1/secret/default/my-secret",
+				User:         &User{Name: "u-abcde"},
+				ResponseCode: 200,
+			},
+			Allowed: false,
+		},
+		{
+			Name:   "Steve Secret Type",
+			Filter: nonAdminDeniedSecrets,
+			log: log{
+				RequestURI:   "/v1/secret/default/my-secret",
+				User:         &User{Name: "u-abcde"},
+				ResponseCode: 403,
+			},
+			Allowed: true,
+		},
+		{
+			Name: "User And Method",
+			Filter: auditlogv1.Filter{
+				Action:  auditlogv1.FilterActionAllow,
+				Users:   []string{"^u-"},
+				Methods: []string{"post", "put"},
+			},
+			log: log{
+				RequestURI: "/v3/clusters",
+				User:       &User{Name: "u-abcde"},
+				Method:     http.MethodPost,
+			},
+			Allowed: true,
+		},
+		{
+			Name: "User And Wrong Method",
+			Filter: auditlogv1.Filter{
+				Action:  auditlogv1.FilterActionAllow,
+				Users:   []string{"^u-"},
+				Methods: []string{"post", "put"},
+			},
+			log: log{
+				RequestURI: "/v3/clusters",
+				User:       &User{Name: "u-abcde"},
+				Method:     http.MethodGet,
+			},
+			Allowed: false,
+		},
+		{
+			Name: "Missing User",
+			Filter: auditlogv1.Filter{
+				Action: auditlogv1.FilterActionAllow,
+				Users:  []string{".*"},
+				Groups: []string{".*"},
+			},
+			log: log{
+				RequestURI: "/v3/clusters",
+			},
+			Allowed: false,
+		},
+		{
+			Name: "Status Class",
+			Filter: auditlogv1.Filter{
+				Action:        auditlogv1.FilterActionAllow,
+				ResponseCodes: []string{"5xx"},
+			},
+			log: log{
+				ResponseCode: 502,
+			},
+			Allowed: true,
+		},
+		{
+			Name: "Source IP In CIDR",
+			Filter: auditlogv1.Filter{
+				Action:    auditlogv1.FilterActionAllow,
+				SourceIPs: []string{"10.0.0.0/8", "192.168.1.1"},
+			},
+			log: log{
+				RemoteAddr: "10.42.0.12:51234",
+			},
+			Allowed: true,
+		},
+		{
+			Name: "Source IP Not In CIDR",
+			Filter: auditlogv1.Filter{
+				Action:    auditlogv1.FilterActionAllow,
+				SourceIPs: []string{"10.0.0.0/8", "192.168.1.1"},
+			},
+			log: log{
+				RemoteAddr: "192.168.1.2:51234",
+			},
+			Allowed: false,
+		},
+		{
+			Name: "Source IP Of Proxied Request",
+			Filter: auditlogv1.Filter{
+				Action:    auditlogv1.FilterActionAllow,
+				SourceIPs: []string{"192.168.1.1"},
+			},
+			log: log{
+				RemoteAddr: "10.42.0.12:51234",
+				SourceIP:   "192.168.1.1",
+			},
+			Allowed: true,
+		},
+		{
+			Name: "Proxy IP Of Proxied Request",
+			Filter: auditlogv1.Filter{
+				Action:    auditlogv1.FilterActionAllow,
+				SourceIPs: []string{"10.0.0.0/8"},
+			},
+			log: log{
+				RemoteAddr: "10.42.0.12:51234",
+				SourceIP:   "192.168.1.1",
+			},
+			Allowed: false,
+		},
+		{
+			Name: "API Group And Namespace",
+			Filter: auditlogv1.Filter{
+				Action:     auditlogv1.FilterActionAllow,
+				APIGroups:  []string{"apps"},
+				Namespaces: []string{"cattle-system"},
+			},
+			log: log{
+				RequestURI: "/apis/apps/v1/namespaces/cattle-system/deployments/rancher?fieldManager=kubectl",
+			},
+			Allowed: true,
+		},
+		{
+			Name: "Core API Group",
+			Filter: auditlogv1.Filter{
+				Action:    auditlogv1.FilterActionAllow,
+				APIGroups: []string{"apps"},
+			},
+			log: log{
+				RequestURI: "/api/v1/namespaces/cattle-system/pods",
+			},
+			Allowed: false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			filter, err := NewFilter(c.Filter)
+			assert.NoError(t, err)
+
+			actual := filter.Allowed(&c.log)
+			assert.Equal(t, c.Allowed, actual)
+		})
+	}
+}
+
+func TestNewFilterInvalid(t *testing.T) {
+	cases := map[string]auditlogv1.Filter{
+		"Invalid Response Code":  {ResponseCodes: []string{"4x"}},
+		"Invalid Response Class": {ResponseCodes: []string{"6xx"}},
+		"Invalid Source IP":      {SourceIPs: []string{"10.0.0.0/33"}},
+		"Invalid User Regex":     {Users: []string{"("}},
+	}
+
+	for name, filter := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewFilter(filter)
+			assert.Error(t, err)
+		})
+	}
+}
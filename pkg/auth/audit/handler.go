@@ -8,7 +8,6 @@
 	"net"
 	"net/http"
 	"reflect"
-	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -25,10 +24,9 @@
 func NewAuditLogMiddleware(writer *Writer) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return handler{
-			next:    next,
-			writer:  writer,
-			errMap:  make(map[string]time.Time),
-			errLock: &sync.Mutex{},
+			next:   next,
+			writer: writer,
+			errors: newErrorDebouncer(),
 		}
 	}
 }
@@ -37,8 +35,7 @@ type handler struct {
 	next   http.Handler
 	writer *Writer
 
-	errMap  map[string]time.Time
-	errLock *sync.Mutex
+	errors *errorDebouncer
 }
 
 func (h handler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
@@ -65,20 +62,10 @@ func (h handler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 
 	respTimestamp := time.Now().Format(time.RFC3339)
 
-	log := newLog(user, req, wr, reqTimestamp, respTimestamp, rawBody, userName)
+	log := newLog(user, req, wr, reqTimestamp, respTimestamp, rawBody, userName, sourceIP(req, h.writer.trustedProxies))
 
 	if err := h.writer.Write(log); err != nil {
-		// Locking after next is called to avoid performance hits on the request.
-		h.errLock.Lock()
-		defer h.errLock.Unlock()
-
-		// Only log duplicate error messages at most every errorDebounceTime.
-		// This is to prevent the rancher logs from being flooded with error messages
-		// when the log path is invalid or any other error that will always cause a write to fail.
-		if lastSeen, ok := h.errMap[err.Error()]; !ok || time.Since(lastSeen) > errorDebounceTime {
-			logrus.Warnf("Failed to write audit log: %s", err)
-			h.errMap[err.Error()] = time.Now()
-		}
+		h.errors.warn(err)
 	}
 }
 
@@ -75,6 +75,7 @@ type log struct {
 	User          *User        `json:"user,omitempty"`
 	Method        string       `json:"method,omitempty"`
 	RemoteAddr    string       `json:"remoteAddr,omitempty"`
+	SourceIP      string       `json:"sourceIP,omitempty"`
 	ResponseCode  int          `json:"responseCode,omitempty"`
 	UserLoginName string       `json:"userLoginName,omitempty"`
 
@@ -124,6 +125,7 @@ func newLog(
 	respTimestamp string,
 	rawBody []byte,
 	userName string,
+	sourceIP string,
 ) *log {
 	log := &log{
 		AuditID:       k8stypes.UID(uuid.NewRandom().String()),
@@ -131,14 +133,16 @@ func newLog(
 		User:          userInfo,
 		Method:        req.Method,
 		RemoteAddr:    req.RemoteAddr,
+		SourceIP:      sourceIP,
 		ResponseCode:  rw.statusCode,
 		UserLoginName: userName,
 
 		RequestTimestamp:  reqTimestamp,
 		ResponseTimestamp: respTimestamp,
 
-		RequestHeader:  req.Header,
-		ResponseHeader: rw.Header(),
+		// Headers are cloned since the log may be written after the request has completed.
+		RequestHeader:  req.Header.Clone(),
+		ResponseHeader: rw.Header().Clone(),
 
 		rawRequestBody:  rawBody,
 		rawResponseBody: rw.buf.Bytes(),
@@ -147,6 +151,15 @@ func newLog(
 	return log
 }
 
+// clone returns a copy of the log which can be prepared and redacted without affecting the log.
+func (l *log) clone() *log {
+	c := *l
+	c.RequestHeader = l.RequestHeader.Clone()
+	c.ResponseHeader = l.ResponseHeader.Clone()
+
+	return &c
+}
+
 func (l *log) decompressResponse() error {
 	var err error
 	var decompressed []byte
@@ -0,0 +1,59 @@
+package audit
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	queuedLogs = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Subsystem: "audit_log",
+			Name:      "queued_total",
+			Help:      "Number of audit logs added to the write queue",
+		},
+	)
+
+	droppedLogs = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Subsystem: "audit_log",
+			Name:      "dropped_total",
+			Help:      "Number of audit logs dropped because the write queue was full",
+		},
+	)
+
+	failedLogs = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "audit_log",
+			Name:      "failed_total",
+			Help:      "Number of audit logs which could not be written to a sink",
+		},
+		[]string{"sink"},
+	)
+
+	queueLength = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Subsystem: "audit_log",
+			Name:      "queue_length",
+			Help:      "Number of audit logs currently waiting in the write queue",
+		},
+	)
+
+	sinkLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: "audit_log",
+			Name:      "sink_write_duration_seconds",
+			Help:      "Time taken to write a single audit log to a sink",
+			Buckets:   prometheus.ExponentialBuckets(0.0001, 4, 10),
+		},
+		[]string{"sink"},
+	)
+)
+
+// RegisterMetrics registers the audit log prometheus metrics.
+func RegisterMetrics() {
+	prometheus.MustRegister(queuedLogs)
+	prometheus.MustRegister(droppedLogs)
+	prometheus.MustRegister(failedLogs)
+	prometheus.MustRegister(queueLength)
+	prometheus.MustRegister(sinkLatency)
+}
@@ -0,0 +1,164 @@
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type OverflowPolicy string
+
+const (
+	// OverflowPolicyBlock makes Write wait until there is room in the queue.
+	OverflowPolicyBlock OverflowPolicy = "block"
+
+	// OverflowPolicyDropOldest discards the oldest queued log to make room for the new one.
+	OverflowPolicyDropOldest OverflowPolicy = "drop-oldest"
+
+	// OverflowPolicyDrop discards the new log.
+	OverflowPolicyDrop OverflowPolicy = "drop"
+)
+
+var (
+	ErrLogDropped = errors.New("audit log queue is full, a log was dropped")
+)
+
+type queue struct {
+	policy OverflowPolicy
+	logs   chan *log
+
+	// mu guards against sending on logs after it has been closed, senders hold a read lock.
+	mu      sync.RWMutex
+	started bool
+	stopped bool
+
+	workers sync.WaitGroup
+	errors  *errorDebouncer
+}
+
+func newQueue(size int, policy OverflowPolicy) (*queue, error) {
+	switch policy {
+	case "":
+		policy = OverflowPolicyBlock
+	case OverflowPolicyBlock, OverflowPolicyDropOldest, OverflowPolicyDrop:
+	default:
+		return nil, fmt.Errorf("invalid overflow policy '%s'", policy)
+	}
+
+	return &queue{
+		policy: policy,
+		logs:   make(chan *log, size),
+		errors: newErrorDebouncer(),
+	}, nil
+}
+
+// push adds log to the queue, returning false if the queue is not running and the log must be written directly.
+func (q *queue) push(log *log) (bool, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if !q.started || q.stopped {
+		return false, nil
+	}
+
+	var err error
+
+	switch q.policy {
+	case OverflowPolicyDrop:
+		select {
+		case q.logs <- log:
+		default:
+			droppedLogs.Inc()
+			return true, ErrLogDropped
+		}
+	case OverflowPolicyDropOldest:
+	loop:
+		for {
+			select {
+			case q.logs <- log:
+				break loop
+			default:
+			}
+
+			// Another sender or a worker may empty the queue between the two selects, so neither may block.
+			select {
+			case <-q.logs:
+				droppedLogs.Inc()
+				queueLength.Dec()
+				err = ErrLogDropped
+			default:
+			}
+		}
+	default:
+		q.logs <- log
+	}
+
+	queuedLogs.Inc()
+	queueLength.Inc()
+
+	return true, err
+}
+
+func (q *queue) start(workers int, write func(*log)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.started {
+		return
+	}
+	q.started = true
+
+	for range workers {
+		q.workers.Add(1)
+		go func() {
+			defer q.workers.Done()
+
+			for log := range q.logs {
+				queueLength.Dec()
+				write(log)
+			}
+		}()
+	}
+}
+
+// stop closes the queue and waits for the workers to write every remaining log.
+func (q *queue) stop() {
+	q.mu.Lock()
+	if q.stopped || !q.started {
+		q.stopped = true
+		q.mu.Unlock()
+		return
+	}
+	q.stopped = true
+	close(q.logs)
+	q.mu.Unlock()
+
+	q.workers.Wait()
+}
+
+// errorDebouncer logs duplicate error messages at most every errorDebounceTime. This prevents the rancher logs from
+// being flooded with error messages when the log path is invalid or any other error that will always cause a write to
+// fail.
+type errorDebouncer struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+func newErrorDebouncer() *errorDebouncer {
+	return &errorDebouncer{
+		seen: make(map[string]time.Time),
+	}
+}
+
+func (d *errorDebouncer) warn(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if lastSeen, ok := d.seen[err.Error()]; !ok || time.Since(lastSeen) > errorDebounceTime {
+		logrus.Warnf("Failed to write audit log: %s", err)
+		d.seen[err.Error()] = time.Now()
+	}
+}
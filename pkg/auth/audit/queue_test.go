@@ -0,0 +1,143 @@
+package audit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingWriter blocks every write until release is closed.
+type blockingWriter struct {
+	logWriter
+
+	mu      sync.Mutex
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.logWriter.Write(p)
+}
+
+func (w *blockingWriter) uris() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var uris []string
+	for _, l := range w.logs {
+		uris = append(uris, l.RequestURI)
+	}
+
+	return uris
+}
+
+func TestQueueFlushesOnShutdown(t *testing.T) {
+	out := &blockingWriter{release: make(chan struct{})}
+	close(out.release)
+
+	w, err := NewWriter(out, WriterOptions{
+		DisableDefaultPolicies: true,
+		QueueSize:              10,
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.Start(ctx)
+
+	for _, uri := range []string{"/a", "/b", "/c"} {
+		require.NoError(t, w.Write(&log{RequestURI: uri}))
+	}
+
+	cancel()
+
+	assert.Eventually(t, func() bool {
+		return len(out.uris()) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"/a", "/b", "/c"}, out.uris())
+}
+
+func TestQueueWritesSynchronouslyBeforeStart(t *testing.T) {
+	out := &blockingWriter{release: make(chan struct{})}
+	close(out.release)
+
+	w, err := NewWriter(out, WriterOptions{
+		DisableDefaultPolicies: true,
+		QueueSize:              1,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, w.Write(&log{RequestURI: "/a"}))
+	assert.Equal(t, []string{"/a"}, out.uris())
+}
+
+func TestQueueOverflow(t *testing.T) {
+	type testCase struct {
+		Name     string
+		Policy   OverflowPolicy
+		Expected []string
+	}
+
+	cases := []testCase{
+		{
+			Name:     "Drop Oldest",
+			Policy:   OverflowPolicyDropOldest,
+			Expected: []string{"/a", "/c", "/d"},
+		},
+		{
+			Name:     "Drop",
+			Policy:   OverflowPolicyDrop,
+			Expected: []string{"/a", "/b", "/c"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			out := &blockingWriter{release: make(chan struct{})}
+
+			w, err := NewWriter(out, WriterOptions{
+				DisableDefaultPolicies: true,
+				QueueSize:              2,
+				OverflowPolicy:         c.Policy,
+			})
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			w.Start(ctx)
+
+			// the worker takes "/a" and blocks on the writer, leaving "/b" and "/c" in the queue
+			require.NoError(t, w.Write(&log{RequestURI: "/a"}))
+			assert.Eventually(t, func() bool {
+				return len(w.queue.logs) == 0
+			}, 5*time.Second, 10*time.Millisecond)
+
+			require.NoError(t, w.Write(&log{RequestURI: "/b"}))
+			require.NoError(t, w.Write(&log{RequestURI: "/c"}))
+			assert.ErrorIs(t, w.Write(&log{RequestURI: "/d"}), ErrLogDropped)
+
+			close(out.release)
+			cancel()
+
+			assert.Eventually(t, func() bool {
+				return len(out.uris()) == len(c.Expected)
+			}, 5*time.Second, 10*time.Millisecond)
+			assert.Equal(t, c.Expected, out.uris())
+		})
+	}
+}
+
+func TestInvalidOverflowPolicy(t *testing.T) {
+	_, err := NewWriter(&logWriter{}, WriterOptions{
+		QueueSize:      1,
+		OverflowPolicy: "unknown",
+	})
+	assert.Error(t, err)
+}
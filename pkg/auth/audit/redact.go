@@ -76,6 +76,16 @@ func (rf RedactFunc) Redact(log *log) error {
 var (
 	secretBaseType    = regexp.MustCompile("^[A-Za-z]*[S|s]ecret$")
 	configmapBaseType = regexp.MustCompile("^[A-Za-z]*[C|c]onfig[M|m]ap$")
+
+	// sensitiveText matches sensitive values in free text like logs, the first group is kept and the rest is redacted:
+	// key=value and key: value pairs and --flag value arguments whose key matches the keys of defaultRegex, bearer
+	// tokens and the inline credentials of kubeconfigs.
+	sensitiveText = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)([\w.-]*(?:` + sensitiveKeys + `)[\w.-]*["']?\s*[:=]\s*["']?)[^\s"',}]+`),
+		regexp.MustCompile(`(?i)(--[\w-]*(?:` + sensitiveKeys + `)[\w-]*\s+)[^\s-]\S*`),
+		regexp.MustCompile(`(?i)(bearer\s+)[^\s"',]+`),
+		regexp.MustCompile(`(?i)((?:client-key|client-certificate|certificate-authority)-data["']?\s*[:=]\s*["']?)[^\s"',}]+`),
+	}
 )
 
 func redactData(secret map[string]any) bool {
@@ -238,3 +248,43 @@ func redactImportUrl(l *log) error {
 
 	return nil
 }
+
+// RedactObject redacts sensitive information within obj in place, using the same redactors as the default audit log
+// policies. The uri is the request URI the object would be served at, some redactors only apply to certain resources.
+func RedactObject(uri string, obj map[string]any) error {
+	defaultMu.Lock()
+	redactors := append([]Redactor{}, defaultRedactors...)
+	defaultMu.Unlock()
+
+	for _, policy := range DefaultPolicies() {
+		for _, redaction := range policy.Spec.AdditionalRedactions {
+			r, err := NewRedactor(redaction)
+			if err != nil {
+				return fmt.Errorf("failed to create redactor for policy %s: %w", policy.Name, err)
+			}
+			redactors = append(redactors, r)
+		}
+	}
+
+	// the object is redacted as both the request and response body so that every redactor applies to it
+	l := &log{
+		RequestURI:   uri,
+		RequestBody:  obj,
+		ResponseBody: obj,
+	}
+	for _, r := range redactors {
+		if err := r.Redact(l); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RedactText redacts sensitive values within free text like logs, which can't be redacted by path like objects.
+func RedactText(text string) string {
+	for _, r := range sensitiveText {
+		text = r.ReplaceAllString(text, "${1}"+redacted)
+	}
+	return text
+}
@@ -0,0 +1,169 @@
+package audit
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/rancher/wrangler/v3/pkg/name"
+)
+
+// resourceInfo describes the kubernetes or rancher resource targeted by a request.
+type resourceInfo struct {
+	Cluster   string
+	APIGroup  string
+	Resource  string
+	Namespace string
+	Name      string
+}
+
+// parseResourceInfo extracts resource information from the request uri of steve (/v1), norman (/v3) and kubernetes
+// (/api, /apis) requests, including requests proxied to downstream clusters through /k8s/clusters/<id>. Unknown paths
+// result in an empty resourceInfo.
+func parseResourceInfo(requestURI string) resourceInfo {
+	path := requestURI
+	if u, err := url.ParseRequestURI(requestURI); err == nil {
+		path = u.Path
+	}
+
+	parts := splitPath(path)
+
+	var info resourceInfo
+	if len(parts) >= 3 && parts[0] == "k8s" && parts[1] == "clusters" {
+		info.Cluster = parts[2]
+		parts = parts[3:]
+	}
+
+	if len(parts) == 0 {
+		return info
+	}
+
+	switch parts[0] {
+	case "api":
+		// /api/<version>/...
+		if len(parts) >= 2 {
+			parseKubernetesResource(&info, parts[2:])
+		}
+	case "apis":
+		// /apis/<group>/<version>/...
+		if len(parts) >= 3 {
+			info.APIGroup = parts[1]
+			parseKubernetesResource(&info, parts[3:])
+		}
+	case "v1":
+		parseSteveResource(&info, parts[1:])
+	case "v3":
+		parseNormanResource(&info, parts[1:])
+	}
+
+	return info
+}
+
+func splitPath(path string) []string {
+	var parts []string
+	for _, p := range strings.Split(path, "/") {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+
+	return parts
+}
+
+// parseKubernetesResource handles the path after the api version:
+//
+//	<resource>[/<name>[/<subresource>]]
+//	namespaces/<namespace>/<resource>[/<name>[/<subresource>]]
+func parseKubernetesResource(info *resourceInfo, parts []string) {
+	if len(parts) == 0 {
+		return
+	}
+
+	if parts[0] == "namespaces" && len(parts) >= 3 {
+		info.Namespace = parts[1]
+		parts = parts[2:]
+	}
+
+	info.Resource = parts[0]
+	if len(parts) >= 2 {
+		info.Name = parts[1]
+	}
+
+	if info.Resource == "namespaces" && info.Name != "" {
+		info.Namespace = info.Name
+	}
+}
+
+// parseSteveResource handles the path after /v1:
+//
+//	<type>[/<name>]
+//	<type>/<namespace>/<name>
+//
+// Steve types are of the form [<group>.]<resource>, for example "secret" or "apps.deployment".
+func parseSteveResource(info *resourceInfo, parts []string) {
+	if len(parts) == 0 {
+		return
+	}
+
+	steveType := parts[0]
+	if i := strings.LastIndex(steveType, "."); i >= 0 {
+		info.APIGroup = steveType[:i]
+		info.Resource = steveType[i+1:]
+	} else {
+		info.Resource = steveType
+	}
+
+	switch len(parts) {
+	case 1:
+	case 2:
+		info.Name = parts[1]
+	default:
+		info.Namespace = parts[1]
+		info.Name = parts[2]
+	}
+}
+
+// parseNormanResource handles the path after /v3:
+//
+//	<type>[/<id>]
+//	cluster/<clusterID>/<type>[/<id>]
+//	project/<projectID>/<type>[/<id>]
+//
+// Norman ids of the form <namespace>:<name> are split into the namespace and name. Norman paths carry no api group.
+func parseNormanResource(info *resourceInfo, parts []string) {
+	if len(parts) >= 3 && (parts[0] == "cluster" || parts[0] == "project") {
+		if parts[0] == "cluster" {
+			info.Cluster = parts[1]
+		} else if clusterID, _, ok := strings.Cut(parts[1], ":"); ok {
+			info.Cluster = clusterID
+		}
+		parts = parts[2:]
+	}
+
+	if len(parts) == 0 {
+		return
+	}
+
+	info.Resource = parts[0]
+
+	if len(parts) >= 2 {
+		if namespace, name, ok := strings.Cut(parts[1], ":"); ok {
+			info.Namespace = namespace
+			info.Name = name
+		} else {
+			info.Name = parts[1]
+		}
+	}
+}
+
+// resourceNameMatches compares resource names while tolerating the singular form used by steve types and the plural
+// form used by kubernetes and norman paths. Plurals are guessed the same way steve derives the plural names of its
+// types, so that names like ingress and networkpolicy match ingresses and networkpolicies.
+func resourceNameMatches(expected string, actual string) bool {
+	expected = strings.ToLower(expected)
+	actual = strings.ToLower(actual)
+	if expected == "" || actual == "" {
+		return expected == actual
+	}
+
+	return expected == actual || expected == name.GuessPluralName(actual) || name.GuessPluralName(expected) == actual
+}
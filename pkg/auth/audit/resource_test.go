@@ -0,0 +1,93 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseResourceInfo(t *testing.T) {
+	cases := []struct {
+		uri      string
+		expected resourceInfo
+	}{
+		{
+			uri:      "/api/v1/secrets?limit=500",
+			expected: resourceInfo{Resource: "secrets"},
+		},
+		{
+			uri:      "/api/v1/namespaces/default/secrets/my-secret",
+			expected: resourceInfo{Resource: "secrets", Namespace: "default", Name: "my-secret"},
+		},
+		{
+			uri:      "/api/v1/namespaces/default",
+			expected: resourceInfo{Resource: "namespaces", Namespace: "default", Name: "default"},
+		},
+		{
+			uri:      "/apis/apps/v1/namespaces/cattle-system/deployments/rancher/scale",
+			expected: resourceInfo{APIGroup: "apps", Resource: "deployments", Namespace: "cattle-system", Name: "rancher"},
+		},
+		{
+			uri:      "/k8s/clusters/c-12345/apis/management.cattle.io/v3/clusters",
+			expected: resourceInfo{Cluster: "c-12345", APIGroup: "management.cattle.io", Resource: "clusters"},
+		},
+		{
+			uri:      "/v1/secret/default/my-secret",
+			expected: resourceInfo{Resource: "secret", Namespace: "default", Name: "my-secret"},
+		},
+		{
+			uri:      "/v1/management.cattle.io.cluster/c-12345",
+			expected: resourceInfo{APIGroup: "management.cattle.io", Resource: "cluster", Name: "c-12345"},
+		},
+		{
+			uri:      "/k8s/clusters/c-12345/v1/apps.deployment",
+			expected: resourceInfo{Cluster: "c-12345", APIGroup: "apps", Resource: "deployment"},
+		},
+		{
+			uri:      "/v3/project/c-12345:p-abcde/secrets/p-abcde:my-secret",
+			expected: resourceInfo{Cluster: "c-12345", Resource: "secrets", Namespace: "p-abcde", Name: "my-secret"},
+		},
+		{
+			uri:      "/v3/clusters/c-12345?action=generateKubeconfig",
+			expected: resourceInfo{Resource: "clusters", Name: "c-12345"},
+		},
+		{
+			uri:      "/dashboard/",
+			expected: resourceInfo{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.uri, func(t *testing.T) {
+			assert.Equal(t, c.expected, parseResourceInfo(c.uri))
+		})
+	}
+}
+
+func TestResourceNameMatches(t *testing.T) {
+	cases := []struct {
+		expected string
+		actual   string
+		matches  bool
+	}{
+		{expected: "secrets", actual: "secrets", matches: true},
+		{expected: "secrets", actual: "secret", matches: true},
+		{expected: "secret", actual: "secrets", matches: true},
+		{expected: "Secrets", actual: "secret", matches: true},
+		{expected: "ingresses", actual: "ingress", matches: true},
+		{expected: "ingress", actual: "ingresses", matches: true},
+		{expected: "networkpolicies", actual: "networkpolicy", matches: true},
+		{expected: "networkpolicy", actual: "networkpolicies", matches: true},
+		{expected: "storageclasses", actual: "storageclass", matches: true},
+		{expected: "storageclass", actual: "storageclasses", matches: true},
+		{expected: "endpoints", actual: "endpoints", matches: true},
+		{expected: "secrets", actual: "configmaps", matches: false},
+		{expected: "secrets", actual: "", matches: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.expected+"/"+c.actual, func(t *testing.T) {
+			assert.Equal(t, c.matches, resourceNameMatches(c.expected, c.actual))
+		})
+	}
+}
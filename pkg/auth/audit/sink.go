@@ -0,0 +1,73 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+const (
+	// DefaultSinkName is the name given to the output passed directly to NewWriter.
+	DefaultSinkName = "default"
+)
+
+// Sink is a named destination for audit logs. Each call to Write receives exactly one newline terminated JSON log.
+type Sink interface {
+	io.Writer
+
+	// Name identifies the sink so that policies can route logs to it.
+	Name() string
+}
+
+// starter is implemented by sinks which need to run background work (batching, reconnecting, etc) for the lifetime
+// of the Writer.
+type starter interface {
+	Start(ctx context.Context)
+}
+
+type writerSink struct {
+	name   string
+	output io.Writer
+}
+
+// NewWriterSink wraps an io.Writer in a Sink with the given name.
+func NewWriterSink(name string, output io.Writer) Sink {
+	return &writerSink{
+		name:   name,
+		output: output,
+	}
+}
+
+func (s *writerSink) Name() string {
+	return s.name
+}
+
+func (s *writerSink) Write(p []byte) (int, error) {
+	return s.output.Write(p)
+}
+
+func (s *writerSink) Close() error {
+	if closer, ok := s.output.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+func validateSinks(sinks []Sink) error {
+	seen := make(map[string]bool, len(sinks))
+
+	for _, s := range sinks {
+		if s.Name() == "" {
+			return fmt.Errorf("sink name cannot be empty")
+		}
+
+		if seen[s.Name()] {
+			return fmt.Errorf("duplicate sink name '%s'", s.Name())
+		}
+
+		seen[s.Name()] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package audit
+
+import (
+	"fmt"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// FileSinkOptions configures a local file sink which is rotated once it reaches a size limit.
+type FileSinkOptions struct {
+	Path string
+
+	// MaxSize is the maximum size in megabytes of the log file before it gets rotated.
+	MaxSize int
+
+	// MaxAge is the maximum number of days to retain old log files.
+	MaxAge int
+
+	// MaxBackups is the maximum number of old log files to retain.
+	MaxBackups int
+
+	// Compress determines if rotated log files are gzipped.
+	Compress bool
+}
+
+// NewFileSink creates a Sink writing to a rotating file on the local filesystem.
+func NewFileSink(name string, opts FileSinkOptions) (Sink, error) {
+	if opts.Path == "" {
+		return nil, fmt.Errorf("file sink '%s' requires a path", name)
+	}
+
+	return NewWriterSink(name, &lumberjack.Logger{
+		Filename:   opts.Path,
+		MaxSize:    opts.MaxSize,
+		MaxAge:     opts.MaxAge,
+		MaxBackups: opts.MaxBackups,
+		Compress:   opts.Compress,
+	}), nil
+}
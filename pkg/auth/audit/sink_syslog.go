@@ -0,0 +1,183 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	syslogVersion             = 1
+	syslogSeverityInfo        = 6
+	syslogDefaultFacility     = 16 // local0
+	syslogDefaultAppName      = "rancher"
+	syslogMsgID               = "audit"
+	syslogNilValue            = "-"
+	syslogDefaultDialTimout   = 10 * time.Second
+	syslogDefaultWriteTimeout = 10 * time.Second
+)
+
+// SyslogSinkOptions configures a sink which sends logs to a remote syslog server using the RFC5424 message format
+// over TCP, optionally secured with TLS (RFC5425).
+type SyslogSinkOptions struct {
+	// Address is the host:port of the syslog server.
+	Address string
+
+	// TLSConfig is used to establish a TLS connection with the server. When nil, plain TCP is used.
+	TLSConfig *tls.Config
+
+	// Facility is the syslog facility code, defaults to local0.
+	Facility int
+
+	// AppName is the APP-NAME field of every message, defaults to "rancher".
+	AppName string
+
+	// Hostname is the HOSTNAME field of every message, defaults to the hostname reported by the kernel.
+	Hostname string
+
+	DialTimeout time.Duration
+
+	// WriteTimeout bounds the time spent writing a message, so that a server which stops reading does not hold up the
+	// other sinks. A write which times out fails, and the connection is closed and redialed.
+	WriteTimeout time.Duration
+}
+
+type syslogSink struct {
+	name string
+	opts SyslogSinkOptions
+
+	mu   sync.Mutex
+	conn net.Conn
+
+	now  func() time.Time
+	dial func() (net.Conn, error)
+}
+
+// NewSyslogSink creates a Sink which forwards logs to a syslog server.
+func NewSyslogSink(name string, opts SyslogSinkOptions) (Sink, error) {
+	if opts.Address == "" {
+		return nil, fmt.Errorf("syslog sink '%s' requires an address", name)
+	}
+
+	if opts.Facility < 0 || opts.Facility > 23 {
+		return nil, fmt.Errorf("syslog sink '%s' has invalid facility %d", name, opts.Facility)
+	}
+
+	if opts.Facility == 0 {
+		opts.Facility = syslogDefaultFacility
+	}
+
+	if opts.AppName == "" {
+		opts.AppName = syslogDefaultAppName
+	}
+
+	if opts.Hostname == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = syslogNilValue
+		}
+		opts.Hostname = hostname
+	}
+
+	if opts.DialTimeout == 0 {
+		opts.DialTimeout = syslogDefaultDialTimout
+	}
+
+	if opts.WriteTimeout == 0 {
+		opts.WriteTimeout = syslogDefaultWriteTimeout
+	}
+
+	s := &syslogSink{
+		name: name,
+		opts: opts,
+		now:  time.Now,
+	}
+
+	s.dial = func() (net.Conn, error) {
+		dialer := &net.Dialer{Timeout: s.opts.DialTimeout}
+
+		if s.opts.TLSConfig != nil {
+			return tls.DialWithDialer(dialer, "tcp", s.opts.Address, s.opts.TLSConfig)
+		}
+
+		return dialer.Dial("tcp", s.opts.Address)
+	}
+
+	return s, nil
+}
+
+func (s *syslogSink) Name() string {
+	return s.name
+}
+
+// format builds an RFC5424 message framed using octet counting as described in RFC6587.
+func (s *syslogSink) format(p []byte) []byte {
+	var msg bytes.Buffer
+
+	fmt.Fprintf(&msg, "<%d>%d %s %s %s %s %s %s ",
+		s.opts.Facility*8+syslogSeverityInfo,
+		syslogVersion,
+		s.now().UTC().Format(time.RFC3339Nano),
+		s.opts.Hostname,
+		s.opts.AppName,
+		syslogNilValue,
+		syslogMsgID,
+		syslogNilValue,
+	)
+	msg.Write(bytes.TrimRight(p, "\n"))
+
+	framed := make([]byte, 0, msg.Len()+8)
+	framed = strconv.AppendInt(framed, int64(msg.Len()), 10)
+	framed = append(framed, ' ')
+	framed = append(framed, msg.Bytes()...)
+
+	return framed
+}
+
+func (s *syslogSink) Write(p []byte) (int, error) {
+	msg := s.format(p)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Retry once on a fresh connection since the server may have closed an idle connection.
+	var err error
+	for range 2 {
+		if s.conn == nil {
+			if s.conn, err = s.dial(); err != nil {
+				s.conn = nil
+				return 0, fmt.Errorf("failed to connect to syslog server '%s': %w", s.opts.Address, err)
+			}
+		}
+
+		if err = s.conn.SetWriteDeadline(s.now().Add(s.opts.WriteTimeout)); err == nil {
+			if _, err = s.conn.Write(msg); err == nil {
+				return len(p), nil
+			}
+		}
+
+		s.conn.Close()
+		s.conn = nil
+	}
+
+	return 0, fmt.Errorf("failed to write to syslog server '%s': %w", s.opts.Address, err)
+}
+
+func (s *syslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+
+	err := s.conn.Close()
+	s.conn = nil
+
+	return err
+}
@@ -0,0 +1,359 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyslogSinkFormat(t *testing.T) {
+	sink, err := NewSyslogSink("syslog", SyslogSinkOptions{
+		Address:  "127.0.0.1:514",
+		Hostname: "rancher-0",
+	})
+	require.NoError(t, err)
+
+	s := sink.(*syslogSink)
+	s.now = func() time.Time {
+		return time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	}
+
+	msg := `<134>1 2025-01-02T03:04:05Z rancher-0 rancher - audit - {"requestURI":"/v3"}`
+	expected := strconv.Itoa(len(msg)) + " " + msg
+
+	assert.Equal(t, expected, string(s.format([]byte(`{"requestURI":"/v3"}`+"\n"))))
+}
+
+func TestSyslogSinkWrite(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		length, err := reader.ReadString(' ')
+		if err != nil {
+			return
+		}
+
+		n, _ := strconv.Atoi(strings.TrimSpace(length))
+		msg := make([]byte, n)
+		if _, err := io.ReadFull(reader, msg); err != nil {
+			return
+		}
+
+		received <- string(msg)
+	}()
+
+	sink, err := NewSyslogSink("syslog", SyslogSinkOptions{
+		Address: listener.Addr().String(),
+	})
+	require.NoError(t, err)
+	defer sink.(io.Closer).Close()
+
+	_, err = sink.Write([]byte(`{"requestURI":"/v3"}` + "\n"))
+	require.NoError(t, err)
+
+	select {
+	case msg := <-received:
+		assert.True(t, strings.HasPrefix(msg, "<134>1 "))
+		assert.True(t, strings.HasSuffix(msg, ` rancher - audit - {"requestURI":"/v3"}`))
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for syslog message")
+	}
+}
+
+func TestSyslogSinkWriteTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	// accept connections but never read from them
+	var (
+		mu    sync.Mutex
+		conns []net.Conn
+	)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+		}
+	}()
+	defer func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+
+	sink, err := NewSyslogSink("syslog", SyslogSinkOptions{
+		Address:      listener.Addr().String(),
+		WriteTimeout: 100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	defer sink.(io.Closer).Close()
+
+	// large messages fill the socket buffers, so that writes block once the server stops reading
+	msg := []byte(`{"requestURI":"/v3","responseBody":"` + strings.Repeat("a", 1<<20) + `"}` + "\n")
+
+	// every write either succeeds or times out, redialing the server, instead of blocking
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 20 {
+			sink.Write(msg)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("syslog sink write blocked on a server which does not read")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Greater(t, len(conns), 1, "the timed out connection should be redialed")
+}
+
+type webhookServer struct {
+	mu       sync.Mutex
+	batches  [][]map[string]any
+	failures int
+}
+
+func (s *webhookServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failures > 0 {
+		s.failures--
+		rw.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	var batch []map[string]any
+	if err := json.NewDecoder(req.Body).Decode(&batch); err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.batches = append(s.batches, batch)
+}
+
+func newTestWebhookSink(t *testing.T, url string, opts WebhookSinkOptions) *webhookSink {
+	opts.URL = url
+
+	sink, err := NewWebhookSink("webhook", opts)
+	require.NoError(t, err)
+
+	s := sink.(*webhookSink)
+	s.sleep = func(context.Context, time.Duration) error { return nil }
+
+	return s
+}
+
+func TestWebhookSinkBatches(t *testing.T) {
+	server := &webhookServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	sink := newTestWebhookSink(t, ts.URL, WebhookSinkOptions{BatchSize: 2})
+
+	for _, uri := range []string{"/a", "/b", "/c"} {
+		_, err := sink.Write([]byte(`{"requestURI":"` + uri + `"}` + "\n"))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, sink.flush(context.Background()))
+
+	expected := [][]map[string]any{
+		{{"requestURI": "/a"}, {"requestURI": "/b"}},
+		{{"requestURI": "/c"}},
+	}
+	assert.Equal(t, expected, server.batches)
+}
+
+func TestWebhookSinkRetry(t *testing.T) {
+	server := &webhookServer{failures: 2}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	sink := newTestWebhookSink(t, ts.URL, WebhookSinkOptions{MaxRetries: 2})
+
+	_, err := sink.Write([]byte(`{"requestURI":"/a"}` + "\n"))
+	require.NoError(t, err)
+	require.NoError(t, sink.flush(context.Background()))
+
+	assert.Equal(t, [][]map[string]any{{{"requestURI": "/a"}}}, server.batches)
+}
+
+func TestWebhookSinkBuffer(t *testing.T) {
+	server := &webhookServer{failures: 2}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	bufferPath := filepath.Join(t.TempDir(), "buffer")
+	sink := newTestWebhookSink(t, ts.URL, WebhookSinkOptions{MaxRetries: -1, BufferPath: bufferPath})
+
+	_, err := sink.Write([]byte(`{"requestURI":"/a"}` + "\n"))
+	require.NoError(t, err)
+	assert.Error(t, sink.flush(context.Background()))
+
+	data, err := os.ReadFile(bufferPath)
+	require.NoError(t, err)
+	assert.Equal(t, `{"requestURI":"/a"}`+"\n", string(data))
+
+	// the buffer still can't be delivered, so new logs must be queued behind it
+	_, err = sink.Write([]byte(`{"requestURI":"/b"}` + "\n"))
+	require.NoError(t, err)
+	assert.Error(t, sink.flush(context.Background()))
+
+	data, err = os.ReadFile(bufferPath)
+	require.NoError(t, err)
+	assert.Equal(t, `{"requestURI":"/a"}`+"\n"+`{"requestURI":"/b"}`+"\n", string(data))
+
+	_, err = sink.Write([]byte(`{"requestURI":"/c"}` + "\n"))
+	require.NoError(t, err)
+	require.NoError(t, sink.flush(context.Background()))
+
+	expected := [][]map[string]any{
+		{{"requestURI": "/a"}, {"requestURI": "/b"}},
+		{{"requestURI": "/c"}},
+	}
+	assert.Equal(t, expected, server.batches)
+
+	_, err = os.Stat(bufferPath)
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
+
+func TestWebhookSinkNonRetryable(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		calls++
+		rw.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	sink := newTestWebhookSink(t, ts.URL, WebhookSinkOptions{MaxRetries: 5})
+
+	_, err := sink.Write([]byte(`{"requestURI":"/a"}` + "\n"))
+	require.NoError(t, err)
+	assert.ErrorIs(t, sink.flush(context.Background()), errWebhookNonRetryable)
+	assert.Equal(t, 1, calls)
+}
+
+func TestWebhookSinkRejected(t *testing.T) {
+	var calls []string
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		calls = append(calls, string(body))
+		if strings.Contains(string(body), "/rejected") {
+			rw.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	bufferPath := filepath.Join(dir, "buffer")
+	rejectedPath := filepath.Join(dir, "rejected")
+	require.NoError(t, os.WriteFile(bufferPath, []byte(`{"requestURI":"/rejected"}`+"\n"), 0600))
+	sink := newTestWebhookSink(t, ts.URL, WebhookSinkOptions{BatchSize: 1, BufferPath: bufferPath, RejectedPath: rejectedPath})
+
+	for _, uri := range []string{"/a", "/rejected", "/b"} {
+		_, err := sink.Write([]byte(`{"requestURI":"` + uri + `"}` + "\n"))
+		require.NoError(t, err)
+	}
+
+	err := sink.flush(context.Background())
+	assert.ErrorIs(t, err, errWebhookNonRetryable)
+
+	// rejected batches are set aside without holding back the following ones
+	expected := []string{`[{"requestURI":"/rejected"}]`, `[{"requestURI":"/a"}]`, `[{"requestURI":"/rejected"}]`, `[{"requestURI":"/b"}]`}
+	assert.Equal(t, expected, calls)
+
+	data, err := os.ReadFile(rejectedPath)
+	require.NoError(t, err)
+	assert.Equal(t, `{"requestURI":"/rejected"}`+"\n"+`{"requestURI":"/rejected"}`+"\n", string(data))
+	_, err = os.Stat(bufferPath)
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+
+	// rejected logs are not resent
+	require.NoError(t, sink.flush(context.Background()))
+	assert.Len(t, calls, 4)
+}
+
+func TestWebhookSinkMaxPending(t *testing.T) {
+	sink := newTestWebhookSink(t, "http://127.0.0.1:0", WebhookSinkOptions{BatchSize: 2, MaxPending: 2})
+
+	for _, uri := range []string{"/a", "/b"} {
+		_, err := sink.Write([]byte(`{"requestURI":"` + uri + `"}` + "\n"))
+		require.NoError(t, err)
+	}
+
+	_, err := sink.Write([]byte(`{"requestURI":"/c"}` + "\n"))
+	assert.EqualError(t, err, "dropped audit log: 2 logs are already waiting to be delivered")
+	assert.Len(t, sink.pending, 2)
+}
+
+func TestWebhookSinkWriteAfterStop(t *testing.T) {
+	server := &webhookServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	sink := newTestWebhookSink(t, ts.URL, WebhookSinkOptions{FlushInterval: time.Hour})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sink.Start(ctx)
+
+	_, err := sink.Write([]byte(`{"requestURI":"/a"}` + "\n"))
+	require.NoError(t, err)
+	cancel()
+
+	// the logs written before the context is done are flushed once it is
+	require.Eventually(t, func() bool {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		return len(server.batches) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// logs written afterwards are delivered as they are written
+	_, err = sink.Write([]byte(`{"requestURI":"/b"}` + "\n"))
+	require.NoError(t, err)
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	expected := [][]map[string]any{
+		{{"requestURI": "/a"}},
+		{{"requestURI": "/b"}},
+	}
+	assert.Equal(t, expected, server.batches)
+}
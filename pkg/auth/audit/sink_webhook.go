@@ -0,0 +1,457 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	webhookDefaultBatchSize     = 100
+	webhookDefaultFlushInterval = 5 * time.Second
+	webhookDefaultMaxRetries    = 3
+	webhookDefaultRetryBackoff  = time.Second
+	webhookDefaultTimeout       = 10 * time.Second
+	webhookDefaultMaxBufferSize = 100 * 1024 * 1024
+	webhookDefaultMaxPending    = 10000
+	webhookShutdownTimeout      = 30 * time.Second
+)
+
+var (
+	errWebhookNonRetryable = errors.New("webhook rejected audit logs")
+)
+
+// WebhookSinkOptions configures a sink which POSTs batches of logs as a JSON array to an HTTP endpoint.
+type WebhookSinkOptions struct {
+	URL string
+
+	// Headers are added to every request, for example to provide an Authorization header.
+	Headers http.Header
+
+	TLSConfig *tls.Config
+
+	// BatchSize is the number of logs which triggers an immediate flush.
+	BatchSize int
+
+	// FlushInterval is the maximum amount of time logs are held before being sent.
+	FlushInterval time.Duration
+
+	// MaxRetries is the number of times delivery of a batch is retried before the batch is buffered to disk.
+	MaxRetries int
+
+	// RetryBackoff is the initial delay between retries, it is doubled after each failed attempt.
+	RetryBackoff time.Duration
+
+	Timeout time.Duration
+
+	// BufferPath is a file used to hold batches which could not be delivered. Buffered logs are resent before any
+	// new logs on the next flush. Leave empty to drop undeliverable batches.
+	BufferPath string
+
+	// RejectedPath is a file used to hold batches the endpoint rejected with a non-retryable status, so that they are
+	// kept for inspection without being resent. Leave empty to drop rejected batches.
+	RejectedPath string
+
+	// MaxBufferSize is the maximum size in bytes of the buffer and rejected files. Batches which would exceed it are
+	// dropped.
+	MaxBufferSize int64
+
+	// MaxPending is the maximum number of logs waiting for the next flush. Logs written while it is reached are
+	// dropped.
+	MaxPending int
+}
+
+type webhookSink struct {
+	name   string
+	opts   WebhookSinkOptions
+	client *http.Client
+
+	mu      sync.Mutex
+	pending [][]byte
+	// stopped is set once the sink no longer flushes in the background, logs are then delivered as they are written.
+	stopped bool
+
+	flushMu sync.Mutex
+	flushCh chan struct{}
+
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewWebhookSink creates a Sink which delivers logs to an HTTP endpoint in batches.
+func NewWebhookSink(name string, opts WebhookSinkOptions) (Sink, error) {
+	if opts.URL == "" {
+		return nil, fmt.Errorf("webhook sink '%s' requires a url", name)
+	}
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = webhookDefaultBatchSize
+	}
+
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = webhookDefaultFlushInterval
+	}
+
+	if opts.MaxRetries < 0 {
+		opts.MaxRetries = 0
+	} else if opts.MaxRetries == 0 {
+		opts.MaxRetries = webhookDefaultMaxRetries
+	}
+
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = webhookDefaultRetryBackoff
+	}
+
+	if opts.Timeout <= 0 {
+		opts.Timeout = webhookDefaultTimeout
+	}
+
+	if opts.MaxBufferSize <= 0 {
+		opts.MaxBufferSize = webhookDefaultMaxBufferSize
+	}
+
+	if opts.MaxPending <= 0 {
+		opts.MaxPending = max(webhookDefaultMaxPending, opts.BatchSize)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.TLSConfig != nil {
+		transport.TLSClientConfig = opts.TLSConfig
+	}
+
+	return &webhookSink{
+		name: name,
+		opts: opts,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   opts.Timeout,
+		},
+
+		flushCh: make(chan struct{}, 1),
+		sleep:   sleepContext,
+	}, nil
+}
+
+func (s *webhookSink) Name() string {
+	return s.name
+}
+
+func (s *webhookSink) Write(p []byte) (int, error) {
+	line := bytes.TrimRight(p, "\n")
+	if len(line) == 0 {
+		return len(p), nil
+	}
+
+	s.mu.Lock()
+	if len(s.pending) >= s.opts.MaxPending {
+		s.mu.Unlock()
+		return 0, fmt.Errorf("dropped audit log: %d logs are already waiting to be delivered", s.opts.MaxPending)
+	}
+	s.pending = append(s.pending, bytes.Clone(line))
+	full := len(s.pending) >= s.opts.BatchSize
+	stopped := s.stopped
+	s.mu.Unlock()
+
+	if stopped {
+		return len(p), s.flushWithTimeout()
+	}
+
+	if full {
+		select {
+		case s.flushCh <- struct{}{}:
+		default:
+		}
+	}
+
+	return len(p), nil
+}
+
+func (s *webhookSink) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.opts.FlushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				// Deliver the logs written since the last flush, and any written from now on as they are written.
+				if err := s.stop(); err != nil {
+					logrus.Warnf("Failed to deliver audit logs to webhook sink '%s': %s", s.name, err)
+				}
+				return
+			case <-ticker.C:
+			case <-s.flushCh:
+			}
+
+			if err := s.flush(ctx); err != nil {
+				logrus.Warnf("Failed to deliver audit logs to webhook sink '%s': %s", s.name, err)
+			}
+		}
+	}()
+}
+
+// Close makes a final attempt at delivering any pending logs. Logs written after Close are delivered as they are
+// written.
+func (s *webhookSink) Close() error {
+	return s.stop()
+}
+
+// stop stops batching logs and delivers any pending logs.
+func (s *webhookSink) stop() error {
+	s.mu.Lock()
+	s.stopped = true
+	s.mu.Unlock()
+
+	return s.flushWithTimeout()
+}
+
+func (s *webhookSink) flushWithTimeout() error {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookShutdownTimeout)
+	defer cancel()
+
+	return s.flush(ctx)
+}
+
+func (s *webhookSink) flush(ctx context.Context) error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.mu.Lock()
+	batch := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+
+	replayed, err := s.replayBuffer(ctx)
+	if !replayed {
+		// Preserve ordering by buffering the new batch behind the logs which are still waiting to be delivered.
+		if bufErr := s.buffer(batch); bufErr != nil {
+			return errors.Join(err, bufErr)
+		}
+
+		return err
+	}
+
+	// Batches the endpoint rejects are set aside, the following batches are still delivered.
+	rejectErrs := []error{err}
+
+	for start := 0; start < len(batch); start += s.opts.BatchSize {
+		end := min(start+s.opts.BatchSize, len(batch))
+
+		err := s.send(ctx, batch[start:end])
+		if errors.Is(err, errWebhookNonRetryable) {
+			rejectErrs = append(rejectErrs, s.reject(batch[start:end], err))
+			continue
+		}
+		if err != nil {
+			if bufErr := s.buffer(batch[start:]); bufErr != nil {
+				return errors.Join(append(rejectErrs, err, bufErr)...)
+			}
+
+			return errors.Join(append(rejectErrs, err)...)
+		}
+	}
+
+	return errors.Join(rejectErrs...)
+}
+
+// send POSTs the given logs, retrying with an exponential backoff.
+func (s *webhookSink) send(ctx context.Context, lines [][]byte) error {
+	if len(lines) == 0 {
+		return nil
+	}
+
+	body := make([]byte, 0, len(lines)*256)
+	body = append(body, '[')
+	body = append(body, bytes.Join(lines, []byte{','})...)
+	body = append(body, ']')
+
+	backoff := s.opts.RetryBackoff
+
+	var err error
+	for attempt := 0; attempt <= s.opts.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if sleepErr := s.sleep(ctx, backoff); sleepErr != nil {
+				return fmt.Errorf("%w: %w", err, sleepErr)
+			}
+			backoff *= 2
+		}
+
+		if err = s.post(ctx, body); err == nil || errors.Is(err, errWebhookNonRetryable) {
+			return err
+		}
+	}
+
+	return err
+}
+
+func (s *webhookSink) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: failed to create request: %w", errWebhookNonRetryable, err)
+	}
+
+	for k, v := range s.opts.Headers {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", contentTypeJSON)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	default:
+		return fmt.Errorf("%w: unexpected response status %d", errWebhookNonRetryable, resp.StatusCode)
+	}
+}
+
+// buffer appends lines to the on-disk buffer file.
+func (s *webhookSink) buffer(lines [][]byte) error {
+	if len(lines) == 0 {
+		return nil
+	}
+
+	if s.opts.BufferPath == "" {
+		return fmt.Errorf("dropped %d audit logs: no buffer configured", len(lines))
+	}
+
+	return s.appendLines(s.opts.BufferPath, lines)
+}
+
+// reject appends lines the endpoint rejected to the on-disk rejected file, as resending them would fail again.
+func (s *webhookSink) reject(lines [][]byte, err error) error {
+	if s.opts.RejectedPath == "" {
+		return fmt.Errorf("dropped %d audit logs: %w", len(lines), err)
+	}
+
+	if appendErr := s.appendLines(s.opts.RejectedPath, lines); appendErr != nil {
+		return fmt.Errorf("%w; %w", err, appendErr)
+	}
+
+	return fmt.Errorf("moved %d audit logs to '%s': %w", len(lines), s.opts.RejectedPath, err)
+}
+
+// appendLines appends lines to the file at path, unless it would grow beyond the maximum buffer size.
+func (s *webhookSink) appendLines(path string, lines [][]byte) error {
+	var size int64
+	if info, err := os.Stat(path); err == nil {
+		size = info.Size()
+	}
+
+	var data bytes.Buffer
+	for _, l := range lines {
+		data.Write(l)
+		data.WriteByte('\n')
+	}
+
+	if size+int64(data.Len()) > s.opts.MaxBufferSize {
+		return fmt.Errorf("dropped %d audit logs: buffer '%s' is full", len(lines), path)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open buffer: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(data.Bytes()); err != nil {
+		return fmt.Errorf("failed to write buffer: %w", err)
+	}
+
+	return nil
+}
+
+// replayBuffer sends any logs held in the on-disk buffer and returns whether the buffer was emptied. Logs which still
+// cannot be delivered are written back, logs the endpoint rejects are moved to the rejected file.
+func (s *webhookSink) replayBuffer(ctx context.Context) (bool, error) {
+	if s.opts.BufferPath == "" {
+		return true, nil
+	}
+
+	lines, err := readLines(s.opts.BufferPath)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && len(lines) == 0) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read buffer: %w", err)
+	}
+
+	var rejectErrs []error
+	for start := 0; start < len(lines); start += s.opts.BatchSize {
+		end := min(start+s.opts.BatchSize, len(lines))
+
+		err := s.send(ctx, lines[start:end])
+		if errors.Is(err, errWebhookNonRetryable) {
+			rejectErrs = append(rejectErrs, s.reject(lines[start:end], err))
+			continue
+		}
+		if err != nil {
+			var remaining bytes.Buffer
+			for _, l := range lines[start:] {
+				remaining.Write(l)
+				remaining.WriteByte('\n')
+			}
+
+			if writeErr := os.WriteFile(s.opts.BufferPath, remaining.Bytes(), 0600); writeErr != nil {
+				return false, errors.Join(append(rejectErrs, fmt.Errorf("%w; failed to rewrite buffer: %w", err, writeErr))...)
+			}
+
+			return false, errors.Join(append(rejectErrs, err)...)
+		}
+	}
+
+	if err := os.Remove(s.opts.BufferPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, errors.Join(append(rejectErrs, fmt.Errorf("failed to clear buffer: %w", err))...)
+	}
+
+	return true, errors.Join(rejectErrs...)
+}
+
+func readLines(path string) ([][]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines [][]byte
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		lines = append(lines, bytes.Clone(scanner.Bytes()))
+	}
+
+	return lines, scanner.Err()
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
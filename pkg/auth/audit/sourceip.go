@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// sourceIP returns the IP address of the client which sent the request. When the request was received from a trusted
+// proxy, such as the ingress controller or load balancer in front of Rancher, the client is taken from the
+// X-Forwarded-For header, skipping any trusted proxies from the right, or else from the X-Real-IP header. Headers sent
+// by any other peer are ignored, since they can be set to anything by the client.
+func sourceIP(req *http.Request, trustedProxies []netip.Prefix) string {
+	peer, ok := parseAddr(req.RemoteAddr)
+	if !ok {
+		return ""
+	}
+	if !isTrustedProxy(peer, trustedProxies) {
+		return peer.String()
+	}
+
+	var forwardedFor []string
+	for _, header := range req.Header.Values("X-Forwarded-For") {
+		forwardedFor = append(forwardedFor, strings.Split(header, ",")...)
+	}
+	var client netip.Addr
+	for i := len(forwardedFor) - 1; i >= 0; i-- {
+		addr, ok := parseAddr(strings.TrimSpace(forwardedFor[i]))
+		if !ok {
+			break
+		}
+		client = addr
+		if !isTrustedProxy(addr, trustedProxies) {
+			break
+		}
+	}
+	if client.IsValid() {
+		return client.String()
+	}
+
+	if addr, ok := parseAddr(strings.TrimSpace(req.Header.Get("X-Real-IP"))); ok {
+		return addr.String()
+	}
+
+	return peer.String()
+}
+
+func isTrustedProxy(addr netip.Addr, trustedProxies []netip.Prefix) bool {
+	return slices.ContainsFunc(trustedProxies, func(p netip.Prefix) bool { return p.Contains(addr) })
+}
+
+// parseAddr parses an IP address with an optional port.
+func parseAddr(s string) (netip.Addr, bool) {
+	host := s
+	if h, _, err := net.SplitHostPort(s); err == nil {
+		host = h
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	return addr.Unmap(), true
+}
@@ -0,0 +1,103 @@
+package audit
+
+import (
+	"net/http"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceIP(t *testing.T) {
+	trustedProxies := []netip.Prefix{
+		netip.MustParsePrefix("10.42.0.0/16"),
+		netip.MustParsePrefix("172.16.0.1/32"),
+	}
+
+	cases := []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		expected   string
+	}{
+		{
+			name:       "direct request",
+			remoteAddr: "192.168.1.1:51234",
+			expected:   "192.168.1.1",
+		},
+		{
+			name:       "forwarded for by an untrusted peer",
+			remoteAddr: "192.168.1.1:51234",
+			header:     http.Header{"X-Forwarded-For": {"1.2.3.4"}},
+			expected:   "192.168.1.1",
+		},
+		{
+			name:       "forwarded for by a trusted proxy",
+			remoteAddr: "10.42.0.12:51234",
+			header:     http.Header{"X-Forwarded-For": {"1.2.3.4"}},
+			expected:   "1.2.3.4",
+		},
+		{
+			name:       "forwarded for through several trusted proxies",
+			remoteAddr: "10.42.0.12:51234",
+			header:     http.Header{"X-Forwarded-For": {"1.2.3.4, 172.16.0.1"}},
+			expected:   "1.2.3.4",
+		},
+		{
+			name:       "forwarded for with a spoofed client",
+			remoteAddr: "10.42.0.12:51234",
+			header:     http.Header{"X-Forwarded-For": {"5.6.7.8, 1.2.3.4"}},
+			expected:   "1.2.3.4",
+		},
+		{
+			name:       "forwarded for in several headers",
+			remoteAddr: "10.42.0.12:51234",
+			header:     http.Header{"X-Forwarded-For": {"5.6.7.8", "1.2.3.4"}},
+			expected:   "1.2.3.4",
+		},
+		{
+			name:       "forwarded for only through trusted proxies",
+			remoteAddr: "10.42.0.12:51234",
+			header:     http.Header{"X-Forwarded-For": {"172.16.0.1"}},
+			expected:   "172.16.0.1",
+		},
+		{
+			name:       "invalid forwarded for",
+			remoteAddr: "10.42.0.12:51234",
+			header:     http.Header{"X-Forwarded-For": {"unknown"}},
+			expected:   "10.42.0.12",
+		},
+		{
+			name:       "real ip from a trusted proxy",
+			remoteAddr: "10.42.0.12:51234",
+			header:     http.Header{"X-Real-Ip": {"1.2.3.4"}},
+			expected:   "1.2.3.4",
+		},
+		{
+			name:       "real ip from an untrusted peer",
+			remoteAddr: "192.168.1.1:51234",
+			header:     http.Header{"X-Real-Ip": {"1.2.3.4"}},
+			expected:   "192.168.1.1",
+		},
+		{
+			name:       "ipv6 peer",
+			remoteAddr: "[::ffff:192.168.1.1]:51234",
+			expected:   "192.168.1.1",
+		},
+		{
+			name:       "invalid peer",
+			remoteAddr: "pipe",
+			expected:   "",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := &http.Request{RemoteAddr: c.remoteAddr, Header: c.header}
+			if req.Header == nil {
+				req.Header = http.Header{}
+			}
+			assert.Equal(t, c.expected, sourceIP(req, trustedProxies))
+		})
+	}
+}
@@ -4,11 +4,16 @@
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net/netip"
+	"slices"
 	"sync"
+	"time"
 
 	auditlogv1 "github.com/rancher/rancher/pkg/apis/auditlog.cattle.io/v1"
+	"github.com/sirupsen/logrus"
 )
 
 var (
@@ -19,6 +24,13 @@ type Policy struct {
 	Filters   []*Filter
 	Redactors []Redactor
 	Verbosity auditlogv1.LogVerbosity
+
+	// Sinks are the names of the sinks this policy applies to, an empty list applies the policy to all sinks.
+	Sinks []string
+}
+
+func (p Policy) appliesToSink(name string) bool {
+	return len(p.Sinks) == 0 || slices.Contains(p.Sinks, name)
 }
 
 func (p Policy) actionForLog(log *log) auditlogv1.FilterAction {
@@ -40,6 +52,7 @@ func PolicyFromAuditPolicy(policy *auditlogv1.AuditPolicy) (Policy, error) {
 		Filters:   make([]*Filter, len(policy.Spec.Filters)),
 		Redactors: make([]Redactor, len(policy.Spec.AdditionalRedactions)),
 		Verbosity: policy.Spec.Verbosity,
+		Sinks:     policy.Spec.Sinks,
 	}
 
 	if newPolicy.Verbosity.Level != auditlogv1.LevelNull {
@@ -77,6 +90,30 @@ type WriterOptions struct {
 	DefaultPolicyLevel auditlogv1.Level
 
 	DisableDefaultPolicies bool
+
+	// Sinks are additional destinations for audit logs alongside the output given to NewWriter.
+	Sinks []Sink
+
+	// QueueSize is the number of logs which can wait to be written. When zero, logs are written synchronously by
+	// Write, otherwise they are processed and written to the sinks by background workers once Start is called.
+	QueueSize int
+
+	// Workers is the number of goroutines writing queued logs, defaults to 1. Logs may be written out of order when
+	// there is more than one worker.
+	Workers int
+
+	// OverflowPolicy determines what happens when a log is written while the queue is full, defaults to
+	// OverflowPolicyBlock.
+	OverflowPolicy OverflowPolicy
+
+	// EnableChain adds a sequence number and an HMAC linking each log to the previous one on the same sink, so that
+	// tampering can be detected with VerifyChain. Logs cannot be written until a key is given with SetChainKey.
+	EnableChain bool
+
+	// TrustedProxies are the IP addresses or CIDRs of the proxies in front of Rancher, such as the ingress controller
+	// or load balancer. The source IP of requests received from them is taken from the X-Forwarded-For or X-Real-IP
+	// headers instead of the peer address.
+	TrustedProxies []string
 }
 
 type Writer struct {
@@ -85,15 +122,55 @@ type Writer struct {
 	policiesMutex sync.RWMutex
 	policies      map[string]Policy
 
-	output io.Writer
+	sinks []Sink
+
+	trustedProxies []netip.Prefix
+
+	queue *queue
 }
 
+// NewWriter creates a Writer sending logs to output and any sinks in opts. If output is not nil it is registered as a
+// sink named DefaultSinkName.
 func NewWriter(output io.Writer, opts WriterOptions) (*Writer, error) {
+	var sinks []Sink
+	if output != nil {
+		sinks = append(sinks, NewWriterSink(DefaultSinkName, output))
+	}
+	sinks = append(sinks, opts.Sinks...)
+
+	if err := validateSinks(sinks); err != nil {
+		return nil, fmt.Errorf("invalid sinks: %w", err)
+	}
+
+	var trustedProxies []netip.Prefix
+	for _, proxy := range opts.TrustedProxies {
+		prefix, err := parsePrefix(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy '%s': %w", proxy, err)
+		}
+		trustedProxies = append(trustedProxies, prefix)
+	}
+
+	if opts.EnableChain {
+		for i, sink := range sinks {
+			sinks[i] = newChainSink(sink)
+		}
+	}
+
 	w := &Writer{
 		WriterOptions: opts,
 
-		policies: make(map[string]Policy),
-		output:   output,
+		policies:       make(map[string]Policy),
+		sinks:          sinks,
+		trustedProxies: trustedProxies,
+	}
+
+	if opts.QueueSize > 0 {
+		q, err := newQueue(opts.QueueSize, opts.OverflowPolicy)
+		if err != nil {
+			return nil, err
+		}
+		w.queue = q
 	}
 
 	if !opts.DisableDefaultPolicies {
@@ -107,7 +184,19 @@ func NewWriter(output io.Writer, opts WriterOptions) (*Writer, error) {
 	return w, nil
 }
 
+// Write writes the log to every sink which allows it. When the Writer has a running queue the log is only enqueued,
+// and any errors writing it are logged by the workers instead of returned.
 func (w *Writer) Write(log *log) error {
+	if w.queue != nil {
+		if queued, err := w.queue.push(log); queued {
+			return err
+		}
+	}
+
+	return w.write(log)
+}
+
+func (w *Writer) write(log *log) error {
 	redactors := []Redactor{}
 	if !w.DisableDefaultPolicies {
 		defaultMu.Lock()
@@ -115,53 +204,131 @@ func (w *Writer) Write(log *log) error {
 		defaultMu.Unlock()
 	}
 
-	verbosity := verbosityForLevel(w.DefaultPolicyLevel)
-	action := auditlogv1.FilterActionUnknown
+	// Filtering and verbosity are decided per sink, while redactions are merged across every policy allowing the log
+	// so that a log is never less redacted on one sink than on another.
+	actions := make([]auditlogv1.FilterAction, len(w.sinks))
+	verbosities := make([]auditlogv1.LogVerbosity, len(w.sinks))
+	for i := range verbosities {
+		verbosities[i] = verbosityForLevel(w.DefaultPolicyLevel)
+	}
 
 	w.policiesMutex.RLock()
 	for _, policy := range w.policies {
-		switch policy.actionForLog(log) {
-		case auditlogv1.FilterActionAllow:
+		action := policy.actionForLog(log)
+
+		if action == auditlogv1.FilterActionAllow {
 			redactors = append(redactors, policy.Redactors...)
-			verbosity = mergeLogVerbosities(verbosity, policy.Verbosity)
+		}
+
+		for i, sink := range w.sinks {
+			if !policy.appliesToSink(sink.Name()) {
+				continue
+			}
 
-			action = auditlogv1.FilterActionAllow
-		case auditlogv1.FilterActionDeny:
-			if action != auditlogv1.FilterActionAllow {
-				action = auditlogv1.FilterActionDeny
+			switch action {
+			case auditlogv1.FilterActionAllow:
+				actions[i] = auditlogv1.FilterActionAllow
+				verbosities[i] = mergeLogVerbosities(verbosities[i], policy.Verbosity)
+			case auditlogv1.FilterActionDeny:
+				if actions[i] != auditlogv1.FilterActionAllow {
+					actions[i] = auditlogv1.FilterActionDeny
+				}
 			}
 		}
 	}
 	w.policiesMutex.RUnlock()
 
-	if action == auditlogv1.FilterActionDeny {
-		return nil
+	// Sinks logging with the same verbosity share the encoded log.
+	var (
+		order   []auditlogv1.LogVerbosity
+		targets = map[auditlogv1.LogVerbosity][]Sink{}
+	)
+	for i, sink := range w.sinks {
+		if actions[i] == auditlogv1.FilterActionDeny {
+			continue
+		}
+		if _, ok := targets[verbosities[i]]; !ok {
+			order = append(order, verbosities[i])
+		}
+		targets[verbosities[i]] = append(targets[verbosities[i]], sink)
+	}
+
+	var errs []error
+	for _, verbosity := range order {
+		sinks := targets[verbosity]
+
+		data, err := encodeLog(log.clone(), verbosity, redactors)
+		if err != nil {
+			countFailed(sinks)
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, sink := range sinks {
+			start := time.Now()
+			_, err := sink.Write(data)
+			sinkLatency.WithLabelValues(sink.Name()).Observe(time.Since(start).Seconds())
+
+			if err != nil {
+				failedLogs.WithLabelValues(sink.Name()).Inc()
+				errs = append(errs, fmt.Errorf("failed to write log to sink '%s': %w", sink.Name(), err))
+			}
+		}
 	}
 
+	return errors.Join(errs...)
+}
+
+// encodeLog prepares the log for the verbosity, redacts it and encodes it as a single line of JSON.
+func encodeLog(log *log, verbosity auditlogv1.LogVerbosity, redactors []Redactor) ([]byte, error) {
 	log.prepare(verbosity)
 
 	for _, r := range redactors {
 		if err := r.Redact(log); err != nil {
-			return fmt.Errorf("failed to redact log: %w", err)
+			return nil, fmt.Errorf("failed to redact log: %w", err)
 		}
 	}
 
 	data, err := json.Marshal(log)
 	if err != nil {
-		return fmt.Errorf("failed to marshal log: %w", err)
+		return nil, fmt.Errorf("failed to marshal log: %w", err)
 	}
 
 	var buffer bytes.Buffer
 	if err := json.Compact(&buffer, data); err != nil {
-		return fmt.Errorf("failed to compact log: %w", err)
+		return nil, fmt.Errorf("failed to compact log: %w", err)
 	}
 	buffer.WriteByte('\n')
 
-	if _, err := w.output.Write(buffer.Bytes()); err != nil {
-		return fmt.Errorf("failed to write log: %w", err)
+	return buffer.Bytes(), nil
+}
+
+// SetChainKey sets the key used to chain logs on every sink. Changing the key is recorded in each chain.
+func (w *Writer) SetChainKey(id string, key []byte) error {
+	if !w.EnableChain {
+		return fmt.Errorf("audit log chaining is not enabled")
 	}
 
-	return nil
+	if id == "" || len(key) == 0 {
+		return fmt.Errorf("chain key and key id cannot be empty")
+	}
+
+	var errs []error
+	for _, sink := range w.sinks {
+		if cs, ok := sink.(*chainSink); ok {
+			if err := cs.setKey(id, key); err != nil {
+				errs = append(errs, fmt.Errorf("failed to set chain key for sink '%s': %w", sink.Name(), err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func countFailed(sinks []Sink) {
+	for _, sink := range sinks {
+		failedLogs.WithLabelValues(sink.Name()).Inc()
+	}
 }
 
 func (w *Writer) UpdatePolicy(policy *auditlogv1.AuditPolicy) error {
@@ -198,12 +365,43 @@ func (w *Writer) GetPolicy(name string) (Policy, bool) {
 	return p, ok
 }
 
+// Start runs any background work needed by the sinks and the queue workers. Once ctx is done, the queue is drained
+// and the sinks are closed.
 func (l *Writer) Start(ctx context.Context) {
 	if l == nil {
 		return
 	}
 
+	for _, sink := range l.sinks {
+		if s, ok := sink.(starter); ok {
+			s.Start(ctx)
+		}
+	}
+
+	if l.queue != nil {
+		l.queue.start(max(l.Workers, 1), func(log *log) {
+			if err := l.write(log); err != nil {
+				l.queue.errors.warn(err)
+			}
+		})
+	}
+
 	go func() {
 		<-ctx.Done()
+
+		if l.queue != nil {
+			l.queue.stop()
+		}
+
+		for _, sink := range l.sinks {
+			closer, ok := sink.(io.Closer)
+			if !ok {
+				continue
+			}
+
+			if err := closer.Close(); err != nil {
+				logrus.Warnf("Failed to close audit log sink '%s': %s", sink.Name(), err)
+			}
+		}
 	}()
 }
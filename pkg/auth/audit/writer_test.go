@@ -300,3 +300,97 @@ func TestCompressedZLib(t *testing.T) {
 
 	assert.Equal(t, expected, logs.logs)
 }
+
+func TestSinkPolicies(t *testing.T) {
+	local := &logWriter{logs: []log{}}
+	remote := &logWriter{logs: []log{}}
+
+	w, err := NewWriter(nil, WriterOptions{
+		DisableDefaultPolicies: true,
+		Sinks: []Sink{
+			NewWriterSink("local", local),
+			NewWriterSink("remote", remote),
+		},
+	})
+	assert.NoError(t, err)
+
+	err = w.UpdatePolicy(&auditlogv1.AuditPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "only-secrets-remotely",
+		},
+		Spec: auditlogv1.AuditPolicySpec{
+			Filters: []auditlogv1.Filter{
+				{
+					Action:     auditlogv1.FilterActionAllow,
+					RequestURI: ".*secrets.*",
+				},
+			},
+			Sinks: []string{"remote"},
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, w.Write(&log{RequestURI: "/api/v1/secrets"}))
+	assert.NoError(t, w.Write(&log{RequestURI: "/api/v1/configmaps"}))
+
+	assert.Equal(t, []log{{RequestURI: "/api/v1/secrets"}, {RequestURI: "/api/v1/configmaps"}}, local.logs)
+	assert.Equal(t, []log{{RequestURI: "/api/v1/secrets"}}, remote.logs)
+}
+
+func TestSinkVerbosity(t *testing.T) {
+	local := &logWriter{logs: []log{}}
+	remote := &logWriter{logs: []log{}}
+
+	w, err := NewWriter(nil, WriterOptions{
+		DefaultPolicyLevel:     auditlogv1.LevelNull,
+		DisableDefaultPolicies: true,
+		Sinks: []Sink{
+			NewWriterSink("local", local),
+			NewWriterSink("remote", remote),
+		},
+	})
+	assert.NoError(t, err)
+
+	err = w.UpdatePolicy(&auditlogv1.AuditPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "request-remotely",
+		},
+		Spec: auditlogv1.AuditPolicySpec{
+			Verbosity: auditlogv1.LogVerbosity{
+				Level: auditlogv1.LevelRequest,
+			},
+			Sinks: []string{"remote"},
+		},
+	})
+	assert.NoError(t, err)
+
+	headers := http.Header{"Content-Type": {contentTypeJSON}}
+	assert.NoError(t, w.Write(&log{
+		RequestURI:     "/api/v1/secrets",
+		RequestHeader:  headers,
+		rawRequestBody: []byte(`{"foo":"bar"}`),
+	}))
+
+	assert.Equal(t, []log{{RequestURI: "/api/v1/secrets"}}, local.logs)
+	assert.Equal(t, []log{{
+		RequestURI:    "/api/v1/secrets",
+		RequestHeader: headers,
+		RequestBody:   map[string]any{"foo": "bar"},
+	}}, remote.logs)
+}
+
+func TestDuplicateSinkNames(t *testing.T) {
+	_, err := NewWriter(&logWriter{}, WriterOptions{
+		Sinks: []Sink{
+			NewWriterSink(DefaultSinkName, &logWriter{}),
+		},
+	})
+	assert.Error(t, err)
+}
+
+func TestInvalidTrustedProxies(t *testing.T) {
+	_, err := NewWriter(&logWriter{}, WriterOptions{
+		TrustedProxies: []string{"10.42.0.0/16", "ingress"},
+	})
+	assert.Error(t, err)
+}
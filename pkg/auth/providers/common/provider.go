@@ -17,6 +17,8 @@
 	ExtraRequestTokenID = "requesttokenid"
 	// ExtraRequestHost is the key for the request host name in the UserInfo's extra attributes.
 	ExtraRequestHost = "requesthost"
+	// ExtraRequestTokenScoped is the key marking requests authenticated with a scoped token in the UserInfo's extra attributes.
+	ExtraRequestTokenScoped = "requesttokenscoped"
 
 	// UserPrincipalType is the user principal type across all providers.
 	UserPrincipalType = "user"
@@ -8,6 +8,7 @@
 	"github.com/mitchellh/mapstructure"
 	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
 )
 
 // Decode will decode to the output structure by creating a custom decoder
@@ -67,3 +68,9 @@ func SamePrincipal(me, other v3.Principal) bool {
 		me.PrincipalType == other.PrincipalType
 
 }
+
+// IsScopedTokenRequest reports whether the request of the given user was authenticated with a scoped token.
+// Scoped tokens can't be used to create other tokens or kubeconfigs.
+func IsScopedTokenRequest(userInfo user.Info) bool {
+	return len(userInfo.GetExtra()[ExtraRequestTokenScoped]) > 0
+}
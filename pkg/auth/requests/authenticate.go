@@ -130,6 +130,12 @@ func (a *tokenAuthenticator) Authenticate(req *http.Request) (*AuthenticatorResp
 	if cluster != "" && cluster != a.clusterRouter(req) {
 		return nil, errors.Wrapf(ErrMustAuthenticate, "clusterID does not match")
 	}
+	// The scope of the token is only enforced here, for every request it authenticates. Scoped requests are marked
+	// in the user info, so that they can't be used to create unscoped tokens or kubeconfigs.
+	scope := exttokenstore.ScopeOf(token)
+	if err := exttokenstore.CheckScope(scope, a.clusterRouter(req), req); err != nil {
+		return nil, errors.Wrapf(ErrMustAuthenticate, "%v", err)
+	}
 
 	// If the auth provider is specified make sure it exists and enabled.
 	if token.GetAuthProvider() != "" {
@@ -194,6 +200,9 @@ func (a *tokenAuthenticator) Authenticate(req *http.Request) (*AuthenticatorResp
 		common.ExtraRequestTokenID: {token.GetName()},
 		common.ExtraRequestHost:    {req.Host},
 	}
+	if scope != nil {
+		extras[common.ExtraRequestTokenScoped] = []string{"true"}
+	}
 	for key, value := range getUserExtraInfo(token, authUser, attribs) {
 		extras[key] = value
 	}
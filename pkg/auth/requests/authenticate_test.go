@@ -801,9 +801,26 @@ func TestTokenAuthenticatorAuthenticateExtToken(t *testing.T) {
 		assert.True(t, userRefresher.called)
 		assert.Equal(t, userID, userRefresher.userID)
 		assert.False(t, userRefresher.force)
+		assert.NotContains(t, resp.Extras, common.ExtraRequestTokenScoped)
 		require.NotEmpty(t, patchData)
 	})
 
+	t.Run("scoped token", func(t *testing.T) {
+		tokenSecret.Data[exttokenstore.FieldScope] = []byte(`{"readOnly":true}`)
+		defer delete(tokenSecret.Data, exttokenstore.FieldScope)
+		userRefresher.reset()
+
+		resp, err := authenticator.Authenticate(req)
+		require.NoError(t, err)
+		require.NotNil(t, resp)
+		assert.Equal(t, []string{"true"}, resp.Extras[common.ExtraRequestTokenScoped])
+
+		writeReq := httptest.NewRequest(http.MethodPost, "/v1/namespaces", nil)
+		writeReq.Header.Set("Authorization", "Bearer ext/"+token.Name+":"+tokenValue)
+		_, err = authenticator.Authenticate(writeReq)
+		require.ErrorIs(t, err, ErrMustAuthenticate)
+	})
+
 	t.Run("subsecond lastUsedAt updates are throttled", func(t *testing.T) {
 		oldTokenLastUsedAt := tokenSecret.Data["last-used-at"]
 		defer func() {
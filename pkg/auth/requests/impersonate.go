@@ -123,6 +123,13 @@ func (i *ImpersonatingAuth) ImpersonationMiddleware(next http.Handler) http.Hand
 					}
 				}
 				reqGroup = append(reqGroup, k8sUser.AllAuthenticated)
+				// A scoped token stays scoped when impersonating.
+				if common.IsScopedTokenRequest(userInfo) {
+					if reqExtras == nil {
+						reqExtras = map[string][]string{}
+					}
+					reqExtras[common.ExtraRequestTokenScoped] = []string{"true"}
+				}
 
 				userInfo := &k8sUser.DefaultInfo{
 					Name:   reqUser,
@@ -44,14 +44,17 @@
 
 const (
 	AddressAnnotation = "rke.cattle.io/address"
+	CanaryAnnotation  = "rke.cattle.io/canary"
 	ClusterNameLabel  = "rke.cattle.io/cluster-name"
 	// ClusterSpecAnnotation is used to define the cluster spec used to generate the rkecontrolplane object as an annotation on the object
 	ClusterSpecAnnotation                      = "rke.cattle.io/cluster-spec"
 	ControlPlaneRoleLabel                      = "rke.cattle.io/control-plane-role"
 	DrainAnnotation                            = "rke.cattle.io/drain-options"
+	DrainBlockedPodsAnnotation                 = "rke.cattle.io/drain-blocked-pods"
 	DrainDoneAnnotation                        = "rke.cattle.io/drain-done"
 	DrainErrorAnnotation                       = "rke.cattle.io/drain-error"
 	EtcdRoleLabel                              = "rke.cattle.io/etcd-role"
+	ETCDSnapshotPolicyLabel                    = "rke.cattle.io/etcd-snapshot-policy"
 	ForceRemoveEtcdAnnotation                  = "rke.cattle.io/etcd-force-remove"
 	HostnameLengthLimitAnnotation              = "rke.cattle.io/hostname-length-limit"
 	InitNodeLabel                              = "rke.cattle.io/init-node"
@@ -79,7 +82,11 @@
 	AuthorizedObjectAnnotation                 = "rke.cattle.io/object-authorized-for-clusters"
 	PlanUpdatedTimeAnnotation                  = "rke.cattle.io/plan-last-updated"
 	PlanProbesPassedAnnotation                 = "rke.cattle.io/plan-probes-passed"
+	PlanDryRunAnnotation                       = "rke.cattle.io/plan-dry-run"
+	MaintenanceWindowOverrideAnnotation        = "rke.cattle.io/maintenance-window-override"
 	DeleteMissingCustomMachinesAfterAnnotation = "rke.cattle.io/delete-missing-custom-machines-after"
+	UpgradeSnapshotRetryAnnotation             = "rke.cattle.io/upgrade-snapshot-retry"
+	UpgradeSnapshotSkipAnnotation              = "rke.cattle.io/upgrade-snapshot-skip"
 
 	SnapshotNameAnnotation = "etcdsnapshot.rke.io/snapshot-name"
 
@@ -112,6 +119,9 @@
 	InfrastructureReady          = condition.Cond(capi.InfrastructureReadyCondition)
 	SystemUpgradeControllerReady = condition.Cond("SystemUpgradeControllerReady")
 	Bootstrapped                 = condition.Cond("Bootstrapped")
+	MaintenanceWindow            = condition.Cond("MaintenanceWindow")
+	CanaryRollout                = condition.Cond("CanaryRollout")
+	Drained                      = condition.Cond("Drained")
 
 	RuntimeK3S  = "k3s"
 	RuntimeRKE2 = "rke2"
@@ -258,6 +268,13 @@ func GetDistroDataDir(controlPlane *rkev1.RKEControlPlane) string {
 	return fmt.Sprintf("/var/lib/rancher/%s", GetRuntime(controlPlane.Spec.KubernetesVersion))
 }
 
+// ETCDSnapshotPolicySnapshotName returns the base name of the etcd snapshots taken on the schedule of the etcd snapshot
+// policy. The hash of the policy name makes the prefix of the snapshot files of a policy unique, so that it isn't also the
+// prefix of the files of another policy whose name starts with the same characters.
+func ETCDSnapshotPolicySnapshotName(policyName string) string {
+	return fmt.Sprintf("etcd-policy-%s-%s", name.Hex(policyName, 8), policyName)
+}
+
 func GetProvisioningDataDir(spec *rkev1.ClusterConfiguration) string {
 	if spec.DataDirectories.Provisioning != "" {
 		return spec.DataDirectories.Provisioning
@@ -635,6 +652,12 @@ func ParseSnapshotClusterSpecOrError(snapshot *rkev1.ETCDSnapshot) (*provv1.Clus
 	return nil, fmt.Errorf("unable to find and decode snapshot ClusterSpec for snapshot")
 }
 
+// IsCrossClusterRestore returns true if the given etcd snapshot restore restores a snapshot taken from a cluster other
+// than the cluster with the given name.
+func IsCrossClusterRestore(restore *rkev1.ETCDSnapshotRestore, clusterName string) bool {
+	return restore != nil && restore.ClusterName != "" && restore.ClusterName != clusterName
+}
+
 func PreBootstrap(mgmtCluster *v3.Cluster) bool {
 	// if the upstream rancher _does not_ have pre-bootstrapping enabled just always return false.
 	if !features.ProvisioningPreBootstrap.Enabled() {
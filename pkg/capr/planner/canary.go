@@ -0,0 +1,260 @@
+package planner
+
+import (
+	"fmt"
+	"time"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/capr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const defaultCanaryUnhealthyThreshold = 10 * time.Minute
+
+type canaryState int
+
+const (
+	canaryDone canaryState = iota
+	canaryInProgress
+	canaryHalted
+)
+
+// canaryStage is the canary stage of the plan rollout in a tier.
+type canaryStage struct {
+	// canaries are the names of the machines which receive plan changes first.
+	canaries sets.Set[string]
+	// selected are the canaries selected by count in this reconcile, whose canary annotation must be persisted.
+	selected []*reconcilable
+	// complete indicates that no machine of the tier is waiting for a plan change, so the canaries can be released.
+	complete     bool
+	state        canaryState
+	message      string
+	requeueAfter time.Duration
+}
+
+// holds returns true if the plan change of the reconcilable must wait for the canaries of the tier.
+func (s *canaryStage) holds(r *reconcilable) bool {
+	return s != nil && s.state != canaryDone && !s.canaries.Has(r.entry.Machine.Name)
+}
+
+// halted returns true if the canaries of the tier did not become healthy, and the rollout is stopped.
+func (s *canaryStage) halted() bool {
+	return s != nil && s.state == canaryHalted
+}
+
+// newCanaryStage determines the canaries of the tier and whether they are healthy with their new plan for long enough
+// to deliver the plan changes to the remaining machines. Canaries which are selected by count are marked with the
+// canary annotation, which must be persisted with markCanaries so that the selection is stable until the rollout in
+// the tier is complete, even if the plan change of a canary is held by a maintenance window or the concurrency.
+func newCanaryStage(canary *rkev1.CanaryRollout, tierName string, reconcilables []*reconcilable, exclude roleFilter, now time.Time) (*canaryStage, error) {
+	var selector labels.Selector
+	if canary.MachineSelector != nil {
+		var err error
+		selector, err = metav1.LabelSelectorAsSelector(canary.MachineSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid canary machine selector: %w", err)
+		}
+	}
+
+	stage := &canaryStage{
+		canaries: sets.New[string](),
+		complete: true,
+	}
+	var candidates []*reconcilable
+	for _, r := range reconcilables {
+		if exclude(r.entry) || r.entry.Plan == nil {
+			continue
+		}
+		if r.change {
+			stage.complete = false
+		}
+		if selector != nil {
+			if selector.Matches(labels.Set(r.entry.Machine.Labels)) {
+				stage.canaries.Insert(r.entry.Machine.Name)
+			}
+		} else if r.entry.Metadata.Annotations[capr.CanaryAnnotation] != "" {
+			stage.canaries.Insert(r.entry.Machine.Name)
+		} else if r.change {
+			candidates = append(candidates, r)
+		}
+	}
+
+	if stage.complete {
+		return stage, nil
+	}
+
+	if selector == nil {
+		count := canary.Machines
+		if count <= 0 {
+			count = 1
+		}
+		for _, r := range candidates {
+			if stage.canaries.Len() >= count {
+				break
+			}
+			r.entry.Metadata.Annotations[capr.CanaryAnnotation] = tierName
+			stage.canaries.Insert(r.entry.Machine.Name)
+			stage.selected = append(stage.selected, r)
+		}
+	}
+
+	unhealthyThreshold := canary.UnhealthyThreshold.Duration
+	if unhealthyThreshold <= 0 {
+		unhealthyThreshold = defaultCanaryUnhealthyThreshold
+	}
+
+	var failed, unhealthy, pending, soaking []string
+	for _, r := range reconcilables {
+		if !stage.canaries.Has(r.entry.Machine.Name) {
+			continue
+		}
+		switch {
+		case r.change:
+			pending = append(pending, r.entry.Machine.Name)
+		case r.entry.Plan.Failed:
+			failed = append(failed, r.entry.Machine.Name)
+		case !r.entry.Plan.InSync || !r.entry.Plan.Healthy:
+			updated, err := time.Parse(time.RFC3339, r.entry.Metadata.Annotations[capr.PlanUpdatedTimeAnnotation])
+			if err == nil && now.Sub(updated) > unhealthyThreshold {
+				unhealthy = append(unhealthy, r.entry.Machine.Name)
+				continue
+			}
+			pending = append(pending, r.entry.Machine.Name)
+			if err == nil {
+				stage.requeue(updated.Add(unhealthyThreshold).Sub(now))
+			}
+		default:
+			passed, err := time.Parse(time.RFC3339, r.entry.Metadata.Annotations[capr.PlanProbesPassedAnnotation])
+			if err != nil {
+				// the probes passed, but the plansecret controller did not record it yet
+				pending = append(pending, r.entry.Machine.Name)
+				continue
+			}
+			if remaining := passed.Add(canary.SoakTime.Duration).Sub(now); remaining > 0 {
+				soaking = append(soaking, r.entry.Machine.Name)
+				stage.requeue(remaining)
+			}
+		}
+	}
+
+	switch {
+	case len(failed) > 0:
+		stage.state = canaryHalted
+		stage.message = fmt.Sprintf("canary rollout of %s tier halted: plan failed to apply on canary machine(s) %s", tierName, atMostThree(failed))
+	case len(unhealthy) > 0:
+		stage.state = canaryHalted
+		stage.message = fmt.Sprintf("canary rollout of %s tier halted: probes of canary machine(s) %s did not become healthy within %s", tierName, atMostThree(unhealthy), unhealthyThreshold)
+	case len(pending) > 0:
+		stage.state = canaryInProgress
+		stage.message = fmt.Sprintf("canary rollout of %s tier: waiting for canary machine(s) %s to become healthy", tierName, atMostThree(pending))
+	case len(soaking) > 0:
+		stage.state = canaryInProgress
+		stage.message = fmt.Sprintf("canary rollout of %s tier: canary machine(s) %s are healthy, soaking for %s", tierName, atMostThree(soaking), canary.SoakTime.Duration)
+	}
+
+	return stage, nil
+}
+
+func (s *canaryStage) requeue(after time.Duration) {
+	if s.requeueAfter == 0 || after < s.requeueAfter {
+		s.requeueAfter = after
+	}
+}
+
+// canaryTracker determines the canary stage of each tier reconciled during a full reconcile, and reports the least
+// advanced stage on the controlplane status.
+type canaryTracker struct {
+	canary       *rkev1.CanaryRollout
+	status       *rkev1.RKEControlPlaneStatus
+	now          time.Time
+	state        canaryState
+	enqueueAfter func(time.Duration)
+}
+
+// newCanaryTracker returns a tracker for the canary configuration of the controlplane, or nil if no canary is
+// configured.
+func (p *Planner) newCanaryTracker(controlPlane *rkev1.RKEControlPlane, status *rkev1.RKEControlPlaneStatus, now time.Time) *canaryTracker {
+	canary := controlPlane.Spec.UpgradeStrategy.Canary
+	if canary == nil && capr.CanaryRollout.GetStatus(status) == "" {
+		return nil
+	}
+
+	capr.CanaryRollout.True(status)
+	capr.CanaryRollout.Reason(status, "")
+	capr.CanaryRollout.Message(status, "")
+	if canary == nil {
+		return nil
+	}
+
+	return &canaryTracker{
+		canary: canary,
+		status: status,
+		now:    now,
+		enqueueAfter: func(after time.Duration) {
+			p.rkeControlPlanes.EnqueueAfter(controlPlane.Namespace, controlPlane.Name, after)
+		},
+	}
+}
+
+// stage returns the canary stage of the tier. A nil stage does not hold any plan change.
+func (c *canaryTracker) stage(tierName string, reconcilables []*reconcilable, exclude roleFilter) (*canaryStage, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	stage, err := newCanaryStage(c.canary, tierName, reconcilables, exclude, c.now)
+	if err != nil {
+		return nil, err
+	}
+
+	if stage.requeueAfter > 0 {
+		c.enqueueAfter(stage.requeueAfter)
+	}
+
+	if stage.state > c.state {
+		c.state = stage.state
+		if stage.halted() {
+			capr.CanaryRollout.False(c.status)
+			capr.CanaryRollout.Reason(c.status, "Halted")
+		} else {
+			capr.CanaryRollout.Unknown(c.status)
+			capr.CanaryRollout.Reason(c.status, "InProgress")
+		}
+		capr.CanaryRollout.Message(c.status, stage.message)
+	}
+
+	return stage, nil
+}
+
+// markCanaries persists the canary annotation of the canaries selected in this reconcile to their plan secrets, as
+// their plan changes may not be delivered in this reconcile.
+func (p *Planner) markCanaries(stage *canaryStage) error {
+	if stage == nil {
+		return nil
+	}
+	for _, r := range stage.selected {
+		if err := p.store.updatePlanSecretLabelsAndAnnotations(r.entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseCanaries removes the canary annotation from the machines of a tier once its rollout is complete.
+func (p *Planner) releaseCanaries(stage *canaryStage, reconcilables []*reconcilable) error {
+	if stage == nil || !stage.complete {
+		return nil
+	}
+	for _, r := range reconcilables {
+		if r.entry.Metadata.Annotations[capr.CanaryAnnotation] == "" {
+			continue
+		}
+		r.entry.Metadata.Annotations[capr.CanaryAnnotation] = ""
+		if err := p.store.updatePlanSecretLabelsAndAnnotations(r.entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
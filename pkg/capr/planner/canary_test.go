@@ -0,0 +1,235 @@
+package planner
+
+import (
+	"testing"
+	"time"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	"github.com/rancher/rancher/pkg/capr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+type canaryMachine struct {
+	name         string
+	labels       map[string]string
+	canary       bool
+	change       bool
+	failed       bool
+	healthy      bool
+	updated      time.Duration
+	probesPassed time.Duration
+}
+
+func canaryReconcilables(now time.Time, machines []canaryMachine) []*reconcilable {
+	var reconcilables []*reconcilable
+	for _, m := range machines {
+		annotations := map[string]string{
+			capr.PlanUpdatedTimeAnnotation: now.Add(-m.updated).Format(time.RFC3339),
+		}
+		if m.canary {
+			annotations[capr.CanaryAnnotation] = workerTier
+		}
+		if m.healthy && m.probesPassed > 0 {
+			annotations[capr.PlanProbesPassedAnnotation] = now.Add(-m.probesPassed).Format(time.RFC3339)
+		}
+		reconcilables = append(reconcilables, &reconcilable{
+			entry: &planEntry{
+				Machine: &capi.Machine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   m.name,
+						Labels: m.labels,
+					},
+				},
+				Plan: &plan.Node{
+					Failed:  m.failed,
+					InSync:  !m.change,
+					Healthy: m.healthy,
+				},
+				Metadata: &plan.Metadata{
+					Annotations: annotations,
+				},
+			},
+			change: m.change,
+		})
+	}
+	return reconcilables
+}
+
+func Test_newCanaryStage(t *testing.T) {
+	now := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		canary           rkev1.CanaryRollout
+		machines         []canaryMachine
+		wantCanaries     []string
+		wantSelected     []string
+		wantComplete     bool
+		wantState        canaryState
+		wantRequeueAfter time.Duration
+	}{
+		{
+			name:   "rollout complete",
+			canary: rkev1.CanaryRollout{},
+			machines: []canaryMachine{
+				{name: "a", canary: true, healthy: true, probesPassed: time.Hour},
+				{name: "b", healthy: true, probesPassed: time.Hour},
+			},
+			wantCanaries: []string{"a"},
+			wantComplete: true,
+		},
+		{
+			name:   "canaries selected by count",
+			canary: rkev1.CanaryRollout{Machines: 2},
+			machines: []canaryMachine{
+				{name: "a", change: true},
+				{name: "b", change: true},
+				{name: "c", change: true},
+			},
+			wantCanaries: []string{"a", "b"},
+			wantSelected: []string{"a", "b"},
+			wantState:    canaryInProgress,
+		},
+		{
+			name: "canaries selected by label",
+			canary: rkev1.CanaryRollout{
+				Machines: 2,
+				MachineSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"canary": "true"},
+				},
+			},
+			machines: []canaryMachine{
+				{name: "a", change: true},
+				{name: "b", change: true, labels: map[string]string{"canary": "true"}},
+			},
+			wantCanaries: []string{"b"},
+			wantState:    canaryInProgress,
+		},
+		{
+			name: "no machine selected by label",
+			canary: rkev1.CanaryRollout{
+				MachineSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"canary": "true"},
+				},
+			},
+			machines: []canaryMachine{
+				{name: "a", change: true},
+			},
+			wantCanaries: []string{},
+		},
+		{
+			name:   "canary waiting for probes",
+			canary: rkev1.CanaryRollout{},
+			machines: []canaryMachine{
+				{name: "a", canary: true, updated: 4 * time.Minute},
+				{name: "b", change: true},
+			},
+			wantCanaries:     []string{"a"},
+			wantState:        canaryInProgress,
+			wantRequeueAfter: 6 * time.Minute,
+		},
+		{
+			name:   "canary soaking",
+			canary: rkev1.CanaryRollout{SoakTime: metav1.Duration{Duration: 15 * time.Minute}},
+			machines: []canaryMachine{
+				{name: "a", canary: true, healthy: true, probesPassed: 5 * time.Minute},
+				{name: "b", change: true},
+			},
+			wantCanaries:     []string{"a"},
+			wantState:        canaryInProgress,
+			wantRequeueAfter: 10 * time.Minute,
+		},
+		{
+			name:   "canary soaked",
+			canary: rkev1.CanaryRollout{SoakTime: metav1.Duration{Duration: 15 * time.Minute}},
+			machines: []canaryMachine{
+				{name: "a", canary: true, healthy: true, probesPassed: 20 * time.Minute},
+				{name: "b", change: true},
+			},
+			wantCanaries: []string{"a"},
+		},
+		{
+			name:   "canary never healthy",
+			canary: rkev1.CanaryRollout{UnhealthyThreshold: metav1.Duration{Duration: 5 * time.Minute}},
+			machines: []canaryMachine{
+				{name: "a", canary: true, updated: 6 * time.Minute},
+				{name: "b", change: true},
+			},
+			wantCanaries: []string{"a"},
+			wantState:    canaryHalted,
+		},
+		{
+			name:   "canary failed",
+			canary: rkev1.CanaryRollout{},
+			machines: []canaryMachine{
+				{name: "a", canary: true, failed: true},
+				{name: "b", change: true},
+			},
+			wantCanaries: []string{"a"},
+			wantState:    canaryHalted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconcilables := canaryReconcilables(now, tt.machines)
+
+			stage, err := newCanaryStage(&tt.canary, workerTier, reconcilables, isDeleting, now)
+			assert.NoError(t, err)
+			assert.Equal(t, sets.New(tt.wantCanaries...), stage.canaries)
+			assert.Equal(t, tt.wantComplete, stage.complete)
+			assert.Equal(t, tt.wantState, stage.state)
+			assert.Equal(t, tt.wantRequeueAfter, stage.requeueAfter)
+			var selected []string
+			for _, r := range stage.selected {
+				selected = append(selected, r.entry.Machine.Name)
+			}
+			assert.Equal(t, tt.wantSelected, selected)
+
+			for _, r := range reconcilables {
+				if tt.canary.MachineSelector == nil && stage.canaries.Has(r.entry.Machine.Name) {
+					assert.Equal(t, workerTier, r.entry.Metadata.Annotations[capr.CanaryAnnotation])
+				}
+				assert.Equal(t, tt.wantState != canaryDone && !stage.canaries.Has(r.entry.Machine.Name), stage.holds(r))
+			}
+		})
+	}
+}
+
+func Test_markCanaries(t *testing.T) {
+	now := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	reconcilables := canaryReconcilables(now, []canaryMachine{
+		{name: "a", change: true},
+		{name: "b", change: true},
+	})
+	for _, r := range reconcilables {
+		r.entry.Machine.Namespace = "fleet-default"
+		r.entry.Machine.Spec.Bootstrap.ConfigRef = &corev1.ObjectReference{
+			Kind: "RKEBootstrap",
+			Name: r.entry.Machine.Name,
+		}
+	}
+
+	stage, err := newCanaryStage(&rkev1.CanaryRollout{}, workerTier, reconcilables, isDeleting, now)
+	require.NoError(t, err)
+
+	mp := newMockPlanner(t, InfoFunctions{})
+	mp.secretClient.EXPECT().Get("fleet-default", capr.PlanSecretFromBootstrapName("a"), metav1.GetOptions{}).Return(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: capr.PlanSecretFromBootstrapName("a")},
+		Type:       capr.SecretTypeMachinePlan,
+	}, nil)
+	mp.secretClient.EXPECT().Update(gomock.Any()).DoAndReturn(func(secret *corev1.Secret) (*corev1.Secret, error) {
+		assert.Equal(t, workerTier, secret.Annotations[capr.CanaryAnnotation])
+		return secret, nil
+	})
+
+	// the canary annotation is persisted even though the plan change of the canary is not delivered yet
+	require.NoError(t, mp.planner.markCanaries(stage))
+}
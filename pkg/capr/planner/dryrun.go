@@ -0,0 +1,177 @@
+package planner
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	"github.com/rancher/rancher/pkg/capr"
+	"github.com/rancher/rancher/pkg/controllers/capr/managesystemagent"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// isPlanDryRun returns true if a dry-run of the plans was requested for the controlplane.
+func isPlanDryRun(cp *rkev1.RKEControlPlane) bool {
+	_, ok := cp.Annotations[capr.PlanDryRunAnnotation]
+	return ok
+}
+
+// planDryRun renders the desired plan of every machine which is not deleting and summarizes how it differs from the
+// plan currently delivered to the machine. The summaries are published in the returned status. Only the initial plans
+// of new machines are delivered during a dry-run, the machines keep their current plans for as long as it is requested.
+func (p *Planner) planDryRun(cp *rkev1.RKEControlPlane, status rkev1.RKEControlPlaneStatus, clusterSecretTokens plan.Secret, clusterPlan *plan.Plan) (rkev1.RKEControlPlaneStatus, error) {
+	// The init node is not (re-)elected during a dry-run, as it would require updating the machines.
+	var joinServer string
+	for _, entry := range collect(clusterPlan, isInitNode) {
+		joinServer = entry.Metadata.Annotations[capr.JoinURLAnnotation]
+	}
+	if joinServer == "" {
+		return status, errWaiting("plan dry-run: waiting for join url to be available on bootstrap node")
+	}
+
+	// Windows plans are delivered with the same setting in fullReconcile, so it must not be reported as a change.
+	resetFailureCountOnRestart := managesystemagent.CurrentVersionResolvesGH5551(cp.Spec.KubernetesVersion)
+
+	dryRun := &rkev1.PlanDryRun{
+		ObservedGeneration: cp.Generation,
+	}
+	for _, entry := range collect(clusterPlan, isNotDeleting) {
+		forcedJoinURL := joinServer
+		if isInitNode(entry) || isOnlyWorker(entry) {
+			forcedJoinURL = ""
+		}
+
+		joinURL, err := determineJoinURL(cp, entry, clusterPlan, forcedJoinURL)
+		if err != nil {
+			return status, err
+		}
+
+		desiredPlan, _, err := p.desiredPlan(cp, clusterSecretTokens, entry, joinURL)
+		if err != nil {
+			return status, err
+		}
+		desiredPlan.ResetFailureCountOnSystemAgentRestart = isOnlyWindowsWorker(entry) && resetFailureCountOnRestart
+
+		if change := p.planChange(entry, desiredPlan); change != nil {
+			dryRun.Machines = append(dryRun.Machines, *change)
+		}
+	}
+
+	status.PlanDryRun = dryRun
+	return status, nil
+}
+
+// heldPlanChanges returns the number of machines whose plan change is held by the dry-run. Initial plans are not held.
+func heldPlanChanges(dryRun *rkev1.PlanDryRun) int {
+	held := 0
+	for _, change := range dryRun.Machines {
+		if !change.Initial {
+			held++
+		}
+	}
+	return held
+}
+
+// planChange summarizes how the plan of the entry would change if the desired plan was delivered. It returns nil if the
+// plan would not change.
+func (p *Planner) planChange(entry *planEntry, desiredPlan plan.NodePlan) *rkev1.MachinePlanChange {
+	change := &rkev1.MachinePlanChange{
+		MachineName: entry.Machine.Name,
+	}
+
+	if entry.Plan == nil {
+		change.Initial = true
+		return change
+	}
+
+	currentPlan := entry.Plan.Plan
+	if p.equalities.DeepEqual(currentPlan, desiredPlan) {
+		return nil
+	}
+
+	change.Files = changedFiles(currentPlan.Files, desiredPlan.Files)
+	change.Args = changedArgs(currentPlan.Files, desiredPlan.Files)
+	change.InstructionsChanged = !equality.Semantic.DeepEqual(currentPlan.Instructions, desiredPlan.Instructions) ||
+		!equality.Semantic.DeepEqual(currentPlan.PeriodicInstructions, desiredPlan.PeriodicInstructions) ||
+		!equality.Semantic.DeepEqual(currentPlan.Probes, desiredPlan.Probes)
+	// the drain decision is based on the applied plan, as it is in reconcile
+	change.Drain = shouldDrain(entry.Plan.AppliedPlan, desiredPlan)
+	change.Minor = minorPlanChangeDetected(currentPlan, desiredPlan)
+
+	return change
+}
+
+// changedFiles returns the sorted paths of the files which are added, removed or whose content or permissions change.
+func changedFiles(current, desired []plan.File) []string {
+	currentFiles := make(map[string]plan.File, len(current))
+	for _, file := range current {
+		currentFiles[file.Path] = file
+	}
+
+	changed := sets.New[string]()
+	for _, file := range desired {
+		currentFile, ok := currentFiles[file.Path]
+		if !ok || currentFile.Content != file.Content || currentFile.Permissions != file.Permissions {
+			changed.Insert(file.Path)
+		}
+		delete(currentFiles, file.Path)
+	}
+	for path := range currentFiles {
+		changed.Insert(path)
+	}
+
+	return sets.List(changed)
+}
+
+// changedArgs returns the sorted names of the arguments in the distribution configuration file which are added,
+// removed or changed.
+func changedArgs(current, desired []plan.File) []string {
+	currentConfig := distroConfig(current)
+	desiredConfig := distroConfig(desired)
+
+	var changed []string
+	for k, v := range desiredConfig {
+		if currentValue, ok := currentConfig[k]; !ok || !reflect.DeepEqual(currentValue, v) {
+			changed = append(changed, k)
+		}
+	}
+	for k := range currentConfig {
+		if _, ok := desiredConfig[k]; !ok {
+			changed = append(changed, k)
+		}
+	}
+
+	sort.Strings(changed)
+	return changed
+}
+
+// distroConfig returns the content of the distribution configuration file rendered by the planner, or nil if the
+// files do not contain it.
+func distroConfig(files []plan.File) map[string]interface{} {
+	configFirstHalf, configSecondHalf, found := strings.Cut(ConfigYamlFileName, "%s")
+	if !found {
+		return nil
+	}
+
+	for _, file := range files {
+		if !strings.HasPrefix(file.Path, configFirstHalf) || !strings.HasSuffix(file.Path, configSecondHalf) {
+			continue
+		}
+		data, err := base64.StdEncoding.DecodeString(file.Content)
+		if err != nil {
+			return nil
+		}
+		config := map[string]interface{}{}
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil
+		}
+		return config
+	}
+
+	return nil
+}
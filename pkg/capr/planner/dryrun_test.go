@@ -0,0 +1,151 @@
+package planner
+
+import (
+	"encoding/base64"
+	"testing"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+func dryRunFile(path, content string) plan.File {
+	return plan.File{
+		Path:    path,
+		Content: base64.StdEncoding.EncodeToString([]byte(content)),
+	}
+}
+
+func dryRunInstall(drainHash string) plan.OneTimeInstruction {
+	return plan.OneTimeInstruction{
+		Name: "install",
+		Env:  []string{"RESTART_STAMP=" + drainHash, "DRAIN_HASH=" + drainHash},
+	}
+}
+
+func Test_planChange(t *testing.T) {
+	const configPath = "/etc/rancher/rke2/config.yaml.d/50-rancher.yaml"
+
+	currentPlan := plan.NodePlan{
+		Files: []plan.File{
+			dryRunFile(configPath, `{"kubelet-arg":["max-pods=110"],"node-label":["a=b"],"token":"secret"}`),
+			dryRunFile("/etc/rancher/rke2/registries.yaml", "mirrors: {}"),
+		},
+		Instructions: []plan.OneTimeInstruction{dryRunInstall("1")},
+	}
+
+	tests := []struct {
+		name        string
+		plan        *plan.Node
+		desiredPlan plan.NodePlan
+		want        *rkev1.MachinePlanChange
+	}{
+		{
+			name: "initial plan",
+			desiredPlan: plan.NodePlan{
+				Files: []plan.File{dryRunFile(configPath, `{}`)},
+			},
+			want: &rkev1.MachinePlanChange{
+				MachineName: "machine",
+				Initial:     true,
+			},
+		},
+		{
+			name: "no change",
+			plan: &plan.Node{
+				Plan:        currentPlan,
+				AppliedPlan: &currentPlan,
+			},
+			desiredPlan: currentPlan,
+		},
+		{
+			name: "args change",
+			plan: &plan.Node{
+				Plan:        currentPlan,
+				AppliedPlan: &currentPlan,
+			},
+			desiredPlan: plan.NodePlan{
+				Files: []plan.File{
+					dryRunFile(configPath, `{"kubelet-arg":["max-pods=250"],"token":"secret","tls-san":["rancher"]}`),
+					dryRunFile("/etc/rancher/rke2/registries.yaml", "mirrors: {}"),
+				},
+				Instructions: []plan.OneTimeInstruction{dryRunInstall("2")},
+			},
+			want: &rkev1.MachinePlanChange{
+				MachineName:         "machine",
+				Files:               []string{configPath},
+				Args:                []string{"kubelet-arg", "node-label", "tls-san"},
+				InstructionsChanged: true,
+				Drain:               true,
+			},
+		},
+		{
+			name: "minor file change",
+			plan: &plan.Node{
+				Plan:        currentPlan,
+				AppliedPlan: &currentPlan,
+			},
+			desiredPlan: plan.NodePlan{
+				Files: append([]plan.File{
+					{Path: "/var/lib/rancher/idempotence.sh", Content: "c2NyaXB0", Minor: true},
+				}, currentPlan.Files...),
+				Instructions: []plan.OneTimeInstruction{dryRunInstall("1")},
+			},
+			want: &rkev1.MachinePlanChange{
+				MachineName: "machine",
+				Files:       []string{"/var/lib/rancher/idempotence.sh"},
+				Minor:       true,
+			},
+		},
+		{
+			name: "file removed",
+			plan: &plan.Node{
+				Plan:        currentPlan,
+				AppliedPlan: &currentPlan,
+			},
+			desiredPlan: plan.NodePlan{
+				Files:        currentPlan.Files[:1],
+				Instructions: []plan.OneTimeInstruction{dryRunInstall("1")},
+			},
+			want: &rkev1.MachinePlanChange{
+				MachineName: "machine",
+				Files:       []string{"/etc/rancher/rke2/registries.yaml"},
+			},
+		},
+	}
+
+	p := &Planner{
+		equalities: equality.Semantic.Copy(),
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := &planEntry{
+				Machine: &capi.Machine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "machine",
+					},
+				},
+				Plan: tt.plan,
+			}
+
+			assert.Equal(t, tt.want, p.planChange(entry, tt.desiredPlan))
+		})
+	}
+}
+
+func Test_heldPlanChanges(t *testing.T) {
+	dryRun := &rkev1.PlanDryRun{
+		Machines: []rkev1.MachinePlanChange{
+			{MachineName: "new", Initial: true},
+			{MachineName: "upgraded", Drain: true},
+			{MachineName: "reconfigured", Files: []string{"/etc/rancher/rke2/registries.yaml"}},
+		},
+	}
+
+	assert.Equal(t, 2, heldPlanChanges(dryRun))
+	assert.Equal(t, 0, heldPlanChanges(&rkev1.PlanDryRun{}))
+}
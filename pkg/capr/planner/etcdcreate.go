@@ -37,7 +37,10 @@ func (p *Planner) startOrRestartEtcdSnapshotCreate(status rkev1.RKEControlPlaneS
 	return status, nil
 }
 
-func (p *Planner) runEtcdSnapshotCreate(controlPlane *rkev1.RKEControlPlane, tokensSecret plan.Secret, clusterPlan *plan.Plan, joinServer string) []error {
+// runEtcdSnapshotCreate delivers the etcd snapshot create plan to all etcd nodes. If snapshotName is not empty, it is used
+// as the base name of the snapshots instead of the default of the distribution. The snapshots are also copied to the
+// additional S3 targets of the given etcd snapshot policies, which requested the snapshot.
+func (p *Planner) runEtcdSnapshotCreate(controlPlane *rkev1.RKEControlPlane, tokensSecret plan.Secret, clusterPlan *plan.Plan, joinServer, snapshotName string, policies []*rkev1.ETCDSnapshotPolicy) []error {
 	servers := collect(clusterPlan, isEtcd)
 	if len(servers) == 0 {
 		return []error{errors.New("failed to find node to perform etcd snapshot")}
@@ -46,7 +49,7 @@ func (p *Planner) runEtcdSnapshotCreate(controlPlane *rkev1.RKEControlPlane, tok
 	var errs []error
 
 	for _, server := range servers {
-		createPlan, joinedServer, err := p.generateEtcdSnapshotCreatePlan(controlPlane, tokensSecret, server, joinServer)
+		createPlan, joinedServer, err := p.generateEtcdSnapshotCreatePlan(controlPlane, tokensSecret, server, joinServer, snapshotName, policies)
 		if err != nil {
 			return []error{err}
 		}
@@ -65,7 +68,7 @@ func (p *Planner) runEtcdSnapshotCreate(controlPlane *rkev1.RKEControlPlane, tok
 // Notably, this function will blatantly ignore drain and concurrency options, as during an etcd snapshot operation, there is no necessity to drain nodes.
 func (p *Planner) runEtcdSnapshotManagementServiceStart(controlPlane *rkev1.RKEControlPlane, tokensSecret plan.Secret, clusterPlan *plan.Plan, include roleFilter, operation string) error {
 	// Generate and deliver desired plan for the bootstrap/init node first.
-	if err := p.reconcile(controlPlane, tokensSecret, clusterPlan, true, bootstrapTier, isEtcd, isNotInitNodeOrIsDeleting, "1", "", controlPlane.Spec.UpgradeStrategy.ControlPlaneDrainOptions, -1, 1, false); err != nil {
+	if err := p.reconcile(controlPlane, tokensSecret, clusterPlan, true, bootstrapTier, isEtcd, isNotInitNodeOrIsDeleting, "1", "", controlPlane.Spec.UpgradeStrategy.ControlPlaneDrainOptions, -1, 1, false, rolloutControls{}); err != nil {
 		return err
 	}
 
@@ -94,7 +97,7 @@ func (p *Planner) runEtcdSnapshotManagementServiceStart(controlPlane *rkev1.RKEC
 }
 
 // generateEtcdSnapshotCreatePlan generates a plan that contains an instruction to create an etcd snapshot.
-func (p *Planner) generateEtcdSnapshotCreatePlan(controlPlane *rkev1.RKEControlPlane, tokensSecret plan.Secret, entry *planEntry, joinServer string) (plan.NodePlan, string, error) {
+func (p *Planner) generateEtcdSnapshotCreatePlan(controlPlane *rkev1.RKEControlPlane, tokensSecret plan.Secret, entry *planEntry, joinServer, snapshotName string, policies []*rkev1.ETCDSnapshotPolicy) (plan.NodePlan, string, error) {
 	v, err := semver.NewVersion(controlPlane.Spec.KubernetesVersion)
 	if err != nil {
 		return plan.NodePlan{}, "", err
@@ -109,14 +112,29 @@ func (p *Planner) generateEtcdSnapshotCreatePlan(controlPlane *rkev1.RKEControlP
 		args = append(args, "save")
 	}
 
+	if snapshotName != "" {
+		args = append(args, "--name", snapshotName)
+	}
+
 	createPlan, _, joinedServer, err := p.generatePlanWithConfigFiles(controlPlane, tokensSecret, entry, joinServer, true)
+	if err != nil {
+		return createPlan, joinedServer, err
+	}
 	createPlan.Instructions = append(createPlan.Instructions, p.generateInstallInstructionWithSkipStart(controlPlane, entry),
 		plan.OneTimeInstruction{
 			Name:    "create",
 			Command: capr.GetRuntimeCommand(controlPlane.Spec.KubernetesVersion),
 			Args:    args,
 		})
-	return createPlan, joinedServer, err
+
+	// snapshots requested by etcd snapshot policies are also copied to their additional S3 targets
+	instructions, files, err := p.policyS3TargetInstructions(controlPlane, snapshotName, policies)
+	if err != nil {
+		return createPlan, joinedServer, err
+	}
+	createPlan.Instructions = append(createPlan.Instructions, instructions...)
+	createPlan.Files = append(createPlan.Files, files...)
+	return createPlan, joinedServer, nil
 }
 
 func (p *Planner) createEtcdSnapshot(controlPlane *rkev1.RKEControlPlane, status rkev1.RKEControlPlaneStatus, tokensSecret plan.Secret, clusterPlan *plan.Plan) (rkev1.RKEControlPlaneStatus, error) {
@@ -151,7 +169,7 @@ func (p *Planner) createEtcdSnapshot(controlPlane *rkev1.RKEControlPlane, status
 			logrus.Warnf("[planner] rkecluster %s/%s: skipping etcd snapshot creation as cluster does not have an init node", controlPlane.Namespace, controlPlane.Name)
 			return status, nil
 		}
-		if errs := p.runEtcdSnapshotCreate(controlPlane, tokensSecret, clusterPlan, joinServer); len(errs) > 0 {
+		if errs := p.runEtcdSnapshotCreate(controlPlane, tokensSecret, clusterPlan, joinServer, snapshot.Name, nil); len(errs) > 0 {
 			for _, err := range errs {
 				if err == nil {
 					continue
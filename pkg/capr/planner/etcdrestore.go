@@ -14,6 +14,7 @@
 	"github.com/rancher/rancher/pkg/controllers/capr/managesystemagent"
 	"github.com/rancher/rancher/pkg/utils"
 	"github.com/rancher/wrangler/v3/pkg/name"
+	"github.com/rancher/wrangler/v3/pkg/randomtoken"
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -23,6 +24,11 @@
 const (
 	etcdRestoreBinPrefix = "capr/etcd-restore/bin"
 
+	// rotatedServerTokenKey and rotatedAgentTokenKey are the keys of the RKE state secret holding the tokens that
+	// replace the tokens adopted from the cluster an etcd snapshot was taken from, until they are rotated.
+	rotatedServerTokenKey = "rotatedServerToken"
+	rotatedAgentTokenKey  = "rotatedAgentToken"
+
 	etcdRestorePostRestoreWaitForPodListCleanupPath   = "wait_for_pod_list.sh"
 	etcdRestorePostRestoreWaitForPodListCleanupScript = `
 #!/bin/sh
@@ -99,6 +105,89 @@
 
 rm "$MACHINEIDSFILE"
 rm "$NODENAMESFILE"
+`
+	etcdRestoreCloneCleanUpPath   = "clean_up_clone.sh"
+	etcdRestoreCloneCleanUpScript = `
+#!/bin/sh
+
+if [ -z "$KUBECTL" ]; then
+        echo "Must define KUBECTL environment variable"
+        exit 1
+fi
+
+if [ -z "$KUBECONFIG" ]; then
+        echo "Must define KUBECONFIG environment variable"
+        exit 1
+fi
+
+# The credentials the fleet agent and the Rancher agents use to connect to Rancher were restored from the source
+# cluster, remove them so that no agent can act on behalf of the source cluster.
+if ! ${KUBECTL} delete secret -n cattle-fleet-system fleet-agent fleet-agent-bootstrap --ignore-not-found --wait=false; then
+        echo "Error deleting fleet agent registration secrets"
+        exit 1
+fi
+
+TMPSECRETS=$(mktemp)
+
+if ! ${KUBECTL} get secrets -n cattle-system --no-headers -o=custom-columns=NAME:.metadata.name > "$TMPSECRETS"; then
+        echo "Error listing cattle-system secrets"
+        exit 1
+fi
+
+while read -r NAME; do
+        case "${NAME}" in
+        cattle-credentials-*|stv-aggregation*)
+                echo "Deleting secret cattle-system/${NAME}"
+                if ! ${KUBECTL} delete secret -n cattle-system "${NAME}" --ignore-not-found --wait=false; then
+                        echo "Error deleting secret cattle-system/${NAME}"
+                        exit 1
+                fi
+                ;;
+        esac
+done < "$TMPSECRETS"
+rm "$TMPSECRETS"
+
+# Bootstrap tokens of the source cluster must not allow joining this cluster.
+if ! ${KUBECTL} delete secret -n kube-system --field-selector type=bootstrap.kubernetes.io/token --ignore-not-found --wait=false; then
+        echo "Error deleting bootstrap token secrets"
+        exit 1
+fi
+
+# Rewrite the server URL, CA checksum and credentials the cluster agent connects to Rancher with to the ones of this
+# cluster, without waiting for the manifest to be redeployed.
+if [ -f "$CLUSTER_AGENT_MANIFEST" ]; then
+        if ! ${KUBECTL} apply -f "$CLUSTER_AGENT_MANIFEST"; then
+                echo "Error applying cluster agent manifest"
+                exit 1
+        fi
+fi
+
+if [ "$SCRUB_SECRETS" != "true" ]; then
+        exit 0
+fi
+
+TMPSECRETS=$(mktemp)
+
+if ! ${KUBECTL} get secrets --all-namespaces --no-headers -o=jsonpath='{range .items[*]}{.metadata.namespace}{" "}{.metadata.name}{" "}{.type}{"\n"}{end}' > "$TMPSECRETS"; then
+        echo "Error listing all secrets"
+        exit 1
+fi
+
+while IFS=' ' read -r NAMESPACE NAME TYPE; do
+        case "${NAMESPACE}" in
+        ""|kube-*|cattle-*|*-system|tigera-operator)
+                continue
+                ;;
+        esac
+        case "${TYPE}" in
+        kubernetes.io/service-account-token|bootstrap.kubernetes.io/token|helm.sh/release.v1)
+                continue
+                ;;
+        esac
+        echo "Deleting secret ${NAMESPACE}/${NAME}"
+        ${KUBECTL} delete secret -n "${NAMESPACE}" "${NAME}" --wait=false
+done < "$TMPSECRETS"
+rm "$TMPSECRETS"
 `
 	etcdRestoreNodeWaitForReadyPath   = "wait_for_ready.sh"
 	etcdRestoreNodeWaitForReadyScript = `
@@ -298,6 +387,18 @@ func (p *Planner) runEtcdSnapshotPostRestoreNodeCleanupPlan(controlPlane *rkev1.
 	cleanupScriptFiles, cleanupInstructions := p.generateEtcdRestoreNodeCleanupFilesAndInstruction(controlPlane, allMachineUIDs, allNodeNames)
 	initNodePlan.Files = append(initNodePlan.Files, cleanupScriptFiles...)
 	initNodePlan.Instructions = append(initNodePlan.Instructions, cleanupInstructions...)
+	if capr.IsCrossClusterRestore(controlPlane.Spec.ETCDSnapshotRestore, controlPlane.Spec.ClusterName) {
+		cloneCleanupFiles, cloneCleanupInstructions := generateEtcdRestoreCloneCleanupFilesAndInstruction(controlPlane)
+		initNodePlan.Files = append(initNodePlan.Files, cloneCleanupFiles...)
+		initNodePlan.Instructions = append(initNodePlan.Instructions, cloneCleanupInstructions...)
+		if !controlPlane.Spec.UnmanagedConfig {
+			newServerToken, err := p.ensureEtcdRestoreRotatedTokens(controlPlane)
+			if err != nil {
+				return err
+			}
+			initNodePlan.Instructions = append(initNodePlan.Instructions, generateEtcdRestoreTokenRotationInstruction(controlPlane, tokensSecret, newServerToken))
+		}
+	}
 	return assignAndCheckPlan(p.store, ETCDRestoreMessage, initNode, initNodePlan, "", 5, 5)
 }
 
@@ -589,6 +690,38 @@ func (p *Planner) generateEtcdRestoreNodeCleanupFilesAndInstruction(controlPlane
 	}, instructions
 }
 
+// generateEtcdRestoreCloneCleanupFilesAndInstruction generates a file that contains a script and a slice of instructions
+// that remove the state restored from a snapshot of another cluster which must not be shared with that cluster.
+func generateEtcdRestoreCloneCleanupFilesAndInstruction(controlPlane *rkev1.RKEControlPlane) ([]plan.File, []plan.OneTimeInstruction) {
+	kubectl, kubeconfig := capr.GetKubectlAndKubeconfigPaths(controlPlane)
+	if kubectl == "" || kubeconfig == "" {
+		return nil, nil
+	}
+
+	instructions := []plan.OneTimeInstruction{
+		idempotentInstruction(
+			controlPlane,
+			"etcd-restore/cleanup-clone",
+			fmt.Sprintf("%v", controlPlane.Status.ETCDSnapshotRestore),
+			"/bin/sh",
+			[]string{etcdRestoreScriptPath(controlPlane, etcdRestoreCloneCleanUpPath)},
+			[]string{
+				fmt.Sprintf("%s=%s", "KUBECTL", kubectl),
+				fmt.Sprintf("%s=%s", "KUBECONFIG", kubeconfig),
+				fmt.Sprintf("%s=%t", "SCRUB_SECRETS", controlPlane.Spec.ETCDSnapshotRestore.ScrubSecrets),
+				fmt.Sprintf("%s=%s", "CLUSTER_AGENT_MANIFEST", path.Join(capr.GetDistroDataDir(controlPlane), "server/manifests/rancher/cluster-agent.yaml")),
+			}),
+	}
+
+	return []plan.File{
+		{
+			Content: base64.StdEncoding.EncodeToString([]byte(etcdRestoreCloneCleanUpScript)),
+			Path:    etcdRestoreScriptPath(controlPlane, etcdRestoreCloneCleanUpPath),
+			Dynamic: true,
+		},
+	}, instructions
+}
+
 func generateRemoveTLSAndCredDirInstructions(controlPlane *rkev1.RKEControlPlane) []plan.OneTimeInstruction {
 	return []plan.OneTimeInstruction{
 		{
@@ -720,6 +853,147 @@ func (p *Planner) retrieveEtcdSnapshot(controlPlane *rkev1.RKEControlPlane) (*rk
 	return snapshot, err
 }
 
+// validateEtcdSnapshotCluster ensures that an etcd snapshot taken from another cluster is only restored if the restore
+// explicitly names that cluster. Local snapshots of another cluster are stored on machines that do not belong to the
+// given controlplane, so only S3 snapshots can be restored across clusters.
+func validateEtcdSnapshotCluster(controlPlane *rkev1.RKEControlPlane, snapshot *rkev1.ETCDSnapshot) error {
+	restore := controlPlane.Spec.ETCDSnapshotRestore
+	crossCluster := capr.IsCrossClusterRestore(restore, controlPlane.Spec.ClusterName)
+	if snapshot == nil {
+		if crossCluster {
+			return fmt.Errorf("etcd snapshot %s/%s of cluster %s was not found", controlPlane.Namespace, restore.Name, restore.ClusterName)
+		}
+		return nil
+	}
+	switch {
+	case !crossCluster && snapshot.Spec.ClusterName != "" && snapshot.Spec.ClusterName != controlPlane.Spec.ClusterName:
+		return fmt.Errorf("etcd snapshot %s/%s was taken from cluster %s, the cluster name must be set on the restore to restore it into cluster %s", snapshot.Namespace, snapshot.Name, snapshot.Spec.ClusterName, controlPlane.Spec.ClusterName)
+	case crossCluster && snapshot.Spec.ClusterName != restore.ClusterName:
+		return fmt.Errorf("etcd snapshot %s/%s was not taken from cluster %s", snapshot.Namespace, snapshot.Name, restore.ClusterName)
+	case crossCluster && snapshot.SnapshotFile.S3 == nil:
+		return fmt.Errorf("etcd snapshot %s/%s is not stored in S3 and cannot be restored into cluster %s", snapshot.Namespace, snapshot.Name, controlPlane.Spec.ClusterName)
+	}
+	return nil
+}
+
+// adoptEtcdSnapshotSourceTokens copies the server and agent tokens of the cluster an etcd snapshot was taken from into
+// the RKE state secret of the given controlplane. The bootstrap data within the snapshot is encrypted with the server
+// token of the source cluster, so the restored cluster can only be started and joined with the tokens of that cluster.
+func (p *Planner) adoptEtcdSnapshotSourceTokens(controlPlane *rkev1.RKEControlPlane, tokensSecret plan.Secret) error {
+	if controlPlane.Spec.UnmanagedConfig {
+		return nil
+	}
+
+	sourceName := name.SafeConcatName(controlPlane.Spec.ETCDSnapshotRestore.ClusterName, "rke", "state")
+	source, err := p.secretCache.Get(controlPlane.Namespace, sourceName)
+	if err != nil {
+		return fmt.Errorf("error retrieving RKE state secret %s/%s of the cluster the etcd snapshot was taken from: %w", controlPlane.Namespace, sourceName, err)
+	}
+	if source.Type != capr.SecretTypeClusterState {
+		return fmt.Errorf("secret %s/%s type %s did not match expected type %s", source.Namespace, source.Name, source.Type, capr.SecretTypeClusterState)
+	}
+
+	secret, err := p.secretCache.Get(controlPlane.Namespace, name.SafeConcatName(controlPlane.Name, "rke", "state"))
+	if err != nil {
+		return err
+	}
+
+	serverToken, agentToken := source.Data["serverToken"], source.Data["agentToken"]
+	_, pendingRotation := secret.Data[rotatedServerTokenKey]
+	if tokensSecret.ServerToken == string(serverToken) && tokensSecret.AgentToken == string(agentToken) && !pendingRotation {
+		return nil
+	}
+
+	secret = secret.DeepCopy()
+	secret.Data["serverToken"] = serverToken
+	secret.Data["agentToken"] = agentToken
+	// Tokens generated for an earlier restore are replaced by new ones after this restore.
+	delete(secret.Data, rotatedServerTokenKey)
+	delete(secret.Data, rotatedAgentTokenKey)
+	if _, err = p.secretClient.Update(secret); err != nil {
+		return err
+	}
+	return errWaitingf("adopting the tokens of cluster %s for the etcd snapshot restore", controlPlane.Spec.ETCDSnapshotRestore.ClusterName)
+}
+
+// ensureEtcdRestoreRotatedTokens returns the server token the tokens adopted from the cluster an etcd snapshot was
+// taken from are rotated to after the restore, generating and storing new server and agent tokens in the RKE state
+// secret of the given controlplane if needed. The new tokens only replace the adopted ones once the server token has
+// been rotated, see replaceEtcdRestoreRotatedTokens.
+func (p *Planner) ensureEtcdRestoreRotatedTokens(controlPlane *rkev1.RKEControlPlane) (string, error) {
+	secret, err := p.secretCache.Get(controlPlane.Namespace, name.SafeConcatName(controlPlane.Name, "rke", "state"))
+	if err != nil {
+		return "", err
+	}
+	if serverToken, ok := secret.Data[rotatedServerTokenKey]; ok && len(secret.Data[rotatedAgentTokenKey]) > 0 {
+		return string(serverToken), nil
+	}
+
+	serverToken, err := randomtoken.Generate()
+	if err != nil {
+		return "", err
+	}
+	agentToken, err := randomtoken.Generate()
+	if err != nil {
+		return "", err
+	}
+
+	secret = secret.DeepCopy()
+	secret.Data[rotatedServerTokenKey] = []byte(serverToken)
+	secret.Data[rotatedAgentTokenKey] = []byte(agentToken)
+	if _, err = p.secretClient.Update(secret); err != nil {
+		return "", err
+	}
+	return "", errWaitingf("generating new tokens to replace the tokens of cluster %s", controlPlane.Spec.ETCDSnapshotRestore.ClusterName)
+}
+
+// generateEtcdRestoreTokenRotationInstruction returns an instruction that rotates the server token adopted from the
+// cluster an etcd snapshot was taken from to the given new server token, so that the source cluster's token can't be
+// used to join or to decrypt the bootstrap data of the restored cluster.
+func generateEtcdRestoreTokenRotationInstruction(controlPlane *rkev1.RKEControlPlane, tokensSecret plan.Secret, newServerToken string) plan.OneTimeInstruction {
+	runtimeEnv := capr.GetRuntimeEnv(controlPlane.Spec.KubernetesVersion)
+	return idempotentInstruction(
+		controlPlane,
+		"etcd-restore/rotate-token",
+		fmt.Sprintf("%v-%s", controlPlane.Status.ETCDSnapshotRestore, PlanHash([]byte(newServerToken))),
+		capr.GetRuntime(controlPlane.Spec.KubernetesVersion),
+		[]string{"token", "rotate"},
+		[]string{
+			fmt.Sprintf("%s_DATA_DIR=%s", runtimeEnv, capr.GetDistroDataDir(controlPlane)),
+			fmt.Sprintf("%s_TOKEN=%s", runtimeEnv, tokensSecret.ServerToken),
+			fmt.Sprintf("%s_NEW_TOKEN=%s", runtimeEnv, newServerToken),
+		},
+	)
+}
+
+// replaceEtcdRestoreRotatedTokens replaces the server and agent tokens adopted from the cluster an etcd snapshot was
+// taken from with the tokens generated by ensureEtcdRestoreRotatedTokens, once the server token has been rotated. The
+// agent token is rotated by restarting the cluster with the new tokens.
+func (p *Planner) replaceEtcdRestoreRotatedTokens(controlPlane *rkev1.RKEControlPlane) error {
+	if controlPlane.Spec.UnmanagedConfig {
+		return nil
+	}
+
+	secret, err := p.secretCache.Get(controlPlane.Namespace, name.SafeConcatName(controlPlane.Name, "rke", "state"))
+	if err != nil {
+		return err
+	}
+	serverToken, ok := secret.Data[rotatedServerTokenKey]
+	if !ok {
+		return nil
+	}
+
+	secret = secret.DeepCopy()
+	secret.Data["serverToken"] = serverToken
+	secret.Data["agentToken"] = secret.Data[rotatedAgentTokenKey]
+	delete(secret.Data, rotatedServerTokenKey)
+	delete(secret.Data, rotatedAgentTokenKey)
+	if _, err = p.secretClient.Update(secret); err != nil {
+		return err
+	}
+	return errWaitingf("replacing the tokens of cluster %s", controlPlane.Spec.ETCDSnapshotRestore.ClusterName)
+}
+
 // forceDeleteAllDeletingEtcdMachines collects the etcd machines that are deleting for the given plan and force-deletes them.
 // This is helpful for the case where an etcd restore operation is happening on a cluster with "stuck" deleting etcd machines (quorum loss).
 func (p *Planner) forceDeleteAllDeletingEtcdMachines(cp *rkev1.RKEControlPlane, plan *plan.Plan) (int, error) {
@@ -794,6 +1068,10 @@ func (p *Planner) restoreEtcdSnapshot(cp *rkev1.RKEControlPlane, status rkev1.RK
 		return status, err
 	}
 
+	if err := validateEtcdSnapshotCluster(cp, snapshot); err != nil {
+		return status, err
+	}
+
 	// validate the snapshot can be restored by checking to see if the snapshot version is < 1.25.x and the current version is 1.25 or newer.
 	if snapshot != nil {
 		clusterSpec, err := capr.ParseSnapshotClusterSpecOrError(snapshot)
@@ -812,6 +1090,11 @@ func (p *Planner) restoreEtcdSnapshot(cp *rkev1.RKEControlPlane, status rkev1.RK
 
 	switch cp.Status.ETCDSnapshotRestorePhase {
 	case rkev1.ETCDSnapshotPhaseStarted:
+		if capr.IsCrossClusterRestore(cp.Spec.ETCDSnapshotRestore, cp.Spec.ClusterName) {
+			if err = p.adoptEtcdSnapshotSourceTokens(cp, tokensSecret); err != nil {
+				return status, err
+			}
+		}
 		if status.Initialized || status.Ready {
 			status.Initialized = false
 			status.Ready = false
@@ -855,6 +1138,11 @@ func (p *Planner) restoreEtcdSnapshot(cp *rkev1.RKEControlPlane, status rkev1.RK
 		}
 		return p.setEtcdSnapshotRestoreState(status, cp.Spec.ETCDSnapshotRestore, rkev1.ETCDSnapshotPhaseRestartCluster)
 	case rkev1.ETCDSnapshotPhaseRestartCluster:
+		if capr.IsCrossClusterRestore(cp.Spec.ETCDSnapshotRestore, cp.Spec.ClusterName) {
+			if err = p.replaceEtcdRestoreRotatedTokens(cp); err != nil {
+				return status, err
+			}
+		}
 		if err := p.pauseCAPICluster(cp, false); err != nil {
 			return status, err
 		}
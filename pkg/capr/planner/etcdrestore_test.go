@@ -1,6 +1,7 @@
 package planner
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -9,6 +10,7 @@
 	"github.com/rancher/rancher/pkg/capr"
 	"github.com/rancher/rancher/pkg/provisioningv2/image"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -279,3 +281,241 @@ func TestForceDeleteAllDeletingEtcdMachines(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateEtcdSnapshotCluster(t *testing.T) {
+	s3 := &rkev1.ETCDSnapshotS3{Bucket: "backups"}
+	snapshot := func(clusterName string, s3 *rkev1.ETCDSnapshotS3) *rkev1.ETCDSnapshot {
+		return &rkev1.ETCDSnapshot{
+			ObjectMeta:   metav1.ObjectMeta{Namespace: "fleet-default", Name: "snapshot"},
+			Spec:         rkev1.ETCDSnapshotSpec{ClusterName: clusterName},
+			SnapshotFile: rkev1.ETCDSnapshotFile{S3: s3},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		restore     *rkev1.ETCDSnapshotRestore
+		snapshot    *rkev1.ETCDSnapshot
+		expectedErr string
+	}{
+		{
+			name:    "local snapshot name",
+			restore: &rkev1.ETCDSnapshotRestore{Name: "snapshot"},
+		},
+		{
+			name:     "snapshot of the same cluster",
+			restore:  &rkev1.ETCDSnapshotRestore{Name: "snapshot"},
+			snapshot: snapshot("staging", nil),
+		},
+		{
+			name:     "snapshot of the same cluster with cluster name",
+			restore:  &rkev1.ETCDSnapshotRestore{Name: "snapshot", ClusterName: "staging"},
+			snapshot: snapshot("staging", nil),
+		},
+		{
+			name:        "snapshot of another cluster without cluster name",
+			restore:     &rkev1.ETCDSnapshotRestore{Name: "snapshot"},
+			snapshot:    snapshot("prod", s3),
+			expectedErr: "etcd snapshot fleet-default/snapshot was taken from cluster prod, the cluster name must be set on the restore to restore it into cluster staging",
+		},
+		{
+			name:     "S3 snapshot of another cluster",
+			restore:  &rkev1.ETCDSnapshotRestore{Name: "snapshot", ClusterName: "prod"},
+			snapshot: snapshot("prod", s3),
+		},
+		{
+			name:        "snapshot of a different cluster than requested",
+			restore:     &rkev1.ETCDSnapshotRestore{Name: "snapshot", ClusterName: "prod"},
+			snapshot:    snapshot("dev", s3),
+			expectedErr: "etcd snapshot fleet-default/snapshot was not taken from cluster prod",
+		},
+		{
+			name:        "local snapshot of another cluster",
+			restore:     &rkev1.ETCDSnapshotRestore{Name: "snapshot", ClusterName: "prod"},
+			snapshot:    snapshot("prod", nil),
+			expectedErr: "etcd snapshot fleet-default/snapshot is not stored in S3 and cannot be restored into cluster staging",
+		},
+		{
+			name:        "missing snapshot of another cluster",
+			restore:     &rkev1.ETCDSnapshotRestore{Name: "snapshot", ClusterName: "prod"},
+			expectedErr: "etcd snapshot fleet-default/snapshot of cluster prod was not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controlPlane := &rkev1.RKEControlPlane{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "staging"},
+				Spec: rkev1.RKEControlPlaneSpec{
+					ClusterName:         "staging",
+					ETCDSnapshotRestore: tt.restore,
+				},
+			}
+			err := validateEtcdSnapshotCluster(controlPlane, tt.snapshot)
+			if tt.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+func TestAdoptEtcdSnapshotSourceTokens(t *testing.T) {
+	stateSecret := func(name, serverToken, agentToken string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: name},
+			Type:       capr.SecretTypeClusterState,
+			Data: map[string][]byte{
+				"serverToken": []byte(serverToken),
+				"agentToken":  []byte(agentToken),
+			},
+		}
+	}
+	controlPlane := &rkev1.RKEControlPlane{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "staging"},
+		Spec: rkev1.RKEControlPlaneSpec{
+			ClusterName:         "staging",
+			ETCDSnapshotRestore: &rkev1.ETCDSnapshotRestore{Name: "snapshot", ClusterName: "prod"},
+		},
+	}
+
+	t.Run("tokens already adopted", func(t *testing.T) {
+		mp := newMockPlanner(t, InfoFunctions{})
+		mp.secretCache.EXPECT().Get("fleet-default", "prod-rke-state").Return(stateSecret("prod-rke-state", "server", "agent"), nil)
+		mp.secretCache.EXPECT().Get("fleet-default", "staging-rke-state").Return(stateSecret("staging-rke-state", "server", "agent"), nil)
+
+		err := mp.planner.adoptEtcdSnapshotSourceTokens(controlPlane, plan.Secret{ServerToken: "server", AgentToken: "agent"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("tokens are adopted", func(t *testing.T) {
+		mp := newMockPlanner(t, InfoFunctions{})
+		mp.secretCache.EXPECT().Get("fleet-default", "prod-rke-state").Return(stateSecret("prod-rke-state", "server", "agent"), nil)
+		mp.secretCache.EXPECT().Get("fleet-default", "staging-rke-state").Return(stateSecret("staging-rke-state", "other-server", "other-agent"), nil)
+		mp.secretClient.EXPECT().Update(gomock.Any()).DoAndReturn(func(secret *corev1.Secret) (*corev1.Secret, error) {
+			assert.Equal(t, "staging-rke-state", secret.Name)
+			assert.Equal(t, []byte("server"), secret.Data["serverToken"])
+			assert.Equal(t, []byte("agent"), secret.Data["agentToken"])
+			return secret, nil
+		})
+
+		err := mp.planner.adoptEtcdSnapshotSourceTokens(controlPlane, plan.Secret{ServerToken: "other-server", AgentToken: "other-agent"})
+		require.Error(t, err)
+		assert.True(t, IsErrWaiting(err))
+	})
+
+	t.Run("tokens rotated for an earlier restore are cleared", func(t *testing.T) {
+		mp := newMockPlanner(t, InfoFunctions{})
+		secret := stateSecret("staging-rke-state", "server", "agent")
+		secret.Data[rotatedServerTokenKey] = []byte("rotated-server")
+		secret.Data[rotatedAgentTokenKey] = []byte("rotated-agent")
+		mp.secretCache.EXPECT().Get("fleet-default", "prod-rke-state").Return(stateSecret("prod-rke-state", "server", "agent"), nil)
+		mp.secretCache.EXPECT().Get("fleet-default", "staging-rke-state").Return(secret, nil)
+		mp.secretClient.EXPECT().Update(gomock.Any()).DoAndReturn(func(secret *corev1.Secret) (*corev1.Secret, error) {
+			assert.Equal(t, []byte("server"), secret.Data["serverToken"])
+			assert.NotContains(t, secret.Data, rotatedServerTokenKey)
+			assert.NotContains(t, secret.Data, rotatedAgentTokenKey)
+			return secret, nil
+		})
+
+		err := mp.planner.adoptEtcdSnapshotSourceTokens(controlPlane, plan.Secret{ServerToken: "server", AgentToken: "agent"})
+		require.Error(t, err)
+		assert.True(t, IsErrWaiting(err))
+	})
+
+	t.Run("source cluster state is missing", func(t *testing.T) {
+		mp := newMockPlanner(t, InfoFunctions{})
+		mp.secretCache.EXPECT().Get("fleet-default", "prod-rke-state").Return(nil, fmt.Errorf("not found"))
+
+		err := mp.planner.adoptEtcdSnapshotSourceTokens(controlPlane, plan.Secret{ServerToken: "other-server", AgentToken: "other-agent"})
+		assert.EqualError(t, err, "error retrieving RKE state secret fleet-default/prod-rke-state of the cluster the etcd snapshot was taken from: not found")
+	})
+}
+
+func TestEtcdRestoreRotatedTokens(t *testing.T) {
+	stateSecret := func(data map[string]string) *corev1.Secret {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "staging-rke-state"},
+			Type:       capr.SecretTypeClusterState,
+			Data: map[string][]byte{
+				"serverToken": []byte("server"),
+				"agentToken":  []byte("agent"),
+			},
+		}
+		for k, v := range data {
+			secret.Data[k] = []byte(v)
+		}
+		return secret
+	}
+	controlPlane := &rkev1.RKEControlPlane{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "staging"},
+		Spec: rkev1.RKEControlPlaneSpec{
+			ClusterName:         "staging",
+			KubernetesVersion:   "v1.30.4+rke2r1",
+			ETCDSnapshotRestore: &rkev1.ETCDSnapshotRestore{Name: "snapshot", ClusterName: "prod"},
+		},
+	}
+
+	t.Run("new tokens are generated", func(t *testing.T) {
+		mp := newMockPlanner(t, InfoFunctions{})
+		mp.secretCache.EXPECT().Get("fleet-default", "staging-rke-state").Return(stateSecret(nil), nil)
+		mp.secretClient.EXPECT().Update(gomock.Any()).DoAndReturn(func(secret *corev1.Secret) (*corev1.Secret, error) {
+			assert.Equal(t, []byte("server"), secret.Data["serverToken"])
+			assert.NotEmpty(t, secret.Data[rotatedServerTokenKey])
+			assert.NotEmpty(t, secret.Data[rotatedAgentTokenKey])
+			assert.NotEqual(t, secret.Data[rotatedServerTokenKey], secret.Data[rotatedAgentTokenKey])
+			return secret, nil
+		})
+
+		_, err := mp.planner.ensureEtcdRestoreRotatedTokens(controlPlane)
+		require.Error(t, err)
+		assert.True(t, IsErrWaiting(err))
+	})
+
+	t.Run("generated tokens are reused", func(t *testing.T) {
+		mp := newMockPlanner(t, InfoFunctions{})
+		mp.secretCache.EXPECT().Get("fleet-default", "staging-rke-state").Return(stateSecret(map[string]string{
+			rotatedServerTokenKey: "new-server",
+			rotatedAgentTokenKey:  "new-agent",
+		}), nil)
+
+		serverToken, err := mp.planner.ensureEtcdRestoreRotatedTokens(controlPlane)
+		require.NoError(t, err)
+		assert.Equal(t, "new-server", serverToken)
+	})
+
+	t.Run("rotation instruction", func(t *testing.T) {
+		instruction := generateEtcdRestoreTokenRotationInstruction(controlPlane, plan.Secret{ServerToken: "server"}, "new-server")
+		assert.Equal(t, "etcd-restore/rotate-token", instruction.Args[2])
+		assert.Subset(t, instruction.Args, []string{"rke2", "token", "rotate"})
+		assert.Contains(t, instruction.Env, "RKE2_TOKEN=server")
+		assert.Contains(t, instruction.Env, "RKE2_NEW_TOKEN=new-server")
+	})
+
+	t.Run("generated tokens replace the adopted tokens", func(t *testing.T) {
+		mp := newMockPlanner(t, InfoFunctions{})
+		mp.secretCache.EXPECT().Get("fleet-default", "staging-rke-state").Return(stateSecret(map[string]string{
+			rotatedServerTokenKey: "new-server",
+			rotatedAgentTokenKey:  "new-agent",
+		}), nil)
+		mp.secretClient.EXPECT().Update(gomock.Any()).DoAndReturn(func(secret *corev1.Secret) (*corev1.Secret, error) {
+			assert.Equal(t, []byte("new-server"), secret.Data["serverToken"])
+			assert.Equal(t, []byte("new-agent"), secret.Data["agentToken"])
+			assert.NotContains(t, secret.Data, rotatedServerTokenKey)
+			assert.NotContains(t, secret.Data, rotatedAgentTokenKey)
+			return secret, nil
+		})
+
+		err := mp.planner.replaceEtcdRestoreRotatedTokens(controlPlane)
+		require.Error(t, err)
+		assert.True(t, IsErrWaiting(err))
+	})
+
+	t.Run("nothing to replace", func(t *testing.T) {
+		mp := newMockPlanner(t, InfoFunctions{})
+		mp.secretCache.EXPECT().Get("fleet-default", "staging-rke-state").Return(stateSecret(nil), nil)
+
+		assert.NoError(t, mp.planner.replaceEtcdRestoreRotatedTokens(controlPlane))
+	})
+}
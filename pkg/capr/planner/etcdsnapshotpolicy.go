@@ -0,0 +1,374 @@
+package planner
+
+import (
+	"encoding/base64"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	"github.com/rancher/rancher/pkg/capr"
+	"github.com/rancher/wrangler/v3/pkg/merr"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// policySnapshotRetryDelay is how long a failed etcd snapshot before an operation waits before it is taken again.
+	policySnapshotRetryDelay = 5 * time.Minute
+
+	// defaultEtcdSnapshotName is the base name of the on-demand etcd snapshots of the distributions.
+	defaultEtcdSnapshotName = "on-demand"
+
+	etcdSnapshotCopyBinPrefix = "capr/etcd-snapshot-copy/bin"
+	etcdSnapshotCopyPath      = "copy.sh"
+	etcdSnapshotCopyScript    = `
+#!/bin/sh
+
+# Copies the etcd snapshot which was just taken on this node to an additional S3 target. The newest local snapshot file
+# with the given base name is uploaded with curl. The secret key is read from the environment and handed to curl in a
+# config file, so that it never appears in the arguments of a process.
+
+set -e
+
+SNAPSHOT_DIR="$1"
+NAME="$2"
+shift 2
+
+S3_ENDPOINT=s3.amazonaws.com
+S3_REGION=us-east-1
+CURL_ARGS="-fsS"
+for ARG in "$@"; do
+	case "${ARG}" in
+		--etcd-s3-bucket=*) S3_BUCKET="${ARG#*=}" ;;
+		--etcd-s3-folder=*) S3_FOLDER="${ARG#*=}" ;;
+		--etcd-s3-endpoint=*) S3_ENDPOINT="${ARG#*=}" ;;
+		--etcd-s3-region=*) S3_REGION="${ARG#*=}" ;;
+		--etcd-s3-access-key=*) S3_ACCESS_KEY="${ARG#*=}" ;;
+		--etcd-s3-endpoint-ca=*) CURL_ARGS="${CURL_ARGS} --cacert ${ARG#*=}" ;;
+		--etcd-s3-skip-ssl-verify) CURL_ARGS="${CURL_ARGS} -k" ;;
+	esac
+done
+
+if [ -z "${S3_BUCKET}" ] || [ -z "${S3_ACCESS_KEY}" ] || [ -z "${AWS_SECRET_ACCESS_KEY}" ]; then
+	echo "an S3 bucket, access key and secret key are required to copy the snapshot" >&2
+	exit 1
+fi
+
+SNAPSHOT=$(ls -t "${SNAPSHOT_DIR}/${NAME}"-* 2>/dev/null | head -n 1)
+if [ -z "${SNAPSHOT}" ] || [ ! -f "${SNAPSHOT}" ]; then
+	echo "no snapshot ${NAME} was found in ${SNAPSHOT_DIR}" >&2
+	exit 1
+fi
+
+KEY="$(basename "${SNAPSHOT}")"
+if [ -n "${S3_FOLDER}" ]; then
+	KEY="${S3_FOLDER%/}/${KEY}"
+fi
+case "${S3_ENDPOINT}" in
+	http://*|https://*) URL="${S3_ENDPOINT%/}/${S3_BUCKET}/${KEY}" ;;
+	*) URL="https://${S3_ENDPOINT%/}/${S3_BUCKET}/${KEY}" ;;
+esac
+
+escape() {
+	printf '%s' "$1" | sed 's/[\\"]/\\&/g'
+}
+
+CONFIG_DIR=$(mktemp -d)
+trap 'rm -rf "${CONFIG_DIR}"' EXIT
+umask 077
+printf 'user = "%s:%s"\n' "$(escape "${S3_ACCESS_KEY}")" "$(escape "${AWS_SECRET_ACCESS_KEY}")" > "${CONFIG_DIR}/curlrc"
+
+# shellcheck disable=SC2086
+curl ${CURL_ARGS} --config "${CONFIG_DIR}/curlrc" --aws-sigv4 "aws:amz:${S3_REGION}:s3" -T "${SNAPSHOT}" "${URL}"
+echo "copied ${SNAPSHOT} to ${URL}"
+`
+)
+
+// policySnapshotNames are the base names of the etcd snapshots taken before an operation.
+var policySnapshotNames = map[rkev1.ETCDSnapshotPolicyOperation]string{
+	rkev1.ETCDSnapshotPolicyOperationCertificateRotation:   "pre-certificate-rotation",
+	rkev1.ETCDSnapshotPolicyOperationEncryptionKeyRotation: "pre-encryption-key-rotation",
+	rkev1.ETCDSnapshotPolicyOperationKubernetesUpgrade:     "pre-kubernetes-upgrade",
+}
+
+// etcdSnapshotPolicies returns the etcd snapshot policies of the cluster of the controlplane, sorted by name.
+func (p *Planner) etcdSnapshotPolicies(controlPlane *rkev1.RKEControlPlane) ([]*rkev1.ETCDSnapshotPolicy, error) {
+	policies, err := p.etcdSnapshotPolicyCache.List(controlPlane.Namespace, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*rkev1.ETCDSnapshotPolicy
+	for _, policy := range policies {
+		if policy.Spec.ClusterName == controlPlane.Spec.ClusterName && policy.DeletionTimestamp.IsZero() {
+			result = append(result, policy)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// snapshotPolicies returns the etcd snapshot policies of the cluster which requested the snapshot: the policy of a
+// scheduled snapshot, or every policy taking a snapshot before the operation.
+func (p *Planner) snapshotPolicies(controlPlane *rkev1.RKEControlPlane, snapshot *rkev1.ETCDSnapshotPolicySnapshot) ([]*rkev1.ETCDSnapshotPolicy, error) {
+	policies, err := p.etcdSnapshotPolicies(controlPlane)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*rkev1.ETCDSnapshotPolicy
+	for _, policy := range policies {
+		if snapshot.Operation == rkev1.ETCDSnapshotPolicyOperationSchedule {
+			if policy.Name == snapshot.Policy {
+				result = append(result, policy)
+			}
+		} else if slices.Contains(policy.Spec.BeforeOperations, snapshot.Operation) {
+			result = append(result, policy)
+		}
+	}
+	return result, nil
+}
+
+// policyS3TargetInstructions returns the instructions which copy the etcd snapshot just taken with the given base name
+// to each of the additional S3 targets of the etcd snapshot policies, along with the files they need. The snapshot is
+// taken once, every target receives a copy of the same snapshot file.
+func (p *Planner) policyS3TargetInstructions(controlPlane *rkev1.RKEControlPlane, snapshotName string, policies []*rkev1.ETCDSnapshotPolicy) ([]plan.OneTimeInstruction, []plan.File, error) {
+	if snapshotName == "" {
+		snapshotName = defaultEtcdSnapshotName
+	}
+
+	var (
+		instructions []plan.OneTimeInstruction
+		files        []plan.File
+	)
+	scriptPath := path.Join(capr.GetDistroDataDir(controlPlane), etcdSnapshotCopyBinPrefix, etcdSnapshotCopyPath)
+	for _, policy := range policies {
+		for i := range policy.Spec.S3Targets {
+			s3, env, s3Files, err := p.etcdS3Args.ToArgs(&policy.Spec.S3Targets[i], controlPlane, "etcd-", true)
+			if err != nil {
+				return nil, nil, fmt.Errorf("S3 target %d of etcd snapshot policy %s: %w", i, policy.Name, err)
+			}
+			if len(s3) == 0 {
+				continue
+			}
+			instructions = append(instructions, plan.OneTimeInstruction{
+				Name:    fmt.Sprintf("copy-%s-s3-target-%d", policy.Name, i),
+				Command: "sh",
+				Args:    append([]string{scriptPath, etcdSnapshotDir(controlPlane), snapshotName}, s3...),
+				Env:     env,
+			})
+			files = append(files, s3Files...)
+		}
+	}
+	if len(instructions) > 0 {
+		files = append(files, plan.File{
+			Content: base64.StdEncoding.EncodeToString([]byte(etcdSnapshotCopyScript)),
+			Path:    scriptPath,
+			Dynamic: true,
+		})
+	}
+	return instructions, files, nil
+}
+
+// etcdSnapshotDir returns the directory in which the distribution saves the local etcd snapshots of the nodes.
+func etcdSnapshotDir(controlPlane *rkev1.RKEControlPlane) string {
+	if dir, ok := controlPlane.Spec.MachineGlobalConfig.Data["etcd-snapshot-dir"].(string); ok && dir != "" {
+		return dir
+	}
+	return path.Join(capr.GetDistroDataDir(controlPlane), "server/db/snapshots")
+}
+
+// desiredPolicySnapshot returns the etcd snapshot which is requested by one of the etcd snapshot policies of the cluster,
+// or nil if there is none. Snapshots before an operation which is about to be performed take precedence over the
+// scheduled snapshots.
+func (p *Planner) desiredPolicySnapshot(controlPlane *rkev1.RKEControlPlane, status rkev1.RKEControlPlaneStatus) (*rkev1.ETCDSnapshotPolicySnapshot, error) {
+	policies, err := p.etcdSnapshotPolicies(controlPlane)
+	if err != nil || len(policies) == 0 {
+		return nil, err
+	}
+
+	var pending []rkev1.ETCDSnapshotPolicyOperation
+	keys := map[rkev1.ETCDSnapshotPolicyOperation]string{}
+
+	// the operations are checked in the order in which the planner performs them
+	if shouldRotate(controlPlane) {
+		pending = append(pending, rkev1.ETCDSnapshotPolicyOperationCertificateRotation)
+		keys[rkev1.ETCDSnapshotPolicyOperationCertificateRotation] = strconv.FormatInt(controlPlane.Spec.RotateCertificates.Generation, 10)
+	}
+	if canRotateEncryptionKeys(controlPlane) {
+		pending = append(pending, rkev1.ETCDSnapshotPolicyOperationEncryptionKeyRotation)
+		keys[rkev1.ETCDSnapshotPolicyOperationEncryptionKeyRotation] = strconv.FormatInt(controlPlane.Spec.RotateEncryptionKeys.Generation, 10)
+	}
+	if from, to := status.AppliedSpec.KubernetesVersion, controlPlane.Spec.KubernetesVersion; from != to {
+		// minor version upgrades of a cluster with an upgrade rollback are already preceded by the pre-upgrade snapshot
+		covered := false
+		if controlPlane.Spec.UpgradeStrategy.UpgradeRollback != nil {
+			if covered, err = isMinorUpgrade(from, to); err != nil {
+				return nil, err
+			}
+		}
+		if !covered {
+			pending = append(pending, rkev1.ETCDSnapshotPolicyOperationKubernetesUpgrade)
+			keys[rkev1.ETCDSnapshotPolicyOperationKubernetesUpgrade] = to
+		}
+	}
+
+	for _, operation := range pending {
+		for _, policy := range policies {
+			for _, before := range policy.Spec.BeforeOperations {
+				if before == operation {
+					return &rkev1.ETCDSnapshotPolicySnapshot{
+						Operation: operation,
+						Key:       keys[operation],
+						Name:      policySnapshotNames[operation],
+					}, nil
+				}
+			}
+		}
+	}
+
+	for _, policy := range policies {
+		if policy.Spec.Schedule == "" || policy.Spec.Suspend || policy.Status.LastScheduleTime == nil {
+			continue
+		}
+		key := policy.Status.LastScheduleTime.UTC().Format(time.RFC3339)
+		if status.ETCDSnapshotPolicySchedules[policy.Name] != key {
+			return &rkev1.ETCDSnapshotPolicySnapshot{
+				Operation: rkev1.ETCDSnapshotPolicyOperationSchedule,
+				Key:       key,
+				Policy:    policy.Name,
+				Name:      capr.ETCDSnapshotPolicySnapshotName(policy.Name),
+			}, nil
+		}
+	}
+	return nil, nil
+}
+
+// reconcilePolicySnapshot takes the etcd snapshots requested by the etcd snapshot policies of the cluster: before an
+// operation is performed on the cluster, and on their schedule. Snapshots are taken with the spec applied before the
+// operation. The operation is held until the snapshot was taken; a failed snapshot is retried after a delay. A failed
+// scheduled snapshot is not retried, the next one is taken on the schedule.
+func (p *Planner) reconcilePolicySnapshot(cp *rkev1.RKEControlPlane, status rkev1.RKEControlPlaneStatus, tokensSecret plan.Secret, clusterPlan *plan.Plan) (rkev1.RKEControlPlaneStatus, error) {
+	if status.AppliedSpec == nil || !status.Initialized || !capr.Bootstrapped.IsTrue(&status) {
+		return status, nil
+	}
+
+	snapshot := status.ETCDSnapshotPolicySnapshot.DeepCopy()
+	// a snapshot in progress is completed before another one is considered, so that the etcd nodes are restarted
+	if snapshot == nil || (snapshot.Phase != rkev1.ETCDSnapshotPhaseStarted && snapshot.Phase != rkev1.ETCDSnapshotPhaseRestartCluster) {
+		desired, err := p.desiredPolicySnapshot(cp, status)
+		if err != nil || desired == nil {
+			return status, err
+		}
+		if snapshot == nil || snapshot.Operation != desired.Operation || snapshot.Key != desired.Key || snapshot.Policy != desired.Policy {
+			if desired.Operation == rkev1.ETCDSnapshotPolicyOperationEncryptionKeyRotation && rotateEncryptionKeyInProgress(cp) {
+				// the policy was created while the rotation was in progress, it is too late to take a snapshot before it
+				return status, nil
+			}
+			desired.Phase = rkev1.ETCDSnapshotPhaseStarted
+			status.ETCDSnapshotPolicySnapshot = desired
+			return status, errWaitingf("taking %s", policySnapshotDescription(desired))
+		}
+	}
+	description := policySnapshotDescription(snapshot)
+	var err error
+
+	// The snapshot is taken with the spec applied before the operation, so that no other change is delivered with it.
+	snapshotControlPlane := cp.DeepCopy()
+	snapshotControlPlane.Spec = *status.AppliedSpec.DeepCopy()
+
+	switch snapshot.Phase {
+	case rkev1.ETCDSnapshotPhaseStarted:
+		_, joinServer, _, err := p.findInitNode(cp, clusterPlan)
+		if err != nil {
+			return status, err
+		}
+		if joinServer == "" {
+			return status, errWaitingf("waiting for join url to be available on bootstrap node to take the %s", description)
+		}
+		policies, err := p.snapshotPolicies(cp, snapshot)
+		if err != nil {
+			return status, err
+		}
+		if errs := p.runEtcdSnapshotCreate(snapshotControlPlane, tokensSecret, clusterPlan, joinServer, snapshot.Name, policies); len(errs) > 0 {
+			for _, snapshotErr := range errs {
+				if !IsErrWaiting(snapshotErr) {
+					snapshot.Phase = rkev1.ETCDSnapshotPhaseFailed
+					snapshot.LastFailureTime = &metav1.Time{Time: time.Now().UTC().Truncate(time.Second)}
+					status.ETCDSnapshotPolicySnapshot = snapshot
+					if snapshot.Operation == rkev1.ETCDSnapshotPolicyOperationSchedule {
+						logrus.Errorf("[planner] rkecluster %s/%s: %s failed, the next snapshot is taken on the schedule: %v", cp.Namespace, cp.Name, description, snapshotErr)
+						if status, err = p.recordPolicySchedule(cp, status, snapshot); err != nil {
+							return status, err
+						}
+					}
+					return status, errWaitingf("%s failed: %v", description, snapshotErr)
+				}
+			}
+			return status, errWaiting(merr.NewErrors(errs...).Error())
+		}
+		snapshot.Phase = rkev1.ETCDSnapshotPhaseRestartCluster
+		status.ETCDSnapshotPolicySnapshot = snapshot
+		return status, errWaitingf("%s taken, restarting etcd nodes", description)
+	case rkev1.ETCDSnapshotPhaseRestartCluster:
+		if err := p.runEtcdSnapshotManagementServiceStart(snapshotControlPlane, tokensSecret, clusterPlan, isEtcd, description); err != nil {
+			return status, err
+		}
+		snapshot.Phase = rkev1.ETCDSnapshotPhaseFinished
+		status.ETCDSnapshotPolicySnapshot = snapshot
+		if snapshot.Operation == rkev1.ETCDSnapshotPolicyOperationSchedule {
+			if status, err = p.recordPolicySchedule(cp, status, snapshot); err != nil {
+				return status, err
+			}
+		}
+		return status, errWaitingf("%s finished", description)
+	case rkev1.ETCDSnapshotPhaseFailed:
+		retryAt := time.Now()
+		if snapshot.LastFailureTime != nil {
+			retryAt = snapshot.LastFailureTime.Add(policySnapshotRetryDelay)
+		}
+		if remaining := time.Until(retryAt); remaining > 0 {
+			p.rkeControlPlanes.EnqueueAfter(cp.Namespace, cp.Name, remaining)
+			return status, errWaitingf("%s failed, it is retried at %s; the operation is held until then, unless it is removed from the beforeOperations of the etcd snapshot policies of the cluster", description, retryAt.UTC().Format(time.RFC3339))
+		}
+		snapshot.Phase = rkev1.ETCDSnapshotPhaseStarted
+		status.ETCDSnapshotPolicySnapshot = snapshot
+		return status, errWaitingf("retrying %s", description)
+	}
+
+	return status, nil
+}
+
+// recordPolicySchedule records the schedule time of the scheduled snapshot of a policy once it was taken or failed, so
+// that it isn't taken again. The schedule times of the policies which no longer exist are dropped.
+func (p *Planner) recordPolicySchedule(cp *rkev1.RKEControlPlane, status rkev1.RKEControlPlaneStatus, snapshot *rkev1.ETCDSnapshotPolicySnapshot) (rkev1.RKEControlPlaneStatus, error) {
+	policies, err := p.etcdSnapshotPolicies(cp)
+	if err != nil {
+		return status, err
+	}
+
+	schedules := map[string]string{snapshot.Policy: snapshot.Key}
+	for _, policy := range policies {
+		if key, ok := status.ETCDSnapshotPolicySchedules[policy.Name]; ok && policy.Name != snapshot.Policy {
+			schedules[policy.Name] = key
+		}
+	}
+	status.ETCDSnapshotPolicySchedules = schedules
+	return status, nil
+}
+
+// policySnapshotDescription returns a description of the etcd snapshot of a policy for messages.
+func policySnapshotDescription(snapshot *rkev1.ETCDSnapshotPolicySnapshot) string {
+	if snapshot.Operation == rkev1.ETCDSnapshotPolicyOperationSchedule {
+		return fmt.Sprintf("scheduled etcd snapshot of etcd snapshot policy %s", snapshot.Policy)
+	}
+	return fmt.Sprintf("etcd snapshot before %s", snapshot.Operation)
+}
@@ -0,0 +1,207 @@
+package planner
+
+import (
+	"testing"
+	"time"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/capr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_desiredPolicySnapshot(t *testing.T) {
+	scheduleTime := metav1.NewTime(time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC))
+	policy := func(name string, before []rkev1.ETCDSnapshotPolicyOperation, lastScheduleTime *metav1.Time) *rkev1.ETCDSnapshotPolicy {
+		return &rkev1.ETCDSnapshotPolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: name},
+			Spec: rkev1.ETCDSnapshotPolicySpec{
+				ClusterName:      "test",
+				Schedule:         "0 * * * *",
+				BeforeOperations: before,
+			},
+			Status: rkev1.ETCDSnapshotPolicyStatus{LastScheduleTime: lastScheduleTime},
+		}
+	}
+	upgrade := []rkev1.ETCDSnapshotPolicyOperation{rkev1.ETCDSnapshotPolicyOperationKubernetesUpgrade}
+
+	tests := []struct {
+		name      string
+		policies  []*rkev1.ETCDSnapshotPolicy
+		version   string
+		rollback  bool
+		schedules map[string]string
+		want      *rkev1.ETCDSnapshotPolicySnapshot
+	}{
+		{
+			name:     "no policy",
+			version:  "v1.30.1+rke2r1",
+			policies: nil,
+		},
+		{
+			name:     "kubernetes upgrade",
+			version:  "v1.30.1+rke2r1",
+			policies: []*rkev1.ETCDSnapshotPolicy{policy("a", upgrade, nil)},
+			want: &rkev1.ETCDSnapshotPolicySnapshot{
+				Operation: rkev1.ETCDSnapshotPolicyOperationKubernetesUpgrade,
+				Key:       "v1.30.1+rke2r1",
+				Name:      "pre-kubernetes-upgrade",
+			},
+		},
+		{
+			name:     "minor upgrade preceded by the upgrade rollback snapshot",
+			version:  "v1.30.1+rke2r1",
+			rollback: true,
+			policies: []*rkev1.ETCDSnapshotPolicy{policy("a", upgrade, nil)},
+		},
+		{
+			name:     "patch upgrade with upgrade rollback",
+			version:  "v1.29.6+rke2r1",
+			rollback: true,
+			policies: []*rkev1.ETCDSnapshotPolicy{policy("a", upgrade, nil)},
+			want: &rkev1.ETCDSnapshotPolicySnapshot{
+				Operation: rkev1.ETCDSnapshotPolicyOperationKubernetesUpgrade,
+				Key:       "v1.29.6+rke2r1",
+				Name:      "pre-kubernetes-upgrade",
+			},
+		},
+		{
+			name:     "operations take precedence over the schedule",
+			version:  "v1.30.1+rke2r1",
+			policies: []*rkev1.ETCDSnapshotPolicy{policy("a", nil, &scheduleTime), policy("b", upgrade, nil)},
+			want: &rkev1.ETCDSnapshotPolicySnapshot{
+				Operation: rkev1.ETCDSnapshotPolicyOperationKubernetesUpgrade,
+				Key:       "v1.30.1+rke2r1",
+				Name:      "pre-kubernetes-upgrade",
+			},
+		},
+		{
+			name:     "scheduled snapshot",
+			version:  "v1.29.5+rke2r1",
+			policies: []*rkev1.ETCDSnapshotPolicy{policy("a", upgrade, &scheduleTime)},
+			want: &rkev1.ETCDSnapshotPolicySnapshot{
+				Operation: rkev1.ETCDSnapshotPolicyOperationSchedule,
+				Key:       "2024-06-01T12:00:00Z",
+				Policy:    "a",
+				Name:      capr.ETCDSnapshotPolicySnapshotName("a"),
+			},
+		},
+		{
+			name:      "scheduled snapshot already taken",
+			version:   "v1.29.5+rke2r1",
+			policies:  []*rkev1.ETCDSnapshotPolicy{policy("a", nil, &scheduleTime), policy("b", nil, &scheduleTime)},
+			schedules: map[string]string{"a": "2024-06-01T12:00:00Z"},
+			want: &rkev1.ETCDSnapshotPolicySnapshot{
+				Operation: rkev1.ETCDSnapshotPolicyOperationSchedule,
+				Key:       "2024-06-01T12:00:00Z",
+				Policy:    "b",
+				Name:      capr.ETCDSnapshotPolicySnapshotName("b"),
+			},
+		},
+		{
+			name:      "all scheduled snapshots taken",
+			version:   "v1.29.5+rke2r1",
+			policies:  []*rkev1.ETCDSnapshotPolicy{policy("a", nil, &scheduleTime)},
+			schedules: map[string]string{"a": "2024-06-01T12:00:00Z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mp := newMockPlanner(t, InfoFunctions{})
+			mp.etcdSnapshotPolicyCache.EXPECT().List("fleet-default", gomock.Any()).Return(tt.policies, nil)
+
+			cp := &rkev1.RKEControlPlane{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "test"},
+				Spec: rkev1.RKEControlPlaneSpec{
+					ClusterName:       "test",
+					KubernetesVersion: tt.version,
+				},
+			}
+			if tt.rollback {
+				cp.Spec.UpgradeStrategy.UpgradeRollback = &rkev1.UpgradeRollback{}
+			}
+			status := rkev1.RKEControlPlaneStatus{
+				AppliedSpec:                 &rkev1.RKEControlPlaneSpec{KubernetesVersion: "v1.29.5+rke2r1"},
+				ETCDSnapshotPolicySchedules: tt.schedules,
+			}
+
+			got, err := mp.planner.desiredPolicySnapshot(cp, status)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_snapshotPolicies(t *testing.T) {
+	policy := func(name string, before ...rkev1.ETCDSnapshotPolicyOperation) *rkev1.ETCDSnapshotPolicy {
+		return &rkev1.ETCDSnapshotPolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: name},
+			Spec: rkev1.ETCDSnapshotPolicySpec{
+				ClusterName:      "test",
+				BeforeOperations: before,
+			},
+		}
+	}
+	policies := []*rkev1.ETCDSnapshotPolicy{
+		policy("a"),
+		policy("b", rkev1.ETCDSnapshotPolicyOperationKubernetesUpgrade),
+		policy("c", rkev1.ETCDSnapshotPolicyOperationKubernetesUpgrade, rkev1.ETCDSnapshotPolicyOperationCertificateRotation),
+	}
+
+	tests := []struct {
+		name     string
+		snapshot *rkev1.ETCDSnapshotPolicySnapshot
+		want     []string
+	}{
+		{
+			name:     "scheduled snapshot",
+			snapshot: &rkev1.ETCDSnapshotPolicySnapshot{Operation: rkev1.ETCDSnapshotPolicyOperationSchedule, Policy: "b"},
+			want:     []string{"b"},
+		},
+		{
+			name:     "snapshot before an operation",
+			snapshot: &rkev1.ETCDSnapshotPolicySnapshot{Operation: rkev1.ETCDSnapshotPolicyOperationKubernetesUpgrade},
+			want:     []string{"b", "c"},
+		},
+		{
+			name:     "snapshot before an operation of a single policy",
+			snapshot: &rkev1.ETCDSnapshotPolicySnapshot{Operation: rkev1.ETCDSnapshotPolicyOperationCertificateRotation},
+			want:     []string{"c"},
+		},
+		{
+			name:     "scheduled snapshot of a deleted policy",
+			snapshot: &rkev1.ETCDSnapshotPolicySnapshot{Operation: rkev1.ETCDSnapshotPolicyOperationSchedule, Policy: "d"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mp := newMockPlanner(t, InfoFunctions{})
+			mp.etcdSnapshotPolicyCache.EXPECT().List("fleet-default", gomock.Any()).Return(policies, nil)
+
+			cp := &rkev1.RKEControlPlane{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "test"},
+				Spec:       rkev1.RKEControlPlaneSpec{ClusterName: "test"},
+			}
+
+			got, err := mp.planner.snapshotPolicies(cp, tt.snapshot)
+			require.NoError(t, err)
+			var names []string
+			for _, policy := range got {
+				names = append(names, policy.Name)
+			}
+			assert.Equal(t, tt.want, names)
+		})
+	}
+}
+
+func Test_ETCDSnapshotPolicySnapshotName(t *testing.T) {
+	// the base name of a policy is not the prefix of the base name of another policy whose name starts with it
+	a := capr.ETCDSnapshotPolicySnapshotName("a")
+	ab := capr.ETCDSnapshotPolicySnapshotName("a-b")
+	assert.NotContains(t, ab, a+"-")
+	assert.NotContains(t, a, ab+"-")
+}
@@ -0,0 +1,456 @@
+package planner
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	"github.com/rancher/rancher/pkg/capr"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	etcdSnapshotVerifyBinPrefix       = "capr/etcd-snapshot-verify/bin"
+	etcdSnapshotVerifyInstructionName = "etcd-snapshot-verify"
+
+	etcdSnapshotVerifyPath   = "verify.sh"
+	etcdSnapshotVerifyScript = `
+#!/bin/sh
+
+# Verifies an etcd snapshot using the etcd binaries of the image the instruction runs from. S3 snapshots are downloaded
+# first. The snapshot is opened as an etcd database and restored into a scratch data directory, which checks the
+# integrity hash appended to the snapshot. If TEST_RESTORE is true, a scratch etcd member is started from the restored
+# data directory on free ports and queried. The result is written to stdout as a single line of JSON.
+
+set -e
+
+SNAPSHOT="$1"
+shift
+
+# port_in_use returns whether a TCP port is listened on.
+port_in_use() {
+	HEX=$(printf '%04X' "$1")
+	cat /proc/net/tcp /proc/net/tcp6 2>/dev/null | awk -v port=":${HEX}" '$2 ~ (port "$") && $4 == "0A" { found = 1 } END { exit !found }'
+}
+
+# free_port prints a TCP port other than the given one which is not listened on, starting from a random port so that
+# concurrent verifications don't pick the same one.
+free_port() {
+	PORT=$((20000 + $(od -An -N2 -tu2 /dev/urandom | tr -d ' ') % 20000))
+	while [ "${PORT}" = "$1" ] || port_in_use "${PORT}"; do
+		PORT=$((PORT + 1))
+	done
+	echo "${PORT}"
+}
+
+CLIENT_PORT=$(free_port)
+PEER_PORT=$(free_port "${CLIENT_PORT}")
+BIN_DIR="$(pwd)/usr/local/bin"
+
+for BIN in etcd etcdctl etcdutl; do
+	if [ ! -x "${BIN_DIR}/${BIN}" ]; then
+		echo "${BIN} was not found in ${BIN_DIR}" >&2
+		exit 1
+	fi
+done
+
+S3=false
+S3_ENDPOINT=s3.amazonaws.com
+S3_REGION=us-east-1
+CURL_ARGS="-fsS"
+for ARG in "$@"; do
+	case "${ARG}" in
+		--etcd-s3) S3=true ;;
+		--etcd-s3-bucket=*) S3_BUCKET="${ARG#*=}" ;;
+		--etcd-s3-folder=*) S3_FOLDER="${ARG#*=}" ;;
+		--etcd-s3-endpoint=*) S3_ENDPOINT="${ARG#*=}" ;;
+		--etcd-s3-region=*) S3_REGION="${ARG#*=}" ;;
+		--etcd-s3-access-key=*) S3_ACCESS_KEY="${ARG#*=}" ;;
+		--etcd-s3-endpoint-ca=*) CURL_ARGS="${CURL_ARGS} --cacert ${ARG#*=}" ;;
+		--etcd-s3-skip-ssl-verify) CURL_ARGS="${CURL_ARGS} -k" ;;
+	esac
+done
+
+WORK_DIR=$(mktemp -d)
+ETCD_PID=""
+cleanup() {
+	if [ -n "${ETCD_PID}" ]; then
+		kill "${ETCD_PID}" 2>/dev/null || true
+		wait "${ETCD_PID}" 2>/dev/null || true
+	fi
+	rm -rf "${WORK_DIR}"
+}
+trap cleanup EXIT
+
+if [ "${S3}" = "true" ]; then
+	if [ -z "${S3_ACCESS_KEY}" ] || [ -z "${AWS_SECRET_ACCESS_KEY}" ]; then
+		echo "an S3 access key and secret key are required to download the snapshot" >&2
+		exit 1
+	fi
+	KEY="${SNAPSHOT}"
+	if [ -n "${S3_FOLDER}" ]; then
+		KEY="${S3_FOLDER%/}/${SNAPSHOT}"
+	fi
+	case "${S3_ENDPOINT}" in
+		http://*|https://*) URL="${S3_ENDPOINT%/}/${S3_BUCKET}/${KEY}" ;;
+		*) URL="https://${S3_ENDPOINT%/}/${S3_BUCKET}/${KEY}" ;;
+	esac
+
+	escape() {
+		printf '%s' "$1" | sed 's/[\\"]/\\&/g'
+	}
+
+	# the credentials are passed in a config file rather than as arguments, which are visible to other users
+	(umask 077 && printf 'user = "%s:%s"\n' "$(escape "${S3_ACCESS_KEY}")" "$(escape "${AWS_SECRET_ACCESS_KEY}")" > "${WORK_DIR}/curlrc")
+
+	# shellcheck disable=SC2086
+	curl ${CURL_ARGS} --config "${WORK_DIR}/curlrc" --aws-sigv4 "aws:amz:${S3_REGION}:s3" -o "${WORK_DIR}/$(basename "${SNAPSHOT}")" "${URL}"
+	SNAPSHOT="${WORK_DIR}/$(basename "${SNAPSHOT}")"
+fi
+
+if [ ! -f "${SNAPSHOT}" ]; then
+	echo "snapshot ${SNAPSHOT} does not exist" >&2
+	exit 1
+fi
+
+SIZE=$(wc -c < "${SNAPSHOT}" | tr -d ' ')
+CHECKSUM=$(sha256sum "${SNAPSHOT}" | cut -d ' ' -f 1)
+
+DB="${SNAPSHOT}"
+case "${SNAPSHOT}" in
+	*.zip)
+		mkdir "${WORK_DIR}/unzip"
+		unzip -q -d "${WORK_DIR}/unzip" "${SNAPSHOT}"
+		DB=$(find "${WORK_DIR}/unzip" -type f | head -n 1)
+		;;
+esac
+
+STATUS=$("${BIN_DIR}/etcdutl" snapshot status "${DB}" -w json)
+
+"${BIN_DIR}/etcdutl" snapshot restore "${DB}" \
+	--data-dir "${WORK_DIR}/data" \
+	--name verify \
+	--initial-cluster "verify=http://127.0.0.1:${PEER_PORT}" \
+	--initial-advertise-peer-urls "http://127.0.0.1:${PEER_PORT}" >&2
+
+RESTORE=null
+if [ "${TEST_RESTORE}" = "true" ]; then
+	"${BIN_DIR}/etcd" \
+		--name verify \
+		--data-dir "${WORK_DIR}/data" \
+		--listen-client-urls "http://127.0.0.1:${CLIENT_PORT}" \
+		--advertise-client-urls "http://127.0.0.1:${CLIENT_PORT}" \
+		--listen-peer-urls "http://127.0.0.1:${PEER_PORT}" \
+		--initial-advertise-peer-urls "http://127.0.0.1:${PEER_PORT}" \
+		--initial-cluster "verify=http://127.0.0.1:${PEER_PORT}" > "${WORK_DIR}/etcd.log" 2>&1 &
+	ETCD_PID=$!
+
+	i=0
+	until "${BIN_DIR}/etcdctl" --endpoints "http://127.0.0.1:${CLIENT_PORT}" endpoint health >/dev/null 2>&1; do
+		i=$((i + 1))
+		if [ $i -ge 60 ]; then
+			cat "${WORK_DIR}/etcd.log" >&2
+			echo "scratch etcd member did not become healthy" >&2
+			exit 1
+		fi
+		sleep 1
+	done
+	RESTORE=$("${BIN_DIR}/etcdctl" --endpoints "http://127.0.0.1:${CLIENT_PORT}" get "" --prefix --keys-only --count-only -w json)
+fi
+
+printf '{"checksum":"%s","size":%s,"status":%s,"restore":%s}\n' "${CHECKSUM}" "${SIZE}" "${STATUS}" "${RESTORE}"
+`
+)
+
+// etcdSnapshotVerifyOutput is the result written by the etcd snapshot verification script.
+type etcdSnapshotVerifyOutput struct {
+	Checksum string                     `json:"checksum"`
+	Size     int64                      `json:"size"`
+	Status   etcdSnapshotStatusOutput   `json:"status"`
+	Restore  *etcdSnapshotRestoreOutput `json:"restore"`
+}
+
+// etcdSnapshotStatusOutput is the output of etcdutl snapshot status.
+type etcdSnapshotStatusOutput struct {
+	Hash      int64 `json:"hash"`
+	Revision  int64 `json:"revision"`
+	TotalKey  int64 `json:"totalKey"`
+	TotalSize int64 `json:"totalSize"`
+}
+
+// etcdSnapshotRestoreOutput is the output of the count of all keys served by the scratch etcd member.
+type etcdSnapshotRestoreOutput struct {
+	Header struct {
+		ClusterID uint64 `json:"cluster_id"`
+		MemberID  uint64 `json:"member_id"`
+		Revision  int64  `json:"revision"`
+	} `json:"header"`
+	Count int64 `json:"count"`
+}
+
+func (p *Planner) setEtcdSnapshotVerifyState(status rkev1.RKEControlPlaneStatus, verify *rkev1.ETCDSnapshotVerify, phase rkev1.ETCDSnapshotPhase) (rkev1.RKEControlPlaneStatus, error) {
+	if status.ETCDSnapshotVerifyPhase != phase || !equality.Semantic.DeepEqual(status.ETCDSnapshotVerify, verify) {
+		status.ETCDSnapshotVerifyPhase = phase
+		status.ETCDSnapshotVerify = verify
+		return status, errWaiting("refreshing etcd verify state")
+	}
+	return status, nil
+}
+
+func (p *Planner) resetEtcdSnapshotVerifyState(status rkev1.RKEControlPlaneStatus) (rkev1.RKEControlPlaneStatus, error) {
+	if status.ETCDSnapshotVerify == nil && status.ETCDSnapshotVerifyPhase == "" {
+		return status, nil
+	}
+	return p.setEtcdSnapshotVerifyState(status, nil, "")
+}
+
+func (p *Planner) startOrRestartEtcdSnapshotVerify(status rkev1.RKEControlPlaneStatus, verify *rkev1.ETCDSnapshotVerify) (rkev1.RKEControlPlaneStatus, error) {
+	if status.ETCDSnapshotVerify == nil || !equality.Semantic.DeepEqual(verify, status.ETCDSnapshotVerify) {
+		return p.setEtcdSnapshotVerifyState(status, verify, rkev1.ETCDSnapshotPhaseStarted)
+	}
+	return status, nil
+}
+
+// verifyEtcdSnapshot verifies the etcd snapshot requested by the etcd snapshot verify spec of the controlplane, and
+// records the result on the status of the etcdsnapshot object.
+func (p *Planner) verifyEtcdSnapshot(controlPlane *rkev1.RKEControlPlane, status rkev1.RKEControlPlaneStatus, tokensSecret plan.Secret, clusterPlan *plan.Plan) (rkev1.RKEControlPlaneStatus, error) {
+	var err error
+	if controlPlane.Spec.ETCDSnapshotVerify == nil {
+		return p.resetEtcdSnapshotVerifyState(status)
+	}
+
+	// Don't verify an etcd snapshot if the cluster is not initialized or bootstrapped.
+	if !status.Initialized || !capr.Bootstrapped.IsTrue(&status) {
+		logrus.Warnf("[planner] rkecluster %s/%s: skipping etcd snapshot verification as cluster has not yet been initialized or bootstrapped", controlPlane.Namespace, controlPlane.Name)
+		return status, nil
+	}
+
+	verify := controlPlane.Spec.ETCDSnapshotVerify
+
+	if status, err = p.startOrRestartEtcdSnapshotVerify(status, verify); err != nil {
+		return status, err
+	}
+
+	switch controlPlane.Status.ETCDSnapshotVerifyPhase {
+	case rkev1.ETCDSnapshotPhaseStarted:
+		snapshot, err := p.etcdSnapshotCache.Get(controlPlane.Namespace, verify.Name)
+		if apierrors.IsNotFound(err) {
+			logrus.Errorf("[planner] rkecluster %s/%s: etcd snapshot %s to verify was not found", controlPlane.Namespace, controlPlane.Name, verify.Name)
+			return p.setEtcdSnapshotVerifyState(status, verify, rkev1.ETCDSnapshotPhaseFailed)
+		} else if err != nil {
+			return status, err
+		}
+
+		entry, err := etcdSnapshotVerifyEntry(clusterPlan, snapshot)
+		if err != nil {
+			if err := p.recordEtcdSnapshotVerification(snapshot, verify, "", nil, err); err != nil {
+				return status, err
+			}
+			return p.setEtcdSnapshotVerifyState(status, verify, rkev1.ETCDSnapshotPhaseFailed)
+		}
+
+		_, joinServer, _, err := p.findInitNode(controlPlane, clusterPlan)
+		if err != nil {
+			return status, err
+		}
+		if joinServer == "" {
+			return status, errWaiting("waiting for join url to be available on bootstrap node to verify etcd snapshot")
+		}
+
+		verifyPlan, joinedServer, err := p.generateEtcdSnapshotVerifyPlan(controlPlane, tokensSecret, entry, joinServer, snapshot)
+		if err != nil {
+			return status, err
+		}
+
+		nodeName := entry.Machine.Name
+		if entry.Machine.Status.NodeRef != nil && entry.Machine.Status.NodeRef.Name != "" {
+			nodeName = entry.Machine.Status.NodeRef.Name
+		}
+		if err := assignAndCheckPlan(p.store, fmt.Sprintf("etcd snapshot verification on %s", nodeName), entry, verifyPlan, joinedServer, 1, 1); err != nil {
+			if IsErrWaiting(err) {
+				return status, err
+			}
+			if err := p.recordEtcdSnapshotVerification(snapshot, verify, nodeName, nil, err); err != nil {
+				return status, err
+			}
+			return p.setEtcdSnapshotVerifyState(status, verify, rkev1.ETCDSnapshotPhaseFailed)
+		}
+
+		output, err := parseEtcdSnapshotVerifyOutput(entry.Plan.Output[etcdSnapshotVerifyInstructionName])
+		if err := p.recordEtcdSnapshotVerification(snapshot, verify, nodeName, output, err); err != nil {
+			return status, err
+		}
+		return p.setEtcdSnapshotVerifyState(status, verify, rkev1.ETCDSnapshotPhaseFinished)
+	case rkev1.ETCDSnapshotPhaseFailed:
+		fallthrough
+	case rkev1.ETCDSnapshotPhaseFinished:
+		return status, nil
+	default:
+		return p.setEtcdSnapshotVerifyState(status, verify, rkev1.ETCDSnapshotPhaseStarted)
+	}
+}
+
+// etcdSnapshotVerifyEntry returns the plan entry of the etcd node which verifies the snapshot. Local snapshots can only
+// be verified by the node which took them, S3 snapshots are verified by the init node.
+func etcdSnapshotVerifyEntry(clusterPlan *plan.Plan, snapshot *rkev1.ETCDSnapshot) (*planEntry, error) {
+	if snapshot.SnapshotFile.S3 == nil {
+		machineID := snapshot.Labels[capr.MachineIDLabel]
+		if machineID == "" {
+			return nil, fmt.Errorf("label %s did not exist on local etcd snapshot", capr.MachineIDLabel)
+		}
+		for _, entry := range collect(clusterPlan, roleAnd(isEtcd, isNotDeleting)) {
+			if entry.Machine.Labels[capr.MachineIDLabel] == machineID {
+				return entry, nil
+			}
+		}
+		return nil, fmt.Errorf("etcd node with machine ID %s which holds the local etcd snapshot was not found", machineID)
+	}
+
+	if entries := collect(clusterPlan, roleAnd(isInitNode, isNotDeleting)); len(entries) > 0 {
+		return entries[0], nil
+	}
+	if entries := collect(clusterPlan, roleAnd(isEtcd, isNotDeleting)); len(entries) > 0 {
+		return entries[0], nil
+	}
+	return nil, fmt.Errorf("no etcd node was found to verify the S3 etcd snapshot")
+}
+
+// generateEtcdSnapshotVerifyPlan returns the desired plan of the node with the files and the instruction which verify
+// the etcd snapshot added. The instruction runs from the etcd snapshot verify image, so that the etcd binaries it
+// provides are available independently of the distribution.
+func (p *Planner) generateEtcdSnapshotVerifyPlan(controlPlane *rkev1.RKEControlPlane, tokensSecret plan.Secret, entry *planEntry, joinServer string, snapshot *rkev1.ETCDSnapshot) (plan.NodePlan, string, error) {
+	nodePlan, joinedServer, err := p.desiredPlan(controlPlane, tokensSecret, entry, joinServer)
+	if err != nil {
+		return nodePlan, joinedServer, err
+	}
+
+	verify := controlPlane.Spec.ETCDSnapshotVerify
+	args := []string{etcdSnapshotVerifyScriptPath(controlPlane, etcdSnapshotVerifyPath)}
+	env := []string{
+		fmt.Sprintf("ETCD_SNAPSHOT_VERIFY=%s/%d", verify.Name, verify.Generation),
+		fmt.Sprintf("TEST_RESTORE=%t", verify.TestRestore),
+	}
+	if snapshot.SnapshotFile.S3 == nil {
+		args = append(args, localEtcdSnapshotPath(controlPlane, snapshot))
+	} else {
+		s3, s3Env, s3Files, err := p.etcdS3Args.ToArgs(snapshot.SnapshotFile.S3, controlPlane, "etcd-", true)
+		if err != nil {
+			return nodePlan, joinedServer, err
+		}
+		args = append(append(args, snapshot.SnapshotFile.Name), s3...)
+		env = append(env, s3Env...)
+		nodePlan.Files = append(nodePlan.Files, s3Files...)
+	}
+
+	nodePlan.Files = append(nodePlan.Files, plan.File{
+		Content: base64.StdEncoding.EncodeToString([]byte(etcdSnapshotVerifyScript)),
+		Path:    etcdSnapshotVerifyScriptPath(controlPlane, etcdSnapshotVerifyPath),
+		Dynamic: true,
+	})
+	nodePlan.Instructions = append(nodePlan.Instructions, plan.OneTimeInstruction{
+		Name:       etcdSnapshotVerifyInstructionName,
+		Image:      p.retrievalFunctions.ImageResolver(p.retrievalFunctions.EtcdSnapshotVerifyImage(), controlPlane),
+		Command:    "sh",
+		Args:       args,
+		Env:        env,
+		SaveOutput: true,
+	})
+	return nodePlan, joinedServer, nil
+}
+
+// localEtcdSnapshotPath returns the path of a local etcd snapshot on the node which took it, from the location recorded
+// by the distribution, or from the etcd snapshot directory of the cluster if no location was recorded.
+func localEtcdSnapshotPath(controlPlane *rkev1.RKEControlPlane, snapshot *rkev1.ETCDSnapshot) string {
+	if location, ok := strings.CutPrefix(snapshot.SnapshotFile.Location, "file://"); ok && path.IsAbs(location) {
+		return location
+	}
+	return path.Join(etcdSnapshotDir(controlPlane), snapshot.SnapshotFile.Name)
+}
+
+func etcdSnapshotVerifyScriptPath(controlPlane *rkev1.RKEControlPlane, file string) string {
+	return path.Join(capr.GetDistroDataDir(controlPlane), etcdSnapshotVerifyBinPrefix, file)
+}
+
+// parseEtcdSnapshotVerifyOutput parses the result of the etcd snapshot verification script, which is the last line of
+// its output.
+func parseEtcdSnapshotVerifyOutput(output []byte) (*etcdSnapshotVerifyOutput, error) {
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	last := strings.TrimSpace(lines[len(lines)-1])
+	if !strings.HasPrefix(last, "{") {
+		return nil, fmt.Errorf("etcd snapshot verification did not produce a result")
+	}
+	result := &etcdSnapshotVerifyOutput{}
+	if err := json.Unmarshal([]byte(last), result); err != nil {
+		return nil, fmt.Errorf("failed to parse etcd snapshot verification result: %w", err)
+	}
+	return result, nil
+}
+
+// etcdSnapshotVerification returns the verification of the snapshot from the result of the verification script, or
+// from the error which prevented it. The snapshot fails verification if its size differs from the one reported by the
+// distribution, if its checksum changed since it was last verified, or if a scratch restore did not serve the revision of
+// the snapshot.
+func etcdSnapshotVerification(snapshot *rkev1.ETCDSnapshot, verify *rkev1.ETCDSnapshotVerify, nodeName string, output *etcdSnapshotVerifyOutput, verifyErr error, now time.Time) *rkev1.ETCDSnapshotVerification {
+	verification := &rkev1.ETCDSnapshotVerification{
+		Generation: verify.Generation,
+		VerifiedAt: &metav1.Time{Time: now},
+		NodeName:   nodeName,
+	}
+	if verifyErr != nil {
+		verification.Message = verifyErr.Error()
+		return verification
+	}
+
+	verification.Checksum = output.Checksum
+	verification.Hash = output.Status.Hash
+	verification.Revision = output.Status.Revision
+	verification.TotalKeys = output.Status.TotalKey
+	verification.TotalSize = output.Status.TotalSize
+	if output.Restore != nil {
+		verification.TestRestore = &rkev1.ETCDSnapshotTestRestore{
+			ClusterID: strconv.FormatUint(output.Restore.Header.ClusterID, 16),
+			MemberID:  strconv.FormatUint(output.Restore.Header.MemberID, 16),
+			Revision:  output.Restore.Header.Revision,
+			Keys:      output.Restore.Count,
+		}
+	}
+
+	previous := snapshot.Status.Verification
+	switch {
+	case snapshot.SnapshotFile.Size > 0 && output.Size != snapshot.SnapshotFile.Size:
+		verification.Message = fmt.Sprintf("snapshot file size %d does not match the size %d reported when it was taken", output.Size, snapshot.SnapshotFile.Size)
+	case previous != nil && previous.Verified && previous.Checksum != "" && previous.Checksum != output.Checksum:
+		verification.Message = fmt.Sprintf("snapshot checksum %s does not match checksum %s of the previous verification", output.Checksum, previous.Checksum)
+	case verify.TestRestore && output.Restore == nil:
+		verification.Message = "snapshot was not restored into a scratch etcd member"
+	case output.Restore != nil && output.Restore.Header.Revision < output.Status.Revision:
+		verification.Message = fmt.Sprintf("scratch etcd member restored from the snapshot served revision %d instead of %d", output.Restore.Header.Revision, output.Status.Revision)
+	default:
+		verification.Verified = true
+	}
+	return verification
+}
+
+// recordEtcdSnapshotVerification records the verification of the snapshot on its status.
+func (p *Planner) recordEtcdSnapshotVerification(snapshot *rkev1.ETCDSnapshot, verify *rkev1.ETCDSnapshotVerify, nodeName string, output *etcdSnapshotVerifyOutput, verifyErr error) error {
+	verification := etcdSnapshotVerification(snapshot, verify, nodeName, output, verifyErr, time.Now().UTC().Truncate(time.Second))
+	if verification.Verified {
+		logrus.Infof("[planner] etcd snapshot %s/%s verified at revision %d", snapshot.Namespace, snapshot.Name, verification.Revision)
+	} else {
+		logrus.Errorf("[planner] etcd snapshot %s/%s failed verification: %s", snapshot.Namespace, snapshot.Name, verification.Message)
+	}
+
+	snapshot = snapshot.DeepCopy()
+	snapshot.Status.Verification = verification
+	_, err := p.etcdSnapshots.UpdateStatus(snapshot)
+	return err
+}
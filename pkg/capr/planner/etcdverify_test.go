@@ -0,0 +1,218 @@
+package planner
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func restoreOutput(clusterID, memberID uint64, revision, count int64) *etcdSnapshotRestoreOutput {
+	restore := &etcdSnapshotRestoreOutput{Count: count}
+	restore.Header.ClusterID = clusterID
+	restore.Header.MemberID = memberID
+	restore.Header.Revision = revision
+	return restore
+}
+
+func Test_parseEtcdSnapshotVerifyOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    *etcdSnapshotVerifyOutput
+		wantErr bool
+	}{
+		{
+			name:    "no output",
+			wantErr: true,
+		},
+		{
+			name:    "no result",
+			output:  "snapshot /var/lib/rancher/rke2/server/db/snapshots/snap does not exist\n",
+			wantErr: true,
+		},
+		{
+			name: "result after logs",
+			output: `{"level":"info","msg":"restored snapshot"}
+{"checksum":"abc","size":4096,"status":{"hash":123,"revision":456,"totalKey":789,"totalSize":2048,"version":"3.5.0"},"restore":null}
+`,
+			want: &etcdSnapshotVerifyOutput{
+				Checksum: "abc",
+				Size:     4096,
+				Status:   etcdSnapshotStatusOutput{Hash: 123, Revision: 456, TotalKey: 789, TotalSize: 2048},
+			},
+		},
+		{
+			name:   "result with test restore",
+			output: `{"checksum":"abc","size":4096,"status":{"hash":123,"revision":456,"totalKey":789,"totalSize":2048},"restore":{"header":{"cluster_id":255,"member_id":16,"revision":456,"raft_term":2},"count":42}}`,
+			want: &etcdSnapshotVerifyOutput{
+				Checksum: "abc",
+				Size:     4096,
+				Status:   etcdSnapshotStatusOutput{Hash: 123, Revision: 456, TotalKey: 789, TotalSize: 2048},
+				Restore:  restoreOutput(255, 16, 456, 42),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEtcdSnapshotVerifyOutput([]byte(tt.output))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_etcdSnapshotVerification(t *testing.T) {
+	now := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	verify := &rkev1.ETCDSnapshotVerify{Name: "snapshot", Generation: 2}
+	testRestore := &rkev1.ETCDSnapshotVerify{Name: "snapshot", Generation: 2, TestRestore: true}
+
+	output := func(checksum string, size int64, restore *etcdSnapshotRestoreOutput) *etcdSnapshotVerifyOutput {
+		return &etcdSnapshotVerifyOutput{
+			Checksum: checksum,
+			Size:     size,
+			Status:   etcdSnapshotStatusOutput{Hash: 123, Revision: 456, TotalKey: 789, TotalSize: 2048},
+			Restore:  restore,
+		}
+	}
+	snapshot := func(size int64, previous *rkev1.ETCDSnapshotVerification) *rkev1.ETCDSnapshot {
+		return &rkev1.ETCDSnapshot{
+			SnapshotFile: rkev1.ETCDSnapshotFile{Size: size},
+			Status:       rkev1.ETCDSnapshotStatus{Verification: previous},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		snapshot     *rkev1.ETCDSnapshot
+		verify       *rkev1.ETCDSnapshotVerify
+		output       *etcdSnapshotVerifyOutput
+		err          error
+		wantVerified bool
+		wantMessage  string
+	}{
+		{
+			name:        "verification error",
+			snapshot:    snapshot(4096, nil),
+			verify:      verify,
+			err:         errors.New("operation etcd snapshot verification on node1 failed"),
+			wantMessage: "operation etcd snapshot verification on node1 failed",
+		},
+		{
+			name:         "verified",
+			snapshot:     snapshot(4096, nil),
+			verify:       verify,
+			output:       output("abc", 4096, nil),
+			wantVerified: true,
+		},
+		{
+			name:        "size mismatch",
+			snapshot:    snapshot(8192, nil),
+			verify:      verify,
+			output:      output("abc", 4096, nil),
+			wantMessage: "snapshot file size 4096 does not match the size 8192 reported when it was taken",
+		},
+		{
+			name:        "checksum changed",
+			snapshot:    snapshot(4096, &rkev1.ETCDSnapshotVerification{Verified: true, Checksum: "def"}),
+			verify:      verify,
+			output:      output("abc", 4096, nil),
+			wantMessage: "snapshot checksum abc does not match checksum def of the previous verification",
+		},
+		{
+			name:         "checksum of failed verification is ignored",
+			snapshot:     snapshot(4096, &rkev1.ETCDSnapshotVerification{Checksum: "def"}),
+			verify:       verify,
+			output:       output("abc", 4096, nil),
+			wantVerified: true,
+		},
+		{
+			name:        "test restore missing",
+			snapshot:    snapshot(4096, nil),
+			verify:      testRestore,
+			output:      output("abc", 4096, nil),
+			wantMessage: "snapshot was not restored into a scratch etcd member",
+		},
+		{
+			name:        "test restore revision mismatch",
+			snapshot:    snapshot(4096, nil),
+			verify:      testRestore,
+			output:      output("abc", 4096, restoreOutput(255, 16, 400, 42)),
+			wantMessage: "scratch etcd member restored from the snapshot served revision 400 instead of 456",
+		},
+		{
+			name:         "test restore verified",
+			snapshot:     snapshot(4096, nil),
+			verify:       testRestore,
+			output:       output("abc", 4096, restoreOutput(255, 16, 456, 42)),
+			wantVerified: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := etcdSnapshotVerification(tt.snapshot, tt.verify, "node1", tt.output, tt.err, now)
+			assert.Equal(t, tt.wantVerified, got.Verified)
+			assert.Equal(t, tt.wantMessage, got.Message)
+			assert.Equal(t, 2, got.Generation)
+			assert.Equal(t, "node1", got.NodeName)
+			assert.Equal(t, &metav1.Time{Time: now}, got.VerifiedAt)
+			if tt.output != nil {
+				assert.Equal(t, int64(456), got.Revision)
+				assert.Equal(t, tt.output.Checksum, got.Checksum)
+			}
+			if tt.output != nil && tt.output.Restore != nil {
+				assert.Equal(t, &rkev1.ETCDSnapshotTestRestore{ClusterID: "ff", MemberID: "10", Revision: tt.output.Restore.Header.Revision, Keys: 42}, got.TestRestore)
+			}
+		})
+	}
+}
+
+func Test_localEtcdSnapshotPath(t *testing.T) {
+	tests := []struct {
+		name         string
+		snapshotDir  string
+		snapshotFile rkev1.ETCDSnapshotFile
+		want         string
+	}{
+		{
+			name:         "recorded location",
+			snapshotDir:  "/opt/snapshots",
+			snapshotFile: rkev1.ETCDSnapshotFile{Name: "etcd-snapshot-1", Location: "file:///mnt/etcd/etcd-snapshot-1"},
+			want:         "/mnt/etcd/etcd-snapshot-1",
+		},
+		{
+			name:         "custom snapshot dir",
+			snapshotDir:  "/opt/snapshots",
+			snapshotFile: rkev1.ETCDSnapshotFile{Name: "etcd-snapshot-1"},
+			want:         "/opt/snapshots/etcd-snapshot-1",
+		},
+		{
+			name:         "default snapshot dir",
+			snapshotFile: rkev1.ETCDSnapshotFile{Name: "etcd-snapshot-1"},
+			want:         "/var/lib/rancher/rke2/server/db/snapshots/etcd-snapshot-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controlPlane := &rkev1.RKEControlPlane{
+				Spec: rkev1.RKEControlPlaneSpec{KubernetesVersion: "v1.30.1+rke2r1"},
+			}
+			if tt.snapshotDir != "" {
+				controlPlane.Spec.MachineGlobalConfig.Data = map[string]any{"etcd-snapshot-dir": tt.snapshotDir}
+			}
+			snapshot := &rkev1.ETCDSnapshot{SnapshotFile: tt.snapshotFile}
+			assert.Equal(t, tt.want, localEtcdSnapshotPath(controlPlane, snapshot))
+		})
+	}
+}
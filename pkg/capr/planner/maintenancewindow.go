@@ -0,0 +1,110 @@
+package planner
+
+import (
+	"fmt"
+	"time"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	"github.com/rancher/rancher/pkg/capr"
+	"github.com/rancher/wrangler/v3/pkg/kv"
+	"github.com/robfig/cron"
+)
+
+// maintenanceWindowOpen returns whether plan changes which require a drain or a restart can be delivered at the given
+// time. If they can't, it also returns the time the next maintenance window opens. Changes can always be delivered if
+// no maintenance window is configured or if the override annotation is set on the controlplane.
+func maintenanceWindowOpen(controlPlane *rkev1.RKEControlPlane, now time.Time) (bool, time.Time, error) {
+	windows := controlPlane.Spec.UpgradeStrategy.MaintenanceWindows
+	if len(windows) == 0 {
+		return true, time.Time{}, nil
+	}
+	if _, ok := controlPlane.Annotations[capr.MaintenanceWindowOverrideAnnotation]; ok {
+		return true, time.Time{}, nil
+	}
+
+	var next time.Time
+	for i, window := range windows {
+		schedule, err := cron.ParseStandard(window.Schedule)
+		if err != nil {
+			return false, time.Time{}, fmt.Errorf("invalid schedule for maintenance window %d: %w", i, err)
+		}
+		location := time.UTC
+		if window.TimeZone != "" {
+			location, err = time.LoadLocation(window.TimeZone)
+			if err != nil {
+				return false, time.Time{}, fmt.Errorf("invalid time zone for maintenance window %d: %w", i, err)
+			}
+		}
+		if window.Duration.Duration <= 0 {
+			return false, time.Time{}, fmt.Errorf("invalid duration for maintenance window %d: must be positive", i)
+		}
+
+		// The window is open if it started less than its duration ago.
+		localNow := now.In(location)
+		if start := schedule.Next(localNow.Add(-window.Duration.Duration)); !start.After(localNow) {
+			return true, time.Time{}, nil
+		}
+		if start := schedule.Next(localNow); next.IsZero() || start.Before(next) {
+			next = start
+		}
+	}
+
+	return false, next, nil
+}
+
+// setMaintenanceWindowCondition reports on the controlplane whether disruptive plan changes are currently held, or why
+// the maintenance windows could not be evaluated. The condition is only set for controlplanes which have maintenance
+// windows configured, or had them before.
+func setMaintenanceWindowCondition(controlPlane *rkev1.RKEControlPlane, status *rkev1.RKEControlPlaneStatus, open bool, next time.Time, windowErr error) {
+	if len(controlPlane.Spec.UpgradeStrategy.MaintenanceWindows) == 0 && capr.MaintenanceWindow.GetStatus(status) == "" {
+		return
+	}
+
+	if windowErr != nil {
+		capr.MaintenanceWindow.False(status)
+		capr.MaintenanceWindow.Reason(status, "Invalid")
+		capr.MaintenanceWindow.Message(status, fmt.Sprintf("plan changes requiring a drain or restart are held until the maintenance windows are fixed: %v", windowErr))
+		return
+	}
+
+	if open {
+		capr.MaintenanceWindow.True(status)
+		capr.MaintenanceWindow.Reason(status, "")
+		capr.MaintenanceWindow.Message(status, "")
+		return
+	}
+
+	capr.MaintenanceWindow.False(status)
+	capr.MaintenanceWindow.Reason(status, "Hold")
+	capr.MaintenanceWindow.Message(status, fmt.Sprintf("plan changes requiring a drain or restart are held until the next maintenance window at %s", next.Format(time.RFC3339)))
+}
+
+func getRestartStamp(plan *plan.NodePlan) string {
+	for _, instr := range plan.Instructions {
+		if instr.Name != "install" {
+			continue
+		}
+		for _, env := range instr.Env {
+			k, v := kv.Split(env, "=")
+			if k == "RESTART_STAMP" ||
+				k == "WINS_RESTART_STAMP" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// isDisruptivePlanChange determines whether delivering the new plan would drain the node or restart the distribution.
+// Initial plans are never disruptive.
+func isDisruptivePlanChange(oldPlan *plan.NodePlan, newPlan plan.NodePlan) bool {
+	if oldPlan == nil {
+		return false
+	}
+	if shouldDrain(oldPlan, newPlan) {
+		return true
+	}
+	oldRestartStamp := getRestartStamp(oldPlan)
+	return oldRestartStamp != "" && oldRestartStamp != getRestartStamp(&newPlan)
+}
@@ -0,0 +1,173 @@
+package planner
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	"github.com/rancher/rancher/pkg/capr"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_maintenanceWindowOpen(t *testing.T) {
+	// Saturday 2024-06-01 at 02:30 UTC
+	now := time.Date(2024, time.June, 1, 2, 30, 0, 0, time.UTC)
+	saturdayNights := rkev1.MaintenanceWindow{
+		Schedule: "0 1 * * 6",
+		Duration: metav1.Duration{Duration: 4 * time.Hour},
+	}
+
+	tests := []struct {
+		name        string
+		windows     []rkev1.MaintenanceWindow
+		annotations map[string]string
+		wantOpen    bool
+		wantNext    time.Time
+		wantErr     bool
+	}{
+		{
+			name:     "no windows",
+			wantOpen: true,
+		},
+		{
+			name:     "open window",
+			windows:  []rkev1.MaintenanceWindow{saturdayNights},
+			wantOpen: true,
+		},
+		{
+			name: "closed window",
+			windows: []rkev1.MaintenanceWindow{{
+				Schedule: "0 1 * * 6",
+				Duration: metav1.Duration{Duration: time.Hour},
+			}},
+			wantNext: time.Date(2024, time.June, 8, 1, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "earliest of closed windows",
+			windows: []rkev1.MaintenanceWindow{
+				{Schedule: "0 22 * * *", Duration: metav1.Duration{Duration: time.Hour}},
+				{Schedule: "0 4 * * *", Duration: metav1.Duration{Duration: time.Hour}},
+			},
+			wantNext: time.Date(2024, time.June, 1, 4, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "time zone",
+			// 02:30 UTC is 04:30 in Berlin during summer time
+			windows: []rkev1.MaintenanceWindow{{
+				Schedule: "0 4 * * *",
+				Duration: metav1.Duration{Duration: time.Hour},
+				TimeZone: "Europe/Berlin",
+			}},
+			wantOpen: true,
+		},
+		{
+			name:        "override",
+			windows:     []rkev1.MaintenanceWindow{{Schedule: "0 12 * * *", Duration: metav1.Duration{Duration: time.Hour}}},
+			annotations: map[string]string{capr.MaintenanceWindowOverrideAnnotation: "true"},
+			wantOpen:    true,
+		},
+		{
+			name:    "invalid schedule",
+			windows: []rkev1.MaintenanceWindow{{Schedule: "sometimes", Duration: metav1.Duration{Duration: time.Hour}}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid time zone",
+			windows: []rkev1.MaintenanceWindow{{Schedule: "0 4 * * *", Duration: metav1.Duration{Duration: time.Hour}, TimeZone: "Nowhere/Nothing"}},
+			wantErr: true,
+		},
+		{
+			name:    "missing duration",
+			windows: []rkev1.MaintenanceWindow{{Schedule: "0 4 * * *"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controlPlane := &rkev1.RKEControlPlane{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: tt.annotations,
+				},
+			}
+			controlPlane.Spec.UpgradeStrategy.MaintenanceWindows = tt.windows
+
+			open, next, err := maintenanceWindowOpen(controlPlane, now)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantOpen, open)
+			assert.True(t, tt.wantNext.Equal(next), "expected next window at %s, got %s", tt.wantNext, next)
+		})
+	}
+}
+
+func Test_setMaintenanceWindowCondition(t *testing.T) {
+	now := time.Date(2024, time.June, 1, 2, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		windows     []rkev1.MaintenanceWindow
+		wantStatus  string
+		wantReason  string
+		wantMessage string
+	}{
+		{
+			name:       "open window",
+			windows:    []rkev1.MaintenanceWindow{{Schedule: "0 1 * * 6", Duration: metav1.Duration{Duration: 4 * time.Hour}}},
+			wantStatus: "True",
+		},
+		{
+			name:        "closed window",
+			windows:     []rkev1.MaintenanceWindow{{Schedule: "0 1 * * 6", Duration: metav1.Duration{Duration: time.Hour}}},
+			wantStatus:  "False",
+			wantReason:  "Hold",
+			wantMessage: "plan changes requiring a drain or restart are held until the next maintenance window at 2024-06-08T01:00:00Z",
+		},
+		{
+			name:        "invalid schedule",
+			windows:     []rkev1.MaintenanceWindow{{Schedule: "sometimes", Duration: metav1.Duration{Duration: time.Hour}}},
+			wantStatus:  "False",
+			wantReason:  "Invalid",
+			wantMessage: "plan changes requiring a drain or restart are held until the maintenance windows are fixed: invalid schedule for maintenance window 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controlPlane := &rkev1.RKEControlPlane{}
+			controlPlane.Spec.UpgradeStrategy.MaintenanceWindows = tt.windows
+			status := &rkev1.RKEControlPlaneStatus{}
+
+			open, next, err := maintenanceWindowOpen(controlPlane, now)
+			setMaintenanceWindowCondition(controlPlane, status, open, next, err)
+
+			assert.Equal(t, tt.wantStatus == "True", open, "disruptive changes must be held unless the window is open")
+			assert.Equal(t, tt.wantStatus, capr.MaintenanceWindow.GetStatus(status))
+			assert.Equal(t, tt.wantReason, capr.MaintenanceWindow.GetReason(status))
+			assert.True(t, strings.HasPrefix(capr.MaintenanceWindow.GetMessage(status), tt.wantMessage), "unexpected message %q", capr.MaintenanceWindow.GetMessage(status))
+		})
+	}
+}
+
+func Test_isDisruptivePlanChange(t *testing.T) {
+	install := func(restartStamp, drainHash string) plan.NodePlan {
+		return plan.NodePlan{
+			Instructions: []plan.OneTimeInstruction{{
+				Name: "install",
+				Env:  []string{"RESTART_STAMP=" + restartStamp, "DRAIN_HASH=" + drainHash},
+			}},
+		}
+	}
+	applied := install("a", "a")
+
+	assert.False(t, isDisruptivePlanChange(nil, install("b", "b")))
+	assert.False(t, isDisruptivePlanChange(&applied, install("a", "a")))
+	assert.True(t, isDisruptivePlanChange(&applied, install("b", "a")))
+	assert.True(t, isDisruptivePlanChange(&applied, install("a", "b")))
+}
@@ -12,6 +12,7 @@
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/moby/locker"
@@ -114,7 +115,9 @@ type Planner struct {
 	rkeBootstrap                  rkecontrollers.RKEBootstrapClient
 	rkeBootstrapCache             rkecontrollers.RKEBootstrapCache
 	rkeControlPlanes              rkecontrollers.RKEControlPlaneController
+	etcdSnapshots                 rkecontrollers.ETCDSnapshotClient
 	etcdSnapshotCache             rkecontrollers.ETCDSnapshotCache
+	etcdSnapshotPolicyCache       rkecontrollers.ETCDSnapshotPolicyCache
 	secretClient                  corecontrollers.SecretClient
 	secretCache                   corecontrollers.SecretCache
 	configMapCache                corecontrollers.ConfigMapCache
@@ -144,6 +147,7 @@ type InfoFunctions struct {
 	SystemAgentImage        func() string
 	SystemPodLabelSelectors func(plane *rkev1.RKEControlPlane) []string
 	GetBootstrapManifests   func(plane *rkev1.RKEControlPlane) ([]plan.File, error)
+	EtcdSnapshotVerifyImage func() string
 }
 
 func New(ctx context.Context, clients *wrangler.Context, functions InfoFunctions) *Planner {
@@ -171,7 +175,9 @@ func New(ctx context.Context, clients *wrangler.Context, functions InfoFunctions
 		rkeControlPlanes:              clients.RKE.RKEControlPlane(),
 		rkeBootstrap:                  clients.RKE.RKEBootstrap(),
 		rkeBootstrapCache:             clients.RKE.RKEBootstrap().Cache(),
+		etcdSnapshots:                 clients.RKE.ETCDSnapshot(),
 		etcdSnapshotCache:             clients.RKE.ETCDSnapshot().Cache(),
+		etcdSnapshotPolicyCache:       clients.RKE.ETCDSnapshotPolicy().Cache(),
 		etcdS3Args: s3Args{
 			secretCache: clients.Core.Secret().Cache(),
 		},
@@ -325,6 +331,14 @@ func (p *Planner) Process(cp *rkev1.RKEControlPlane, status rkev1.RKEControlPlan
 		return status, err
 	}
 
+	if status, err = p.verifyEtcdSnapshot(cp, status, clusterSecretTokens, plan); err != nil {
+		return status, err
+	}
+
+	if status, err = p.reconcilePolicySnapshot(cp, status, clusterSecretTokens, plan); err != nil {
+		return status, err
+	}
+
 	if status, err = p.rotateCertificates(cp, status, clusterSecretTokens, plan); err != nil {
 		return status, err
 	}
@@ -348,6 +362,23 @@ func (p *Planner) Process(cp *rkev1.RKEControlPlane, status rkev1.RKEControlPlan
 		return status, errWaiting("rkecontrolplane was already initialized but no etcd machines exist that have plans, indicating the etcd plane has been entirely replaced. Restoration from etcd snapshot is required.")
 	}
 
+	// A dry-run reports the plan changes and holds the changes to delivered plans until it is no longer requested. The
+	// initial plans of new machines are still delivered, so that the cluster can scale up during a dry-run.
+	if isPlanDryRun(cp) {
+		if status, err = p.planDryRun(cp, status, clusterSecretTokens, plan); err != nil {
+			return status, err
+		}
+		if status, err = p.fullReconcile(cp, status, clusterSecretTokens, plan, false); err != nil && !IsErrWaiting(err) {
+			return status, err
+		}
+		return status, errWaitingf("plan dry-run: %d machine(s) would be updated, remove the %s annotation to apply the changes", heldPlanChanges(status.PlanDryRun), capr.PlanDryRunAnnotation)
+	}
+	status.PlanDryRun = nil
+
+	if status, err = p.reconcileUpgradeRollback(cp, status, clusterSecretTokens, plan); err != nil {
+		return status, err
+	}
+
 	return p.fullReconcile(cp, status, clusterSecretTokens, plan, false)
 }
 
@@ -364,15 +395,34 @@ func (p *Planner) fullReconcile(cp *rkev1.RKEControlPlane, status rkev1.RKEContr
 		controlPlaneConcurrency, workerConcurrency   string
 	)
 
+	// plan changes are held during dry-runs, disruptive plan changes are held outside of maintenance windows, and plan
+	// changes are delivered to canaries first, except when restoring etcd snapshots
+	var rollout rolloutControls
+
 	if !ignoreDrainAndConcurrency {
 		controlPlaneDrainOptions = cp.Spec.UpgradeStrategy.ControlPlaneDrainOptions
 		workerDrainOptions = cp.Spec.UpgradeStrategy.WorkerDrainOptions
 		controlPlaneConcurrency = cp.Spec.UpgradeStrategy.ControlPlaneConcurrency
 		workerConcurrency = cp.Spec.UpgradeStrategy.WorkerConcurrency
+
+		// invalid maintenance windows are reported on the controlplane and only hold disruptive changes, so that the
+		// cluster keeps planning the other changes until they are fixed
+		open, next, windowErr := maintenanceWindowOpen(cp, time.Now())
+		setMaintenanceWindowCondition(cp, &status, open, next, windowErr)
+		if !open && windowErr == nil {
+			// held changes are not re-enqueued by any other object, so reconcile again once the next window opens
+			p.rkeControlPlanes.EnqueueAfter(cp.Namespace, cp.Name, time.Until(next))
+		}
+		rollout.holdDisruptiveChanges = !open
+
+		rollout.holdPlanChanges = isPlanDryRun(cp)
+		if !rollout.holdPlanChanges {
+			rollout.canary = p.newCanaryTracker(cp, &status, time.Now())
+		}
 	}
 
 	// select all etcd and then filter to just initNodes so that unavailable count is correct
-	err = p.reconcile(cp, clusterSecretTokens, plan, true, bootstrapTier, isEtcd, isNotInitNodeOrIsDeleting, "1", "", controlPlaneDrainOptions, -1, 1, false)
+	err = p.reconcile(cp, clusterSecretTokens, plan, true, bootstrapTier, isEtcd, isNotInitNodeOrIsDeleting, "1", "", controlPlaneDrainOptions, -1, 1, false, rollout)
 	capr.Bootstrapped.True(&status)
 	firstIgnoreError, err = ignoreErrors(firstIgnoreError, err)
 	if err != nil {
@@ -391,14 +441,14 @@ func (p *Planner) fullReconcile(cp *rkev1.RKEControlPlane, status rkev1.RKEContr
 	}
 
 	// Process all nodes that have the etcd role and are NOT an init node or deleting. Only process 1 node at a time.
-	err = p.reconcile(cp, clusterSecretTokens, plan, true, etcdTier, isEtcd, isInitNodeOrDeleting, "1", joinServer, controlPlaneDrainOptions, -1, 1, false)
+	err = p.reconcile(cp, clusterSecretTokens, plan, true, etcdTier, isEtcd, isInitNodeOrDeleting, "1", joinServer, controlPlaneDrainOptions, -1, 1, false, rollout)
 	firstIgnoreError, err = ignoreErrors(firstIgnoreError, err)
 	if err != nil {
 		return status, err
 	}
 
 	// Process all nodes that have the controlplane role and are NOT an init node or deleting.
-	err = p.reconcile(cp, clusterSecretTokens, plan, true, controlPlaneTier, isControlPlane, isInitNodeOrDeleting, controlPlaneConcurrency, joinServer, controlPlaneDrainOptions, -1, 1, false)
+	err = p.reconcile(cp, clusterSecretTokens, plan, true, controlPlaneTier, isControlPlane, isInitNodeOrDeleting, controlPlaneConcurrency, joinServer, controlPlaneDrainOptions, -1, 1, false, rollout)
 	firstIgnoreError, err = ignoreErrors(firstIgnoreError, err)
 	if err != nil {
 		return status, err
@@ -416,7 +466,7 @@ func (p *Planner) fullReconcile(cp *rkev1.RKEControlPlane, status rkev1.RKEContr
 	}
 
 	// Process all nodes that are ONLY linux worker nodes.
-	err = p.reconcile(cp, clusterSecretTokens, plan, false, workerTier, isOnlyLinuxWorker, isInitNodeOrDeleting, workerConcurrency, "", workerDrainOptions, -1, 1, false)
+	err = p.reconcile(cp, clusterSecretTokens, plan, false, workerTier, isOnlyLinuxWorker, isInitNodeOrDeleting, workerConcurrency, "", workerDrainOptions, -1, 1, false, rollout)
 	firstIgnoreError, err = ignoreErrors(firstIgnoreError, err)
 	if err != nil {
 		return status, err
@@ -439,7 +489,7 @@ func (p *Planner) fullReconcile(cp *rkev1.RKEControlPlane, status rkev1.RKEContr
 		resetFailureCountOnRestart = true
 	}
 
-	err = p.reconcile(cp, clusterSecretTokens, plan, false, workerTier, isOnlyWindowsWorker, isInitNodeOrDeleting, workerConcurrency, "", workerDrainOptions, windowsMaxFailures, windowsMaxFailureThreshold, resetFailureCountOnRestart)
+	err = p.reconcile(cp, clusterSecretTokens, plan, false, workerTier, isOnlyWindowsWorker, isInitNodeOrDeleting, workerConcurrency, "", workerDrainOptions, windowsMaxFailures, windowsMaxFailureThreshold, resetFailureCountOnRestart, rollout)
 	firstIgnoreError, err = ignoreErrors(firstIgnoreError, err)
 	if err != nil {
 		return status, err
@@ -856,7 +906,19 @@ type reconcilable struct {
 	minorChange bool
 }
 
-func (p *Planner) reconcile(controlPlane *rkev1.RKEControlPlane, tokensSecret plan.Secret, clusterPlan *plan.Plan, required bool, tierName string, include, exclude roleFilter, maxUnavailable, forcedJoinURL string, drainOptions rkev1.DrainOptions, maxFailures, failureThreshold int, resetFailureCountOnSystemAgentRestart bool) error {
+// rolloutControls hold back the plan changes delivered by reconcile.
+type rolloutControls struct {
+	// holdPlanChanges holds every change to a delivered plan while a plan dry-run is requested. Initial plans are
+	// still delivered.
+	holdPlanChanges bool
+	// holdDisruptiveChanges holds the plan changes which would drain the node or restart the distribution while the
+	// maintenance window is closed.
+	holdDisruptiveChanges bool
+	// canary delivers the plan changes of each tier to its canaries first, nil if no canary rollout is configured.
+	canary *canaryTracker
+}
+
+func (p *Planner) reconcile(controlPlane *rkev1.RKEControlPlane, tokensSecret plan.Secret, clusterPlan *plan.Plan, required bool, tierName string, include, exclude roleFilter, maxUnavailable, forcedJoinURL string, drainOptions rkev1.DrainOptions, maxFailures, failureThreshold int, resetFailureCountOnSystemAgentRestart bool, rollout rolloutControls) error {
 	var (
 		ready, outOfSync, nonReady, errMachines, draining, uncordoned []string
 		messages                                                      = map[string][]string{}
@@ -896,6 +958,17 @@ func (p *Planner) reconcile(controlPlane *rkev1.RKEControlPlane, tokensSecret pl
 		return err
 	}
 
+	canaryStage, err := rollout.canary.stage(tierName, reconcilables, exclude)
+	if err != nil {
+		return err
+	}
+	if err := p.markCanaries(canaryStage); err != nil {
+		return err
+	}
+	if err := p.releaseCanaries(canaryStage, reconcilables); err != nil {
+		return err
+	}
+
 	preBootstrapManifests, err := p.retrievalFunctions.GetBootstrapManifests(controlPlane)
 	if err != nil {
 		return err
@@ -931,7 +1004,7 @@ func (p *Planner) reconcile(controlPlane *rkev1.RKEControlPlane, tokensSecret pl
 			if err := p.store.UpdatePlan(r.entry, r.desiredPlan, r.joinedURL, maxFailures, failureThreshold); err != nil {
 				return err
 			}
-		} else if r.minorChange {
+		} else if r.minorChange && !rollout.holdPlanChanges {
 			logrus.Debugf("[planner] rkecluster %s/%s reconcile tier %s - minor plan change detected for machine %s/%s, updating plan immediately", controlPlane.Namespace, controlPlane.Name, tierName, r.entry.Machine.Namespace, r.entry.Machine.Name)
 			logrus.Tracef("[planner] rkecluster %s/%s reconcile tier %s - minor plan change for machine %s/%s old: %+v, new: %+v", controlPlane.Namespace, controlPlane.Name, tierName, r.entry.Machine.Namespace, r.entry.Machine.Name, r.entry.Plan.Plan, r.desiredPlan)
 			outOfSync = append(outOfSync, r.entry.Machine.Name)
@@ -949,7 +1022,21 @@ func (p *Planner) reconcile(controlPlane *rkev1.RKEControlPlane, tokensSecret pl
 			// 4. unavailable < concurrency meaning we have capacity to make something unavailable
 			// 5. If the plan was successful in application but the probes never went healthy
 			logrus.Debugf("[planner] rkecluster %s/%s reconcile tier %s - concurrency: %d, unavailable: %d", controlPlane.Namespace, controlPlane.Name, tierName, concurrency, unavailable)
-			if isInDrain(r.entry) || r.entry.Plan.Failed || concurrency == 0 || unavailable < concurrency || planAppliedButProbesNeverHealthy(r.entry) {
+			if rollout.holdPlanChanges && !isInDrain(r.entry) {
+				// Plan changes are only reported during a dry-run. Nodes which are already draining are not held.
+				logrus.Debugf("[planner] rkecluster %s/%s reconcile tier %s - holding plan change for machine %s/%s until the plan dry-run is removed", controlPlane.Namespace, controlPlane.Name, tierName, r.entry.Machine.Namespace, r.entry.Machine.Name)
+				messages[r.entry.Machine.Name] = append(messages[r.entry.Machine.Name], "waiting for plan dry-run to be removed")
+			} else if rollout.holdDisruptiveChanges && !isInDrain(r.entry) && !r.entry.Plan.Failed && isDisruptivePlanChange(r.entry.Plan.AppliedPlan, r.desiredPlan) {
+				// Changes requiring a drain or a restart are held until a maintenance window opens. Nodes which are
+				// already draining or failed to apply their plan are not held, as they are already disrupted.
+				logrus.Debugf("[planner] rkecluster %s/%s reconcile tier %s - holding plan change for machine %s/%s until the next maintenance window", controlPlane.Namespace, controlPlane.Name, tierName, r.entry.Machine.Namespace, r.entry.Machine.Name)
+				messages[r.entry.Machine.Name] = append(messages[r.entry.Machine.Name], "waiting for maintenance window")
+			} else if canaryStage.holds(r) && !isInDrain(r.entry) {
+				// Plan changes are only delivered to the remaining machines of the tier once its canaries are healthy
+				// for the soak time. Nodes which are already draining are not held.
+				logrus.Debugf("[planner] rkecluster %s/%s reconcile tier %s - holding plan change for machine %s/%s until the canaries are healthy", controlPlane.Namespace, controlPlane.Name, tierName, r.entry.Machine.Namespace, r.entry.Machine.Name)
+				messages[r.entry.Machine.Name] = append(messages[r.entry.Machine.Name], "waiting for canary machine(s)")
+			} else if isInDrain(r.entry) || r.entry.Plan.Failed || concurrency == 0 || unavailable < concurrency || planAppliedButProbesNeverHealthy(r.entry) {
 				if !isUnavailable(r) {
 					unavailable++
 				}
@@ -1035,6 +1122,11 @@ func (p *Planner) reconcile(controlPlane *rkev1.RKEControlPlane, tokensSecret pl
 		return firstError
 	}
 
+	// A halted canary rollout stops the planner from processing the following tiers.
+	if canaryStage.halted() {
+		return errWaiting(canaryStage.message)
+	}
+
 	// The messages for these machines come from the machine itself, so nothing needs to be added.
 	// we want these errors to get reported, but not block the process
 	if len(errMachines) > 0 {
@@ -28,7 +28,9 @@ type mockPlanner struct {
 	rkeBootstrap                  *fake.MockClientInterface[*rkev1.RKEBootstrap, *rkev1.RKEBootstrapList]
 	rkeBootstrapCache             *fake.MockCacheInterface[*rkev1.RKEBootstrap]
 	rkeControlPlanes              *fake.MockControllerInterface[*rkev1.RKEControlPlane, *rkev1.RKEControlPlaneList]
+	etcdSnapshots                 *fake.MockClientInterface[*rkev1.ETCDSnapshot, *rkev1.ETCDSnapshotList]
 	etcdSnapshotCache             *fake.MockCacheInterface[*rkev1.ETCDSnapshot]
+	etcdSnapshotPolicyCache       *fake.MockCacheInterface[*rkev1.ETCDSnapshotPolicy]
 	secretClient                  *fake.MockClientInterface[*v1.Secret, *v1.SecretList]
 	secretCache                   *fake.MockCacheInterface[*v1.Secret]
 	configMapCache                *fake.MockCacheInterface[*v1.ConfigMap]
@@ -48,7 +50,9 @@ func newMockPlanner(t *testing.T, functions InfoFunctions) *mockPlanner {
 		rkeBootstrap:                  fake.NewMockClientInterface[*rkev1.RKEBootstrap, *rkev1.RKEBootstrapList](ctrl),
 		rkeBootstrapCache:             fake.NewMockCacheInterface[*rkev1.RKEBootstrap](ctrl),
 		rkeControlPlanes:              fake.NewMockControllerInterface[*rkev1.RKEControlPlane, *rkev1.RKEControlPlaneList](ctrl),
+		etcdSnapshots:                 fake.NewMockClientInterface[*rkev1.ETCDSnapshot, *rkev1.ETCDSnapshotList](ctrl),
 		etcdSnapshotCache:             fake.NewMockCacheInterface[*rkev1.ETCDSnapshot](ctrl),
+		etcdSnapshotPolicyCache:       fake.NewMockCacheInterface[*rkev1.ETCDSnapshotPolicy](ctrl),
 		secretClient:                  fake.NewMockClientInterface[*v1.Secret, *v1.SecretList](ctrl),
 		secretCache:                   fake.NewMockCacheInterface[*v1.Secret](ctrl),
 		configMapCache:                fake.NewMockCacheInterface[*v1.ConfigMap](ctrl),
@@ -81,7 +85,9 @@ func newMockPlanner(t *testing.T, functions InfoFunctions) *mockPlanner {
 		rkeControlPlanes:              mp.rkeControlPlanes,
 		rkeBootstrap:                  mp.rkeBootstrap,
 		rkeBootstrapCache:             mp.rkeBootstrapCache,
+		etcdSnapshots:                 mp.etcdSnapshots,
 		etcdSnapshotCache:             mp.etcdSnapshotCache,
+		etcdSnapshotPolicyCache:       mp.etcdSnapshotPolicyCache,
 		etcdS3Args: s3Args{
 			secretCache: mp.secretCache,
 		},
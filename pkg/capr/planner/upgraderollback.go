@@ -0,0 +1,283 @@
+package planner
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	"github.com/rancher/rancher/pkg/capr"
+	"github.com/rancher/wrangler/v3/pkg/merr"
+	"github.com/rancher/wrangler/v3/pkg/name"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const defaultUpgradeUnhealthyTimeout = 10 * time.Minute
+
+// reconcileUpgradeRollback takes an etcd snapshot with the spec applied before a Kubernetes minor version upgrade, before
+// any upgraded plan is delivered. While the upgrade is in progress, it counts the machines which fail to apply their
+// upgraded plan and, once the failure threshold is reached, requests the restore of the snapshot if automatic rollback
+// is enabled. The restore itself is requested on the cluster by the provisioning cluster controller.
+func (p *Planner) reconcileUpgradeRollback(cp *rkev1.RKEControlPlane, status rkev1.RKEControlPlaneStatus, tokensSecret plan.Secret, clusterPlan *plan.Plan) (rkev1.RKEControlPlaneStatus, error) {
+	upgrade := status.UpgradeRollback.DeepCopy()
+	if upgrade != nil && upgrade.Phase == rkev1.UpgradeRollbackPhaseRollbackRequested {
+		return waitForUpgradeRollback(cp, status, upgrade)
+	}
+
+	rollback := cp.Spec.UpgradeStrategy.UpgradeRollback
+	if rollback == nil || status.AppliedSpec == nil {
+		return status, nil
+	}
+
+	from, to := status.AppliedSpec.KubernetesVersion, cp.Spec.KubernetesVersion
+	minorUpgrade, err := isMinorUpgrade(from, to)
+	if err != nil {
+		return status, err
+	}
+	if !minorUpgrade {
+		if upgrade != nil && from == upgrade.ToKubernetesVersion &&
+			(upgrade.Phase == rkev1.UpgradeRollbackPhaseUpgrading || upgrade.Phase == rkev1.UpgradeRollbackPhaseFailed) {
+			upgrade.Phase = rkev1.UpgradeRollbackPhaseCompleted
+			status.UpgradeRollback = upgrade
+		}
+		return status, nil
+	}
+
+	if upgrade == nil || upgrade.FromKubernetesVersion != from || upgrade.ToKubernetesVersion != to || upgrade.Phase == rkev1.UpgradeRollbackPhaseRolledBack {
+		preUpgradeSpec, err := capr.CompressInterface(status.AppliedSpec)
+		if err != nil {
+			return status, err
+		}
+		status.UpgradeRollback = &rkev1.UpgradeRollbackStatus{
+			FromKubernetesVersion: from,
+			ToKubernetesVersion:   to,
+			PreUpgradeSpec:        preUpgradeSpec,
+			Phase:                 rkev1.UpgradeRollbackPhaseSnapshot,
+		}
+		return status, errWaitingf("taking etcd snapshot before upgrading Kubernetes from %s to %s", from, to)
+	}
+
+	// The snapshot is taken with the spec applied before the upgrade, so that the etcd nodes are not upgraded yet.
+	preUpgradeControlPlane, err := preUpgradeControlPlane(cp, status.AppliedSpec, upgrade)
+	if err != nil {
+		return status, err
+	}
+
+	switch upgrade.Phase {
+	case rkev1.UpgradeRollbackPhaseSnapshot:
+		_, joinServer, _, err := p.findInitNode(cp, clusterPlan)
+		if err != nil {
+			return status, err
+		}
+		if joinServer == "" {
+			return status, errWaiting("waiting for join url to be available on bootstrap node to take the pre-upgrade etcd snapshot")
+		}
+		if errs := p.runEtcdSnapshotCreate(preUpgradeControlPlane, tokensSecret, clusterPlan, joinServer, preUpgradeSnapshotName(upgrade), nil); len(errs) > 0 {
+			for _, err := range errs {
+				if !IsErrWaiting(err) {
+					upgrade.Phase = rkev1.UpgradeRollbackPhaseSnapshotFailed
+					status.UpgradeRollback = upgrade
+					return status, errWaitingf("pre-upgrade etcd snapshot failed: %v", err)
+				}
+			}
+			return status, errWaiting(merr.NewErrors(errs...).Error())
+		}
+		upgrade.Phase = rkev1.UpgradeRollbackPhaseSnapshotRestartCluster
+		status.UpgradeRollback = upgrade
+		return status, errWaiting("pre-upgrade etcd snapshot taken, restarting etcd nodes")
+	case rkev1.UpgradeRollbackPhaseSnapshotRestartCluster:
+		if err := p.runEtcdSnapshotManagementServiceStart(preUpgradeControlPlane, tokensSecret, clusterPlan, isEtcd, "pre-upgrade etcd snapshot"); err != nil {
+			return status, err
+		}
+		snapshot, err := p.findPreUpgradeSnapshot(cp, upgrade)
+		if err != nil {
+			return status, err
+		}
+		if snapshot == nil {
+			return status, errWaitingf("waiting for pre-upgrade etcd snapshot %s to be recorded", preUpgradeSnapshotName(upgrade))
+		}
+		upgrade.SnapshotName = snapshot.Name
+		upgrade.Phase = rkev1.UpgradeRollbackPhaseUpgrading
+		upgrade.UpgradeStartTime = &metav1.Time{Time: time.Now().UTC().Truncate(time.Second)}
+		status.UpgradeRollback = upgrade
+		return status, errWaitingf("pre-upgrade etcd snapshot %s recorded, upgrading Kubernetes from %s to %s", snapshot.Name, from, to)
+	case rkev1.UpgradeRollbackPhaseSnapshotFailed:
+		if _, ok := cp.Annotations[capr.UpgradeSnapshotSkipAnnotation]; ok {
+			logrus.Warnf("[planner] rkecluster %s/%s: upgrading Kubernetes from %s to %s without a pre-upgrade etcd snapshot as the %s annotation is set", cp.Namespace, cp.Name, from, to, capr.UpgradeSnapshotSkipAnnotation)
+			upgrade.Phase = rkev1.UpgradeRollbackPhaseUpgrading
+			upgrade.UpgradeStartTime = &metav1.Time{Time: time.Now().UTC().Truncate(time.Second)}
+			status.UpgradeRollback = upgrade
+			return status, errWaitingf("pre-upgrade etcd snapshot skipped, upgrading Kubernetes from %s to %s", from, to)
+		}
+		if retry := cp.Annotations[capr.UpgradeSnapshotRetryAnnotation]; retry != "" && retry != upgrade.SnapshotRetry {
+			upgrade.SnapshotRetry = retry
+			upgrade.Phase = rkev1.UpgradeRollbackPhaseSnapshot
+			status.UpgradeRollback = upgrade
+			return status, errWaitingf("retrying etcd snapshot before upgrading Kubernetes from %s to %s", from, to)
+		}
+		return status, errWaitingf("pre-upgrade etcd snapshot failed, the upgrade of Kubernetes to %s is held until the Kubernetes version is changed, the upgrade rollback is disabled, the %s annotation is set to a new value to retry the snapshot, or the %s annotation is set to upgrade without it", to, capr.UpgradeSnapshotRetryAnnotation, capr.UpgradeSnapshotSkipAnnotation)
+	case rkev1.UpgradeRollbackPhaseUpgrading:
+		unhealthyTimeout := rollback.UnhealthyTimeout.Duration
+		if unhealthyTimeout <= 0 {
+			unhealthyTimeout = defaultUpgradeUnhealthyTimeout
+		}
+		threshold := rollback.FailureThreshold
+		if threshold <= 0 {
+			threshold = 1
+		}
+
+		failed, requeueAfter := upgradeFailures(clusterPlan, upgrade.UpgradeStartTime, unhealthyTimeout, time.Now())
+		if len(failed) < threshold {
+			if requeueAfter > 0 {
+				// the probes are not re-evaluated by any other object, so reconcile again once the timeout is reached
+				p.rkeControlPlanes.EnqueueAfter(cp.Namespace, cp.Name, requeueAfter)
+			}
+			return status, nil
+		}
+
+		upgrade.FailedMachines = failed
+		if upgrade.SnapshotName == "" {
+			// the pre-upgrade etcd snapshot was skipped, so there is nothing to roll back to
+			logrus.Warnf("[planner] rkecluster %s/%s: upgrade of Kubernetes from %s to %s failed on machine(s) %s, no pre-upgrade etcd snapshot was taken", cp.Namespace, cp.Name, from, to, strings.Join(failed, ", "))
+			upgrade.Phase = rkev1.UpgradeRollbackPhaseFailed
+			status.UpgradeRollback = upgrade
+			return status, nil
+		}
+		if !rollback.AutoRollback {
+			logrus.Warnf("[planner] rkecluster %s/%s: upgrade of Kubernetes from %s to %s failed on machine(s) %s, the pre-upgrade etcd snapshot %s can be restored", cp.Namespace, cp.Name, from, to, strings.Join(failed, ", "), upgrade.SnapshotName)
+			upgrade.Phase = rkev1.UpgradeRollbackPhaseFailed
+			status.UpgradeRollback = upgrade
+			return status, nil
+		}
+
+		logrus.Infof("[planner] rkecluster %s/%s: upgrade of Kubernetes from %s to %s failed on machine(s) %s, requesting rollback to etcd snapshot %s", cp.Namespace, cp.Name, from, to, strings.Join(failed, ", "), upgrade.SnapshotName)
+		upgrade.Phase = rkev1.UpgradeRollbackPhaseRollbackRequested
+		status.UpgradeRollback = upgrade
+		return status, errWaitingf("upgrade of Kubernetes to %s failed on machine(s) %s, rolling back to etcd snapshot %s", to, atMostThree(failed), upgrade.SnapshotName)
+	}
+
+	return status, nil
+}
+
+// waitForUpgradeRollback holds the reconciliation of the machines until the pre-upgrade etcd snapshot was restored.
+func waitForUpgradeRollback(cp *rkev1.RKEControlPlane, status rkev1.RKEControlPlaneStatus, upgrade *rkev1.UpgradeRollbackStatus) (rkev1.RKEControlPlaneStatus, error) {
+	restore := cp.Spec.ETCDSnapshotRestore
+	if restore != nil && restore.Name == upgrade.SnapshotName &&
+		status.ETCDSnapshotRestorePhase == rkev1.ETCDSnapshotPhaseFinished &&
+		equality.Semantic.DeepEqual(status.ETCDSnapshotRestore, restore) {
+		upgrade.Phase = rkev1.UpgradeRollbackPhaseRolledBack
+		status.UpgradeRollback = upgrade
+		return status, nil
+	}
+	return status, errWaitingf("waiting for etcd snapshot %s to be restored to roll back the upgrade of Kubernetes to %s", upgrade.SnapshotName, upgrade.ToKubernetesVersion)
+}
+
+// isMinorUpgrade returns true if the Kubernetes version to upgrade to is of a newer minor or major version.
+func isMinorUpgrade(from, to string) (bool, error) {
+	fromVersion, err := semver.NewVersion(from)
+	if err != nil {
+		return false, err
+	}
+	toVersion, err := semver.NewVersion(to)
+	if err != nil {
+		return false, err
+	}
+	if toVersion.Major() != fromVersion.Major() {
+		return toVersion.Major() > fromVersion.Major(), nil
+	}
+	return toVersion.Minor() > fromVersion.Minor(), nil
+}
+
+// preUpgradeControlPlane returns a copy of the controlplane with the spec applied before the upgrade. The cluster spec
+// annotation, which is stored in the metadata of the snapshot and read when restoring the Kubernetes version, is
+// rewritten with the Kubernetes version in use before the upgrade, so that restoring the snapshot rolls back the
+// upgrade.
+func preUpgradeControlPlane(cp *rkev1.RKEControlPlane, appliedSpec *rkev1.RKEControlPlaneSpec, upgrade *rkev1.UpgradeRollbackStatus) (*rkev1.RKEControlPlane, error) {
+	preUpgradeControlPlane := cp.DeepCopy()
+	if upgrade.PreUpgradeSpec != "" {
+		var spec rkev1.RKEControlPlaneSpec
+		if err := capr.DecompressInterface(upgrade.PreUpgradeSpec, &spec); err != nil {
+			return nil, fmt.Errorf("decoding the spec applied before the upgrade: %w", err)
+		}
+		preUpgradeControlPlane.Spec = spec
+	} else {
+		preUpgradeControlPlane.Spec = *appliedSpec.DeepCopy()
+	}
+
+	if v, ok := cp.Annotations[capr.ClusterSpecAnnotation]; ok {
+		clusterSpec, err := capr.DecompressClusterSpec(v)
+		if err != nil {
+			return nil, fmt.Errorf("decoding the cluster spec annotation: %w", err)
+		}
+		clusterSpec.KubernetesVersion = upgrade.FromKubernetesVersion
+		if preUpgradeControlPlane.Annotations[capr.ClusterSpecAnnotation], err = capr.CompressInterface(clusterSpec); err != nil {
+			return nil, err
+		}
+	}
+	return preUpgradeControlPlane, nil
+}
+
+// preUpgradeSnapshotName returns the base name of the etcd snapshots taken before the upgrade.
+func preUpgradeSnapshotName(upgrade *rkev1.UpgradeRollbackStatus) string {
+	return "pre-upgrade-" + name.Hex(upgrade.FromKubernetesVersion+upgrade.ToKubernetesVersion, 8)
+}
+
+// findPreUpgradeSnapshot returns the latest successful etcdsnapshot object of the snapshots taken before the upgrade,
+// or nil if none was recorded yet.
+func (p *Planner) findPreUpgradeSnapshot(cp *rkev1.RKEControlPlane, upgrade *rkev1.UpgradeRollbackStatus) (*rkev1.ETCDSnapshot, error) {
+	snapshots, err := p.etcdSnapshotCache.List(cp.Namespace, labels.SelectorFromSet(labels.Set{capr.ClusterNameLabel: cp.Spec.ClusterName}))
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotName := preUpgradeSnapshotName(upgrade)
+	var latest *rkev1.ETCDSnapshot
+	for _, snapshot := range snapshots {
+		if !strings.HasPrefix(snapshot.SnapshotFile.Name, snapshotName) || snapshot.SnapshotFile.Status != "successful" || snapshot.SnapshotFile.CreatedAt == nil {
+			continue
+		}
+		if latest == nil || snapshot.SnapshotFile.CreatedAt.After(latest.SnapshotFile.CreatedAt.Time) {
+			latest = snapshot
+		}
+	}
+	return latest, nil
+}
+
+// upgradeFailures returns the names of the machines which received a plan since the upgrade started, and failed to
+// apply it or whose probes did not become healthy within the unhealthy timeout. It also returns after how long the
+// next machine whose probes are not healthy yet reaches the timeout.
+func upgradeFailures(clusterPlan *plan.Plan, upgradeStartTime *metav1.Time, unhealthyTimeout time.Duration, now time.Time) ([]string, time.Duration) {
+	var (
+		failed       []string
+		requeueAfter time.Duration
+	)
+
+	for _, entry := range collect(clusterPlan, isNotDeleting) {
+		if entry.Plan == nil || entry.Metadata == nil {
+			continue
+		}
+		updated, err := time.Parse(time.RFC3339, entry.Metadata.Annotations[capr.PlanUpdatedTimeAnnotation])
+		if err != nil || (upgradeStartTime != nil && updated.Before(upgradeStartTime.Time)) {
+			continue
+		}
+		switch {
+		case entry.Plan.Failed:
+			failed = append(failed, entry.Machine.Name)
+		case planAppliedButProbesNeverHealthy(entry):
+			if remaining := updated.Add(unhealthyTimeout).Sub(now); remaining > 0 {
+				if requeueAfter == 0 || remaining < requeueAfter {
+					requeueAfter = remaining
+				}
+				continue
+			}
+			failed = append(failed, entry.Machine.Name)
+		}
+	}
+
+	return failed, requeueAfter
+}
@@ -0,0 +1,278 @@
+package planner
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	provv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	"github.com/rancher/rancher/pkg/capr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/yaml"
+)
+
+func Test_isMinorUpgrade(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		want    bool
+		wantErr bool
+	}{
+		{
+			name: "minor upgrade",
+			from: "v1.29.5+rke2r1",
+			to:   "v1.30.1+rke2r1",
+			want: true,
+		},
+		{
+			name: "major upgrade",
+			from: "v1.30.1+k3s1",
+			to:   "v2.0.0+k3s1",
+			want: true,
+		},
+		{
+			name: "patch upgrade",
+			from: "v1.30.1+rke2r1",
+			to:   "v1.30.2+rke2r1",
+		},
+		{
+			name: "same version",
+			from: "v1.30.1+rke2r1",
+			to:   "v1.30.1+rke2r1",
+		},
+		{
+			name: "downgrade",
+			from: "v1.30.1+rke2r1",
+			to:   "v1.29.5+rke2r1",
+		},
+		{
+			name:    "invalid version",
+			from:    "v1.30.1+rke2r1",
+			to:      "latest",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := isMinorUpgrade(tt.from, tt.to)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_upgradeFailures(t *testing.T) {
+	now := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	upgradeStartTime := &metav1.Time{Time: now.Add(-time.Hour)}
+
+	node := func(failed, applied, healthy bool) *plan.Node {
+		n := &plan.Node{
+			Failed:  failed,
+			Healthy: healthy,
+		}
+		if applied {
+			n.AppliedPlan = &n.Plan
+		}
+		return n
+	}
+
+	tests := []struct {
+		name             string
+		nodes            map[string]*plan.Node
+		updated          map[string]time.Duration
+		wantFailed       []string
+		wantRequeueAfter time.Duration
+	}{
+		{
+			name: "healthy",
+			nodes: map[string]*plan.Node{
+				"a": node(false, true, true),
+			},
+			updated: map[string]time.Duration{"a": 30 * time.Minute},
+		},
+		{
+			name: "failed plan",
+			nodes: map[string]*plan.Node{
+				"a": node(true, false, false),
+				"b": node(false, true, true),
+			},
+			updated:    map[string]time.Duration{"a": 30 * time.Minute, "b": 30 * time.Minute},
+			wantFailed: []string{"a"},
+		},
+		{
+			name: "failed before the upgrade started",
+			nodes: map[string]*plan.Node{
+				"a": node(true, false, false),
+			},
+			updated: map[string]time.Duration{"a": 2 * time.Hour},
+		},
+		{
+			name: "probes not healthy within the timeout",
+			nodes: map[string]*plan.Node{
+				"a": node(false, true, false),
+			},
+			updated:    map[string]time.Duration{"a": 15 * time.Minute},
+			wantFailed: []string{"a"},
+		},
+		{
+			name: "probes not healthy yet",
+			nodes: map[string]*plan.Node{
+				"a": node(false, true, false),
+				"b": node(false, true, false),
+			},
+			updated:          map[string]time.Duration{"a": 4 * time.Minute, "b": 7 * time.Minute},
+			wantRequeueAfter: 3 * time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusterPlan := &plan.Plan{
+				Nodes:    map[string]*plan.Node{},
+				Metadata: map[string]*plan.Metadata{},
+				Machines: map[string]*capi.Machine{},
+			}
+			for name, node := range tt.nodes {
+				clusterPlan.Nodes[name] = node
+				clusterPlan.Metadata[name] = &plan.Metadata{
+					Annotations: map[string]string{
+						capr.PlanUpdatedTimeAnnotation: now.Add(-tt.updated[name]).Format(time.RFC3339),
+					},
+				}
+				clusterPlan.Machines[name] = &capi.Machine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: name,
+					},
+				}
+			}
+
+			failed, requeueAfter := upgradeFailures(clusterPlan, upgradeStartTime, 10*time.Minute, now)
+			assert.Equal(t, tt.wantFailed, failed)
+			assert.Equal(t, tt.wantRequeueAfter, requeueAfter)
+		})
+	}
+}
+
+func Test_reconcileUpgradeRollbackSnapshotFailed(t *testing.T) {
+	tests := []struct {
+		name              string
+		annotations       map[string]string
+		snapshotRetry     string
+		wantPhase         rkev1.UpgradeRollbackPhase
+		wantSnapshotRetry string
+		wantErrContains   string
+	}{
+		{
+			name:            "held",
+			wantPhase:       rkev1.UpgradeRollbackPhaseSnapshotFailed,
+			wantErrContains: "is held until",
+		},
+		{
+			name:              "retried",
+			annotations:       map[string]string{capr.UpgradeSnapshotRetryAnnotation: "1"},
+			wantPhase:         rkev1.UpgradeRollbackPhaseSnapshot,
+			wantSnapshotRetry: "1",
+			wantErrContains:   "retrying etcd snapshot",
+		},
+		{
+			name:              "already retried",
+			annotations:       map[string]string{capr.UpgradeSnapshotRetryAnnotation: "1"},
+			snapshotRetry:     "1",
+			wantPhase:         rkev1.UpgradeRollbackPhaseSnapshotFailed,
+			wantSnapshotRetry: "1",
+			wantErrContains:   "is held until",
+		},
+		{
+			name:            "skipped",
+			annotations:     map[string]string{capr.UpgradeSnapshotSkipAnnotation: "true"},
+			wantPhase:       rkev1.UpgradeRollbackPhaseUpgrading,
+			wantErrContains: "pre-upgrade etcd snapshot skipped",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cp := &rkev1.RKEControlPlane{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   "fleet-default",
+					Name:        "test",
+					Annotations: tt.annotations,
+				},
+				Spec: rkev1.RKEControlPlaneSpec{
+					KubernetesVersion: "v1.30.1+rke2r1",
+					ClusterConfiguration: rkev1.ClusterConfiguration{
+						UpgradeStrategy: rkev1.ClusterUpgradeStrategy{
+							UpgradeRollback: &rkev1.UpgradeRollback{},
+						},
+					},
+				},
+			}
+			status := rkev1.RKEControlPlaneStatus{
+				AppliedSpec: &rkev1.RKEControlPlaneSpec{KubernetesVersion: "v1.29.5+rke2r1"},
+				UpgradeRollback: &rkev1.UpgradeRollbackStatus{
+					FromKubernetesVersion: "v1.29.5+rke2r1",
+					ToKubernetesVersion:   "v1.30.1+rke2r1",
+					Phase:                 rkev1.UpgradeRollbackPhaseSnapshotFailed,
+					SnapshotRetry:         tt.snapshotRetry,
+				},
+			}
+
+			status, err := (&Planner{}).reconcileUpgradeRollback(cp, status, plan.Secret{}, &plan.Plan{})
+			require.Error(t, err)
+			assert.True(t, IsErrWaiting(err))
+			assert.Contains(t, err.Error(), tt.wantErrContains)
+			assert.Equal(t, tt.wantPhase, status.UpgradeRollback.Phase)
+			assert.Equal(t, tt.wantSnapshotRetry, status.UpgradeRollback.SnapshotRetry)
+			assert.Equal(t, tt.wantPhase == rkev1.UpgradeRollbackPhaseUpgrading, status.UpgradeRollback.UpgradeStartTime != nil)
+		})
+	}
+}
+
+func Test_preUpgradeControlPlaneSnapshotMetadata(t *testing.T) {
+	clusterSpec, err := capr.CompressInterface(provv1.ClusterSpec{KubernetesVersion: "v1.30.1+rke2r1"})
+	require.NoError(t, err)
+	preUpgradeSpec, err := capr.CompressInterface(rkev1.RKEControlPlaneSpec{KubernetesVersion: "v1.29.5+rke2r1"})
+	require.NoError(t, err)
+
+	cp := &rkev1.RKEControlPlane{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "fleet-default",
+			Name:        "test",
+			Annotations: map[string]string{capr.ClusterSpecAnnotation: clusterSpec},
+		},
+		Spec: rkev1.RKEControlPlaneSpec{KubernetesVersion: "v1.30.1+rke2r1"},
+	}
+	upgrade := &rkev1.UpgradeRollbackStatus{
+		FromKubernetesVersion: "v1.29.5+rke2r1",
+		ToKubernetesVersion:   "v1.30.1+rke2r1",
+		PreUpgradeSpec:        preUpgradeSpec,
+	}
+
+	preUpgrade, err := preUpgradeControlPlane(cp, &rkev1.RKEControlPlaneSpec{KubernetesVersion: "v1.30.1+rke2r1"}, upgrade)
+	require.NoError(t, err)
+	assert.Equal(t, "v1.29.5+rke2r1", preUpgrade.Spec.KubernetesVersion)
+	assert.Equal(t, clusterSpec, cp.Annotations[capr.ClusterSpecAnnotation], "the controlplane must not be modified")
+
+	file := getEtcdSnapshotExtraMetadata(preUpgrade, capr.GetRuntime(preUpgrade.Spec.KubernetesVersion))
+	require.NotNil(t, file)
+	content, err := base64.StdEncoding.DecodeString(file.Content)
+	require.NoError(t, err)
+	var cm corev1.ConfigMap
+	require.NoError(t, yaml.Unmarshal(content, &cm))
+
+	snapshotClusterSpec, err := capr.DecompressClusterSpec(cm.Data[EtcdSnapshotConfigMapKey])
+	require.NoError(t, err)
+	assert.Equal(t, "v1.29.5+rke2r1", snapshotClusterSpec.KubernetesVersion)
+}
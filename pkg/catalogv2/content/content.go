@@ -34,6 +34,7 @@
 	"github.com/rancher/rancher/pkg/catalogv2/helm"
 	helmhttp "github.com/rancher/rancher/pkg/catalogv2/http"
 	"github.com/rancher/rancher/pkg/catalogv2/oci"
+	"github.com/rancher/rancher/pkg/catalogv2/verify"
 	catalogcontrollers "github.com/rancher/rancher/pkg/generated/controllers/catalog.cattle.io/v1"
 	"github.com/rancher/rancher/pkg/settings"
 	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
@@ -227,6 +228,13 @@ func (c *Manager) Chart(namespace, name, chartName, version string, skipFilter b
 		return nil, err
 	}
 
+	return c.ChartContent(namespace, name, chart)
+}
+
+// ChartContent retrieves the content of a chart version of the index of a Helm repository, which was returned by
+// ChartVersion. Unlike Chart, the chart version isn't looked up again in the index, so the content is the one of the
+// chart version whose verification was checked even if the index was refreshed in between.
+func (c *Manager) ChartContent(namespace, name string, chart *repo.ChartVersion) (io.ReadCloser, error) {
 	// Retrieve the clusterRepo
 	repo, err := c.getRepo(namespace, name)
 	if err != nil {
@@ -259,28 +267,108 @@ func (c *Manager) Chart(namespace, name, chartName, version string, skipFilter b
 	case registry.IsOCI(chart.URLs[0]):
 		return oci.Chart(secret, chart, *repo.spec)
 	default:
-		return helmhttp.Chart(secret, repo.status.URL, repo.spec.CABundle, repo.spec.InsecureSkipTLSverify, repo.spec.DisableSameOriginCheck, chart)
+		chartData, err := helmhttp.Chart(secret, repo.status.URL, repo.spec.CABundle, repo.spec.InsecureSkipTLSverify, repo.spec.DisableSameOriginCheck, chart)
+		if err != nil || repo.spec.Verification == nil {
+			return chartData, err
+		}
+		return checkDigest(chart, chartData)
 	}
 }
 
+// checkDigest checks that the chart downloaded from an HTTP repository matches the digest of the index, which is the
+// digest the provenance file of the chart was verified against.
+func checkDigest(chart *repo.ChartVersion, chartData io.ReadCloser) (io.ReadCloser, error) {
+	defer chartData.Close()
+	data, err := io.ReadAll(chartData)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify.CheckDigest(chart, data); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
 // Info retrieves detailed information about a specific Helm chart from a Helm repository.
 //
 // The function uses the Chart method to get the content of the Helm chart.
 // The Chart method is called with the skipFilter parameter hard-coded to true,
 // meaning that no filtering is applied to the results.
 //
-// Once the chart content is retrieved, the function uses the InfoFromTarball method to extract detailed information.
+// Once the chart content is retrieved, the function uses the InfoFromTarball method to extract detailed information,
+// and adds the result of the verification of the signature of the chart if the repository verifies them.
 //
 // The function returns a types.ChartInfo pointer which represents the detailed information
 // about the Helm chart and can be used by the Steve API.
 func (c *Manager) Info(namespace, name, chartName, version string) (*types.ChartInfo, error) {
-	chart, err := c.Chart(namespace, name, chartName, version, true)
+	chartVersion, verification, err := c.ChartVersion(namespace, name, chartName, version, true)
+	if err != nil {
+		return nil, err
+	}
+	chart, err := c.ChartContent(namespace, name, chartVersion)
 	if err != nil {
 		return nil, err
 	}
 	defer chart.Close()
 
-	return helm.InfoFromTarball(chart)
+	info, err := helm.InfoFromTarball(chart)
+	if err != nil {
+		return nil, err
+	}
+	info.Verification = verification
+	return info, nil
+}
+
+// Verification returns the result of the verification of the signature of a chart, which the repository controllers
+// record in the annotations of the chart versions of the index.
+//
+// The function returns nil if the repository doesn't verify the signatures of its charts. The status of the returned
+// types.ChartVerification is empty if the chart hasn't been verified yet.
+func (c *Manager) Verification(namespace, name, chartName, version string) (*types.ChartVerification, error) {
+	repo, err := c.getRepo(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if repo.spec.Verification == nil {
+		return nil, nil
+	}
+
+	_, verification, err := c.ChartVersion(namespace, name, chartName, version, true)
+	return verification, err
+}
+
+// ChartVersion resolves a chart version in the index of a Helm repository, along with the result of the verification
+// of its signature recorded in that same chart version. The content of the chart version is retrieved with
+// ChartContent.
+//
+// The returned types.ChartVerification is nil if the repository doesn't verify the signatures of its charts.
+func (c *Manager) ChartVersion(namespace, name, chartName, version string, skipFilter bool) (*repo.ChartVersion, *types.ChartVerification, error) {
+	r, err := c.getRepo(namespace, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	index, err := c.Index(namespace, name, "", skipFilter)
+	if err != nil {
+		return nil, nil, err
+	}
+	chart, err := index.Get(chartName, version)
+	if err != nil {
+		return nil, nil, err
+	}
+	if r.spec.Verification == nil {
+		return chart, nil, nil
+	}
+
+	verification := &types.ChartVerification{
+		Required: r.spec.Verification.Required,
+	}
+	if result, ok := verify.FromChart(chart); ok {
+		verification.Status = string(result.Status)
+		verification.Signer = result.Signer
+		verification.Message = result.Message
+	}
+	return chart, verification, nil
 }
 
 // getRepo returns a cluster repository based on the name
@@ -22,11 +22,13 @@
 	"time"
 	"unicode/utf8"
 
+	"github.com/rancher/apiserver/pkg/apierror"
 	"github.com/rancher/apiserver/pkg/types"
 	types2 "github.com/rancher/rancher/pkg/api/steve/catalog/types"
 	catalog "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
 	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/catalogv2/content"
+	"github.com/rancher/rancher/pkg/catalogv2/verify"
 	catalogcontrollers "github.com/rancher/rancher/pkg/generated/controllers/catalog.cattle.io/v1"
 	namespaces "github.com/rancher/rancher/pkg/namespace"
 	"github.com/rancher/rancher/pkg/settings"
@@ -735,7 +737,17 @@ func (s *Operations) enableKustomize(annotations map[string]string, upgrade bool
 // and then creates and return a Command containing the name of the values file, name of the chart file, the chart data
 // and if the command should use kustomize.sh
 func (s *Operations) getChartCommand(namespace, name, chartName, chartVersion string, upgrade bool, annotations map[string]string, values map[string]interface{}) (Command, error) {
-	chart, err := s.contentManager.Chart(namespace, name, chartName, chartVersion, true)
+	// the chart version is resolved once, so that the chart which is installed is the one whose verification is checked
+	// even if the index is refreshed in between
+	version, verification, err := s.contentManager.ChartVersion(namespace, name, chartName, chartVersion, true)
+	if err != nil {
+		return Command{}, err
+	}
+	if err := checkVerification(chartName, chartVersion, verification); err != nil {
+		return Command{}, err
+	}
+
+	chart, err := s.contentManager.ChartContent(namespace, name, version)
 	if err != nil {
 		return Command{}, err
 	}
@@ -770,6 +782,23 @@ func (s *Operations) getChartCommand(namespace, name, chartName, chartVersion st
 	return c, nil
 }
 
+// checkVerification refuses a chart which isn't signed by a trusted key if its repository requires it.
+func checkVerification(chartName, chartVersion string, verification *types2.ChartVerification) error {
+	if verification == nil || !verification.Required || verification.Status == string(verify.StatusVerified) {
+		return nil
+	}
+
+	reason := verification.Message
+	switch {
+	case verification.Status == "":
+		reason = "its signature hasn't been verified yet"
+	case reason == "":
+		reason = "it is " + verification.Status
+	}
+	return apierror.NewAPIError(validation.PermissionDenied,
+		fmt.Sprintf("the repository requires signed charts, chart %s version %s isn't signed by a trusted key: %s", chartName, chartVersion, reason))
+}
+
 // getInstallCommand receives the repository namespace, name, and body of the request.
 // It decodes the request to get chart information for creating the `helm install` command
 // along with args. It returns the catalog.OperationStatus struct and a slice of commands
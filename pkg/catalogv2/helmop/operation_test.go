@@ -327,3 +327,49 @@ func Test_mergeTolerations(t *testing.T) {
 		asserts.ElementsMatch(resp, t.expected, t.name)
 	}
 }
+
+func Test_checkVerification(t *testing.T) {
+	testCases := []struct {
+		name         string
+		verification *types.ChartVerification
+		expectedErr  string
+	}{
+		{
+			name: "repository doesn't verify signatures",
+		},
+		{
+			name:         "signature isn't required",
+			verification: &types.ChartVerification{Status: "unsigned"},
+		},
+		{
+			name:         "verified chart",
+			verification: &types.ChartVerification{Status: "verified", Signer: "CN=signer", Required: true},
+		},
+		{
+			name:         "unsigned chart",
+			verification: &types.ChartVerification{Status: "unsigned", Message: "the chart has no provenance file", Required: true},
+			expectedErr:  "the repository requires signed charts, chart test version 1.0.0 isn't signed by a trusted key: the chart has no provenance file",
+		},
+		{
+			name:         "invalid chart",
+			verification: &types.ChartVerification{Status: "invalid", Required: true},
+			expectedErr:  "the repository requires signed charts, chart test version 1.0.0 isn't signed by a trusted key: it is invalid",
+		},
+		{
+			name:         "chart not verified yet",
+			verification: &types.ChartVerification{Required: true},
+			expectedErr:  "the repository requires signed charts, chart test version 1.0.0 isn't signed by a trusted key: its signature hasn't been verified yet",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkVerification("test", "1.0.0", tc.verification)
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, "PermissionDenied 403: "+tc.expectedErr)
+		})
+	}
+}
@@ -21,6 +21,9 @@
 	corev1 "k8s.io/api/core/v1"
 )
 
+// maxProvenanceSize is the maximum size of the provenance file of a chart.
+const maxProvenanceSize = 1024 * 1024 // 1 MiB
+
 func Icon(secret *corev1.Secret, repoURL string, caBundle []byte, insecureSkipTLSVerify bool, disableSameOriginCheck bool, chart *repo.ChartVersion) (io.ReadCloser, string, error) {
 	if len(chart.URLs) == 0 {
 		return nil, "", fmt.Errorf("failed to find chartName %s version %s: %w", chart.Name, chart.Version, validation.NotFound)
@@ -78,6 +81,63 @@ func Chart(secret *corev1.Secret, repoURL string, caBundle []byte, insecureSkipT
 	}
 	defer client.CloseIdleConnections()
 
+	u, err := chartURL(repoURL, chart)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	return ioutil.NopCloser(bytes.NewBuffer(data)), err
+}
+
+// Provenance returns the Helm provenance file of the chart, which is served next to the chart archive with the .prov
+// extension, or nil if the chart has none.
+func Provenance(client *http.Client, repoURL string, chart *repo.ChartVersion) ([]byte, error) {
+	if len(chart.URLs) == 0 {
+		return nil, fmt.Errorf("failed to find chartName %s version %s: %w", chart.Name, chart.Version, validation.NotFound)
+	}
+
+	u, err := chartURL(repoURL, chart)
+	if err != nil {
+		return nil, err
+	}
+	u.Path += ".prov"
+	u.RawPath = ""
+
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, validation.ErrorCode{
+			Status: resp.StatusCode,
+		}
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxProvenanceSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxProvenanceSize {
+		return nil, fmt.Errorf("provenance file of chart %s version %s is larger than %d bytes", chart.Name, chart.Version, maxProvenanceSize)
+	}
+	return data, nil
+}
+
+// chartURL returns the absolute URL of the chart archive, relative URLs are relative to the repository.
+func chartURL(repoURL string, chart *repo.ChartVersion) (*url.URL, error) {
 	u, err := url.Parse(chart.URLs[0])
 	if err != nil {
 		return nil, err
@@ -95,15 +155,7 @@ func Chart(secret *corev1.Secret, repoURL string, caBundle []byte, insecureSkipT
 		// contain an access credential.
 		u.RawQuery = base.RawQuery
 	}
-
-	resp, err := client.Get(u.String())
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	data, err := ioutil.ReadAll(resp.Body)
-	return ioutil.NopCloser(bytes.NewBuffer(data)), err
+	return u, nil
 }
 
 func DownloadIndex(secret *corev1.Secret, repoURL string, caBundle []byte, insecureSkipTLSVerify bool, disableSameOriginCheck bool) (*repo.IndexFile, error) {
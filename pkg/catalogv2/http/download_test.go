@@ -0,0 +1,68 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+func TestProvenance(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/charts/signed-1.0.0.tgz.prov":
+			assert.Equal(t, "token=abc", r.URL.RawQuery)
+			w.Write([]byte("provenance"))
+		case "/charts/broken-1.0.0.tgz.prov":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		url      string
+		expected []byte
+		wantErr  bool
+	}{
+		{
+			name:     "relative url",
+			url:      "signed-1.0.0.tgz",
+			expected: []byte("provenance"),
+		},
+		{
+			name:     "absolute url",
+			url:      server.URL + "/charts/signed-1.0.0.tgz?token=abc",
+			expected: []byte("provenance"),
+		},
+		{
+			name: "no provenance file",
+			url:  "unsigned-1.0.0.tgz",
+		},
+		{
+			name:    "error",
+			url:     "broken-1.0.0.tgz",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prov, err := Provenance(server.Client(), server.URL+"/charts?token=abc", &repo.ChartVersion{
+				Metadata: &chart.Metadata{Name: "test", Version: "1.0.0"},
+				URLs:     []string{tt.url},
+			})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, prov)
+		})
+	}
+}
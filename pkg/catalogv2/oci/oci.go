@@ -25,6 +25,7 @@
 	"oras.land/oras-go/v2/registry/remote/errcode"
 
 	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/catalogv2/verify"
 )
 
 // maxHelmRepoIndexSize defines what is the max size of helm repo index file we support.
@@ -51,9 +52,16 @@ func Chart(credentialSecret *corev1.Secret, chart *repo.ChartVersion, clusterRep
 		return nil, fmt.Errorf("failed to create an OCI repository for url %s: %w", chartURL, err)
 	}
 
+	// Pull the manifest which was verified, if the signature of the chart was verified, as the tag might have been
+	// moved to another manifest since.
+	reference := ociClient.tag
+	if manifestDigest := verify.ManifestDigest(chart); manifestDigest != "" {
+		reference = manifestDigest
+	}
+
 	// Download the oci artifact manifest
 	memoryStore := memory.New()
-	manifest, err := oras.Copy(ctx, orasRepository, ociClient.tag, memoryStore, "", oras.CopyOptions{
+	manifest, err := oras.Copy(ctx, orasRepository, reference, memoryStore, "", oras.CopyOptions{
 		CopyGraphOptions: oras.CopyGraphOptions{
 			PreCopy: func(ctx context.Context, desc ocispecv1.Descriptor) error {
 				// Download only helm chart related descriptors.
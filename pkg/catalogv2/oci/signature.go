@@ -0,0 +1,161 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/rancher/rancher/pkg/catalogv2/verify"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+// maxSignatureSize defines the max size of the manifests and blobs of the signatures of a chart.
+const maxSignatureSize int64 = 1024 * 1024 // 1 MiB
+
+// VerifySignature verifies the cosign and notation signatures of the chart of the client's tag against the trusted
+// keys. The result records the digest of the manifest which was verified, so that the chart is pulled by this digest
+// rather than by its tag, which could have been moved since.
+func (o *Client) VerifySignature(ctx context.Context, keys *verify.TrustedKeys) verify.Result {
+	ociURL := fmt.Sprintf("%s/%s:%s", o.registry, o.repository, o.tag)
+
+	orasRepository, err := o.GetOrasRepository()
+	if err != nil {
+		return verify.Failed("failed to create an OCI repository for url %s: %v", ociURL, err)
+	}
+	manifest, err := orasRepository.Resolve(ctx, o.tag)
+	if err != nil {
+		return verify.Failed("failed to resolve %s: %v", ociURL, err)
+	}
+
+	signer, problems, err := verifyCosign(ctx, orasRepository, manifest, keys)
+	if err != nil {
+		return verify.Failed("failed to fetch the cosign signatures of %s: %v", ociURL, err)
+	}
+	if signer == "" {
+		var notationProblems []string
+		signer, notationProblems, err = verifyNotation(ctx, orasRepository, manifest, keys)
+		if err != nil {
+			return verify.Failed("failed to fetch the notation signatures of %s: %v", ociURL, err)
+		}
+		problems = append(problems, notationProblems...)
+	}
+
+	var result verify.Result
+	switch {
+	case signer != "":
+		result = verify.Verified(signer)
+	case len(problems) > 0:
+		result = verify.Invalid("%s", strings.Join(problems, "; "))
+	default:
+		result = verify.Unsigned("the chart has no cosign or notation signature")
+	}
+	result.ManifestDigest = manifest.Digest.String()
+	return result
+}
+
+// verifyCosign verifies the cosign signatures of the manifest, which are the layers of the manifest tagged after the
+// digest of the signed manifest. It returns the signer of the first valid signature, and why the others are invalid.
+func verifyCosign(ctx context.Context, orasRepository *remote.Repository, manifest ocispecv1.Descriptor, keys *verify.TrustedKeys) (string, []string, error) {
+	desc, err := orasRepository.Resolve(ctx, verify.CosignSignatureTag(manifest.Digest.String()))
+	if errors.Is(err, errdef.ErrNotFound) {
+		return "", nil, nil
+	} else if err != nil {
+		return "", nil, err
+	}
+	signatures, err := fetchManifest(ctx, orasRepository, desc)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var problems []string
+	for _, layer := range signatures.Layers {
+		if layer.MediaType != verify.CosignSimpleSigningMediaType {
+			continue
+		}
+		payload, err := fetchBlob(ctx, orasRepository, layer)
+		if err != nil {
+			return "", nil, err
+		}
+		signer, err := keys.Cosign(manifest.Digest.String(), payload, layer.Annotations[verify.CosignSignatureAnnotation])
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		return signer, nil, nil
+	}
+	return "", problems, nil
+}
+
+// verifyNotation verifies the notation signatures of the manifest, which are the manifests referring to it with the
+// notation artifact type. It returns the signer of the first valid signature, and why the others are invalid.
+func verifyNotation(ctx context.Context, orasRepository *remote.Repository, manifest ocispecv1.Descriptor, keys *verify.TrustedKeys) (string, []string, error) {
+	var (
+		signer   string
+		problems []string
+	)
+	err := orasRepository.Referrers(ctx, manifest, verify.NotationArtifactType, func(referrers []ocispecv1.Descriptor) error {
+		for _, referrer := range referrers {
+			if signer != "" {
+				return nil
+			}
+			signature, err := fetchManifest(ctx, orasRepository, referrer)
+			if err != nil {
+				return err
+			}
+			for _, layer := range signature.Layers {
+				switch layer.MediaType {
+				case verify.NotationJWSMediaType:
+				case verify.NotationCOSEMediaType:
+					problems = append(problems, "notation signatures in the COSE format aren't supported")
+					continue
+				default:
+					continue
+				}
+				envelope, err := fetchBlob(ctx, orasRepository, layer)
+				if err != nil {
+					return err
+				}
+				if signer, err = keys.Notation(manifest.Digest.String(), envelope, time.Now()); err != nil {
+					problems = append(problems, err.Error())
+					continue
+				}
+				return nil
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	if signer != "" {
+		return signer, nil, nil
+	}
+	return "", problems, nil
+}
+
+func fetchManifest(ctx context.Context, orasRepository *remote.Repository, desc ocispecv1.Descriptor) (*ocispecv1.Manifest, error) {
+	data, err := fetchBlob(ctx, orasRepository, desc)
+	if err != nil {
+		return nil, err
+	}
+	var manifest ocispecv1.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal manifest %s: %w", desc.Digest, err)
+	}
+	return &manifest, nil
+}
+
+func fetchBlob(ctx context.Context, orasRepository *remote.Repository, desc ocispecv1.Descriptor) ([]byte, error) {
+	// We cannot load huge amounts of data into the memory
+	// and so we are defining a limit before fetching.
+	if desc.Size > maxSignatureSize {
+		return nil, fmt.Errorf("%s has size more than %d which is not supported", desc.Digest, maxSignatureSize)
+	}
+	return content.FetchAll(ctx, orasRepository, desc)
+}
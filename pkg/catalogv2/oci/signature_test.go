@@ -0,0 +1,247 @@
+package oci
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/catalogv2/verify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/registry"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var ocispecVersioned = specs.Versioned{SchemaVersion: 2}
+
+// fakeRegistry serves the manifests and blobs of a repository, by reference.
+type fakeRegistry struct {
+	manifests map[string][]byte
+	blobs     map[string][]byte
+	referrers []ocispec.Descriptor
+}
+
+func (f *fakeRegistry) addManifest(t *testing.T, manifest ocispec.Manifest, tags ...string) ocispec.Descriptor {
+	data, err := json.Marshal(manifest)
+	require.NoError(t, err)
+	desc := ocispec.Descriptor{
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: manifest.ArtifactType,
+		Digest:       digest.FromBytes(data),
+		Size:         int64(len(data)),
+	}
+	f.manifests[desc.Digest.String()] = data
+	for _, tag := range tags {
+		f.manifests[tag] = data
+	}
+	return desc
+}
+
+func (f *fakeRegistry) addBlob(mediaType string, data []byte, annotations map[string]string) ocispec.Descriptor {
+	desc := ocispec.Descriptor{
+		MediaType:   mediaType,
+		Digest:      digest.FromBytes(data),
+		Size:        int64(len(data)),
+		Annotations: annotations,
+	}
+	f.blobs[desc.Digest.String()] = data
+	return desc
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/v2/testingchart/")
+	var data []byte
+	switch {
+	case strings.HasPrefix(path, "manifests/"):
+		data = f.manifests[strings.TrimPrefix(path, "manifests/")]
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+	case strings.HasPrefix(path, "blobs/"):
+		data = f.blobs[strings.TrimPrefix(path, "blobs/")]
+		w.Header().Set("Content-Type", "application/octet-stream")
+	case strings.HasPrefix(path, "referrers/"):
+		var err error
+		data, err = json.Marshal(ocispec.Index{
+			Versioned: ocispecVersioned,
+			MediaType: ocispec.MediaTypeImageIndex,
+			Manifests: f.referrers,
+		})
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+	}
+	if data == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Docker-Content-Digest", digest.FromBytes(data).String())
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	if r.Method != http.MethodHead {
+		w.Write(data)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	cosignKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	untrustedKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	cosignDER, err := x509.MarshalPKIXPublicKey(cosignKey.Public())
+	require.NoError(t, err)
+
+	notationKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "signer"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, notationKey.Public(), notationKey)
+	require.NoError(t, err)
+
+	keys, err := verify.LoadTrustedKeys(&corev1.Secret{Data: map[string][]byte{
+		verify.CosignKey:   pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: cosignDER}),
+		verify.NotationKey: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}),
+	}})
+	require.NoError(t, err)
+
+	newRegistry := func() (*fakeRegistry, ocispec.Descriptor) {
+		f := &fakeRegistry{manifests: map[string][]byte{}, blobs: map[string][]byte{}}
+		config := f.addBlob(registry.ConfigMediaType, []byte("config"), nil)
+		layer := f.addBlob(registry.ChartLayerMediaType, []byte("chart"), nil)
+		chart := f.addManifest(t, ocispec.Manifest{
+			Versioned: ocispecVersioned,
+			MediaType: ocispec.MediaTypeImageManifest,
+			Config:    config,
+			Layers:    []ocispec.Descriptor{layer},
+		}, "0.1.0")
+		return f, chart
+	}
+	cosignSignature := func(f *fakeRegistry, chart ocispec.Descriptor, key *ecdsa.PrivateKey) {
+		payload := []byte(`{"critical":{"image":{"docker-manifest-digest":"` + chart.Digest.String() + `"},"type":"cosign container image signature"}}`)
+		sum := sha256.Sum256(payload)
+		sig, err := ecdsa.SignASN1(rand.Reader, key, sum[:])
+		require.NoError(t, err)
+		layer := f.addBlob(verify.CosignSimpleSigningMediaType, payload, map[string]string{
+			verify.CosignSignatureAnnotation: base64.StdEncoding.EncodeToString(sig),
+		})
+		f.addManifest(t, ocispec.Manifest{
+			Versioned: ocispecVersioned,
+			MediaType: ocispec.MediaTypeImageManifest,
+			Config:    f.addBlob("application/vnd.oci.image.config.v1+json", []byte("{}"), nil),
+			Layers:    []ocispec.Descriptor{layer},
+		}, verify.CosignSignatureTag(chart.Digest.String()))
+	}
+	notationSignature := func(f *fakeRegistry, chart ocispec.Descriptor) {
+		header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"ES256","cty":"application/vnd.cncf.notary.payload.v1+json"}`))
+		payload := base64.RawURLEncoding.EncodeToString([]byte(`{"targetArtifact":{"digest":"` + chart.Digest.String() + `"}}`))
+		sum := sha256.Sum256([]byte(header + "." + payload))
+		r, s, err := ecdsa.Sign(rand.Reader, notationKey, sum[:])
+		require.NoError(t, err)
+		sig := make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+		envelope, err := json.Marshal(map[string]any{
+			"payload":   payload,
+			"protected": header,
+			"header":    map[string]any{"x5c": [][]byte{cert}},
+			"signature": base64.RawURLEncoding.EncodeToString(sig),
+		})
+		require.NoError(t, err)
+		layer := f.addBlob(verify.NotationJWSMediaType, envelope, nil)
+		f.referrers = append(f.referrers, f.addManifest(t, ocispec.Manifest{
+			Versioned:    ocispecVersioned,
+			MediaType:    ocispec.MediaTypeImageManifest,
+			ArtifactType: verify.NotationArtifactType,
+			Config:       f.addBlob("application/vnd.oci.empty.v1+json", []byte("{}"), nil),
+			Layers:       []ocispec.Descriptor{layer},
+			Subject:      &chart,
+		}))
+	}
+
+	tests := []struct {
+		name    string
+		sign    func(f *fakeRegistry, chart ocispec.Descriptor)
+		status  verify.Status
+		signer  string
+		message string
+	}{
+		{
+			name:    "unsigned",
+			sign:    func(f *fakeRegistry, chart ocispec.Descriptor) {},
+			status:  verify.StatusUnsigned,
+			message: "the chart has no cosign or notation signature",
+		},
+		{
+			name: "cosign",
+			sign: func(f *fakeRegistry, chart ocispec.Descriptor) {
+				cosignSignature(f, chart, cosignKey)
+			},
+			status: verify.StatusVerified,
+			signer: "sha256:" + digest.FromBytes(cosignDER).Encoded(),
+		},
+		{
+			name: "cosign with an untrusted key",
+			sign: func(f *fakeRegistry, chart ocispec.Descriptor) {
+				cosignSignature(f, chart, untrustedKey)
+			},
+			status:  verify.StatusInvalid,
+			message: "cosign signature isn't made by a trusted key",
+		},
+		{
+			name:   "notation",
+			sign:   notationSignature,
+			status: verify.StatusVerified,
+			signer: "CN=signer",
+		},
+		{
+			name: "untrusted cosign and trusted notation",
+			sign: func(f *fakeRegistry, chart ocispec.Descriptor) {
+				cosignSignature(f, chart, untrustedKey)
+				notationSignature(f, chart)
+			},
+			status: verify.StatusVerified,
+			signer: "CN=signer",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, chart := newRegistry()
+			tt.sign(f, chart)
+			server := httptest.NewServer(f)
+			defer server.Close()
+
+			ociClient, err := NewClient(strings.Replace(server.URL, "http", "oci", 1)+"/testingchart:0.1.0", v1.RepoSpec{InsecurePlainHTTP: true}, nil)
+			require.NoError(t, err)
+			result := ociClient.VerifySignature(context.Background(), keys)
+			assert.Equal(t, tt.status, result.Status)
+			assert.Equal(t, tt.signer, result.Signer)
+			assert.Equal(t, tt.message, result.Message)
+			assert.Equal(t, chart.Digest.String(), result.ManifestDigest)
+		})
+	}
+}
@@ -18,3 +18,16 @@ func GetSecret(secrets corev1controllers.SecretCache, repoSpec *v1.RepoSpec, rep
 
 	return secrets.Get(ns, repoSpec.ClientSecret.Name)
 }
+
+// GetTrustedKeysSecret returns the Secret from the cluster repo's verification trustedKeysSecret spec field
+func GetTrustedKeysSecret(secrets corev1controllers.SecretCache, repoSpec *v1.RepoSpec, repoNamespace string) (*corev1.Secret, error) {
+	if repoSpec.Verification == nil || repoSpec.Verification.TrustedKeysSecret == nil {
+		return nil, nil
+	}
+	ns := repoSpec.Verification.TrustedKeysSecret.Namespace
+	if repoNamespace != "" {
+		ns = repoNamespace
+	}
+
+	return secrets.Get(ns, repoSpec.Verification.TrustedKeysSecret.Name)
+}
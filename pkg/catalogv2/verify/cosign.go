@@ -0,0 +1,98 @@
+package verify
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// CosignSignatureAnnotation is the annotation of the layers of a cosign signature manifest which holds the
+	// signature of the layer.
+	CosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+	// CosignSimpleSigningMediaType is the media type of the layers of a cosign signature manifest.
+	CosignSimpleSigningMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+)
+
+// CosignSignatureTag returns the tag of the cosign signatures of the manifest with the given digest.
+func CosignSignatureTag(manifestDigest string) string {
+	return strings.Replace(manifestDigest, ":", "-", 1) + ".sig"
+}
+
+// simpleSigningPayload is the payload signed by cosign, in the Red Hat simple signing format.
+type simpleSigningPayload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+}
+
+// Cosign verifies the base64 encoded cosign signature of the payload with the trusted cosign keys, and that the payload
+// signs the manifest with the given digest. It returns the key which made the signature.
+func (k *TrustedKeys) Cosign(manifestDigest string, payload []byte, signature string) (string, error) {
+	if len(k.cosign) == 0 {
+		return "", errors.New("no cosign public key is trusted")
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return "", fmt.Errorf("malformed cosign signature: %w", err)
+	}
+
+	signer := ""
+	for _, key := range k.cosign {
+		if verifyCosignSignature(key, payload, sig) {
+			signer, err = keyFingerprint(key)
+			if err != nil {
+				return "", err
+			}
+			break
+		}
+	}
+	if signer == "" {
+		return "", errors.New("cosign signature isn't made by a trusted key")
+	}
+
+	var signed simpleSigningPayload
+	if err := json.Unmarshal(payload, &signed); err != nil {
+		return "", fmt.Errorf("malformed cosign payload: %w", err)
+	}
+	if signed.Critical.Image.DockerManifestDigest != manifestDigest {
+		return "", fmt.Errorf("cosign signature is for manifest %s", signed.Critical.Image.DockerManifestDigest)
+	}
+	return signer, nil
+}
+
+// verifyCosignSignature returns whether sig is a signature of payload by key. cosign hashes payloads with SHA-256
+// regardless of the type of key.
+func verifyCosignSignature(key crypto.PublicKey, payload, sig []byte) bool {
+	digest := sha256.Sum256(payload)
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(key, digest[:], sig)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) == nil
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, payload, sig)
+	}
+	return false
+}
+
+// keyFingerprint identifies a public key by the digest of its DER encoding.
+func keyFingerprint(key crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
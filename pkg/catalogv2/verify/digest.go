@@ -0,0 +1,25 @@
+package verify
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+var errNoURL = errors.New("chart has no urls specified")
+
+// CheckDigest checks that the chart archive downloaded for the chart version matches the digest of the index, which
+// is the digest that was verified.
+func CheckDigest(chartVersion *repo.ChartVersion, data []byte) error {
+	if chartVersion.Digest == "" {
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	if digest := hex.EncodeToString(sum[:]); digest != chartVersion.Digest {
+		return fmt.Errorf("chart %s version %s has digest %s, expected %s", chartVersion.Name, chartVersion.Version, digest, chartVersion.Digest)
+	}
+	return nil
+}
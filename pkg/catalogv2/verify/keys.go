@@ -0,0 +1,112 @@
+package verify
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/openpgp"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// KeyringKey is the key of the PGP keyring, armored or binary, which verifies Helm provenance files.
+	KeyringKey = "keyring"
+	// CosignKey is the key of the PEM encoded public keys which verify cosign signatures.
+	CosignKey = "cosign.pub"
+	// NotationKey is the key of the PEM encoded certificates which verify notation signatures.
+	NotationKey = "notation.crt"
+)
+
+// TrustedKeys are the keys trusted to sign the charts of a repository.
+type TrustedKeys struct {
+	// ID identifies the keys, it changes whenever the keys change.
+	ID string
+
+	keyring       openpgp.EntityList
+	cosign        []crypto.PublicKey
+	notation      *x509.CertPool
+	notationCount int
+}
+
+// LoadTrustedKeys loads the trusted keys from the data of the secret.
+func LoadTrustedKeys(secret *corev1.Secret) (*TrustedKeys, error) {
+	keys := &TrustedKeys{
+		notation: x509.NewCertPool(),
+	}
+
+	if data := secret.Data[KeyringKey]; len(data) > 0 {
+		keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+		if err != nil {
+			keyring, err = openpgp.ReadKeyRing(bytes.NewReader(data))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the PGP keyring %s of secret %s/%s: %w", KeyringKey, secret.Namespace, secret.Name, err)
+		}
+		keys.keyring = keyring
+	}
+
+	err := forEachPEMBlock(secret.Data[CosignKey], "PUBLIC KEY", func(der []byte) error {
+		key, err := x509.ParsePKIXPublicKey(der)
+		if err != nil {
+			return err
+		}
+		keys.cosign = append(keys.cosign, key)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the cosign public keys %s of secret %s/%s: %w", CosignKey, secret.Namespace, secret.Name, err)
+	}
+
+	err = forEachPEMBlock(secret.Data[NotationKey], "CERTIFICATE", func(der []byte) error {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return err
+		}
+		keys.notation.AddCert(cert)
+		keys.notationCount++
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the notation certificates %s of secret %s/%s: %w", NotationKey, secret.Namespace, secret.Name, err)
+	}
+
+	if len(keys.keyring) == 0 && len(keys.cosign) == 0 && keys.notationCount == 0 {
+		return nil, fmt.Errorf("secret %s/%s has none of the keys %s, %s or %s", secret.Namespace, secret.Name, KeyringKey, CosignKey, NotationKey)
+	}
+
+	hash := sha256.New()
+	for _, key := range []string{KeyringKey, CosignKey, NotationKey} {
+		sum := sha256.Sum256(secret.Data[key])
+		hash.Write(sum[:])
+	}
+	keys.ID = hex.EncodeToString(hash.Sum(nil)[:8])
+
+	return keys, nil
+}
+
+// forEachPEMBlock calls fn with the content of each PEM block of data, which must all be of type blockType.
+func forEachPEMBlock(data []byte, blockType string, fn func(der []byte) error) error {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != blockType {
+			return fmt.Errorf("unexpected PEM block %s, expected %s", block.Type, blockType)
+		}
+		if err := fn(block.Bytes); err != nil {
+			return err
+		}
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		return errors.New("data isn't PEM encoded")
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package verify
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+const (
+	// NotationArtifactType is the artifact type of the notation signatures of a manifest.
+	NotationArtifactType = "application/vnd.cncf.notary.signature"
+	// NotationJWSMediaType is the media type of notation signature envelopes in the JWS format.
+	NotationJWSMediaType = "application/jose+json"
+	// NotationCOSEMediaType is the media type of notation signature envelopes in the COSE format.
+	NotationCOSEMediaType = "application/cose"
+
+	notationPayloadContentType = "application/vnd.cncf.notary.payload.v1+json"
+)
+
+// jwsEnvelope is a notation signature envelope in the JWS JSON serialization.
+type jwsEnvelope struct {
+	Payload   string `json:"payload"`
+	Protected string `json:"protected"`
+	Header    struct {
+		CertChain [][]byte `json:"x5c"`
+	} `json:"header"`
+	Signature string `json:"signature"`
+}
+
+type jwsProtectedHeader struct {
+	Algorithm   string     `json:"alg"`
+	ContentType string     `json:"cty"`
+	Expiry      *time.Time `json:"io.cncf.notary.expiry,omitempty"`
+}
+
+type notationPayload struct {
+	TargetArtifact struct {
+		Digest string `json:"digest"`
+	} `json:"targetArtifact"`
+}
+
+// Notation verifies a notation signature envelope in the JWS format, whose certificate chain must lead to a trusted
+// certificate, and that it signs the manifest with the given digest. It returns the subject of the certificate which
+// made the signature.
+func (k *TrustedKeys) Notation(manifestDigest string, envelope []byte, now time.Time) (string, error) {
+	if k.notationCount == 0 {
+		return "", errors.New("no notation certificate is trusted")
+	}
+
+	var jws jwsEnvelope
+	if err := json.Unmarshal(envelope, &jws); err != nil {
+		return "", fmt.Errorf("malformed notation signature envelope: %w", err)
+	}
+	if len(jws.Header.CertChain) == 0 {
+		return "", errors.New("notation signature has no certificate chain")
+	}
+	certs := make([]*x509.Certificate, 0, len(jws.Header.CertChain))
+	for _, der := range jws.Header.CertChain {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return "", fmt.Errorf("malformed certificate in notation signature: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	leaf := certs[0]
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         k.notation,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}); err != nil {
+		return "", fmt.Errorf("notation signature isn't made by a trusted certificate: %w", err)
+	}
+
+	protected, err := base64.RawURLEncoding.DecodeString(jws.Protected)
+	if err != nil {
+		return "", fmt.Errorf("malformed notation signature header: %w", err)
+	}
+	var header jwsProtectedHeader
+	if err := json.Unmarshal(protected, &header); err != nil {
+		return "", fmt.Errorf("malformed notation signature header: %w", err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(jws.Signature)
+	if err != nil {
+		return "", fmt.Errorf("malformed notation signature: %w", err)
+	}
+	if err := verifyJWS(header.Algorithm, leaf.PublicKey, []byte(jws.Protected+"."+jws.Payload), sig); err != nil {
+		return "", err
+	}
+
+	if header.ContentType != notationPayloadContentType {
+		return "", fmt.Errorf("notation signature has unsupported payload type %s", header.ContentType)
+	}
+	if header.Expiry != nil && now.After(*header.Expiry) {
+		return "", fmt.Errorf("notation signature expired at %s", header.Expiry.Format(time.RFC3339))
+	}
+	data, err := base64.RawURLEncoding.DecodeString(jws.Payload)
+	if err != nil {
+		return "", fmt.Errorf("malformed notation payload: %w", err)
+	}
+	var payload notationPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return "", fmt.Errorf("malformed notation payload: %w", err)
+	}
+	if payload.TargetArtifact.Digest != manifestDigest {
+		return "", fmt.Errorf("notation signature is for manifest %s", payload.TargetArtifact.Digest)
+	}
+
+	return leaf.Subject.String(), nil
+}
+
+// verifyJWS verifies the JWS signature of signingInput with the algorithms supported by notation.
+func verifyJWS(algorithm string, key crypto.PublicKey, signingInput, sig []byte) error {
+	var hash crypto.Hash
+	switch algorithm {
+	case "PS256", "ES256":
+		hash = crypto.SHA256
+	case "PS384", "ES384":
+		hash = crypto.SHA384
+	case "PS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("notation signature has unsupported algorithm %s", algorithm)
+	}
+	h := hash.New()
+	h.Write(signingInput)
+	digest := h.Sum(nil)
+
+	invalid := errors.New("notation signature is invalid")
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if algorithm[0] != 'P' {
+			return invalid
+		}
+		if err := rsa.VerifyPSS(key, hash, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}); err != nil {
+			return invalid
+		}
+	case *ecdsa.PublicKey:
+		if algorithm[0] != 'E' || len(sig)%2 != 0 {
+			return invalid
+		}
+		r := new(big.Int).SetBytes(sig[:len(sig)/2])
+		s := new(big.Int).SetBytes(sig[len(sig)/2:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return invalid
+		}
+	default:
+		return fmt.Errorf("notation signature has unsupported key type %T", key)
+	}
+	return nil
+}
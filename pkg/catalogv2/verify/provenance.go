@@ -0,0 +1,88 @@
+package verify
+
+import (
+	"bytes"
+	"net/url"
+	"path"
+	"sort"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/clearsign"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/provenance"
+	"helm.sh/helm/v3/pkg/repo"
+	"sigs.k8s.io/yaml"
+)
+
+// Provenance verifies the Helm provenance file of the chart version, which must be signed by a key of the trusted
+// keyring and contain the digest of the chart of the index.
+func (k *TrustedKeys) Provenance(chartVersion *repo.ChartVersion, prov []byte) Result {
+	if len(k.keyring) == 0 {
+		return Invalid("no PGP keyring is trusted to verify the provenance file")
+	}
+
+	block, _ := clearsign.Decode(prov)
+	if block == nil {
+		return Invalid("provenance file isn't signed")
+	}
+	signer, err := openpgp.CheckDetachedSignature(k.keyring, bytes.NewReader(block.Bytes), block.ArmoredSignature.Body)
+	if err != nil {
+		return Invalid("provenance file isn't signed by a trusted key: %v", err)
+	}
+
+	// The signed message is the Chart.yaml of the chart and the digests of its files, separated by a YAML document
+	// end marker.
+	parts := bytes.Split(block.Plaintext, []byte("\n...\n"))
+	if len(parts) < 2 {
+		return Invalid("provenance file is malformed")
+	}
+	var metadata chart.Metadata
+	if err := yaml.Unmarshal(parts[0], &metadata); err != nil {
+		return Invalid("provenance file has malformed chart metadata: %v", err)
+	}
+	if metadata.Name != chartVersion.Name || metadata.Version != chartVersion.Version {
+		return Invalid("provenance file is for chart %s version %s", metadata.Name, metadata.Version)
+	}
+	var sums provenance.SumCollection
+	if err := yaml.Unmarshal(parts[1], &sums); err != nil {
+		return Invalid("provenance file has malformed digests: %v", err)
+	}
+
+	if chartVersion.Digest == "" {
+		return Invalid("the index has no digest for the chart")
+	}
+	fileName, err := chartFileName(chartVersion)
+	if err != nil {
+		return Invalid("the chart has an invalid URL: %v", err)
+	}
+	if sum, ok := sums.Files[fileName]; !ok || sum != "sha256:"+chartVersion.Digest {
+		return Invalid("the digest of %s in the provenance file doesn't match the index", fileName)
+	}
+
+	return Verified(identity(signer))
+}
+
+// chartFileName returns the file name of the chart archive, which the provenance file refers to.
+func chartFileName(chartVersion *repo.ChartVersion) (string, error) {
+	if len(chartVersion.URLs) == 0 {
+		return "", errNoURL
+	}
+	u, err := url.Parse(chartVersion.URLs[0])
+	if err != nil {
+		return "", err
+	}
+	return path.Base(u.Path), nil
+}
+
+// identity returns the name of an identity of the PGP entity.
+func identity(entity *openpgp.Entity) string {
+	var names []string
+	for name := range entity.Identities {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return entity.PrimaryKey.KeyIdString()
+	}
+	sort.Strings(names)
+	return names[0]
+}
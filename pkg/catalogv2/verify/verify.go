@@ -0,0 +1,140 @@
+// Package verify verifies the signatures of the charts of Helm repositories, against the keys trusted by their
+// ClusterRepo: Helm provenance files for HTTP repositories, and cosign or notation signatures for OCI repositories.
+//
+// The results of the verifications are recorded in the annotations of the chart versions of the index of the
+// repository, from which they are served by the chart info API and enforced when installing or upgrading a chart.
+package verify
+
+import (
+	"fmt"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// Status is the result of the verification of the signature of a chart.
+type Status string
+
+const (
+	// StatusVerified is the status of a chart signed by a trusted key.
+	StatusVerified Status = "verified"
+	// StatusUnsigned is the status of a chart which has no signature.
+	StatusUnsigned Status = "unsigned"
+	// StatusInvalid is the status of a chart which has a signature which isn't valid, or isn't made by a trusted key.
+	StatusInvalid Status = "invalid"
+	// StatusFailed is the status of a chart whose signature couldn't be verified, e.g. because the repository
+	// couldn't be reached. The verification is attempted again on the next refresh of the repository.
+	StatusFailed Status = "failed"
+)
+
+const (
+	StatusAnnotation         = "catalog.cattle.io/verification-status"
+	SignerAnnotation         = "catalog.cattle.io/verification-signer"
+	MessageAnnotation        = "catalog.cattle.io/verification-message"
+	KeysAnnotation           = "catalog.cattle.io/verification-keys"
+	ManifestDigestAnnotation = "catalog.cattle.io/verification-manifest-digest"
+)
+
+var annotations = []string{
+	StatusAnnotation,
+	SignerAnnotation,
+	MessageAnnotation,
+	KeysAnnotation,
+	ManifestDigestAnnotation,
+}
+
+// Result is the result of the verification of the signature of a chart version.
+type Result struct {
+	Status Status
+	// Signer identifies the key which signed the chart, if it is verified.
+	Signer string
+	// Message explains why the chart isn't verified.
+	Message string
+	// KeysID identifies the trusted keys the chart was verified against.
+	KeysID string
+	// ManifestDigest is the digest of the manifest of the OCI artifact of the chart which was verified, the chart is
+	// pulled by this digest rather than by its tag.
+	ManifestDigest string
+}
+
+// Verified returns the result of a chart signed by signer.
+func Verified(signer string) Result {
+	return Result{Status: StatusVerified, Signer: signer}
+}
+
+// Unsigned returns the result of a chart which has no signature.
+func Unsigned(format string, args ...any) Result {
+	return Result{Status: StatusUnsigned, Message: fmt.Sprintf(format, args...)}
+}
+
+// Invalid returns the result of a chart which has an invalid signature.
+func Invalid(format string, args ...any) Result {
+	return Result{Status: StatusInvalid, Message: fmt.Sprintf(format, args...)}
+}
+
+// Failed returns the result of a chart whose signature couldn't be verified.
+func Failed(format string, args ...any) Result {
+	return Result{Status: StatusFailed, Message: fmt.Sprintf(format, args...)}
+}
+
+// Annotate records the result in the annotations of the chart version, replacing any previous result.
+func (r Result) Annotate(chartVersion *repo.ChartVersion) {
+	Clear(chartVersion)
+	if chartVersion.Metadata == nil {
+		chartVersion.Metadata = &chart.Metadata{}
+	}
+	if chartVersion.Annotations == nil {
+		chartVersion.Annotations = map[string]string{}
+	}
+	for key, value := range map[string]string{
+		StatusAnnotation:         string(r.Status),
+		SignerAnnotation:         r.Signer,
+		MessageAnnotation:        r.Message,
+		KeysAnnotation:           r.KeysID,
+		ManifestDigestAnnotation: r.ManifestDigest,
+	} {
+		if value != "" {
+			chartVersion.Annotations[key] = value
+		}
+	}
+}
+
+// FromChart returns the result recorded in the annotations of the chart version, if any.
+func FromChart(chartVersion *repo.ChartVersion) (Result, bool) {
+	if chartVersion == nil || chartVersion.Metadata == nil {
+		return Result{}, false
+	}
+	status, ok := chartVersion.Annotations[StatusAnnotation]
+	if !ok {
+		return Result{}, false
+	}
+	return Result{
+		Status:         Status(status),
+		Signer:         chartVersion.Annotations[SignerAnnotation],
+		Message:        chartVersion.Annotations[MessageAnnotation],
+		KeysID:         chartVersion.Annotations[KeysAnnotation],
+		ManifestDigest: chartVersion.Annotations[ManifestDigestAnnotation],
+	}, true
+}
+
+// Clear removes any result from the annotations of the chart version. The annotations of the chart versions of an
+// index come from the repository and the Chart.yaml files of its charts, so they must be cleared before recording
+// results, or a chart could claim to be verified.
+func Clear(chartVersion *repo.ChartVersion) {
+	if chartVersion.Metadata == nil {
+		return
+	}
+	for _, annotation := range annotations {
+		delete(chartVersion.Annotations, annotation)
+	}
+}
+
+// ManifestDigest returns the digest of the manifest of the OCI artifact of the chart version which was verified, or
+// an empty string if it wasn't verified.
+func ManifestDigest(chartVersion *repo.ChartVersion) string {
+	result, ok := FromChart(chartVersion)
+	if !ok || result.Status != StatusVerified {
+		return ""
+	}
+	return result.ManifestDigest
+}
@@ -0,0 +1,388 @@
+package verify
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/clearsign"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const manifestDigest = "sha256:0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3d2e1f0"
+
+func newEntity(t *testing.T, name string) (*openpgp.Entity, []byte) {
+	t.Helper()
+	entity, err := openpgp.NewEntity(name, "", name+"@example.com", nil)
+	require.NoError(t, err)
+	keyring := &bytes.Buffer{}
+	w, err := armor.Encode(keyring, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.Serialize(w))
+	require.NoError(t, w.Close())
+	return entity, keyring.Bytes()
+}
+
+func newECDSAKey(t *testing.T) (*ecdsa.PrivateKey, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	require.NoError(t, err)
+	return key, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func newCertificate(t *testing.T, cn string) (*ecdsa.PrivateKey, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	return key, der
+}
+
+func newTrustedKeys(t *testing.T, data map[string][]byte) *TrustedKeys {
+	t.Helper()
+	keys, err := LoadTrustedKeys(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "keys", Namespace: "cattle-system"},
+		Data:       data,
+	})
+	require.NoError(t, err)
+	return keys
+}
+
+func TestLoadTrustedKeys(t *testing.T) {
+	_, keyring := newEntity(t, "signer")
+	_, cosignKey := newECDSAKey(t)
+	_, cert := newCertificate(t, "signer")
+	notationCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert})
+
+	tests := []struct {
+		name    string
+		data    map[string][]byte
+		wantErr string
+	}{
+		{
+			name: "all keys",
+			data: map[string][]byte{KeyringKey: keyring, CosignKey: cosignKey, NotationKey: notationCert},
+		},
+		{
+			name: "cosign keys only",
+			data: map[string][]byte{CosignKey: append(cosignKey, cosignKey...)},
+		},
+		{
+			name:    "no keys",
+			data:    map[string][]byte{"other": []byte("data")},
+			wantErr: "secret cattle-system/keys has none of the keys keyring, cosign.pub or notation.crt",
+		},
+		{
+			name:    "invalid keyring",
+			data:    map[string][]byte{KeyringKey: []byte("not a keyring")},
+			wantErr: "failed to read the PGP keyring",
+		},
+		{
+			name:    "certificate instead of public key",
+			data:    map[string][]byte{CosignKey: notationCert},
+			wantErr: "unexpected PEM block CERTIFICATE, expected PUBLIC KEY",
+		},
+		{
+			name:    "not PEM",
+			data:    map[string][]byte{NotationKey: []byte("garbage")},
+			wantErr: "data isn't PEM encoded",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := LoadTrustedKeys(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "keys", Namespace: "cattle-system"},
+				Data:       tt.data,
+			})
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, keys.ID, 16)
+		})
+	}
+
+	first := newTrustedKeys(t, map[string][]byte{CosignKey: cosignKey})
+	second := newTrustedKeys(t, map[string][]byte{CosignKey: cosignKey})
+	_, otherKey := newECDSAKey(t)
+	other := newTrustedKeys(t, map[string][]byte{CosignKey: otherKey})
+	assert.Equal(t, first.ID, second.ID)
+	assert.NotEqual(t, first.ID, other.ID)
+}
+
+// provenanceFile returns a Helm provenance file of the chart archive, signed by entity.
+func provenanceFile(t *testing.T, entity *openpgp.Entity, name, version, fileName string, archive []byte) []byte {
+	t.Helper()
+	sum := sha256.Sum256(archive)
+	message := "apiVersion: v2\nname: " + name + "\nversion: " + version + "\n\n...\nfiles:\n  " + fileName + ": sha256:" + hex.EncodeToString(sum[:]) + "\n"
+
+	prov := &bytes.Buffer{}
+	w, err := clearsign.Encode(prov, entity.PrivateKey, nil)
+	require.NoError(t, err)
+	_, err = w.Write([]byte(message))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return prov.Bytes()
+}
+
+func TestProvenance(t *testing.T) {
+	signer, keyring := newEntity(t, "signer")
+	other, _ := newEntity(t, "other")
+	keys := newTrustedKeys(t, map[string][]byte{KeyringKey: keyring})
+
+	archive := []byte("chart archive")
+	sum := sha256.Sum256(archive)
+	chartVersion := &repo.ChartVersion{
+		Metadata: &chart.Metadata{Name: "test", Version: "1.0.0"},
+		URLs:     []string{"https://charts.example.com/test-1.0.0.tgz?token=abc"},
+		Digest:   hex.EncodeToString(sum[:]),
+	}
+
+	tests := []struct {
+		name    string
+		prov    []byte
+		want    Status
+		message string
+	}{
+		{
+			name: "verified",
+			prov: provenanceFile(t, signer, "test", "1.0.0", "test-1.0.0.tgz", archive),
+			want: StatusVerified,
+		},
+		{
+			name:    "not signed",
+			prov:    []byte("apiVersion: v2\n"),
+			want:    StatusInvalid,
+			message: "provenance file isn't signed",
+		},
+		{
+			name:    "signed by an untrusted key",
+			prov:    provenanceFile(t, other, "test", "1.0.0", "test-1.0.0.tgz", archive),
+			want:    StatusInvalid,
+			message: "provenance file isn't signed by a trusted key",
+		},
+		{
+			name:    "for another chart",
+			prov:    provenanceFile(t, signer, "other", "1.0.0", "test-1.0.0.tgz", archive),
+			want:    StatusInvalid,
+			message: "provenance file is for chart other version 1.0.0",
+		},
+		{
+			name:    "for another archive",
+			prov:    provenanceFile(t, signer, "test", "1.0.0", "test-1.0.0.tgz", []byte("tampered")),
+			want:    StatusInvalid,
+			message: "the digest of test-1.0.0.tgz in the provenance file doesn't match the index",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := keys.Provenance(chartVersion, tt.prov)
+			assert.Equal(t, tt.want, result.Status)
+			assert.Contains(t, result.Message, tt.message)
+			if tt.want == StatusVerified {
+				assert.Equal(t, "signer <signer@example.com>", result.Signer)
+			}
+		})
+	}
+}
+
+func TestCosign(t *testing.T) {
+	key, cosignKey := newECDSAKey(t)
+	otherKey, _ := newECDSAKey(t)
+	keys := newTrustedKeys(t, map[string][]byte{CosignKey: cosignKey})
+
+	sign := func(key *ecdsa.PrivateKey, digest string) ([]byte, string) {
+		payload := []byte(`{"critical":{"identity":{"docker-reference":"registry.example.com/charts/test"},"image":{"docker-manifest-digest":"` + digest + `"},"type":"cosign container image signature"},"optional":null}`)
+		sum := sha256.Sum256(payload)
+		sig, err := ecdsa.SignASN1(rand.Reader, key, sum[:])
+		require.NoError(t, err)
+		return payload, base64.StdEncoding.EncodeToString(sig)
+	}
+
+	payload, sig := sign(key, manifestDigest)
+	signer, err := keys.Cosign(manifestDigest, payload, sig)
+	require.NoError(t, err)
+	fingerprint, err := keyFingerprint(key.Public())
+	require.NoError(t, err)
+	assert.Equal(t, fingerprint, signer)
+
+	payload, sig = sign(otherKey, manifestDigest)
+	_, err = keys.Cosign(manifestDigest, payload, sig)
+	assert.EqualError(t, err, "cosign signature isn't made by a trusted key")
+
+	payload, sig = sign(key, "sha256:other")
+	_, err = keys.Cosign(manifestDigest, payload, sig)
+	assert.EqualError(t, err, "cosign signature is for manifest sha256:other")
+
+	assert.Equal(t, "sha256-0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3d2e1f0.sig", CosignSignatureTag(manifestDigest))
+}
+
+// jws returns a notation signature envelope of the manifest, signed by key with the certificate chain.
+func jws(t *testing.T, key *ecdsa.PrivateKey, chain [][]byte, digest string, expiry time.Time) []byte {
+	t.Helper()
+	header, err := json.Marshal(map[string]any{
+		"alg":                   "ES256",
+		"cty":                   notationPayloadContentType,
+		"crit":                  []string{"io.cncf.notary.signingScheme"},
+		"io.cncf.notary.expiry": expiry,
+	})
+	require.NoError(t, err)
+	payload, err := json.Marshal(map[string]any{
+		"targetArtifact": map[string]any{
+			"mediaType": "application/vnd.oci.image.manifest.v1+json",
+			"digest":    digest,
+			"size":      1024,
+		},
+	})
+	require.NoError(t, err)
+
+	protected := base64.RawURLEncoding.EncodeToString(header)
+	encodedPayload := base64.RawURLEncoding.EncodeToString(payload)
+	h := crypto.SHA256.New()
+	h.Write([]byte(protected + "." + encodedPayload))
+	r, s, err := ecdsa.Sign(rand.Reader, key, h.Sum(nil))
+	require.NoError(t, err)
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+
+	envelope, err := json.Marshal(map[string]any{
+		"payload":   encodedPayload,
+		"protected": protected,
+		"header":    map[string]any{"x5c": chain, "io.cncf.notary.signingAgent": "notation-go/1.0.0"},
+		"signature": base64.RawURLEncoding.EncodeToString(sig),
+	})
+	require.NoError(t, err)
+	return envelope
+}
+
+func TestNotation(t *testing.T) {
+	key, cert := newCertificate(t, "signer")
+	otherKey, otherCert := newCertificate(t, "other")
+	keys := newTrustedKeys(t, map[string][]byte{
+		NotationKey: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}),
+	})
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		envelope []byte
+		wantErr  string
+	}{
+		{
+			name:     "verified",
+			envelope: jws(t, key, [][]byte{cert}, manifestDigest, now.Add(time.Hour)),
+		},
+		{
+			name:     "untrusted certificate",
+			envelope: jws(t, otherKey, [][]byte{otherCert}, manifestDigest, now.Add(time.Hour)),
+			wantErr:  "notation signature isn't made by a trusted certificate",
+		},
+		{
+			name:     "signed with another key",
+			envelope: jws(t, otherKey, [][]byte{cert}, manifestDigest, now.Add(time.Hour)),
+			wantErr:  "notation signature is invalid",
+		},
+		{
+			name:     "for another manifest",
+			envelope: jws(t, key, [][]byte{cert}, "sha256:other", now.Add(time.Hour)),
+			wantErr:  "notation signature is for manifest sha256:other",
+		},
+		{
+			name:     "expired",
+			envelope: jws(t, key, [][]byte{cert}, manifestDigest, now.Add(-time.Minute)),
+			wantErr:  "notation signature expired",
+		},
+		{
+			name:     "no certificate chain",
+			envelope: jws(t, key, nil, manifestDigest, now.Add(time.Hour)),
+			wantErr:  "notation signature has no certificate chain",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := keys.Notation(manifestDigest, tt.envelope, now)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "CN=signer", signer)
+		})
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	archive := []byte("chart archive")
+	sum := sha256.Sum256(archive)
+	chartVersion := &repo.ChartVersion{
+		Metadata: &chart.Metadata{
+			Name:    "test",
+			Version: "1.0.0",
+			Annotations: map[string]string{
+				"catalog.cattle.io/display-name": "Test",
+				// a chart can't claim to be verified
+				StatusAnnotation: string(StatusVerified),
+				SignerAnnotation: "someone",
+			},
+		},
+		Digest: hex.EncodeToString(sum[:]),
+	}
+
+	_, ok := FromChart(chartVersion)
+	assert.True(t, ok)
+	Clear(chartVersion)
+	_, ok = FromChart(chartVersion)
+	assert.False(t, ok)
+	assert.Equal(t, map[string]string{"catalog.cattle.io/display-name": "Test"}, chartVersion.Annotations)
+
+	result := Verified("CN=signer")
+	result.KeysID = "0123456789abcdef"
+	result.ManifestDigest = manifestDigest
+	result.Annotate(chartVersion)
+	got, ok := FromChart(chartVersion)
+	assert.True(t, ok)
+	assert.Equal(t, result, got)
+	assert.Equal(t, manifestDigest, ManifestDigest(chartVersion))
+
+	Invalid("bad signature").Annotate(chartVersion)
+	got, _ = FromChart(chartVersion)
+	assert.Equal(t, Result{Status: StatusInvalid, Message: "bad signature"}, got)
+	assert.Empty(t, ManifestDigest(chartVersion))
+
+	assert.NoError(t, CheckDigest(chartVersion, archive))
+	assert.ErrorContains(t, CheckDigest(chartVersion, []byte("tampered")), "chart test version 1.0.0 has digest")
+}
@@ -76,6 +76,7 @@
 	ClusterFieldState                                                = "state"
 	ClusterFieldTransitioning                                        = "transitioning"
 	ClusterFieldTransitioningMessage                                 = "transitioningMessage"
+	ClusterFieldTunnelDialPolicy                                     = "tunnelDialPolicy"
 	ClusterFieldUUID                                                 = "uuid"
 	ClusterFieldVersion                                              = "version"
 	ClusterFieldVirtualCenterSecret                                  = "virtualCenterSecret"
@@ -157,6 +158,7 @@ type Cluster struct {
 	State                                                string                         `json:"state,omitempty" yaml:"state,omitempty"`
 	Transitioning                                        string                         `json:"transitioning,omitempty" yaml:"transitioning,omitempty"`
 	TransitioningMessage                                 string                         `json:"transitioningMessage,omitempty" yaml:"transitioningMessage,omitempty"`
+	TunnelDialPolicy                                     *TunnelDialPolicy              `json:"tunnelDialPolicy,omitempty" yaml:"tunnelDialPolicy,omitempty"`
 	UUID                                                 string                         `json:"uuid,omitempty" yaml:"uuid,omitempty"`
 	Version                                              *Info                          `json:"version,omitempty" yaml:"version,omitempty"`
 	VirtualCenterSecret                                  string                         `json:"virtualCenterSecret,omitempty" yaml:"virtualCenterSecret,omitempty"`
@@ -33,6 +33,7 @@
 	ClusterSpecFieldLocalClusterAuthEndpoint                             = "localClusterAuthEndpoint"
 	ClusterSpecFieldRancherKubernetesEngineConfig                        = "rancherKubernetesEngineConfig"
 	ClusterSpecFieldRke2Config                                           = "rke2Config"
+	ClusterSpecFieldTunnelDialPolicy                                     = "tunnelDialPolicy"
 	ClusterSpecFieldWindowsPreferedCluster                               = "windowsPreferedCluster"
 )
 
@@ -68,5 +69,6 @@ type ClusterSpec struct {
 	LocalClusterAuthEndpoint                             *LocalClusterAuthEndpoint      `json:"localClusterAuthEndpoint,omitempty" yaml:"localClusterAuthEndpoint,omitempty"`
 	RancherKubernetesEngineConfig                        *RancherKubernetesEngineConfig `json:"rancherKubernetesEngineConfig,omitempty" yaml:"rancherKubernetesEngineConfig,omitempty"`
 	Rke2Config                                           *Rke2Config                    `json:"rke2Config,omitempty" yaml:"rke2Config,omitempty"`
+	TunnelDialPolicy                                     *TunnelDialPolicy              `json:"tunnelDialPolicy,omitempty" yaml:"tunnelDialPolicy,omitempty"`
 	WindowsPreferedCluster                               bool                           `json:"windowsPreferedCluster,omitempty" yaml:"windowsPreferedCluster,omitempty"`
 }
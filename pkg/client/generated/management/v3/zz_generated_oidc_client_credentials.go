@@ -0,0 +1,14 @@
+package client
+
+const (
+	OIDCClientCredentialsType               = "oidcClientCredentials"
+	OIDCClientCredentialsFieldAllowedScopes = "allowedScopes"
+	OIDCClientCredentialsFieldPrincipalID   = "principalID"
+	OIDCClientCredentialsFieldUserName      = "userName"
+)
+
+type OIDCClientCredentials struct {
+	AllowedScopes []string `json:"allowedScopes,omitempty" yaml:"allowedScopes,omitempty"`
+	PrincipalID   string   `json:"principalID,omitempty" yaml:"principalID,omitempty"`
+	UserName      string   `json:"userName,omitempty" yaml:"userName,omitempty"`
+}
@@ -2,15 +2,19 @@
 
 const (
 	OIDCClientSpecType                               = "oidcClientSpec"
+	OIDCClientSpecFieldClientCredentials             = "clientCredentials"
 	OIDCClientSpecFieldDescription                   = "description"
+	OIDCClientSpecFieldPostLogoutRedirectURIs        = "postLogoutRedirectURIs"
 	OIDCClientSpecFieldRedirectURIs                  = "redirectURIs"
 	OIDCClientSpecFieldRefreshTokenExpirationSeconds = "refreshTokenExpirationSeconds"
 	OIDCClientSpecFieldTokenExpirationSeconds        = "tokenExpirationSeconds"
 )
 
 type OIDCClientSpec struct {
-	Description                   string   `json:"description,omitempty" yaml:"description,omitempty"`
-	RedirectURIs                  []string `json:"redirectURIs,omitempty" yaml:"redirectURIs,omitempty"`
-	RefreshTokenExpirationSeconds int64    `json:"refreshTokenExpirationSeconds,omitempty" yaml:"refreshTokenExpirationSeconds,omitempty"`
-	TokenExpirationSeconds        int64    `json:"tokenExpirationSeconds,omitempty" yaml:"tokenExpirationSeconds,omitempty"`
+	ClientCredentials             *OIDCClientCredentials `json:"clientCredentials,omitempty" yaml:"clientCredentials,omitempty"`
+	Description                   string                 `json:"description,omitempty" yaml:"description,omitempty"`
+	PostLogoutRedirectURIs        []string               `json:"postLogoutRedirectURIs,omitempty" yaml:"postLogoutRedirectURIs,omitempty"`
+	RedirectURIs                  []string               `json:"redirectURIs,omitempty" yaml:"redirectURIs,omitempty"`
+	RefreshTokenExpirationSeconds int64                  `json:"refreshTokenExpirationSeconds,omitempty" yaml:"refreshTokenExpirationSeconds,omitempty"`
+	TokenExpirationSeconds        int64                  `json:"tokenExpirationSeconds,omitempty" yaml:"tokenExpirationSeconds,omitempty"`
 }
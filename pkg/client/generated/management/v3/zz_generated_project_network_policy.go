@@ -10,6 +10,8 @@
 	ProjectNetworkPolicyFieldCreated              = "created"
 	ProjectNetworkPolicyFieldCreatorID            = "creatorId"
 	ProjectNetworkPolicyFieldDescription          = "description"
+	ProjectNetworkPolicyFieldEgress               = "egress"
+	ProjectNetworkPolicyFieldIngress              = "ingress"
 	ProjectNetworkPolicyFieldLabels               = "labels"
 	ProjectNetworkPolicyFieldName                 = "name"
 	ProjectNetworkPolicyFieldNamespaceId          = "namespaceId"
@@ -29,6 +31,8 @@ type ProjectNetworkPolicy struct {
 	Created              string                      `json:"created,omitempty" yaml:"created,omitempty"`
 	CreatorID            string                      `json:"creatorId,omitempty" yaml:"creatorId,omitempty"`
 	Description          string                      `json:"description,omitempty" yaml:"description,omitempty"`
+	Egress               []ProjectNetworkPolicyRule  `json:"egress,omitempty" yaml:"egress,omitempty"`
+	Ingress              []ProjectNetworkPolicyRule  `json:"ingress,omitempty" yaml:"ingress,omitempty"`
 	Labels               map[string]string           `json:"labels,omitempty" yaml:"labels,omitempty"`
 	Name                 string                      `json:"name,omitempty" yaml:"name,omitempty"`
 	NamespaceId          string                      `json:"namespaceId,omitempty" yaml:"namespaceId,omitempty"`
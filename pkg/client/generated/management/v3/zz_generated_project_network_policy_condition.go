@@ -0,0 +1,18 @@
+package client
+
+const (
+	ProjectNetworkPolicyConditionType                    = "projectNetworkPolicyCondition"
+	ProjectNetworkPolicyConditionFieldLastTransitionTime = "lastTransitionTime"
+	ProjectNetworkPolicyConditionFieldMessage            = "message"
+	ProjectNetworkPolicyConditionFieldReason             = "reason"
+	ProjectNetworkPolicyConditionFieldStatus             = "status"
+	ProjectNetworkPolicyConditionFieldType               = "type"
+)
+
+type ProjectNetworkPolicyCondition struct {
+	LastTransitionTime string `json:"lastTransitionTime,omitempty" yaml:"lastTransitionTime,omitempty"`
+	Message            string `json:"message,omitempty" yaml:"message,omitempty"`
+	Reason             string `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Status             string `json:"status,omitempty" yaml:"status,omitempty"`
+	Type               string `json:"type,omitempty" yaml:"type,omitempty"`
+}
@@ -0,0 +1,16 @@
+package client
+
+const (
+	ProjectNetworkPolicyPeerType               = "projectNetworkPolicyPeer"
+	ProjectNetworkPolicyPeerFieldCidr          = "cidr"
+	ProjectNetworkPolicyPeerFieldExcept        = "except"
+	ProjectNetworkPolicyPeerFieldNamespaceName = "namespaceName"
+	ProjectNetworkPolicyPeerFieldProjectName   = "projectName"
+)
+
+type ProjectNetworkPolicyPeer struct {
+	Cidr          string   `json:"cidr,omitempty" yaml:"cidr,omitempty"`
+	Except        []string `json:"except,omitempty" yaml:"except,omitempty"`
+	NamespaceName string   `json:"namespaceName,omitempty" yaml:"namespaceName,omitempty"`
+	ProjectName   string   `json:"projectName,omitempty" yaml:"projectName,omitempty"`
+}
@@ -0,0 +1,14 @@
+package client
+
+const (
+	ProjectNetworkPolicyPortType          = "projectNetworkPolicyPort"
+	ProjectNetworkPolicyPortFieldEndPort  = "endPort"
+	ProjectNetworkPolicyPortFieldPort     = "port"
+	ProjectNetworkPolicyPortFieldProtocol = "protocol"
+)
+
+type ProjectNetworkPolicyPort struct {
+	EndPort  int64  `json:"endPort,omitempty" yaml:"endPort,omitempty"`
+	Port     int64  `json:"port,omitempty" yaml:"port,omitempty"`
+	Protocol string `json:"protocol,omitempty" yaml:"protocol,omitempty"`
+}
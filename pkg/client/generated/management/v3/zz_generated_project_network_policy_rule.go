@@ -0,0 +1,12 @@
+package client
+
+const (
+	ProjectNetworkPolicyRuleType       = "projectNetworkPolicyRule"
+	ProjectNetworkPolicyRuleFieldPeers = "peers"
+	ProjectNetworkPolicyRuleFieldPorts = "ports"
+)
+
+type ProjectNetworkPolicyRule struct {
+	Peers []ProjectNetworkPolicyPeer `json:"peers,omitempty" yaml:"peers,omitempty"`
+	Ports []ProjectNetworkPolicyPort `json:"ports,omitempty" yaml:"ports,omitempty"`
+}
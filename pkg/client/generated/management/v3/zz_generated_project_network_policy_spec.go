@@ -3,10 +3,14 @@
 const (
 	ProjectNetworkPolicySpecType             = "projectNetworkPolicySpec"
 	ProjectNetworkPolicySpecFieldDescription = "description"
+	ProjectNetworkPolicySpecFieldEgress      = "egress"
+	ProjectNetworkPolicySpecFieldIngress     = "ingress"
 	ProjectNetworkPolicySpecFieldProjectID   = "projectId"
 )
 
 type ProjectNetworkPolicySpec struct {
-	Description string `json:"description,omitempty" yaml:"description,omitempty"`
-	ProjectID   string `json:"projectId,omitempty" yaml:"projectId,omitempty"`
+	Description string                     `json:"description,omitempty" yaml:"description,omitempty"`
+	Egress      []ProjectNetworkPolicyRule `json:"egress,omitempty" yaml:"egress,omitempty"`
+	Ingress     []ProjectNetworkPolicyRule `json:"ingress,omitempty" yaml:"ingress,omitempty"`
+	ProjectID   string                     `json:"projectId,omitempty" yaml:"projectId,omitempty"`
 }
@@ -1,8 +1,10 @@
 package client
 
 const (
-	ProjectNetworkPolicyStatusType = "projectNetworkPolicyStatus"
+	ProjectNetworkPolicyStatusType            = "projectNetworkPolicyStatus"
+	ProjectNetworkPolicyStatusFieldConditions = "conditions"
 )
 
 type ProjectNetworkPolicyStatus struct {
+	Conditions []ProjectNetworkPolicyCondition `json:"conditions,omitempty" yaml:"conditions,omitempty"`
 }
@@ -0,0 +1,12 @@
+package client
+
+const (
+	TunnelDialDestinationType       = "tunnelDialDestination"
+	TunnelDialDestinationFieldCidr  = "cidr"
+	TunnelDialDestinationFieldPorts = "ports"
+)
+
+type TunnelDialDestination struct {
+	Cidr  string  `json:"cidr,omitempty" yaml:"cidr,omitempty"`
+	Ports []int64 `json:"ports,omitempty" yaml:"ports,omitempty"`
+}
@@ -0,0 +1,10 @@
+package client
+
+const (
+	TunnelDialPolicyType                     = "tunnelDialPolicy"
+	TunnelDialPolicyFieldAllowedDestinations = "allowedDestinations"
+)
+
+type TunnelDialPolicy struct {
+	AllowedDestinations []TunnelDialDestination `json:"allowedDestinations,omitempty" yaml:"allowedDestinations,omitempty"`
+}
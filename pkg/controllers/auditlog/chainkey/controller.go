@@ -0,0 +1,94 @@
+package chainkey
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"github.com/rancher/rancher/pkg/auth/audit"
+	"github.com/rancher/rancher/pkg/namespace"
+	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	keySize = 32
+)
+
+type handler struct {
+	writer *audit.Writer
+}
+
+// Ensure creates the chain key secret with a random key if it does not exist yet, and sets its active key on the
+// writer. It must be called before any logs are written since chained logs can't be written without a key.
+func Ensure(secrets corecontrollers.SecretClient, writer *audit.Writer) error {
+	secret, err := secrets.Get(namespace.System, audit.ChainKeySecretName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		secret, err = secrets.Create(newKeySecret(time.Now()))
+		if apierrors.IsAlreadyExists(err) {
+			secret, err = secrets.Get(namespace.System, audit.ChainKeySecretName, metav1.GetOptions{})
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get audit log chain key secret: %w", err)
+	}
+
+	return setActiveKey(writer, secret)
+}
+
+func newKeySecret(now time.Time) *corev1.Secret {
+	key := make([]byte, keySize)
+	_, _ = rand.Read(key)
+
+	keyID := now.UTC().Format("20060102T150405Z")
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      audit.ChainKeySecretName,
+			Namespace: namespace.System,
+			Annotations: map[string]string{
+				audit.ChainActiveKeyAnnotation: keyID,
+			},
+		},
+		Data: map[string][]byte{
+			keyID: key,
+		},
+	}
+}
+
+func setActiveKey(writer *audit.Writer, secret *corev1.Secret) error {
+	keys, active, err := audit.ChainKeysFromSecret(secret)
+	if err != nil {
+		return err
+	}
+
+	return writer.SetChainKey(active, keys[active])
+}
+
+// OnChange updates the writer when the active key is rotated.
+func (h *handler) OnChange(key string, obj *corev1.Secret) (*corev1.Secret, error) {
+	if obj == nil || obj.DeletionTimestamp != nil {
+		return obj, nil
+	}
+
+	if obj.Namespace != namespace.System || obj.Name != audit.ChainKeySecretName {
+		return obj, nil
+	}
+
+	if err := setActiveKey(h.writer, obj); err != nil {
+		return obj, fmt.Errorf("failed to update audit log chain key: %w", err)
+	}
+
+	return obj, nil
+}
+
+func Register(ctx context.Context, writer *audit.Writer, secrets corecontrollers.SecretController) {
+	h := &handler{
+		writer: writer,
+	}
+
+	secrets.OnChange(ctx, "auditlog-chain-key-controller", h.OnChange)
+}
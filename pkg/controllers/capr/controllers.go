@@ -7,6 +7,7 @@
 	"github.com/rancher/rancher/pkg/capr/planner"
 	"github.com/rancher/rancher/pkg/controllers/capr/bootstrap"
 	"github.com/rancher/rancher/pkg/controllers/capr/dynamicschema"
+	"github.com/rancher/rancher/pkg/controllers/capr/etcdsnapshotpolicy"
 	"github.com/rancher/rancher/pkg/controllers/capr/machinedrain"
 	"github.com/rancher/rancher/pkg/controllers/capr/machinenodelookup"
 	"github.com/rancher/rancher/pkg/controllers/capr/machineprovision"
@@ -32,6 +33,7 @@ func Register(ctx context.Context, clients *wrangler.Context, kubeconfigManager
 		SystemAgentImage:        settings.SystemAgentInstallerImage.Get,
 		SystemPodLabelSelectors: systeminfo.NewRetriever(clients).GetSystemPodLabelSelectors,
 		GetBootstrapManifests:   prebootstrap.NewRetriever(clients).GeneratePreBootstrapClusterAgentManifest,
+		EtcdSnapshotVerifyImage: settings.EtcdSnapshotVerifyImage.Get,
 	})
 	if features.MCM.Enabled() {
 		if err := dynamicschema.Register(ctx, clients); err != nil {
@@ -48,6 +50,7 @@ func Register(ctx context.Context, clients *wrangler.Context, kubeconfigManager
 	rkecontrolplane.Register(ctx, clients)
 	managesystemagent.Register(ctx, clients)
 	machinedrain.Register(ctx, clients)
+	etcdsnapshotpolicy.Register(ctx, clients)
 
 	return nil
 }
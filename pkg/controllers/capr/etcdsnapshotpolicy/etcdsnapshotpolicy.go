@@ -0,0 +1,217 @@
+package etcdsnapshotpolicy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	provv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/capr"
+	provcontrollers "github.com/rancher/rancher/pkg/generated/controllers/provisioning.cattle.io/v1"
+	rkecontrollers "github.com/rancher/rancher/pkg/generated/controllers/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/wrangler"
+	"github.com/rancher/wrangler/v3/pkg/relatedresource"
+	"github.com/robfig/cron"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type handler struct {
+	policies          rkecontrollers.ETCDSnapshotPolicyController
+	policyCache       rkecontrollers.ETCDSnapshotPolicyCache
+	etcdSnapshots     rkecontrollers.ETCDSnapshotClient
+	etcdSnapshotCache rkecontrollers.ETCDSnapshotCache
+	clusterCache      provcontrollers.ClusterCache
+	now               func() time.Time
+}
+
+// Register sets up the etcd snapshot policy controller. It requests etcd snapshots of the clusters on the schedule of
+// their policies, which are taken by the planner, and marks the snapshots of the policies which are no longer kept by
+// any retention tier as expired.
+func Register(ctx context.Context, clients *wrangler.Context) {
+	h := handler{
+		policies:          clients.RKE.ETCDSnapshotPolicy(),
+		policyCache:       clients.RKE.ETCDSnapshotPolicy().Cache(),
+		etcdSnapshots:     clients.RKE.ETCDSnapshot(),
+		etcdSnapshotCache: clients.RKE.ETCDSnapshot().Cache(),
+		clusterCache:      clients.Provisioning.Cluster().Cache(),
+		now:               time.Now,
+	}
+
+	rkecontrollers.RegisterETCDSnapshotPolicyStatusHandler(ctx, clients.RKE.ETCDSnapshotPolicy(),
+		capr.Reconciled, "etcd-snapshot-policy", h.OnChange)
+	relatedresource.Watch(ctx, "etcd-snapshot-policy-trigger", h.resolvePolicies, clients.RKE.ETCDSnapshotPolicy(), clients.RKE.ETCDSnapshot())
+	relatedresource.Watch(ctx, "etcd-snapshot-policy-controlplane", resolveControlPlane, clients.RKE.RKEControlPlane(), clients.RKE.ETCDSnapshotPolicy())
+}
+
+// resolveControlPlane enqueues the controlplane of the cluster of a changed etcd snapshot policy, so that the planner
+// takes the snapshots it requests.
+func resolveControlPlane(namespace, _ string, obj runtime.Object) ([]relatedresource.Key, error) {
+	policy, ok := obj.(*rkev1.ETCDSnapshotPolicy)
+	if !ok || policy.Spec.ClusterName == "" {
+		return nil, nil
+	}
+	return []relatedresource.Key{{Namespace: namespace, Name: policy.Spec.ClusterName}}, nil
+}
+
+// resolvePolicies enqueues the etcd snapshot policies of the cluster of a changed etcd snapshot.
+func (h *handler) resolvePolicies(namespace, _ string, obj runtime.Object) ([]relatedresource.Key, error) {
+	snapshot, ok := obj.(*rkev1.ETCDSnapshot)
+	if !ok || snapshot.Spec.ClusterName == "" {
+		return nil, nil
+	}
+
+	policies, err := h.policyCache.List(namespace, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []relatedresource.Key
+	for _, policy := range policies {
+		if policy.Spec.ClusterName == snapshot.Spec.ClusterName {
+			keys = append(keys, relatedresource.Key{Namespace: policy.Namespace, Name: policy.Name})
+		}
+	}
+	return keys, nil
+}
+
+func (h *handler) OnChange(policy *rkev1.ETCDSnapshotPolicy, status rkev1.ETCDSnapshotPolicyStatus) (rkev1.ETCDSnapshotPolicyStatus, error) {
+	if policy == nil || !policy.DeletionTimestamp.IsZero() {
+		return status, nil
+	}
+	status.ObservedGeneration = policy.Generation
+
+	cluster, err := h.clusterCache.Get(policy.Namespace, policy.Spec.ClusterName)
+	if err != nil {
+		return status, err
+	}
+	if cluster.Spec.RKEConfig == nil {
+		return status, fmt.Errorf("cluster %s/%s is not an RKE2 or K3s cluster provisioned by Rancher", cluster.Namespace, cluster.Name)
+	}
+
+	if status, err = h.reconcileSchedule(policy, cluster, status, h.now().UTC()); err != nil {
+		return status, err
+	}
+	return h.reconcileRetention(policy, cluster, status)
+}
+
+// reconcileSchedule requests an etcd snapshot of the cluster once the next time of the schedule is reached by recording
+// it as the last schedule time, and enqueues the policy for the following one. The snapshot is taken by the planner,
+// which records the schedule time of the last snapshot it took for the policy on the status of the controlplane. Missed
+// schedule times only result in a single snapshot.
+func (h *handler) reconcileSchedule(policy *rkev1.ETCDSnapshotPolicy, cluster *provv1.Cluster, status rkev1.ETCDSnapshotPolicyStatus, now time.Time) (rkev1.ETCDSnapshotPolicyStatus, error) {
+	if policy.Spec.Schedule == "" || policy.Spec.Suspend {
+		status.NextScheduleTime = nil
+		return status, nil
+	}
+
+	schedule, err := cron.ParseStandard(policy.Spec.Schedule)
+	if err != nil {
+		return status, fmt.Errorf("invalid schedule %q: %w", policy.Spec.Schedule, err)
+	}
+
+	from := policy.CreationTimestamp.Time
+	if status.LastScheduleTime != nil {
+		from = status.LastScheduleTime.Time
+	}
+	next := schedule.Next(from.UTC())
+	if next.After(now) {
+		status.NextScheduleTime = &metav1.Time{Time: next}
+		h.policies.EnqueueAfter(policy.Namespace, policy.Name, next.Sub(now))
+		return status, nil
+	}
+
+	logrus.Infof("[etcdsnapshotpolicy] %s/%s: requested scheduled etcd snapshot of cluster %s", policy.Namespace, policy.Name, cluster.Name)
+	next = schedule.Next(now)
+	status.LastScheduleTime = &metav1.Time{Time: now.Truncate(time.Second)}
+	status.NextScheduleTime = &metav1.Time{Time: next}
+	h.policies.EnqueueAfter(policy.Namespace, policy.Name, next.Sub(now))
+	return status, nil
+}
+
+// reconcileRetention labels the snapshots taken on the schedule of the policy with its name, records the policy and the
+// retention tiers which keep them on the successful ones, and marks the snapshots which are not kept as expired. Expired
+// snapshots are deleted from the downstream cluster by the snapshot backpopulate controller.
+func (h *handler) reconcileRetention(policy *rkev1.ETCDSnapshotPolicy, cluster *provv1.Cluster, status rkev1.ETCDSnapshotPolicyStatus) (rkev1.ETCDSnapshotPolicyStatus, error) {
+	if err := h.labelSnapshots(policy, cluster); err != nil {
+		return status, err
+	}
+
+	snapshots, err := h.etcdSnapshotCache.List(policy.Namespace, labels.SelectorFromSet(labels.Set{
+		capr.ClusterNameLabel:        cluster.Name,
+		capr.ETCDSnapshotPolicyLabel: policy.Name,
+	}))
+	if err != nil {
+		return status, err
+	}
+
+	var scheduled []*rkev1.ETCDSnapshot
+	for _, snapshot := range snapshots {
+		if snapshot.SnapshotFile.Status == "successful" && snapshot.SnapshotFile.CreatedAt != nil {
+			scheduled = append(scheduled, snapshot)
+		}
+	}
+
+	retention := policy.Spec.Retention
+	prune := retention.Hourly > 0 || retention.Daily > 0 || retention.Weekly > 0
+	var tiers map[string][]string
+	if prune {
+		tiers = retentionTiers(retention, scheduled)
+	}
+
+	retained := 0
+	for _, snapshot := range scheduled {
+		want := *snapshot.Status.DeepCopy()
+		want.Policy = policy.Name
+		want.RetentionTiers, want.Expired = nil, false
+		if prune {
+			want.RetentionTiers = tiers[snapshot.Name]
+			want.Expired = len(want.RetentionTiers) == 0
+		}
+		if !want.Expired {
+			retained++
+		}
+		if equality.Semantic.DeepEqual(snapshot.Status, want) {
+			continue
+		}
+
+		if want.Expired {
+			logrus.Infof("[etcdsnapshotpolicy] %s/%s: etcd snapshot %s expired", policy.Namespace, policy.Name, snapshot.Name)
+		}
+		snapshot = snapshot.DeepCopy()
+		snapshot.Status = want
+		if _, err := h.etcdSnapshots.UpdateStatus(snapshot); err != nil {
+			return status, err
+		}
+	}
+
+	status.RetainedSnapshots = retained
+	return status, nil
+}
+
+// labelSnapshots labels the etcd snapshots of the cluster which were taken on the schedule of the policy with its name.
+// The snapshots are recognized by the base name of the snapshots of the policy, which is unique to it.
+func (h *handler) labelSnapshots(policy *rkev1.ETCDSnapshotPolicy, cluster *provv1.Cluster) error {
+	snapshots, err := h.etcdSnapshotCache.List(policy.Namespace, labels.SelectorFromSet(labels.Set{capr.ClusterNameLabel: cluster.Name}))
+	if err != nil {
+		return err
+	}
+
+	prefix := capr.ETCDSnapshotPolicySnapshotName(policy.Name) + "-"
+	for _, snapshot := range snapshots {
+		if _, ok := snapshot.Labels[capr.ETCDSnapshotPolicyLabel]; ok || !strings.HasPrefix(snapshot.SnapshotFile.Name, prefix) {
+			continue
+		}
+		snapshot = snapshot.DeepCopy()
+		snapshot.Labels[capr.ETCDSnapshotPolicyLabel] = policy.Name
+		if _, err := h.etcdSnapshots.Update(snapshot); err != nil {
+			return err
+		}
+	}
+	return nil
+}
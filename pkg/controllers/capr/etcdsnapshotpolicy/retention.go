@@ -0,0 +1,78 @@
+package etcdsnapshotpolicy
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+)
+
+const (
+	tierLatest = "latest"
+	tierHourly = "hourly"
+	tierDaily  = "daily"
+	tierWeekly = "weekly"
+)
+
+// retentionTiers returns the retention tiers which keep each of the snapshots, by snapshot name. Snapshots which are not
+// kept by any tier have no entry. The tiers are applied separately to each series of snapshots, which are the snapshots
+// of a node in the same storage location, and the newest snapshot of each series is always kept. A tier keeps the
+// newest snapshot of each of the last hours, days or weeks in which the series has a snapshot.
+func retentionTiers(retention rkev1.ETCDSnapshotPolicyRetention, snapshots []*rkev1.ETCDSnapshot) map[string][]string {
+	series := map[string][]*rkev1.ETCDSnapshot{}
+	for _, snapshot := range snapshots {
+		key := seriesKey(snapshot)
+		series[key] = append(series[key], snapshot)
+	}
+
+	result := map[string][]string{}
+	for _, snapshots := range series {
+		sort.Slice(snapshots, func(i, j int) bool {
+			ti, tj := snapshots[i].SnapshotFile.CreatedAt.Time, snapshots[j].SnapshotFile.CreatedAt.Time
+			if !ti.Equal(tj) {
+				return ti.After(tj)
+			}
+			return snapshots[i].Name < snapshots[j].Name
+		})
+
+		result[snapshots[0].Name] = append(result[snapshots[0].Name], tierLatest)
+		keepNewestPerPeriod(result, snapshots, tierHourly, retention.Hourly, func(t time.Time) string {
+			return t.Format("2006-01-02T15")
+		})
+		keepNewestPerPeriod(result, snapshots, tierDaily, retention.Daily, func(t time.Time) string {
+			return t.Format("2006-01-02")
+		})
+		keepNewestPerPeriod(result, snapshots, tierWeekly, retention.Weekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-%d", year, week)
+		})
+	}
+	return result
+}
+
+// keepNewestPerPeriod adds the tier to the newest snapshot of each of the last count periods which have a snapshot. The
+// snapshots must be sorted from newest to oldest.
+func keepNewestPerPeriod(result map[string][]string, snapshots []*rkev1.ETCDSnapshot, tier string, count int, period func(time.Time) string) {
+	last := ""
+	for _, snapshot := range snapshots {
+		if count <= 0 {
+			return
+		}
+		p := period(snapshot.SnapshotFile.CreatedAt.UTC())
+		if p == last {
+			continue
+		}
+		last = p
+		result[snapshot.Name] = append(result[snapshot.Name], tier)
+		count--
+	}
+}
+
+// seriesKey identifies the node and the storage location of the snapshot.
+func seriesKey(snapshot *rkev1.ETCDSnapshot) string {
+	if s3 := snapshot.SnapshotFile.S3; s3 != nil {
+		return fmt.Sprintf("%s/s3/%s/%s/%s", snapshot.SnapshotFile.NodeName, s3.Endpoint, s3.Bucket, s3.Folder)
+	}
+	return snapshot.SnapshotFile.NodeName + "/local"
+}
@@ -0,0 +1,89 @@
+package etcdsnapshotpolicy
+
+import (
+	"testing"
+	"time"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func snapshot(name, nodeName string, createdAt time.Time, s3 *rkev1.ETCDSnapshotS3) *rkev1.ETCDSnapshot {
+	return &rkev1.ETCDSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "fleet-default",
+			Name:      name,
+		},
+		SnapshotFile: rkev1.ETCDSnapshotFile{
+			Name:      "etcd-policy-test-" + name,
+			NodeName:  nodeName,
+			CreatedAt: &metav1.Time{Time: createdAt},
+			S3:        s3,
+			Status:    "successful",
+		},
+	}
+}
+
+func Test_retentionTiers(t *testing.T) {
+	// Monday
+	base := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	s3 := &rkev1.ETCDSnapshotS3{Bucket: "backups"}
+
+	snapshots := []*rkev1.ETCDSnapshot{
+		snapshot("mon-00", "node1", base, nil),
+		snapshot("mon-06", "node1", base.Add(6*time.Hour), nil),
+		snapshot("mon-12", "node1", base.Add(12*time.Hour), nil),
+		snapshot("tue-00", "node1", base.Add(24*time.Hour), nil),
+		snapshot("tue-06", "node1", base.Add(30*time.Hour), nil),
+		snapshot("next-mon-00", "node1", base.Add(7*24*time.Hour), nil),
+		snapshot("next-mon-06", "node1", base.Add(7*24*time.Hour+6*time.Hour), nil),
+		snapshot("s3-mon-00", "node1", base, s3),
+		snapshot("s3-tue-00", "node1", base.Add(24*time.Hour), s3),
+		snapshot("node2-mon-00", "node2", base, nil),
+	}
+
+	tests := []struct {
+		name      string
+		retention rkev1.ETCDSnapshotPolicyRetention
+		want      map[string][]string
+	}{
+		{
+			name: "only latest",
+			want: map[string][]string{
+				"next-mon-06":  {tierLatest},
+				"s3-tue-00":    {tierLatest},
+				"node2-mon-00": {tierLatest},
+			},
+		},
+		{
+			name:      "hourly",
+			retention: rkev1.ETCDSnapshotPolicyRetention{Hourly: 3},
+			want: map[string][]string{
+				"next-mon-06":  {tierLatest, tierHourly},
+				"next-mon-00":  {tierHourly},
+				"tue-06":       {tierHourly},
+				"s3-tue-00":    {tierLatest, tierHourly},
+				"s3-mon-00":    {tierHourly},
+				"node2-mon-00": {tierLatest, tierHourly},
+			},
+		},
+		{
+			name:      "daily and weekly",
+			retention: rkev1.ETCDSnapshotPolicyRetention{Daily: 2, Weekly: 2},
+			want: map[string][]string{
+				"next-mon-06":  {tierLatest, tierDaily, tierWeekly},
+				"tue-06":       {tierDaily, tierWeekly},
+				"s3-tue-00":    {tierLatest, tierDaily, tierWeekly},
+				"s3-mon-00":    {tierDaily},
+				"node2-mon-00": {tierLatest, tierDaily, tierWeekly},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, retentionTiers(tt.retention, snapshots))
+		})
+	}
+}
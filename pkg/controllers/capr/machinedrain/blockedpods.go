@@ -0,0 +1,149 @@
+package machinedrain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	blockedReasonDisruptionBudget = string(policyv1.DisruptionBudgetCause)
+	blockedReasonTerminating      = "Terminating"
+	blockedReasonNotEvicted       = "NotEvicted"
+)
+
+// blockedPod is a pod which was still running on the node when the drain failed. It is recorded as json on the plan
+// secret of the machine.
+type blockedPod struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// PodDisruptionBudget is the name of the pod disruption budget in the namespace of the pod which does not allow its
+	// eviction, if any.
+	PodDisruptionBudget string `json:"podDisruptionBudget,omitempty"`
+	Reason              string `json:"reason"`
+	Message             string `json:"message,omitempty"`
+}
+
+func (b blockedPod) String() string {
+	return fmt.Sprintf("%s/%s (%s)", b.Namespace, b.Name, b.Message)
+}
+
+// drainError is returned when a node could not be drained, along with the pods which blocked the drain.
+type drainError struct {
+	err         error
+	blockedPods []blockedPod
+}
+
+func (e *drainError) Error() string {
+	if len(e.blockedPods) == 0 {
+		return e.err.Error()
+	}
+
+	pods := make([]string, 0, 3)
+	for i, pod := range e.blockedPods {
+		if i == 3 {
+			pods = append(pods, fmt.Sprintf("and %d more", len(e.blockedPods)-3))
+			break
+		}
+		pods = append(pods, pod.String())
+	}
+	return fmt.Sprintf("%v: eviction blocked for pod(s) %s", e.err, strings.Join(pods, ", "))
+}
+
+func (e *drainError) Unwrap() error {
+	return e.err
+}
+
+// ignoreDrainError returns true if the error of a drain is ignored as configured. Only failures to evict or delete the
+// pods of the node are ignored, failures to look up the node are not.
+func ignoreDrainError(drainErr error, ignoreErrors bool) bool {
+	var dErr *drainError
+	return ignoreErrors && errors.As(drainErr, &dErr)
+}
+
+// findBlockedPods returns the pods which are still on the node and would have been evicted by the drain, along with the
+// reason they were not. Pods of daemonsets are only considered if they are not ignored by the drain.
+func findBlockedPods(ctx context.Context, client kubernetes.Interface, nodeName string, ignoreDaemonSets bool) ([]blockedPod, error) {
+	pods, err := client.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.SelectorFromSet(fields.Set{"spec.nodeName": nodeName}).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pdbsByNamespace := map[string][]policyv1.PodDisruptionBudget{}
+	var blocked []blockedPod
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName != nodeName || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if _, ok := pod.Annotations[corev1.MirrorPodAnnotationKey]; ok {
+			continue
+		}
+		if controller := metav1.GetControllerOf(&pod); ignoreDaemonSets && controller != nil && controller.Kind == "DaemonSet" {
+			continue
+		}
+
+		pdbs, ok := pdbsByNamespace[pod.Namespace]
+		if !ok {
+			pdbList, err := client.PolicyV1().PodDisruptionBudgets(pod.Namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			pdbs = pdbList.Items
+			pdbsByNamespace[pod.Namespace] = pdbs
+		}
+
+		blocked = append(blocked, blockedPodReason(pod, pdbs))
+	}
+
+	sort.Slice(blocked, func(i, j int) bool {
+		if blocked[i].Namespace != blocked[j].Namespace {
+			return blocked[i].Namespace < blocked[j].Namespace
+		}
+		return blocked[i].Name < blocked[j].Name
+	})
+
+	return blocked, nil
+}
+
+func blockedPodReason(pod corev1.Pod, pdbs []policyv1.PodDisruptionBudget) blockedPod {
+	result := blockedPod{
+		Namespace: pod.Namespace,
+		Name:      pod.Name,
+	}
+
+	for _, pdb := range pdbs {
+		// a nil selector selects no pods, while an empty selector selects all pods in the namespace
+		if pdb.Spec.Selector == nil || pdb.Status.DisruptionsAllowed > 0 {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil || !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		result.PodDisruptionBudget = pdb.Name
+		result.Reason = blockedReasonDisruptionBudget
+		result.Message = fmt.Sprintf("the disruption budget %s needs %d healthy pods and has %d currently", pdb.Name, pdb.Status.DesiredHealthy, pdb.Status.CurrentHealthy)
+		return result
+	}
+
+	if pod.DeletionTimestamp != nil {
+		result.Reason = blockedReasonTerminating
+		result.Message = "pod is terminating"
+		return result
+	}
+
+	result.Reason = blockedReasonNotEvicted
+	result.Message = "pod was not evicted"
+	return result
+}
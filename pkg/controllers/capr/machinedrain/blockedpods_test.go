@@ -0,0 +1,182 @@
+package machinedrain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func pod(namespace, name, nodeName string, podLabels map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			Labels:    podLabels,
+		},
+		Spec: corev1.PodSpec{
+			NodeName: nodeName,
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+		},
+	}
+}
+
+func Test_findBlockedPods(t *testing.T) {
+	now := metav1.Now()
+
+	daemonSetPod := pod("kube-system", "canal-abcde", "node1", nil)
+	daemonSetPod.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: "apps/v1",
+		Kind:       "DaemonSet",
+		Name:       "canal",
+		Controller: &[]bool{true}[0],
+	}}
+	mirrorPod := pod("kube-system", "etcd-node1", "node1", nil)
+	mirrorPod.Annotations = map[string]string{corev1.MirrorPodAnnotationKey: "hash"}
+	completedPod := pod("default", "job-abcde", "node1", nil)
+	completedPod.Status.Phase = corev1.PodSucceeded
+	terminatingPod := pod("default", "web-terminating", "node1", nil)
+	terminatingPod.DeletionTimestamp = &now
+
+	objects := []runtime.Object{
+		pod("default", "db-0", "node1", map[string]string{"app": "db"}),
+		pod("default", "web-0", "node1", map[string]string{"app": "web"}),
+		pod("default", "db-1", "node2", map[string]string{"app": "db"}),
+		daemonSetPod,
+		mirrorPod,
+		completedPod,
+		terminatingPod,
+		&policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "db",
+			},
+			Spec: policyv1.PodDisruptionBudgetSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+			},
+			Status: policyv1.PodDisruptionBudgetStatus{
+				DisruptionsAllowed: 0,
+				DesiredHealthy:     2,
+				CurrentHealthy:     2,
+			},
+		},
+		&policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "web",
+			},
+			Spec: policyv1.PodDisruptionBudgetSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			},
+			Status: policyv1.PodDisruptionBudgetStatus{
+				DisruptionsAllowed: 1,
+			},
+		},
+	}
+
+	tests := []struct {
+		name             string
+		ignoreDaemonSets bool
+		want             []blockedPod
+	}{
+		{
+			name:             "ignoring daemonsets",
+			ignoreDaemonSets: true,
+			want: []blockedPod{
+				{
+					Namespace:           "default",
+					Name:                "db-0",
+					PodDisruptionBudget: "db",
+					Reason:              "DisruptionBudget",
+					Message:             "the disruption budget db needs 2 healthy pods and has 2 currently",
+				},
+				{
+					Namespace: "default",
+					Name:      "web-0",
+					Reason:    "NotEvicted",
+					Message:   "pod was not evicted",
+				},
+				{
+					Namespace: "default",
+					Name:      "web-terminating",
+					Reason:    "Terminating",
+					Message:   "pod is terminating",
+				},
+			},
+		},
+		{
+			name: "not ignoring daemonsets",
+			want: []blockedPod{
+				{
+					Namespace:           "default",
+					Name:                "db-0",
+					PodDisruptionBudget: "db",
+					Reason:              "DisruptionBudget",
+					Message:             "the disruption budget db needs 2 healthy pods and has 2 currently",
+				},
+				{
+					Namespace: "default",
+					Name:      "web-0",
+					Reason:    "NotEvicted",
+					Message:   "pod was not evicted",
+				},
+				{
+					Namespace: "default",
+					Name:      "web-terminating",
+					Reason:    "Terminating",
+					Message:   "pod is terminating",
+				},
+				{
+					Namespace: "kube-system",
+					Name:      "canal-abcde",
+					Reason:    "NotEvicted",
+					Message:   "pod was not evicted",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(objects...)
+
+			got, err := findBlockedPods(context.Background(), client, "node1", tt.ignoreDaemonSets)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_drainErrorMessage(t *testing.T) {
+	err := errors.New("error when evicting pods/\"db-0\" -n \"default\": global timeout reached: 10m0s")
+
+	assert.Equal(t, err.Error(), (&drainError{err: err}).Error())
+
+	var blocked []blockedPod
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		blocked = append(blocked, blockedPod{Namespace: "default", Name: name, Reason: "NotEvicted", Message: "pod was not evicted"})
+	}
+	assert.Equal(t,
+		err.Error()+": eviction blocked for pod(s) default/a (pod was not evicted), default/b (pod was not evicted), default/c (pod was not evicted), and 2 more",
+		(&drainError{err: err, blockedPods: blocked}).Error())
+	assert.ErrorIs(t, &drainError{err: err, blockedPods: blocked}, err)
+}
+
+func Test_ignoreDrainError(t *testing.T) {
+	evictionErr := &drainError{err: errors.New("error when evicting pods/\"db-0\" -n \"default\": global timeout reached: 10m0s")}
+	nodeErr := errors.New("nodes \"node1\" not found")
+
+	assert.True(t, ignoreDrainError(evictionErr, true))
+	assert.True(t, ignoreDrainError(fmt.Errorf("draining: %w", evictionErr), true))
+	assert.False(t, ignoreDrainError(evictionErr, false))
+	assert.False(t, ignoreDrainError(nodeErr, true), "failing to look up the node must not be ignored")
+}
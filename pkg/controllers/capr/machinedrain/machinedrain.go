@@ -3,8 +3,10 @@
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
 	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
@@ -21,18 +23,24 @@
 	"k8s.io/client-go/util/retry"
 	"k8s.io/kubectl/pkg/drain"
 	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
 )
 
 type handler struct {
 	ctx          context.Context
+	machines     capicontrollers.MachineClient
 	machineCache capicontrollers.MachineCache
-	secrets      corecontrollers.SecretClient
+	secrets      corecontrollers.SecretController
 	secretCache  corecontrollers.SecretCache
+
+	webhookCalls     map[string]*webhookCall
+	webhookCallsLock sync.Mutex
 }
 
 func Register(ctx context.Context, clients *wrangler.Context) {
 	h := &handler{
 		ctx:          ctx,
+		machines:     clients.CAPI.Machine(),
 		machineCache: clients.CAPI.Machine().Cache(),
 		secrets:      clients.Core.Secret(),
 		secretCache:  clients.Core.Secret().Cache(),
@@ -41,10 +49,15 @@ func Register(ctx context.Context, clients *wrangler.Context) {
 	clients.Core.Secret().OnChange(ctx, "machine-drain", h.OnChange)
 }
 
-func (h *handler) OnChange(_ string, secret *corev1.Secret) (*corev1.Secret, error) {
-	if secret == nil || secret.DeletionTimestamp != nil || secret.Labels[capr.MachineNameLabel] == "" || secret.Type != capr.SecretTypeMachinePlan {
+func (h *handler) OnChange(key string, secret *corev1.Secret) (*corev1.Secret, error) {
+	if secret == nil || secret.DeletionTimestamp != nil {
+		h.forgetWebhookCalls(key, "", nil)
+		return secret, nil
+	}
+	if secret.Labels[capr.MachineNameLabel] == "" || secret.Type != capr.SecretTypeMachinePlan {
 		return secret, nil
 	}
+	h.forgetWebhookCalls(key, secret.Annotations[capr.DrainAnnotation], webhookHookAnnotations(secret.Annotations[capr.DrainAnnotation]))
 
 	machine, err := h.machineCache.Get(secret.Namespace, secret.Labels[capr.MachineNameLabel])
 	if err != nil {
@@ -117,11 +130,15 @@ func (h *handler) unDrain(secret *corev1.Secret, machine *capi.Machine, drainDat
 		return secret, err
 	}
 
-	checkPostDrainHooks := checkHookAnnotations(drainData, drainOpts.PostDrainHooks)
+	checkPostDrainHooks := checkHookAnnotations(drainData, postDrainPhase, drainOpts.PostDrainHooks)
 	if len(drainOpts.PostDrainHooks) > 0 {
 		postDrainAnnDoesNotHaveValue := secretAnnotationDoesNotHaveValue(capr.PostDrainAnnotation, drainData)
 		if postDrainAnnDoesNotHaveValue(secret) {
 			return h.updateSecretAnnotationIfCheckTrue(secret, capr.PostDrainAnnotation, drainData, postDrainAnnDoesNotHaveValue)
+		}
+		var err error
+		if secret, err = h.runWebhookHooks(secret, machine, drainData, postDrainPhase, drainOpts, drainOpts.PostDrainHooks); err != nil {
+			return secret, err
 		} else if !checkPostDrainHooks(secret) {
 			return secret, nil
 		}
@@ -150,19 +167,44 @@ func (h *handler) drain(secret *corev1.Secret, machine *capi.Machine, drainData
 		return secret, err
 	}
 
-	checkPreDrainHooks := checkHookAnnotations(drainData, drainOpts.PreDrainHooks)
+	checkPreDrainHooks := checkHookAnnotations(drainData, preDrainPhase, drainOpts.PreDrainHooks)
 	if len(drainOpts.PreDrainHooks) > 0 {
 		preDrainAnnDoesNotHaveValue := secretAnnotationDoesNotHaveValue(capr.PreDrainAnnotation, drainData)
 		if preDrainAnnDoesNotHaveValue(secret) {
 			return h.updateSecretAnnotationIfCheckTrue(secret, capr.PreDrainAnnotation, drainData, preDrainAnnDoesNotHaveValue)
+		}
+		var err error
+		if secret, err = h.runWebhookHooks(secret, machine, drainData, preDrainPhase, *drainOpts, drainOpts.PreDrainHooks); err != nil {
+			return secret, err
 		} else if !checkPreDrainHooks(secret) {
 			return secret, nil
 		}
 	}
 
 	if drainOpts.Enabled {
-		if err := h.performDrain(machine, drainOpts); err != nil {
-			return nil, err
+		drainErr := h.performDrain(machine, drainOpts)
+
+		var (
+			blocked []blockedPod
+			dErr    *drainError
+			err     error
+		)
+		if errors.As(drainErr, &dErr) {
+			blocked = dErr.blockedPods
+		}
+		ignoreErr := ignoreDrainError(drainErr, drainOpts.IgnoreErrors)
+		if secret, err = h.updateBlockedPodsAnnotation(secret, blocked); err != nil {
+			return secret, err
+		}
+		if err := h.reconcileMachineDrainedCondition(machine, drainErr, ignoreErr); err != nil {
+			return secret, err
+		}
+
+		if drainErr != nil {
+			if !ignoreErr {
+				return secret, drainErr
+			}
+			logrus.Warnf("[machinedrain] machine %s/%s: ignoring drain error as configured: %v", machine.Namespace, machine.Name, drainErr)
 		}
 	}
 
@@ -225,7 +267,75 @@ func (h *handler) performDrain(machine *capi.Machine, drainOpts *rkev1.DrainOpti
 		return err
 	}
 
-	return drain.RunNodeDrain(helper, node.Name)
+	if err := drain.RunNodeDrain(helper, node.Name); err != nil {
+		// The helper only reports the last eviction error, so determine which pods are still on the node and why.
+		blocked, blockedErr := findBlockedPods(h.ctx, helper.Client, node.Name, helper.IgnoreAllDaemonSets)
+		if blockedErr != nil {
+			logrus.Errorf("[machinedrain] machine %s/%s: failed to determine pods blocking the drain: %v", machine.Namespace, machine.Name, blockedErr)
+		}
+		return &drainError{err: err, blockedPods: blocked}
+	}
+	return nil
+}
+
+// updateBlockedPodsAnnotation records the pods which blocked the drain of the machine on its plan secret, or removes the
+// annotation if no pod blocked it.
+func (h *handler) updateBlockedPodsAnnotation(secret *corev1.Secret, blocked []blockedPod) (*corev1.Secret, error) {
+	var value string
+	if len(blocked) > 0 {
+		data, err := json.Marshal(blocked)
+		if err != nil {
+			return secret, err
+		}
+		value = string(data)
+	}
+
+	var err error
+	return secret, retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret, err = h.secrets.Get(secret.Namespace, secret.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if current, ok := secret.Annotations[capr.DrainBlockedPodsAnnotation]; current == value && (ok || value == "") {
+			return nil
+		}
+		secret = secret.DeepCopy()
+		if value == "" {
+			delete(secret.Annotations, capr.DrainBlockedPodsAnnotation)
+		} else {
+			secret.Annotations[capr.DrainBlockedPodsAnnotation] = value
+		}
+		secret, err = h.secrets.Update(secret)
+		return err
+	})
+}
+
+// reconcileMachineDrainedCondition reports the result of the drain on the Drained condition of the machine, so that the
+// pods which blocked it are visible without access to the downstream cluster.
+func (h *handler) reconcileMachineDrainedCondition(machine *capi.Machine, drainErr error, ignoreErrors bool) error {
+	condition := capi.ConditionType(capr.Drained)
+
+	machine = machine.DeepCopy()
+	switch {
+	case drainErr == nil:
+		if conditions.IsTrue(machine, condition) {
+			return nil
+		}
+		conditions.MarkTrue(machine, condition)
+	case ignoreErrors:
+		if conditions.GetReason(machine, condition) == "ErrorsIgnored" && conditions.GetMessage(machine, condition) == drainErr.Error() {
+			return nil
+		}
+		conditions.MarkFalse(machine, condition, "ErrorsIgnored", capi.ConditionSeverityWarning, "%s", drainErr.Error())
+	default:
+		if conditions.GetReason(machine, condition) == "Error" && conditions.GetMessage(machine, condition) == drainErr.Error() {
+			return nil
+		}
+		conditions.MarkFalse(machine, condition, "Error", capi.ConditionSeverityError, "%s", drainErr.Error())
+	}
+
+	_, err := h.machines.UpdateStatus(machine)
+	return err
 }
 
 func (h *handler) updateSecretAnnotationIfCheckTrue(secret *corev1.Secret, annotation, value string, check func(*corev1.Secret) bool) (*corev1.Secret, error) {
@@ -255,13 +365,14 @@ func (h *handler) cleanSecretAnnotationsIfCheckTrue(secret *corev1.Secret, drain
 		delete(secret.Annotations, capr.PreDrainAnnotation)
 		delete(secret.Annotations, capr.PostDrainAnnotation)
 		delete(secret.Annotations, capr.DrainAnnotation)
+		delete(secret.Annotations, capr.DrainBlockedPodsAnnotation)
 		delete(secret.Annotations, capr.DrainDoneAnnotation)
 		delete(secret.Annotations, capr.UnCordonAnnotation)
 		for _, hook := range drainOpts.PreDrainHooks {
-			delete(secret.Annotations, hook.Annotation)
+			delete(secret.Annotations, hookAnnotation(hook, preDrainPhase))
 		}
 		for _, hook := range drainOpts.PostDrainHooks {
-			delete(secret.Annotations, hook.Annotation)
+			delete(secret.Annotations, hookAnnotation(hook, postDrainPhase))
 		}
 		_, err = h.secrets.Update(secret)
 		return err
@@ -274,10 +385,10 @@ func secretAnnotationDoesNotHaveValue(annotation, value string) func(*corev1.Sec
 	}
 }
 
-func checkHookAnnotations(drainData string, hooks []rkev1.DrainHook) func(secret *corev1.Secret) bool {
+func checkHookAnnotations(drainData, phase string, hooks []rkev1.DrainHook) func(secret *corev1.Secret) bool {
 	return func(secret *corev1.Secret) bool {
 		for _, hook := range hooks {
-			if hook.Annotation != "" && secret.Annotations[hook.Annotation] != drainData {
+			if annotation := hookAnnotation(hook, phase); annotation != "" && secret.Annotations[annotation] != drainData {
 				return false
 			}
 		}
@@ -0,0 +1,308 @@
+package machinedrain
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/wrangler/v3/pkg/name"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+const (
+	preDrainPhase  = "pre-drain"
+	postDrainPhase = "post-drain"
+
+	defaultWebhookTimeout = 10 * time.Second
+	defaultRetryAfter     = 30 * time.Second
+	maxWebhookResponse    = 1 << 20
+)
+
+type webhookDecision string
+
+const (
+	webhookDecisionApprove webhookDecision = "Approve"
+	webhookDecisionReject  webhookDecision = "Reject"
+	webhookDecisionRetry   webhookDecision = "Retry"
+)
+
+// webhookObjectRef refers to an object in the management cluster.
+type webhookObjectRef struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// webhookRequest is the payload sent to a drain hook webhook.
+type webhookRequest struct {
+	// Phase is either "pre-drain" or "post-drain".
+	Phase   string           `json:"phase"`
+	Cluster webhookObjectRef `json:"cluster"`
+	Machine webhookObjectRef `json:"machine"`
+	// Node is the name of the node in the downstream cluster.
+	Node         string             `json:"node"`
+	DrainOptions rkev1.DrainOptions `json:"drainOptions"`
+}
+
+// webhookResponse is the payload returned by a drain hook webhook.
+type webhookResponse struct {
+	Decision          webhookDecision `json:"decision"`
+	RetryAfterSeconds int             `json:"retryAfterSeconds,omitempty"`
+	Message           string          `json:"message,omitempty"`
+}
+
+// hookAnnotation returns the annotation of the machine-plan secret which records that the hook passed. Webhooks without
+// an annotation use one derived from the phase and the endpoint, so that the approval is not requested again.
+func hookAnnotation(hook rkev1.DrainHook, phase string) string {
+	if hook.Annotation != "" || hook.Webhook == nil {
+		return hook.Annotation
+	}
+	return fmt.Sprintf("rke.cattle.io/%s-hook-%s", phase, name.Hex(hook.Webhook.URL, 10))
+}
+
+// runWebhookHooks calls the webhooks of the hooks which did not pass yet in order, and records the approvals on the
+// machine-plan secret. A webhook which asks for a retry is called again after the requested delay, and the remaining
+// webhooks are only called once it approved.
+func (h *handler) runWebhookHooks(secret *corev1.Secret, machine *capi.Machine, drainData, phase string, drainOpts rkev1.DrainOptions, hooks []rkev1.DrainHook) (*corev1.Secret, error) {
+	for _, hook := range hooks {
+		if hook.Webhook == nil {
+			continue
+		}
+		annotation := hookAnnotation(hook, phase)
+		if secret.Annotations[annotation] == drainData {
+			continue
+		}
+
+		webhook, request := hook.Webhook, webhookRequest{
+			Phase: phase,
+			Cluster: webhookObjectRef{
+				Namespace: machine.Namespace,
+				Name:      machine.Spec.ClusterName,
+			},
+			Machine: webhookObjectRef{
+				Namespace: machine.Namespace,
+				Name:      machine.Name,
+			},
+			Node:         nodeName(machine),
+			DrainOptions: drainOpts,
+		}
+		response, done, err := h.webhookResult(secret, annotation, drainData, func() (*webhookResponse, error) {
+			return h.callWebhook(secret.Namespace, webhook, request)
+		})
+		if !done {
+			return secret, nil
+		}
+		if err != nil {
+			if hook.Webhook.FailurePolicy != rkev1.DrainHookFailurePolicyIgnore {
+				return secret, fmt.Errorf("%s hook %s failed: %w", phase, hook.Webhook.URL, err)
+			}
+			logrus.Warnf("[machinedrain] machine %s/%s: ignoring failed %s hook %s: %v", machine.Namespace, machine.Name, phase, hook.Webhook.URL, err)
+			response = &webhookResponse{Decision: webhookDecisionApprove}
+		}
+
+		switch response.Decision {
+		case webhookDecisionApprove:
+			logrus.Infof("[machinedrain] machine %s/%s: %s hook %s approved", machine.Namespace, machine.Name, phase, hook.Webhook.URL)
+			if secret, err = h.updateSecretAnnotationIfCheckTrue(secret, annotation, drainData, secretAnnotationDoesNotHaveValue(annotation, drainData)); err != nil {
+				return secret, err
+			}
+		case webhookDecisionReject:
+			return secret, fmt.Errorf("%s hook %s rejected the drain: %s", phase, hook.Webhook.URL, response.Message)
+		default:
+			retryAfter := time.Duration(response.RetryAfterSeconds) * time.Second
+			if retryAfter <= 0 {
+				retryAfter = defaultRetryAfter
+			}
+			logrus.Debugf("[machinedrain] machine %s/%s: %s hook %s asked to retry after %s: %s", machine.Namespace, machine.Name, phase, hook.Webhook.URL, retryAfter, response.Message)
+			h.secrets.EnqueueAfter(secret.Namespace, secret.Name, retryAfter)
+			return secret, nil
+		}
+	}
+	return secret, nil
+}
+
+// webhookCall is a call to a drain hook webhook made in the background.
+type webhookCall struct {
+	drainData string
+	done      bool
+	response  *webhookResponse
+	err       error
+}
+
+// webhookResult returns the result of calling the webhook of the hook with the given annotation for the machine-plan
+// secret. The webhook is called in the background and the secret is enqueued once it answered, so that slow endpoints
+// don't block the worker; done is false until then. A result for a previous drain is discarded.
+func (h *handler) webhookResult(secret *corev1.Secret, annotation, drainData string, call func() (*webhookResponse, error)) (response *webhookResponse, done bool, err error) {
+	key := secret.Namespace + "/" + secret.Name + "/" + annotation
+
+	h.webhookCallsLock.Lock()
+	defer h.webhookCallsLock.Unlock()
+
+	if c, ok := h.webhookCalls[key]; ok {
+		if !c.done {
+			return nil, false, nil
+		}
+		delete(h.webhookCalls, key)
+		if c.drainData == drainData {
+			return c.response, true, c.err
+		}
+	}
+
+	if h.webhookCalls == nil {
+		h.webhookCalls = map[string]*webhookCall{}
+	}
+	c := &webhookCall{drainData: drainData}
+	h.webhookCalls[key] = c
+	namespace, name := secret.Namespace, secret.Name
+	go func() {
+		response, err := call()
+		h.webhookCallsLock.Lock()
+		c.done, c.response, c.err = true, response, err
+		h.webhookCallsLock.Unlock()
+		h.secrets.Enqueue(namespace, name)
+	}()
+	return nil, false, nil
+}
+
+// forgetWebhookCalls forgets the webhook calls for the machine-plan secret with the given key which are not for one of
+// the hook annotations of its current drain, such as the calls of hooks which were removed while they were called, and
+// all of its calls once the secret is deleted. Results of calls in progress are discarded once they answer.
+func (h *handler) forgetWebhookCalls(secretKey, drainData string, annotations []string) {
+	h.webhookCallsLock.Lock()
+	defer h.webhookCallsLock.Unlock()
+
+	for key, c := range h.webhookCalls {
+		annotation, ok := strings.CutPrefix(key, secretKey+"/")
+		if ok && (c.drainData != drainData || !slices.Contains(annotations, annotation)) {
+			delete(h.webhookCalls, key)
+		}
+	}
+}
+
+// webhookHookAnnotations returns the annotations of the webhook hooks of both phases of the drain.
+func webhookHookAnnotations(drainData string) []string {
+	var drainOpts rkev1.DrainOptions
+	if drainData == "" || json.Unmarshal([]byte(drainData), &drainOpts) != nil {
+		return nil
+	}
+
+	var annotations []string
+	for phase, hooks := range map[string][]rkev1.DrainHook{preDrainPhase: drainOpts.PreDrainHooks, postDrainPhase: drainOpts.PostDrainHooks} {
+		for _, hook := range hooks {
+			if hook.Webhook != nil {
+				annotations = append(annotations, hookAnnotation(hook, phase))
+			}
+		}
+	}
+	return annotations
+}
+
+// callWebhook sends the request to the webhook and returns its decision.
+func (h *handler) callWebhook(namespace string, webhook *rkev1.DrainHookWebhook, request webhookRequest) (*webhookResponse, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(h.ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	if webhook.AuthSecretName != "" {
+		authSecret, err := h.secretCache.Get(namespace, webhook.AuthSecretName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get auth secret %s/%s: %w", namespace, webhook.AuthSecretName, err)
+		}
+		if authSecret.Type != rkev1.DrainHookAuthSecretType {
+			return nil, fmt.Errorf("auth secret %s/%s is not of type %s", namespace, webhook.AuthSecretName, rkev1.DrainHookAuthSecretType)
+		}
+		if token := authSecret.Data[rkev1.TokenDrainHookAuthSecretKey]; len(token) > 0 {
+			req.Header.Set("Authorization", "Bearer "+string(token))
+		} else if username := authSecret.Data[corev1.BasicAuthUsernameKey]; len(username) > 0 {
+			req.SetBasicAuth(string(username), string(authSecret.Data[corev1.BasicAuthPasswordKey]))
+		} else {
+			return nil, fmt.Errorf("auth secret %s/%s has neither a %s nor a %s", namespace, webhook.AuthSecretName, rkev1.TokenDrainHookAuthSecretKey, corev1.BasicAuthUsernameKey)
+		}
+	}
+
+	client, err := webhookClient(webhook)
+	if err != nil {
+		return nil, err
+	}
+	// Every call uses its own transport, whose connections would otherwise be kept open until they time out.
+	defer client.CloseIdleConnections()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponse))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		// The body isn't included, since the endpoint is user supplied and may be anything reachable from Rancher.
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	response := &webhookResponse{}
+	if err := json.Unmarshal(data, response); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	switch response.Decision {
+	case webhookDecisionApprove, webhookDecisionReject, webhookDecisionRetry:
+		return response, nil
+	default:
+		return nil, fmt.Errorf("invalid decision %q", response.Decision)
+	}
+}
+
+func webhookClient(webhook *rkev1.DrainHookWebhook) (*http.Client, error) {
+	timeout := defaultWebhookTimeout
+	if webhook.TimeoutSeconds > 0 {
+		timeout = time.Duration(webhook.TimeoutSeconds) * time.Second
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if webhook.CABundle != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(webhook.CABundle)) {
+			return nil, errors.New("no certificate found in the CA bundle")
+		}
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs:    pool,
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+		// Redirects aren't followed, so that the request and its credentials are only sent to the configured endpoint.
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}, nil
+}
+
+func nodeName(machine *capi.Machine) string {
+	if machine.Status.NodeRef == nil {
+		return ""
+	}
+	return machine.Status.NodeRef.Name
+}
@@ -0,0 +1,247 @@
+package machinedrain
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	ctrlfake "github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_hookAnnotation(t *testing.T) {
+	webhook := &rkev1.DrainHookWebhook{URL: "https://ceph.example.com/quiesce"}
+
+	assert.Equal(t, "example.com/quiesced", hookAnnotation(rkev1.DrainHook{Annotation: "example.com/quiesced", Webhook: webhook}, preDrainPhase))
+	assert.Equal(t, "", hookAnnotation(rkev1.DrainHook{}, preDrainPhase))
+
+	pre := hookAnnotation(rkev1.DrainHook{Webhook: webhook}, preDrainPhase)
+	post := hookAnnotation(rkev1.DrainHook{Webhook: webhook}, postDrainPhase)
+	assert.Regexp(t, `^rke\.cattle\.io/pre-drain-hook-[0-9a-f]{10}$`, pre)
+	assert.Regexp(t, `^rke\.cattle\.io/post-drain-hook-[0-9a-f]{10}$`, post)
+}
+
+func Test_callWebhook(t *testing.T) {
+	request := webhookRequest{
+		Phase:   preDrainPhase,
+		Cluster: webhookObjectRef{Namespace: "fleet-default", Name: "cluster"},
+		Machine: webhookObjectRef{Namespace: "fleet-default", Name: "machine"},
+		Node:    "node1",
+	}
+
+	tests := []struct {
+		name       string
+		authSecret *corev1.Secret
+		status     int
+		response   string
+		want       *webhookResponse
+		wantErr    string
+	}{
+		{
+			name:     "approve",
+			status:   http.StatusOK,
+			response: `{"decision":"Approve"}`,
+			want:     &webhookResponse{Decision: webhookDecisionApprove},
+		},
+		{
+			name:     "retry",
+			status:   http.StatusOK,
+			response: `{"decision":"Retry","retryAfterSeconds":60,"message":"waiting for osd noout"}`,
+			want:     &webhookResponse{Decision: webhookDecisionRetry, RetryAfterSeconds: 60, Message: "waiting for osd noout"},
+		},
+		{
+			name: "basic auth",
+			authSecret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "auth"},
+				Type:       rkev1.DrainHookAuthSecretType,
+				Data: map[string][]byte{
+					corev1.BasicAuthUsernameKey: []byte("user"),
+					corev1.BasicAuthPasswordKey: []byte("password"),
+				},
+			},
+			status:   http.StatusOK,
+			response: `{"decision":"Reject","message":"cluster degraded"}`,
+			want:     &webhookResponse{Decision: webhookDecisionReject, Message: "cluster degraded"},
+		},
+		{
+			name: "bearer token",
+			authSecret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "auth"},
+				Type:       rkev1.DrainHookAuthSecretType,
+				Data: map[string][]byte{
+					"token": []byte("secret-token"),
+				},
+			},
+			status:   http.StatusOK,
+			response: `{"decision":"Approve"}`,
+			want:     &webhookResponse{Decision: webhookDecisionApprove},
+		},
+		{
+			name: "secret of another type",
+			authSecret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "auth"},
+				Type:       corev1.SecretTypeOpaque,
+				Data: map[string][]byte{
+					"token": []byte("secret-token"),
+				},
+			},
+			wantErr: "auth secret fleet-default/auth is not of type rke.cattle.io/drain-hook-auth",
+		},
+		{
+			name:     "error status",
+			status:   http.StatusInternalServerError,
+			response: `internal error`,
+			wantErr:  "unexpected status code 500",
+		},
+		{
+			name:     "redirect",
+			status:   http.StatusFound,
+			response: `redirected`,
+			wantErr:  "unexpected status code 302",
+		},
+		{
+			name:     "invalid decision",
+			status:   http.StatusOK,
+			response: `{"decision":"Maybe"}`,
+			wantErr:  `invalid decision "Maybe"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+				if tt.status == http.StatusFound {
+					w.Header().Set("Location", "/elsewhere")
+				}
+				if tt.authSecret != nil && tt.authSecret.Data["token"] == nil {
+					username, password, ok := r.BasicAuth()
+					assert.True(t, ok)
+					assert.Equal(t, "user", username)
+					assert.Equal(t, "password", password)
+				} else if tt.authSecret != nil {
+					assert.Equal(t, "Bearer secret-token", r.Header.Get("Authorization"))
+				}
+
+				var got webhookRequest
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+				assert.Equal(t, request, got)
+
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			ctrl := gomock.NewController(t)
+			secretCache := ctrlfake.NewMockCacheInterface[*corev1.Secret](ctrl)
+			webhook := &rkev1.DrainHookWebhook{
+				URL:      server.URL,
+				CABundle: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})),
+			}
+			if tt.authSecret != nil {
+				webhook.AuthSecretName = tt.authSecret.Name
+				secretCache.EXPECT().Get(tt.authSecret.Namespace, tt.authSecret.Name).Return(tt.authSecret, nil)
+			}
+
+			h := &handler{
+				ctx:         context.Background(),
+				secretCache: secretCache,
+			}
+			got, err := h.callWebhook("fleet-default", webhook, request)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_callWebhookUntrustedCertificate(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"decision":"Approve"}`))
+	}))
+	defer server.Close()
+
+	h := &handler{ctx: context.Background()}
+	_, err := h.callWebhook("fleet-default", &rkev1.DrainHookWebhook{URL: server.URL}, webhookRequest{})
+	assert.Error(t, err)
+
+	_, err = h.callWebhook("fleet-default", &rkev1.DrainHookWebhook{URL: server.URL, CABundle: "not a certificate"}, webhookRequest{})
+	assert.EqualError(t, err, "no certificate found in the CA bundle")
+}
+
+func Test_webhookResult(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	secrets := ctrlfake.NewMockControllerInterface[*corev1.Secret, *corev1.SecretList](ctrl)
+	enqueued := make(chan struct{})
+	secrets.EXPECT().Enqueue("fleet-default", "plan").Do(func(string, string) { close(enqueued) })
+
+	h := &handler{secrets: secrets}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "plan"}}
+	release := make(chan struct{})
+	calls := 0
+	call := func() (*webhookResponse, error) {
+		calls++
+		<-release
+		return &webhookResponse{Decision: webhookDecisionApprove}, nil
+	}
+
+	// The webhook is called in the background, the worker doesn't wait for it.
+	_, done, err := h.webhookResult(secret, "hook", "drain-1", call)
+	assert.NoError(t, err)
+	assert.False(t, done)
+	_, done, _ = h.webhookResult(secret, "hook", "drain-1", call)
+	assert.False(t, done)
+
+	close(release)
+	<-enqueued
+	response, done, err := h.webhookResult(secret, "hook", "drain-1", call)
+	assert.NoError(t, err)
+	assert.True(t, done)
+	assert.Equal(t, webhookDecisionApprove, response.Decision)
+	assert.Equal(t, 1, calls)
+	assert.Empty(t, h.webhookCalls)
+}
+
+func Test_forgetWebhookCalls(t *testing.T) {
+	webhook := &rkev1.DrainHookWebhook{URL: "https://ceph.example.com/quiesce"}
+	drainData, err := json.Marshal(rkev1.DrainOptions{
+		PreDrainHooks:  []rkev1.DrainHook{{Annotation: "example.com/quiesced", Webhook: webhook}},
+		PostDrainHooks: []rkev1.DrainHook{{Webhook: webhook}, {Annotation: "example.com/manual"}},
+	})
+	assert.NoError(t, err)
+	postAnnotation := hookAnnotation(rkev1.DrainHook{Webhook: webhook}, postDrainPhase)
+	assert.ElementsMatch(t, []string{"example.com/quiesced", postAnnotation}, webhookHookAnnotations(string(drainData)))
+
+	h := &handler{webhookCalls: map[string]*webhookCall{
+		"fleet-default/plan/example.com/quiesced":   {drainData: string(drainData), done: true},
+		"fleet-default/plan/" + postAnnotation:      {drainData: string(drainData)},
+		"fleet-default/plan/example.com/removed":    {drainData: string(drainData)},
+		"fleet-default/plan/example.com/old":        {drainData: "old", done: true},
+		"fleet-default/plan-2/example.com/quiesced": {drainData: string(drainData)},
+	}}
+
+	// Calls of hooks which were removed or of a previous drain are forgotten.
+	h.forgetWebhookCalls("fleet-default/plan", string(drainData), webhookHookAnnotations(string(drainData)))
+	assert.Len(t, h.webhookCalls, 3)
+	assert.Contains(t, h.webhookCalls, "fleet-default/plan/example.com/quiesced")
+	assert.Contains(t, h.webhookCalls, "fleet-default/plan/"+postAnnotation)
+	assert.Contains(t, h.webhookCalls, "fleet-default/plan-2/example.com/quiesced")
+
+	// All calls are forgotten once the secret is deleted.
+	_, err = h.OnChange("fleet-default/plan", nil)
+	assert.NoError(t, err)
+	assert.Len(t, h.webhookCalls, 1)
+	assert.Contains(t, h.webhookCalls, "fleet-default/plan-2/example.com/quiesced")
+}
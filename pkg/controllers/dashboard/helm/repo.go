@@ -311,6 +311,10 @@ func (r *repoHandler) download(repository *catalog.ClusterRepo, newStatus *catal
 		return setErrorCondition(repository, err, newStatus, interval, repoCondition, r.clusterRepos)
 	}
 
+	if err := r.verifyCharts(repository, index, secret, owner); err != nil {
+		return setErrorCondition(repository, err, newStatus, interval, repoCondition, r.clusterRepos)
+	}
+
 	index.SortEntries()
 	cm, err := createOrUpdateMap(metadata.Namespace, index, owner, r.apply)
 	if err != nil {
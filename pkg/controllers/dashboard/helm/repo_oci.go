@@ -152,6 +152,13 @@ func (o *OCIRepohandler) onClusterRepoChange(key string, clusterRepo *catalog.Cl
 		logrus.Errorf("Error while marshalling indexfile for cluster repo %s: %v", clusterRepo.Name, err)
 		return setErrorCondition(clusterRepo, fmt.Errorf("error while reading indexfile"), newStatus, ociInterval, ociCondition, o.clusterRepoController)
 	}
+	keys, err := trustedKeys(o.secretCacheController, clusterRepo)
+	if err != nil {
+		return setErrorCondition(clusterRepo, err, newStatus, ociInterval, ociCondition, o.clusterRepoController)
+	}
+	// The results of the verifications of the previous index, before generating the index replaces its entries.
+	verified := verifiedCharts(index)
+
 	// Create a new oci client
 	ociClient, err := oci.NewClient(clusterRepo.Spec.URL, clusterRepo.Spec, secret)
 	if err != nil {
@@ -176,6 +183,8 @@ func (o *OCIRepohandler) onClusterRepoChange(key string, clusterRepo *catalog.Cl
 		if index != nil && len(index.Entries) > 0 {
 			newStatus.URL = clusterRepo.Spec.URL
 
+			// Don't verify the signatures of the charts while the registry is rate limiting the requests.
+			verifyIndex(index, verified, keys, nil)
 			index.SortEntries()
 			_, err := createOrUpdateMap(clusterRepo.Namespace, index, owner, o.apply)
 			if err != nil {
@@ -215,6 +224,8 @@ func (o *OCIRepohandler) onClusterRepoChange(key string, clusterRepo *catalog.Cl
 		return setErrorCondition(clusterRepo, err, newStatus, ociInterval, ociCondition, o.clusterRepoController)
 	}
 
+	verifyIndex(index, verified, keys, verifyOCIChart(clusterRepo, secret, keys))
+
 	newIndexBytes, err := json.Marshal(index)
 	if err != nil {
 		logrus.Errorf("Error while marshalling indexfile for cluster repo %s: %v", clusterRepo.Name, err)
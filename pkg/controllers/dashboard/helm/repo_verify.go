@@ -0,0 +1,176 @@
+package helm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	catalog "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/catalogv2"
+	helmhttp "github.com/rancher/rancher/pkg/catalogv2/http"
+	"github.com/rancher/rancher/pkg/catalogv2/oci"
+	"github.com/rancher/rancher/pkg/catalogv2/verify"
+	corev1controllers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/repo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// verificationWorkers is the number of chart versions whose signatures are verified concurrently.
+const verificationWorkers = 4
+
+// verifiedChart is the result of the verification of a chart version of a previous index.
+type verifiedChart struct {
+	url    string
+	digest string
+	result verify.Result
+}
+
+// trustedKeys loads the keys trusted to sign the charts of the ClusterRepo, or returns nil if the ClusterRepo doesn't
+// verify the signatures of its charts.
+func trustedKeys(secrets corev1controllers.SecretCache, clusterRepo *catalog.ClusterRepo) (*verify.TrustedKeys, error) {
+	secret, err := catalogv2.GetTrustedKeysSecret(secrets, &clusterRepo.Spec, clusterRepo.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the trusted keys secret: %w", err)
+	}
+	if secret == nil {
+		return nil, nil
+	}
+	return verify.LoadTrustedKeys(secret)
+}
+
+// verifiedCharts returns the results of the verifications recorded in an index written by the controllers, by chart
+// name and version.
+func verifiedCharts(index *repo.IndexFile) map[string]verifiedChart {
+	results := map[string]verifiedChart{}
+	if index == nil {
+		return results
+	}
+	for _, versions := range index.Entries {
+		for _, version := range versions {
+			result, ok := verify.FromChart(version)
+			if !ok || len(version.URLs) == 0 {
+				continue
+			}
+			results[chartKey(version)] = verifiedChart{
+				url:    version.URLs[0],
+				digest: version.Digest,
+				result: result,
+			}
+		}
+	}
+	return results
+}
+
+func chartKey(version *repo.ChartVersion) string {
+	return version.Name + "/" + version.Version
+}
+
+// verifyIndex records the results of the verification of the signatures of the chart versions of the index in their
+// annotations, replacing any result claimed by the repository. The results of previous verifications against the same
+// keys are reused for the chart versions which didn't change, except for unsigned charts of OCI repositories whose
+// signatures can be pushed to the registry after the chart without changing it. Chart versions which need to be verified are left
+// without a result if verifyChart is nil, they are verified on the next refresh of the repository.
+func verifyIndex(index *repo.IndexFile, previous map[string]verifiedChart, keys *verify.TrustedKeys, verifyChart func(*repo.ChartVersion) verify.Result) {
+	var pending []*repo.ChartVersion
+	for _, versions := range index.Entries {
+		for _, version := range versions {
+			if version.Metadata == nil {
+				continue
+			}
+			verify.Clear(version)
+			if keys == nil || len(version.URLs) == 0 {
+				continue
+			}
+			if prev, ok := previous[chartKey(version)]; ok &&
+				prev.url == version.URLs[0] &&
+				prev.digest == version.Digest &&
+				prev.result.KeysID == keys.ID &&
+				prev.result.Status != verify.StatusFailed &&
+				!(registry.IsOCI(version.URLs[0]) && prev.result.Status == verify.StatusUnsigned) {
+				prev.result.Annotate(version)
+				continue
+			}
+			pending = append(pending, version)
+		}
+	}
+	if verifyChart == nil {
+		return
+	}
+
+	work := make(chan *repo.ChartVersion)
+	var wg sync.WaitGroup
+	for i := 0; i < verificationWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for version := range work {
+				result := verifyChart(version)
+				result.KeysID = keys.ID
+				result.Annotate(version)
+			}
+		}()
+	}
+	for _, version := range pending {
+		work <- version
+	}
+	close(work)
+	wg.Wait()
+}
+
+// verifyCharts verifies the provenance files of the charts of the index of an HTTP repository. Charts of git
+// repositories can't be signed.
+func (r *repoHandler) verifyCharts(repository *catalog.ClusterRepo, index *repo.IndexFile, secret *corev1.Secret, owner metav1.OwnerReference) error {
+	keys, err := trustedKeys(r.secrets, repository)
+	if err != nil {
+		return err
+	}
+	if keys == nil {
+		verifyIndex(index, nil, nil, nil)
+		return nil
+	}
+
+	repoSpec := repository.Spec
+	if repoSpec.GitRepo != "" {
+		verifyIndex(index, nil, keys, func(*repo.ChartVersion) verify.Result {
+			return verify.Unsigned("charts of git repositories can't be signed")
+		})
+		return nil
+	}
+
+	previous, err := getIndexfile(repository.Status, repoSpec, r.configMaps, owner, repository.Namespace)
+	if err != nil {
+		logrus.Warnf("Failed to read the previous index of cluster repo %s, verifying all its charts: %v", repository.Name, err)
+	}
+
+	client, err := helmhttp.HelmClient(secret, repoSpec.CABundle, repoSpec.InsecureSkipTLSverify, repoSpec.DisableSameOriginCheck, repoSpec.URL)
+	if err != nil {
+		return err
+	}
+	defer client.CloseIdleConnections()
+
+	verifyIndex(index, verifiedCharts(previous), keys, func(chart *repo.ChartVersion) verify.Result {
+		prov, err := helmhttp.Provenance(client, repoSpec.URL, chart)
+		if err != nil {
+			return verify.Failed("failed to download the provenance file: %v", err)
+		}
+		if prov == nil {
+			return verify.Unsigned("the chart has no provenance file")
+		}
+		return keys.Provenance(chart, prov)
+	})
+	return nil
+}
+
+// verifyOCIChart verifies the cosign and notation signatures of a chart of an OCI repository.
+func verifyOCIChart(clusterRepo *catalog.ClusterRepo, secret *corev1.Secret, keys *verify.TrustedKeys) func(*repo.ChartVersion) verify.Result {
+	return func(chart *repo.ChartVersion) verify.Result {
+		ociClient, err := oci.NewClient(chart.URLs[0], clusterRepo.Spec, secret)
+		if err != nil {
+			return verify.Failed("failed to create an OCI client for url %s: %v", chart.URLs[0], err)
+		}
+		return ociClient.VerifySignature(context.Background(), keys)
+	}
+}
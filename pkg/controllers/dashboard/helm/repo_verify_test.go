@@ -0,0 +1,159 @@
+package helm
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/rancher/rancher/pkg/catalogv2/verify"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+func TestVerifyIndex(t *testing.T) {
+	newIndex := func(annotations map[string]string) *repo.IndexFile {
+		index := repo.NewIndexFile()
+		for _, version := range []string{"1.0.0", "1.1.0", "1.2.0"} {
+			chartAnnotations := map[string]string{"catalog.cattle.io/display-name": "Test"}
+			for k, v := range annotations {
+				chartAnnotations[k] = v
+			}
+			index.Entries["test"] = append(index.Entries["test"], &repo.ChartVersion{
+				Metadata: &chart.Metadata{Name: "test", Version: version, Annotations: chartAnnotations},
+				URLs:     []string{"test-" + version + ".tgz"},
+				Digest:   "digest-" + version,
+			})
+		}
+		return index
+	}
+	results := func(index *repo.IndexFile) map[string]verify.Status {
+		statuses := map[string]verify.Status{}
+		for _, version := range index.Entries["test"] {
+			if result, ok := verify.FromChart(version); ok {
+				statuses[version.Version] = result.Status
+			}
+		}
+		return statuses
+	}
+	keys := &verify.TrustedKeys{ID: "keys"}
+
+	previous := newIndex(nil)
+	verify.Verified("signer").Annotate(previous.Entries["test"][0])
+	previous.Entries["test"][0].Annotations[verify.KeysAnnotation] = "keys"
+	verify.Failed("timeout").Annotate(previous.Entries["test"][1])
+	previous.Entries["test"][1].Annotations[verify.KeysAnnotation] = "keys"
+	verify.Invalid("bad signature").Annotate(previous.Entries["test"][2])
+	previous.Entries["test"][2].Annotations[verify.KeysAnnotation] = "keys"
+
+	// the first two charts are pulled from an OCI registry, the last one from an HTTP repository
+	toOCI := func(index *repo.IndexFile) {
+		for _, version := range index.Entries["test"][:2] {
+			version.URLs = []string{"oci://registry.example.com/charts/test:" + version.Version}
+		}
+	}
+	previousOCI := newIndex(nil)
+	toOCI(previousOCI)
+	verify.Unsigned("the chart has no signature").Annotate(previousOCI.Entries["test"][0])
+	previousOCI.Entries["test"][0].Annotations[verify.KeysAnnotation] = "keys"
+	verify.Verified("signer").Annotate(previousOCI.Entries["test"][1])
+	previousOCI.Entries["test"][1].Annotations[verify.KeysAnnotation] = "keys"
+	verify.Unsigned("the chart has no provenance file").Annotate(previousOCI.Entries["test"][2])
+	previousOCI.Entries["test"][2].Annotations[verify.KeysAnnotation] = "keys"
+
+	tests := []struct {
+		name     string
+		keys     *verify.TrustedKeys
+		previous *repo.IndexFile
+		change   func(index *repo.IndexFile)
+		verify   bool
+		want     map[string]verify.Status
+		verified []string
+	}{
+		{
+			name: "no verification clears the results claimed by the repository",
+			want: map[string]verify.Status{},
+		},
+		{
+			name:     "all charts are verified without a previous index",
+			keys:     keys,
+			verify:   true,
+			want:     map[string]verify.Status{"1.0.0": verify.StatusUnsigned, "1.1.0": verify.StatusUnsigned, "1.2.0": verify.StatusUnsigned},
+			verified: []string{"1.0.0", "1.1.0", "1.2.0"},
+		},
+		{
+			name:     "previous results are reused, failed verifications are retried",
+			keys:     keys,
+			previous: previous,
+			verify:   true,
+			want:     map[string]verify.Status{"1.0.0": verify.StatusVerified, "1.1.0": verify.StatusUnsigned, "1.2.0": verify.StatusInvalid},
+			verified: []string{"1.1.0"},
+		},
+		{
+			name:     "charts whose digest changed are verified again",
+			keys:     keys,
+			previous: previous,
+			change: func(index *repo.IndexFile) {
+				index.Entries["test"][0].Digest = "changed"
+			},
+			verify:   true,
+			want:     map[string]verify.Status{"1.0.0": verify.StatusUnsigned, "1.1.0": verify.StatusUnsigned, "1.2.0": verify.StatusInvalid},
+			verified: []string{"1.0.0", "1.1.0"},
+		},
+		{
+			name:     "all charts are verified again when the keys change",
+			keys:     &verify.TrustedKeys{ID: "other"},
+			previous: previous,
+			verify:   true,
+			want:     map[string]verify.Status{"1.0.0": verify.StatusUnsigned, "1.1.0": verify.StatusUnsigned, "1.2.0": verify.StatusUnsigned},
+			verified: []string{"1.0.0", "1.1.0", "1.2.0"},
+		},
+		{
+			name:     "unsigned charts of OCI repositories are verified again",
+			keys:     keys,
+			previous: previousOCI,
+			change:   toOCI,
+			verify:   true,
+			want:     map[string]verify.Status{"1.0.0": verify.StatusUnsigned, "1.1.0": verify.StatusVerified, "1.2.0": verify.StatusUnsigned},
+			verified: []string{"1.0.0"},
+		},
+		{
+			name:     "charts are left unverified without a verification",
+			keys:     keys,
+			previous: previous,
+			want:     map[string]verify.Status{"1.0.0": verify.StatusVerified, "1.2.0": verify.StatusInvalid},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the repository claims its charts are verified
+			index := newIndex(map[string]string{verify.StatusAnnotation: string(verify.StatusVerified), verify.KeysAnnotation: "keys"})
+			if tt.change != nil {
+				tt.change(index)
+			}
+
+			var (
+				lock     sync.Mutex
+				verified []string
+			)
+			var verifyChart func(*repo.ChartVersion) verify.Result
+			if tt.verify {
+				verifyChart = func(version *repo.ChartVersion) verify.Result {
+					lock.Lock()
+					defer lock.Unlock()
+					verified = append(verified, version.Version)
+					return verify.Unsigned("the chart has no provenance file")
+				}
+			}
+
+			verifyIndex(index, verifiedCharts(tt.previous), tt.keys, verifyChart)
+			assert.Equal(t, tt.want, results(index))
+			assert.ElementsMatch(t, tt.verified, verified)
+			for _, version := range index.Entries["test"] {
+				assert.Equal(t, "Test", version.Annotations["catalog.cattle.io/display-name"])
+				if result, ok := verify.FromChart(version); ok {
+					assert.Equal(t, tt.keys.ID, result.KeysID)
+				}
+			}
+		})
+	}
+}
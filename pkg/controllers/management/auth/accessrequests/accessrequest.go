@@ -0,0 +1,237 @@
+package accessrequests
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/audit/event"
+	mgmtv3 "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const bindingNamePrefix = "accessrequest-"
+
+// accessRequestHandler grants the access of approved AccessRequests by creating a ClusterRoleTemplateBinding or
+// ProjectRoleTemplateBinding that expires after the requested duration, and tracks the binding until it expires.
+type accessRequestHandler struct {
+	accessRequests mgmtv3.AccessRequestController
+	crtbs          mgmtv3.ClusterRoleTemplateBindingController
+	prtbs          mgmtv3.ProjectRoleTemplateBindingController
+	projectCache   mgmtv3.ProjectCache
+	now            func() time.Time
+}
+
+func (a *accessRequestHandler) onChange(_ string, ar *v3.AccessRequest) (*v3.AccessRequest, error) {
+	if ar == nil || ar.DeletionTimestamp != nil {
+		return ar, nil
+	}
+
+	switch ar.Status.State {
+	case v3.AccessRequestStateDenied, v3.AccessRequestStateExpired, v3.AccessRequestStateFailed:
+		return ar, nil
+	case v3.AccessRequestStateActive:
+		return a.checkExpiry(ar)
+	}
+
+	// The admission policy only allows users to request access for themselves, so the user access is requested for
+	// is the user who created the request. It is persisted by the next status update.
+	recordRequester := ar.Status.RequesterName == ""
+	if recordRequester {
+		ar = ar.DeepCopy()
+		ar.Status.RequesterName = ar.Spec.UserName
+	}
+
+	switch ar.Status.Decision {
+	case "":
+		if ar.Status.State == v3.AccessRequestStatePending && !recordRequester {
+			return ar, nil
+		}
+		ar = ar.DeepCopy()
+		ar.Status.State = v3.AccessRequestStatePending
+		ar.Status.Message = "waiting for a decision"
+		return a.accessRequests.UpdateStatus(ar)
+	case v3.AccessRequestApproved, v3.AccessRequestDenied:
+	default:
+		return a.setState(ar, v3.AccessRequestStateFailed, fmt.Sprintf("unknown decision %q", ar.Status.Decision))
+	}
+
+	if err := a.validateApprover(ar); err != nil {
+		return a.setState(ar, v3.AccessRequestStateFailed, err.Error())
+	}
+
+	if ar.Status.Decision == v3.AccessRequestDenied {
+		var err error
+		if ar, err = a.setState(ar, v3.AccessRequestStateDenied, "denied by "+ar.Status.ApproverName); err != nil {
+			return ar, err
+		}
+		recordDecision(ar, http.MethodPut, accessRequestURI(ar.Name), ar)
+		return ar, nil
+	}
+
+	return a.grant(ar)
+}
+
+// validateApprover checks that the request was decided by a user other than the requester and the user access was
+// requested for. Decisions are only
+// recorded by the accessrequestdecisions ext API, which authorizes the approver and takes their name from the
+// authenticated request, since the status of the request must not be writable by anyone else.
+func (a *accessRequestHandler) validateApprover(ar *v3.AccessRequest) error {
+	approver := ar.Status.ApproverName
+	if approver == "" {
+		return fmt.Errorf("decision has no approver")
+	}
+	if approver == ar.Spec.UserName || approver == ar.Status.RequesterName {
+		return fmt.Errorf("user %s cannot decide their own access request", approver)
+	}
+	return nil
+}
+
+// grant creates the role template binding granting the requested access.
+func (a *accessRequestHandler) grant(ar *v3.AccessRequest) (*v3.AccessRequest, error) {
+	if ar.Spec.Duration.Duration <= 0 {
+		return a.setState(ar, v3.AccessRequestStateFailed, "duration must be positive")
+	}
+
+	now := a.now()
+	grantedAt := metav1.NewTime(now)
+	expiresAt := &metav1.Time{Time: now.Add(ar.Spec.Duration.Duration)}
+	meta := metav1.ObjectMeta{
+		Name: bindingNamePrefix + ar.Name,
+		OwnerReferences: []metav1.OwnerReference{{
+			APIVersion: v3.SchemeGroupVersion.String(),
+			Kind:       "AccessRequest",
+			Name:       ar.Name,
+			UID:        ar.UID,
+		}},
+	}
+
+	var (
+		binding  any
+		resource string
+	)
+	if ar.Spec.ProjectName == "" {
+		meta.Namespace = ar.Spec.ClusterName
+		crtb := &v3.ClusterRoleTemplateBinding{
+			ObjectMeta:       meta,
+			ClusterName:      ar.Spec.ClusterName,
+			UserName:         ar.Spec.UserName,
+			RoleTemplateName: ar.Spec.RoleTemplateName,
+			ExpiresAt:        expiresAt,
+		}
+		if _, err := a.crtbs.Create(crtb); apierrors.IsAlreadyExists(err) {
+			// The status of the request failed to update after the binding was created.
+			if crtb, err = a.crtbs.Get(crtb.Namespace, crtb.Name, metav1.GetOptions{}); err != nil {
+				return ar, fmt.Errorf("failed to get clusterroletemplatebinding for access request %s: %w", ar.Name, err)
+			}
+			if crtb.ExpiresAt != nil {
+				expiresAt = crtb.ExpiresAt
+			}
+		} else if err != nil {
+			return ar, fmt.Errorf("failed to create clusterroletemplatebinding for access request %s: %w", ar.Name, err)
+		}
+		binding, resource = crtb, "clusterroletemplatebindings"
+	} else {
+		clusterName, projectName, _ := strings.Cut(ar.Spec.ProjectName, ":")
+		if clusterName != ar.Spec.ClusterName || projectName == "" {
+			return a.setState(ar, v3.AccessRequestStateFailed, fmt.Sprintf("project %s is not in cluster %s", ar.Spec.ProjectName, ar.Spec.ClusterName))
+		}
+		project, err := a.projectCache.Get(clusterName, projectName)
+		if apierrors.IsNotFound(err) {
+			return a.setState(ar, v3.AccessRequestStateFailed, fmt.Sprintf("project %s not found", ar.Spec.ProjectName))
+		} else if err != nil {
+			return ar, fmt.Errorf("failed to get project %s: %w", ar.Spec.ProjectName, err)
+		}
+		meta.Namespace = project.GetProjectBackingNamespace()
+		prtb := &v3.ProjectRoleTemplateBinding{
+			ObjectMeta:       meta,
+			ProjectName:      ar.Spec.ProjectName,
+			UserName:         ar.Spec.UserName,
+			RoleTemplateName: ar.Spec.RoleTemplateName,
+			ExpiresAt:        expiresAt,
+		}
+		if _, err := a.prtbs.Create(prtb); apierrors.IsAlreadyExists(err) {
+			// The status of the request failed to update after the binding was created.
+			if prtb, err = a.prtbs.Get(prtb.Namespace, prtb.Name, metav1.GetOptions{}); err != nil {
+				return ar, fmt.Errorf("failed to get projectroletemplatebinding for access request %s: %w", ar.Name, err)
+			}
+			if prtb.ExpiresAt != nil {
+				expiresAt = prtb.ExpiresAt
+			}
+		} else if err != nil {
+			return ar, fmt.Errorf("failed to create projectroletemplatebinding for access request %s: %w", ar.Name, err)
+		}
+		binding, resource = prtb, "projectroletemplatebindings"
+	}
+
+	ar = ar.DeepCopy()
+	ar.Status.State = v3.AccessRequestStateActive
+	ar.Status.Message = "approved by " + ar.Status.ApproverName
+	ar.Status.BindingNamespace = meta.Namespace
+	ar.Status.BindingName = meta.Name
+	ar.Status.GrantedAt = &grantedAt
+	ar.Status.ExpiresAt = expiresAt
+	ar, err := a.accessRequests.UpdateStatus(ar)
+	if err != nil {
+		return ar, err
+	}
+	logrus.Infof("[%s] Granted access request %s of user %s until %s", accessRequestChangeHandler, ar.Name, ar.Spec.UserName, expiresAt.UTC().Format(time.RFC3339))
+	recordDecision(ar, http.MethodPost, bindingURI(resource, meta.Namespace, meta.Name), binding)
+
+	a.accessRequests.EnqueueAfter(ar.Name, expiresAt.Sub(now))
+	return ar, nil
+}
+
+// checkExpiry marks an active request as expired once its binding has expired or was removed. The binding is read
+// from the API server, since it may not be in the cache yet right after the request was granted.
+func (a *accessRequestHandler) checkExpiry(ar *v3.AccessRequest) (*v3.AccessRequest, error) {
+	if ar.Status.ExpiresAt != nil {
+		remaining := ar.Status.ExpiresAt.Sub(a.now())
+		if remaining <= 0 {
+			return a.setState(ar, v3.AccessRequestStateExpired, "access expired")
+		}
+		a.accessRequests.EnqueueAfter(ar.Name, remaining)
+	}
+
+	var err error
+	if ar.Spec.ProjectName == "" {
+		_, err = a.crtbs.Get(ar.Status.BindingNamespace, ar.Status.BindingName, metav1.GetOptions{})
+	} else {
+		_, err = a.prtbs.Get(ar.Status.BindingNamespace, ar.Status.BindingName, metav1.GetOptions{})
+	}
+	if apierrors.IsNotFound(err) {
+		return a.setState(ar, v3.AccessRequestStateExpired, "access was revoked before it expired")
+	}
+
+	return ar, err
+}
+
+func (a *accessRequestHandler) setState(ar *v3.AccessRequest, state v3.AccessRequestState, message string) (*v3.AccessRequest, error) {
+	if ar.Status.State == state && ar.Status.Message == message {
+		return ar, nil
+	}
+
+	ar = ar.DeepCopy()
+	ar.Status.State = state
+	ar.Status.Message = message
+	return a.accessRequests.UpdateStatus(ar)
+}
+
+func recordDecision(ar *v3.AccessRequest, method, uri string, body any) {
+	if err := event.Record(event.Event{
+		Method:     method,
+		RequestURI: uri,
+		UserName:   ar.Status.ApproverName,
+		Body:       body,
+	}); err != nil {
+		logrus.Errorf("Failed to record decision on access request %s in the audit log: %v", ar.Name, err)
+	}
+}
+
+func accessRequestURI(name string) string {
+	return "/apis/management.cattle.io/v3/accessrequests/" + name
+}
@@ -0,0 +1,283 @@
+package accessrequests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type mocks struct {
+	accessRequests *fake.MockNonNamespacedControllerInterface[*v3.AccessRequest, *v3.AccessRequestList]
+	crtbs          *fake.MockControllerInterface[*v3.ClusterRoleTemplateBinding, *v3.ClusterRoleTemplateBindingList]
+	prtbs          *fake.MockControllerInterface[*v3.ProjectRoleTemplateBinding, *v3.ProjectRoleTemplateBindingList]
+	projectCache   *fake.MockCacheInterface[*v3.Project]
+}
+
+func Test_accessRequestHandler_onChange(t *testing.T) {
+	now := time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+	expiresAt := &metav1.Time{Time: now.Add(time.Hour)}
+
+	request := func(projectName string, status v3.AccessRequestStatus) *v3.AccessRequest {
+		return &v3.AccessRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "ar-1", UID: "uid-1"},
+			Spec: v3.AccessRequestSpec{
+				UserName:         "u-requester",
+				ClusterName:      "c-abc",
+				ProjectName:      projectName,
+				RoleTemplateName: "cluster-owner",
+				Duration:         metav1.Duration{Duration: time.Hour},
+			},
+			Status: status,
+		}
+	}
+	approved := v3.AccessRequestStatus{Decision: v3.AccessRequestApproved, ApproverName: "u-approver"}
+	tests := []struct {
+		name          string
+		request       *v3.AccessRequest
+		setup         func(mocks)
+		wantState     v3.AccessRequestState
+		wantMessage   string
+		wantBinding   string
+		wantRequester string
+	}{
+		{
+			name:          "new request is pending",
+			request:       request("", v3.AccessRequestStatus{}),
+			wantState:     v3.AccessRequestStatePending,
+			wantMessage:   "waiting for a decision",
+			wantRequester: "u-requester",
+		},
+		{
+			name: "pending request without requester",
+			request: request("", v3.AccessRequestStatus{
+				State:   v3.AccessRequestStatePending,
+				Message: "waiting for a decision",
+			}),
+			setup: func(m mocks) {
+				m.accessRequests.EXPECT().UpdateStatus(gomock.Any()).DoAndReturn(func(ar *v3.AccessRequest) (*v3.AccessRequest, error) {
+					assert.Equal(t, "u-requester", ar.Status.RequesterName)
+					return ar, nil
+				})
+			},
+			wantState:     v3.AccessRequestStatePending,
+			wantMessage:   "waiting for a decision",
+			wantRequester: "u-requester",
+		},
+		{
+			name: "pending request with requester",
+			request: request("", v3.AccessRequestStatus{
+				RequesterName: "u-requester",
+				State:         v3.AccessRequestStatePending,
+				Message:       "waiting for a decision",
+			}),
+			wantState:     v3.AccessRequestStatePending,
+			wantMessage:   "waiting for a decision",
+			wantRequester: "u-requester",
+		},
+		{
+			name:        "self approval",
+			request:     request("", v3.AccessRequestStatus{Decision: v3.AccessRequestApproved, ApproverName: "u-requester"}),
+			wantState:   v3.AccessRequestStateFailed,
+			wantMessage: "user u-requester cannot decide their own access request",
+		},
+		{
+			name: "approved by the recorded requester",
+			request: request("", v3.AccessRequestStatus{
+				RequesterName: "u-filer",
+				Decision:      v3.AccessRequestApproved,
+				ApproverName:  "u-filer",
+			}),
+			wantState:   v3.AccessRequestStateFailed,
+			wantMessage: "user u-filer cannot decide their own access request",
+		},
+		{
+			name:        "decision without approver",
+			request:     request("", v3.AccessRequestStatus{Decision: v3.AccessRequestApproved}),
+			wantState:   v3.AccessRequestStateFailed,
+			wantMessage: "decision has no approver",
+		},
+		{
+			name:        "denied",
+			request:     request("", v3.AccessRequestStatus{Decision: v3.AccessRequestDenied, ApproverName: "u-approver"}),
+			wantState:   v3.AccessRequestStateDenied,
+			wantMessage: "denied by u-approver",
+		},
+		{
+			name:    "approved for cluster",
+			request: request("", approved),
+			setup: func(m mocks) {
+				m.crtbs.EXPECT().Create(gomock.Any()).DoAndReturn(func(crtb *v3.ClusterRoleTemplateBinding) (*v3.ClusterRoleTemplateBinding, error) {
+					assert.Equal(t, "c-abc", crtb.Namespace)
+					assert.Equal(t, "c-abc", crtb.ClusterName)
+					assert.Equal(t, "u-requester", crtb.UserName)
+					assert.Equal(t, "cluster-owner", crtb.RoleTemplateName)
+					assert.Equal(t, expiresAt, crtb.ExpiresAt)
+					assert.Equal(t, "ar-1", crtb.OwnerReferences[0].Name)
+					return crtb, nil
+				})
+				m.accessRequests.EXPECT().EnqueueAfter("ar-1", time.Hour)
+			},
+			wantState:   v3.AccessRequestStateActive,
+			wantMessage: "approved by u-approver",
+			wantBinding: "c-abc/accessrequest-ar-1",
+		},
+		{
+			name:    "approved for project",
+			request: request("c-abc:p-xyz", approved),
+			setup: func(m mocks) {
+				m.projectCache.EXPECT().Get("c-abc", "p-xyz").Return(&v3.Project{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "c-abc", Name: "p-xyz"},
+					Status:     v3.ProjectStatus{BackingNamespace: "c-abc-p-xyz"},
+				}, nil)
+				m.prtbs.EXPECT().Create(gomock.Any()).DoAndReturn(func(prtb *v3.ProjectRoleTemplateBinding) (*v3.ProjectRoleTemplateBinding, error) {
+					assert.Equal(t, "c-abc:p-xyz", prtb.ProjectName)
+					assert.Equal(t, expiresAt, prtb.ExpiresAt)
+					return prtb, nil
+				})
+				m.accessRequests.EXPECT().EnqueueAfter("ar-1", time.Hour)
+			},
+			wantState:   v3.AccessRequestStateActive,
+			wantMessage: "approved by u-approver",
+			wantBinding: "c-abc-p-xyz/accessrequest-ar-1",
+		},
+		{
+			name:        "approved for project in another cluster",
+			request:     request("c-def:p-xyz", approved),
+			wantState:   v3.AccessRequestStateFailed,
+			wantMessage: "project c-def:p-xyz is not in cluster c-abc",
+		},
+		{
+			name: "active",
+			request: request("", v3.AccessRequestStatus{
+				State:            v3.AccessRequestStateActive,
+				Message:          "approved by u-approver",
+				BindingNamespace: "c-abc",
+				BindingName:      "accessrequest-ar-1",
+				ExpiresAt:        expiresAt,
+			}),
+			setup: func(m mocks) {
+				m.accessRequests.EXPECT().EnqueueAfter("ar-1", time.Hour)
+				m.crtbs.EXPECT().Get("c-abc", "accessrequest-ar-1", metav1.GetOptions{}).Return(&v3.ClusterRoleTemplateBinding{}, nil)
+			},
+			wantState:   v3.AccessRequestStateActive,
+			wantMessage: "approved by u-approver",
+			wantBinding: "c-abc/accessrequest-ar-1",
+		},
+		{
+			name: "active with deleted binding",
+			request: request("", v3.AccessRequestStatus{
+				State:            v3.AccessRequestStateActive,
+				BindingNamespace: "c-abc",
+				BindingName:      "accessrequest-ar-1",
+				ExpiresAt:        expiresAt,
+			}),
+			setup: func(m mocks) {
+				m.accessRequests.EXPECT().EnqueueAfter("ar-1", time.Hour)
+				m.crtbs.EXPECT().Get("c-abc", "accessrequest-ar-1", metav1.GetOptions{}).
+					Return(nil, apierrors.NewNotFound(schema.GroupResource{}, "accessrequest-ar-1"))
+			},
+			wantState:   v3.AccessRequestStateExpired,
+			wantMessage: "access was revoked before it expired",
+			wantBinding: "c-abc/accessrequest-ar-1",
+		},
+		{
+			name: "active and expired",
+			request: request("", v3.AccessRequestStatus{
+				State:            v3.AccessRequestStateActive,
+				BindingNamespace: "c-abc",
+				BindingName:      "accessrequest-ar-1",
+				ExpiresAt:        &metav1.Time{Time: now},
+			}),
+			wantState:   v3.AccessRequestStateExpired,
+			wantMessage: "access expired",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			m := mocks{
+				accessRequests: fake.NewMockNonNamespacedControllerInterface[*v3.AccessRequest, *v3.AccessRequestList](ctrl),
+				crtbs:          fake.NewMockControllerInterface[*v3.ClusterRoleTemplateBinding, *v3.ClusterRoleTemplateBindingList](ctrl),
+				prtbs:          fake.NewMockControllerInterface[*v3.ProjectRoleTemplateBinding, *v3.ProjectRoleTemplateBindingList](ctrl),
+				projectCache:   fake.NewMockCacheInterface[*v3.Project](ctrl),
+			}
+			if tt.setup != nil {
+				tt.setup(m)
+			}
+			m.accessRequests.EXPECT().UpdateStatus(gomock.Any()).DoAndReturn(func(ar *v3.AccessRequest) (*v3.AccessRequest, error) {
+				return ar, nil
+			}).AnyTimes()
+			a := &accessRequestHandler{
+				accessRequests: m.accessRequests,
+				crtbs:          m.crtbs,
+				prtbs:          m.prtbs,
+				projectCache:   m.projectCache,
+				now:            func() time.Time { return now },
+			}
+
+			got, err := a.onChange("", tt.request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantState, got.Status.State)
+			assert.Equal(t, tt.wantMessage, got.Status.Message)
+			if tt.wantRequester != "" {
+				assert.Equal(t, tt.wantRequester, got.Status.RequesterName)
+			}
+			if tt.wantBinding != "" {
+				assert.Equal(t, tt.wantBinding, got.Status.BindingNamespace+"/"+got.Status.BindingName)
+				assert.Equal(t, expiresAt.Time, got.Status.ExpiresAt.Time)
+			}
+		})
+	}
+}
+
+func Test_accessRequestHandler_grantRetry(t *testing.T) {
+	now := time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+	existingExpiry := &metav1.Time{Time: now.Add(30 * time.Minute)}
+
+	ctrl := gomock.NewController(t)
+	accessRequests := fake.NewMockNonNamespacedControllerInterface[*v3.AccessRequest, *v3.AccessRequestList](ctrl)
+	crtbs := fake.NewMockControllerInterface[*v3.ClusterRoleTemplateBinding, *v3.ClusterRoleTemplateBindingList](ctrl)
+
+	crtbs.EXPECT().Create(gomock.Any()).Return(nil, apierrors.NewAlreadyExists(schema.GroupResource{}, "accessrequest-ar-1"))
+	crtbs.EXPECT().Get("c-abc", "accessrequest-ar-1", metav1.GetOptions{}).Return(&v3.ClusterRoleTemplateBinding{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "c-abc", Name: "accessrequest-ar-1"},
+		ExpiresAt:  existingExpiry,
+	}, nil)
+	accessRequests.EXPECT().UpdateStatus(gomock.Any()).DoAndReturn(func(ar *v3.AccessRequest) (*v3.AccessRequest, error) {
+		return ar, nil
+	})
+	accessRequests.EXPECT().EnqueueAfter("ar-1", 30*time.Minute)
+
+	a := &accessRequestHandler{
+		accessRequests: accessRequests,
+		crtbs:          crtbs,
+		now:            func() time.Time { return now },
+	}
+	got, err := a.grant(&v3.AccessRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "ar-1"},
+		Spec: v3.AccessRequestSpec{
+			UserName:         "u-requester",
+			ClusterName:      "c-abc",
+			RoleTemplateName: "cluster-owner",
+			Duration:         metav1.Duration{Duration: time.Hour},
+		},
+		Status: v3.AccessRequestStatus{Decision: v3.AccessRequestApproved, ApproverName: "u-approver"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, existingExpiry, got.Status.ExpiresAt)
+
+	// Errors creating the binding are returned so that the request is retried.
+	crtbs.EXPECT().Create(gomock.Any()).Return(nil, errors.New("unavailable"))
+	_, err = a.grant(got)
+	assert.Error(t, err)
+}
@@ -0,0 +1,29 @@
+package accessrequests
+
+import (
+	"github.com/rancher/rancher/pkg/admissionpolicy"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	requesterSetID = "access-request-requester-admission"
+
+	// requesterExpression only allows users to request access for themselves, so that the user named by a request is
+	// always the user who filed it. The spec is immutable, so updates are always allowed.
+	requesterExpression = `oldObject != null || object.spec.userName == request.userInfo.username`
+)
+
+// requesterAdmissionPolicy restricts access requests to the user creating them. Otherwise, an approver could file a
+// request for another user and approve it themselves.
+var requesterAdmissionPolicy = admissionpolicy.Policy{
+	Name:     "rancher-access-request-requester",
+	Resource: v3.SchemeGroupVersion.WithResource("accessrequests"),
+	Validations: []admissionv1.Validation{{
+		Expression:        requesterExpression,
+		MessageExpression: "'user ' + request.userInfo.username + ' can only request access for themselves, not for user ' + object.spec.userName",
+		Reason:            ptr.To(metav1.StatusReasonForbidden),
+	}},
+}
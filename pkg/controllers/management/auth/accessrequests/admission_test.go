@@ -0,0 +1,85 @@
+package accessrequests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rancher/rancher/pkg/admissionpolicy/admissionpolicytest"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/admission/plugin/policy/validating"
+	celconfig "k8s.io/apiserver/pkg/apis/cel"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+func TestRequesterAdmissionPolicy(t *testing.T) {
+	policy := requesterAdmissionPolicy.Objects()[0].(*admissionv1.ValidatingAdmissionPolicy)
+	validator := admissionpolicytest.Compile(t, policy)
+
+	request := func(userName string) *v3.AccessRequest {
+		return &v3.AccessRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "ar-1"},
+			Spec:       v3.AccessRequestSpec{UserName: userName},
+		}
+	}
+
+	tests := map[string]struct {
+		requester string
+		operation admission.Operation
+		object    *v3.AccessRequest
+		oldObject *v3.AccessRequest
+		wantDeny  bool
+	}{
+		"create for the requester": {
+			requester: "u-requester",
+			operation: admission.Create,
+			object:    request("u-requester"),
+		},
+		"create for another user": {
+			requester: "u-approver",
+			operation: admission.Create,
+			object:    request("u-requester"),
+			wantDeny:  true,
+		},
+		"update by another user": {
+			requester: "u-approver",
+			operation: admission.Update,
+			object:    request("u-requester"),
+			oldObject: request("u-requester"),
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			gvk := v3.SchemeGroupVersion.WithKind("AccessRequest")
+			gvr := v3.SchemeGroupVersion.WithResource("accessrequests")
+			var oldObject runtime.Object
+			if tt.oldObject != nil {
+				oldObject = tt.oldObject
+			}
+			attr := admission.NewAttributesRecord(tt.object, oldObject, gvk, "", tt.object.Name, gvr, "", tt.operation, nil, false, &user.DefaultInfo{Name: tt.requester})
+			versionedAttr := &admission.VersionedAttributes{
+				Attributes:         attr,
+				VersionedKind:      gvk,
+				VersionedObject:    tt.object,
+				VersionedOldObject: oldObject,
+			}
+
+			result := validator.Validate(context.Background(), gvr, versionedAttr, nil, nil, celconfig.RuntimeCELCostBudget, nil)
+			require.Len(t, result.Decisions, 1)
+			decision := result.Decisions[0]
+			if tt.wantDeny {
+				assert.Equal(t, validating.ActionDeny, decision.Action, decision.Message)
+				assert.Equal(t, metav1.StatusReasonForbidden, decision.Reason)
+				assert.Equal(t, "user u-approver can only request access for themselves, not for user u-requester", decision.Message)
+			} else {
+				assert.Equal(t, validating.ActionAdmit, decision.Action, decision.Message)
+			}
+		})
+	}
+}
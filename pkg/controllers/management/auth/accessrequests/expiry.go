@@ -0,0 +1,75 @@
+package accessrequests
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/audit/event"
+	mgmtv3 "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// expiryHandler deletes role template bindings once they expire. Deleting a binding revokes the RBAC it created in
+// the local and downstream clusters through the existing binding controllers.
+type expiryHandler struct {
+	crtbs mgmtv3.ClusterRoleTemplateBindingController
+	prtbs mgmtv3.ProjectRoleTemplateBindingController
+	now   func() time.Time
+}
+
+func (e *expiryHandler) onCRTBChange(_ string, crtb *v3.ClusterRoleTemplateBinding) (*v3.ClusterRoleTemplateBinding, error) {
+	if crtb == nil || crtb.DeletionTimestamp != nil || crtb.ExpiresAt == nil {
+		return crtb, nil
+	}
+
+	if remaining := crtb.ExpiresAt.Sub(e.now()); remaining > 0 {
+		e.crtbs.EnqueueAfter(crtb.Namespace, crtb.Name, remaining)
+		return crtb, nil
+	}
+
+	if err := e.crtbs.Delete(crtb.Namespace, crtb.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return crtb, fmt.Errorf("failed to delete expired clusterroletemplatebinding %s/%s: %w", crtb.Namespace, crtb.Name, err)
+	}
+	logrus.Infof("[%s] Deleted clusterroletemplatebinding %s/%s which expired at %s", crtbExpiryHandler, crtb.Namespace, crtb.Name, crtb.ExpiresAt.UTC().Format(time.RFC3339))
+	recordExpiry(bindingURI("clusterroletemplatebindings", crtb.Namespace, crtb.Name), crtb.ExpiresAt)
+
+	return crtb, nil
+}
+
+func (e *expiryHandler) onPRTBChange(_ string, prtb *v3.ProjectRoleTemplateBinding) (*v3.ProjectRoleTemplateBinding, error) {
+	if prtb == nil || prtb.DeletionTimestamp != nil || prtb.ExpiresAt == nil {
+		return prtb, nil
+	}
+
+	if remaining := prtb.ExpiresAt.Sub(e.now()); remaining > 0 {
+		e.prtbs.EnqueueAfter(prtb.Namespace, prtb.Name, remaining)
+		return prtb, nil
+	}
+
+	if err := e.prtbs.Delete(prtb.Namespace, prtb.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return prtb, fmt.Errorf("failed to delete expired projectroletemplatebinding %s/%s: %w", prtb.Namespace, prtb.Name, err)
+	}
+	logrus.Infof("[%s] Deleted projectroletemplatebinding %s/%s which expired at %s", prtbExpiryHandler, prtb.Namespace, prtb.Name, prtb.ExpiresAt.UTC().Format(time.RFC3339))
+	recordExpiry(bindingURI("projectroletemplatebindings", prtb.Namespace, prtb.Name), prtb.ExpiresAt)
+
+	return prtb, nil
+}
+
+func recordExpiry(uri string, expiresAt *metav1.Time) {
+	if err := event.Record(event.Event{
+		Method:     http.MethodDelete,
+		RequestURI: uri,
+		Body:       map[string]any{"reason": "expired", "expiresAt": expiresAt},
+	}); err != nil {
+		logrus.Errorf("Failed to record expiry of %s in the audit log: %v", uri, err)
+	}
+}
+
+// bindingURI returns the API path of a role template binding, used as the request URI of audit events.
+func bindingURI(resource, namespace, name string) string {
+	return fmt.Sprintf("/apis/management.cattle.io/v3/namespaces/%s/%s/%s", namespace, resource, name)
+}
@@ -0,0 +1,80 @@
+package accessrequests
+
+import (
+	"testing"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_expiryHandler_onCRTBChange(t *testing.T) {
+	now := time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		expiresAt *metav1.Time
+		setup     func(*fake.MockControllerInterface[*v3.ClusterRoleTemplateBinding, *v3.ClusterRoleTemplateBindingList])
+	}{
+		{
+			name: "no expiry",
+		},
+		{
+			name:      "not expired",
+			expiresAt: &metav1.Time{Time: now.Add(time.Hour)},
+			setup: func(crtbs *fake.MockControllerInterface[*v3.ClusterRoleTemplateBinding, *v3.ClusterRoleTemplateBindingList]) {
+				crtbs.EXPECT().EnqueueAfter("c-abc", "crtb-xyz", time.Hour)
+			},
+		},
+		{
+			name:      "expired",
+			expiresAt: &metav1.Time{Time: now.Add(-time.Second)},
+			setup: func(crtbs *fake.MockControllerInterface[*v3.ClusterRoleTemplateBinding, *v3.ClusterRoleTemplateBindingList]) {
+				crtbs.EXPECT().Delete("c-abc", "crtb-xyz", &metav1.DeleteOptions{}).Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			crtbs := fake.NewMockControllerInterface[*v3.ClusterRoleTemplateBinding, *v3.ClusterRoleTemplateBindingList](ctrl)
+			if tt.setup != nil {
+				tt.setup(crtbs)
+			}
+			e := &expiryHandler{
+				crtbs: crtbs,
+				now:   func() time.Time { return now },
+			}
+
+			crtb := &v3.ClusterRoleTemplateBinding{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "c-abc", Name: "crtb-xyz"},
+				ExpiresAt:  tt.expiresAt,
+			}
+			_, err := e.onCRTBChange("", crtb)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_expiryHandler_onPRTBChange(t *testing.T) {
+	now := time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+
+	ctrl := gomock.NewController(t)
+	prtbs := fake.NewMockControllerInterface[*v3.ProjectRoleTemplateBinding, *v3.ProjectRoleTemplateBindingList](ctrl)
+	prtbs.EXPECT().Delete("c-abc-p-xyz", "prtb-xyz", &metav1.DeleteOptions{}).Return(nil)
+	e := &expiryHandler{
+		prtbs: prtbs,
+		now:   func() time.Time { return now },
+	}
+
+	prtb := &v3.ProjectRoleTemplateBinding{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "c-abc-p-xyz", Name: "prtb-xyz"},
+		ExpiresAt:  &metav1.Time{Time: now},
+	}
+	_, err := e.onPRTBChange("", prtb)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,40 @@
+package accessrequests
+
+import (
+	"context"
+	"time"
+
+	"github.com/rancher/rancher/pkg/admissionpolicy"
+	"github.com/rancher/rancher/pkg/types/config"
+)
+
+const (
+	accessRequestChangeHandler = "mgmt-accessrequest-change-handler"
+	crtbExpiryHandler          = "mgmt-crtb-expiry-handler"
+	prtbExpiryHandler          = "mgmt-prtb-expiry-handler"
+)
+
+// Register applies the admission policy restricting access requests to the user creating them, and registers the
+// controllers granting the access of approved AccessRequests and revoking the access of expired
+// ClusterRoleTemplateBindings and ProjectRoleTemplateBindings.
+func Register(ctx context.Context, management *config.ManagementContext) {
+	admissionpolicy.Apply(ctx, management.Wrangler.Apply, requesterSetID, requesterAdmissionPolicy)
+
+	mgmt := management.Wrangler.Mgmt
+	a := &accessRequestHandler{
+		accessRequests: mgmt.AccessRequest(),
+		crtbs:          mgmt.ClusterRoleTemplateBinding(),
+		prtbs:          mgmt.ProjectRoleTemplateBinding(),
+		projectCache:   mgmt.Project().Cache(),
+		now:            time.Now,
+	}
+	mgmt.AccessRequest().OnChange(ctx, accessRequestChangeHandler, a.onChange)
+
+	e := &expiryHandler{
+		crtbs: mgmt.ClusterRoleTemplateBinding(),
+		prtbs: mgmt.ProjectRoleTemplateBinding(),
+		now:   time.Now,
+	}
+	mgmt.ClusterRoleTemplateBinding().OnChange(ctx, crtbExpiryHandler, e.onCRTBChange)
+	mgmt.ProjectRoleTemplateBinding().OnChange(ctx, prtbExpiryHandler, e.onPRTBChange)
+}
@@ -0,0 +1,43 @@
+package oidcprovider
+
+import (
+	"github.com/rancher/rancher/pkg/admissionpolicy"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	clientCredentialsSetID = "oidc-client-credentials-admission"
+
+	// clientCredentialsUserNameExpression only allows binding the client_credentials grant of an OIDC client to a
+	// Rancher user by that user, or by a user who is allowed to impersonate them. Updates which keep the bound user
+	// are allowed, so that the client can still be managed by anyone who can edit it.
+	clientCredentialsUserNameExpression = `variables.userName == '' ||
+variables.userName == variables.oldUserName ||
+request.userInfo.username == variables.userName ||
+authorizer.group('').resource('users').name(variables.userName).check('impersonate').allowed()`
+)
+
+// clientCredentialsAdmissionPolicy restricts the users the client_credentials grant of OIDC clients can be bound to.
+// Otherwise, anyone who can create an OIDC client could obtain access tokens for any user.
+var clientCredentialsAdmissionPolicy = admissionpolicy.Policy{
+	Name:     "rancher-oidc-client-credentials",
+	Resource: v3.SchemeGroupVersion.WithResource("oidcclients"),
+	Variables: []admissionv1.Variable{
+		{
+			Name:       "userName",
+			Expression: "has(object.spec.clientCredentials) && has(object.spec.clientCredentials.userName) ? object.spec.clientCredentials.userName : ''",
+		},
+		{
+			Name:       "oldUserName",
+			Expression: "oldObject != null && has(oldObject.spec.clientCredentials) && has(oldObject.spec.clientCredentials.userName) ? oldObject.spec.clientCredentials.userName : ''",
+		},
+	},
+	Validations: []admissionv1.Validation{{
+		Expression:        clientCredentialsUserNameExpression,
+		MessageExpression: "'the client_credentials grant can only be bound to user ' + variables.userName + ' by that user or by a user allowed to impersonate them'",
+		Reason:            ptr.To(metav1.StatusReasonForbidden),
+	}},
+}
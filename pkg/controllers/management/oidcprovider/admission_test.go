@@ -0,0 +1,120 @@
+package oidcprovider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rancher/rancher/pkg/admissionpolicy/admissionpolicytest"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/admission/plugin/policy/validating"
+	celconfig "k8s.io/apiserver/pkg/apis/cel"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+)
+
+func TestClientCredentialsAdmissionPolicy(t *testing.T) {
+	policy := clientCredentialsAdmissionPolicy.Objects()[0].(*admissionv1.ValidatingAdmissionPolicy)
+	validator := admissionpolicytest.Compile(t, policy)
+
+	// impersonator is only allowed to impersonate u-impersonated.
+	impersonator := authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+		if a.GetUser().GetName() == "u-impersonator" && a.GetVerb() == "impersonate" && a.GetResource() == "users" && a.GetName() == "u-impersonated" {
+			return authorizer.DecisionAllow, "", nil
+		}
+		return authorizer.DecisionDeny, "", nil
+	})
+	client := func(userName string) *v3.OIDCClient {
+		c := &v3.OIDCClient{
+			ObjectMeta: metav1.ObjectMeta{Name: "client"},
+		}
+		if userName != "" {
+			c.Spec.ClientCredentials = &v3.OIDCClientCredentials{UserName: userName}
+		}
+		return c
+	}
+
+	tests := map[string]struct {
+		requester string
+		operation admission.Operation
+		object    *v3.OIDCClient
+		oldObject *v3.OIDCClient
+		wantDeny  bool
+	}{
+		"create without client credentials": {
+			requester: "u-other",
+			operation: admission.Create,
+			object:    client(""),
+		},
+		"create bound to the requester": {
+			requester: "u-owner",
+			operation: admission.Create,
+			object:    client("u-owner"),
+		},
+		"create bound to an impersonated user": {
+			requester: "u-impersonator",
+			operation: admission.Create,
+			object:    client("u-impersonated"),
+		},
+		"create bound to another user": {
+			requester: "u-other",
+			operation: admission.Create,
+			object:    client("u-owner"),
+			wantDeny:  true,
+		},
+		"update keeping the bound user": {
+			requester: "u-other",
+			operation: admission.Update,
+			object:    client("u-owner"),
+			oldObject: client("u-owner"),
+		},
+		"update binding another user": {
+			requester: "u-other",
+			operation: admission.Update,
+			object:    client("u-owner"),
+			oldObject: client(""),
+			wantDeny:  true,
+		},
+		"update binding a user who can't be impersonated": {
+			requester: "u-impersonator",
+			operation: admission.Update,
+			object:    client("u-owner"),
+			oldObject: client("u-impersonated"),
+			wantDeny:  true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			gvk := v3.SchemeGroupVersion.WithKind("OIDCClient")
+			gvr := v3.SchemeGroupVersion.WithResource("oidcclients")
+			var oldObject runtime.Object
+			if tt.oldObject != nil {
+				oldObject = tt.oldObject
+			}
+			attr := admission.NewAttributesRecord(tt.object, oldObject, gvk, "", tt.object.Name, gvr, "", tt.operation, nil, false, &user.DefaultInfo{Name: tt.requester})
+			versionedAttr := &admission.VersionedAttributes{
+				Attributes:         attr,
+				VersionedKind:      gvk,
+				VersionedObject:    tt.object,
+				VersionedOldObject: oldObject,
+			}
+
+			result := validator.Validate(context.Background(), gvr, versionedAttr, nil, nil, celconfig.RuntimeCELCostBudget, impersonator)
+			require.Len(t, result.Decisions, 1)
+			decision := result.Decisions[0]
+			if tt.wantDeny {
+				assert.Equal(t, validating.ActionDeny, decision.Action, decision.Message)
+				assert.Equal(t, metav1.StatusReasonForbidden, decision.Reason)
+				assert.Contains(t, decision.Message, tt.object.Spec.ClientCredentials.UserName)
+			} else {
+				assert.Equal(t, validating.ActionAdmit, decision.Action, decision.Message)
+			}
+		})
+	}
+}
@@ -11,6 +11,7 @@
 	"strings"
 	"time"
 
+	"github.com/rancher/rancher/pkg/admissionpolicy"
 	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	wrangmgmtv3 "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/oidc/randomstring"
@@ -48,6 +49,8 @@ type oidcClientController struct {
 }
 
 func Register(ctx context.Context, wContext *wrangler.Context) {
+	admissionpolicy.Apply(ctx, wContext.Apply, clientCredentialsSetID, clientCredentialsAdmissionPolicy)
+
 	oidcClient := wContext.Mgmt.OIDCClient()
 	controller := &oidcClientController{
 		secretClient:    wContext.Core.Secret(),
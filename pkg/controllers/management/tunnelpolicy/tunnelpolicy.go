@@ -0,0 +1,121 @@
+package tunnelpolicy
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/rancher/rancher/pkg/agent/tunnel"
+	apimgmtv3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/clustermanager"
+	"github.com/rancher/rancher/pkg/namespace"
+	"github.com/rancher/rancher/pkg/wrangler"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// handler pushes the tunnel dial policy of the downstream clusters to their agents, which refuse to dial destinations
+// that aren't allowed by it. A single handler serves all clusters and routes each change to the cluster it belongs to.
+type handler struct {
+	ctx context.Context
+	// configMaps returns the client of the ConfigMaps in the cattle-system namespace of the cluster
+	configMaps func(clusterName string) (typedcorev1.ConfigMapInterface, error)
+}
+
+func Register(ctx context.Context, wContext *wrangler.Context, manager *clustermanager.Manager) {
+	h := &handler{
+		ctx: ctx,
+		configMaps: func(clusterName string) (typedcorev1.ConfigMapInterface, error) {
+			userContext, err := manager.UserContextNoControllersReconnecting(clusterName, false)
+			if err != nil {
+				return nil, err
+			}
+			// Downstream ConfigMaps are not cached, the single policy ConfigMap is read directly
+			return userContext.K8sClient.CoreV1().ConfigMaps(namespace.System), nil
+		},
+	}
+	wContext.Mgmt.Cluster().OnChange(ctx, "tunnel-dial-policy", h.onClusterChange)
+}
+
+func (h *handler) onClusterChange(_ string, cluster *apimgmtv3.Cluster) (*apimgmtv3.Cluster, error) {
+	// The local cluster is not reached through an agent tunnel
+	if cluster == nil || cluster.Name == "local" || cluster.DeletionTimestamp != nil {
+		return cluster, nil
+	}
+	// The policy is pushed once the agent is connected, which updates the cluster
+	if !apimgmtv3.ClusterConditionReady.IsTrue(cluster) {
+		return cluster, nil
+	}
+
+	configMaps, err := h.configMaps(cluster.Name)
+	if err != nil {
+		return cluster, err
+	}
+	return cluster, h.sync(configMaps, cluster.Spec.TunnelDialPolicy)
+}
+
+// sync writes the policy to the policy ConfigMap of the cluster, or replaces it with the no policy marker if the cluster
+// has no policy.
+func (h *handler) sync(configMaps typedcorev1.ConfigMapInterface, policy *apimgmtv3.TunnelDialPolicy) error {
+	if policy == nil {
+		return h.remove(configMaps)
+	}
+
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+
+	cm, err := configMaps.Get(h.ctx, tunnel.PolicyConfigMapName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = configMaps.Create(h.ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      tunnel.PolicyConfigMapName,
+				Namespace: namespace.System,
+			},
+			Data: map[string]string{
+				tunnel.PolicyKey: string(data),
+			},
+		}, metav1.CreateOptions{})
+		return err
+	} else if err != nil {
+		return err
+	}
+
+	if _, ok := cm.Data[tunnel.NoPolicyKey]; !ok && cm.Data[tunnel.PolicyKey] == string(data) {
+		return nil
+	}
+	cm = cm.DeepCopy()
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[tunnel.PolicyKey] = string(data)
+	delete(cm.Data, tunnel.NoPolicyKey)
+	_, err = configMaps.Update(h.ctx, cm, metav1.UpdateOptions{})
+	return err
+}
+
+// remove replaces the policy of the policy ConfigMap of the cluster with the no policy marker. The ConfigMap is kept,
+// as the agent only allows dialing the kube-apiserver once the ConfigMap of a configured policy is deleted or has
+// neither key.
+func (h *handler) remove(configMaps typedcorev1.ConfigMapInterface) error {
+	cm, err := configMaps.Get(h.ctx, tunnel.PolicyConfigMapName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if _, ok := cm.Data[tunnel.PolicyKey]; !ok && cm.Data[tunnel.NoPolicyKey] == "true" {
+		return nil
+	}
+
+	cm = cm.DeepCopy()
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	delete(cm.Data, tunnel.PolicyKey)
+	cm.Data[tunnel.NoPolicyKey] = "true"
+	_, err = configMaps.Update(h.ctx, cm, metav1.UpdateOptions{})
+	return err
+}
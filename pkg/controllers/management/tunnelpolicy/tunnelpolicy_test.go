@@ -0,0 +1,109 @@
+package tunnelpolicy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/rancher/rancher/pkg/agent/tunnel"
+	apimgmtv3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/namespace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+func TestOnClusterChange(t *testing.T) {
+	ctx := context.Background()
+	clusterConfigMaps := map[string]typedcorev1.ConfigMapInterface{
+		"c-abc": fake.NewSimpleClientset().CoreV1().ConfigMaps(namespace.System),
+		"c-def": fake.NewSimpleClientset().CoreV1().ConfigMaps(namespace.System),
+	}
+	h := &handler{
+		ctx: ctx,
+		configMaps: func(clusterName string) (typedcorev1.ConfigMapInterface, error) {
+			configMaps, ok := clusterConfigMaps[clusterName]
+			if !ok {
+				return nil, fmt.Errorf("cluster context %s is unavailable", clusterName)
+			}
+			return configMaps, nil
+		},
+	}
+	newCluster := func(name string) *apimgmtv3.Cluster {
+		cluster := &apimgmtv3.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+		}
+		apimgmtv3.ClusterConditionReady.True(cluster)
+		return cluster
+	}
+	cluster := newCluster("c-abc")
+	configMaps := clusterConfigMaps["c-abc"]
+
+	// no policy, nothing to push
+	_, err := h.onClusterChange("", cluster)
+	require.NoError(t, err)
+	_, err = configMaps.Get(ctx, tunnel.PolicyConfigMapName, metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+
+	cluster.Spec.TunnelDialPolicy = &apimgmtv3.TunnelDialPolicy{
+		AllowedDestinations: []apimgmtv3.TunnelDialDestination{{CIDR: "10.42.0.0/16", Ports: []int32{9090}}},
+	}
+	_, err = h.onClusterChange("", cluster)
+	require.NoError(t, err)
+	cm, err := configMaps.Get(ctx, tunnel.PolicyConfigMapName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"allowedDestinations":[{"cidr":"10.42.0.0/16","ports":[9090]}]}`, cm.Data[tunnel.PolicyKey])
+
+	cluster.Spec.TunnelDialPolicy.AllowedDestinations = nil
+	_, err = h.onClusterChange("", cluster)
+	require.NoError(t, err)
+	cm, err = configMaps.Get(ctx, tunnel.PolicyConfigMapName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{}`, cm.Data[tunnel.PolicyKey])
+
+	// the policy of each cluster is pushed to that cluster only
+	other := newCluster("c-def")
+	other.Spec.TunnelDialPolicy = &apimgmtv3.TunnelDialPolicy{
+		AllowedDestinations: []apimgmtv3.TunnelDialDestination{{CIDR: "10.0.0.0/8"}},
+	}
+	_, err = h.onClusterChange("", other)
+	require.NoError(t, err)
+	cm, err = clusterConfigMaps["c-def"].Get(ctx, tunnel.PolicyConfigMapName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"allowedDestinations":[{"cidr":"10.0.0.0/8"}]}`, cm.Data[tunnel.PolicyKey])
+	cm, err = configMaps.Get(ctx, tunnel.PolicyConfigMapName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{}`, cm.Data[tunnel.PolicyKey])
+
+	// the ConfigMap is kept with the no policy marker, so that the agent can tell a removed policy from a deleted
+	// ConfigMap or a removed policy key
+	cluster.Spec.TunnelDialPolicy = nil
+	_, err = h.onClusterChange("", cluster)
+	require.NoError(t, err)
+	cm, err = configMaps.Get(ctx, tunnel.PolicyConfigMapName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{tunnel.NoPolicyKey: "true"}, cm.Data)
+
+	cluster.Spec.TunnelDialPolicy = &apimgmtv3.TunnelDialPolicy{}
+	_, err = h.onClusterChange("", cluster)
+	require.NoError(t, err)
+	cm, err = configMaps.Get(ctx, tunnel.PolicyConfigMapName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{tunnel.PolicyKey: "{}"}, cm.Data)
+
+	// clusters that aren't connected or aren't reached through a tunnel are skipped
+	notReady := &apimgmtv3.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c-ghi"}}
+	notReady.Spec.TunnelDialPolicy = &apimgmtv3.TunnelDialPolicy{}
+	_, err = h.onClusterChange("", notReady)
+	assert.NoError(t, err)
+	local := newCluster("local")
+	local.Spec.TunnelDialPolicy = &apimgmtv3.TunnelDialPolicy{}
+	_, err = h.onClusterChange("", local)
+	assert.NoError(t, err)
+
+	_, err = h.onClusterChange("", newCluster("c-ghi"))
+	assert.EqualError(t, err, "cluster context c-ghi is unavailable")
+}
@@ -0,0 +1,52 @@
+package networkpolicy
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/rancher/rancher/pkg/controllers/managementuser/nodesyncer"
+	"github.com/rancher/rancher/pkg/types/config"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// watchAPIServerEndpoints watches the endpoints of the kubernetes service, which are always allowed as egress peers
+// of projects, until the context is done. Only that object is cached. The project network policies are reprogrammed
+// whenever its addresses change, as they do on hosted clusters whose kube-apiserver IPs rotate.
+func watchAPIServerEndpoints(ctx context.Context, cluster *config.UserContext) (corelisters.EndpointsLister, error) {
+	listWatch := cache.NewFilteredListWatchFromClient(cluster.K8sClient.CoreV1().RESTClient(), "endpoints", metav1.NamespaceDefault, func(options *metav1.ListOptions) {
+		options.FieldSelector = fields.OneTermEqualSelector("metadata.name", kubernetesServiceName).String()
+	})
+	informer := cache.NewSharedIndexInformer(listWatch, &corev1.Endpoints{}, 0, cache.Indexers{})
+
+	// the node handler reprograms the project network policies with the egress peers of the cluster on the all
+	// nodes key, and retries on errors
+	nodes := cluster.Management.Management.Nodes(cluster.ClusterName).Controller()
+	enqueue := func() {
+		logrus.Debugf("watchAPIServerEndpoints: endpoints of the %v service changed in cluster %v", kubernetesServiceName, cluster.ClusterName)
+		nodes.Enqueue(cluster.ClusterName, nodesyncer.AllNodeKey)
+	}
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(interface{}) {
+			enqueue()
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if !reflect.DeepEqual(oldObj.(*corev1.Endpoints).Subsets, newObj.(*corev1.Endpoints).Subsets) {
+				enqueue()
+			}
+		},
+		DeleteFunc: func(interface{}) {
+			enqueue()
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go informer.Run(ctx.Done())
+	return corelisters.NewEndpointsLister(informer.GetIndexer()), nil
+}
@@ -25,6 +25,7 @@
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/selection"
+	corelisters "k8s.io/client-go/listers/core/v1"
 )
 
 const (
@@ -35,6 +36,7 @@
 const (
 	defaultNamespacePolicyName              = "np-default"
 	defaultSystemProjectNamespacePolicyName = "np-default-allow-all"
+	projectRulesPolicyName                  = "np-project-rules"
 	hostNetworkPolicyName                   = "hn-nodes"
 	creatorNorman                           = "norman"
 	calicoNetworkPlugin                     = "calico"
@@ -47,11 +49,13 @@ type netpolMgr struct {
 	clusters         rancherv1.ClusterCache
 	nsLister         typescorev1.NamespaceLister
 	nodeLister       typescorev1.NodeLister
+	endpointsLister  corelisters.EndpointsLister
 	pods             typescorev1.PodInterface
 	projects         v3.ProjectInterface
 	npLister         rnetworkingv1.NetworkPolicyLister
 	npClient         rnetworkingv1.Interface
 	projLister       v3.ProjectLister
+	pnpLister        v3.ProjectNetworkPolicyLister
 	clusterNamespace string
 }
 
@@ -115,6 +119,13 @@ func (npmgr *netpolMgr) programNetworkPolicy(projectID string, clusterNamespace
 		return fmt.Errorf("netpolMgr: programNetworkPolicy getSystemNamespaces: err=%v", err)
 	}
 
+	var rulesPolicy *knetworkingv1.NetworkPolicy
+	if projectID != "" && projectID != systemProjectID {
+		if rulesPolicy, err = npmgr.projectRulesPolicy(projectID, systemProjectID); err != nil {
+			return err
+		}
+	}
+
 	for _, aNS := range namespaces {
 		id, _ := aNS.Labels[nslabels.ProjectIDFieldLabel]
 
@@ -125,6 +136,7 @@ func (npmgr *netpolMgr) programNetworkPolicy(projectID string, clusterNamespace
 		// will only be added if there are no other network policies in the namespace (network policies are additive)
 		if systemNamespaces[aNS.Name] {
 			npmgr.delete(aNS.Name, defaultNamespacePolicyName)
+			npmgr.delete(aNS.Name, projectRulesPolicyName)
 
 			// this requirement includes objects with no creatorLabel or a value != creatorNorman
 			labelReq, err := labels.NewRequirement(creatorLabel, selection.NotEquals, []string{creatorNorman})
@@ -160,6 +172,7 @@ func (npmgr *netpolMgr) programNetworkPolicy(projectID string, clusterNamespace
 		}
 		if id == "" {
 			npmgr.delete(aNS.Name, defaultNamespacePolicyName)
+			npmgr.delete(aNS.Name, projectRulesPolicyName)
 			continue
 		}
 		if aNS.DeletionTimestamp != nil {
@@ -171,6 +184,19 @@ func (npmgr *netpolMgr) programNetworkPolicy(projectID string, clusterNamespace
 		if err := npmgr.program(np); err != nil {
 			return fmt.Errorf("netpolMgr: programNetworkPolicy: error programming default network policy for ns=%v err=%v", aNS.Name, err)
 		}
+
+		// program the allow rules of the project network policies, which are additive to the default network policy
+		if rulesPolicy == nil {
+			if err := npmgr.delete(aNS.Name, projectRulesPolicyName); err != nil {
+				return err
+			}
+			continue
+		}
+		np = rulesPolicy.DeepCopy()
+		np.Namespace = aNS.Name
+		if err := npmgr.program(np); err != nil {
+			return fmt.Errorf("netpolMgr: programNetworkPolicy: error programming project rules network policy for ns=%v err=%v", aNS.Name, err)
+		}
 	}
 	return nil
 }
@@ -484,7 +510,7 @@ func portToString(port knetworkingv1.NetworkPolicyPort) string {
 
 func (npmgr *netpolMgr) SyncDefaultNetworkPolicies(key string, np *rnetworkingv1.NetworkPolicy) (runtime.Object, error) {
 	nsName, npName := splitKey(key)
-	if npName != defaultNamespacePolicyName && npName != defaultSystemProjectNamespacePolicyName && npName != hostNetworkPolicyName {
+	if npName != defaultNamespacePolicyName && npName != defaultSystemProjectNamespacePolicyName && npName != hostNetworkPolicyName && npName != projectRulesPolicyName {
 		return nil, nil
 	}
 
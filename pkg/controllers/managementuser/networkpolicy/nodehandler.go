@@ -25,7 +25,10 @@ func (nh *nodeHandler) Sync(key string, machine *v3.Node) (runtime.Object, error
 			return nil, nil
 		}
 		logrus.Debugf("nodeHandler: Sync: key=%v", key)
-		return nil, nh.npmgr.handleHostNetwork(nh.clusterNamespace)
+		if err := nh.npmgr.handleHostNetwork(nh.clusterNamespace); err != nil {
+			return nil, err
+		}
+		return nil, nh.npmgr.reprogramProjectsWithEgressRules(nh.clusterNamespace)
 	}
 	return nil, nil
 }
@@ -68,6 +68,7 @@ func (nss *nsSyncer) syncOnMove(nsName string, projectID string, movedToNone boo
 		nss.npmgr.delete(nsName, defaultNamespacePolicyName)
 		nss.npmgr.delete(nsName, hostNetworkPolicyName)
 		nss.npmgr.delete(nsName, defaultSystemProjectNamespacePolicyName)
+		nss.npmgr.delete(nsName, projectRulesPolicyName)
 	}
 	if err = nss.syncNodePortServices(systemNamespaces, nsName, movedToNone); err != nil {
 		return fmt.Errorf("nsSyncer: error syncing services %v", err)
@@ -1,20 +1,80 @@
 package networkpolicy
 
 import (
+	"time"
+
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
 	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
 type projectNetworkPolicySyncer struct {
 	npmgr *netpolMgr
+	pnps  v3.ProjectNetworkPolicyInterface
 }
 
 // Sync invokes the Policy Handler to take care of installing the native network policies
 func (pnps *projectNetworkPolicySyncer) Sync(key string, pnp *v3.ProjectNetworkPolicy) (runtime.Object, error) {
 	if pnp == nil || pnp.DeletionTimestamp != nil {
-		return nil, nil
+		// reprogram the project of a removed policy, so that its rules are removed from the namespaces of the project
+		projectID, _ := splitKey(key)
+		if _, err := pnps.npmgr.projLister.Get(pnps.npmgr.clusterNamespace, projectID); err != nil {
+			if kerrors.IsNotFound(err) {
+				// the project is in another cluster or was removed along with the policy
+				return nil, nil
+			}
+			return nil, err
+		}
+		logrus.Debugf("projectNetworkPolicySyncer: Sync: pnp=%v removed", key)
+		return nil, pnps.npmgr.programNetworkPolicy(projectID, pnps.npmgr.clusterNamespace)
 	}
 	logrus.Debugf("projectNetworkPolicySyncer: Sync: pnp=%+v", pnp)
+
+	// invalid rules are skipped when programming the project, report them on the policy
+	_, _, rulesErr := renderProjectRules(&pnp.Spec, pnps.npmgr.clusterNamespace)
+	if updated := pnp.DeepCopy(); setRulesValidCondition(updated, rulesErr, time.Now()) {
+		var err error
+		if pnp, err = pnps.pnps.Update(updated); err != nil {
+			return nil, err
+		}
+	}
 	return nil, pnps.npmgr.programNetworkPolicy(pnp.Namespace, pnps.npmgr.clusterNamespace)
 }
+
+// setRulesValidCondition sets the RulesValid condition of the policy from the error rendering its rules. It returns
+// true if the condition changed.
+func setRulesValidCondition(pnp *v3.ProjectNetworkPolicy, rulesErr error, now time.Time) bool {
+	cond := v32.ProjectNetworkPolicyCondition{
+		Type:   v32.ProjectNetworkPolicyConditionRulesValid,
+		Status: corev1.ConditionTrue,
+	}
+	if rulesErr != nil {
+		cond.Status = corev1.ConditionFalse
+		cond.Reason = "InvalidRules"
+		cond.Message = rulesErr.Error()
+	}
+
+	if pnp.Status == nil {
+		pnp.Status = &v32.ProjectNetworkPolicyStatus{}
+	}
+	for i, existing := range pnp.Status.Conditions {
+		if existing.Type != cond.Type {
+			continue
+		}
+		if existing.Status == cond.Status && existing.Reason == cond.Reason && existing.Message == cond.Message {
+			return false
+		}
+		cond.LastTransitionTime = existing.LastTransitionTime
+		if existing.Status != cond.Status {
+			cond.LastTransitionTime = now.UTC().Format(time.RFC3339)
+		}
+		pnp.Status.Conditions[i] = cond
+		return true
+	}
+	cond.LastTransitionTime = now.UTC().Format(time.RFC3339)
+	pnp.Status.Conditions = append(pnp.Status.Conditions, cond)
+	return true
+}
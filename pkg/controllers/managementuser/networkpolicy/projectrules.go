@@ -0,0 +1,296 @@
+package networkpolicy
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/controllers/managementagent/nslabels"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	knetworkingv1 "k8s.io/api/networking/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	kubernetesServiceName = "kubernetes"
+	// nodeLocalDNSAddress is the link-local address of the node-local DNS cache.
+	nodeLocalDNSAddress = "169.254.20.10"
+)
+
+// projectRulesPolicy renders the ingress and egress rules of the ProjectNetworkPolicies of a project into a single
+// network policy, which is programmed in every namespace of the project. It returns nil if the project has no rules.
+func (npmgr *netpolMgr) projectRulesPolicy(projectID, systemProjectID string) (*knetworkingv1.NetworkPolicy, error) {
+	pnps, err := npmgr.pnpLister.List(projectID, labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("netpolMgr: projectRulesPolicy: couldn't list project network policies of project %v err=%v", projectID, err)
+	}
+	// sort policies so the rules always appear in a certain order
+	sort.Slice(pnps, func(i, j int) bool {
+		return pnps[i].Name < pnps[j].Name
+	})
+
+	var ingress []knetworkingv1.NetworkPolicyIngressRule
+	var egress []knetworkingv1.NetworkPolicyEgressRule
+	for _, pnp := range pnps {
+		if pnp.DeletionTimestamp != nil {
+			continue
+		}
+		pnpIngress, pnpEgress, err := renderProjectRules(&pnp.Spec, npmgr.clusterNamespace)
+		if err != nil {
+			// an invalid policy must not prevent programming the rules of the other policies of the project, the error is
+			// reported on the status of the policy by the projectNetworkPolicySyncer
+			logrus.Debugf("netpolMgr: projectRulesPolicy: skipping rules of project network policy %v/%v err=%v", pnp.Namespace, pnp.Name, err)
+			continue
+		}
+		ingress = append(ingress, pnpIngress...)
+		egress = append(egress, pnpEgress...)
+	}
+	if len(ingress) == 0 && len(egress) == 0 {
+		return nil, nil
+	}
+
+	np := &knetworkingv1.NetworkPolicy{
+		ObjectMeta: v1.ObjectMeta{
+			Name: projectRulesPolicyName,
+			Labels: map[string]string{
+				nslabels.ProjectIDFieldLabel: projectID,
+				creatorLabel:                 creatorNorman,
+			},
+		},
+		Spec: knetworkingv1.NetworkPolicySpec{
+			// An empty PodSelector selects all pods in this Namespace.
+			PodSelector: v1.LabelSelector{},
+		},
+	}
+	if len(ingress) > 0 {
+		np.Spec.Ingress = ingress
+		np.Spec.PolicyTypes = append(np.Spec.PolicyTypes, knetworkingv1.PolicyTypeIngress)
+	}
+	if len(egress) > 0 {
+		// traffic to the project itself and to the system project, which runs the cluster DNS, is always allowed
+		alwaysAllowed := []knetworkingv1.NetworkPolicyEgressRule{
+			{
+				To: []knetworkingv1.NetworkPolicyPeer{
+					projectPeer(projectID),
+					projectPeer(systemProjectID),
+				},
+			},
+		}
+		// as is traffic to the kube-apiserver, to host network endpoints and to the node-local DNS cache, which in-cluster
+		// clients depend on and which are not selected by namespace selectors
+		clusterPeers, err := npmgr.clusterEgressPeers()
+		if err != nil {
+			return nil, err
+		}
+		alwaysAllowed = append(alwaysAllowed, knetworkingv1.NetworkPolicyEgressRule{To: clusterPeers})
+		np.Spec.Egress = append(alwaysAllowed, egress...)
+		np.Spec.PolicyTypes = append(np.Spec.PolicyTypes, knetworkingv1.PolicyTypeEgress)
+	}
+	return np, nil
+}
+
+// clusterEgressPeers returns the addresses of the nodes, the endpoints of the kube-apiserver and the node-local DNS cache
+// of the cluster.
+func (npmgr *netpolMgr) clusterEgressPeers() ([]knetworkingv1.NetworkPolicyPeer, error) {
+	nodes, err := npmgr.nodeLister.List("", labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("netpolMgr: clusterEgressPeers: couldn't list nodes err=%v", err)
+	}
+	apiEndpoints, err := npmgr.endpointsLister.Endpoints(v1.NamespaceDefault).Get(kubernetesServiceName)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return nil, fmt.Errorf("netpolMgr: clusterEgressPeers: couldn't get endpoints of the %v service err=%v", kubernetesServiceName, err)
+	}
+	return clusterEgressPeers(nodes, apiEndpoints), nil
+}
+
+func clusterEgressPeers(nodes []*corev1.Node, apiEndpoints *corev1.Endpoints) []knetworkingv1.NetworkPolicyPeer {
+	addresses := map[string]bool{nodeLocalDNSAddress: true}
+	for _, node := range nodes {
+		for _, address := range node.Status.Addresses {
+			if address.Type == corev1.NodeInternalIP || address.Type == corev1.NodeExternalIP {
+				addresses[address.Address] = true
+			}
+		}
+	}
+	if apiEndpoints != nil {
+		for _, subset := range apiEndpoints.Subsets {
+			for _, address := range subset.Addresses {
+				addresses[address.IP] = true
+			}
+		}
+	}
+
+	var peers []knetworkingv1.NetworkPolicyPeer
+	for address := range addresses {
+		ip := net.ParseIP(address)
+		if ip == nil {
+			continue
+		}
+		cidr := ip.String() + "/32"
+		if ip.To4() == nil {
+			cidr = ip.String() + "/128"
+		}
+		peers = append(peers, knetworkingv1.NetworkPolicyPeer{IPBlock: &knetworkingv1.IPBlock{CIDR: cidr}})
+	}
+	// sort ipblocks so they always appear in a certain order
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].IPBlock.CIDR < peers[j].IPBlock.CIDR
+	})
+	return peers
+}
+
+// reprogramProjectsWithEgressRules programs the network policies of the projects which have egress rules, as the
+// addresses of the nodes and the kube-apiserver are part of their project rules policy.
+func (npmgr *netpolMgr) reprogramProjectsWithEgressRules(clusterNamespace string) error {
+	pnps, err := npmgr.pnpLister.List("", labels.Everything())
+	if err != nil {
+		return fmt.Errorf("netpolMgr: reprogramProjectsWithEgressRules: couldn't list project network policies err=%v", err)
+	}
+	programmed := map[string]bool{}
+	for _, pnp := range pnps {
+		if len(pnp.Spec.Egress) == 0 || programmed[pnp.Namespace] {
+			continue
+		}
+		if _, err := npmgr.projLister.Get(clusterNamespace, pnp.Namespace); err != nil {
+			if kerrors.IsNotFound(err) {
+				// the project is in another cluster
+				continue
+			}
+			return err
+		}
+		programmed[pnp.Namespace] = true
+		if err := npmgr.programNetworkPolicy(pnp.Namespace, clusterNamespace); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderProjectRules converts the rules of a ProjectNetworkPolicy into network policy rules.
+func renderProjectRules(spec *v32.ProjectNetworkPolicySpec, clusterName string) ([]knetworkingv1.NetworkPolicyIngressRule, []knetworkingv1.NetworkPolicyEgressRule, error) {
+	var ingress []knetworkingv1.NetworkPolicyIngressRule
+	for i, rule := range spec.Ingress {
+		peers, ports, err := renderProjectRule(rule, clusterName)
+		if err != nil {
+			return nil, nil, fmt.Errorf("ingress rule %d: %w", i, err)
+		}
+		ingress = append(ingress, knetworkingv1.NetworkPolicyIngressRule{From: peers, Ports: ports})
+	}
+
+	var egress []knetworkingv1.NetworkPolicyEgressRule
+	for i, rule := range spec.Egress {
+		peers, ports, err := renderProjectRule(rule, clusterName)
+		if err != nil {
+			return nil, nil, fmt.Errorf("egress rule %d: %w", i, err)
+		}
+		egress = append(egress, knetworkingv1.NetworkPolicyEgressRule{To: peers, Ports: ports})
+	}
+
+	return ingress, egress, nil
+}
+
+func renderProjectRule(rule v32.ProjectNetworkPolicyRule, clusterName string) ([]knetworkingv1.NetworkPolicyPeer, []knetworkingv1.NetworkPolicyPort, error) {
+	var peers []knetworkingv1.NetworkPolicyPeer
+	for _, peer := range rule.Peers {
+		np, err := renderProjectPeer(peer, clusterName)
+		if err != nil {
+			return nil, nil, err
+		}
+		peers = append(peers, np)
+	}
+
+	var ports []knetworkingv1.NetworkPolicyPort
+	for _, port := range rule.Ports {
+		np, err := renderProjectPort(port)
+		if err != nil {
+			return nil, nil, err
+		}
+		ports = append(ports, np)
+	}
+
+	return peers, ports, nil
+}
+
+func renderProjectPeer(peer v32.ProjectNetworkPolicyPeer, clusterName string) (knetworkingv1.NetworkPolicyPeer, error) {
+	set := 0
+	for _, field := range []string{peer.ProjectName, peer.NamespaceName, peer.CIDR} {
+		if field != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return knetworkingv1.NetworkPolicyPeer{}, fmt.Errorf("exactly one of projectName, namespaceName and cidr must be set")
+	}
+	if len(peer.Except) > 0 && peer.CIDR == "" {
+		return knetworkingv1.NetworkPolicyPeer{}, fmt.Errorf("except requires cidr to be set")
+	}
+
+	switch {
+	case peer.ProjectName != "":
+		projectCluster, projectID, _ := strings.Cut(peer.ProjectName, ":")
+		if projectCluster != clusterName || projectID == "" {
+			return knetworkingv1.NetworkPolicyPeer{}, fmt.Errorf("project %v is not in cluster %v", peer.ProjectName, clusterName)
+		}
+		return projectPeer(projectID), nil
+	case peer.NamespaceName != "":
+		return knetworkingv1.NetworkPolicyPeer{
+			NamespaceSelector: &v1.LabelSelector{
+				MatchLabels: map[string]string{corev1.LabelMetadataName: peer.NamespaceName},
+			},
+		}, nil
+	default:
+		for _, cidr := range append([]string{peer.CIDR}, peer.Except...) {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return knetworkingv1.NetworkPolicyPeer{}, fmt.Errorf("invalid cidr %v: %w", cidr, err)
+			}
+		}
+		return knetworkingv1.NetworkPolicyPeer{
+			IPBlock: &knetworkingv1.IPBlock{
+				CIDR:   peer.CIDR,
+				Except: peer.Except,
+			},
+		}, nil
+	}
+}
+
+func renderProjectPort(port v32.ProjectNetworkPolicyPort) (knetworkingv1.NetworkPolicyPort, error) {
+	protocol := corev1.Protocol(port.Protocol)
+	switch protocol {
+	case "":
+		protocol = corev1.ProtocolTCP
+	case corev1.ProtocolTCP, corev1.ProtocolUDP, corev1.ProtocolSCTP:
+	default:
+		return knetworkingv1.NetworkPolicyPort{}, fmt.Errorf("invalid protocol %v", port.Protocol)
+	}
+	if port.Port < 1 || port.Port > 65535 {
+		return knetworkingv1.NetworkPolicyPort{}, fmt.Errorf("invalid port %v", port.Port)
+	}
+
+	p := intstr.FromInt32(port.Port)
+	np := knetworkingv1.NetworkPolicyPort{
+		Protocol: &protocol,
+		Port:     &p,
+	}
+	if port.EndPort != 0 {
+		if port.EndPort < port.Port || port.EndPort > 65535 {
+			return knetworkingv1.NetworkPolicyPort{}, fmt.Errorf("invalid port range %v-%v", port.Port, port.EndPort)
+		}
+		endPort := port.EndPort
+		np.EndPort = &endPort
+	}
+	return np, nil
+}
+
+func projectPeer(projectID string) knetworkingv1.NetworkPolicyPeer {
+	return knetworkingv1.NetworkPolicyPeer{
+		NamespaceSelector: &v1.LabelSelector{
+			MatchLabels: map[string]string{nslabels.ProjectIDFieldLabel: projectID},
+		},
+	}
+}
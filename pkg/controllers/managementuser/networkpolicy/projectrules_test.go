@@ -0,0 +1,182 @@
+package networkpolicy
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/controllers/managementagent/nslabels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	knetworkingv1 "k8s.io/api/networking/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestRenderProjectRules(t *testing.T) {
+	tcp := corev1.ProtocolTCP
+	udp := corev1.ProtocolUDP
+	port80 := intstr.FromInt32(80)
+	port5000 := intstr.FromInt32(5000)
+	endPort := int32(5100)
+
+	tests := []struct {
+		name        string
+		spec        v32.ProjectNetworkPolicySpec
+		wantIngress []knetworkingv1.NetworkPolicyIngressRule
+		wantEgress  []knetworkingv1.NetworkPolicyEgressRule
+		wantErr     string
+	}{
+		{
+			name: "no rules",
+		},
+		{
+			name: "ingress from a project and a namespace on a port",
+			spec: v32.ProjectNetworkPolicySpec{
+				Ingress: []v32.ProjectNetworkPolicyRule{{
+					Peers: []v32.ProjectNetworkPolicyPeer{
+						{ProjectName: "c-abc:p-xyz"},
+						{NamespaceName: "monitoring"},
+					},
+					Ports: []v32.ProjectNetworkPolicyPort{{Port: 80}},
+				}},
+			},
+			wantIngress: []knetworkingv1.NetworkPolicyIngressRule{{
+				From: []knetworkingv1.NetworkPolicyPeer{
+					{NamespaceSelector: &v1.LabelSelector{MatchLabels: map[string]string{nslabels.ProjectIDFieldLabel: "p-xyz"}}},
+					{NamespaceSelector: &v1.LabelSelector{MatchLabels: map[string]string{corev1.LabelMetadataName: "monitoring"}}},
+				},
+				Ports: []knetworkingv1.NetworkPolicyPort{{Protocol: &tcp, Port: &port80}},
+			}},
+		},
+		{
+			name: "egress to a cidr on a port range",
+			spec: v32.ProjectNetworkPolicySpec{
+				Egress: []v32.ProjectNetworkPolicyRule{{
+					Peers: []v32.ProjectNetworkPolicyPeer{{CIDR: "10.0.0.0/8", Except: []string{"10.1.0.0/16"}}},
+					Ports: []v32.ProjectNetworkPolicyPort{{Protocol: "UDP", Port: 5000, EndPort: 5100}},
+				}},
+			},
+			wantEgress: []knetworkingv1.NetworkPolicyEgressRule{{
+				To: []knetworkingv1.NetworkPolicyPeer{
+					{IPBlock: &knetworkingv1.IPBlock{CIDR: "10.0.0.0/8", Except: []string{"10.1.0.0/16"}}},
+				},
+				Ports: []knetworkingv1.NetworkPolicyPort{{Protocol: &udp, Port: &port5000, EndPort: &endPort}},
+			}},
+		},
+		{
+			name: "project in another cluster",
+			spec: v32.ProjectNetworkPolicySpec{
+				Ingress: []v32.ProjectNetworkPolicyRule{{
+					Peers: []v32.ProjectNetworkPolicyPeer{{ProjectName: "c-def:p-xyz"}},
+				}},
+			},
+			wantErr: "ingress rule 0: project c-def:p-xyz is not in cluster c-abc",
+		},
+		{
+			name: "peer with more than one field",
+			spec: v32.ProjectNetworkPolicySpec{
+				Egress: []v32.ProjectNetworkPolicyRule{{
+					Peers: []v32.ProjectNetworkPolicyPeer{{NamespaceName: "monitoring", CIDR: "10.0.0.0/8"}},
+				}},
+			},
+			wantErr: "egress rule 0: exactly one of projectName, namespaceName and cidr must be set",
+		},
+		{
+			name: "invalid cidr",
+			spec: v32.ProjectNetworkPolicySpec{
+				Ingress: []v32.ProjectNetworkPolicyRule{{
+					Peers: []v32.ProjectNetworkPolicyPeer{{CIDR: "10.0.0.0"}},
+				}},
+			},
+			wantErr: "ingress rule 0: invalid cidr 10.0.0.0",
+		},
+		{
+			name: "invalid port range",
+			spec: v32.ProjectNetworkPolicySpec{
+				Ingress: []v32.ProjectNetworkPolicyRule{{
+					Ports: []v32.ProjectNetworkPolicyPort{{Port: 5000, EndPort: 4000}},
+				}},
+			},
+			wantErr: "ingress rule 0: invalid port range 5000-4000",
+		},
+		{
+			name: "invalid protocol",
+			spec: v32.ProjectNetworkPolicySpec{
+				Ingress: []v32.ProjectNetworkPolicyRule{{
+					Ports: []v32.ProjectNetworkPolicyPort{{Protocol: "ICMP", Port: 80}},
+				}},
+			},
+			wantErr: "ingress rule 0: invalid protocol ICMP",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ingress, egress, err := renderProjectRules(&tt.spec, "c-abc")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantIngress, ingress)
+			assert.Equal(t, tt.wantEgress, egress)
+		})
+	}
+}
+
+func TestClusterEgressPeers(t *testing.T) {
+	nodes := []*corev1.Node{
+		{Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+			{Type: corev1.NodeInternalIP, Address: "10.0.0.2"},
+			{Type: corev1.NodeExternalIP, Address: "2001:db8::2"},
+			{Type: corev1.NodeHostName, Address: "node-1"},
+		}}},
+		{Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+			{Type: corev1.NodeInternalIP, Address: "10.0.0.1"},
+		}}},
+	}
+	apiEndpoints := &corev1.Endpoints{
+		Subsets: []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}, {IP: "172.16.0.10"}},
+		}},
+	}
+
+	peer := func(cidr string) knetworkingv1.NetworkPolicyPeer {
+		return knetworkingv1.NetworkPolicyPeer{IPBlock: &knetworkingv1.IPBlock{CIDR: cidr}}
+	}
+	assert.Equal(t, []knetworkingv1.NetworkPolicyPeer{
+		peer("10.0.0.1/32"),
+		peer("10.0.0.2/32"),
+		peer("169.254.20.10/32"),
+		peer("172.16.0.10/32"),
+		peer("2001:db8::2/128"),
+	}, clusterEgressPeers(nodes, apiEndpoints))
+	assert.Equal(t, []knetworkingv1.NetworkPolicyPeer{peer("169.254.20.10/32")}, clusterEgressPeers(nil, nil))
+}
+
+func TestSetRulesValidCondition(t *testing.T) {
+	now := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+	pnp := &v32.ProjectNetworkPolicy{}
+
+	require.True(t, setRulesValidCondition(pnp, nil, now))
+	assert.Equal(t, []v32.ProjectNetworkPolicyCondition{{
+		Type:               v32.ProjectNetworkPolicyConditionRulesValid,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: now.Format(time.RFC3339),
+	}}, pnp.Status.Conditions)
+
+	assert.False(t, setRulesValidCondition(pnp, nil, later), "an unchanged condition must not be updated")
+
+	require.True(t, setRulesValidCondition(pnp, errors.New("ingress rule 0: invalid port 0"), later))
+	assert.Equal(t, []v32.ProjectNetworkPolicyCondition{{
+		Type:               v32.ProjectNetworkPolicyConditionRulesValid,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: later.Format(time.RFC3339),
+		Reason:             "InvalidRules",
+		Message:            "ingress rule 0: invalid port 0",
+	}}, pnp.Status.Conditions)
+}
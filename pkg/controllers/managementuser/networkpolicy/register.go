@@ -12,8 +12,7 @@
 // Register initializes the controllers and registers
 func Register(ctx context.Context, cluster *config.UserContext) {
 	starter := cluster.DeferredStart(ctx, func(ctx context.Context) error {
-		registerDeferred(ctx, cluster)
-		return nil
+		return registerDeferred(ctx, cluster)
 	})
 	clusters := cluster.Management.Management.Clusters("")
 	clusters.AddHandler(ctx, "networkpolicy-deferred", func(key string, obj *v3.Cluster) (runtime.Object, error) {
@@ -27,7 +26,7 @@ func Register(ctx context.Context, cluster *config.UserContext) {
 	})
 }
 
-func registerDeferred(ctx context.Context, cluster *config.UserContext) {
+func registerDeferred(ctx context.Context, cluster *config.UserContext) error {
 	logrus.Infof("Registering project network policy")
 
 	pnpLister := cluster.Management.Management.ProjectNetworkPolicies("").Controller().Lister()
@@ -39,6 +38,10 @@ func registerDeferred(ctx context.Context, cluster *config.UserContext) {
 	clusters := cluster.Management.Wrangler.Provisioning.Cluster().Cache()
 
 	nodeLister := cluster.Core.Nodes("").Controller().Lister()
+	endpointsLister, err := watchAPIServerEndpoints(ctx, cluster)
+	if err != nil {
+		return err
+	}
 	nsLister := cluster.Core.Namespaces("").Controller().Lister()
 	nses := cluster.Core.Namespaces("")
 	serviceLister := cluster.Core.Services("").Controller().Lister()
@@ -49,12 +52,12 @@ func registerDeferred(ctx context.Context, cluster *config.UserContext) {
 	npLister := cluster.Networking.NetworkPolicies("").Controller().Lister()
 	npClient := cluster.Networking
 
-	npmgr := &netpolMgr{clusterLister, clusters, nsLister, nodeLister, pods, projects,
-		npLister, npClient, projectLister, cluster.ClusterName}
+	npmgr := &netpolMgr{clusterLister, clusters, nsLister, nodeLister, endpointsLister, pods, projects,
+		npLister, npClient, projectLister, pnpLister, cluster.ClusterName}
 	ps := &projectSyncer{pnpLister, pnps, projects, clusterLister, cluster.ClusterName}
 	nss := &nsSyncer{npmgr, clusterLister, serviceLister, podLister,
 		services, pods, cluster.ClusterName}
-	pnpsyncer := &projectNetworkPolicySyncer{npmgr}
+	pnpsyncer := &projectNetworkPolicySyncer{npmgr, pnps}
 	podHandler := &podHandler{npmgr, pods, clusterLister, cluster.ClusterName}
 	serviceHandler := &serviceHandler{npmgr, clusterLister, cluster.ClusterName}
 	nodeHandler := &nodeHandler{npmgr, clusterLister, cluster.ClusterName}
@@ -74,4 +77,5 @@ func registerDeferred(ctx context.Context, cluster *config.UserContext) {
 
 	mgmtClusters.AddHandler(ctx, "clusterNetAnnHandler", clusterNetAnnHandler.Sync)
 	npClient.NetworkPolicies("").AddHandler(ctx, "netpol-handler", npmgr.SyncDefaultNetworkPolicies)
+	return nil
 }
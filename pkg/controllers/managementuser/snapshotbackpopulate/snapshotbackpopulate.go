@@ -78,7 +78,8 @@ func Register(ctx context.Context, userContext *config.UserContext) {
 }
 
 // OnUpstreamChange will check if the downstream snapshot CR exists for a given snapshot, and if it does not the local
-// representation is summarily deleted.
+// representation is summarily deleted. The downstream snapshot CR of snapshots expired by their etcd snapshot policy is
+// deleted.
 func (h *handler) OnUpstreamChange(_ string, snapshot *rkev1.ETCDSnapshot) (*rkev1.ETCDSnapshot, error) {
 	if snapshot == nil {
 		return nil, nil
@@ -113,7 +114,17 @@ func (h *handler) OnUpstreamChange(_ string, snapshot *rkev1.ETCDSnapshot) (*rke
 		return snapshot, nil
 	}
 
-	_, err = h.etcdSnapshotFileController.Get(snapshot.Annotations[capr.SnapshotNameAnnotation], metav1.GetOptions{})
+	downstream, err := h.etcdSnapshotFileController.Get(snapshot.Annotations[capr.SnapshotNameAnnotation], metav1.GetOptions{})
+	if err == nil && snapshot.Status.Expired && downstream.DeletionTimestamp.IsZero() {
+		// The snapshot is no longer kept by the retention of its etcd snapshot policy. Deleting the downstream object
+		// deletes the snapshot file, after which the local version is deleted as well.
+		logrus.Infof("%s deleting expired snapshot %s of etcd snapshot policy %s", logPrefix, snapshot.Name, snapshot.Status.Policy)
+		if err := h.etcdSnapshotFileController.Delete(downstream.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return snapshot, err
+		}
+		h.etcdSnapshotController.EnqueueAfter(snapshot.Namespace, snapshot.Name, 1*time.Minute)
+		return snapshot, nil
+	}
 	if apierrors.IsNotFound(err) {
 		// If the downstream snapshot does not exist in the downstream cluster, delete the local version
 		logrus.Debugf("%s deleting snapshot %s", logPrefix, snapshot.Name)
@@ -294,6 +294,62 @@ func TestOnUpstreamChange(t *testing.T) {
 			},
 			expectErr: false,
 		},
+		{
+			name: "expired snapshot",
+			snapshot: rkev1.NewETCDSnapshot("test-namespace", "test-snapshot", rkev1.ETCDSnapshot{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						capr.SnapshotNameAnnotation: "test-snapshot-downstream",
+					},
+					Labels: map[string]string{
+						capr.ClusterNameLabel: "test-cluster",
+					},
+				},
+				Status: rkev1.ETCDSnapshotStatus{
+					Policy:  "test-policy",
+					Expired: true,
+				},
+			}),
+			handlerFunc: func(ctrl *gomock.Controller) handler {
+				clusterCache := fake.NewMockCacheInterface[*provv1.Cluster](ctrl)
+				cluster := &provv1.Cluster{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "test-namespace",
+						Name:      "test-cluster",
+					},
+					Status: provv1.ClusterStatus{
+						ClusterName: "test-mgmt-cluster",
+					},
+				}
+				clusterCache.EXPECT().GetByIndex(cluster2.ByCluster, cluster.Status.ClusterName).Return([]*provv1.Cluster{cluster}, nil)
+				controlPlaneCache := fake.NewMockCacheInterface[*rkev1.RKEControlPlane](ctrl)
+				controlPlane := &rkev1.RKEControlPlane{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "test-namespace",
+						Name:      "test-cluster",
+					},
+				}
+				controlPlaneCache.EXPECT().Get(cluster.Namespace, cluster.Name).Return(controlPlane, nil)
+				etcdSnapshotFileController := fake.NewMockNonNamespacedControllerInterface[*k3s.ETCDSnapshotFile, *k3s.ETCDSnapshotFileList](ctrl)
+				etcdSnapshotFileController.EXPECT().Get("test-snapshot-downstream", gomock.Any()).Return(&k3s.ETCDSnapshotFile{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test-snapshot-downstream",
+					},
+				}, nil)
+				etcdSnapshotFileController.EXPECT().Delete("test-snapshot-downstream", gomock.Any()).Return(nil)
+				etcdSnapshotController := fake.NewMockControllerInterface[*rkev1.ETCDSnapshot, *rkev1.ETCDSnapshotList](ctrl)
+				etcdSnapshotController.EXPECT().EnqueueAfter(cluster.Namespace, "test-snapshot", gomock.Any())
+				h := handler{
+					clusterName:                cluster.Status.ClusterName,
+					clusterCache:               clusterCache,
+					controlPlaneCache:          controlPlaneCache,
+					etcdSnapshotFileController: etcdSnapshotFileController,
+					etcdSnapshotController:     etcdSnapshotController,
+				}
+				return h
+			},
+			expectErr: false,
+		},
 		{
 			name: "downstream snapshot does not exist",
 			snapshot: rkev1.NewETCDSnapshot("test-namespace", "test-snapshot", rkev1.ETCDSnapshot{
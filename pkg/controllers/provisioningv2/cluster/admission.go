@@ -0,0 +1,67 @@
+package cluster
+
+import (
+	"github.com/rancher/rancher/pkg/admissionpolicy"
+	v1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	etcdSnapshotRestoreSetID = "etcd-snapshot-restore-source-admission"
+
+	// etcdSnapshotRestoreClusterNameExpression only allows restoring an etcd snapshot of a cluster in the same
+	// namespace, which is where the snapshot and the state of the source cluster are looked up. The name must not
+	// reference another namespace, as the permissions of the requester are only checked in the namespace of the
+	// restored cluster.
+	etcdSnapshotRestoreClusterNameExpression = `variables.sourceClusterName == '' ||
+variables.sourceClusterName.matches('^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$')`
+
+	// etcdSnapshotRestoreSourceExpression only allows restoring an etcd snapshot of another cluster by users who can
+	// read that snapshot and update the cluster it was taken from, as the restored cluster adopts its tokens and
+	// data. Updates which keep the restore unchanged are allowed, so that the cluster can still be managed by anyone
+	// who can edit it.
+	etcdSnapshotRestoreSourceExpression = `variables.sourceClusterName == '' ||
+variables.sourceClusterName == object.metadata.name ||
+(variables.sourceClusterName == variables.oldSourceClusterName && variables.snapshotName == variables.oldSnapshotName) ||
+(authorizer.group('rke.cattle.io').resource('etcdsnapshots').namespace(object.metadata.namespace).name(variables.snapshotName).check('get').allowed() &&
+authorizer.group('provisioning.cattle.io').resource('clusters').namespace(object.metadata.namespace).name(variables.sourceClusterName).check('update').allowed())`
+)
+
+// etcdSnapshotRestoreAdmissionPolicy restricts who can restore an etcd snapshot of another cluster. Otherwise, anyone
+// who can edit a cluster could clone the data and tokens of any cluster in the same namespace into it.
+var etcdSnapshotRestoreAdmissionPolicy = admissionpolicy.Policy{
+	Name:     "rancher-etcd-snapshot-restore-source",
+	Resource: v1.SchemeGroupVersion.WithResource("clusters"),
+	Variables: []admissionv1.Variable{
+		{
+			Name:       "sourceClusterName",
+			Expression: "has(object.spec.rkeConfig) && has(object.spec.rkeConfig.etcdSnapshotRestore) && has(object.spec.rkeConfig.etcdSnapshotRestore.clusterName) ? object.spec.rkeConfig.etcdSnapshotRestore.clusterName : ''",
+		},
+		{
+			Name:       "snapshotName",
+			Expression: "has(object.spec.rkeConfig) && has(object.spec.rkeConfig.etcdSnapshotRestore) && has(object.spec.rkeConfig.etcdSnapshotRestore.name) ? object.spec.rkeConfig.etcdSnapshotRestore.name : ''",
+		},
+		{
+			Name:       "oldSourceClusterName",
+			Expression: "oldObject != null && has(oldObject.spec.rkeConfig) && has(oldObject.spec.rkeConfig.etcdSnapshotRestore) && has(oldObject.spec.rkeConfig.etcdSnapshotRestore.clusterName) ? oldObject.spec.rkeConfig.etcdSnapshotRestore.clusterName : ''",
+		},
+		{
+			Name:       "oldSnapshotName",
+			Expression: "oldObject != null && has(oldObject.spec.rkeConfig) && has(oldObject.spec.rkeConfig.etcdSnapshotRestore) && has(oldObject.spec.rkeConfig.etcdSnapshotRestore.name) ? oldObject.spec.rkeConfig.etcdSnapshotRestore.name : ''",
+		},
+	},
+	Validations: []admissionv1.Validation{
+		{
+			Expression:        etcdSnapshotRestoreClusterNameExpression,
+			MessageExpression: "'etcdSnapshotRestore.clusterName ' + variables.sourceClusterName + ' must be the name of a cluster in the same namespace'",
+			Reason:            ptr.To(metav1.StatusReasonInvalid),
+		},
+		{
+			Expression:        etcdSnapshotRestoreSourceExpression,
+			MessageExpression: "'restoring etcd snapshot ' + variables.snapshotName + ' of cluster ' + variables.sourceClusterName + ' requires permission to get the snapshot and to update that cluster'",
+			Reason:            ptr.To(metav1.StatusReasonForbidden),
+		},
+	},
+}
@@ -0,0 +1,160 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rancher/rancher/pkg/admissionpolicy/admissionpolicytest"
+	v1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/admission/plugin/policy/validating"
+	celconfig "k8s.io/apiserver/pkg/apis/cel"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+)
+
+func TestEtcdSnapshotRestoreAdmissionPolicy(t *testing.T) {
+	policy := etcdSnapshotRestoreAdmissionPolicy.Objects()[0].(*admissionv1.ValidatingAdmissionPolicy)
+	validator := admissionpolicytest.Compile(t, policy)
+
+	// u-owner can get the snapshots of and update the source cluster, u-reader can only get its snapshots, u-admin can
+	// do anything.
+	authz := authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+		if a.GetUser().GetName() == "u-admin" {
+			return authorizer.DecisionAllow, "", nil
+		}
+		if a.GetNamespace() != "fleet-default" {
+			return authorizer.DecisionDeny, "", nil
+		}
+		switch {
+		case a.GetAPIGroup() == "rke.cattle.io" && a.GetResource() == "etcdsnapshots" && a.GetName() == "source-snapshot" && a.GetVerb() == "get":
+			if a.GetUser().GetName() == "u-owner" || a.GetUser().GetName() == "u-reader" {
+				return authorizer.DecisionAllow, "", nil
+			}
+		case a.GetAPIGroup() == "provisioning.cattle.io" && a.GetResource() == "clusters" && a.GetName() == "source" && a.GetVerb() == "update":
+			if a.GetUser().GetName() == "u-owner" {
+				return authorizer.DecisionAllow, "", nil
+			}
+		}
+		return authorizer.DecisionDeny, "", nil
+	})
+	cluster := func(snapshotName, clusterName string) *v1.Cluster {
+		c := &v1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "target", Namespace: "fleet-default"},
+			Spec: v1.ClusterSpec{
+				RKEConfig: &v1.RKEConfig{},
+			},
+		}
+		if snapshotName != "" {
+			c.Spec.RKEConfig.ETCDSnapshotRestore = &rkev1.ETCDSnapshotRestore{
+				Name:        snapshotName,
+				ClusterName: clusterName,
+			}
+		}
+		return c
+	}
+
+	tests := map[string]struct {
+		requester  string
+		operation  admission.Operation
+		object     *v1.Cluster
+		oldObject  *v1.Cluster
+		wantReason metav1.StatusReason
+	}{
+		"create without restore": {
+			requester: "u-other",
+			operation: admission.Create,
+			object:    cluster("", ""),
+		},
+		"restore of a snapshot of the same cluster": {
+			requester: "u-other",
+			operation: admission.Update,
+			object:    cluster("target-snapshot", ""),
+			oldObject: cluster("", ""),
+		},
+		"restore of a snapshot of the cluster itself": {
+			requester: "u-other",
+			operation: admission.Update,
+			object:    cluster("target-snapshot", "target"),
+			oldObject: cluster("", ""),
+		},
+		"restore of a snapshot of another cluster by its owner": {
+			requester: "u-owner",
+			operation: admission.Create,
+			object:    cluster("source-snapshot", "source"),
+		},
+		"restore of a snapshot of another cluster by a user who can only read the snapshot": {
+			requester:  "u-reader",
+			operation:  admission.Update,
+			object:     cluster("source-snapshot", "source"),
+			oldObject:  cluster("", ""),
+			wantReason: metav1.StatusReasonForbidden,
+		},
+		"restore of a snapshot of another cluster by another user": {
+			requester:  "u-other",
+			operation:  admission.Create,
+			object:     cluster("source-snapshot", "source"),
+			wantReason: metav1.StatusReasonForbidden,
+		},
+		"update keeping the restore": {
+			requester: "u-other",
+			operation: admission.Update,
+			object:    cluster("source-snapshot", "source"),
+			oldObject: cluster("source-snapshot", "source"),
+		},
+		"update changing the restored snapshot": {
+			requester:  "u-other",
+			operation:  admission.Update,
+			object:     cluster("other-snapshot", "source"),
+			oldObject:  cluster("source-snapshot", "source"),
+			wantReason: metav1.StatusReasonForbidden,
+		},
+		"restore of a snapshot of a cluster in another namespace": {
+			requester:  "u-admin",
+			operation:  admission.Create,
+			object:     cluster("source-snapshot", "other-namespace/source"),
+			wantReason: metav1.StatusReasonInvalid,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			gvk := v1.SchemeGroupVersion.WithKind("Cluster")
+			gvr := v1.SchemeGroupVersion.WithResource("clusters")
+			var oldObject runtime.Object
+			if tt.oldObject != nil {
+				oldObject = tt.oldObject
+			}
+			attr := admission.NewAttributesRecord(tt.object, oldObject, gvk, tt.object.Namespace, tt.object.Name, gvr, "", tt.operation, nil, false, &user.DefaultInfo{Name: tt.requester})
+			versionedAttr := &admission.VersionedAttributes{
+				Attributes:         attr,
+				VersionedKind:      gvk,
+				VersionedObject:    tt.object,
+				VersionedOldObject: oldObject,
+			}
+
+			result := validator.Validate(context.Background(), gvr, versionedAttr, nil, nil, celconfig.RuntimeCELCostBudget, authz)
+			require.Len(t, result.Decisions, len(policy.Spec.Validations))
+			var denied []validating.PolicyDecision
+			for _, decision := range result.Decisions {
+				if decision.Action != validating.ActionAdmit {
+					denied = append(denied, decision)
+				}
+			}
+			if tt.wantReason == "" {
+				assert.Empty(t, denied)
+				return
+			}
+			require.Len(t, denied, 1)
+			assert.Equal(t, validating.ActionDeny, denied[0].Action, denied[0].Message)
+			assert.Equal(t, tt.wantReason, denied[0].Reason)
+			assert.Contains(t, denied[0].Message, tt.object.Spec.RKEConfig.ETCDSnapshotRestore.ClusterName)
+		})
+	}
+}
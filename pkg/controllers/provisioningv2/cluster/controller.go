@@ -8,6 +8,7 @@
 	"strings"
 
 	"github.com/rancher/norman/types/convert"
+	"github.com/rancher/rancher/pkg/admissionpolicy"
 	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	v1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
 	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
@@ -83,6 +84,8 @@ type handler struct {
 func Register(
 	ctx context.Context,
 	clients *wrangler.Context, kubeconfigManager *kubeconfig.Manager) {
+	admissionpolicy.Apply(ctx, clients.Apply, etcdSnapshotRestoreSetID, etcdSnapshotRestoreAdmissionPolicy)
+
 	h := handler{
 		mgmtClusterCache:      clients.Mgmt.Cluster().Cache(),
 		mgmtClusters:          clients.Mgmt.Cluster(),
@@ -10,12 +10,15 @@
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	apimgmtv3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	provv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/capr"
 	mgmtcluster "github.com/rancher/rancher/pkg/cluster"
 	fleetconst "github.com/rancher/rancher/pkg/fleet"
 	fleetpkg "github.com/rancher/rancher/pkg/fleet"
 	fleetcontrollers "github.com/rancher/rancher/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
 	v3 "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
 	rocontrollers "github.com/rancher/rancher/pkg/generated/controllers/provisioning.cattle.io/v1"
+	rkecontrollers "github.com/rancher/rancher/pkg/generated/controllers/rke.cattle.io/v1"
 	"github.com/rancher/rancher/pkg/provisioningv2/image"
 	"github.com/rancher/rancher/pkg/settings"
 	"github.com/rancher/rancher/pkg/taints"
@@ -26,6 +29,8 @@
 	"github.com/rancher/wrangler/v3/pkg/yaml"
 	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation"
@@ -56,6 +61,8 @@ type handler struct {
 	secretsController corecontrollers.SecretController
 	nodesController   corecontrollers.NodeController
 	fleetClusters     fleetcontrollers.ClusterController
+	fleetClusterCache fleetcontrollers.ClusterCache
+	rkeControlPlanes  rkecontrollers.RKEControlPlaneCache
 	apply             apply.Apply
 	getPrivateRepoURL func(*provv1.Cluster, *apimgmtv3.Cluster) string
 }
@@ -74,6 +81,8 @@ func Register(ctx context.Context, clients *wrangler.Context) {
 		secretsController: clients.Core.Secret(),
 		nodesController:   clients.Core.Node(),
 		fleetClusters:     clients.Fleet.Cluster(),
+		fleetClusterCache: clients.Fleet.Cluster().Cache(),
+		rkeControlPlanes:  clients.RKE.RKEControlPlane().Cache(),
 		apply:             clients.Apply.WithCacheTypes(clients.Provisioning.Cluster()),
 	}
 
@@ -245,6 +254,11 @@ func (h *handler) createCluster(cluster *provv1.Cluster, status provv1.ClusterSt
 		return nil, status, err
 	}
 
+	redeployAgentGeneration, err := h.redeployAgentGeneration(cluster, mgmtCluster.Spec.FleetWorkspaceName)
+	if err != nil {
+		return nil, status, err
+	}
+
 	return append(objs, &fleet.Cluster{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        cluster.Name,
@@ -261,10 +275,45 @@ func (h *handler) createCluster(cluster *provv1.Cluster, status provv1.ClusterSt
 			AgentTolerations:          tolerations,
 			AgentAffinity:             agentAffinity,
 			AgentResources:            mgmtcluster.GetFleetAgentResourceRequirements(mgmtCluster),
+			RedeployAgentGeneration:   redeployAgentGeneration,
 		},
 	}), status, nil
 }
 
+// redeployAgentGeneration returns the generation at which fleet is requested to redeploy its agent into the cluster.
+// The fleet agent registration restored from an etcd snapshot of another cluster belongs to that cluster and is removed
+// during the restore, so the agent is redeployed once such a restore has finished.
+func (h *handler) redeployAgentGeneration(cluster *provv1.Cluster, fleetWorkspace string) (int64, error) {
+	if cluster.Spec.RKEConfig == nil {
+		return 0, nil
+	}
+
+	var generation int64
+	fleetCluster, err := h.fleetClusterCache.Get(fleetWorkspace, cluster.Name)
+	if err == nil {
+		generation = fleetCluster.Spec.RedeployAgentGeneration
+	} else if !apierrors.IsNotFound(err) {
+		return 0, err
+	}
+
+	restore := cluster.Spec.RKEConfig.ETCDSnapshotRestore
+	if !capr.IsCrossClusterRestore(restore, cluster.Name) || int64(restore.Generation) <= generation {
+		return generation, nil
+	}
+
+	cp, err := h.rkeControlPlanes.Get(cluster.Namespace, cluster.Name)
+	if apierrors.IsNotFound(err) {
+		return generation, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	if cp.Status.ETCDSnapshotRestorePhase == rkev1.ETCDSnapshotPhaseFinished && equality.Semantic.DeepEqual(cp.Status.ETCDSnapshotRestore, restore) {
+		return int64(restore.Generation), nil
+	}
+	return generation, nil
+}
+
 // addAPIServer populates the internal API server URL and CA into the provided secret, which should be used as the
 // KubeConfig secret in the local cluster.
 func (h *handler) addAPIServer(clientSecret string) {
@@ -8,14 +8,17 @@
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	apimgmtv3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	provv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
 	v3 "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
 	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
 	"github.com/rancher/wrangler/v3/pkg/generic/fake"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 var (
@@ -752,6 +755,93 @@ func TestCreateCluster(t *testing.T) {
 	}
 }
 
+func TestRedeployAgentGeneration(t *testing.T) {
+	restore := &rkev1.ETCDSnapshotRestore{Name: "snapshot", Generation: 3, ClusterName: "prod"}
+	notFound := apierrors.NewNotFound(schema.GroupResource{}, "staging")
+
+	tests := []struct {
+		name         string
+		restore      *rkev1.ETCDSnapshotRestore
+		fleetCluster *fleet.Cluster
+		controlPlane *rkev1.RKEControlPlane
+		expected     int64
+	}{
+		{
+			name:     "no restore",
+			expected: 0,
+		},
+		{
+			name:         "keeps existing generation",
+			fleetCluster: &fleet.Cluster{Spec: fleet.ClusterSpec{RedeployAgentGeneration: 2}},
+			expected:     2,
+		},
+		{
+			name:     "restore of the same cluster",
+			restore:  &rkev1.ETCDSnapshotRestore{Name: "snapshot", Generation: 3},
+			expected: 0,
+		},
+		{
+			name:    "restore of another cluster in progress",
+			restore: restore,
+			controlPlane: &rkev1.RKEControlPlane{Status: rkev1.RKEControlPlaneStatus{
+				ETCDSnapshotRestore:      restore,
+				ETCDSnapshotRestorePhase: rkev1.ETCDSnapshotPhaseRestore,
+			}},
+			expected: 0,
+		},
+		{
+			name:    "restore of another cluster finished",
+			restore: restore,
+			controlPlane: &rkev1.RKEControlPlane{Status: rkev1.RKEControlPlaneStatus{
+				ETCDSnapshotRestore:      restore,
+				ETCDSnapshotRestorePhase: rkev1.ETCDSnapshotPhaseFinished,
+			}},
+			expected: 3,
+		},
+		{
+			name:         "agent already redeployed for restore",
+			restore:      restore,
+			fleetCluster: &fleet.Cluster{Spec: fleet.ClusterSpec{RedeployAgentGeneration: 3}},
+			expected:     3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			fleetClusterCache := fake.NewMockCacheInterface[*fleet.Cluster](ctrl)
+			fleetClusterCache.EXPECT().Get("fleet-default", "staging").DoAndReturn(func(string, string) (*fleet.Cluster, error) {
+				if tt.fleetCluster == nil {
+					return nil, notFound
+				}
+				return tt.fleetCluster, nil
+			})
+			rkeControlPlanes := fake.NewMockCacheInterface[*rkev1.RKEControlPlane](ctrl)
+			rkeControlPlanes.EXPECT().Get("fleet-default", "staging").DoAndReturn(func(string, string) (*rkev1.RKEControlPlane, error) {
+				if tt.controlPlane == nil {
+					return nil, notFound
+				}
+				return tt.controlPlane, nil
+			}).AnyTimes()
+
+			h := &handler{
+				fleetClusterCache: fleetClusterCache,
+				rkeControlPlanes:  rkeControlPlanes,
+			}
+			cluster := &provv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "staging", Namespace: "fleet-default"},
+				Spec: provv1.ClusterSpec{
+					RKEConfig: &provv1.RKEConfig{ETCDSnapshotRestore: tt.restore},
+				},
+			}
+
+			generation, err := h.redeployAgentGeneration(cluster, "fleet-default")
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, generation)
+		})
+	}
+}
+
 func newMgmtCluster(
 	name string,
 	labels map[string]string,
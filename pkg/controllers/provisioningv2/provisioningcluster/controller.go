@@ -305,6 +305,30 @@ func (h *handler) OnRancherClusterChange(obj *rancherv1.Cluster, status rancherv
 
 	// If the rkecontrolplane is not nil, we can check it to determine action items.
 	if rkeCP != nil {
+		// If the planner requested the rollback of a failed upgrade, restore the etcd snapshot taken before the upgrade
+		// along with the Kubernetes version it was taken with.
+		if upgrade := rkeCP.Status.UpgradeRollback; upgrade != nil &&
+			upgrade.Phase == rkev1.UpgradeRollbackPhaseRollbackRequested &&
+			upgrade.SnapshotName != "" &&
+			(obj.Spec.RKEConfig.ETCDSnapshotRestore == nil || obj.Spec.RKEConfig.ETCDSnapshotRestore.Name != upgrade.SnapshotName) {
+			generation := 1
+			if obj.Spec.RKEConfig.ETCDSnapshotRestore != nil {
+				generation = obj.Spec.RKEConfig.ETCDSnapshotRestore.Generation + 1
+			}
+			logrus.Infof("rkecluster %s/%s: rolling back upgrade of Kubernetes from %s to %s by restoring etcd snapshot %s", obj.Namespace, obj.Name, upgrade.FromKubernetesVersion, upgrade.ToKubernetesVersion, upgrade.SnapshotName)
+			obj = obj.DeepCopy()
+			obj.Spec.RKEConfig.ETCDSnapshotRestore = &rkev1.ETCDSnapshotRestore{
+				Name:             upgrade.SnapshotName,
+				Generation:       generation,
+				RestoreRKEConfig: restoreRKEConfigKubernetesVersion,
+			}
+			_, err = h.clusterController.Update(obj)
+			if err == nil {
+				err = generic.ErrSkip // if update was successful, return ErrSkip waiting for caches to sync
+			}
+			return nil, status, err
+		}
+
 		// If EtcdSnapshotRestore is not nil, we need to check to see if we need to update the cluster object it.
 		if obj.Spec.RKEConfig.ETCDSnapshotRestore != nil &&
 			obj.Spec.RKEConfig.ETCDSnapshotRestore.Name != "" &&
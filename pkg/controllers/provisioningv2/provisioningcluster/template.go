@@ -525,6 +525,7 @@ func rkeControlPlane(cluster *rancherv1.Cluster) (*rkev1.RKEControlPlane, error)
 	// set the corresponding specification for various operations to nil as these cause unnecessary reconciliation.
 	filteredClusterSpec.RKEConfig.ETCDSnapshotRestore = nil
 	filteredClusterSpec.RKEConfig.ETCDSnapshotCreate = nil
+	filteredClusterSpec.RKEConfig.ETCDSnapshotVerify = nil
 	filteredClusterSpec.RKEConfig.RotateEncryptionKeys = nil
 	filteredClusterSpec.RKEConfig.RotateCertificates = nil
 	b64GZCluster, err := capr.CompressInterface(filteredClusterSpec)
@@ -533,6 +534,15 @@ func rkeControlPlane(cluster *rancherv1.Cluster) (*rkev1.RKEControlPlane, error)
 		return nil, err
 	}
 	rkeConfig := cluster.Spec.RKEConfig.DeepCopy()
+	annotations := map[string]string{
+		capr.ClusterSpecAnnotation: b64GZCluster,
+	}
+	// annotations controlling how the planner delivers plan changes are set on the cluster by users
+	for _, annotation := range []string{capr.PlanDryRunAnnotation, capr.MaintenanceWindowOverrideAnnotation, capr.UpgradeSnapshotRetryAnnotation, capr.UpgradeSnapshotSkipAnnotation} {
+		if value, ok := cluster.Annotations[annotation]; ok {
+			annotations[annotation] = value
+		}
+	}
 	return &rkev1.RKEControlPlane{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      cluster.Name,
@@ -540,15 +550,14 @@ func rkeControlPlane(cluster *rancherv1.Cluster) (*rkev1.RKEControlPlane, error)
 			Labels: map[string]string{
 				capr.InitNodeMachineIDLabel: cluster.Labels[capr.InitNodeMachineIDLabel],
 			},
-			Annotations: map[string]string{
-				capr.ClusterSpecAnnotation: b64GZCluster,
-			},
+			Annotations: annotations,
 		},
 		Spec: rkev1.RKEControlPlaneSpec{
 			ClusterConfiguration:     rkeConfig.ClusterConfiguration,
 			LocalClusterAuthEndpoint: *cluster.Spec.LocalClusterAuthEndpoint.DeepCopy(),
 			ETCDSnapshotRestore:      rkeConfig.ETCDSnapshotRestore,
 			ETCDSnapshotCreate:       rkeConfig.ETCDSnapshotCreate,
+			ETCDSnapshotVerify:       rkeConfig.ETCDSnapshotVerify,
 			RotateCertificates:       rkeConfig.RotateCertificates,
 			RotateEncryptionKeys:     rkeConfig.RotateEncryptionKeys,
 			KubernetesVersion:        cluster.Spec.KubernetesVersion,
@@ -95,6 +95,7 @@ func RKE2CRDs() []string {
 	return []string{
 		"clusters.provisioning.cattle.io",
 		"custommachines.rke.cattle.io",
+		"etcdsnapshotpolicies.rke.cattle.io",
 		"etcdsnapshots.rke.cattle.io",
 		"rkebootstraps.rke.cattle.io",
 		"rkebootstraptemplates.rke.cattle.io",
@@ -143,6 +144,7 @@ func ClusterAuthCRDs() []string {
 // MCMCRDs returns a list of CRD names needed for Multi Cluster Management.
 func MCMCRDs() []string {
 	return []string{
+		"accessrequests.management.cattle.io",
 		"authconfigs.management.cattle.io",
 		"clusters.management.cattle.io",
 		"clusterregistrationtokens.management.cattle.io",
@@ -201,6 +203,7 @@ func SCCRegistrationCRDs() []string {
 
 // MigratedResources map list of resource that have been migrated after all resource have a CRD this can be removed.
 var MigratedResources = map[string]bool{
+	"accessrequests.management.cattle.io":                             true,
 	"activedirectoryproviders.management.cattle.io":                   false,
 	"apiservices.management.cattle.io":                                false,
 	"apps.catalog.cattle.io":                                          false,
@@ -227,6 +230,7 @@ func SCCRegistrationCRDs() []string {
 	"custommachines.rke.cattle.io":                                    true,
 	"dockercredentials.project.cattle.io":                             false,
 	"dynamicschemas.management.cattle.io":                             true,
+	"etcdsnapshotpolicies.rke.cattle.io":                              true,
 	"etcdsnapshots.rke.cattle.io":                                     true,
 	"extensionconfigs.runtime.cluster.x-k8s.io":                       false,
 	"features.management.cattle.io":                                   false,
@@ -111,6 +111,7 @@ func rke2() []crd.CRD {
 			}
 			return clusterIndexed(c)
 		}),
+		newRKECRD(&rkev1.ETCDSnapshotPolicy{}, clusterIndexed),
 	}
 }
 
@@ -55,6 +55,7 @@ func addRoles(wrangler *wrangler.Context, management *config.ManagementContext)
 	rb.addRole("Manage Users", "users-manage").
 		addNamespacedRule(pbkdf2.LocalUserPasswordsNamespace).addRule().apiGroups("").resources("secrets").verbs("create").
 		addRule().apiGroups("ext.cattle.io").resources("groupmembershiprefreshrequests").verbs("create").
+		addRule().apiGroups("ext.cattle.io").resources("effectivepermissionsreviews").verbs("create", "review").
 		addRule().apiGroups("management.cattle.io").resources("users", "globalrolebindings").verbs("*").
 		addRule().apiGroups("management.cattle.io").resources("globalroles").verbs("get", "list", "watch")
 	rb.addRole("Manage Roles", "roles-manage").
@@ -67,6 +68,13 @@ func addRoles(wrangler *wrangler.Context, management *config.ManagementContext)
 		addRule().apiGroups("management.cattle.io").resources("features").verbs("get", "list", "watch", "update")
 	rb.addRole("View Rancher Metrics", "view-rancher-metrics").
 		addRule().apiGroups("management.cattle.io").resources("ranchermetrics").verbs("get")
+	rb.addRole("Approve Access Requests", "access-requests-approve").
+		addRule().apiGroups("management.cattle.io").resources("accessrequests").verbs("get", "list", "watch", "approve").
+		addRule().apiGroups("ext.cattle.io").resources("accessrequestdecisions").verbs("create")
+	rb.addRole("Replay Session Recordings", "session-recordings-replay").
+		addRule().apiGroups("management.cattle.io").resources("sessionrecordings").verbs("get", "list")
+	rb.addRole("Generate Support Bundles", "support-bundles-generate").
+		addRule().apiGroups("management.cattle.io").resources("supportbundles").verbs("get")
 	if features.OIDCProvider.Enabled() {
 		rb.addRole("Manage OIDC Clients", "manage-oidc-clients").
 			addRule().apiGroups("management.cattle.io").resources("oidcclients").verbs("get", "list", "patch", "create", "update", "watch", "delete", "deletecollection")
@@ -82,6 +90,7 @@ func addRoles(wrangler *wrangler.Context, management *config.ManagementContext)
 		addRule().apiGroups("ext.cattle.io").resources("useractivities").verbs("get", "create").
 		addRule().apiGroups("ext.cattle.io").resources("selfusers").verbs("create").
 		addRule().apiGroups("ext.cattle.io").resources("passwordchangerequests").verbs("create").
+		addRule().apiGroups("ext.cattle.io").resources("effectivepermissionsreviews").verbs("create").
 		addRule().apiGroups("ext.cattle.io").resources("kubeconfigs").verbs("get", "list", "watch", "create", "delete", "deletecollection", "update", "patch").
 		// standard permissions for regular users, on their tokens
 		// Note: The ext token store applies additional restrictions. A user can see and manipulate only their own tokens.
@@ -89,6 +98,7 @@ func addRoles(wrangler *wrangler.Context, management *config.ManagementContext)
 		addRule().apiGroups("management.cattle.io").resources("preferences").verbs("*").
 		addRule().apiGroups("management.cattle.io").resources("settings").verbs("get", "list", "watch").
 		addRule().apiGroups("management.cattle.io").resources("features").verbs("get", "list", "watch").
+		addRule().apiGroups("management.cattle.io").resources("accessrequests").verbs("create").
 		addRule().apiGroups("management.cattle.io").resources("rancherusernotifications").verbs("get", "list", "watch")
 
 	// TODO user should be dynamically authorized to only see herself
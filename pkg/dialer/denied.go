@@ -0,0 +1,120 @@
+package dialer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rancher/rancher/pkg/agent/tunnel"
+	"github.com/rancher/rancher/pkg/types/config/dialer"
+	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// deniedDialEventInterval is the minimum interval between two events for dials to the same destination of a cluster.
+const deniedDialEventInterval = 5 * time.Minute
+
+// deniedDialReporter reports the dials denied by the tunnel dial policy of clusters. The agent refuses these dials
+// with an error that is only seen once the connection is read or written to.
+type deniedDialReporter struct {
+	events corecontrollers.EventClient
+
+	lock     sync.Mutex
+	reported map[string]time.Time
+}
+
+func newDeniedDialReporter(events corecontrollers.EventClient) *deniedDialReporter {
+	return &deniedDialReporter{
+		events:   events,
+		reported: map[string]time.Time{},
+	}
+}
+
+// wrap returns a dialer reporting the connections of the cluster denied by the tunnel dial policy. Connections which are
+// not tunneled are never denied, so any dialer of the cluster can be wrapped.
+func (r *deniedDialReporter) wrap(clusterName string, d dialer.Dialer) dialer.Dialer {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		conn, err := d(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+		return &deniedDialConn{
+			Conn: conn,
+			report: func() {
+				r.report(clusterName, address)
+			},
+		}, nil
+	}
+}
+
+func (r *deniedDialReporter) report(clusterName, address string) {
+	TunnelDialsDenied.With(prometheus.Labels{"cluster": clusterName}).Inc()
+
+	now := time.Now()
+	key := clusterName + "/" + address
+	r.lock.Lock()
+	if last, ok := r.reported[key]; ok && now.Sub(last) < deniedDialEventInterval {
+		r.lock.Unlock()
+		return
+	}
+	for k, last := range r.reported {
+		if now.Sub(last) >= deniedDialEventInterval {
+			delete(r.reported, k)
+		}
+	}
+	r.reported[key] = now
+	r.lock.Unlock()
+
+	// the event is recorded in the namespace of the cluster, which only the members of the cluster can read
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: clusterName + ".",
+			Namespace:    clusterName,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: "management.cattle.io/v3",
+			Kind:       "Cluster",
+			Name:       clusterName,
+		},
+		Reason:         "TunnelDialDenied",
+		Message:        fmt.Sprintf("Dial to %s was denied by the tunnel dial policy of the cluster", address),
+		Type:           corev1.EventTypeWarning,
+		Source:         corev1.EventSource{Component: "rancher"},
+		FirstTimestamp: metav1.NewTime(now),
+		LastTimestamp:  metav1.NewTime(now),
+		Count:          1,
+	}
+	if _, err := r.events.Create(event); err != nil {
+		logrus.Debugf("dialerFactory: failed to record denied dial to [%s] of cluster [%s]: %v", address, clusterName, err)
+	}
+}
+
+// deniedDialConn is a tunneled connection calling report once if it fails because the agent denied the dial.
+type deniedDialConn struct {
+	net.Conn
+	report func()
+	once   sync.Once
+}
+
+func (c *deniedDialConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.check(err)
+	return n, err
+}
+
+func (c *deniedDialConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.check(err)
+	return n, err
+}
+
+func (c *deniedDialConn) check(err error) {
+	if tunnel.IsDenied(err) {
+		c.once.Do(c.report)
+	}
+}
@@ -33,6 +33,7 @@ func NewFactory(apiContext *config.ScaledContext, wrangler *wrangler.Context) (*
 		clusterLister: apiContext.Management.Clusters("").Controller().Lister(),
 		TunnelServer:  wrangler.TunnelServer,
 		dialHolders:   map[string]*transport.DialHolder{},
+		deniedDials:   newDeniedDialReporter(wrangler.Core.Event()),
 	}, nil
 }
 
@@ -42,17 +43,20 @@ type Factory struct {
 
 	dialHolders     map[string]*transport.DialHolder
 	dialHoldersLock sync.RWMutex
+
+	deniedDials *deniedDialReporter
 }
 
 func (f *Factory) ClusterDialer(clusterName string, retryOnError bool) (dialer.Dialer, error) {
-	return func(ctx context.Context, network, address string) (net.Conn, error) {
+	// all dialers of the cluster are wrapped, so that every dial denied by its tunnel dial policy is reported
+	return f.deniedDials.wrap(clusterName, func(ctx context.Context, network, address string) (net.Conn, error) {
 		d, err := f.clusterDialer(clusterName, address, retryOnError)
 		if err != nil {
 			logrus.Debugf(WaitForAgentError, clusterName)
 			return nil, err
 		}
 		return d(ctx, network, address)
-	}, nil
+	}), nil
 }
 
 func (f *Factory) ClusterDialHolder(clusterName string, retryOnError bool) (*transport.DialHolder, error) {
@@ -0,0 +1,16 @@
+package dialer
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TunnelDialsDenied counts the dials through the tunnel of cluster agents denied by the tunnel dial policy of their
+// cluster.
+var TunnelDialsDenied = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Subsystem: "cluster_manager",
+		Name:      "tunnel_dials_denied_total",
+		Help:      "Number of dials through the tunnel of a cluster agent denied by the tunnel dial policy of the cluster",
+	},
+	[]string{"cluster"},
+)
@@ -0,0 +1,211 @@
+// accessrequestdecision implements the store for the imperative accessrequestdecision resource.
+package accessrequestdecision
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	ext "github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	mgmtv3 "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/wrangler"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+)
+
+const (
+	SingularName = "accessrequestdecision"
+	kind         = "AccessRequestDecision"
+
+	// ApproveVerb is the verb on accessrequests which allows deciding them.
+	ApproveVerb = "approve"
+)
+
+var (
+	_ rest.Creater                  = &Store{}
+	_ rest.Storage                  = &Store{}
+	_ rest.Scoper                   = &Store{}
+	_ rest.SingularNameProvider     = &Store{}
+	_ rest.GroupVersionKindProvider = &Store{}
+)
+
+var (
+	GVK = ext.SchemeGroupVersion.WithKind(kind)
+	GVR = ext.SchemeGroupVersion.WithResource(ext.AccessRequestDecisionResourceName)
+)
+
+// +k8s:openapi-gen=false
+// +k8s:deepcopy-gen=false
+
+// Store records decisions on AccessRequests for the requesting user. The approver of an access request is only ever
+// taken from the authenticated request, never from user-writable data.
+type Store struct {
+	authorizer     authorizer.Authorizer
+	accessRequests mgmtv3.AccessRequestClient
+	projectCache   mgmtv3.ProjectCache
+}
+
+// +k8s:openapi-gen=false
+// +k8s:deepcopy-gen=false
+
+// New is a convenience function for creating an access request decision
+// store. It initializes the returned store from the provided wrangler context.
+func New(wranglerContext *wrangler.Context, authorizer authorizer.Authorizer) *Store {
+	return &Store{
+		authorizer:     authorizer,
+		accessRequests: wranglerContext.Mgmt.AccessRequest(),
+		projectCache:   wranglerContext.Mgmt.Project().Cache(),
+	}
+}
+
+// GroupVersionKind implements [rest.GroupVersionKindProvider], a required interface.
+func (s *Store) GroupVersionKind(_ schema.GroupVersion) schema.GroupVersionKind {
+	return GVK
+}
+
+// NamespaceScoped implements [rest.Scoper], a required interface.
+func (s *Store) NamespaceScoped() bool {
+	return false
+}
+
+// GetSingularName implements [rest.SingularNameProvider], a required interface.
+func (s *Store) GetSingularName() string {
+	return SingularName
+}
+
+// New implements [rest.Storage], a required interface.
+func (s *Store) New() runtime.Object {
+	return &ext.AccessRequestDecision{}
+}
+
+// Destroy implements [rest.Storage], a required interface.
+func (s *Store) Destroy() {
+}
+
+// Create implements [rest.Creator], the interface to support the `create`
+// verb. Delegates to the actual store method after some generic boilerplate.
+func (s *Store) Create(
+	ctx context.Context,
+	obj runtime.Object,
+	createValidation rest.ValidateObjectFunc,
+	options *metav1.CreateOptions) (runtime.Object, error) {
+	if createValidation != nil {
+		err := createValidation(ctx, obj)
+		if err != nil {
+			return obj, err
+		}
+	}
+	dryRun := options != nil && len(options.DryRun) > 0 && options.DryRun[0] == metav1.DryRunAll
+
+	objDecision, ok := obj.(*ext.AccessRequestDecision)
+	if !ok {
+		var zeroT *ext.AccessRequestDecision
+		return nil, apierrors.NewInternalError(fmt.Errorf("expected %T but got %T",
+			zeroT, obj))
+	}
+	spec := objDecision.Spec
+	if spec.AccessRequestName == "" {
+		return nil, apierrors.NewBadRequest("accessRequestName must be set")
+	}
+	decision := v3.AccessRequestDecision(spec.Decision)
+	if decision != v3.AccessRequestApproved && decision != v3.AccessRequestDenied {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("decision must be either %s or %s", v3.AccessRequestApproved, v3.AccessRequestDenied))
+	}
+
+	userInfo, ok := request.UserFrom(ctx)
+	if !ok {
+		return nil, apierrors.NewInternalError(fmt.Errorf("can't get user info from context"))
+	}
+	if err := s.authorize(ctx, userInfo, ApproveVerb, "accessrequests", "", spec.AccessRequestName); err != nil {
+		return nil, err
+	}
+
+	ar, err := s.accessRequests.Get(spec.AccessRequestName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if ar.Spec.UserName == userInfo.GetName() || ar.Status.RequesterName == userInfo.GetName() {
+		return nil, apierrors.NewForbidden(GVR.GroupResource(), spec.AccessRequestName, fmt.Errorf("user %s cannot decide their own access request", userInfo.GetName()))
+	}
+	if ar.Status.Decision != "" {
+		return nil, apierrors.NewConflict(GVR.GroupResource(), spec.AccessRequestName, fmt.Errorf("access request was already decided: %s", ar.Status.Decision))
+	}
+	if decision == v3.AccessRequestApproved {
+		if err := s.authorizeGrant(ctx, userInfo, ar); err != nil {
+			return nil, err
+		}
+	}
+
+	if dryRun {
+		return obj, nil
+	}
+
+	ar = ar.DeepCopy()
+	ar.Status.Decision = decision
+	ar.Status.ApproverName = userInfo.GetName()
+	if _, err := s.accessRequests.UpdateStatus(ar); err != nil {
+		return nil, err
+	}
+
+	objDecision.Status.ApproverName = userInfo.GetName()
+	return objDecision, nil
+}
+
+// authorizeGrant checks that the user approving the request could create the role template binding granting the
+// requested access themselves, so that approving a request never escalates beyond the approver's own permissions.
+func (s *Store) authorizeGrant(ctx context.Context, userInfo user.Info, ar *v3.AccessRequest) error {
+	resource, namespace := "clusterroletemplatebindings", ar.Spec.ClusterName
+	if ar.Spec.ProjectName != "" {
+		clusterName, projectName, _ := strings.Cut(ar.Spec.ProjectName, ":")
+		if clusterName != ar.Spec.ClusterName || projectName == "" {
+			return apierrors.NewBadRequest(fmt.Sprintf("project %s is not in cluster %s", ar.Spec.ProjectName, ar.Spec.ClusterName))
+		}
+		project, err := s.projectCache.Get(clusterName, projectName)
+		if apierrors.IsNotFound(err) {
+			return apierrors.NewBadRequest(fmt.Sprintf("project %s not found", ar.Spec.ProjectName))
+		} else if err != nil {
+			return apierrors.NewInternalError(fmt.Errorf("error getting project %s: %w", ar.Spec.ProjectName, err))
+		}
+		resource, namespace = "projectroletemplatebindings", project.GetProjectBackingNamespace()
+	}
+
+	if err := s.authorize(ctx, userInfo, "create", resource, namespace, ""); err != nil {
+		return err
+	}
+	return s.authorize(ctx, userInfo, "bind", "roletemplates", "", ar.Spec.RoleTemplateName)
+}
+
+func (s *Store) authorize(ctx context.Context, userInfo user.Info, verb, resource, namespace, name string) error {
+	decision, _, err := s.authorizer.Authorize(ctx, &authorizer.AttributesRecord{
+		User:            userInfo,
+		Verb:            verb,
+		APIGroup:        v3.SchemeGroupVersion.Group,
+		APIVersion:      v3.SchemeGroupVersion.Version,
+		Resource:        resource,
+		Namespace:       namespace,
+		Name:            name,
+		ResourceRequest: true,
+	})
+	if err != nil {
+		return apierrors.NewInternalError(fmt.Errorf("error checking permissions %w", err))
+	}
+	if decision != authorizer.DecisionAllow {
+		target := resource
+		if name != "" {
+			target += " " + name
+		}
+		if namespace != "" {
+			target += " in namespace " + namespace
+		}
+		return apierrors.NewForbidden(GVR.GroupResource(), "", fmt.Errorf("user %s is not allowed to %s %s", userInfo.GetName(), verb, target))
+	}
+
+	return nil
+}
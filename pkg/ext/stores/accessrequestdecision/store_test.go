@@ -0,0 +1,161 @@
+package accessrequestdecision
+
+import (
+	"context"
+	"testing"
+
+	ext "github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func TestCreate(t *testing.T) {
+	// allowExcept allows every action except the given verbs.
+	allowExcept := func(verbs ...string) authorizer.Authorizer {
+		return authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+			for _, verb := range verbs {
+				if a.GetVerb() == verb {
+					return authorizer.DecisionDeny, "", nil
+				}
+			}
+			return authorizer.DecisionAllow, "", nil
+		})
+	}
+	accessRequest := func(projectName string, status v3.AccessRequestStatus) *v3.AccessRequest {
+		return &v3.AccessRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "ar-1"},
+			Spec: v3.AccessRequestSpec{
+				UserName:         "u-requester",
+				ClusterName:      "c-abc",
+				ProjectName:      projectName,
+				RoleTemplateName: "cluster-owner",
+			},
+			Status: status,
+		}
+	}
+
+	tests := map[string]struct {
+		decision     string
+		userName     string
+		request      *v3.AccessRequest
+		authorizer   authorizer.Authorizer
+		wantApprover string
+		wantErrFunc  func(error) bool
+	}{
+		"approved": {
+			decision:     string(v3.AccessRequestApproved),
+			userName:     "u-approver",
+			request:      accessRequest("", v3.AccessRequestStatus{State: v3.AccessRequestStatePending}),
+			authorizer:   allowExcept(),
+			wantApprover: "u-approver",
+		},
+		"approved for project": {
+			decision:     string(v3.AccessRequestApproved),
+			userName:     "u-approver",
+			request:      accessRequest("c-abc:p-xyz", v3.AccessRequestStatus{}),
+			authorizer:   allowExcept(),
+			wantApprover: "u-approver",
+		},
+		"denied without the permission to create the binding": {
+			decision:     string(v3.AccessRequestDenied),
+			userName:     "u-approver",
+			request:      accessRequest("", v3.AccessRequestStatus{}),
+			authorizer:   allowExcept("create", "bind"),
+			wantApprover: "u-approver",
+		},
+		"approved without the approve verb": {
+			decision:    string(v3.AccessRequestApproved),
+			userName:    "u-approver",
+			authorizer:  allowExcept(ApproveVerb),
+			wantErrFunc: apierrors.IsForbidden,
+		},
+		"approved without the permission to create the binding": {
+			decision:    string(v3.AccessRequestApproved),
+			userName:    "u-approver",
+			request:     accessRequest("", v3.AccessRequestStatus{}),
+			authorizer:  allowExcept("create"),
+			wantErrFunc: apierrors.IsForbidden,
+		},
+		"approved without the permission to bind the role template": {
+			decision:    string(v3.AccessRequestApproved),
+			userName:    "u-approver",
+			request:     accessRequest("", v3.AccessRequestStatus{}),
+			authorizer:  allowExcept("bind"),
+			wantErrFunc: apierrors.IsForbidden,
+		},
+		"approved by the requester": {
+			decision:    string(v3.AccessRequestApproved),
+			userName:    "u-requester",
+			request:     accessRequest("", v3.AccessRequestStatus{}),
+			authorizer:  allowExcept(),
+			wantErrFunc: apierrors.IsForbidden,
+		},
+		"approved by the recorded requester": {
+			decision:    string(v3.AccessRequestApproved),
+			userName:    "u-filer",
+			request:     accessRequest("", v3.AccessRequestStatus{RequesterName: "u-filer"}),
+			authorizer:  allowExcept(),
+			wantErrFunc: apierrors.IsForbidden,
+		},
+		"already decided": {
+			decision:    string(v3.AccessRequestApproved),
+			userName:    "u-approver",
+			request:     accessRequest("", v3.AccessRequestStatus{Decision: v3.AccessRequestDenied, ApproverName: "u-other"}),
+			authorizer:  allowExcept(),
+			wantErrFunc: apierrors.IsConflict,
+		},
+		"unknown decision": {
+			decision:    "Maybe",
+			userName:    "u-approver",
+			authorizer:  allowExcept(),
+			wantErrFunc: apierrors.IsBadRequest,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			accessRequests := fake.NewMockNonNamespacedClientInterface[*v3.AccessRequest, *v3.AccessRequestList](ctrl)
+			projectCache := fake.NewMockCacheInterface[*v3.Project](ctrl)
+			if tt.request != nil {
+				accessRequests.EXPECT().Get("ar-1", metav1.GetOptions{}).Return(tt.request, nil)
+			}
+			projectCache.EXPECT().Get("c-abc", "p-xyz").Return(&v3.Project{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "c-abc", Name: "p-xyz"},
+				Status:     v3.ProjectStatus{BackingNamespace: "c-abc-p-xyz"},
+			}, nil).AnyTimes()
+			if tt.wantApprover != "" {
+				accessRequests.EXPECT().UpdateStatus(gomock.Any()).DoAndReturn(func(ar *v3.AccessRequest) (*v3.AccessRequest, error) {
+					assert.Equal(t, v3.AccessRequestDecision(tt.decision), ar.Status.Decision)
+					assert.Equal(t, tt.wantApprover, ar.Status.ApproverName)
+					return ar, nil
+				})
+			}
+
+			store := &Store{
+				authorizer:     tt.authorizer,
+				accessRequests: accessRequests,
+				projectCache:   projectCache,
+			}
+			ctx := request.WithUser(context.Background(), &user.DefaultInfo{Name: tt.userName})
+			obj, err := store.Create(ctx, &ext.AccessRequestDecision{
+				Spec: ext.AccessRequestDecisionSpec{AccessRequestName: "ar-1", Decision: tt.decision},
+			}, nil, &metav1.CreateOptions{})
+			if tt.wantErrFunc != nil {
+				require.Error(t, err)
+				assert.True(t, tt.wantErrFunc(err), err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantApprover, obj.(*ext.AccessRequestDecision).Status.ApproverName)
+		})
+	}
+}
@@ -0,0 +1,490 @@
+package effectivepermissionsreview
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	ext "github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	mgmtv3 "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/rbac"
+	wcorev1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	wrbacv1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/rbac/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	rbacauthorizer "k8s.io/kubernetes/plugin/pkg/auth/authorizer/rbac"
+)
+
+const (
+	localCluster        = "local"
+	localFleetWorkspace = "fleet-local"
+
+	projectIDAnnotation = "field.cattle.io/projectId"
+
+	globalRoleBindingKind          = "GlobalRoleBinding"
+	clusterRoleTemplateBindingKind = "ClusterRoleTemplateBinding"
+	projectRoleTemplateBindingKind = "ProjectRoleTemplateBinding"
+)
+
+// adminClusterRule is the rule granted to admins in every downstream cluster.
+var adminClusterRule = rbacv1.PolicyRule{
+	APIGroups: []string{rbacv1.APIGroupAll},
+	Resources: []string{rbacv1.ResourceAll},
+	Verbs:     []string{rbacv1.VerbAll},
+}
+
+// subject is a user along with the groups they are a member of, or a single group.
+type subject struct {
+	userName            string
+	groupPrincipalNames []string
+}
+
+// boundBy returns true if a binding to the given user or group principal applies to the subject.
+func (s subject) boundBy(userName, groupPrincipalName string) bool {
+	if userName != "" {
+		return userName == s.userName
+	}
+	return groupPrincipalName != "" && slices.Contains(s.groupPrincipalNames, groupPrincipalName)
+}
+
+// reviewer resolves the rules granted by global role bindings and role template bindings.
+type reviewer struct {
+	grbCache            mgmtv3.GlobalRoleBindingCache
+	grCache             mgmtv3.GlobalRoleCache
+	crtbCache           mgmtv3.ClusterRoleTemplateBindingCache
+	prtbCache           mgmtv3.ProjectRoleTemplateBindingCache
+	rtCache             mgmtv3.RoleTemplateCache
+	clusterRoleCache    wrbacv1.ClusterRoleCache
+	clusterCache        mgmtv3.ClusterCache
+	userAttributeCache  mgmtv3.UserAttributeCache
+	namespaceCache      wcorev1.NamespaceCache
+	fleetWorkspaceCache mgmtv3.FleetWorkspaceCache
+	now                 func() time.Time
+}
+
+// userSubject returns the user along with the group principals stored in their UserAttribute.
+func (r *reviewer) userSubject(userName string) (subject, error) {
+	sub := subject{userName: userName}
+	attribs, err := r.userAttributeCache.Get(userName)
+	if apierrors.IsNotFound(err) {
+		return sub, nil
+	} else if err != nil {
+		return sub, fmt.Errorf("failed to get userattribute %s: %w", userName, err)
+	}
+
+	for _, principals := range attribs.GroupPrincipals {
+		for _, principal := range principals.Items {
+			sub.groupPrincipalNames = append(sub.groupPrincipalNames, principal.Name)
+		}
+	}
+	slices.Sort(sub.groupPrincipalNames)
+	sub.groupPrincipalNames = slices.Compact(sub.groupPrincipalNames)
+
+	return sub, nil
+}
+
+// permissionsOf returns every rule granted to the subject, globally and in each cluster and project.
+func (r *reviewer) permissionsOf(sub subject) (ext.EffectivePermissionsReviewStatus, error) {
+	var status ext.EffectivePermissionsReviewStatus
+	clusters := map[string]*ext.ClusterPermissions{}
+	projects := map[string]*ext.ProjectPermissions{}
+	clusterPermissions := func(clusterName string) *ext.ClusterPermissions {
+		if clusters[clusterName] == nil {
+			clusters[clusterName] = &ext.ClusterPermissions{ClusterName: clusterName}
+		}
+		return clusters[clusterName]
+	}
+
+	downstreamClusters, err := r.downstreamClusters()
+	if err != nil {
+		return status, err
+	}
+
+	grbs, err := r.globalRoleBindings()
+	if err != nil {
+		return status, err
+	}
+	for _, grb := range grbs {
+		if !sub.boundBy(grb.UserName, grb.GroupPrincipalName) {
+			continue
+		}
+		globalRules, clusterRules, err := r.globalRoleRules(grb)
+		if err != nil {
+			return status, err
+		}
+		status.GlobalRules = append(status.GlobalRules, globalRules...)
+		if len(clusterRules) == 0 {
+			continue
+		}
+		for _, clusterName := range downstreamClusters {
+			cluster := clusterPermissions(clusterName)
+			cluster.Rules = append(cluster.Rules, clusterRules...)
+		}
+	}
+
+	crtbs, err := r.clusterRoleTemplateBindings("")
+	if err != nil {
+		return status, err
+	}
+	for _, crtb := range crtbs {
+		if !sub.boundBy(crtb.UserName, crtb.GroupPrincipalName) {
+			continue
+		}
+		rules, err := r.templateRules(crtb.RoleTemplateName, crtbGrant(crtb))
+		if err != nil {
+			return status, err
+		}
+		if len(rules) > 0 {
+			cluster := clusterPermissions(crtb.ClusterName)
+			cluster.Rules = append(cluster.Rules, rules...)
+		}
+	}
+
+	prtbs, err := r.projectRoleTemplateBindings()
+	if err != nil {
+		return status, err
+	}
+	for _, prtb := range prtbs {
+		if !sub.boundBy(prtb.UserName, prtb.GroupPrincipalName) {
+			continue
+		}
+		rules, err := r.templateRules(prtb.RoleTemplateName, prtbGrant(prtb))
+		if err != nil {
+			return status, err
+		}
+		if len(rules) == 0 {
+			continue
+		}
+		project := projects[prtb.ProjectName]
+		if project == nil {
+			project = &ext.ProjectPermissions{ProjectName: prtb.ProjectName}
+			projects[prtb.ProjectName] = project
+		}
+		project.Rules = append(project.Rules, rules...)
+	}
+
+	for _, project := range projects {
+		clusterName, _, _ := strings.Cut(project.ProjectName, ":")
+		cluster := clusterPermissions(clusterName)
+		cluster.Projects = append(cluster.Projects, *project)
+	}
+	for _, cluster := range clusters {
+		slices.SortFunc(cluster.Projects, func(a, b ext.ProjectPermissions) int {
+			return cmp.Compare(a.ProjectName, b.ProjectName)
+		})
+		status.Clusters = append(status.Clusters, *cluster)
+	}
+	slices.SortFunc(status.Clusters, func(a, b ext.ClusterPermissions) int {
+		return cmp.Compare(a.ClusterName, b.ClusterName)
+	})
+
+	return status, nil
+}
+
+// subjectsFor returns the users and groups allowed to perform the action described by attrs, along with a binding
+// allowing each of them to.
+func (r *reviewer) subjectsFor(attrs *ext.PermissionResourceAttributes) ([]ext.PermissionSubject, error) {
+	record := &authorizer.AttributesRecord{
+		Verb:            attrs.Verb,
+		Namespace:       attrs.Namespace,
+		APIGroup:        attrs.Group,
+		Resource:        attrs.Resource,
+		Subresource:     attrs.Subresource,
+		Name:            attrs.Name,
+		ResourceRequest: true,
+	}
+	var subjects []ext.PermissionSubject
+	addSubject := func(userName, groupPrincipalName string, rules []ext.EffectiveRule) {
+		if userName == "" && groupPrincipalName == "" {
+			return
+		}
+		for _, rule := range rules {
+			if rule.Namespace != "" && rule.Namespace != attrs.Namespace {
+				continue
+			}
+			if !rbacauthorizer.RuleAllows(record, &rule.Rule) {
+				continue
+			}
+			if userName != "" {
+				subjects = append(subjects, ext.PermissionSubject{Kind: rbacv1.UserKind, Name: userName, GrantedBy: rule.GrantedBy})
+			} else {
+				subjects = append(subjects, ext.PermissionSubject{Kind: rbacv1.GroupKind, Name: groupPrincipalName, GrantedBy: rule.GrantedBy})
+			}
+			return
+		}
+	}
+
+	grbs, err := r.globalRoleBindings()
+	if err != nil {
+		return nil, err
+	}
+	for _, grb := range grbs {
+		globalRules, clusterRules, err := r.globalRoleRules(grb)
+		if err != nil {
+			return nil, err
+		}
+		// Global roles are bound in the local cluster, inherited cluster roles in every other cluster.
+		if attrs.ClusterName == "" || attrs.ClusterName == localCluster {
+			addSubject(grb.UserName, grb.GroupPrincipalName, globalRules)
+		} else {
+			addSubject(grb.UserName, grb.GroupPrincipalName, clusterRules)
+		}
+	}
+
+	if attrs.ClusterName != "" {
+		crtbs, err := r.clusterRoleTemplateBindings(attrs.ClusterName)
+		if err != nil {
+			return nil, err
+		}
+		for _, crtb := range crtbs {
+			if crtb.ClusterName != attrs.ClusterName {
+				continue
+			}
+			rules, err := r.templateRules(crtb.RoleTemplateName, crtbGrant(crtb))
+			if err != nil {
+				return nil, err
+			}
+			addSubject(crtb.UserName, crtb.GroupPrincipalName, rules)
+		}
+	}
+
+	projectName, err := r.projectName(attrs)
+	if err != nil {
+		return nil, err
+	}
+	if projectName != "" {
+		prtbs, err := r.projectRoleTemplateBindings()
+		if err != nil {
+			return nil, err
+		}
+		for _, prtb := range prtbs {
+			if prtb.ProjectName != projectName {
+				continue
+			}
+			rules, err := r.templateRules(prtb.RoleTemplateName, prtbGrant(prtb))
+			if err != nil {
+				return nil, err
+			}
+			addSubject(prtb.UserName, prtb.GroupPrincipalName, rules)
+		}
+	}
+
+	slices.SortStableFunc(subjects, func(a, b ext.PermissionSubject) int {
+		return cmp.Or(cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.Name, b.Name))
+	})
+	return subjects, nil
+}
+
+// projectName returns the project of the action, looking it up from the namespace for actions in the local cluster.
+func (r *reviewer) projectName(attrs *ext.PermissionResourceAttributes) (string, error) {
+	if attrs.ProjectName != "" || attrs.Namespace == "" || attrs.ClusterName != localCluster {
+		return attrs.ProjectName, nil
+	}
+
+	ns, err := r.namespaceCache.Get(attrs.Namespace)
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to get namespace %s: %w", attrs.Namespace, err)
+	}
+
+	return ns.Annotations[projectIDAnnotation], nil
+}
+
+// globalRoleRules returns the rules granted by the global role of a binding in the local cluster, including those only
+// granted in some of its namespaces and in fleet workspaces, and the rules it grants in every downstream cluster.
+func (r *reviewer) globalRoleRules(grb *v3.GlobalRoleBinding) ([]ext.EffectiveRule, []ext.EffectiveRule, error) {
+	gr, err := r.grCache.Get(grb.GlobalRoleName)
+	if apierrors.IsNotFound(err) {
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("failed to get globalrole %s: %w", grb.GlobalRoleName, err)
+	}
+
+	grant := ext.PermissionGrant{
+		Kind:               globalRoleBindingKind,
+		Name:               grb.Name,
+		GroupPrincipalName: grb.GroupPrincipalName,
+		GlobalRoleName:     gr.Name,
+	}
+	var globalRules, clusterRules []ext.EffectiveRule
+	for _, rule := range gr.Rules {
+		globalRules = append(globalRules, ext.EffectiveRule{Rule: rule, GrantedBy: grant})
+	}
+	for _, namespace := range slices.Sorted(maps.Keys(gr.NamespacedRules)) {
+		for _, rule := range gr.NamespacedRules[namespace] {
+			globalRules = append(globalRules, ext.EffectiveRule{Rule: rule, Namespace: namespace, GrantedBy: grant})
+		}
+	}
+	if permissions := gr.InheritedFleetWorkspacePermissions; permissions != nil {
+		rules, err := r.fleetWorkspaceRules(permissions, grant)
+		if err != nil {
+			return nil, nil, err
+		}
+		globalRules = append(globalRules, rules...)
+	}
+	if gr.Name == rbac.GlobalAdmin {
+		clusterRules = append(clusterRules, ext.EffectiveRule{Rule: adminClusterRule, GrantedBy: grant})
+	}
+	for _, rtName := range gr.InheritedClusterRoles {
+		rules, err := r.templateRules(rtName, grant)
+		if err != nil {
+			return nil, nil, err
+		}
+		clusterRules = append(clusterRules, rules...)
+	}
+
+	return globalRules, clusterRules, nil
+}
+
+// fleetWorkspaceRules returns the rules granted in the backing namespace of every fleet workspace besides the local
+// one, and the rule granting the workspace verbs on those fleet workspaces.
+func (r *reviewer) fleetWorkspaceRules(permissions *v3.FleetWorkspacePermission, grant ext.PermissionGrant) ([]ext.EffectiveRule, error) {
+	fleetWorkspaces, err := r.fleetWorkspaceCache.List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fleetworkspaces: %w", err)
+	}
+	var names []string
+	for _, fleetWorkspace := range fleetWorkspaces {
+		if fleetWorkspace.Name != localFleetWorkspace {
+			names = append(names, fleetWorkspace.Name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	slices.Sort(names)
+
+	var rules []ext.EffectiveRule
+	for _, name := range names {
+		for _, rule := range permissions.ResourceRules {
+			rules = append(rules, ext.EffectiveRule{Rule: rule, Namespace: name, GrantedBy: grant})
+		}
+	}
+	if len(permissions.WorkspaceVerbs) > 0 {
+		rules = append(rules, ext.EffectiveRule{
+			Rule: rbacv1.PolicyRule{
+				Verbs:         permissions.WorkspaceVerbs,
+				APIGroups:     []string{v3.SchemeGroupVersion.Group},
+				Resources:     []string{"fleetworkspaces"},
+				ResourceNames: names,
+			},
+			GrantedBy: grant,
+		})
+	}
+	return rules, nil
+}
+
+// templateRules returns the rules of a role template and the templates it inherits from, granted by the given binding.
+func (r *reviewer) templateRules(rtName string, grant ext.PermissionGrant) ([]ext.EffectiveRule, error) {
+	rt, err := r.rtCache.Get(rtName)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get roletemplate %s: %w", rtName, err)
+	}
+
+	templateRules, err := rbac.TemplateRulesFromTemplate(r.clusterRoleCache, r.rtCache, rt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rules of roletemplate %s: %w", rtName, err)
+	}
+
+	rules := make([]ext.EffectiveRule, 0, len(templateRules))
+	for _, templateRule := range templateRules {
+		ruleGrant := grant
+		ruleGrant.RoleTemplateNames = templateRule.RoleTemplateNames
+		rules = append(rules, ext.EffectiveRule{Rule: templateRule.Rule, GrantedBy: ruleGrant})
+	}
+	return rules, nil
+}
+
+func (r *reviewer) downstreamClusters() ([]string, error) {
+	clusters, err := r.clusterCache.List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+
+	var names []string
+	for _, cluster := range clusters {
+		if cluster.Name != localCluster {
+			names = append(names, cluster.Name)
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// globalRoleBindings lists the global role bindings sorted by name, so that reviews are stable regardless of the
+// order of the cache.
+func (r *reviewer) globalRoleBindings() ([]*v3.GlobalRoleBinding, error) {
+	grbs, err := r.grbCache.List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list globalrolebindings: %w", err)
+	}
+	slices.SortFunc(grbs, func(a, b *v3.GlobalRoleBinding) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return grbs, nil
+}
+
+// clusterRoleTemplateBindings lists the unexpired cluster role template bindings in the namespace, or all namespaces if
+// empty, sorted by namespace and name.
+func (r *reviewer) clusterRoleTemplateBindings(namespace string) ([]*v3.ClusterRoleTemplateBinding, error) {
+	crtbs, err := r.crtbCache.List(namespace, labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusterroletemplatebindings: %w", err)
+	}
+	crtbs = slices.DeleteFunc(slices.Clone(crtbs), func(crtb *v3.ClusterRoleTemplateBinding) bool {
+		return r.expired(crtb.ExpiresAt)
+	})
+	slices.SortFunc(crtbs, func(a, b *v3.ClusterRoleTemplateBinding) int {
+		return cmp.Or(cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Name, b.Name))
+	})
+	return crtbs, nil
+}
+
+// projectRoleTemplateBindings lists all unexpired project role template bindings sorted by namespace and name.
+func (r *reviewer) projectRoleTemplateBindings() ([]*v3.ProjectRoleTemplateBinding, error) {
+	prtbs, err := r.prtbCache.List("", labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projectroletemplatebindings: %w", err)
+	}
+	prtbs = slices.DeleteFunc(slices.Clone(prtbs), func(prtb *v3.ProjectRoleTemplateBinding) bool {
+		return r.expired(prtb.ExpiresAt)
+	})
+	slices.SortFunc(prtbs, func(a, b *v3.ProjectRoleTemplateBinding) int {
+		return cmp.Or(cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Name, b.Name))
+	})
+	return prtbs, nil
+}
+
+// expired returns true if a binding expiring at the given time no longer grants any permission, even if it has not
+// been deleted yet.
+func (r *reviewer) expired(expiresAt *metav1.Time) bool {
+	return expiresAt != nil && !expiresAt.After(r.now())
+}
+
+func crtbGrant(crtb *v3.ClusterRoleTemplateBinding) ext.PermissionGrant {
+	return ext.PermissionGrant{
+		Kind:               clusterRoleTemplateBindingKind,
+		Namespace:          crtb.Namespace,
+		Name:               crtb.Name,
+		GroupPrincipalName: crtb.GroupPrincipalName,
+	}
+}
+
+func prtbGrant(prtb *v3.ProjectRoleTemplateBinding) ext.PermissionGrant {
+	return ext.PermissionGrant{
+		Kind:               projectRoleTemplateBindingKind,
+		Namespace:          prtb.Namespace,
+		Name:               prtb.Name,
+		GroupPrincipalName: prtb.GroupPrincipalName,
+	}
+}
@@ -0,0 +1,211 @@
+// effectivepermissionsreview implements the store for the imperative effectivepermissionsreview resource.
+package effectivepermissionsreview
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	ext "github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/wrangler"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+)
+
+const (
+	SingularName = "effectivepermissionsreview"
+	kind         = "EffectivePermissionsReview"
+
+	// reviewVerb is the verb on effectivepermissionsreviews required to review the permissions of other users and
+	// groups, and who is allowed to perform an action.
+	reviewVerb = "review"
+)
+
+var (
+	_ rest.Creater                  = &Store{}
+	_ rest.Storage                  = &Store{}
+	_ rest.Scoper                   = &Store{}
+	_ rest.SingularNameProvider     = &Store{}
+	_ rest.GroupVersionKindProvider = &Store{}
+)
+
+var (
+	GVK = ext.SchemeGroupVersion.WithKind(kind)
+	GVR = ext.SchemeGroupVersion.WithResource(ext.EffectivePermissionsReviewResourceName)
+)
+
+// +k8s:openapi-gen=false
+// +k8s:deepcopy-gen=false
+
+type Store struct {
+	authorizer authorizer.Authorizer
+	reviewer   *reviewer
+}
+
+// +k8s:openapi-gen=false
+// +k8s:deepcopy-gen=false
+
+// New is a convenience function for creating an effective permissions review
+// store. It initializes the returned store from the provided wrangler context.
+func New(wranglerContext *wrangler.Context, authorizer authorizer.Authorizer) *Store {
+	mgmt := wranglerContext.Mgmt
+	return &Store{
+		authorizer: authorizer,
+		reviewer: &reviewer{
+			grbCache:            mgmt.GlobalRoleBinding().Cache(),
+			grCache:             mgmt.GlobalRole().Cache(),
+			crtbCache:           mgmt.ClusterRoleTemplateBinding().Cache(),
+			prtbCache:           mgmt.ProjectRoleTemplateBinding().Cache(),
+			rtCache:             mgmt.RoleTemplate().Cache(),
+			clusterRoleCache:    wranglerContext.RBAC.ClusterRole().Cache(),
+			clusterCache:        mgmt.Cluster().Cache(),
+			userAttributeCache:  mgmt.UserAttribute().Cache(),
+			namespaceCache:      wranglerContext.Core.Namespace().Cache(),
+			fleetWorkspaceCache: mgmt.FleetWorkspace().Cache(),
+			now:                 time.Now,
+		},
+	}
+}
+
+// GroupVersionKind implements [rest.GroupVersionKindProvider], a required interface.
+func (s *Store) GroupVersionKind(_ schema.GroupVersion) schema.GroupVersionKind {
+	return GVK
+}
+
+// NamespaceScoped implements [rest.Scoper], a required interface.
+func (s *Store) NamespaceScoped() bool {
+	return false
+}
+
+// GetSingularName implements [rest.SingularNameProvider], a required interface.
+func (s *Store) GetSingularName() string {
+	return SingularName
+}
+
+// New implements [rest.Storage], a required interface.
+func (s *Store) New() runtime.Object {
+	return &ext.EffectivePermissionsReview{}
+}
+
+// Destroy implements [rest.Storage], a required interface.
+func (s *Store) Destroy() {
+}
+
+// Create implements [rest.Creator], the interface to support the `create`
+// verb. Delegates to the actual store method after some generic boilerplate.
+func (s *Store) Create(
+	ctx context.Context,
+	obj runtime.Object,
+	createValidation rest.ValidateObjectFunc,
+	options *metav1.CreateOptions) (runtime.Object, error) {
+	if createValidation != nil {
+		err := createValidation(ctx, obj)
+		if err != nil {
+			return obj, err
+		}
+	}
+	dryRun := options != nil && len(options.DryRun) > 0 && options.DryRun[0] == metav1.DryRunAll
+
+	objReview, ok := obj.(*ext.EffectivePermissionsReview)
+	if !ok {
+		var zeroT *ext.EffectivePermissionsReview
+		return nil, apierrors.NewInternalError(fmt.Errorf("expected %T but got %T",
+			zeroT, obj))
+	}
+	if err := validateSpec(&objReview.Spec); err != nil {
+		return nil, err
+	}
+
+	userInfo, ok := request.UserFrom(ctx)
+	if !ok {
+		return nil, apierrors.NewInternalError(fmt.Errorf("can't get user info from context"))
+	}
+	if !isSelfReview(&objReview.Spec, userInfo) {
+		if err := s.authorizeReview(ctx, userInfo); err != nil {
+			return nil, err
+		}
+	}
+
+	if dryRun {
+		return obj, nil
+	}
+
+	var (
+		status ext.EffectivePermissionsReviewStatus
+		err    error
+	)
+	switch spec := objReview.Spec; {
+	case spec.ResourceAttributes != nil:
+		status.Subjects, err = s.reviewer.subjectsFor(spec.ResourceAttributes)
+	case spec.GroupPrincipalName != "":
+		status, err = s.reviewer.permissionsOf(subject{groupPrincipalNames: []string{spec.GroupPrincipalName}})
+	default:
+		userName := spec.UserName
+		if userName == "" {
+			userName = userInfo.GetName()
+		}
+		var sub subject
+		if sub, err = s.reviewer.userSubject(userName); err == nil {
+			status, err = s.reviewer.permissionsOf(sub)
+			status.GroupPrincipalNames = sub.groupPrincipalNames
+		}
+	}
+	if err != nil {
+		return nil, apierrors.NewInternalError(fmt.Errorf("error reviewing permissions: %w", err))
+	}
+
+	objReview.Status = status
+	return objReview, nil
+}
+
+func validateSpec(spec *ext.EffectivePermissionsReviewSpec) error {
+	if spec.UserName != "" && spec.GroupPrincipalName != "" {
+		return apierrors.NewBadRequest("only one of userName and groupPrincipalName can be set")
+	}
+	attrs := spec.ResourceAttributes
+	if attrs == nil {
+		return nil
+	}
+	if spec.UserName != "" || spec.GroupPrincipalName != "" {
+		return apierrors.NewBadRequest("resourceAttributes cannot be combined with userName or groupPrincipalName")
+	}
+	if attrs.Verb == "" || attrs.Resource == "" {
+		return apierrors.NewBadRequest("resourceAttributes must set verb and resource")
+	}
+
+	return nil
+}
+
+// isSelfReview returns true if the review only reveals the permissions of the requesting user.
+func isSelfReview(spec *ext.EffectivePermissionsReviewSpec, userInfo user.Info) bool {
+	return spec.ResourceAttributes == nil &&
+		spec.GroupPrincipalName == "" &&
+		(spec.UserName == "" || spec.UserName == userInfo.GetName())
+}
+
+// authorizeReview checks that the user is allowed to see the permissions of other users, which requires the review
+// verb on effectivepermissionsreviews.
+func (s *Store) authorizeReview(ctx context.Context, userInfo user.Info) error {
+	decision, _, err := s.authorizer.Authorize(ctx, &authorizer.AttributesRecord{
+		User:            userInfo,
+		Verb:            reviewVerb,
+		APIGroup:        GVR.Group,
+		APIVersion:      GVR.Version,
+		Resource:        GVR.Resource,
+		ResourceRequest: true,
+	})
+	if err != nil {
+		return apierrors.NewInternalError(fmt.Errorf("error checking permissions %w", err))
+	}
+	if decision != authorizer.DecisionAllow {
+		return apierrors.NewForbidden(GVR.GroupResource(), "", fmt.Errorf("user %s is not allowed to review the permissions of other users", userInfo.GetName()))
+	}
+
+	return nil
+}
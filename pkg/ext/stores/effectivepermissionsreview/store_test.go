@@ -0,0 +1,401 @@
+package effectivepermissionsreview
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ext "github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+var (
+	getPods     = rbacv1.PolicyRule{Verbs: []string{"get", "list"}, APIGroups: []string{""}, Resources: []string{"pods"}}
+	createApps  = rbacv1.PolicyRule{Verbs: []string{"create"}, APIGroups: []string{"apps"}, Resources: []string{"deployments"}}
+	getSettings = rbacv1.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{"management.cattle.io"}, Resources: []string{"settings"}}
+	getSecrets  = rbacv1.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"secrets"}}
+	listRepos   = rbacv1.PolicyRule{Verbs: []string{"list"}, APIGroups: []string{"fleet.cattle.io"}, Resources: []string{"gitrepos"}}
+
+	testNow = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// newTestReviewer returns a reviewer with a downstream cluster c-abc in which user u-1 is granted permissions through
+// a global role, a group cluster role template binding and a project role template binding, and an expired cluster
+// role template binding. User u-3 is granted permissions in some namespaces and fleet workspaces of the local cluster.
+func newTestReviewer(t *testing.T) *reviewer {
+	ctrl := gomock.NewController(t)
+
+	clusterCache := fake.NewMockNonNamespacedCacheInterface[*v3.Cluster](ctrl)
+	clusterCache.EXPECT().List(labels.Everything()).Return([]*v3.Cluster{
+		{ObjectMeta: metav1.ObjectMeta{Name: "local"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "c-abc"}},
+	}, nil).AnyTimes()
+
+	grbCache := fake.NewMockNonNamespacedCacheInterface[*v3.GlobalRoleBinding](ctrl)
+	grbCache.EXPECT().List(labels.Everything()).Return([]*v3.GlobalRoleBinding{
+		{ObjectMeta: metav1.ObjectMeta{Name: "grb-2"}, UserName: "u-2", GlobalRoleName: "missing"},
+		{ObjectMeta: metav1.ObjectMeta{Name: "grb-1"}, UserName: "u-1", GlobalRoleName: "viewer"},
+		{ObjectMeta: metav1.ObjectMeta{Name: "grb-3"}, UserName: "u-3", GlobalRoleName: "fleet"},
+	}, nil).AnyTimes()
+
+	grCache := fake.NewMockNonNamespacedCacheInterface[*v3.GlobalRole](ctrl)
+	grCache.EXPECT().Get("viewer").Return(&v3.GlobalRole{
+		ObjectMeta:            metav1.ObjectMeta{Name: "viewer"},
+		Rules:                 []rbacv1.PolicyRule{getSettings},
+		InheritedClusterRoles: []string{"rt-view"},
+	}, nil).AnyTimes()
+	grCache.EXPECT().Get("fleet").Return(&v3.GlobalRole{
+		ObjectMeta: metav1.ObjectMeta{Name: "fleet"},
+		NamespacedRules: map[string][]rbacv1.PolicyRule{
+			"ns-secrets": {getSecrets},
+		},
+		InheritedFleetWorkspacePermissions: &v3.FleetWorkspacePermission{
+			ResourceRules:  []rbacv1.PolicyRule{listRepos},
+			WorkspaceVerbs: []string{"get"},
+		},
+	}, nil).AnyTimes()
+	grCache.EXPECT().Get("missing").Return(nil, apierrors.NewNotFound(schema.GroupResource{}, "missing")).AnyTimes()
+
+	crtbCache := fake.NewMockCacheInterface[*v3.ClusterRoleTemplateBinding](ctrl)
+	crtbs := []*v3.ClusterRoleTemplateBinding{
+		{
+			ObjectMeta:         metav1.ObjectMeta{Namespace: "c-abc", Name: "crtb-1"},
+			ClusterName:        "c-abc",
+			GroupPrincipalName: "github_team://1",
+			RoleTemplateName:   "rt-owner",
+		},
+		{
+			ObjectMeta:       metav1.ObjectMeta{Namespace: "c-abc", Name: "crtb-expired"},
+			ClusterName:      "c-abc",
+			UserName:         "u-1",
+			RoleTemplateName: "rt-owner",
+			ExpiresAt:        &metav1.Time{Time: testNow.Add(-time.Minute)},
+		},
+	}
+	crtbCache.EXPECT().List("", labels.Everything()).Return(crtbs, nil).AnyTimes()
+	crtbCache.EXPECT().List("c-abc", labels.Everything()).Return(crtbs, nil).AnyTimes()
+	crtbCache.EXPECT().List("local", labels.Everything()).Return(nil, nil).AnyTimes()
+
+	prtbCache := fake.NewMockCacheInterface[*v3.ProjectRoleTemplateBinding](ctrl)
+	prtbCache.EXPECT().List("", labels.Everything()).Return([]*v3.ProjectRoleTemplateBinding{{
+		ObjectMeta:       metav1.ObjectMeta{Namespace: "c-abc-p-xyz", Name: "prtb-1"},
+		ProjectName:      "c-abc:p-xyz",
+		UserName:         "u-1",
+		RoleTemplateName: "rt-view",
+		ExpiresAt:        &metav1.Time{Time: testNow.Add(time.Hour)},
+	}}, nil).AnyTimes()
+
+	rtCache := fake.NewMockNonNamespacedCacheInterface[*v3.RoleTemplate](ctrl)
+	rtCache.EXPECT().Get("rt-view").Return(&v3.RoleTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "rt-view"},
+		Rules:      []rbacv1.PolicyRule{getPods},
+	}, nil).AnyTimes()
+	rtCache.EXPECT().Get("rt-owner").Return(&v3.RoleTemplate{
+		ObjectMeta:        metav1.ObjectMeta{Name: "rt-owner"},
+		Rules:             []rbacv1.PolicyRule{createApps},
+		RoleTemplateNames: []string{"rt-view"},
+	}, nil).AnyTimes()
+
+	userAttributeCache := fake.NewMockNonNamespacedCacheInterface[*v3.UserAttribute](ctrl)
+	userAttributeCache.EXPECT().Get("u-1").Return(&v3.UserAttribute{
+		GroupPrincipals: map[string]v3.Principals{
+			"github": {Items: []v3.Principal{{ObjectMeta: metav1.ObjectMeta{Name: "github_team://1"}}}},
+		},
+	}, nil).AnyTimes()
+	userAttributeCache.EXPECT().Get("u-3").Return(nil, apierrors.NewNotFound(schema.GroupResource{}, "u-3")).AnyTimes()
+
+	namespaceCache := fake.NewMockNonNamespacedCacheInterface[*corev1.Namespace](ctrl)
+	namespaceCache.EXPECT().Get("ns-1").Return(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "ns-1",
+			Annotations: map[string]string{projectIDAnnotation: "local:p-local"},
+		},
+	}, nil).AnyTimes()
+
+	fleetWorkspaceCache := fake.NewMockNonNamespacedCacheInterface[*v3.FleetWorkspace](ctrl)
+	fleetWorkspaceCache.EXPECT().List(labels.Everything()).Return([]*v3.FleetWorkspace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "fleet-local"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "fleet-default"}},
+	}, nil).AnyTimes()
+
+	return &reviewer{
+		grbCache:            grbCache,
+		grCache:             grCache,
+		crtbCache:           crtbCache,
+		prtbCache:           prtbCache,
+		rtCache:             rtCache,
+		clusterRoleCache:    fake.NewMockNonNamespacedCacheInterface[*rbacv1.ClusterRole](ctrl),
+		clusterCache:        clusterCache,
+		userAttributeCache:  userAttributeCache,
+		namespaceCache:      namespaceCache,
+		fleetWorkspaceCache: fleetWorkspaceCache,
+		now:                 func() time.Time { return testNow },
+	}
+}
+
+func TestCreate(t *testing.T) {
+	allow := authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+		return authorizer.DecisionAllow, "", nil
+	})
+	deny := authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+		return authorizer.DecisionDeny, "", nil
+	})
+	allowReview := authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+		if a.GetVerb() == "review" && a.GetAPIGroup() == "ext.cattle.io" && a.GetResource() == "effectivepermissionsreviews" {
+			return authorizer.DecisionAllow, "", nil
+		}
+		return authorizer.DecisionDeny, "", nil
+	})
+	allowGetUsers := authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+		if a.GetVerb() == "get" && a.GetResource() == "users" {
+			return authorizer.DecisionAllow, "", nil
+		}
+		return authorizer.DecisionDeny, "", nil
+	})
+
+	grbGrant := ext.PermissionGrant{Kind: globalRoleBindingKind, Name: "grb-1", GlobalRoleName: "viewer"}
+	crtbGrant := ext.PermissionGrant{Kind: clusterRoleTemplateBindingKind, Namespace: "c-abc", Name: "crtb-1", GroupPrincipalName: "github_team://1"}
+	prtbGrant := ext.PermissionGrant{Kind: projectRoleTemplateBindingKind, Namespace: "c-abc-p-xyz", Name: "prtb-1"}
+	fleetGrant := ext.PermissionGrant{Kind: globalRoleBindingKind, Name: "grb-3", GlobalRoleName: "fleet"}
+	withTemplates := func(grant ext.PermissionGrant, roleTemplateNames ...string) ext.PermissionGrant {
+		grant.RoleTemplateNames = roleTemplateNames
+		return grant
+	}
+
+	tests := map[string]struct {
+		spec        ext.EffectivePermissionsReviewSpec
+		userName    string
+		authorizer  authorizer.Authorizer
+		wantStatus  ext.EffectivePermissionsReviewStatus
+		wantErrFunc func(error) bool
+	}{
+		"permissions of the requesting user": {
+			userName:   "u-1",
+			authorizer: deny,
+			wantStatus: ext.EffectivePermissionsReviewStatus{
+				GroupPrincipalNames: []string{"github_team://1"},
+				GlobalRules: []ext.EffectiveRule{
+					{Rule: getSettings, GrantedBy: grbGrant},
+				},
+				Clusters: []ext.ClusterPermissions{{
+					ClusterName: "c-abc",
+					Rules: []ext.EffectiveRule{
+						{Rule: getPods, GrantedBy: withTemplates(grbGrant, "rt-view")},
+						{Rule: createApps, GrantedBy: withTemplates(crtbGrant, "rt-owner")},
+						{Rule: getPods, GrantedBy: withTemplates(crtbGrant, "rt-owner", "rt-view")},
+					},
+					Projects: []ext.ProjectPermissions{{
+						ProjectName: "c-abc:p-xyz",
+						Rules: []ext.EffectiveRule{
+							{Rule: getPods, GrantedBy: withTemplates(prtbGrant, "rt-view")},
+						},
+					}},
+				}},
+			},
+		},
+		"permissions granted in namespaces and fleet workspaces": {
+			userName:   "u-3",
+			authorizer: deny,
+			wantStatus: ext.EffectivePermissionsReviewStatus{
+				GlobalRules: []ext.EffectiveRule{
+					{Rule: getSecrets, Namespace: "ns-secrets", GrantedBy: fleetGrant},
+					{Rule: listRepos, Namespace: "fleet-default", GrantedBy: fleetGrant},
+					{
+						Rule: rbacv1.PolicyRule{
+							Verbs:         []string{"get"},
+							APIGroups:     []string{"management.cattle.io"},
+							Resources:     []string{"fleetworkspaces"},
+							ResourceNames: []string{"fleet-default"},
+						},
+						GrantedBy: fleetGrant,
+					},
+				},
+			},
+		},
+		"permissions of another user": {
+			spec:       ext.EffectivePermissionsReviewSpec{UserName: "u-3"},
+			userName:   "u-2",
+			authorizer: allowReview,
+			wantStatus: ext.EffectivePermissionsReviewStatus{
+				GlobalRules: []ext.EffectiveRule{
+					{Rule: getSecrets, Namespace: "ns-secrets", GrantedBy: fleetGrant},
+					{Rule: listRepos, Namespace: "fleet-default", GrantedBy: fleetGrant},
+					{
+						Rule: rbacv1.PolicyRule{
+							Verbs:         []string{"get"},
+							APIGroups:     []string{"management.cattle.io"},
+							Resources:     []string{"fleetworkspaces"},
+							ResourceNames: []string{"fleet-default"},
+						},
+						GrantedBy: fleetGrant,
+					},
+				},
+			},
+		},
+		"who can get secrets in a namespace": {
+			spec: ext.EffectivePermissionsReviewSpec{
+				ResourceAttributes: &ext.PermissionResourceAttributes{
+					Namespace: "ns-secrets",
+					Verb:      "get",
+					Resource:  "secrets",
+				},
+			},
+			userName:   "u-2",
+			authorizer: allowReview,
+			wantStatus: ext.EffectivePermissionsReviewStatus{
+				Subjects: []ext.PermissionSubject{
+					{Kind: rbacv1.UserKind, Name: "u-3", GrantedBy: fleetGrant},
+				},
+			},
+		},
+		"rules granted in a namespace do not apply to other namespaces": {
+			spec: ext.EffectivePermissionsReviewSpec{
+				ResourceAttributes: &ext.PermissionResourceAttributes{
+					Namespace: "ns-other",
+					Verb:      "get",
+					Resource:  "secrets",
+				},
+			},
+			userName:   "u-2",
+			authorizer: allowReview,
+		},
+		"permissions of a group": {
+			spec:       ext.EffectivePermissionsReviewSpec{GroupPrincipalName: "github_team://1"},
+			userName:   "u-2",
+			authorizer: allow,
+			wantStatus: ext.EffectivePermissionsReviewStatus{
+				Clusters: []ext.ClusterPermissions{{
+					ClusterName: "c-abc",
+					Rules: []ext.EffectiveRule{
+						{Rule: createApps, GrantedBy: withTemplates(crtbGrant, "rt-owner")},
+						{Rule: getPods, GrantedBy: withTemplates(crtbGrant, "rt-owner", "rt-view")},
+					},
+				}},
+			},
+		},
+		"who can get pods in a project": {
+			spec: ext.EffectivePermissionsReviewSpec{
+				ResourceAttributes: &ext.PermissionResourceAttributes{
+					ClusterName: "c-abc",
+					ProjectName: "c-abc:p-xyz",
+					Namespace:   "ns-2",
+					Verb:        "get",
+					Resource:    "pods",
+				},
+			},
+			userName:   "u-2",
+			authorizer: allow,
+			wantStatus: ext.EffectivePermissionsReviewStatus{
+				Subjects: []ext.PermissionSubject{
+					{Kind: rbacv1.GroupKind, Name: "github_team://1", GrantedBy: withTemplates(crtbGrant, "rt-owner", "rt-view")},
+					{Kind: rbacv1.UserKind, Name: "u-1", GrantedBy: withTemplates(grbGrant, "rt-view")},
+					{Kind: rbacv1.UserKind, Name: "u-1", GrantedBy: withTemplates(prtbGrant, "rt-view")},
+				},
+			},
+		},
+		"who can get settings": {
+			spec: ext.EffectivePermissionsReviewSpec{
+				ResourceAttributes: &ext.PermissionResourceAttributes{
+					Verb:     "get",
+					Group:    "management.cattle.io",
+					Resource: "settings",
+				},
+			},
+			userName:   "u-2",
+			authorizer: allow,
+			wantStatus: ext.EffectivePermissionsReviewStatus{
+				Subjects: []ext.PermissionSubject{
+					{Kind: rbacv1.UserKind, Name: "u-1", GrantedBy: grbGrant},
+				},
+			},
+		},
+		"project is looked up from the namespace": {
+			spec: ext.EffectivePermissionsReviewSpec{
+				ResourceAttributes: &ext.PermissionResourceAttributes{
+					ClusterName: "local",
+					Namespace:   "ns-1",
+					Verb:        "get",
+					Resource:    "pods",
+				},
+			},
+			userName:   "u-2",
+			authorizer: allow,
+		},
+		"permissions of another user require the review verb": {
+			spec:        ext.EffectivePermissionsReviewSpec{UserName: "u-1"},
+			userName:    "u-2",
+			authorizer:  allowGetUsers,
+			wantErrFunc: apierrors.IsForbidden,
+		},
+		"user and group": {
+			spec:        ext.EffectivePermissionsReviewSpec{UserName: "u-1", GroupPrincipalName: "github_team://1"},
+			userName:    "u-1",
+			authorizer:  allow,
+			wantErrFunc: apierrors.IsBadRequest,
+		},
+		"resource attributes and user": {
+			spec: ext.EffectivePermissionsReviewSpec{
+				UserName:           "u-1",
+				ResourceAttributes: &ext.PermissionResourceAttributes{Verb: "get", Resource: "pods"},
+			},
+			userName:    "u-1",
+			authorizer:  allow,
+			wantErrFunc: apierrors.IsBadRequest,
+		},
+		"resource attributes without a verb": {
+			spec: ext.EffectivePermissionsReviewSpec{
+				ResourceAttributes: &ext.PermissionResourceAttributes{Resource: "pods"},
+			},
+			userName:    "u-1",
+			authorizer:  allow,
+			wantErrFunc: apierrors.IsBadRequest,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			store := &Store{
+				authorizer: tt.authorizer,
+				reviewer:   newTestReviewer(t),
+			}
+			ctx := request.WithUser(context.Background(), &user.DefaultInfo{Name: tt.userName})
+
+			obj, err := store.Create(ctx, &ext.EffectivePermissionsReview{Spec: tt.spec}, nil, &metav1.CreateOptions{})
+			if tt.wantErrFunc != nil {
+				require.Error(t, err)
+				assert.True(t, tt.wantErrFunc(err), err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			review, ok := obj.(*ext.EffectivePermissionsReview)
+			require.True(t, ok)
+			assert.Equal(t, tt.wantStatus, review.Status)
+		})
+	}
+}
+
+func TestProjectName(t *testing.T) {
+	r := newTestReviewer(t)
+
+	projectName, err := r.projectName(&ext.PermissionResourceAttributes{ClusterName: "local", Namespace: "ns-1"})
+	require.NoError(t, err)
+	assert.Equal(t, "local:p-local", projectName)
+
+	projectName, err = r.projectName(&ext.PermissionResourceAttributes{ClusterName: "c-abc", Namespace: "ns-1"})
+	require.NoError(t, err)
+	assert.Empty(t, projectName)
+}
@@ -5,6 +5,8 @@
 
 	extv1 "github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1"
 	"github.com/rancher/rancher/pkg/auth/providers/common"
+	"github.com/rancher/rancher/pkg/ext/stores/accessrequestdecision"
+	"github.com/rancher/rancher/pkg/ext/stores/effectivepermissionsreview"
 	"github.com/rancher/rancher/pkg/ext/stores/groupmembershiprefreshrequest"
 	"github.com/rancher/rancher/pkg/ext/stores/kubeconfig"
 	"github.com/rancher/rancher/pkg/ext/stores/passwordchangerequest"
@@ -92,6 +94,20 @@ func InstallStores(
 	if err != nil {
 		return fmt.Errorf("unable to install %s store: %w", selfuser.SingularName, err)
 	}
+	err = server.Install(
+		extv1.EffectivePermissionsReviewResourceName,
+		effectivepermissionsreview.GVK,
+		effectivepermissionsreview.New(wranglerContext, server.GetAuthorizer()))
+	if err != nil {
+		return fmt.Errorf("unable to install %s store: %w", effectivepermissionsreview.SingularName, err)
+	}
+	err = server.Install(
+		extv1.AccessRequestDecisionResourceName,
+		accessrequestdecision.GVK,
+		accessrequestdecision.New(wranglerContext, server.GetAuthorizer()))
+	if err != nil {
+		return fmt.Errorf("unable to install %s store: %w", accessrequestdecision.SingularName, err)
+	}
 
 	return nil
 }
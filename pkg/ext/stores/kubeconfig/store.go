@@ -228,6 +228,10 @@ func (s *Store) Create(
 		return nil, apierrors.NewForbidden(gvr.GroupResource(), "", fmt.Errorf("user %s is not a Rancher user", userInfo.GetName()))
 	}
 
+	if common.IsScopedTokenRequest(userInfo) {
+		return nil, apierrors.NewForbidden(gvr.GroupResource(), "", fmt.Errorf("kubeconfigs can't be created with a scoped token"))
+	}
+
 	extras := userInfo.GetExtra()
 
 	authTokenID := first(extras[common.ExtraRequestTokenID])
@@ -1004,6 +1004,34 @@ func TestStoreCreate(t *testing.T) {
 		assert.True(t, apierrors.IsForbidden(err))
 		assert.Contains(t, err.Error(), "missing request token ID")
 	})
+	t.Run("scoped request token", func(t *testing.T) {
+		store := &Store{
+			authorizer: commonAuthorizer,
+			userCache:  userCache,
+			tokenCache: tokenCache,
+			userMgr:    userManager,
+		}
+
+		ctx := request.WithUser(context.Background(), &k8suser.DefaultInfo{
+			Extra: map[string][]string{
+				common.ExtraRequestTokenID:     {authTokenID},
+				common.ExtraRequestTokenScoped: {"true"},
+			},
+			Name: userID,
+		})
+		kubeconfig := &ext.Kubeconfig{
+			Spec: ext.KubeconfigSpec{
+				Clusters:       []string{downstream1},
+				CurrentContext: downstream1,
+			},
+		}
+
+		obj, err := store.Create(ctx, kubeconfig, nil, options)
+		require.Error(t, err)
+		assert.Nil(t, obj)
+		assert.True(t, apierrors.IsForbidden(err))
+		assert.Contains(t, err.Error(), "scoped token")
+	})
 	t.Run("request token doesn't exist", func(t *testing.T) {
 		store := &Store{
 			authorizer: commonAuthorizer,
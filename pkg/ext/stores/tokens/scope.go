@@ -0,0 +1,131 @@
+package tokens
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	ext "github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/auth/accessor"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+// localClusterID is the ID of the cluster Rancher runs in. Requests which are not routed to a cluster, i.e. requests
+// to the Rancher management API, are considered to be for this cluster.
+const localClusterID = "local"
+
+// requestInfoFactory parses the Kubernetes API requests proxied to clusters.
+var requestInfoFactory = request.RequestInfoFactory{
+	APIPrefixes:          sets.NewString("apis", "api"),
+	GrouplessAPIPrefixes: sets.NewString("api"),
+}
+
+// discoveryPaths are the first path segments of the non-resource requests allowed for tokens restricted to
+// namespaces.
+var discoveryPaths = sets.New("api", "apis", "version", "openapi")
+
+// readOnlyMethods are the HTTP methods allowed for tokens with a read-only scope.
+var readOnlyMethods = sets.New(http.MethodGet, http.MethodHead, http.MethodOptions)
+
+// ScopeOf returns the scope of the given token, nil if the token is not scoped.
+// Only ext tokens can be scoped.
+func ScopeOf(token accessor.TokenAccessor) *ext.TokenScope {
+	if extToken, ok := token.(*ext.Token); ok {
+		return extToken.Spec.Scope
+	}
+	return nil
+}
+
+// CheckScope returns an error if the scope does not allow the request. The
+// clusterID is the cluster the request is routed to, or the empty string for
+// requests to the Rancher management API.
+func CheckScope(scope *ext.TokenScope, clusterID string, req *http.Request) error {
+	if scope == nil {
+		return nil
+	}
+
+	if len(scope.ClusterIDs) > 0 {
+		targetID := clusterID
+		if targetID == "" {
+			targetID = localClusterID
+		}
+		if !slices.Contains(scope.ClusterIDs, targetID) {
+			return fmt.Errorf("token scope does not allow access to cluster %s", targetID)
+		}
+	}
+
+	if scope.ReadOnly && (!readOnlyMethods.Has(req.Method) || httpstream.IsUpgradeRequest(req)) {
+		return fmt.Errorf("token scope is read-only")
+	}
+
+	if len(scope.Namespaces) > 0 {
+		return checkNamespaceScope(scope.Namespaces, clusterID, req)
+	}
+
+	return nil
+}
+
+// checkNamespaceScope returns an error if the request is not for a resource in
+// one of the given namespaces. Only requests proxied to a cluster can be
+// restricted to namespaces.
+func checkNamespaceScope(namespaces []string, clusterID string, req *http.Request) error {
+	prefix := "/k8s/clusters/" + clusterID
+	path, found := strings.CutPrefix(req.URL.Path, prefix)
+	if clusterID == "" || !found || (path != "" && !strings.HasPrefix(path, "/")) {
+		return fmt.Errorf("token scope is restricted to namespaces and only allows requests to the cluster API")
+	}
+
+	// Parse the request as the cluster would see it.
+	clusterReq := req.Clone(req.Context())
+	clusterReq.URL.Path = path
+	info, err := requestInfoFactory.NewRequestInfo(clusterReq)
+	if err != nil {
+		return fmt.Errorf("failed to parse request: %w", err)
+	}
+
+	if !info.IsResourceRequest {
+		// Allow API discovery, version and openapi requests, needed by any client.
+		// Anything else, e.g. the Steve API, can't be restricted to namespaces.
+		root, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+		if !discoveryPaths.Has(root) || info.Verb != "get" {
+			return fmt.Errorf("token scope is restricted to namespaces and does not allow %s", path)
+		}
+		return nil
+	}
+
+	if info.Namespace == "" || !slices.Contains(namespaces, info.Namespace) {
+		return fmt.Errorf("token scope does not allow access to %s outside of its namespaces", info.Resource)
+	}
+
+	// The namespace itself is visible but can't be changed.
+	if info.Resource == "namespaces" && info.Verb != "get" {
+		return fmt.Errorf("token scope does not allow %s of namespace %s", info.Verb, info.Namespace)
+	}
+
+	return nil
+}
+
+// validateScope checks the fields of a new token's scope.
+func validateScope(scope *ext.TokenScope) error {
+	if scope == nil {
+		return nil
+	}
+
+	for _, id := range scope.ClusterIDs {
+		if id == "" {
+			return fmt.Errorf("spec.scope.clusterIDs must not contain empty IDs")
+		}
+	}
+
+	for _, namespace := range scope.Namespaces {
+		if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+			return fmt.Errorf("spec.scope.namespaces contains invalid namespace %q: %s", namespace, strings.Join(errs, ", "))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,199 @@
+package tokens
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ext "github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CheckScope(t *testing.T) {
+	tests := []struct {
+		name      string
+		scope     *ext.TokenScope
+		clusterID string
+		method    string
+		path      string
+		upgrade   bool
+		wantErr   bool
+	}{
+		{
+			name:   "no scope",
+			method: http.MethodDelete,
+			path:   "/v3/clusters/c-1",
+		},
+		{
+			name:      "allowed cluster",
+			scope:     &ext.TokenScope{ClusterIDs: []string{"c-1"}},
+			clusterID: "c-1",
+			method:    http.MethodPost,
+			path:      "/k8s/clusters/c-1/api/v1/namespaces/default/pods",
+		},
+		{
+			name:      "other cluster",
+			scope:     &ext.TokenScope{ClusterIDs: []string{"c-1"}},
+			clusterID: "c-2",
+			method:    http.MethodGet,
+			path:      "/k8s/clusters/c-2/api/v1/pods",
+			wantErr:   true,
+		},
+		{
+			name:    "management API without local cluster",
+			scope:   &ext.TokenScope{ClusterIDs: []string{"c-1"}},
+			method:  http.MethodGet,
+			path:    "/v1/management.cattle.io.clusters",
+			wantErr: true,
+		},
+		{
+			name:   "management API with local cluster",
+			scope:  &ext.TokenScope{ClusterIDs: []string{"local"}},
+			method: http.MethodGet,
+			path:   "/v1/management.cattle.io.clusters",
+		},
+		{
+			name:   "read-only get",
+			scope:  &ext.TokenScope{ReadOnly: true},
+			method: http.MethodGet,
+			path:   "/v1/management.cattle.io.clusters",
+		},
+		{
+			name:    "read-only update",
+			scope:   &ext.TokenScope{ReadOnly: true},
+			method:  http.MethodPut,
+			path:    "/v1/management.cattle.io.clusters/c-1",
+			wantErr: true,
+		},
+		{
+			name:      "read-only exec",
+			scope:     &ext.TokenScope{ReadOnly: true},
+			clusterID: "c-1",
+			method:    http.MethodGet,
+			path:      "/k8s/clusters/c-1/api/v1/namespaces/default/pods/p/exec",
+			upgrade:   true,
+			wantErr:   true,
+		},
+		{
+			name:      "allowed namespace",
+			scope:     &ext.TokenScope{ClusterIDs: []string{"c-1"}, Namespaces: []string{"ci"}},
+			clusterID: "c-1",
+			method:    http.MethodPost,
+			path:      "/k8s/clusters/c-1/apis/apps/v1/namespaces/ci/deployments",
+		},
+		{
+			name:      "other namespace",
+			scope:     &ext.TokenScope{ClusterIDs: []string{"c-1"}, Namespaces: []string{"ci"}},
+			clusterID: "c-1",
+			method:    http.MethodGet,
+			path:      "/k8s/clusters/c-1/api/v1/namespaces/kube-system/secrets",
+			wantErr:   true,
+		},
+		{
+			name:      "all namespaces",
+			scope:     &ext.TokenScope{Namespaces: []string{"ci"}},
+			clusterID: "c-1",
+			method:    http.MethodGet,
+			path:      "/k8s/clusters/c-1/api/v1/pods",
+			wantErr:   true,
+		},
+		{
+			name:      "cluster-scoped resource",
+			scope:     &ext.TokenScope{Namespaces: []string{"ci"}},
+			clusterID: "c-1",
+			method:    http.MethodGet,
+			path:      "/k8s/clusters/c-1/api/v1/nodes",
+			wantErr:   true,
+		},
+		{
+			name:      "get allowed namespace",
+			scope:     &ext.TokenScope{Namespaces: []string{"ci"}},
+			clusterID: "c-1",
+			method:    http.MethodGet,
+			path:      "/k8s/clusters/c-1/api/v1/namespaces/ci",
+		},
+		{
+			name:      "delete allowed namespace",
+			scope:     &ext.TokenScope{Namespaces: []string{"ci"}},
+			clusterID: "c-1",
+			method:    http.MethodDelete,
+			path:      "/k8s/clusters/c-1/api/v1/namespaces/ci",
+			wantErr:   true,
+		},
+		{
+			name:      "discovery",
+			scope:     &ext.TokenScope{Namespaces: []string{"ci"}},
+			clusterID: "c-1",
+			method:    http.MethodGet,
+			path:      "/k8s/clusters/c-1/apis/apps/v1",
+		},
+		{
+			name:      "steve API",
+			scope:     &ext.TokenScope{Namespaces: []string{"ci"}},
+			clusterID: "c-1",
+			method:    http.MethodGet,
+			path:      "/k8s/clusters/c-1/v1/secrets",
+			wantErr:   true,
+		},
+		{
+			name:      "norman API",
+			scope:     &ext.TokenScope{Namespaces: []string{"ci"}},
+			clusterID: "c-1",
+			method:    http.MethodGet,
+			path:      "/v3/clusters/c-1",
+			wantErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, test.path, nil)
+			if test.upgrade {
+				req.Header.Set("Connection", "Upgrade")
+				req.Header.Set("Upgrade", "websocket")
+			}
+
+			err := CheckScope(test.scope, test.clusterID, req)
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_validateScope(t *testing.T) {
+	assert.NoError(t, validateScope(nil))
+	assert.NoError(t, validateScope(&ext.TokenScope{ClusterIDs: []string{"c-1"}, Namespaces: []string{"ci"}}))
+	assert.Error(t, validateScope(&ext.TokenScope{ClusterIDs: []string{""}}))
+	assert.Error(t, validateScope(&ext.TokenScope{Namespaces: []string{"Not_A_Namespace"}}))
+}
+
+func Test_ScopeSecretRoundTrip(t *testing.T) {
+	scope := &ext.TokenScope{
+		ClusterIDs: []string{"c-1"},
+		Namespaces: []string{"ci"},
+		ReadOnly:   true,
+	}
+
+	token := properToken.DeepCopy()
+	token.Spec.Scope = scope
+
+	secret, err := toSecret(token)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"clusterIDs":["c-1"],"namespaces":["ci"],"readOnly":true}`, secret.StringData[FieldScope])
+
+	stored := properSecret.DeepCopy()
+	stored.Data[FieldScope] = []byte(secret.StringData[FieldScope])
+
+	restored, err := fromSecret(stored)
+	require.NoError(t, err)
+	assert.Equal(t, scope, restored.Spec.Scope)
+
+	// Tokens stored without a scope remain unscoped.
+	restored, err = fromSecret(properSecret.DeepCopy())
+	require.NoError(t, err)
+	assert.Nil(t, restored.Spec.Scope)
+}
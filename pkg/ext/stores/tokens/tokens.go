@@ -67,6 +67,7 @@
 	FieldLastUpdateTime   = "last-update-time"
 	FieldLastUsedAt       = "last-used-at"
 	FieldPrincipal        = "principal"
+	FieldScope            = "scope"
 	FieldTTL              = "ttl"
 	FieldUID              = "kube-uid"
 	FieldUserID           = "user-id"
@@ -621,6 +622,17 @@ func (t *SystemStore) Create(ctx context.Context, group schema.GroupResource, to
 		return nil, apierrors.NewInternalError(err)
 	}
 
+	if err := validateScope(token.Spec.Scope); err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+
+	// A scoped token must not be able to escape its scope by creating
+	// another token.
+	if userInfo, ok := request.UserFrom(ctx); ok && common.IsScopedTokenRequest(userInfo) {
+		return nil, apierrors.NewForbidden(group, "",
+			fmt.Errorf("tokens can't be created with a scoped token"))
+	}
+
 	rtPrincipal := requestToken.GetUserPrincipal()
 	token.Spec.UserPrincipal = ext.TokenPrincipal{
 		Name:           rtPrincipal.ObjectMeta.Name,
@@ -862,6 +874,10 @@ func (t *SystemStore) update(sessionID string, fullPermission bool, oldToken, to
 		return nil, apierrors.NewBadRequest("spec.kind is immutable")
 	}
 
+	if !reflect.DeepEqual(token.Spec.Scope, oldToken.Spec.Scope) {
+		return nil, apierrors.NewBadRequest("spec.scope is immutable")
+	}
+
 	if token.Spec.UserPrincipal.Name != oldToken.Spec.UserPrincipal.Name ||
 		token.Spec.UserPrincipal.DisplayName != oldToken.Spec.UserPrincipal.DisplayName ||
 		token.Spec.UserPrincipal.LoginName != oldToken.Spec.UserPrincipal.LoginName ||
@@ -1450,6 +1466,15 @@ func toSecret(token *ext.Token) (*corev1.Secret, error) {
 		return nil, err
 	}
 
+	// token scope, optional
+	scopeBytes := []byte{}
+	if token.Spec.Scope != nil {
+		scopeBytes, err = json.Marshal(token.Spec.Scope)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// system information. remainder is handled through secret's ObjectMeta
 	secret.StringData[FieldUID] = string(token.ObjectMeta.UID)
 
@@ -1466,6 +1491,7 @@ func toSecret(token *ext.Token) (*corev1.Secret, error) {
 	secret.StringData[FieldEnabled] = fmt.Sprintf("%t", token.Spec.Enabled == nil || *token.Spec.Enabled)
 	secret.StringData[FieldKind] = token.Spec.Kind
 	secret.StringData[FieldPrincipal] = string(principalBytes)
+	secret.StringData[FieldScope] = string(scopeBytes)
 	secret.StringData[FieldTTL] = fmt.Sprintf("%d", ttl)
 	secret.StringData[FieldUserID] = token.Spec.UserID
 
@@ -1534,6 +1560,13 @@ func fromSecret(secret *corev1.Secret) (*ext.Token, error) {
 	}
 	token.Spec.TTL = ttl
 
+	if scopeBytes := secret.Data[FieldScope]; len(scopeBytes) > 0 {
+		token.Spec.Scope = &ext.TokenScope{}
+		if err := json.Unmarshal(scopeBytes, token.Spec.Scope); err != nil {
+			return nil, fmt.Errorf("failed to parse scope data: %w", err)
+		}
+	}
+
 	// status information
 	if token.Status.Hash = string(secret.Data[FieldHash]); token.Status.Hash == "" {
 		return nil, fmt.Errorf("token hash missing")
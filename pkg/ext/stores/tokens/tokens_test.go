@@ -10,6 +10,7 @@
 
 	ext "github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1"
 	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/providers/common"
 	"github.com/rancher/wrangler/v3/pkg/generic/fake"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -22,6 +23,8 @@
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
+	k8suser "k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/utils/pointer"
 )
 
@@ -917,6 +920,7 @@ func Test_Store_Create(t *testing.T) {
 		tok        *ext.Token            // token input
 		rtok       *ext.Token            // expected op result, created token
 		opts       *metav1.CreateOptions // create options
+		ctx        context.Context       // request context, context.TODO() if nil
 		storeSetup func(                 // configure store backend clients
 			space *fake.MockNonNamespacedControllerInterface[*corev1.Namespace, *corev1.NamespaceList],
 			secrets *fake.MockControllerInterface[*corev1.Secret, *corev1.SecretList],
@@ -1035,6 +1039,48 @@ func Test_Store_Create(t *testing.T) {
 					Return(enabledUser, nil)
 			},
 		},
+		{
+			name: "scoped request token",
+			err: apierrors.NewForbidden(GVR.GroupResource(), "",
+				fmt.Errorf("tokens can't be created with a scoped token")),
+			tok: &ext.Token{
+				Spec: ext.TokenSpec{
+					UserID: "world",
+				},
+			},
+			opts: &metav1.CreateOptions{},
+			ctx: request.WithUser(context.TODO(), &k8suser.DefaultInfo{
+				Name: "world",
+				Extra: map[string][]string{
+					common.ExtraRequestTokenID:     {"session-token"},
+					common.ExtraRequestTokenScoped: {"true"},
+				},
+			}),
+			storeSetup: func( // configure store backend clients
+				space *fake.MockNonNamespacedControllerInterface[*corev1.Namespace, *corev1.NamespaceList],
+				secrets *fake.MockControllerInterface[*corev1.Secret, *corev1.SecretList],
+				scache *fake.MockCacheInterface[*corev1.Secret],
+				users *fake.MockNonNamespacedCacheInterface[*v3.User],
+				token *fake.MockNonNamespacedCacheInterface[*v3.Token],
+				timer *MocktimeHandler,
+				hasher *MockhashHandler,
+				auth *MockauthHandler) {
+
+				auth.EXPECT().UserName(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(&mockUser{name: "world"}, false, true, nil)
+
+				auth.EXPECT().SessionID(gomock.Any()).
+					Return("session-token")
+				token.EXPECT().Get("session-token").Return(&v3.Token{
+					AuthProvider: "local",
+					UserPrincipal: v3.Principal{
+						ObjectMeta: metav1.ObjectMeta{Name: "local://world"},
+					}}, nil)
+
+				users.EXPECT().Get("world").
+					Return(enabledUser, nil)
+			},
+		},
 		{
 			name: "generation or hash error",
 			err:  someerror,
@@ -1308,7 +1354,11 @@ func Test_Store_Create(t *testing.T) {
 			test.storeSetup(nil, secrets, scache, ucache, tcache, timer, hasher, auth)
 
 			// perform test and validate results
-			tok, err := store.create(context.TODO(), test.tok, test.opts)
+			ctx := test.ctx
+			if ctx == nil {
+				ctx = context.TODO()
+			}
+			tok, err := store.create(ctx, test.tok, test.opts)
 			if test.err != nil {
 				assert.Equal(t, test.err, err)
 				assert.Nil(t, tok)
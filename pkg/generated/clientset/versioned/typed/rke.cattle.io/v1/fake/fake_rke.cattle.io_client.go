@@ -36,6 +36,10 @@ func (c *FakeRkeV1) ETCDSnapshots(namespace string) v1.ETCDSnapshotInterface {
 	return newFakeETCDSnapshots(c, namespace)
 }
 
+func (c *FakeRkeV1) ETCDSnapshotPolicies(namespace string) v1.ETCDSnapshotPolicyInterface {
+	return newFakeETCDSnapshotPolicies(c, namespace)
+}
+
 func (c *FakeRkeV1) RKEBootstraps(namespace string) v1.RKEBootstrapInterface {
 	return newFakeRKEBootstraps(c, namespace)
 }
@@ -22,6 +22,8 @@ type CustomMachineExpansion interface{
 
 type ETCDSnapshotExpansion interface{}
 
+type ETCDSnapshotPolicyExpansion interface{}
+
 type RKEBootstrapExpansion interface{}
 
 type RKEBootstrapTemplateExpansion interface{}
@@ -30,6 +30,7 @@ type RkeV1Interface interface {
 	RESTClient() rest.Interface
 	CustomMachinesGetter
 	ETCDSnapshotsGetter
+	ETCDSnapshotPoliciesGetter
 	RKEBootstrapsGetter
 	RKEBootstrapTemplatesGetter
 	RKEClustersGetter
@@ -49,6 +50,10 @@ func (c *RkeV1Client) ETCDSnapshots(namespace string) ETCDSnapshotInterface {
 	return newETCDSnapshots(c, namespace)
 }
 
+func (c *RkeV1Client) ETCDSnapshotPolicies(namespace string) ETCDSnapshotPolicyInterface {
+	return newETCDSnapshotPolicies(c, namespace)
+}
+
 func (c *RkeV1Client) RKEBootstraps(namespace string) RKEBootstrapInterface {
 	return newRKEBootstraps(c, namespace)
 }
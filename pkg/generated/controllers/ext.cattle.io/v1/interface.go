@@ -31,6 +31,8 @@ func init() {
 }
 
 type Interface interface {
+	AccessRequestDecision() AccessRequestDecisionController
+	EffectivePermissionsReview() EffectivePermissionsReviewController
 	GroupMembershipRefreshRequest() GroupMembershipRefreshRequestController
 	Kubeconfig() KubeconfigController
 	PasswordChangeRequest() PasswordChangeRequestController
@@ -49,6 +51,14 @@ type version struct {
 	controllerFactory controller.SharedControllerFactory
 }
 
+func (v *version) AccessRequestDecision() AccessRequestDecisionController {
+	return generic.NewNonNamespacedController[*v1.AccessRequestDecision, *v1.AccessRequestDecisionList](schema.GroupVersionKind{Group: "ext.cattle.io", Version: "v1", Kind: "AccessRequestDecision"}, "accessrequestdecisions", v.controllerFactory)
+}
+
+func (v *version) EffectivePermissionsReview() EffectivePermissionsReviewController {
+	return generic.NewNonNamespacedController[*v1.EffectivePermissionsReview, *v1.EffectivePermissionsReviewList](schema.GroupVersionKind{Group: "ext.cattle.io", Version: "v1", Kind: "EffectivePermissionsReview"}, "effectivepermissionsreviews", v.controllerFactory)
+}
+
 func (v *version) GroupMembershipRefreshRequest() GroupMembershipRefreshRequestController {
 	return generic.NewController[*v1.GroupMembershipRefreshRequest, *v1.GroupMembershipRefreshRequestList](schema.GroupVersionKind{Group: "ext.cattle.io", Version: "v1", Kind: "GroupMembershipRefreshRequest"}, "groupmembershiprefreshrequests", true, v.controllerFactory)
 }
@@ -29,6 +29,16 @@
 
 func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenAPIDefinition {
 	return map[string]common.OpenAPIDefinition{
+		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.AccessRequestDecision":               schema_pkg_apis_extcattleio_v1_AccessRequestDecision(ref),
+		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.AccessRequestDecisionList":           schema_pkg_apis_extcattleio_v1_AccessRequestDecisionList(ref),
+		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.AccessRequestDecisionSpec":           schema_pkg_apis_extcattleio_v1_AccessRequestDecisionSpec(ref),
+		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.AccessRequestDecisionStatus":         schema_pkg_apis_extcattleio_v1_AccessRequestDecisionStatus(ref),
+		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.ClusterPermissions":                  schema_pkg_apis_extcattleio_v1_ClusterPermissions(ref),
+		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.EffectivePermissionsReview":          schema_pkg_apis_extcattleio_v1_EffectivePermissionsReview(ref),
+		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.EffectivePermissionsReviewList":      schema_pkg_apis_extcattleio_v1_EffectivePermissionsReviewList(ref),
+		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.EffectivePermissionsReviewSpec":      schema_pkg_apis_extcattleio_v1_EffectivePermissionsReviewSpec(ref),
+		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.EffectivePermissionsReviewStatus":    schema_pkg_apis_extcattleio_v1_EffectivePermissionsReviewStatus(ref),
+		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.EffectiveRule":                       schema_pkg_apis_extcattleio_v1_EffectiveRule(ref),
 		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.GroupMembershipRefreshRequest":       schema_pkg_apis_extcattleio_v1_GroupMembershipRefreshRequest(ref),
 		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.GroupMembershipRefreshRequestList":   schema_pkg_apis_extcattleio_v1_GroupMembershipRefreshRequestList(ref),
 		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.GroupMembershipRefreshRequestSpec":   schema_pkg_apis_extcattleio_v1_GroupMembershipRefreshRequestSpec(ref),
@@ -41,12 +51,17 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.PasswordChangeRequestList":           schema_pkg_apis_extcattleio_v1_PasswordChangeRequestList(ref),
 		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.PasswordChangeRequestSpec":           schema_pkg_apis_extcattleio_v1_PasswordChangeRequestSpec(ref),
 		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.PasswordChangeRequestStatus":         schema_pkg_apis_extcattleio_v1_PasswordChangeRequestStatus(ref),
+		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.PermissionGrant":                     schema_pkg_apis_extcattleio_v1_PermissionGrant(ref),
+		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.PermissionResourceAttributes":        schema_pkg_apis_extcattleio_v1_PermissionResourceAttributes(ref),
+		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.PermissionSubject":                   schema_pkg_apis_extcattleio_v1_PermissionSubject(ref),
+		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.ProjectPermissions":                  schema_pkg_apis_extcattleio_v1_ProjectPermissions(ref),
 		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.SelfUser":                            schema_pkg_apis_extcattleio_v1_SelfUser(ref),
 		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.SelfUserList":                        schema_pkg_apis_extcattleio_v1_SelfUserList(ref),
 		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.SelfUserStatus":                      schema_pkg_apis_extcattleio_v1_SelfUserStatus(ref),
 		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.Token":                               schema_pkg_apis_extcattleio_v1_Token(ref),
 		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.TokenList":                           schema_pkg_apis_extcattleio_v1_TokenList(ref),
 		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.TokenPrincipal":                      schema_pkg_apis_extcattleio_v1_TokenPrincipal(ref),
+		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.TokenScope":                          schema_pkg_apis_extcattleio_v1_TokenScope(ref),
 		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.TokenSpec":                           schema_pkg_apis_extcattleio_v1_TokenSpec(ref),
 		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.TokenStatus":                         schema_pkg_apis_extcattleio_v1_TokenStatus(ref),
 		"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.UserActivity":                        schema_pkg_apis_extcattleio_v1_UserActivity(ref),
@@ -114,6 +129,451 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 	}
 }
 
+func schema_pkg_apis_extcattleio_v1_AccessRequestDecision(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AccessRequestDecision is used to approve or deny an AccessRequest as the requesting user.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Standard object metadata; More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#metadata.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"),
+						},
+					},
+					"spec": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Spec is the decision on the access request.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.AccessRequestDecisionSpec"),
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Status is the result of the decision.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.AccessRequestDecisionStatus"),
+						},
+					},
+				},
+				Required: []string{"spec"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.AccessRequestDecisionSpec", "github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.AccessRequestDecisionStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
+	}
+}
+
+func schema_pkg_apis_extcattleio_v1_AccessRequestDecisionList(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AccessRequestDecisionList is a list of AccessRequestDecision resources",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref("k8s.io/apimachinery/pkg/apis/meta/v1.ListMeta"),
+						},
+					},
+					"items": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.AccessRequestDecision"),
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"metadata", "items"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.AccessRequestDecision", "k8s.io/apimachinery/pkg/apis/meta/v1.ListMeta"},
+	}
+}
+
+func schema_pkg_apis_extcattleio_v1_AccessRequestDecisionSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AccessRequestDecisionSpec contains the decision on an access request.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"accessRequestName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "AccessRequestName is the name of the access request being decided.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"decision": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Decision is either Approved or Denied.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"accessRequestName", "decision"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_extcattleio_v1_AccessRequestDecisionStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AccessRequestDecisionStatus contains the result of an AccessRequestDecision.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"approverName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ApproverName is the name of the user the decision was recorded for.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_extcattleio_v1_ClusterPermissions(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ClusterPermissions are the rules granted in a cluster.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"clusterName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ClusterName is the name of the cluster.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"rules": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Rules are the rules granted in the whole cluster.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.EffectiveRule"),
+									},
+								},
+							},
+						},
+					},
+					"projects": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Projects are the rules granted in the namespaces of each project of the cluster.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.ProjectPermissions"),
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"clusterName"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.EffectiveRule", "github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.ProjectPermissions"},
+	}
+}
+
+func schema_pkg_apis_extcattleio_v1_EffectivePermissionsReview(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "EffectivePermissionsReview is used to query the permissions a user or group is granted across Rancher, or who is allowed to perform an action.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Standard object metadata; More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#metadata.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"),
+						},
+					},
+					"spec": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Spec is the subject or action to review.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.EffectivePermissionsReviewSpec"),
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Status is the result of the review.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.EffectivePermissionsReviewStatus"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.EffectivePermissionsReviewSpec", "github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.EffectivePermissionsReviewStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
+	}
+}
+
+func schema_pkg_apis_extcattleio_v1_EffectivePermissionsReviewList(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "EffectivePermissionsReviewList is a list of EffectivePermissionsReview resources",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref("k8s.io/apimachinery/pkg/apis/meta/v1.ListMeta"),
+						},
+					},
+					"items": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.EffectivePermissionsReview"),
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"metadata", "items"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.EffectivePermissionsReview", "k8s.io/apimachinery/pkg/apis/meta/v1.ListMeta"},
+	}
+}
+
+func schema_pkg_apis_extcattleio_v1_EffectivePermissionsReviewSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "EffectivePermissionsReviewSpec contains the subject or action to review. The permissions of the requesting user are reviewed if none of the fields are set.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"userName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UserName is the name of the user whose permissions are reviewed, including the permissions granted to the groups they are a member of.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"groupPrincipalName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "GroupPrincipalName is the name of the group principal whose permissions are reviewed.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"resourceAttributes": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ResourceAttributes describes an action to find the users and groups allowed to perform. Cannot be combined with UserName or GroupPrincipalName.",
+							Ref:         ref("github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.PermissionResourceAttributes"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.PermissionResourceAttributes"},
+	}
+}
+
+func schema_pkg_apis_extcattleio_v1_EffectivePermissionsReviewStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "EffectivePermissionsReviewStatus contains the result of an EffectivePermissionsReview.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"groupPrincipalNames": {
+						SchemaProps: spec.SchemaProps{
+							Description: "GroupPrincipalNames are the groups of the reviewed user whose permissions are included.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"globalRules": {
+						SchemaProps: spec.SchemaProps{
+							Description: "GlobalRules are the rules granted by global roles.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.EffectiveRule"),
+									},
+								},
+							},
+						},
+					},
+					"clusters": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Clusters are the rules granted in each cluster and its projects.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.ClusterPermissions"),
+									},
+								},
+							},
+						},
+					},
+					"subjects": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Subjects are the users and groups allowed to perform the action given in ResourceAttributes.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.PermissionSubject"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.ClusterPermissions", "github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.EffectiveRule", "github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.PermissionSubject"},
+	}
+}
+
+func schema_pkg_apis_extcattleio_v1_EffectiveRule(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "EffectiveRule is a rule along with the binding that granted it.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"rule": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Rule is the granted rule.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("k8s.io/api/rbac/v1.PolicyRule"),
+						},
+					},
+					"namespace": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Namespace is the namespace of the local cluster the rule is granted in, for global rules only granted in some namespaces. The rule is granted in all namespaces if it is not set.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"grantedBy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "GrantedBy is the binding that granted the rule.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.PermissionGrant"),
+						},
+					},
+				},
+				Required: []string{"rule", "grantedBy"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.PermissionGrant", "k8s.io/api/rbac/v1.PolicyRule"},
+	}
+}
+
 func schema_pkg_apis_extcattleio_v1_GroupMembershipRefreshRequest(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -661,6 +1121,221 @@ func schema_pkg_apis_extcattleio_v1_PasswordChangeRequestStatus(ref common.Refer
 	}
 }
 
+func schema_pkg_apis_extcattleio_v1_PermissionGrant(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PermissionGrant identifies the binding and roles granting a permission.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is the kind of the binding, one of GlobalRoleBinding, ClusterRoleTemplateBinding or ProjectRoleTemplateBinding.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"namespace": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Namespace is the namespace of the binding.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name is the name of the binding.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"groupPrincipalName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "GroupPrincipalName is set when the binding grants the permission to a group rather than to a user.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"globalRoleName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "GlobalRoleName is the name of the global role granting the permission, for global role bindings.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"roleTemplateNames": {
+						SchemaProps: spec.SchemaProps{
+							Description: "RoleTemplateNames is the chain of role templates the permission was inherited through, starting with the role template of the binding and ending with the role template defining the rule.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"kind", "name"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_extcattleio_v1_PermissionResourceAttributes(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PermissionResourceAttributes describes an action on a resource.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"clusterName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ClusterName is the name of the cluster the action is performed in. Only global permissions are reviewed if it is not set.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"projectName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ProjectName is the name of the project the action is performed in, in the format <cluster>:<project>. It is looked up from Namespace in the local cluster if not set.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"namespace": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Namespace is the namespace the action is performed in.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"verb": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Verb is the kubernetes verb of the action, such as get, list or create.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"group": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Group is the API group of the resource.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"resource": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Resource is the resource the action is performed on.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"subresource": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Subresource is the subresource the action is performed on.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name is the name of the resource the action is performed on.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"verb", "resource"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_extcattleio_v1_PermissionSubject(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PermissionSubject is a user or group allowed to perform an action.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is either User or Group.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name is the name of the user or group principal.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"grantedBy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "GrantedBy is the binding that allows the subject to perform the action.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.PermissionGrant"),
+						},
+					},
+				},
+				Required: []string{"kind", "name", "grantedBy"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.PermissionGrant"},
+	}
+}
+
+func schema_pkg_apis_extcattleio_v1_ProjectPermissions(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ProjectPermissions are the rules granted in the namespaces of a project.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"projectName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ProjectName is the name of the project, in the format <cluster>:<project>.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"rules": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Rules are the rules granted in the project.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.EffectiveRule"),
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"projectName"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.EffectiveRule"},
+	}
+}
+
 func schema_pkg_apis_extcattleio_v1_SelfUser(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -966,6 +1641,66 @@ func schema_pkg_apis_extcattleio_v1_TokenPrincipal(ref common.ReferenceCallback)
 	}
 }
 
+func schema_pkg_apis_extcattleio_v1_TokenScope(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "TokenScope restricts where and how a token can be used. The restrictions are applied on top of the permissions of the user owning the token.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"clusterIDs": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-type": "set",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "ClusterIDs is the list of clusters the token can access. The Rancher management API is only accessible if the list contains the \"local\" cluster. An empty list allows all clusters.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"namespaces": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-type": "set",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "Namespaces is the list of namespaces the token can access in the allowed clusters. Cluster-scoped resources and the Rancher management API are not accessible, except for API discovery. An empty list allows all namespaces.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"readOnly": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ReadOnly restricts the token to requests which do not change anything, i.e. get, list and watch. Interactive sessions such as exec, attach and shells are not allowed either.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_extcattleio_v1_TokenSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -1017,12 +1752,18 @@ func schema_pkg_apis_extcattleio_v1_TokenSpec(ref common.ReferenceCallback) comm
 							Format:      "",
 						},
 					},
+					"scope": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Scope restricts what the token can be used for. The default (`null`) indicates an unrestricted token, carrying the full permissions of the user. The scope is immutable and scoped tokens can't be used to create tokens or kubeconfigs.",
+							Ref:         ref("github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.TokenScope"),
+						},
+					},
 				},
 				Required: []string{"userPrincipal"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.TokenPrincipal"},
+			"github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.TokenPrincipal", "github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1.TokenScope"},
 	}
 }
 
@@ -6,6 +6,7 @@
 
 	"github.com/prometheus/client_golang/prometheus"
 	dto "github.com/prometheus/client_model/go"
+	"github.com/rancher/rancher/pkg/dialer"
 	v1 "github.com/rancher/rancher/pkg/generated/norman/core/v1"
 	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/settings"
@@ -84,7 +85,7 @@ func (gc *metricGarbageCollector) metricGarbageCollection() {
 
 	buildObservedLabelMaps(targetMetricsByNameForClientKey, "clientkey", observedLabelsMap)
 	buildObservedLabelMaps(targetMetricsByIPForPeer, "peer", observedLabelsMap)
-	buildObservedLabelMaps([]interface{}{clusterOwner}, "cluster", observedLabelsMap)
+	buildObservedLabelMaps([]interface{}{clusterOwner, dialer.TunnelDialsDenied}, "cluster", observedLabelsMap)
 
 	removedCount := removeMetricsForDeletedResource(observedLabelsMap, observedResourceNames)
 
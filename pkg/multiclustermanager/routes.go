@@ -29,6 +29,7 @@
 	"github.com/rancher/rancher/pkg/metrics"
 	"github.com/rancher/rancher/pkg/multiclustermanager/whitelist"
 	"github.com/rancher/rancher/pkg/rbac"
+	"github.com/rancher/rancher/pkg/sessionrecording"
 	"github.com/rancher/rancher/pkg/tunnelserver/mcmauthorizer"
 	"github.com/rancher/rancher/pkg/types/config"
 	"github.com/rancher/rancher/pkg/version"
@@ -65,6 +66,9 @@ func router(ctx context.Context, localClusterEnabled bool, tunnelAuthorizer *mcm
 	channelserver := channelserver.NewHandler(ctx)
 
 	supportConfigGenerator := supportconfigs.NewHandler(scaledContext)
+	supportBundleGenerator := supportconfigs.NewBundleHandler(scaledContext, clusterManager)
+	sessionRecordings := sessionrecording.NewHandler(sessionrecording.NewRecorder(scaledContext.Wrangler.Core.Secret()))
+	sars := scaledContext.K8sClient.AuthorizationV1().SubjectAccessReviews()
 	// Unauthenticated routes
 	unauthed := mux.NewRouter()
 	unauthed.UseEncodedPath()
@@ -111,6 +115,11 @@ func router(ctx context.Context, localClusterEnabled bool, tunnelAuthorizer *mcm
 	authed.Path("/meta/vsphere/{field}").Methods(http.MethodGet).Handler(vsphere.NewVsphereHandler(scaledContext))
 	authed.Path("/v3/tokenreview").Methods(http.MethodPost).Handler(&webhook.TokenReviewer{})
 	authed.Path(supportconfigs.Endpoint).Handler(&supportConfigGenerator)
+	authed.Path(supportconfigs.BundleEndpoint).Methods(http.MethodGet).Handler(supportBundleGenerator)
+	authed.Path(sessionrecording.Endpoint).Methods(http.MethodGet).
+		Handler(sessionrecording.NewAuthorizationHandler(sars, "list")(http.HandlerFunc(sessionRecordings.List)))
+	authed.Path(sessionrecording.Endpoint + "/{id}").Methods(http.MethodGet).
+		Handler(sessionrecording.NewAuthorizationHandler(sars, "get")(http.HandlerFunc(sessionRecordings.Replay)))
 	authed.PathPrefix("/meta/proxy").Handler(metaProxy)
 	authed.PathPrefix("/v3/identit").Handler(tokenAPI)
 	authed.PathPrefix("/v3/token").Handler(tokenAPI)
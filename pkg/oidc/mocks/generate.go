@@ -1,5 +1,6 @@
 //go:generate mockgen -source=../../controllers/management/oidcprovider/controller.go -destination=./strgenerator.go -package=mocks
 //go:generate mockgen -source=../provider/authorize.go -destination=./authorize.go -package=mocks
 //go:generate mockgen -source=../provider/token.go -destination=./token.go -package=mocks
+//go:generate mockgen -source=../provider/device.go -destination=./device.go -package=mocks
 
 package mocks
@@ -0,0 +1,145 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	oidcerror "github.com/rancher/rancher/pkg/oidc/provider/error"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	clientCredentialsGrantType = "client_credentials"
+
+	// clientCredentialsSubjectPrefix prefixes the subject of the access tokens issued to the principal bound to an OIDC
+	// client, so that it can never be the name of a Rancher user or the ID of a principal of another client.
+	clientCredentialsSubjectPrefix = "client:"
+)
+
+// createTokenFromClientCredentials creates a response with an access_token for the identity bound to the OIDC client.
+// Neither an id_token nor a refresh_token are issued, since there is no end-user involved.
+func (h *tokenHandler) createTokenFromClientCredentials(r *http.Request) (TokenResponse, *oidcerror.Error) {
+	// verify clientID and secret. This also records when the client secret was last used.
+	clientID, clientSecret := clientCredentialsFromRequest(r)
+	if clientID == "" {
+		return TokenResponse{}, oidcerror.New(oidcerror.InvalidRequest, "missing client_id")
+	}
+	oidcClient, oidcErr := h.verifyClient(clientID, clientSecret)
+	if oidcErr != nil {
+		return TokenResponse{}, oidcErr
+	}
+	credentials := oidcClient.Spec.ClientCredentials
+	if credentials == nil {
+		return TokenResponse{}, oidcerror.New(oidcerror.UnauthorizedClient, "client_credentials grant is not enabled for the client")
+	}
+
+	scopes, oidcErr := clientCredentialsScopes(strings.Fields(r.Form.Get("scope")), credentials.AllowedScopes)
+	if oidcErr != nil {
+		return TokenResponse{}, oidcErr
+	}
+
+	accessClaims := jwt.MapClaims{
+		"aud":        []string{oidcClient.Status.ClientID},
+		"exp":        h.now().Add(time.Duration(oidcClient.Spec.TokenExpirationSeconds) * time.Second).Unix(),
+		"iss":        settings.ServerURL.Get() + "/oidc",
+		"iat":        h.now().Unix(),
+		"scope":      scopes,
+		"client_id":  oidcClient.Status.ClientID,
+		"token_type": accessTokenType,
+	}
+	switch {
+	case credentials.UserName != "":
+		user, oidcErr := h.getClientCredentialsUser(credentials)
+		if oidcErr != nil {
+			return TokenResponse{}, oidcErr
+		}
+		groups, oidcErr := h.getUserGroups(user.Name)
+		if oidcErr != nil {
+			return TokenResponse{}, oidcErr
+		}
+		accessClaims["sub"] = user.Name
+		if slices.Contains(scopes, "profile") {
+			accessClaims["name"] = user.DisplayName
+		}
+		if groups != nil {
+			accessClaims["groups"] = groups
+		}
+	case credentials.PrincipalID != "":
+		accessClaims["sub"] = clientCredentialsSubject(oidcClient)
+	default:
+		return TokenResponse{}, oidcerror.New(oidcerror.ServerError, "client_credentials doesn't have an identity")
+	}
+
+	key, kid, err := h.jwks.GetSigningKey()
+	if err != nil {
+		return TokenResponse{}, oidcerror.New(oidcerror.ServerError, fmt.Sprintf("failed to get signing key: %v", err))
+	}
+	accessToken := jwt.NewWithClaims(jwt.SigningMethodRS256, accessClaims)
+	accessToken.Header["kid"] = kid
+	accessTokenString, err := accessToken.SignedString(key)
+	if err != nil {
+		logrus.Errorf("[OIDC provider] failed to sign access token %v", err)
+		return TokenResponse{}, oidcerror.New(oidcerror.ServerError, fmt.Sprintf("failed to sign access token: %v", err))
+	}
+
+	return TokenResponse{
+		AccessToken: accessTokenString,
+		TokenType:   bearerTokenType,
+		ExpiresIn:   time.Duration(oidcClient.Spec.TokenExpirationSeconds) * time.Second,
+	}, nil
+}
+
+// getClientCredentialsUser returns the Rancher user bound to the OIDC client if it exists and is enabled.
+func (h *tokenHandler) getClientCredentialsUser(credentials *v3.OIDCClientCredentials) (*v3.User, *oidcerror.Error) {
+	user, err := h.userLister.Get(credentials.UserName)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, oidcerror.New(oidcerror.AccessDenied, "user bound to the client doesn't exist")
+		}
+		return nil, oidcerror.New(oidcerror.ServerError, fmt.Sprintf("can't get user: %v", err))
+	}
+	if user.Enabled != nil && !*user.Enabled {
+		return nil, oidcerror.New(oidcerror.AccessDenied, "user is disabled")
+	}
+
+	return user, nil
+}
+
+// clientCredentialsScopes returns the scopes granted for a client_credentials request. Clients can only request
+// supported scopes they are allowed, and get all of them if they don't request any. offline_access is never granted,
+// since refresh tokens aren't issued for this grant.
+func clientCredentialsScopes(requested []string, allowed []string) ([]string, *oidcerror.Error) {
+	if len(requested) == 0 {
+		return slices.DeleteFunc(slices.Clone(allowed), func(scope string) bool {
+			return scope == "offline_access" || !slices.Contains(supportedScopes, scope)
+		}), nil
+	}
+	for _, scope := range requested {
+		if scope == "offline_access" || !slices.Contains(supportedScopes, scope) || !slices.Contains(allowed, scope) {
+			return nil, oidcerror.New(oidcerror.InvalidScope, fmt.Sprintf("scope %s is not allowed for the client", scope))
+		}
+	}
+
+	return requested, nil
+}
+
+// clientCredentialsSubject returns the subject of the access tokens issued to the principal bound to the OIDC client,
+// client:<client name>:<principal ID>.
+func clientCredentialsSubject(oidcClient *v3.OIDCClient) string {
+	return clientCredentialsSubjectPrefix + oidcClient.Name + ":" + oidcClient.Spec.ClientCredentials.PrincipalID
+}
+
+// isClientCredentialsPrincipal reports whether the subject is the principal bound to the OIDC client. Access tokens
+// issued to principals through the client_credentials grant don't belong to a Rancher user.
+func isClientCredentialsPrincipal(oidcClient *v3.OIDCClient, subject string) bool {
+	credentials := oidcClient.Spec.ClientCredentials
+
+	return credentials != nil && credentials.PrincipalID != "" && clientCredentialsSubject(oidcClient) == subject
+}
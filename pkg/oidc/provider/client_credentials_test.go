@@ -0,0 +1,361 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	fakeClientCredentialsPrincipal = "local://service"
+	fakeClientCredentialsSubject   = "client:" + fakeIntrospectClientName + ":" + fakeClientCredentialsPrincipal
+)
+
+func clientCredentialsRequest(scope string) *http.Request {
+	data := url.Values{}
+	data.Set("grant_type", "client_credentials")
+	if scope != "" {
+		data.Set("scope", scope)
+	}
+	req, _ := http.NewRequest(http.MethodPost, "https://rancher.com", bytes.NewBufferString(data.Encode()))
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add("Authorization", fmt.Sprintf("Basic %s", base64.StdEncoding.EncodeToString([]byte(fakeIntrospectClientID+":"+fakeIntrospectClientSecret))))
+
+	return req
+}
+
+func TestClientCredentialsGrant(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	assert.NoError(t, settings.ServerURL.Set("https://rancher.com"))
+
+	newOIDCClient := func(credentials *v3.OIDCClientCredentials) *v3.OIDCClient {
+		return &v3.OIDCClient{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        fakeIntrospectClientName,
+				Annotations: map[string]string{},
+			},
+			Spec: v3.OIDCClientSpec{
+				TokenExpirationSeconds: 600,
+				ClientCredentials:      credentials,
+			},
+			Status: v3.OIDCClientStatus{
+				ClientID: fakeIntrospectClientID,
+			},
+		}
+	}
+	userClient := newOIDCClient(&v3.OIDCClientCredentials{
+		UserName:      fakeIntrospectUserID,
+		AllowedScopes: []string{"openid", "profile"},
+	})
+	principalClient := newOIDCClient(&v3.OIDCClientCredentials{
+		PrincipalID:   fakeClientCredentialsPrincipal,
+		AllowedScopes: []string{"openid", "offline_access"},
+	})
+	fakeClientSecret := &v1.Secret{
+		Data: map[string][]byte{
+			fakeIntrospectClientSecretID: []byte(fakeIntrospectClientSecret),
+		},
+	}
+	fakeUser := &v3.User{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: fakeIntrospectUserID,
+		},
+		DisplayName: "Service",
+		Username:    fakeIntrospectUsername,
+		Enabled:     ptr.To(true),
+	}
+	clientAuthenticated := func(m introspectMocks, oidcClient *v3.OIDCClient) {
+		m.oidcClientCache.EXPECT().GetByIndex(oidcClientByIDIndex, fakeIntrospectClientID).Return([]*v3.OIDCClient{oidcClient}, nil)
+		m.secretCache.EXPECT().Get(secretsNamespace, fakeIntrospectClientID).Return(fakeClientSecret, nil)
+		m.oidcClient.EXPECT().Patch(fakeIntrospectClientName, types.JSONPatchType, gomock.Any()).DoAndReturn(func(name string, pt types.PatchType, data []byte, subresources ...any) (*v3.OIDCClient, error) {
+			assert.Equal(t, fmt.Sprintf(`[{"op":"add","path":"/metadata/annotations/cattle.io.oidc-client-secret-used-%s","value":"%d"}]`, fakeIntrospectClientSecretID, introspectNow.Unix()), string(data))
+			return oidcClient, nil
+		})
+	}
+
+	tests := map[string]struct {
+		req        func() *http.Request
+		mockSetup  func(introspectMocks, *fake.MockNonNamespacedCacheInterface[*v3.UserAttribute])
+		wantStatus int
+		wantBody   string
+		wantClaims jwt.MapClaims
+	}{
+		"user bound client": {
+			req: func() *http.Request {
+				return clientCredentialsRequest("")
+			},
+			mockSetup: func(m introspectMocks, userAttributeLister *fake.MockNonNamespacedCacheInterface[*v3.UserAttribute]) {
+				clientAuthenticated(m, userClient)
+				m.userLister.EXPECT().Get(fakeIntrospectUserID).Return(fakeUser, nil)
+				userAttributeLister.EXPECT().Get(fakeIntrospectUserID).Return(&v3.UserAttribute{
+					GroupPrincipals: map[string]v3.Principals{
+						"provider": {Items: []v3.Principal{{ObjectMeta: metav1.ObjectMeta{Name: "local://group"}}}},
+					},
+				}, nil)
+				m.signingKeyGetter.EXPECT().GetSigningKey().Return(introspectPrivateKey, fakeIntrospectSigningKey, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantClaims: jwt.MapClaims{
+				"aud":        []interface{}{fakeIntrospectClientID},
+				"exp":        float64(introspectNow.Add(600 * time.Second).Unix()),
+				"iat":        float64(introspectNow.Unix()),
+				"iss":        "https://rancher.com/oidc",
+				"sub":        fakeIntrospectUserID,
+				"scope":      []interface{}{"openid", "profile"},
+				"client_id":  fakeIntrospectClientID,
+				"token_type": accessTokenType,
+				"name":       "Service",
+				"groups":     []interface{}{"group"},
+			},
+		},
+		"principal bound client": {
+			req: func() *http.Request {
+				return clientCredentialsRequest("")
+			},
+			mockSetup: func(m introspectMocks, _ *fake.MockNonNamespacedCacheInterface[*v3.UserAttribute]) {
+				clientAuthenticated(m, principalClient)
+				m.signingKeyGetter.EXPECT().GetSigningKey().Return(introspectPrivateKey, fakeIntrospectSigningKey, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantClaims: jwt.MapClaims{
+				"aud":        []interface{}{fakeIntrospectClientID},
+				"exp":        float64(introspectNow.Add(600 * time.Second).Unix()),
+				"iat":        float64(introspectNow.Unix()),
+				"iss":        "https://rancher.com/oidc",
+				"sub":        fakeClientCredentialsSubject,
+				"scope":      []interface{}{"openid"},
+				"client_id":  fakeIntrospectClientID,
+				"token_type": accessTokenType,
+			},
+		},
+		"unsupported allowed scopes are not granted": {
+			req: func() *http.Request {
+				return clientCredentialsRequest("")
+			},
+			mockSetup: func(m introspectMocks, _ *fake.MockNonNamespacedCacheInterface[*v3.UserAttribute]) {
+				clientAuthenticated(m, newOIDCClient(&v3.OIDCClientCredentials{
+					PrincipalID:   fakeClientCredentialsPrincipal,
+					AllowedScopes: []string{"openid", "groups"},
+				}))
+				m.signingKeyGetter.EXPECT().GetSigningKey().Return(introspectPrivateKey, fakeIntrospectSigningKey, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantClaims: jwt.MapClaims{
+				"aud":        []interface{}{fakeIntrospectClientID},
+				"exp":        float64(introspectNow.Add(600 * time.Second).Unix()),
+				"iat":        float64(introspectNow.Unix()),
+				"iss":        "https://rancher.com/oidc",
+				"sub":        fakeClientCredentialsSubject,
+				"scope":      []interface{}{"openid"},
+				"client_id":  fakeIntrospectClientID,
+				"token_type": accessTokenType,
+			},
+		},
+		"requested scopes": {
+			req: func() *http.Request {
+				return clientCredentialsRequest("openid")
+			},
+			mockSetup: func(m introspectMocks, userAttributeLister *fake.MockNonNamespacedCacheInterface[*v3.UserAttribute]) {
+				clientAuthenticated(m, userClient)
+				m.userLister.EXPECT().Get(fakeIntrospectUserID).Return(fakeUser, nil)
+				userAttributeLister.EXPECT().Get(fakeIntrospectUserID).Return(nil, errors.NewNotFound(schema.GroupResource{}, fakeIntrospectUserID))
+				m.signingKeyGetter.EXPECT().GetSigningKey().Return(introspectPrivateKey, fakeIntrospectSigningKey, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantClaims: jwt.MapClaims{
+				"aud":        []interface{}{fakeIntrospectClientID},
+				"exp":        float64(introspectNow.Add(600 * time.Second).Unix()),
+				"iat":        float64(introspectNow.Unix()),
+				"iss":        "https://rancher.com/oidc",
+				"sub":        fakeIntrospectUserID,
+				"scope":      []interface{}{"openid"},
+				"client_id":  fakeIntrospectClientID,
+				"token_type": accessTokenType,
+			},
+		},
+		"scope not allowed": {
+			req: func() *http.Request {
+				return clientCredentialsRequest("openid groups")
+			},
+			mockSetup: func(m introspectMocks, _ *fake.MockNonNamespacedCacheInterface[*v3.UserAttribute]) {
+				clientAuthenticated(m, userClient)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"invalid_scope","error_description":"scope groups is not allowed for the client"}`,
+		},
+		"offline_access is never granted": {
+			req: func() *http.Request {
+				return clientCredentialsRequest("offline_access")
+			},
+			mockSetup: func(m introspectMocks, _ *fake.MockNonNamespacedCacheInterface[*v3.UserAttribute]) {
+				clientAuthenticated(m, principalClient)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"invalid_scope","error_description":"scope offline_access is not allowed for the client"}`,
+		},
+		"client without client credentials": {
+			req: func() *http.Request {
+				return clientCredentialsRequest("")
+			},
+			mockSetup: func(m introspectMocks, _ *fake.MockNonNamespacedCacheInterface[*v3.UserAttribute]) {
+				clientAuthenticated(m, newOIDCClient(nil))
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"unauthorized_client","error_description":"client_credentials grant is not enabled for the client"}`,
+		},
+		"disabled user": {
+			req: func() *http.Request {
+				return clientCredentialsRequest("")
+			},
+			mockSetup: func(m introspectMocks, _ *fake.MockNonNamespacedCacheInterface[*v3.UserAttribute]) {
+				clientAuthenticated(m, userClient)
+				disabledUser := fakeUser.DeepCopy()
+				disabledUser.Enabled = ptr.To(false)
+				m.userLister.EXPECT().Get(fakeIntrospectUserID).Return(disabledUser, nil)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"access_denied","error_description":"user is disabled"}`,
+		},
+		"user not found": {
+			req: func() *http.Request {
+				return clientCredentialsRequest("")
+			},
+			mockSetup: func(m introspectMocks, _ *fake.MockNonNamespacedCacheInterface[*v3.UserAttribute]) {
+				clientAuthenticated(m, userClient)
+				m.userLister.EXPECT().Get(fakeIntrospectUserID).Return(nil, errors.NewNotFound(schema.GroupResource{}, fakeIntrospectUserID))
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"access_denied","error_description":"user bound to the client doesn't exist"}`,
+		},
+		"invalid client secret": {
+			req: func() *http.Request {
+				req := clientCredentialsRequest("")
+				req.SetBasicAuth(fakeIntrospectClientID, "wrong")
+
+				return req
+			},
+			mockSetup: func(m introspectMocks, _ *fake.MockNonNamespacedCacheInterface[*v3.UserAttribute]) {
+				m.oidcClientCache.EXPECT().GetByIndex(oidcClientByIDIndex, fakeIntrospectClientID).Return([]*v3.OIDCClient{userClient}, nil)
+				m.secretCache.EXPECT().Get(secretsNamespace, fakeIntrospectClientID).Return(fakeClientSecret, nil)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"invalid_request","error_description":"invalid client_secret"}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := newIntrospectMocks(ctrl)
+			userAttributeLister := fake.NewMockNonNamespacedCacheInterface[*v3.UserAttribute](ctrl)
+			if test.mockSetup != nil {
+				test.mockSetup(m, userAttributeLister)
+			}
+			h := newTokenHandler(m.tokenCache, m.userLister, userAttributeLister, nil, m.signingKeyGetter, m.oidcClientCache, m.oidcClient, m.secretCache, m.tokenClient, nil, nil)
+			h.now = func() time.Time { return introspectNow }
+			rec := httptest.NewRecorder()
+
+			h.tokenEndpoint(rec, test.req())
+
+			assert.Equal(t, test.wantStatus, rec.Code)
+			if test.wantBody != "" {
+				assert.JSONEq(t, test.wantBody, strings.TrimSpace(rec.Body.String()))
+			}
+			if test.wantClaims != nil {
+				var resp map[string]any
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+				assert.Equal(t, "Bearer", resp["token_type"])
+				assert.NotContains(t, resp, "id_token")
+				assert.NotContains(t, resp, "refresh_token")
+
+				claims := jwt.MapClaims{}
+				_, err := jwt.ParseWithClaims(resp["access_token"].(string), &claims, func(token *jwt.Token) (interface{}, error) {
+					return &introspectPrivateKey.PublicKey, nil
+				})
+				// the token is issued at a fixed time in the past, so only the signature is checked.
+				var validationErr *jwt.ValidationError
+				if err != nil {
+					require.ErrorAs(t, err, &validationErr)
+					assert.Equal(t, jwt.ValidationErrorExpired, validationErr.Errors)
+				}
+				assert.Equal(t, test.wantClaims, claims)
+			}
+		})
+	}
+}
+
+func TestIntrospectClientCredentialsPrincipal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	m := newIntrospectMocks(ctrl)
+
+	oidcClient := &v3.OIDCClient{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: fakeIntrospectClientName,
+		},
+		Spec: v3.OIDCClientSpec{
+			ClientCredentials: &v3.OIDCClientCredentials{PrincipalID: fakeClientCredentialsPrincipal},
+		},
+		Status: v3.OIDCClientStatus{
+			ClientID: fakeIntrospectClientID,
+		},
+	}
+	accessToken := signIntrospectToken(jwt.MapClaims{
+		"aud":        []string{fakeIntrospectClientID},
+		"exp":        introspectNow.Add(time.Minute).Unix(),
+		"iat":        introspectNow.Unix(),
+		"sub":        fakeClientCredentialsSubject,
+		"scope":      []string{"openid"},
+		"token_type": accessTokenType,
+	})
+	m.signingKeyGetter.EXPECT().GetPublicKey(fakeIntrospectSigningKey).Return(&introspectPrivateKey.PublicKey, nil).Times(3)
+
+	h := newTokenHandler(m.tokenCache, m.userLister, nil, nil, m.signingKeyGetter, m.oidcClientCache, m.oidcClient, m.secretCache, m.tokenClient, nil, nil)
+	h.now = func() time.Time { return introspectNow }
+
+	resp, oidcErr := h.introspect(accessToken, oidcClient)
+	require.Nil(t, oidcErr)
+	assert.True(t, resp.Active)
+	assert.Equal(t, fakeClientCredentialsSubject, resp.Subject)
+	assert.Empty(t, resp.Username)
+
+	// tokens with the principal as subject, as if it was a user, are not issued to the principal.
+	m.userLister.EXPECT().Get(fakeClientCredentialsPrincipal).Return(nil, errors.NewNotFound(schema.GroupResource{}, fakeClientCredentialsPrincipal))
+	resp, oidcErr = h.introspect(signIntrospectToken(jwt.MapClaims{
+		"aud":        []string{fakeIntrospectClientID},
+		"exp":        introspectNow.Add(time.Minute).Unix(),
+		"iat":        introspectNow.Unix(),
+		"sub":        fakeClientCredentialsPrincipal,
+		"scope":      []string{"openid"},
+		"token_type": accessTokenType,
+	}), oidcClient)
+	require.Nil(t, oidcErr)
+	assert.False(t, resp.Active)
+
+	// tokens are no longer active once the client can't use the grant.
+	oidcClient.Spec.ClientCredentials = nil
+	m.userLister.EXPECT().Get(fakeClientCredentialsSubject).Return(nil, errors.NewNotFound(schema.GroupResource{}, fakeClientCredentialsSubject))
+
+	resp, oidcErr = h.introspect(accessToken, oidcClient)
+	require.Nil(t, oidcErr)
+	assert.False(t, resp.Active)
+}
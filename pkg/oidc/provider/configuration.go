@@ -17,6 +17,14 @@ type OpenIDConfiguration struct {
 	TokenEndpoint string `json:"token_endpoint"`
 	// UserInfoEndpoint is the userinfo endpoint
 	UserInfoEndpoint string `json:"userinfo_endpoint"`
+	// IntrospectionEndpoint is the token introspection endpoint
+	IntrospectionEndpoint string `json:"introspection_endpoint"`
+	// RevocationEndpoint is the token revocation endpoint
+	RevocationEndpoint string `json:"revocation_endpoint"`
+	// DeviceAuthorizationEndpoint is the device authorization endpoint
+	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+	// EndSessionEndpoint is the RP-initiated logout endpoint
+	EndSessionEndpoint string `json:"end_session_endpoint"`
 	// JWKSURI is the jwksuri endpoint
 	JWKSURI string `json:"jwks_uri"`
 	// ResponseTypesSupported response types supported, only 'code' is supported
@@ -29,23 +37,33 @@ type OpenIDConfiguration struct {
 	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported"`
 	// ScopesSupported can be openid, profile, offline_token
 	ScopesSupported []string `json:"scopes_supported"`
-	// GrantTypesSupported only code is supported
+	// GrantTypesSupported can be authorization_code, refresh_token, device_code and client_credentials
 	GrantTypesSupported []string `json:"grant_types_supported"`
+	// IntrospectionEndpointAuthMethodsSupported client authentication methods supported by the introspection endpoint
+	IntrospectionEndpointAuthMethodsSupported []string `json:"introspection_endpoint_auth_methods_supported"`
+	// RevocationEndpointAuthMethodsSupported client authentication methods supported by the revocation endpoint
+	RevocationEndpointAuthMethodsSupported []string `json:"revocation_endpoint_auth_methods_supported"`
 }
 
 func openIDConfigurationEndpoint(w http.ResponseWriter, r *http.Request) {
 	config := OpenIDConfiguration{
-		Issuer:                            oidcProviderHost(),
-		AuthorizationEndpoint:             oidcProviderHost() + "/authorize",
-		TokenEndpoint:                     oidcProviderHost() + "/token",
-		JWKSURI:                           oidcProviderHost() + "/.well-known/jwks.json",
-		UserInfoEndpoint:                  oidcProviderHost() + "/userinfo",
-		ResponseTypesSupported:            []string{"code"},
-		SubjectTypesSupported:             []string{"public"},
-		IDTokenSigningAlgsValuesSupported: []string{"RS256"},
-		CodeChallengeMethodsSupported:     []string{"S256"},
-		ScopesSupported:                   []string{"openid", "profile", "offline_access"},
-		GrantTypesSupported:               []string{"authorization_code", "refresh_token"},
+		Issuer:                                    oidcProviderHost(),
+		AuthorizationEndpoint:                     oidcProviderHost() + "/authorize",
+		TokenEndpoint:                             oidcProviderHost() + "/token",
+		JWKSURI:                                   oidcProviderHost() + "/.well-known/jwks.json",
+		UserInfoEndpoint:                          oidcProviderHost() + "/userinfo",
+		IntrospectionEndpoint:                     oidcProviderHost() + "/introspect",
+		RevocationEndpoint:                        oidcProviderHost() + "/revoke",
+		DeviceAuthorizationEndpoint:               oidcProviderHost() + "/device_authorization",
+		EndSessionEndpoint:                        oidcProviderHost() + "/end_session",
+		ResponseTypesSupported:                    []string{"code"},
+		SubjectTypesSupported:                     []string{"public"},
+		IDTokenSigningAlgsValuesSupported:         []string{"RS256"},
+		CodeChallengeMethodsSupported:             []string{"S256"},
+		ScopesSupported:                           []string{"openid", "profile", "offline_access"},
+		GrantTypesSupported:                       []string{"authorization_code", "refresh_token", deviceCodeGrantType, clientCredentialsGrantType},
+		IntrospectionEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post"},
+		RevocationEndpointAuthMethodsSupported:    []string{"client_secret_basic", "client_secret_post"},
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -19,5 +19,5 @@ func TestOpenIDConfigurationEndpoint(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, rec.Code)
 	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
-	assert.JSONEq(t, `{"issuer":"https://rancher.com/oidc","authorization_endpoint":"https://rancher.com/oidc/authorize","token_endpoint":"https://rancher.com/oidc/token","userinfo_endpoint":"https://rancher.com/oidc/userinfo","jwks_uri":"https://rancher.com/oidc/.well-known/jwks.json","response_types_supported":["code"],"subject_types_supported":["public"],"id_token_signing_alg_values_supported":["RS256"],"code_challenge_methods_supported":["S256"],"scopes_supported":["openid","profile","offline_access"],"grant_types_supported":["authorization_code","refresh_token"]}`, strings.TrimSpace(rec.Body.String()))
+	assert.JSONEq(t, `{"issuer":"https://rancher.com/oidc","authorization_endpoint":"https://rancher.com/oidc/authorize","token_endpoint":"https://rancher.com/oidc/token","userinfo_endpoint":"https://rancher.com/oidc/userinfo","introspection_endpoint":"https://rancher.com/oidc/introspect","revocation_endpoint":"https://rancher.com/oidc/revoke","device_authorization_endpoint":"https://rancher.com/oidc/device_authorization","end_session_endpoint":"https://rancher.com/oidc/end_session","jwks_uri":"https://rancher.com/oidc/.well-known/jwks.json","response_types_supported":["code"],"subject_types_supported":["public"],"id_token_signing_alg_values_supported":["RS256"],"code_challenge_methods_supported":["S256"],"scopes_supported":["openid","profile","offline_access"],"grant_types_supported":["authorization_code","refresh_token","urn:ietf:params:oauth:grant-type:device_code","client_credentials"],"introspection_endpoint_auth_methods_supported":["client_secret_basic","client_secret_post"],"revocation_endpoint_auth_methods_supported":["client_secret_basic","client_secret_post"]}`, strings.TrimSpace(rec.Body.String()))
 }
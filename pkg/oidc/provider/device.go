@@ -0,0 +1,359 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	wrangmgmtv3 "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/oidc/provider/device"
+	oidcerror "github.com/rancher/rancher/pkg/oidc/provider/error"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/rancher/wrangler/v3/pkg/randomtoken"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+	// deviceCodeInterval is the minimum amount of seconds devices must wait between polling requests.
+	deviceCodeInterval = 5
+	deviceCSRFCookie   = "R_OIDC_DEVICE_CSRF"
+)
+
+type deviceCodeStore interface {
+	Add(deviceCode string, authorization device.Authorization) error
+	Get(deviceCode string) (*device.Authorization, error)
+	GetByUserCode(userCode string) (string, *device.Authorization, error)
+	Update(deviceCode string, update func(*device.Authorization) error) error
+	Remove(deviceCode string) error
+}
+
+type deviceCodeCreator interface {
+	GenerateDeviceCode() (string, error)
+	GenerateUserCode() (string, error)
+}
+
+// DeviceAuthorizationResponse represents a successful response returned by the device authorization endpoint as
+// defined in RFC 8628.
+type DeviceAuthorizationResponse struct {
+	// DeviceCode is the code the device uses to poll the token endpoint.
+	DeviceCode string `json:"device_code"`
+	// UserCode is the code the user enters in the verification page.
+	UserCode string `json:"user_code"`
+	// VerificationURI is the verification page.
+	VerificationURI string `json:"verification_uri"`
+	// VerificationURIComplete is the verification page including the user code.
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	// ExpiresIn is the lifetime in seconds of the device code and user code.
+	ExpiresIn int `json:"expires_in"`
+	// Interval is the minimum amount of seconds the device must wait between polling requests.
+	Interval int `json:"interval"`
+}
+
+// deviceAuthorizationEndpoint handles the device authorization endpoint of the OIDC provider as defined in RFC 8628.
+// Devices must authenticate with a client secret, here and when polling the token endpoint. The provider has no public
+// clients: every OIDC client gets client secrets, which all grants require. Otherwise, anyone who learnt the client ID
+// could start device authorizations, which users would approve believing they come from the real client.
+func (h *tokenHandler) deviceAuthorizationEndpoint(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		oidcerror.WriteError(oidcerror.InvalidRequest, "method not allowed", http.StatusMethodNotAllowed, w)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		oidcerror.WriteError(oidcerror.InvalidRequest, fmt.Sprintf("error parsing parameters from request %v", err), http.StatusBadRequest, w)
+		return
+	}
+	oidcClient, oidcErr := h.authenticateClient(r)
+	if oidcErr != nil {
+		oidcErr.Write(http.StatusUnauthorized, w)
+		return
+	}
+	scopes := strings.Fields(r.PostForm.Get("scope"))
+	if !slices.Contains(scopes, "openid") {
+		oidcerror.WriteError(oidcerror.InvalidScope, "missing openid scope", http.StatusBadRequest, w)
+		return
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(supportedScopes, scope) {
+			oidcerror.WriteError(oidcerror.InvalidScope, fmt.Sprintf("invalid scope: %s", scope), http.StatusBadRequest, w)
+			return
+		}
+	}
+
+	deviceCode, err := h.deviceCodeCreator.GenerateDeviceCode()
+	if err != nil {
+		oidcerror.WriteError(oidcerror.ServerError, fmt.Sprintf("failed to generate device code: %v", err), http.StatusInternalServerError, w)
+		return
+	}
+	userCode, err := h.deviceCodeCreator.GenerateUserCode()
+	if err != nil {
+		oidcerror.WriteError(oidcerror.ServerError, fmt.Sprintf("failed to generate user code: %v", err), http.StatusInternalServerError, w)
+		return
+	}
+	err = h.deviceStore.Add(deviceCode, device.Authorization{
+		ClientID:  oidcClient.Status.ClientID,
+		UserCode:  userCode,
+		Scope:     scopes,
+		Status:    device.StatusPending,
+		Interval:  deviceCodeInterval,
+		CreatedAt: h.now(),
+	})
+	if err != nil {
+		logrus.Errorf("[OIDC provider] error adding device authorization %v", err)
+		oidcerror.WriteError(oidcerror.ServerError, fmt.Sprintf("failed to store device authorization: %v", err), http.StatusInternalServerError, w)
+		return
+	}
+
+	resp := DeviceAuthorizationResponse{
+		DeviceCode:              deviceCode,
+		UserCode:                userCode,
+		VerificationURI:         oidcProviderHost() + "/device",
+		VerificationURIComplete: oidcProviderHost() + "/device?user_code=" + userCode,
+		ExpiresIn:               int(maxTime.Seconds()),
+		Interval:                deviceCodeInterval,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		oidcerror.WriteError(oidcerror.ServerError, "failed to encode device authorization response", http.StatusInternalServerError, w)
+	}
+}
+
+// createTokenFromDeviceCode creates a response with an id_token, access_token and refresh_token once the user
+// approved the device authorization. Devices poll this until the user approves or denies it.
+func (h *tokenHandler) createTokenFromDeviceCode(r *http.Request) (TokenResponse, *oidcerror.Error) {
+	deviceCode := r.Form.Get("device_code")
+	authorization, err := h.deviceStore.Get(deviceCode)
+	if err != nil {
+		if errors.Is(err, device.ErrExpired) {
+			return TokenResponse{}, oidcerror.New(oidcerror.ExpiredToken, "device_code has expired")
+		}
+		if apierrors.IsNotFound(err) {
+			return TokenResponse{}, oidcerror.New(oidcerror.InvalidRequest, "invalid device_code")
+		}
+		return TokenResponse{}, oidcerror.New(oidcerror.ServerError, "error retrieving device authorization: "+err.Error())
+	}
+
+	// verify clientID and secret.
+	clientID, clientSecret := clientCredentialsFromRequest(r)
+	if clientID != authorization.ClientID {
+		return TokenResponse{}, oidcerror.New(oidcerror.InvalidRequest, "invalid client_id")
+	}
+	oidcClient, oidcErr := h.verifyClient(clientID, clientSecret)
+	if oidcErr != nil {
+		return TokenResponse{}, oidcErr
+	}
+
+	switch authorization.Status {
+	case device.StatusApproved:
+		rancherToken, err := h.tokenCache.Get(authorization.TokenName)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return TokenResponse{}, oidcerror.New(oidcerror.InvalidRequest, "Rancher token is not valid anymore")
+			}
+			return TokenResponse{}, oidcerror.New(oidcerror.ServerError, "failed to get Rancher token: "+err.Error())
+		}
+		resp, oidcErr := h.createTokenResponse(rancherToken, oidcClient, "", authorization.Scope)
+		if oidcErr == nil {
+			if err := h.deviceStore.Remove(deviceCode); err != nil && !apierrors.IsNotFound(err) {
+				logrus.Warnf("[OIDC provider] error removing device authorization: %v", err)
+			}
+		}
+		return resp, oidcErr
+	case device.StatusDenied:
+		if err := h.deviceStore.Remove(deviceCode); err != nil && !apierrors.IsNotFound(err) {
+			logrus.Warnf("[OIDC provider] error removing device authorization: %v", err)
+		}
+		return TokenResponse{}, oidcerror.New(oidcerror.AccessDenied, "the user denied the authorization request")
+	default:
+		slowDown := false
+		err := h.deviceStore.Update(deviceCode, func(a *device.Authorization) error {
+			// devices polling too fast have to wait 5 more seconds between requests, as described in RFC 8628.
+			if h.now().Sub(a.LastPolledAt) < time.Duration(a.Interval)*time.Second {
+				slowDown = true
+				a.Interval += deviceCodeInterval
+			}
+			a.LastPolledAt = h.now()
+			return nil
+		})
+		if err != nil {
+			return TokenResponse{}, oidcerror.New(oidcerror.ServerError, "error updating device authorization: "+err.Error())
+		}
+		if slowDown {
+			return TokenResponse{}, oidcerror.New(oidcerror.SlowDown, "polling too frequently")
+		}
+		return TokenResponse{}, oidcerror.New(oidcerror.AuthorizationPending, "the user hasn't completed the authorization request yet")
+	}
+}
+
+// devicePage holds the data rendered in the device verification page.
+type devicePage struct {
+	LoginURL   string
+	UserCode   string
+	ClientName string
+	Scopes     []string
+	CSRF       string
+	Message    string
+	Error      string
+}
+
+var devicePageTemplate = template.Must(template.New("device").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Rancher - Device Authorization</title></head>
+<body>
+<h1>Device Authorization</h1>
+{{- if .Error}}<p role="alert">{{.Error}}</p>{{end}}
+{{- if .Message}}<p>{{.Message}}</p>
+{{- else if .LoginURL}}<p>You need to <a href="{{.LoginURL}}">log in to Rancher</a> before authorizing a device. Open this page again once you are logged in.</p>
+{{- else if .ClientName}}
+<p><strong>{{.ClientName}}</strong> is requesting access to your Rancher account with the following scopes: {{range $i, $s := .Scopes}}{{if $i}}, {{end}}{{$s}}{{end}}.</p>
+<p>Make sure the code <strong>{{.UserCode}}</strong> is the code displayed on your device.</p>
+<form method="post">
+<input type="hidden" name="user_code" value="{{.UserCode}}">
+<input type="hidden" name="csrf" value="{{.CSRF}}">
+<button type="submit" name="action" value="approve">Approve</button>
+<button type="submit" name="action" value="deny">Deny</button>
+</form>
+{{- else}}
+<form method="get">
+<label for="user_code">Enter the code displayed on your device</label>
+<input id="user_code" name="user_code" autocomplete="off" autofocus>
+<button type="submit">Continue</button>
+</form>
+{{- end}}
+</body>
+</html>
+`))
+
+type deviceVerificationHandler struct {
+	deviceStore     deviceCodeStore
+	oidcClientCache wrangmgmtv3.OIDCClientCache
+	tokenVerifier   rancherTokenVerifier
+}
+
+func newDeviceVerificationHandler(deviceStore deviceCodeStore, oidcClientCache wrangmgmtv3.OIDCClientCache, tokenVerifier rancherTokenVerifier) *deviceVerificationHandler {
+	return &deviceVerificationHandler{
+		deviceStore:     deviceStore,
+		oidcClientCache: oidcClientCache,
+		tokenVerifier:   tokenVerifier,
+	}
+}
+
+// verificationEndpoint handles the page where users logged in to Rancher enter the user code displayed on their
+// device, and approve or deny the device authorization.
+func (h *deviceVerificationHandler) verificationEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	if err := r.ParseForm(); err != nil {
+		h.render(w, http.StatusBadRequest, devicePage{Error: "Invalid request."})
+		return
+	}
+	token, err := h.tokenVerifier.getAndVerifyRancherTokenFromRequest(r)
+	if err != nil {
+		h.render(w, http.StatusUnauthorized, devicePage{LoginURL: settings.ServerURL.Get() + "/dashboard/auth/login"})
+		return
+	}
+
+	userCode := normalizeUserCode(r.Form.Get("user_code"))
+	if userCode == "" {
+		h.render(w, http.StatusOK, devicePage{})
+		return
+	}
+	deviceCode, authorization, err := h.deviceStore.GetByUserCode(userCode)
+	if err != nil || authorization.Status != device.StatusPending {
+		logrus.Debugf("[OIDC provider] invalid user code: %v", err)
+		h.render(w, http.StatusBadRequest, devicePage{Error: "The code is invalid or has expired."})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		oidcClients, err := h.oidcClientCache.GetByIndex(oidcClientByIDIndex, authorization.ClientID)
+		if err != nil || len(oidcClients) == 0 {
+			h.render(w, http.StatusBadRequest, devicePage{Error: "The OIDC client can't be found."})
+			return
+		}
+		csrf, err := randomtoken.Generate()
+		if err != nil {
+			h.render(w, http.StatusInternalServerError, devicePage{Error: "Failed to process the request."})
+			return
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     deviceCSRFCookie,
+			Value:    csrf,
+			Path:     r.URL.Path,
+			Secure:   r.URL.Scheme == "https" || r.TLS != nil,
+			HttpOnly: true,
+			SameSite: http.SameSiteStrictMode,
+			MaxAge:   int(maxTime.Seconds()),
+		})
+		h.render(w, http.StatusOK, devicePage{
+			UserCode:   userCode,
+			ClientName: oidcClients[0].Name,
+			Scopes:     authorization.Scope,
+			CSRF:       csrf,
+		})
+	case http.MethodPost:
+		cookie, err := r.Cookie(deviceCSRFCookie)
+		if err != nil || cookie.Value == "" || cookie.Value != r.PostForm.Get("csrf") {
+			h.render(w, http.StatusForbidden, devicePage{Error: "The request could not be verified. Please enter the code again."})
+			return
+		}
+		approve := r.PostForm.Get("action") == "approve"
+		err = h.deviceStore.Update(deviceCode, func(a *device.Authorization) error {
+			if a.Status != device.StatusPending {
+				return fmt.Errorf("device authorization is already %s", a.Status)
+			}
+			if approve {
+				a.Status = device.StatusApproved
+				a.TokenName = token.Name
+			} else {
+				a.Status = device.StatusDenied
+			}
+			return nil
+		})
+		if err != nil {
+			logrus.Errorf("[OIDC provider] error updating device authorization: %v", err)
+			h.render(w, http.StatusInternalServerError, devicePage{Error: "Failed to process the request."})
+			return
+		}
+		if approve {
+			h.render(w, http.StatusOK, devicePage{Message: "The device has been authorized. You can return to your device."})
+		} else {
+			h.render(w, http.StatusOK, devicePage{Message: "The device authorization has been denied."})
+		}
+	default:
+		h.render(w, http.StatusMethodNotAllowed, devicePage{Error: "Method not allowed."})
+	}
+}
+
+func (h *deviceVerificationHandler) render(w http.ResponseWriter, status int, page devicePage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	// the page must not be framed, otherwise another site could trick the user into approving a device
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Content-Security-Policy", "frame-ancestors 'none'")
+	w.WriteHeader(status)
+	if err := devicePageTemplate.Execute(w, page); err != nil {
+		logrus.Errorf("[OIDC provider] failed to render device verification page: %v", err)
+	}
+}
+
+// normalizeUserCode makes user codes case-insensitive and accepts them with or without the dash.
+func normalizeUserCode(userCode string) string {
+	code := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(userCode)))
+	if len(code) != 8 {
+		return code
+	}
+
+	return code[:4] + "-" + code[4:]
+}
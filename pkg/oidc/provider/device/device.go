@@ -0,0 +1,234 @@
+package device
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	namespace     = "cattle-oidc-codes"
+	secretKey     = "authorization"
+	secretLabel   = "cattle.io/oidc-device-code"
+	userCodeLabel = "cattle.io/oidc-user-code"
+)
+
+const (
+	// StatusPending is the status of an authorization until the user approves or denies it.
+	StatusPending = "pending"
+	// StatusApproved is the status of an authorization approved by the user.
+	StatusApproved = "approved"
+	// StatusDenied is the status of an authorization denied by the user.
+	StatusDenied = "denied"
+)
+
+// ErrExpired is returned when the device code has expired.
+var ErrExpired = errors.New("the device code has expired")
+
+// Authorization holds information provided in the device authorization endpoint, and the decision of the user in the
+// verification page. It is used in the token endpoint when the device polls for tokens.
+type Authorization struct {
+	// ClientID represents the OIDC client id
+	ClientID string
+	// UserCode is the code the user enters in the verification page
+	UserCode string
+	// Scope is the OIDC scope
+	Scope []string
+	// Status is pending until the user approves or denies the authorization
+	Status string
+	// TokenName is the Rancher token name of the user that approved the authorization
+	TokenName string
+	// Interval is the minimum amount of seconds the device must wait between polling requests
+	Interval int
+	// LastPolledAt represents when the device last polled the token endpoint
+	LastPolledAt time.Time
+	// CreatedAt represents when the authorization was created
+	CreatedAt time.Time
+}
+
+// SecretDeviceCodeStore stores device authorizations in k8s secrets. The name of the secret is the device code
+// generated in the device authorization endpoint, and the user code is stored as a label.
+type SecretDeviceCodeStore struct {
+	secretCache  corecontrollers.SecretCache
+	secretClient corecontrollers.SecretClient
+	expiryTime   time.Duration
+	mu           sync.Mutex
+}
+
+// NewSecretDeviceCodeStore creates a new SecretDeviceCodeStore
+func NewSecretDeviceCodeStore(ctx context.Context, secretCache corecontrollers.SecretCache, secretClient corecontrollers.SecretClient, expiryTime time.Duration) *SecretDeviceCodeStore {
+	storage := &SecretDeviceCodeStore{
+		secretCache:  secretCache,
+		secretClient: secretClient,
+		expiryTime:   expiryTime,
+	}
+	t := time.NewTicker(expiryTime)
+	// device codes are only valid for the expiry time. Therefore, we need to clean the expired authorizations.
+	go storage.cleanUpExpiredAuthorizations(ctx, t.C)
+
+	return storage
+}
+
+// Add stores an authorization referenced by a device code in a k8s secret.
+func (m *SecretDeviceCodeStore) Add(deviceCode string, authorization Authorization) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, err := m.secretClient.List(namespace, metav1.ListOptions{
+		LabelSelector: labels.Set{userCodeLabel: authorization.UserCode}.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("error checking user code: %v", err)
+	}
+	if len(existing.Items) > 0 {
+		return fmt.Errorf("user code already exists")
+	}
+	authorizationBytes, err := json.Marshal(authorization)
+	if err != nil {
+		return fmt.Errorf("error marshalling device authorization: %v", err)
+	}
+	_, err = m.secretClient.Create(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deviceCode,
+			Namespace: namespace,
+			Labels: map[string]string{
+				secretLabel:   "true",
+				userCodeLabel: authorization.UserCode,
+			},
+		},
+		Data: map[string][]byte{
+			secretKey: authorizationBytes,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating device authorization: %v", err)
+	}
+
+	return nil
+}
+
+// Get retrieves the authorization associated with the given device code. ErrExpired is returned if the device code
+// has expired, and a NotFound error if it doesn't exist.
+func (m *SecretDeviceCodeStore) Get(deviceCode string) (*Authorization, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	secret, err := m.secretClient.Get(namespace, deviceCode, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return m.authorizationFromSecret(secret)
+}
+
+// GetByUserCode retrieves the device code and authorization associated with the given user code.
+func (m *SecretDeviceCodeStore) GetByUserCode(userCode string) (string, *Authorization, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	secrets, err := m.secretClient.List(namespace, metav1.ListOptions{
+		LabelSelector: labels.Set{secretLabel: "true", userCodeLabel: userCode}.String(),
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("error getting user code: %v", err)
+	}
+	if len(secrets.Items) != 1 {
+		return "", nil, fmt.Errorf("invalid user code")
+	}
+	authorization, err := m.authorizationFromSecret(&secrets.Items[0])
+	if err != nil {
+		return "", nil, err
+	}
+
+	return secrets.Items[0].Name, authorization, nil
+}
+
+// Update applies the given function to the authorization associated with the device code, and stores the result.
+func (m *SecretDeviceCodeStore) Update(deviceCode string, update func(*Authorization) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret, err := m.secretClient.Get(namespace, deviceCode, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		authorization, err := m.authorizationFromSecret(secret)
+		if err != nil {
+			return err
+		}
+		if err := update(authorization); err != nil {
+			return err
+		}
+		authorizationBytes, err := json.Marshal(authorization)
+		if err != nil {
+			return fmt.Errorf("error marshalling device authorization: %v", err)
+		}
+		secret = secret.DeepCopy()
+		secret.Data[secretKey] = authorizationBytes
+		_, err = m.secretClient.Update(secret)
+
+		return err
+	})
+}
+
+// Remove removes the authorization associated with the given device code.
+func (m *SecretDeviceCodeStore) Remove(deviceCode string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.secretClient.Delete(namespace, deviceCode, &metav1.DeleteOptions{})
+}
+
+func (m *SecretDeviceCodeStore) authorizationFromSecret(secret *corev1.Secret) (*Authorization, error) {
+	var authorization Authorization
+	if err := json.Unmarshal(secret.Data[secretKey], &authorization); err != nil {
+		return nil, fmt.Errorf("error unmarshalling device authorization: %v", err)
+	}
+	if time.Since(authorization.CreatedAt) > m.expiryTime {
+		return nil, ErrExpired
+	}
+
+	return &authorization, nil
+}
+
+func (m *SecretDeviceCodeStore) cleanUpExpiredAuthorizations(ctx context.Context, c <-chan time.Time) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c:
+			m.mu.Lock()
+			secrets, err := m.secretCache.List(namespace, labels.Set{secretLabel: "true"}.AsSelector())
+			if err != nil {
+				logrus.Errorf("[OIDC provider] error listing device codes: %v", err)
+				m.mu.Unlock()
+				continue
+			}
+			for _, secret := range secrets {
+				var authorization Authorization
+				if err := json.Unmarshal(secret.Data[secretKey], &authorization); err != nil {
+					logrus.Errorf("[OIDC provider] error unmarshalling device authorization: %v", err)
+				}
+				if time.Since(authorization.CreatedAt) > m.expiryTime {
+					err := m.secretClient.Delete(namespace, secret.Name, &metav1.DeleteOptions{})
+					if err != nil && !apierrors.IsNotFound(err) {
+						logrus.Errorf("[OIDC provider] error deleting device code: %v", err)
+					}
+				}
+			}
+			m.mu.Unlock()
+		}
+	}
+}
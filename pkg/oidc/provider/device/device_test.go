@@ -0,0 +1,237 @@
+package device
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	fakeDeviceCode = "device-code"
+	fakeUserCode   = "BCDF-GHJK"
+)
+
+func authorizationSecret(authorization Authorization) *v1.Secret {
+	authorizationBytes, _ := json.Marshal(authorization)
+
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fakeDeviceCode,
+			Namespace: namespace,
+			Labels: map[string]string{
+				secretLabel:   "true",
+				userCodeLabel: authorization.UserCode,
+			},
+		},
+		Data: map[string][]byte{
+			secretKey: authorizationBytes,
+		},
+	}
+}
+
+func TestAdd(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	authorization := Authorization{
+		ClientID:  "client-id",
+		UserCode:  fakeUserCode,
+		Status:    StatusPending,
+		CreatedAt: time.Now(),
+	}
+	userCodeListOptions := metav1.ListOptions{LabelSelector: userCodeLabel + "=" + fakeUserCode}
+
+	tests := map[string]struct {
+		secretClient   func() corev1.SecretClient
+		expectedErrMsg string
+	}{
+		"user code is not present": {
+			secretClient: func() corev1.SecretClient {
+				mock := fake.NewMockClientInterface[*v1.Secret, *v1.SecretList](ctrl)
+				mock.EXPECT().List(namespace, userCodeListOptions).Return(&v1.SecretList{}, nil)
+				mock.EXPECT().Create(authorizationSecret(authorization)).Return(&v1.Secret{}, nil)
+
+				return mock
+			},
+		},
+		"user code is already present": {
+			secretClient: func() corev1.SecretClient {
+				mock := fake.NewMockClientInterface[*v1.Secret, *v1.SecretList](ctrl)
+				mock.EXPECT().List(namespace, userCodeListOptions).Return(&v1.SecretList{
+					Items: []v1.Secret{*authorizationSecret(authorization)},
+				}, nil)
+
+				return mock
+			},
+			expectedErrMsg: "user code already exists",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			store := &SecretDeviceCodeStore{
+				secretClient: test.secretClient(),
+				expiryTime:   time.Hour,
+			}
+
+			err := store.Add(fakeDeviceCode, authorization)
+
+			if test.expectedErrMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, test.expectedErrMsg)
+			}
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	authorization := Authorization{
+		ClientID:  "client-id",
+		UserCode:  fakeUserCode,
+		Status:    StatusPending,
+		CreatedAt: time.Now().Truncate(time.Second),
+	}
+	expiredAuthorization := authorization
+	expiredAuthorization.CreatedAt = time.Now().Add(-2 * time.Hour)
+
+	tests := map[string]struct {
+		secretClient          func() corev1.SecretClient
+		expectedAuthorization *Authorization
+		expectedErr           func(error) bool
+	}{
+		"device code is present": {
+			secretClient: func() corev1.SecretClient {
+				mock := fake.NewMockClientInterface[*v1.Secret, *v1.SecretList](ctrl)
+				mock.EXPECT().Get(namespace, fakeDeviceCode, metav1.GetOptions{}).Return(authorizationSecret(authorization), nil)
+
+				return mock
+			},
+			expectedAuthorization: &authorization,
+		},
+		"device code has expired": {
+			secretClient: func() corev1.SecretClient {
+				mock := fake.NewMockClientInterface[*v1.Secret, *v1.SecretList](ctrl)
+				mock.EXPECT().Get(namespace, fakeDeviceCode, metav1.GetOptions{}).Return(authorizationSecret(expiredAuthorization), nil)
+
+				return mock
+			},
+			expectedErr: func(err error) bool {
+				return err == ErrExpired
+			},
+		},
+		"device code is not present": {
+			secretClient: func() corev1.SecretClient {
+				mock := fake.NewMockClientInterface[*v1.Secret, *v1.SecretList](ctrl)
+				mock.EXPECT().Get(namespace, fakeDeviceCode, metav1.GetOptions{}).Return(nil, errors.NewNotFound(schema.GroupResource{}, fakeDeviceCode))
+
+				return mock
+			},
+			expectedErr: errors.IsNotFound,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			store := &SecretDeviceCodeStore{
+				secretClient: test.secretClient(),
+				expiryTime:   time.Hour,
+			}
+
+			got, err := store.Get(fakeDeviceCode)
+
+			if test.expectedErr != nil {
+				assert.True(t, test.expectedErr(err))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedAuthorization.ClientID, got.ClientID)
+				assert.Equal(t, test.expectedAuthorization.Status, got.Status)
+				assert.True(t, test.expectedAuthorization.CreatedAt.Equal(got.CreatedAt))
+			}
+		})
+	}
+}
+
+func TestGetByUserCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	authorization := Authorization{
+		ClientID:  "client-id",
+		UserCode:  fakeUserCode,
+		Status:    StatusPending,
+		CreatedAt: time.Now(),
+	}
+	listOptions := metav1.ListOptions{LabelSelector: secretLabel + "=true," + userCodeLabel + "=" + fakeUserCode}
+
+	mock := fake.NewMockClientInterface[*v1.Secret, *v1.SecretList](ctrl)
+	mock.EXPECT().List(namespace, listOptions).Return(&v1.SecretList{
+		Items: []v1.Secret{*authorizationSecret(authorization)},
+	}, nil)
+	mock.EXPECT().List(namespace, listOptions).Return(&v1.SecretList{}, nil)
+	store := &SecretDeviceCodeStore{
+		secretClient: mock,
+		expiryTime:   time.Hour,
+	}
+
+	deviceCode, got, err := store.GetByUserCode(fakeUserCode)
+	assert.NoError(t, err)
+	assert.Equal(t, fakeDeviceCode, deviceCode)
+	assert.Equal(t, "client-id", got.ClientID)
+
+	_, _, err = store.GetByUserCode(fakeUserCode)
+	assert.ErrorContains(t, err, "invalid user code")
+}
+
+func TestUpdate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	authorization := Authorization{
+		ClientID:  "client-id",
+		UserCode:  fakeUserCode,
+		Status:    StatusPending,
+		CreatedAt: time.Now(),
+	}
+	approved := authorization
+	approved.Status = StatusApproved
+	approved.TokenName = "token-name"
+
+	mock := fake.NewMockClientInterface[*v1.Secret, *v1.SecretList](ctrl)
+	mock.EXPECT().Get(namespace, fakeDeviceCode, metav1.GetOptions{}).Return(authorizationSecret(authorization), nil)
+	mock.EXPECT().Update(authorizationSecret(approved)).Return(&v1.Secret{}, nil)
+	store := &SecretDeviceCodeStore{
+		secretClient: mock,
+		expiryTime:   time.Hour,
+	}
+
+	err := store.Update(fakeDeviceCode, func(a *Authorization) error {
+		a.Status = StatusApproved
+		a.TokenName = "token-name"
+		return nil
+	})
+	assert.NoError(t, err)
+
+	mock.EXPECT().Get(namespace, fakeDeviceCode, metav1.GetOptions{}).Return(authorizationSecret(approved), nil)
+	err = store.Update(fakeDeviceCode, func(a *Authorization) error {
+		return fmt.Errorf("already %s", a.Status)
+	})
+	assert.ErrorContains(t, err, "already approved")
+}
+
+func TestRemove(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mock := fake.NewMockClientInterface[*v1.Secret, *v1.SecretList](ctrl)
+	mock.EXPECT().Delete(namespace, fakeDeviceCode, &metav1.DeleteOptions{}).Return(nil)
+	store := &SecretDeviceCodeStore{
+		secretClient: mock,
+		expiryTime:   time.Hour,
+	}
+
+	assert.NoError(t, store.Remove(fakeDeviceCode))
+}
@@ -0,0 +1,409 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/oidc/mocks"
+	"github.com/rancher/rancher/pkg/oidc/provider/device"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	fakeDeviceCode = "device-code"
+	fakeUserCode   = "BCDF-GHJK"
+)
+
+func deviceRequest(data url.Values) *http.Request {
+	req, _ := http.NewRequest(http.MethodPost, "https://rancher.com", bytes.NewBufferString(data.Encode()))
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add("Authorization", fmt.Sprintf("Basic %s", base64.StdEncoding.EncodeToString([]byte(fakeIntrospectClientID+":"+fakeIntrospectClientSecret))))
+
+	return req
+}
+
+// updateWith makes the mocked Update apply the update to the given authorization.
+func updateWith(authorization device.Authorization) func(string, func(*device.Authorization) error) error {
+	return func(_ string, update func(*device.Authorization) error) error {
+		return update(&authorization)
+	}
+}
+
+func TestDeviceAuthorizationEndpoint(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	assert.NoError(t, settings.ServerURL.Set("https://rancher.com"))
+
+	fakeOIDCClient := &v3.OIDCClient{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fakeIntrospectClientName,
+			Annotations: map[string]string{},
+		},
+		Status: v3.OIDCClientStatus{
+			ClientID: fakeIntrospectClientID,
+		},
+	}
+	fakeClientSecret := &v1.Secret{
+		Data: map[string][]byte{
+			fakeIntrospectClientSecretID: []byte(fakeIntrospectClientSecret),
+		},
+	}
+
+	tests := map[string]struct {
+		req        *http.Request
+		mockSetup  func(introspectMocks, *mocks.MockdeviceCodeStore, *mocks.MockdeviceCodeCreator)
+		wantStatus int
+		wantBody   string
+	}{
+		"returns a device code and user code": {
+			req: deviceRequest(url.Values{"scope": {"openid offline_access"}}),
+			mockSetup: func(m introspectMocks, store *mocks.MockdeviceCodeStore, creator *mocks.MockdeviceCodeCreator) {
+				m.oidcClientCache.EXPECT().GetByIndex(oidcClientByIDIndex, fakeIntrospectClientID).Return([]*v3.OIDCClient{fakeOIDCClient}, nil)
+				m.secretCache.EXPECT().Get(secretsNamespace, fakeIntrospectClientID).Return(fakeClientSecret, nil)
+				m.oidcClient.EXPECT().Patch(fakeIntrospectClientName, types.JSONPatchType, gomock.Any()).Return(fakeOIDCClient, nil)
+				creator.EXPECT().GenerateDeviceCode().Return(fakeDeviceCode, nil)
+				creator.EXPECT().GenerateUserCode().Return(fakeUserCode, nil)
+				store.EXPECT().Add(fakeDeviceCode, device.Authorization{
+					ClientID:  fakeIntrospectClientID,
+					UserCode:  fakeUserCode,
+					Scope:     []string{"openid", "offline_access"},
+					Status:    device.StatusPending,
+					Interval:  deviceCodeInterval,
+					CreatedAt: introspectNow,
+				}).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"device_code":"device-code","user_code":"BCDF-GHJK","verification_uri":"https://rancher.com/oidc/device","verification_uri_complete":"https://rancher.com/oidc/device?user_code=BCDF-GHJK","expires_in":600,"interval":5}`,
+		},
+		"invalid scope": {
+			req: deviceRequest(url.Values{"scope": {"openid admin"}}),
+			mockSetup: func(m introspectMocks, store *mocks.MockdeviceCodeStore, creator *mocks.MockdeviceCodeCreator) {
+				m.oidcClientCache.EXPECT().GetByIndex(oidcClientByIDIndex, fakeIntrospectClientID).Return([]*v3.OIDCClient{fakeOIDCClient}, nil)
+				m.secretCache.EXPECT().Get(secretsNamespace, fakeIntrospectClientID).Return(fakeClientSecret, nil)
+				m.oidcClient.EXPECT().Patch(fakeIntrospectClientName, types.JSONPatchType, gomock.Any()).Return(fakeOIDCClient, nil)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"invalid_scope","error_description":"invalid scope: admin"}`,
+		},
+		"missing client credentials": {
+			req: func() *http.Request {
+				req := deviceRequest(url.Values{"scope": {"openid"}})
+				req.Header.Del("Authorization")
+
+				return req
+			}(),
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   `{"error":"invalid_client","error_description":"client authentication is required"}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := newIntrospectMocks(ctrl)
+			store := mocks.NewMockdeviceCodeStore(ctrl)
+			creator := mocks.NewMockdeviceCodeCreator(ctrl)
+			if test.mockSetup != nil {
+				test.mockSetup(m, store, creator)
+			}
+			h := newTokenHandler(m.tokenCache, m.userLister, nil, nil, m.signingKeyGetter, m.oidcClientCache, m.oidcClient, m.secretCache, m.tokenClient, store, creator)
+			h.now = func() time.Time { return introspectNow }
+			rec := httptest.NewRecorder()
+
+			h.deviceAuthorizationEndpoint(rec, test.req)
+
+			assert.Equal(t, test.wantStatus, rec.Code)
+			assert.JSONEq(t, test.wantBody, strings.TrimSpace(rec.Body.String()))
+		})
+	}
+}
+
+func TestTokenEndpointDeviceCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	fakeOIDCClient := &v3.OIDCClient{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fakeIntrospectClientName,
+			Annotations: map[string]string{},
+		},
+		Spec: v3.OIDCClientSpec{
+			TokenExpirationSeconds: 600,
+		},
+		Status: v3.OIDCClientStatus{
+			ClientID: fakeIntrospectClientID,
+		},
+	}
+	fakeClientSecret := &v1.Secret{
+		Data: map[string][]byte{
+			fakeIntrospectClientSecretID: []byte(fakeIntrospectClientSecret),
+		},
+	}
+	pending := device.Authorization{
+		ClientID:  fakeIntrospectClientID,
+		UserCode:  fakeUserCode,
+		Scope:     []string{"openid"},
+		Status:    device.StatusPending,
+		Interval:  deviceCodeInterval,
+		CreatedAt: introspectNow.Add(-time.Minute),
+	}
+	polledRecently := pending
+	polledRecently.LastPolledAt = introspectNow.Add(-time.Second)
+	approved := pending
+	approved.Status = device.StatusApproved
+	approved.TokenName = fakeIntrospectTokenName
+	denied := pending
+	denied.Status = device.StatusDenied
+	clientAuthenticated := func(m introspectMocks) {
+		m.oidcClientCache.EXPECT().GetByIndex(oidcClientByIDIndex, fakeIntrospectClientID).Return([]*v3.OIDCClient{fakeOIDCClient}, nil)
+		m.secretCache.EXPECT().Get(secretsNamespace, fakeIntrospectClientID).Return(fakeClientSecret, nil)
+		m.oidcClient.EXPECT().Patch(fakeIntrospectClientName, types.JSONPatchType, gomock.Any()).Return(fakeOIDCClient, nil)
+	}
+	req := func() *http.Request {
+		return deviceRequest(url.Values{
+			"grant_type":  {deviceCodeGrantType},
+			"device_code": {fakeDeviceCode},
+		})
+	}
+
+	tests := map[string]struct {
+		mockSetup func(introspectMocks, *mocks.MockdeviceCodeStore)
+		wantError string
+	}{
+		"authorization pending": {
+			mockSetup: func(m introspectMocks, store *mocks.MockdeviceCodeStore) {
+				clientAuthenticated(m)
+				store.EXPECT().Get(fakeDeviceCode).Return(&pending, nil)
+				store.EXPECT().Update(fakeDeviceCode, gomock.Any()).DoAndReturn(updateWith(pending))
+			},
+			wantError: `{"error":"authorization_pending","error_description":"the user hasn't completed the authorization request yet"}`,
+		},
+		"slow down": {
+			mockSetup: func(m introspectMocks, store *mocks.MockdeviceCodeStore) {
+				clientAuthenticated(m)
+				store.EXPECT().Get(fakeDeviceCode).Return(&polledRecently, nil)
+				store.EXPECT().Update(fakeDeviceCode, gomock.Any()).DoAndReturn(updateWith(polledRecently))
+			},
+			wantError: `{"error":"slow_down","error_description":"polling too frequently"}`,
+		},
+		"access denied": {
+			mockSetup: func(m introspectMocks, store *mocks.MockdeviceCodeStore) {
+				clientAuthenticated(m)
+				store.EXPECT().Get(fakeDeviceCode).Return(&denied, nil)
+				store.EXPECT().Remove(fakeDeviceCode).Return(nil)
+			},
+			wantError: `{"error":"access_denied","error_description":"the user denied the authorization request"}`,
+		},
+		"expired device code": {
+			mockSetup: func(m introspectMocks, store *mocks.MockdeviceCodeStore) {
+				store.EXPECT().Get(fakeDeviceCode).Return(nil, device.ErrExpired)
+			},
+			wantError: `{"error":"expired_token","error_description":"device_code has expired"}`,
+		},
+		"invalid device code": {
+			mockSetup: func(m introspectMocks, store *mocks.MockdeviceCodeStore) {
+				store.EXPECT().Get(fakeDeviceCode).Return(nil, errors.NewNotFound(schema.GroupResource{}, fakeDeviceCode))
+			},
+			wantError: `{"error":"invalid_request","error_description":"invalid device_code"}`,
+		},
+		"approved returns an id_token and access_token": {
+			mockSetup: func(m introspectMocks, store *mocks.MockdeviceCodeStore) {
+				clientAuthenticated(m)
+				store.EXPECT().Get(fakeDeviceCode).Return(&approved, nil)
+				store.EXPECT().Remove(fakeDeviceCode).Return(nil)
+				m.tokenCache.EXPECT().Get(fakeIntrospectTokenName).Return(&v3.Token{
+					ObjectMeta: metav1.ObjectMeta{Name: fakeIntrospectTokenName},
+					UserID:     fakeIntrospectUserID,
+					Enabled:    ptr.To(true),
+				}, nil)
+				m.userLister.EXPECT().Get(fakeIntrospectUserID).Return(&v3.User{Enabled: ptr.To(true)}, nil)
+				m.signingKeyGetter.EXPECT().GetSigningKey().Return(introspectPrivateKey, fakeIntrospectSigningKey, nil)
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := newIntrospectMocks(ctrl)
+			userAttributeLister := fake.NewMockNonNamespacedCacheInterface[*v3.UserAttribute](ctrl)
+			userAttributeLister.EXPECT().Get(fakeIntrospectUserID).Return(nil, errors.NewNotFound(schema.GroupResource{}, fakeIntrospectUserID)).AnyTimes()
+			store := mocks.NewMockdeviceCodeStore(ctrl)
+			test.mockSetup(m, store)
+			h := newTokenHandler(m.tokenCache, m.userLister, userAttributeLister, nil, m.signingKeyGetter, m.oidcClientCache, m.oidcClient, m.secretCache, m.tokenClient, store, nil)
+			h.now = func() time.Time { return introspectNow }
+			rec := httptest.NewRecorder()
+
+			h.tokenEndpoint(rec, req())
+
+			if test.wantError != "" {
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+				assert.JSONEq(t, test.wantError, strings.TrimSpace(rec.Body.String()))
+			} else {
+				var tokenResponse TokenResponse
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &tokenResponse))
+				assert.NotEmpty(t, tokenResponse.IDToken)
+				assert.NotEmpty(t, tokenResponse.AccessToken)
+				assert.Empty(t, tokenResponse.RefreshToken)
+			}
+		})
+	}
+}
+
+func TestDeviceVerificationEndpoint(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	assert.NoError(t, settings.ServerURL.Set("https://rancher.com"))
+
+	fakeOIDCClient := &v3.OIDCClient{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: fakeIntrospectClientName,
+		},
+	}
+	fakeSessionToken := &v3.Token{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: fakeIntrospectTokenName,
+		},
+		UserID: fakeIntrospectUserID,
+	}
+	pending := device.Authorization{
+		ClientID: fakeIntrospectClientID,
+		UserCode: fakeUserCode,
+		Scope:    []string{"openid"},
+		Status:   device.StatusPending,
+	}
+	postRequest := func(action, csrf string) *http.Request {
+		data := url.Values{"user_code": {fakeUserCode}, "action": {action}, "csrf": {"csrf-token"}}
+		req := httptest.NewRequest(http.MethodPost, "https://rancher.com/oidc/device", bytes.NewBufferString(data.Encode()))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: deviceCSRFCookie, Value: csrf})
+
+		return req
+	}
+
+	tests := map[string]struct {
+		req           *http.Request
+		tokenVerifier *fakeTokenVerifier
+		mockSetup     func(*mocks.MockdeviceCodeStore, *fake.MockNonNamespacedCacheInterface[*v3.OIDCClient])
+		wantStatus    int
+		wantContains  []string
+	}{
+		"asks to log in without a Rancher session": {
+			req:           httptest.NewRequest(http.MethodGet, "https://rancher.com/oidc/device?user_code="+fakeUserCode, nil),
+			tokenVerifier: &fakeTokenVerifier{err: fmt.Errorf("rancher token not present")},
+			wantStatus:    http.StatusUnauthorized,
+			wantContains:  []string{"https://rancher.com/dashboard/auth/login"},
+		},
+		"shows the user code form": {
+			req:           httptest.NewRequest(http.MethodGet, "https://rancher.com/oidc/device", nil),
+			tokenVerifier: &fakeTokenVerifier{token: fakeSessionToken},
+			wantStatus:    http.StatusOK,
+			wantContains:  []string{`name="user_code"`},
+		},
+		"shows the authorization request": {
+			req:           httptest.NewRequest(http.MethodGet, "https://rancher.com/oidc/device?user_code=bcdfghjk", nil),
+			tokenVerifier: &fakeTokenVerifier{token: fakeSessionToken},
+			mockSetup: func(store *mocks.MockdeviceCodeStore, oidcClientCache *fake.MockNonNamespacedCacheInterface[*v3.OIDCClient]) {
+				store.EXPECT().GetByUserCode(fakeUserCode).Return(fakeDeviceCode, &pending, nil)
+				oidcClientCache.EXPECT().GetByIndex(oidcClientByIDIndex, fakeIntrospectClientID).Return([]*v3.OIDCClient{fakeOIDCClient}, nil)
+			},
+			wantStatus:   http.StatusOK,
+			wantContains: []string{fakeIntrospectClientName, fakeUserCode, `value="approve"`},
+		},
+		"invalid user code": {
+			req:           httptest.NewRequest(http.MethodGet, "https://rancher.com/oidc/device?user_code="+fakeUserCode, nil),
+			tokenVerifier: &fakeTokenVerifier{token: fakeSessionToken},
+			mockSetup: func(store *mocks.MockdeviceCodeStore, oidcClientCache *fake.MockNonNamespacedCacheInterface[*v3.OIDCClient]) {
+				store.EXPECT().GetByUserCode(fakeUserCode).Return("", nil, fmt.Errorf("invalid user code"))
+			},
+			wantStatus:   http.StatusBadRequest,
+			wantContains: []string{"The code is invalid or has expired."},
+		},
+		"approves the authorization": {
+			req:           postRequest("approve", "csrf-token"),
+			tokenVerifier: &fakeTokenVerifier{token: fakeSessionToken},
+			mockSetup: func(store *mocks.MockdeviceCodeStore, oidcClientCache *fake.MockNonNamespacedCacheInterface[*v3.OIDCClient]) {
+				store.EXPECT().GetByUserCode(fakeUserCode).Return(fakeDeviceCode, &pending, nil)
+				store.EXPECT().Update(fakeDeviceCode, gomock.Any()).DoAndReturn(func(_ string, update func(*device.Authorization) error) error {
+					authorization := pending
+					if err := update(&authorization); err != nil {
+						return err
+					}
+					assert.Equal(t, device.StatusApproved, authorization.Status)
+					assert.Equal(t, fakeIntrospectTokenName, authorization.TokenName)
+					return nil
+				})
+			},
+			wantStatus:   http.StatusOK,
+			wantContains: []string{"The device has been authorized."},
+		},
+		"denies the authorization": {
+			req:           postRequest("deny", "csrf-token"),
+			tokenVerifier: &fakeTokenVerifier{token: fakeSessionToken},
+			mockSetup: func(store *mocks.MockdeviceCodeStore, oidcClientCache *fake.MockNonNamespacedCacheInterface[*v3.OIDCClient]) {
+				store.EXPECT().GetByUserCode(fakeUserCode).Return(fakeDeviceCode, &pending, nil)
+				store.EXPECT().Update(fakeDeviceCode, gomock.Any()).DoAndReturn(func(_ string, update func(*device.Authorization) error) error {
+					authorization := pending
+					if err := update(&authorization); err != nil {
+						return err
+					}
+					assert.Equal(t, device.StatusDenied, authorization.Status)
+					assert.Empty(t, authorization.TokenName)
+					return nil
+				})
+			},
+			wantStatus:   http.StatusOK,
+			wantContains: []string{"The device authorization has been denied."},
+		},
+		"rejects a request without a matching CSRF token": {
+			req:           postRequest("approve", "another-token"),
+			tokenVerifier: &fakeTokenVerifier{token: fakeSessionToken},
+			mockSetup: func(store *mocks.MockdeviceCodeStore, oidcClientCache *fake.MockNonNamespacedCacheInterface[*v3.OIDCClient]) {
+				store.EXPECT().GetByUserCode(fakeUserCode).Return(fakeDeviceCode, &pending, nil)
+			},
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			store := mocks.NewMockdeviceCodeStore(ctrl)
+			oidcClientCache := fake.NewMockNonNamespacedCacheInterface[*v3.OIDCClient](ctrl)
+			if test.mockSetup != nil {
+				test.mockSetup(store, oidcClientCache)
+			}
+			h := newDeviceVerificationHandler(store, oidcClientCache, test.tokenVerifier)
+			rec := httptest.NewRecorder()
+
+			h.verificationEndpoint(rec, test.req)
+
+			assert.Equal(t, test.wantStatus, rec.Code)
+			assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+			assert.Equal(t, "DENY", rec.Header().Get("X-Frame-Options"))
+			assert.Equal(t, "frame-ancestors 'none'", rec.Header().Get("Content-Security-Policy"))
+			for _, s := range test.wantContains {
+				assert.Contains(t, rec.Body.String(), s)
+			}
+		})
+	}
+}
+
+func TestNormalizeUserCode(t *testing.T) {
+	assert.Equal(t, "BCDF-GHJK", normalizeUserCode("BCDF-GHJK"))
+	assert.Equal(t, "BCDF-GHJK", normalizeUserCode(" bcdfghjk "))
+	assert.Equal(t, "BCDF-GHJK", normalizeUserCode("bcdf ghjk"))
+	assert.Equal(t, "", normalizeUserCode(""))
+}
@@ -0,0 +1,150 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/tokens"
+	wrangmgmtv3 "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
+	oidcerror "github.com/rancher/rancher/pkg/oidc/provider/error"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type rancherTokenVerifier interface {
+	getAndVerifyRancherTokenFromRequest(r *http.Request) (*v3.Token, error)
+}
+
+type endSessionHandler struct {
+	tokenClient     wrangmgmtv3.TokenClient
+	oidcClientCache wrangmgmtv3.OIDCClientCache
+	tokenVerifier   rancherTokenVerifier
+	jwks            signingKeyGetter
+}
+
+func newEndSessionHandler(tokenClient wrangmgmtv3.TokenClient, oidcClientCache wrangmgmtv3.OIDCClientCache, tokenVerifier rancherTokenVerifier, jwks signingKeyGetter) *endSessionHandler {
+	return &endSessionHandler{
+		tokenClient:     tokenClient,
+		oidcClientCache: oidcClientCache,
+		tokenVerifier:   tokenVerifier,
+		jwks:            jwks,
+	}
+}
+
+// endSessionEndpoint handles RP-initiated logout as described in the OpenID Connect RP-Initiated Logout spec.
+// The Rancher session of the user is logged out, and the user is redirected to the post_logout_redirect_uri
+// registered for the OIDC client, or to the Rancher login page. Logout can be requested with a GET, so an unexpired
+// id_token_hint is required to prevent other sites from logging the user out.
+func (h *endSessionHandler) endSessionEndpoint(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		oidcerror.WriteError(oidcerror.InvalidRequest, fmt.Sprintf("error parsing parameters from request %v", err), http.StatusBadRequest, w)
+		return
+	}
+	idTokenHint := r.Form.Get("id_token_hint")
+	clientID := r.Form.Get("client_id")
+	postLogoutRedirectURI := r.Form.Get("post_logout_redirect_uri")
+	state := r.Form.Get("state")
+
+	if idTokenHint == "" {
+		oidcerror.WriteError(oidcerror.InvalidRequest, "missing id_token_hint", http.StatusBadRequest, w)
+		return
+	}
+	claims := &jwt.RegisteredClaims{}
+	if _, err := jwt.ParseWithClaims(idTokenHint, claims, verificationKey(h.jwks)); err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			// an expired id_token_hint may have leaked, the user has to log out of Rancher instead
+			oidcerror.WriteError(oidcerror.InvalidRequest, "id_token_hint has expired", http.StatusBadRequest, w)
+			return
+		}
+		oidcerror.WriteError(oidcerror.InvalidRequest, "invalid id_token_hint", http.StatusBadRequest, w)
+		return
+	}
+	if clientID == "" && len(claims.Audience) > 0 {
+		clientID = claims.Audience[0]
+	}
+	if !slices.Contains(claims.Audience, clientID) {
+		oidcerror.WriteError(oidcerror.InvalidRequest, "client_id doesn't match the id_token_hint", http.StatusBadRequest, w)
+		return
+	}
+	oidcClients, err := h.oidcClientCache.GetByIndex(oidcClientByIDIndex, clientID)
+	if err != nil {
+		oidcerror.WriteError(oidcerror.ServerError, "failed to get OIDC client", http.StatusInternalServerError, w)
+		return
+	}
+	if len(oidcClients) == 0 {
+		oidcerror.WriteError(oidcerror.InvalidRequest, "client_id not found", http.StatusBadRequest, w)
+		return
+	}
+	if postLogoutRedirectURI != "" && !slices.Contains(oidcClients[0].Spec.PostLogoutRedirectURIs, postLogoutRedirectURI) {
+		oidcerror.WriteError(oidcerror.InvalidRequest, "post_logout_redirect_uri not registered", http.StatusBadRequest, w)
+		return
+	}
+
+	if err := h.logout(w, r, claims.Subject); err != nil {
+		logrus.Errorf("[OIDC provider] failed to log out: %v", err)
+		oidcerror.WriteError(oidcerror.ServerError, "failed to log out", http.StatusInternalServerError, w)
+		return
+	}
+
+	redirectURI := settings.ServerURL.Get() + "/dashboard/auth/login"
+	if postLogoutRedirectURI != "" {
+		redirectURI = postLogoutRedirectURI
+	}
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		oidcerror.WriteError(oidcerror.ServerError, "error parsing redirect uri", http.StatusInternalServerError, w)
+		return
+	}
+	if postLogoutRedirectURI != "" && state != "" {
+		q := u.Query()
+		q.Set("state", state)
+		u.RawQuery = q.Encode()
+	}
+
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+// logout deletes the Rancher session token of the request if it belongs to the subject of the id_token_hint, and
+// expires the session cookies.
+func (h *endSessionHandler) logout(w http.ResponseWriter, r *http.Request, subject string) error {
+	token, err := h.tokenVerifier.getAndVerifyRancherTokenFromRequest(r)
+	if err != nil {
+		// there is no valid session to log out from
+		logrus.Debugf("[OIDC provider] no Rancher session found for end_session: %v", err)
+		return nil
+	}
+	if token.UserID != subject {
+		logrus.Debugf("[OIDC provider] Rancher session doesn't belong to the subject of the id_token_hint")
+		return nil
+	}
+	// only session tokens are logged out, API keys are not affected.
+	if token.IsDerived {
+		return nil
+	}
+
+	if err := h.tokenClient.Delete(token.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	for _, cookieName := range []string{tokens.CookieName, tokens.CSRFCookie} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     cookieName,
+			Value:    "",
+			Secure:   r.URL.Scheme == "https" || r.TLS != nil,
+			Path:     "/",
+			HttpOnly: true,
+			MaxAge:   -1,
+			Expires:  time.Date(1982, time.February, 10, 23, 0, 0, 0, time.UTC),
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,242 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/oidc/mocks"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeTokenVerifier struct {
+	token *v3.Token
+	err   error
+}
+
+func (f *fakeTokenVerifier) getAndVerifyRancherTokenFromRequest(_ *http.Request) (*v3.Token, error) {
+	return f.token, f.err
+}
+
+func TestEndSessionEndpoint(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	type mockParams struct {
+		tokenClient      *fake.MockNonNamespacedClientInterface[*v3.Token, *v3.TokenList]
+		oidcClientCache  *fake.MockNonNamespacedCacheInterface[*v3.OIDCClient]
+		signingKeyGetter *mocks.MocksigningKeyGetter
+	}
+	const (
+		fakeClientID              = "client-id"
+		fakeTokenName             = "token-name"
+		fakeUserID                = "user-id"
+		fakeSigningKey            = "key"
+		fakePostLogoutRedirectURI = "https://app.com/logged-out"
+	)
+	assert.NoError(t, settings.ServerURL.Set("https://rancher.com"))
+
+	fakeOIDCClient := &v3.OIDCClient{
+		Spec: v3.OIDCClientSpec{
+			PostLogoutRedirectURIs: []string{fakePostLogoutRedirectURI},
+		},
+		Status: v3.OIDCClientStatus{
+			ClientID: fakeClientID,
+		},
+	}
+	fakeSessionToken := &v3.Token{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: fakeTokenName,
+		},
+		UserID: fakeUserID,
+	}
+	fakeDerivedToken := fakeSessionToken.DeepCopy()
+	fakeDerivedToken.IsDerived = true
+
+	signIDToken := func(exp time.Time) string {
+		idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"aud": []string{fakeClientID},
+			"exp": exp.Unix(),
+			"sub": fakeUserID,
+		})
+		idToken.Header["kid"] = fakeSigningKey
+		idTokenString, _ := idToken.SignedString(introspectPrivateKey)
+		return idTokenString
+	}
+	idTokenString := signIDToken(time.Now().Add(time.Hour))
+	expiredIDTokenString := signIDToken(time.Now().Add(-time.Hour))
+
+	endSessionRequest := func(params url.Values) *http.Request {
+		return httptest.NewRequest(http.MethodGet, "https://rancher.com/oidc/end_session?"+params.Encode(), nil)
+	}
+
+	tests := map[string]struct {
+		req              *http.Request
+		tokenVerifier    *fakeTokenVerifier
+		mockSetup        func(mockParams)
+		wantStatus       int
+		wantLocation     string
+		wantBody         string
+		wantCookiesReset bool
+	}{
+		"logs out and redirects to the post logout redirect uri": {
+			req: endSessionRequest(url.Values{
+				"id_token_hint":            {idTokenString},
+				"post_logout_redirect_uri": {fakePostLogoutRedirectURI},
+				"state":                    {"abc"},
+			}),
+			tokenVerifier: &fakeTokenVerifier{token: fakeSessionToken},
+			mockSetup: func(m mockParams) {
+				m.signingKeyGetter.EXPECT().GetPublicKey(fakeSigningKey).Return(&introspectPrivateKey.PublicKey, nil)
+				m.oidcClientCache.EXPECT().GetByIndex(oidcClientByIDIndex, fakeClientID).Return([]*v3.OIDCClient{fakeOIDCClient}, nil)
+				m.tokenClient.EXPECT().Delete(fakeTokenName, &metav1.DeleteOptions{}).Return(nil)
+			},
+			wantStatus:       http.StatusFound,
+			wantLocation:     fakePostLogoutRedirectURI + "?state=abc",
+			wantCookiesReset: true,
+		},
+		"redirects to the login page without a post logout redirect uri": {
+			req: endSessionRequest(url.Values{
+				"id_token_hint": {idTokenString},
+			}),
+			tokenVerifier: &fakeTokenVerifier{token: fakeSessionToken},
+			mockSetup: func(m mockParams) {
+				m.signingKeyGetter.EXPECT().GetPublicKey(fakeSigningKey).Return(&introspectPrivateKey.PublicKey, nil)
+				m.oidcClientCache.EXPECT().GetByIndex(oidcClientByIDIndex, fakeClientID).Return([]*v3.OIDCClient{fakeOIDCClient}, nil)
+				m.tokenClient.EXPECT().Delete(fakeTokenName, &metav1.DeleteOptions{}).Return(nil)
+			},
+			wantStatus:       http.StatusFound,
+			wantLocation:     "https://rancher.com/dashboard/auth/login",
+			wantCookiesReset: true,
+		},
+		"doesn't delete the token of another user": {
+			req: endSessionRequest(url.Values{
+				"id_token_hint": {idTokenString},
+			}),
+			tokenVerifier: &fakeTokenVerifier{token: &v3.Token{UserID: "another-user"}},
+			mockSetup: func(m mockParams) {
+				m.signingKeyGetter.EXPECT().GetPublicKey(fakeSigningKey).Return(&introspectPrivateKey.PublicKey, nil)
+				m.oidcClientCache.EXPECT().GetByIndex(oidcClientByIDIndex, fakeClientID).Return([]*v3.OIDCClient{fakeOIDCClient}, nil)
+			},
+			wantStatus:   http.StatusFound,
+			wantLocation: "https://rancher.com/dashboard/auth/login",
+		},
+		"doesn't delete derived tokens": {
+			req: endSessionRequest(url.Values{
+				"id_token_hint": {idTokenString},
+			}),
+			tokenVerifier: &fakeTokenVerifier{token: fakeDerivedToken},
+			mockSetup: func(m mockParams) {
+				m.signingKeyGetter.EXPECT().GetPublicKey(fakeSigningKey).Return(&introspectPrivateKey.PublicKey, nil)
+				m.oidcClientCache.EXPECT().GetByIndex(oidcClientByIDIndex, fakeClientID).Return([]*v3.OIDCClient{fakeOIDCClient}, nil)
+			},
+			wantStatus:   http.StatusFound,
+			wantLocation: "https://rancher.com/dashboard/auth/login",
+		},
+		"redirects when there is no Rancher session": {
+			req: endSessionRequest(url.Values{
+				"id_token_hint": {idTokenString},
+			}),
+			tokenVerifier: &fakeTokenVerifier{err: fmt.Errorf("rancher token not present")},
+			mockSetup: func(m mockParams) {
+				m.signingKeyGetter.EXPECT().GetPublicKey(fakeSigningKey).Return(&introspectPrivateKey.PublicKey, nil)
+				m.oidcClientCache.EXPECT().GetByIndex(oidcClientByIDIndex, fakeClientID).Return([]*v3.OIDCClient{fakeOIDCClient}, nil)
+			},
+			wantStatus:   http.StatusFound,
+			wantLocation: "https://rancher.com/dashboard/auth/login",
+		},
+		"post logout redirect uri not registered": {
+			req: endSessionRequest(url.Values{
+				"id_token_hint":            {idTokenString},
+				"post_logout_redirect_uri": {"https://evil.com"},
+			}),
+			tokenVerifier: &fakeTokenVerifier{token: fakeSessionToken},
+			mockSetup: func(m mockParams) {
+				m.signingKeyGetter.EXPECT().GetPublicKey(fakeSigningKey).Return(&introspectPrivateKey.PublicKey, nil)
+				m.oidcClientCache.EXPECT().GetByIndex(oidcClientByIDIndex, fakeClientID).Return([]*v3.OIDCClient{fakeOIDCClient}, nil)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"invalid_request","error_description":"post_logout_redirect_uri not registered"}`,
+		},
+		"client_id doesn't match the id_token_hint": {
+			req: endSessionRequest(url.Values{
+				"id_token_hint": {idTokenString},
+				"client_id":     {"another-client"},
+			}),
+			tokenVerifier: &fakeTokenVerifier{token: fakeSessionToken},
+			mockSetup: func(m mockParams) {
+				m.signingKeyGetter.EXPECT().GetPublicKey(fakeSigningKey).Return(&introspectPrivateKey.PublicKey, nil)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"invalid_request","error_description":"client_id doesn't match the id_token_hint"}`,
+		},
+		"missing id_token_hint": {
+			req:           endSessionRequest(url.Values{}),
+			tokenVerifier: &fakeTokenVerifier{token: fakeSessionToken},
+			wantStatus:    http.StatusBadRequest,
+			wantBody:      `{"error":"invalid_request","error_description":"missing id_token_hint"}`,
+		},
+		"expired id_token_hint": {
+			req: endSessionRequest(url.Values{
+				"id_token_hint": {expiredIDTokenString},
+			}),
+			tokenVerifier: &fakeTokenVerifier{token: fakeSessionToken},
+			mockSetup: func(m mockParams) {
+				m.signingKeyGetter.EXPECT().GetPublicKey(fakeSigningKey).Return(&introspectPrivateKey.PublicKey, nil)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"invalid_request","error_description":"id_token_hint has expired"}`,
+		},
+		"invalid id_token_hint": {
+			req: endSessionRequest(url.Values{
+				"id_token_hint": {"invalid"},
+			}),
+			tokenVerifier: &fakeTokenVerifier{token: fakeSessionToken},
+			wantStatus:    http.StatusBadRequest,
+			wantBody:      `{"error":"invalid_request","error_description":"invalid id_token_hint"}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := mockParams{
+				tokenClient:      fake.NewMockNonNamespacedClientInterface[*v3.Token, *v3.TokenList](ctrl),
+				oidcClientCache:  fake.NewMockNonNamespacedCacheInterface[*v3.OIDCClient](ctrl),
+				signingKeyGetter: mocks.NewMocksigningKeyGetter(ctrl),
+			}
+			if test.mockSetup != nil {
+				test.mockSetup(m)
+			}
+			h := newEndSessionHandler(m.tokenClient, m.oidcClientCache, test.tokenVerifier, m.signingKeyGetter)
+			rec := httptest.NewRecorder()
+
+			h.endSessionEndpoint(rec, test.req)
+
+			assert.Equal(t, test.wantStatus, rec.Code)
+			if test.wantLocation != "" {
+				assert.Equal(t, test.wantLocation, rec.Header().Get("Location"))
+			}
+			if test.wantBody != "" {
+				assert.JSONEq(t, test.wantBody, strings.TrimSpace(rec.Body.String()))
+			}
+			cookies := rec.Result().Cookies()
+			if test.wantCookiesReset {
+				assert.Len(t, cookies, 2)
+				for _, cookie := range cookies {
+					assert.Empty(t, cookie.Value)
+					assert.Equal(t, -1, cookie.MaxAge)
+				}
+			} else {
+				assert.Empty(t, cookies)
+			}
+		})
+	}
+}
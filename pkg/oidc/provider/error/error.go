@@ -16,6 +16,18 @@
 	UnsupportedResponseType = "unsupported_response_type"
 	// InvalidScope the requested scope is invalid, unknown, or malformed
 	InvalidScope = "invalid_scope"
+	// InvalidClient client authentication failed.
+	InvalidClient = "invalid_client"
+	// UnauthorizedClient the authenticated client is not authorized to use this authorization grant type.
+	UnauthorizedClient = "unauthorized_client"
+	// UnsupportedTokenType the authorization server does not support the revocation of the presented token type.
+	UnsupportedTokenType = "unsupported_token_type"
+	// AuthorizationPending the user hasn't completed the device authorization request yet.
+	AuthorizationPending = "authorization_pending"
+	// SlowDown the device is polling the token endpoint too frequently.
+	SlowDown = "slow_down"
+	// ExpiredToken the device_code has expired.
+	ExpiredToken = "expired_token"
 	// ServerError the authorization server encountered an unexpected condition that prevented it from fulfilling the request.
 	ServerError = "server_error"
 )
@@ -0,0 +1,304 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	oidcerror "github.com/rancher/rancher/pkg/oidc/provider/error"
+	"github.com/rancher/wrangler/v3/pkg/name"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// revokedAtAnnotationPrefix is the prefix of the annotation added to a Rancher token when the refresh tokens issued for
+// an OIDC client are revoked. The value is the unix time of the revocation in nanoseconds.
+const revokedAtAnnotationPrefix = "cattle.io.oidc-client-revoked-at"
+
+// revokedAtAnnotation returns the revocation annotation of the OIDC client. Client names which would make the key
+// longer than the 63 characters allowed for annotation names are truncated and suffixed with a hash of the full key.
+func revokedAtAnnotation(oidcClientName string) string {
+	return name.SafeConcatName(revokedAtAnnotationPrefix, oidcClientName)
+}
+
+// IntrospectionResponse represents a response returned by the introspection endpoint as defined in RFC 7662.
+type IntrospectionResponse struct {
+	// Active indicates whether the token is currently active.
+	Active bool `json:"active"`
+	// Scope is a space-separated list of the scopes of the token.
+	Scope string `json:"scope,omitempty"`
+	// ClientID is the client identifier of the OIDC client the token was issued to.
+	ClientID string `json:"client_id,omitempty"`
+	// Username is the username of the user the token was issued for.
+	Username string `json:"username,omitempty"`
+	// TokenType is the type of the token, only set for access tokens.
+	TokenType string `json:"token_type,omitempty"`
+	// ExpiresAt indicates when the token expires.
+	ExpiresAt int64 `json:"exp,omitempty"`
+	// IssuedAt indicates when the token was issued.
+	IssuedAt int64 `json:"iat,omitempty"`
+	// Subject is the user id of the user the token was issued for.
+	Subject string `json:"sub,omitempty"`
+	// Audience is the audience of the token.
+	Audience []string `json:"aud,omitempty"`
+	// Issuer is the issuer of the token.
+	Issuer string `json:"iss,omitempty"`
+}
+
+// introspectEndpoint handles the introspection endpoint of the OIDC provider as defined in RFC 7662.
+// Clients can only introspect tokens issued to them.
+func (h *tokenHandler) introspectEndpoint(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		oidcerror.WriteError(oidcerror.InvalidRequest, "method not allowed", http.StatusMethodNotAllowed, w)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		oidcerror.WriteError(oidcerror.InvalidRequest, fmt.Sprintf("error parsing parameters from request %v", err), http.StatusBadRequest, w)
+		return
+	}
+	oidcClient, oidcErr := h.authenticateClient(r)
+	if oidcErr != nil {
+		oidcErr.Write(http.StatusUnauthorized, w)
+		return
+	}
+	token := r.PostForm.Get("token")
+	if token == "" {
+		oidcerror.WriteError(oidcerror.InvalidRequest, "missing token", http.StatusBadRequest, w)
+		return
+	}
+
+	resp, oidcErr := h.introspect(token, oidcClient)
+	if oidcErr != nil {
+		logrus.Errorf("[OIDC provider] error introspecting token: %s", oidcErr.ToString())
+		oidcErr.Write(http.StatusInternalServerError, w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		oidcerror.WriteError(oidcerror.ServerError, "failed to encode introspection response", http.StatusInternalServerError, w)
+	}
+}
+
+// introspect returns the introspection response for the token. Tokens that are invalid, expired, revoked, not issued to
+// the OIDC client, or neither access nor refresh tokens are inactive. An error is only returned if the state of the
+// token can't be determined.
+func (h *tokenHandler) introspect(tokenString string, oidcClient *v3.OIDCClient) (IntrospectionResponse, *oidcerror.Error) {
+	inactive := IntrospectionResponse{Active: false}
+
+	claims := &RefreshTokenClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, verificationKey(h.jwks), jwt.WithTimeFunc(h.now))
+	if err != nil || !token.Valid {
+		logrus.Debugf("[OIDC provider] introspected token is not valid: %v", err)
+		return inactive, nil
+	}
+	if !slices.Contains(claims.Audience, oidcClient.Status.ClientID) {
+		return inactive, nil
+	}
+	// id tokens are signed with the same key, but are not meant to be presented to resource servers. Refresh tokens
+	// issued before the token_type claim was added have none, but are the only tokens with a Rancher token hash.
+	tokenType := claims.TokenType
+	if tokenType == "" && claims.RancherTokenHash != "" {
+		tokenType = refreshTokenType
+	}
+	isRefreshToken := tokenType == refreshTokenType
+	if !isRefreshToken && tokenType != accessTokenType {
+		return inactive, nil
+	}
+	if isRefreshToken != (claims.RancherTokenHash != "") {
+		return inactive, nil
+	}
+
+	var user *v3.User
+	if isRefreshToken {
+		// refresh tokens are only active while the Rancher token they were derived from is valid
+		rancherToken, oidcErr := h.getRancherTokenForRefreshToken(claims)
+		if oidcErr != nil {
+			return inactiveUnlessServerError(inactive, oidcErr)
+		}
+		if isRefreshTokenRevoked(rancherToken, oidcClient, claims) {
+			return inactive, nil
+		}
+		user, oidcErr = h.verifyRancherToken(rancherToken)
+		if oidcErr != nil {
+			return inactiveUnlessServerError(inactive, oidcErr)
+		}
+	} else if !isClientCredentialsPrincipal(oidcClient, claims.Subject) {
+		user, err = h.userLister.Get(claims.Subject)
+		if err != nil {
+			return inactive, nil
+		}
+		if user.Enabled != nil && !*user.Enabled {
+			return inactive, nil
+		}
+	}
+
+	resp := IntrospectionResponse{
+		Active:   true,
+		Scope:    strings.Join(claims.Scope, " "),
+		ClientID: oidcClient.Status.ClientID,
+		Subject:  claims.Subject,
+		Audience: claims.Audience,
+		Issuer:   claims.Issuer,
+	}
+	if user != nil {
+		resp.Username = user.Username
+	}
+	if !isRefreshToken {
+		resp.TokenType = bearerTokenType
+	}
+	if claims.ExpiresAt != nil {
+		resp.ExpiresAt = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		resp.IssuedAt = claims.IssuedAt.Unix()
+	}
+
+	return resp, nil
+}
+
+func inactiveUnlessServerError(inactive IntrospectionResponse, oidcErr *oidcerror.Error) (IntrospectionResponse, *oidcerror.Error) {
+	if oidcErr.Error == oidcerror.ServerError {
+		return IntrospectionResponse{}, oidcErr
+	}
+
+	return inactive, nil
+}
+
+// revokeEndpoint handles the revocation endpoint of the OIDC provider as defined in RFC 7009.
+// Only refresh tokens can be revoked. Access tokens are short-lived and expire on their own.
+func (h *tokenHandler) revokeEndpoint(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		oidcerror.WriteError(oidcerror.InvalidRequest, "method not allowed", http.StatusMethodNotAllowed, w)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		oidcerror.WriteError(oidcerror.InvalidRequest, fmt.Sprintf("error parsing parameters from request %v", err), http.StatusBadRequest, w)
+		return
+	}
+	oidcClient, oidcErr := h.authenticateClient(r)
+	if oidcErr != nil {
+		oidcErr.Write(http.StatusUnauthorized, w)
+		return
+	}
+	token := r.PostForm.Get("token")
+	if token == "" {
+		oidcerror.WriteError(oidcerror.InvalidRequest, "missing token", http.StatusBadRequest, w)
+		return
+	}
+
+	if oidcErr := h.revoke(token, oidcClient); oidcErr != nil {
+		logrus.Debugf("[OIDC provider] error revoking token: %s", oidcErr.ToString())
+		if oidcErr.Error == oidcerror.ServerError {
+			oidcErr.Write(http.StatusInternalServerError, w)
+		} else {
+			oidcErr.Write(http.StatusBadRequest, w)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// revoke revokes the refresh tokens issued to the OIDC client for the Rancher token the given refresh token was
+// derived from. Invalid tokens are ignored, as they can't be used anyway.
+func (h *tokenHandler) revoke(tokenString string, oidcClient *v3.OIDCClient) *oidcerror.Error {
+	claims := &RefreshTokenClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, verificationKey(h.jwks), jwt.WithTimeFunc(h.now))
+	if err != nil || !token.Valid {
+		logrus.Debugf("[OIDC provider] revoked token is not valid: %v", err)
+		return nil
+	}
+	if !slices.Contains(claims.Audience, oidcClient.Status.ClientID) {
+		return oidcerror.New(oidcerror.InvalidRequest, "token was not issued to the client")
+	}
+	if claims.RancherTokenHash == "" {
+		return oidcerror.New(oidcerror.UnsupportedTokenType, "only refresh tokens can be revoked")
+	}
+
+	rancherToken, oidcErr := h.getRancherTokenForRefreshToken(claims)
+	if oidcErr != nil {
+		if oidcErr.Error == oidcerror.ServerError {
+			return oidcErr
+		}
+		// the Rancher token is gone, so the refresh token can't be used anymore
+		return nil
+	}
+
+	if err := h.addRevokedAtToRancherToken(oidcClient.Name, rancherToken); err != nil {
+		return oidcerror.New(oidcerror.ServerError, fmt.Sprintf("failed to revoke refresh token: %v", err))
+	}
+
+	return nil
+}
+
+// authenticateClient verifies the credentials of the OIDC client making the request.
+func (h *tokenHandler) authenticateClient(r *http.Request) (*v3.OIDCClient, *oidcerror.Error) {
+	clientID, clientSecret := clientCredentialsFromRequest(r)
+	if clientID == "" || clientSecret == "" {
+		return nil, oidcerror.New(oidcerror.InvalidClient, "client authentication is required")
+	}
+	oidcClient, oidcErr := h.verifyClient(clientID, clientSecret)
+	if oidcErr != nil {
+		logrus.Debugf("[OIDC provider] failed to authenticate client %s: %s", clientID, oidcErr.ToString())
+		return nil, oidcerror.New(oidcerror.InvalidClient, "client authentication failed")
+	}
+
+	return oidcClient, nil
+}
+
+// isRefreshTokenRevoked returns true if the refresh token was issued before the refresh tokens of the OIDC client
+// were revoked for the Rancher token. Refresh tokens without a nanosecond issue time are compared by their iat, and
+// are revoked if they were issued in the same second.
+func isRefreshTokenRevoked(rancherToken *v3.Token, oidcClient *v3.OIDCClient, claims *RefreshTokenClaims) bool {
+	value, ok := rancherToken.Annotations[revokedAtAnnotation(oidcClient.Name)]
+	if !ok {
+		return false
+	}
+	revokedAt, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		logrus.Errorf("[OIDC provider] invalid value for annotation %s in token %s: %v", revokedAtAnnotation(oidcClient.Name), rancherToken.Name, err)
+		return true
+	}
+	if claims.IssuedAtNano != 0 {
+		return claims.IssuedAtNano <= revokedAt
+	}
+	if claims.IssuedAt == nil {
+		return true
+	}
+
+	return claims.IssuedAt.Unix() <= time.Unix(0, revokedAt).Unix()
+}
+
+func (h *tokenHandler) addRevokedAtToRancherToken(oidcClientName string, rancherToken *v3.Token) error {
+	var patch []byte
+	var err error
+	if rancherToken.Annotations != nil {
+		patch, err = json.Marshal([]jsonPatch{{
+			Op:    "add",
+			Path:  "/metadata/annotations/" + revokedAtAnnotation(oidcClientName),
+			Value: fmt.Sprintf("%d", h.now().UnixNano()),
+		}})
+	} else {
+		patch, err = json.Marshal([]jsonPatch{{
+			Op:   "add",
+			Path: "/metadata/annotations",
+			Value: map[string]string{
+				revokedAtAnnotation(oidcClientName): fmt.Sprintf("%d", h.now().UnixNano()),
+			},
+		}})
+	}
+	if err != nil {
+		return err
+	}
+	_, err = h.tokenClient.Patch(rancherToken.Name, types.JSONPatchType, patch)
+
+	return err
+}
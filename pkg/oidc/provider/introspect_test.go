@@ -0,0 +1,536 @@
+package provider
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/tokens"
+	"github.com/rancher/rancher/pkg/oidc/mocks"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/ptr"
+)
+
+type introspectMocks struct {
+	tokenCache       *fake.MockNonNamespacedCacheInterface[*v3.Token]
+	tokenClient      *fake.MockNonNamespacedClientInterface[*v3.Token, *v3.TokenList]
+	secretCache      *fake.MockCacheInterface[*v1.Secret]
+	oidcClientCache  *fake.MockNonNamespacedCacheInterface[*v3.OIDCClient]
+	oidcClient       *fake.MockNonNamespacedClientInterface[*v3.OIDCClient, *v3.OIDCClientList]
+	userLister       *fake.MockNonNamespacedCacheInterface[*v3.User]
+	signingKeyGetter *mocks.MocksigningKeyGetter
+}
+
+const (
+	fakeIntrospectClientID       = "client-id"
+	fakeIntrospectClientName     = "client-name"
+	fakeIntrospectClientSecret   = "client-secret"
+	fakeIntrospectClientSecretID = "client-secret-1"
+	fakeIntrospectTokenName      = "token-name"
+	fakeIntrospectUserID         = "user-id"
+	fakeIntrospectUsername       = "username"
+	fakeIntrospectSigningKey     = "key"
+)
+
+var (
+	introspectNow        = time.Unix(1700000000, 0)
+	introspectPrivateKey *rsa.PrivateKey
+)
+
+func init() {
+	introspectPrivateKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+}
+
+func signIntrospectToken(claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = fakeIntrospectSigningKey
+	signed, _ := token.SignedString(introspectPrivateKey)
+
+	return signed
+}
+
+func introspectRequest(token string) *http.Request {
+	data := url.Values{}
+	data.Set("token", token)
+	req, _ := http.NewRequest(http.MethodPost, "https://rancher.com", bytes.NewBufferString(data.Encode()))
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add("Authorization", fmt.Sprintf("Basic %s", base64.StdEncoding.EncodeToString([]byte(fakeIntrospectClientID+":"+fakeIntrospectClientSecret))))
+
+	return req
+}
+
+func TestIntrospectEndpoint(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	fakeOIDCClient := &v3.OIDCClient{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fakeIntrospectClientName,
+			Annotations: map[string]string{},
+		},
+		Status: v3.OIDCClientStatus{
+			ClientID: fakeIntrospectClientID,
+		},
+	}
+	fakeClientSecret := &v1.Secret{
+		Data: map[string][]byte{
+			fakeIntrospectClientSecretID: []byte(fakeIntrospectClientSecret),
+		},
+	}
+	fakeToken := &v3.Token{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: fakeIntrospectTokenName,
+		},
+		UserID:  fakeIntrospectUserID,
+		Enabled: ptr.To(true),
+	}
+	fakeUser := &v3.User{
+		Username: fakeIntrospectUsername,
+		Enabled:  ptr.To(true),
+	}
+	hash := sha256.Sum256([]byte(fakeIntrospectTokenName))
+	rancherTokenHash := hex.EncodeToString(hash[:])
+	refreshToken := signIntrospectToken(jwt.MapClaims{
+		"aud":                []string{fakeIntrospectClientID},
+		"exp":                introspectNow.Add(time.Hour).Unix(),
+		"iat":                introspectNow.Unix(),
+		"sub":                fakeIntrospectUserID,
+		"rancher_token_hash": rancherTokenHash,
+		"scope":              []string{"openid", "offline_access"},
+		"token_type":         refreshTokenType,
+		"rancher_iat_ns":     introspectNow.UnixNano(),
+	})
+	// refresh tokens issued before the token_type and rancher_iat_ns claims were added
+	legacyRefreshToken := signIntrospectToken(jwt.MapClaims{
+		"aud":                []string{fakeIntrospectClientID},
+		"exp":                introspectNow.Add(time.Hour).Unix(),
+		"iat":                introspectNow.Unix(),
+		"sub":                fakeIntrospectUserID,
+		"rancher_token_hash": rancherTokenHash,
+		"scope":              []string{"openid", "offline_access"},
+	})
+	accessToken := signIntrospectToken(jwt.MapClaims{
+		"aud":        []string{fakeIntrospectClientID},
+		"exp":        introspectNow.Add(time.Minute).Unix(),
+		"iat":        introspectNow.Unix(),
+		"iss":        "https://rancher.com/oidc",
+		"sub":        fakeIntrospectUserID,
+		"scope":      []string{"openid", "profile"},
+		"token_type": accessTokenType,
+	})
+	idToken := signIntrospectToken(jwt.MapClaims{
+		"aud": []string{fakeIntrospectClientID},
+		"exp": introspectNow.Add(time.Minute).Unix(),
+		"iat": introspectNow.Unix(),
+		"iss": "https://rancher.com/oidc",
+		"sub": fakeIntrospectUserID,
+	})
+	expiredAccessToken := signIntrospectToken(jwt.MapClaims{
+		"aud":        []string{fakeIntrospectClientID},
+		"exp":        introspectNow.Add(-time.Minute).Unix(),
+		"iat":        introspectNow.Add(-time.Hour).Unix(),
+		"sub":        fakeIntrospectUserID,
+		"token_type": accessTokenType,
+	})
+	otherClientAccessToken := signIntrospectToken(jwt.MapClaims{
+		"aud":        []string{"other-client"},
+		"exp":        introspectNow.Add(time.Minute).Unix(),
+		"iat":        introspectNow.Unix(),
+		"sub":        fakeIntrospectUserID,
+		"token_type": accessTokenType,
+	})
+	clientAuthenticated := func(m introspectMocks) {
+		m.oidcClientCache.EXPECT().GetByIndex(oidcClientByIDIndex, fakeIntrospectClientID).Return([]*v3.OIDCClient{fakeOIDCClient}, nil)
+		m.secretCache.EXPECT().Get(secretsNamespace, fakeIntrospectClientID).Return(fakeClientSecret, nil)
+		m.oidcClient.EXPECT().Patch(fakeIntrospectClientName, types.JSONPatchType, gomock.Any()).Return(fakeOIDCClient, nil)
+	}
+
+	tests := map[string]struct {
+		req        func() *http.Request
+		mockSetup  func(introspectMocks)
+		wantStatus int
+		wantBody   string
+	}{
+		"active access token": {
+			req: func() *http.Request {
+				return introspectRequest(accessToken)
+			},
+			mockSetup: func(m introspectMocks) {
+				clientAuthenticated(m)
+				m.signingKeyGetter.EXPECT().GetPublicKey(fakeIntrospectSigningKey).Return(&introspectPrivateKey.PublicKey, nil)
+				m.userLister.EXPECT().Get(fakeIntrospectUserID).Return(fakeUser, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   fmt.Sprintf(`{"active":true,"scope":"openid profile","client_id":"client-id","username":"username","token_type":"Bearer","exp":%d,"iat":%d,"sub":"user-id","aud":["client-id"],"iss":"https://rancher.com/oidc"}`, introspectNow.Add(time.Minute).Unix(), introspectNow.Unix()),
+		},
+		"active refresh token": {
+			req: func() *http.Request {
+				return introspectRequest(refreshToken)
+			},
+			mockSetup: func(m introspectMocks) {
+				clientAuthenticated(m)
+				m.signingKeyGetter.EXPECT().GetPublicKey(fakeIntrospectSigningKey).Return(&introspectPrivateKey.PublicKey, nil)
+				m.tokenCache.EXPECT().List(labels.SelectorFromSet(map[string]string{
+					tokens.UserIDLabel: fakeIntrospectUserID,
+				})).Return([]*v3.Token{fakeToken}, nil)
+				m.userLister.EXPECT().Get(fakeIntrospectUserID).Return(fakeUser, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   fmt.Sprintf(`{"active":true,"scope":"openid offline_access","client_id":"client-id","username":"username","exp":%d,"iat":%d,"sub":"user-id","aud":["client-id"]}`, introspectNow.Add(time.Hour).Unix(), introspectNow.Unix()),
+		},
+		"active legacy refresh token without token type": {
+			req: func() *http.Request {
+				return introspectRequest(legacyRefreshToken)
+			},
+			mockSetup: func(m introspectMocks) {
+				clientAuthenticated(m)
+				m.signingKeyGetter.EXPECT().GetPublicKey(fakeIntrospectSigningKey).Return(&introspectPrivateKey.PublicKey, nil)
+				m.tokenCache.EXPECT().List(labels.SelectorFromSet(map[string]string{
+					tokens.UserIDLabel: fakeIntrospectUserID,
+				})).Return([]*v3.Token{fakeToken}, nil)
+				m.userLister.EXPECT().Get(fakeIntrospectUserID).Return(fakeUser, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   fmt.Sprintf(`{"active":true,"scope":"openid offline_access","client_id":"client-id","username":"username","exp":%d,"iat":%d,"sub":"user-id","aud":["client-id"]}`, introspectNow.Add(time.Hour).Unix(), introspectNow.Unix()),
+		},
+		"revoked refresh token is inactive": {
+			req: func() *http.Request {
+				return introspectRequest(refreshToken)
+			},
+			mockSetup: func(m introspectMocks) {
+				clientAuthenticated(m)
+				revokedToken := fakeToken.DeepCopy()
+				revokedToken.Annotations = map[string]string{
+					revokedAtAnnotation(fakeIntrospectClientName): fmt.Sprintf("%d", introspectNow.UnixNano()),
+				}
+				m.signingKeyGetter.EXPECT().GetPublicKey(fakeIntrospectSigningKey).Return(&introspectPrivateKey.PublicKey, nil)
+				m.tokenCache.EXPECT().List(labels.SelectorFromSet(map[string]string{
+					tokens.UserIDLabel: fakeIntrospectUserID,
+				})).Return([]*v3.Token{revokedToken}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"active":false}`,
+		},
+		"refresh token issued after the revocation within the same second is active": {
+			req: func() *http.Request {
+				return introspectRequest(refreshToken)
+			},
+			mockSetup: func(m introspectMocks) {
+				clientAuthenticated(m)
+				revokedToken := fakeToken.DeepCopy()
+				revokedToken.Annotations = map[string]string{
+					revokedAtAnnotation(fakeIntrospectClientName): fmt.Sprintf("%d", introspectNow.Add(-time.Millisecond).UnixNano()),
+				}
+				m.signingKeyGetter.EXPECT().GetPublicKey(fakeIntrospectSigningKey).Return(&introspectPrivateKey.PublicKey, nil)
+				m.tokenCache.EXPECT().List(labels.SelectorFromSet(map[string]string{
+					tokens.UserIDLabel: fakeIntrospectUserID,
+				})).Return([]*v3.Token{revokedToken}, nil)
+				m.userLister.EXPECT().Get(fakeIntrospectUserID).Return(fakeUser, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   fmt.Sprintf(`{"active":true,"scope":"openid offline_access","client_id":"client-id","username":"username","exp":%d,"iat":%d,"sub":"user-id","aud":["client-id"]}`, introspectNow.Add(time.Hour).Unix(), introspectNow.Unix()),
+		},
+		"id token is inactive": {
+			req: func() *http.Request {
+				return introspectRequest(idToken)
+			},
+			mockSetup: func(m introspectMocks) {
+				clientAuthenticated(m)
+				m.signingKeyGetter.EXPECT().GetPublicKey(fakeIntrospectSigningKey).Return(&introspectPrivateKey.PublicKey, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"active":false}`,
+		},
+		"refresh token is inactive when the Rancher token is gone": {
+			req: func() *http.Request {
+				return introspectRequest(refreshToken)
+			},
+			mockSetup: func(m introspectMocks) {
+				clientAuthenticated(m)
+				m.signingKeyGetter.EXPECT().GetPublicKey(fakeIntrospectSigningKey).Return(&introspectPrivateKey.PublicKey, nil)
+				m.tokenCache.EXPECT().List(labels.SelectorFromSet(map[string]string{
+					tokens.UserIDLabel: fakeIntrospectUserID,
+				})).Return([]*v3.Token{}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"active":false}`,
+		},
+		"expired token is inactive": {
+			req: func() *http.Request {
+				return introspectRequest(expiredAccessToken)
+			},
+			mockSetup: func(m introspectMocks) {
+				clientAuthenticated(m)
+				m.signingKeyGetter.EXPECT().GetPublicKey(fakeIntrospectSigningKey).Return(&introspectPrivateKey.PublicKey, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"active":false}`,
+		},
+		"token issued to another client is inactive": {
+			req: func() *http.Request {
+				return introspectRequest(otherClientAccessToken)
+			},
+			mockSetup: func(m introspectMocks) {
+				clientAuthenticated(m)
+				m.signingKeyGetter.EXPECT().GetPublicKey(fakeIntrospectSigningKey).Return(&introspectPrivateKey.PublicKey, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"active":false}`,
+		},
+		"invalid token is inactive": {
+			req: func() *http.Request {
+				return introspectRequest("invalid")
+			},
+			mockSetup:  clientAuthenticated,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"active":false}`,
+		},
+		"invalid client secret": {
+			req: func() *http.Request {
+				req := introspectRequest(accessToken)
+				req.SetBasicAuth(fakeIntrospectClientID, "wrong")
+
+				return req
+			},
+			mockSetup: func(m introspectMocks) {
+				m.oidcClientCache.EXPECT().GetByIndex(oidcClientByIDIndex, fakeIntrospectClientID).Return([]*v3.OIDCClient{fakeOIDCClient}, nil)
+				m.secretCache.EXPECT().Get(secretsNamespace, fakeIntrospectClientID).Return(fakeClientSecret, nil)
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   `{"error":"invalid_client","error_description":"client authentication failed"}`,
+		},
+		"missing client credentials": {
+			req: func() *http.Request {
+				req := introspectRequest(accessToken)
+				req.Header.Del("Authorization")
+
+				return req
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   `{"error":"invalid_client","error_description":"client authentication is required"}`,
+		},
+		"GET is not allowed": {
+			req: func() *http.Request {
+				req, _ := http.NewRequest(http.MethodGet, "https://rancher.com", nil)
+
+				return req
+			},
+			wantStatus: http.StatusMethodNotAllowed,
+			wantBody:   `{"error":"invalid_request","error_description":"method not allowed"}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			m := newIntrospectMocks(ctrl)
+			if test.mockSetup != nil {
+				test.mockSetup(m)
+			}
+			h := newTokenHandler(m.tokenCache, m.userLister, nil, nil, m.signingKeyGetter, m.oidcClientCache, m.oidcClient, m.secretCache, m.tokenClient, nil, nil)
+			h.now = func() time.Time { return introspectNow }
+			rec := httptest.NewRecorder()
+
+			h.introspectEndpoint(rec, test.req())
+
+			assert.Equal(t, test.wantStatus, rec.Code)
+			assert.JSONEq(t, test.wantBody, strings.TrimSpace(rec.Body.String()))
+		})
+	}
+}
+
+func TestRevokeEndpoint(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	fakeOIDCClient := &v3.OIDCClient{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fakeIntrospectClientName,
+			Annotations: map[string]string{},
+		},
+		Status: v3.OIDCClientStatus{
+			ClientID: fakeIntrospectClientID,
+		},
+	}
+	fakeClientSecret := &v1.Secret{
+		Data: map[string][]byte{
+			fakeIntrospectClientSecretID: []byte(fakeIntrospectClientSecret),
+		},
+	}
+	fakeToken := &v3.Token{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: fakeIntrospectTokenName,
+		},
+		UserID:  fakeIntrospectUserID,
+		Enabled: ptr.To(true),
+	}
+	hash := sha256.Sum256([]byte(fakeIntrospectTokenName))
+	rancherTokenHash := hex.EncodeToString(hash[:])
+	refreshToken := signIntrospectToken(jwt.MapClaims{
+		"aud":                []string{fakeIntrospectClientID},
+		"exp":                introspectNow.Add(time.Hour).Unix(),
+		"iat":                introspectNow.Unix(),
+		"sub":                fakeIntrospectUserID,
+		"rancher_token_hash": rancherTokenHash,
+	})
+	accessToken := signIntrospectToken(jwt.MapClaims{
+		"aud": []string{fakeIntrospectClientID},
+		"exp": introspectNow.Add(time.Minute).Unix(),
+		"iat": introspectNow.Unix(),
+		"sub": fakeIntrospectUserID,
+	})
+	otherClientRefreshToken := signIntrospectToken(jwt.MapClaims{
+		"aud":                []string{"other-client"},
+		"exp":                introspectNow.Add(time.Hour).Unix(),
+		"iat":                introspectNow.Unix(),
+		"sub":                fakeIntrospectUserID,
+		"rancher_token_hash": rancherTokenHash,
+	})
+	revokedPatch, _ := json.Marshal([]jsonPatch{{
+		Op:   "add",
+		Path: "/metadata/annotations",
+		Value: map[string]string{
+			revokedAtAnnotation(fakeIntrospectClientName): fmt.Sprintf("%d", introspectNow.UnixNano()),
+		},
+	}})
+	clientAuthenticated := func(m introspectMocks) {
+		m.oidcClientCache.EXPECT().GetByIndex(oidcClientByIDIndex, fakeIntrospectClientID).Return([]*v3.OIDCClient{fakeOIDCClient}, nil)
+		m.secretCache.EXPECT().Get(secretsNamespace, fakeIntrospectClientID).Return(fakeClientSecret, nil)
+		m.oidcClient.EXPECT().Patch(fakeIntrospectClientName, types.JSONPatchType, gomock.Any()).Return(fakeOIDCClient, nil)
+	}
+
+	tests := map[string]struct {
+		req        func() *http.Request
+		mockSetup  func(introspectMocks)
+		wantStatus int
+		wantBody   string
+	}{
+		"refresh token is revoked": {
+			req: func() *http.Request {
+				return introspectRequest(refreshToken)
+			},
+			mockSetup: func(m introspectMocks) {
+				clientAuthenticated(m)
+				m.signingKeyGetter.EXPECT().GetPublicKey(fakeIntrospectSigningKey).Return(&introspectPrivateKey.PublicKey, nil)
+				m.tokenCache.EXPECT().List(labels.SelectorFromSet(map[string]string{
+					tokens.UserIDLabel: fakeIntrospectUserID,
+				})).Return([]*v3.Token{fakeToken}, nil)
+				m.tokenClient.EXPECT().Patch(fakeIntrospectTokenName, types.JSONPatchType, revokedPatch).Return(fakeToken, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		"refresh token is ignored when the Rancher token is gone": {
+			req: func() *http.Request {
+				return introspectRequest(refreshToken)
+			},
+			mockSetup: func(m introspectMocks) {
+				clientAuthenticated(m)
+				m.signingKeyGetter.EXPECT().GetPublicKey(fakeIntrospectSigningKey).Return(&introspectPrivateKey.PublicKey, nil)
+				m.tokenCache.EXPECT().List(labels.SelectorFromSet(map[string]string{
+					tokens.UserIDLabel: fakeIntrospectUserID,
+				})).Return([]*v3.Token{}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		"invalid token is ignored": {
+			req: func() *http.Request {
+				return introspectRequest("invalid")
+			},
+			mockSetup:  clientAuthenticated,
+			wantStatus: http.StatusOK,
+		},
+		"access token can't be revoked": {
+			req: func() *http.Request {
+				return introspectRequest(accessToken)
+			},
+			mockSetup: func(m introspectMocks) {
+				clientAuthenticated(m)
+				m.signingKeyGetter.EXPECT().GetPublicKey(fakeIntrospectSigningKey).Return(&introspectPrivateKey.PublicKey, nil)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"unsupported_token_type","error_description":"only refresh tokens can be revoked"}`,
+		},
+		"token issued to another client can't be revoked": {
+			req: func() *http.Request {
+				return introspectRequest(otherClientRefreshToken)
+			},
+			mockSetup: func(m introspectMocks) {
+				clientAuthenticated(m)
+				m.signingKeyGetter.EXPECT().GetPublicKey(fakeIntrospectSigningKey).Return(&introspectPrivateKey.PublicKey, nil)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"invalid_request","error_description":"token was not issued to the client"}`,
+		},
+		"missing client credentials": {
+			req: func() *http.Request {
+				req := introspectRequest(refreshToken)
+				req.Header.Del("Authorization")
+
+				return req
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   `{"error":"invalid_client","error_description":"client authentication is required"}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			m := newIntrospectMocks(ctrl)
+			if test.mockSetup != nil {
+				test.mockSetup(m)
+			}
+			h := newTokenHandler(m.tokenCache, m.userLister, nil, nil, m.signingKeyGetter, m.oidcClientCache, m.oidcClient, m.secretCache, m.tokenClient, nil, nil)
+			h.now = func() time.Time { return introspectNow }
+			rec := httptest.NewRecorder()
+
+			h.revokeEndpoint(rec, test.req())
+
+			assert.Equal(t, test.wantStatus, rec.Code)
+			if test.wantBody != "" {
+				assert.JSONEq(t, test.wantBody, strings.TrimSpace(rec.Body.String()))
+			} else {
+				assert.Empty(t, rec.Body.String())
+			}
+		})
+	}
+}
+
+func newIntrospectMocks(ctrl *gomock.Controller) introspectMocks {
+	return introspectMocks{
+		tokenCache:       fake.NewMockNonNamespacedCacheInterface[*v3.Token](ctrl),
+		tokenClient:      fake.NewMockNonNamespacedClientInterface[*v3.Token, *v3.TokenList](ctrl),
+		secretCache:      fake.NewMockCacheInterface[*v1.Secret](ctrl),
+		oidcClientCache:  fake.NewMockNonNamespacedCacheInterface[*v3.OIDCClient](ctrl),
+		oidcClient:       fake.NewMockNonNamespacedClientInterface[*v3.OIDCClient, *v3.OIDCClientList](ctrl),
+		userLister:       fake.NewMockNonNamespacedCacheInterface[*v3.User](ctrl),
+		signingKeyGetter: mocks.NewMocksigningKeyGetter(ctrl),
+	}
+}
+
+func TestRevokedAtAnnotation(t *testing.T) {
+	assert.Equal(t, "cattle.io.oidc-client-revoked-at-client-1", revokedAtAnnotation("client-1"))
+
+	longName := strings.Repeat("a", 253)
+	key := revokedAtAnnotation(longName)
+	assert.Empty(t, validation.IsQualifiedName(key))
+	assert.NotEqual(t, key, revokedAtAnnotation(longName[:252]+"b"))
+}
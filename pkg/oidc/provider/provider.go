@@ -9,6 +9,7 @@
 	"github.com/gorilla/mux"
 	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	wrangmgmtv3 "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/oidc/provider/device"
 	oidcerror "github.com/rancher/rancher/pkg/oidc/provider/error"
 	"github.com/rancher/rancher/pkg/oidc/provider/session"
 	"github.com/rancher/rancher/pkg/oidc/randomstring"
@@ -28,14 +29,17 @@
 )
 
 type Provider struct {
-	jwksHandler     *jwksHandler
-	authHandler     *authorizeHandler
-	tokenHandler    *tokenHandler
-	userInfoHandler *userInfoHandler
+	jwksHandler       *jwksHandler
+	authHandler       *authorizeHandler
+	tokenHandler      *tokenHandler
+	userInfoHandler   *userInfoHandler
+	endSessionHandler *endSessionHandler
+	deviceHandler     *deviceVerificationHandler
 }
 
 func NewProvider(ctx context.Context, tokenCache wrangmgmtv3.TokenCache, tokenClient wrangmgmtv3.TokenClient, userLister wrangmgmtv3.UserCache, userAttributeLister wrangmgmtv3.UserAttributeCache, secretCache corecontrollers.SecretCache, secretClient corecontrollers.SecretClient, oidcClientCache wrangmgmtv3.OIDCClientCache, oidcClientController wrangmgmtv3.OIDCClientController, namespaceClient corecontrollers.NamespaceClient) (Provider, error) {
 	sessionStorage := session.NewSecretSessionStore(ctx, secretCache, secretClient, maxTime)
+	deviceCodeStorage := device.NewSecretDeviceCodeStore(ctx, secretCache, secretClient, maxTime)
 	jwks, err := newJWKSHandler(secretCache, secretClient)
 	if err != nil {
 		return Provider{}, err
@@ -72,11 +76,15 @@ func NewProvider(ctx context.Context, tokenCache wrangmgmtv3.TokenCache, tokenCl
 		return Provider{}, err
 	}
 
+	authHandler := newAuthorizeHandler(tokenCache, userLister, sessionStorage, &randomstring.Generator{}, oidcClientCache)
+
 	return Provider{
-		jwksHandler:     jwks,
-		authHandler:     newAuthorizeHandler(tokenCache, userLister, sessionStorage, &randomstring.Generator{}, oidcClientCache),
-		tokenHandler:    newTokenHandler(tokenCache, userLister, userAttributeLister, sessionStorage, jwks, oidcClientCache, oidcClientController, secretCache, tokenClient),
-		userInfoHandler: newUserInfoHandler(userLister, userAttributeLister, jwks),
+		jwksHandler:       jwks,
+		authHandler:       authHandler,
+		tokenHandler:      newTokenHandler(tokenCache, userLister, userAttributeLister, sessionStorage, jwks, oidcClientCache, oidcClientController, secretCache, tokenClient, deviceCodeStorage, &randomstring.Generator{}),
+		userInfoHandler:   newUserInfoHandler(userLister, userAttributeLister, jwks),
+		endSessionHandler: newEndSessionHandler(tokenClient, oidcClientCache, authHandler, jwks),
+		deviceHandler:     newDeviceVerificationHandler(deviceCodeStorage, oidcClientCache, authHandler),
 	}, nil
 }
 
@@ -122,4 +130,9 @@ func (p *Provider) RegisterOIDCProviderHandles(mux *mux.Router) {
 	mux.HandleFunc("/oidc/authorize", p.middleware(p.authHandler.authEndpoint))
 	mux.HandleFunc("/oidc/token", p.middleware(p.tokenHandler.tokenEndpoint))
 	mux.HandleFunc("/oidc/userinfo", p.middleware(p.userInfoHandler.userInfoEndpoint))
+	mux.HandleFunc("/oidc/introspect", p.middleware(p.tokenHandler.introspectEndpoint))
+	mux.HandleFunc("/oidc/revoke", p.middleware(p.tokenHandler.revokeEndpoint))
+	mux.HandleFunc("/oidc/end_session", p.middleware(p.endSessionHandler.endSessionEndpoint))
+	mux.HandleFunc("/oidc/device_authorization", p.middleware(p.tokenHandler.deviceAuthorizationEndpoint))
+	mux.HandleFunc("/oidc/device", p.middleware(p.deviceHandler.verificationEndpoint))
 }
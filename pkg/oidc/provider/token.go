@@ -29,7 +29,14 @@
 	"k8s.io/client-go/tools/cache"
 )
 
-const bearerTokenType = "Bearer"
+const (
+	bearerTokenType = "Bearer"
+
+	// accessTokenType and refreshTokenType are the values of the token_type claim of the access and refresh tokens
+	// issued by the provider, which tells them apart from id tokens signed with the same key.
+	accessTokenType  = "access_token"
+	refreshTokenType = "refresh_token"
+)
 
 type sessionGetterRemover interface {
 	Get(code string) (*session.Session, error)
@@ -58,13 +65,15 @@ type tokenHandler struct {
 	secretCache         corev1.SecretCache
 	oidcClientIndexer   cache.Indexer
 	jwks                signingKeyGetter
+	deviceStore         deviceCodeStore
+	deviceCodeCreator   deviceCodeCreator
 	now                 func() time.Time
 }
 
 // TokenResponse represents a successful response returned by the token endpoint
 type TokenResponse struct {
-	// IDToken is the oidc token generated.
-	IDToken string `json:"id_token"`
+	// IDToken is the oidc token generated. It isn't issued for the client_credentials grant.
+	IDToken string `json:"id_token,omitempty"`
 	// AccessToken is the access token generated.
 	AccessToken string `json:"access_token"`
 	// AccessToken is the refresh token generated.
@@ -82,6 +91,11 @@ type RefreshTokenClaims struct {
 	RancherTokenHash string `json:"rancher_token_hash"`
 	// Scope indicates the scopes for this token.
 	Scope []string `json:"scope"`
+	// TokenType is the type of the token, either accessTokenType or refreshTokenType.
+	TokenType string `json:"token_type,omitempty"`
+	// IssuedAtNano is the unix time in nanoseconds the refresh token was issued at. Unlike iat, it can be compared with
+	// the time the refresh tokens were revoked at without ambiguity within the same second.
+	IssuedAtNano int64 `json:"rancher_iat_ns,omitempty"`
 }
 
 func newTokenHandler(tokenCache wrangmgmtv3.TokenCache,
@@ -92,7 +106,9 @@ func newTokenHandler(tokenCache wrangmgmtv3.TokenCache,
 	oidcClientCache wrangmgmtv3.OIDCClientCache,
 	oidcClient wrangmgmtv3.OIDCClientClient,
 	secretCache corev1.SecretCache,
-	tokenClient wrangmgmtv3.TokenClient) *tokenHandler {
+	tokenClient wrangmgmtv3.TokenClient,
+	deviceStore deviceCodeStore,
+	deviceCodeCreator deviceCodeCreator) *tokenHandler {
 
 	return &tokenHandler{
 		tokenCache:          tokenCache,
@@ -104,6 +120,8 @@ func newTokenHandler(tokenCache wrangmgmtv3.TokenCache,
 		oidcClientCache:     oidcClientCache,
 		oidcClient:          oidcClient,
 		secretCache:         secretCache,
+		deviceStore:         deviceStore,
+		deviceCodeCreator:   deviceCodeCreator,
 		now:                 time.Now,
 	}
 }
@@ -144,6 +162,32 @@ func (h *tokenHandler) tokenEndpoint(w http.ResponseWriter, r *http.Request) {
 			oidcerror.WriteError(oidcerror.ServerError, "failed to encode refresh token response", http.StatusInternalServerError, w)
 			return
 		}
+	case deviceCodeGrantType:
+		tokenResponse, oidcErr := h.createTokenFromDeviceCode(r)
+		if oidcErr != nil {
+			logrus.Debug("[OIDC provider] error creating device code token response: " + oidcErr.ToString())
+			oidcErr.Write(http.StatusBadRequest, w)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(tokenResponse)
+		if err != nil {
+			oidcerror.WriteError(oidcerror.ServerError, "failed to encode device code token response", http.StatusInternalServerError, w)
+			return
+		}
+	case clientCredentialsGrantType:
+		tokenResponse, oidcErr := h.createTokenFromClientCredentials(r)
+		if oidcErr != nil {
+			logrus.Debug("[OIDC provider] error creating client credentials token response: " + oidcErr.ToString())
+			oidcErr.Write(http.StatusBadRequest, w)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(tokenResponse)
+		if err != nil {
+			oidcerror.WriteError(oidcerror.ServerError, "failed to encode client credentials token response", http.StatusInternalServerError, w)
+			return
+		}
 	default:
 		http.Error(w, "grant_type not supported", http.StatusInternalServerError)
 		return
@@ -161,36 +205,14 @@ func (h *tokenHandler) createTokenFromCode(r *http.Request) (TokenResponse, *oid
 		return TokenResponse{}, oidcerror.New(oidcerror.ServerError, "error retrieving session :"+err.Error())
 	}
 
-	// verify clientID and secret. They can be set in the Authorization header or as a form param as specified in the OIDC spec.
-	var clientID, _ string
-	clientID, clientSecret, ok := r.BasicAuth()
-	if !ok {
-		clientID = r.FormValue("client_id")
-		clientSecret = r.FormValue("client_secret")
-	}
+	// verify clientID and secret.
+	clientID, clientSecret := clientCredentialsFromRequest(r)
 	if clientID != session.ClientID {
 		return TokenResponse{}, oidcerror.New(oidcerror.InvalidRequest, "invalid client_id")
 	}
-	oidcClient, err := h.getOIDCClientByClientID(clientID)
-	if err != nil {
-		return TokenResponse{}, oidcerror.New(oidcerror.ServerError, "failed to get OIDC client")
-	}
-	secret, err := h.secretCache.Get(secretsNamespace, clientID)
-	if err != nil {
-		return TokenResponse{}, oidcerror.New(oidcerror.ServerError, "failed to get client secret")
-	}
-	clientSecretFound := false
-	for key, cs := range secret.Data {
-		if clientSecret == string(cs) {
-			clientSecretFound = true
-			if err := h.updateClientSecretUsedTimeStamp(oidcClient, key); err != nil {
-				logrus.Errorf("[OIDC provider] failed to update client secret's used timestamp: %v", err)
-			}
-			break
-		}
-	}
-	if !clientSecretFound {
-		return TokenResponse{}, oidcerror.New(oidcerror.InvalidRequest, "invalid client_secret")
+	oidcClient, oidcErr := h.verifyClient(clientID, clientSecret)
+	if oidcErr != nil {
+		return TokenResponse{}, oidcErr
 	}
 
 	// PKCE verification
@@ -221,22 +243,7 @@ func (h *tokenHandler) createTokenFromCode(r *http.Request) (TokenResponse, *oid
 func (h *tokenHandler) createRefreshToken(r *http.Request) (TokenResponse, *oidcerror.Error) {
 	refreshToken := r.Form.Get("refresh_token")
 	// verify refresh_token signature
-	token, err := jwt.ParseWithClaims(refreshToken, &RefreshTokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Ensure correct signing method
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		kid, ok := token.Header["kid"].(string)
-		if !ok {
-			return nil, fmt.Errorf("can't find kid")
-		}
-		pubKey, err := h.jwks.GetPublicKey(kid)
-		if err != nil {
-			return nil, err
-		}
-
-		return pubKey, nil
-	})
+	token, err := jwt.ParseWithClaims(refreshToken, &RefreshTokenClaims{}, verificationKey(h.jwks))
 	if err != nil {
 		return TokenResponse{}, oidcerror.New(oidcerror.ServerError, fmt.Sprintf("failed to parse refresh token: %v", err))
 	}
@@ -246,23 +253,9 @@ func (h *tokenHandler) createRefreshToken(r *http.Request) (TokenResponse, *oidc
 	}
 
 	// get rancher Token associated with this refresh_token
-	tokenList, err := h.tokenCache.List(labels.SelectorFromSet(map[string]string{
-		tokens.UserIDLabel: claims.Subject,
-	}))
-	if err != nil {
-		return TokenResponse{}, oidcerror.New(oidcerror.ServerError, fmt.Sprintf("failed to add OIDC Client ID to Rancher token: %v", err))
-	}
-	var rancherToken *v3.Token
-	for _, token := range tokenList {
-		hash := sha256.Sum256([]byte(token.Name))
-		rancherTokenHash := hex.EncodeToString(hash[:])
-		if rancherTokenHash == claims.RancherTokenHash {
-			rancherToken = token
-			break
-		}
-	}
-	if rancherToken == nil {
-		return TokenResponse{}, oidcerror.New(oidcerror.AccessDenied, "Rancher token no longer present.")
+	rancherToken, oidcErr := h.getRancherTokenForRefreshToken(claims)
+	if oidcErr != nil {
+		return TokenResponse{}, oidcErr
 	}
 
 	// identify the OIDC client for the refresh_token using the audience
@@ -273,47 +266,70 @@ func (h *tokenHandler) createRefreshToken(r *http.Request) (TokenResponse, *oidc
 	if err != nil {
 		return TokenResponse{}, oidcerror.New(oidcerror.ServerError, fmt.Sprintf("failed to get oidc client: %v", err))
 	}
+	if isRefreshTokenRevoked(rancherToken, oidcClient, claims) {
+		return TokenResponse{}, oidcerror.New(oidcerror.AccessDenied, "refresh token has been revoked")
+	}
 
 	return h.createTokenResponse(rancherToken, oidcClient, "", claims.Scope)
 }
 
-// createTokenResponse creates an id_token, access_token and refresh_token for a valid Rancher token
-func (h *tokenHandler) createTokenResponse(rancherToken *v3.Token, oidcClient *v3.OIDCClient, nonce string, scopes []string) (TokenResponse, *oidcerror.Error) {
-	// verify Rancher token and user are valid
+// getRancherTokenForRefreshToken returns the Rancher token the refresh_token was derived from.
+func (h *tokenHandler) getRancherTokenForRefreshToken(claims *RefreshTokenClaims) (*v3.Token, *oidcerror.Error) {
+	tokenList, err := h.tokenCache.List(labels.SelectorFromSet(map[string]string{
+		tokens.UserIDLabel: claims.Subject,
+	}))
+	if err != nil {
+		return nil, oidcerror.New(oidcerror.ServerError, fmt.Sprintf("failed to add OIDC Client ID to Rancher token: %v", err))
+	}
+	for _, token := range tokenList {
+		hash := sha256.Sum256([]byte(token.Name))
+		rancherTokenHash := hex.EncodeToString(hash[:])
+		if rancherTokenHash == claims.RancherTokenHash {
+			return token, nil
+		}
+	}
+
+	return nil, oidcerror.New(oidcerror.AccessDenied, "Rancher token no longer present.")
+}
+
+// verifyRancherToken checks that the Rancher token and its user are still valid, and returns the user.
+func (h *tokenHandler) verifyRancherToken(rancherToken *v3.Token) (*v3.User, *oidcerror.Error) {
 	if tokens.IsExpired(*rancherToken) {
-		return TokenResponse{}, oidcerror.New(oidcerror.AccessDenied, "Rancher token has expired")
+		return nil, oidcerror.New(oidcerror.AccessDenied, "Rancher token has expired")
 	}
 	if rancherToken.Enabled != nil && !*rancherToken.Enabled {
-		return TokenResponse{}, oidcerror.New(oidcerror.AccessDenied, "Rancher token is disabled")
+		return nil, oidcerror.New(oidcerror.AccessDenied, "Rancher token is disabled")
 	}
 	if rancherToken.AuthProvider != "" {
 		disabled, err := providers.IsDisabledProvider(rancherToken.AuthProvider)
 		if err != nil {
-			return TokenResponse{}, oidcerror.New(oidcerror.ServerError, fmt.Sprintf("can't check if auth provider is disabled: %v", err))
+			return nil, oidcerror.New(oidcerror.ServerError, fmt.Sprintf("can't check if auth provider is disabled: %v", err))
 		}
 		if disabled {
-			return TokenResponse{}, oidcerror.New(oidcerror.AccessDenied, "auth provider is disabled")
+			return nil, oidcerror.New(oidcerror.AccessDenied, "auth provider is disabled")
 		}
 	}
 	user, err := h.userLister.Get(rancherToken.UserID)
 	if err != nil {
-		return TokenResponse{}, oidcerror.New(oidcerror.ServerError, fmt.Sprintf("can't get user: %v", err))
+		return nil, oidcerror.New(oidcerror.ServerError, fmt.Sprintf("can't get user: %v", err))
 	}
 	if user.Enabled != nil && !*user.Enabled {
-		return TokenResponse{}, oidcerror.New(oidcerror.AccessDenied, "user is disabled")
+		return nil, oidcerror.New(oidcerror.AccessDenied, "user is disabled")
 	}
-	attribs, err := h.userAttributeLister.Get(rancherToken.UserID)
-	if err != nil && !apierrors.IsNotFound(err) {
-		return TokenResponse{}, oidcerror.New(oidcerror.ServerError, fmt.Sprintf("can't get user attributes: %v", err))
+
+	return user, nil
+}
+
+// createTokenResponse creates an id_token, access_token and refresh_token for a valid Rancher token
+func (h *tokenHandler) createTokenResponse(rancherToken *v3.Token, oidcClient *v3.OIDCClient, nonce string, scopes []string) (TokenResponse, *oidcerror.Error) {
+	// verify Rancher token and user are valid
+	user, oidcErr := h.verifyRancherToken(rancherToken)
+	if oidcErr != nil {
+		return TokenResponse{}, oidcErr
 	}
-	var groups []string
-	if attribs != nil {
-		for _, gps := range attribs.GroupPrincipals {
-			for _, principal := range gps.Items {
-				name := strings.TrimPrefix(principal.Name, "local://")
-				groups = append(groups, name)
-			}
-		}
+	groups, oidcErr := h.getUserGroups(rancherToken.UserID)
+	if oidcErr != nil {
+		return TokenResponse{}, oidcErr
 	}
 
 	key, kid, err := h.jwks.GetSigningKey()
@@ -350,12 +366,13 @@ func (h *tokenHandler) createTokenResponse(rancherToken *v3.Token, oidcClient *v
 
 	// create access_token
 	accessClaims := jwt.MapClaims{
-		"aud":   []string{oidcClient.Status.ClientID},
-		"exp":   h.now().Add(time.Duration(oidcClient.Spec.TokenExpirationSeconds) * time.Second).Unix(),
-		"iss":   settings.ServerURL.Get() + "/oidc",
-		"iat":   h.now().Unix(),
-		"sub":   rancherToken.UserID,
-		"scope": scopes,
+		"aud":        []string{oidcClient.Status.ClientID},
+		"exp":        h.now().Add(time.Duration(oidcClient.Spec.TokenExpirationSeconds) * time.Second).Unix(),
+		"iss":        settings.ServerURL.Get() + "/oidc",
+		"iat":        h.now().Unix(),
+		"sub":        rancherToken.UserID,
+		"scope":      scopes,
+		"token_type": accessTokenType,
 	}
 	if rancherToken.AuthProvider != "" {
 		accessClaims["auth_provider"] = rancherToken.AuthProvider
@@ -385,6 +402,8 @@ func (h *tokenHandler) createTokenResponse(rancherToken *v3.Token, oidcClient *v
 			"sub":                rancherToken.UserID,
 			"rancher_token_hash": rancherTokenHash,
 			"scope":              scopes,
+			"token_type":         refreshTokenType,
+			"rancher_iat_ns":     h.now().UnixNano(),
 		}
 		if rancherToken.AuthProvider != "" {
 			refreshClaims["auth_provider"] = rancherToken.AuthProvider
@@ -408,6 +427,25 @@ func (h *tokenHandler) createTokenResponse(rancherToken *v3.Token, oidcClient *v
 	return resp, nil
 }
 
+// getUserGroups returns the names of the group principals the user belongs to.
+func (h *tokenHandler) getUserGroups(userID string) ([]string, *oidcerror.Error) {
+	attribs, err := h.userAttributeLister.Get(userID)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, oidcerror.New(oidcerror.ServerError, fmt.Sprintf("can't get user attributes: %v", err))
+	}
+	var groups []string
+	if attribs != nil {
+		for _, gps := range attribs.GroupPrincipals {
+			for _, principal := range gps.Items {
+				name := strings.TrimPrefix(principal.Name, "local://")
+				groups = append(groups, name)
+			}
+		}
+	}
+
+	return groups, nil
+}
+
 func (h *tokenHandler) updateClientSecretUsedTimeStamp(oidcClient *v3.OIDCClient, clientSecretID string) interface{} {
 	var patch []byte
 	var err error
@@ -461,6 +499,61 @@ func (h *tokenHandler) addOIDCClientIDToRancherToken(oidcClientName string, ranc
 	return err
 }
 
+// clientCredentialsFromRequest returns the client_id and client_secret of the request. They can be set in the
+// Authorization header or as a form param as specified in the OIDC spec.
+func clientCredentialsFromRequest(r *http.Request) (string, string) {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID = r.FormValue("client_id")
+		clientSecret = r.FormValue("client_secret")
+	}
+
+	return clientID, clientSecret
+}
+
+// verifyClient returns the OIDC client if the client secret is one of its secrets.
+func (h *tokenHandler) verifyClient(clientID, clientSecret string) (*v3.OIDCClient, *oidcerror.Error) {
+	oidcClient, err := h.getOIDCClientByClientID(clientID)
+	if err != nil {
+		return nil, oidcerror.New(oidcerror.ServerError, "failed to get OIDC client")
+	}
+	secret, err := h.secretCache.Get(secretsNamespace, clientID)
+	if err != nil {
+		return nil, oidcerror.New(oidcerror.ServerError, "failed to get client secret")
+	}
+	for key, cs := range secret.Data {
+		if clientSecret == string(cs) {
+			if err := h.updateClientSecretUsedTimeStamp(oidcClient, key); err != nil {
+				logrus.Errorf("[OIDC provider] failed to update client secret's used timestamp: %v", err)
+			}
+			return oidcClient, nil
+		}
+	}
+
+	return nil, oidcerror.New(oidcerror.InvalidRequest, "invalid client_secret")
+}
+
+// verificationKey returns a jwt.Keyfunc that returns the public key used to verify the signature of a token issued
+// by the provider.
+func verificationKey(jwks signingKeyGetter) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		// Ensure correct signing method
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		kid, ok := token.Header["kid"].(string)
+		if !ok {
+			return nil, fmt.Errorf("can't find kid")
+		}
+		pubKey, err := jwks.GetPublicKey(kid)
+		if err != nil {
+			return nil, err
+		}
+
+		return pubKey, nil
+	}
+}
+
 func (h *tokenHandler) getOIDCClientByClientID(clientID string) (*v3.OIDCClient, error) {
 	oidcClients, err := h.oidcClientCache.GetByIndex(oidcClientByIDIndex, clientID)
 	if err != nil {
@@ -228,6 +228,7 @@ type mockParams struct {
 				"sub":           fakeUserID,
 				"auth_provider": fakeAuthProvider,
 				"scope":         fakeScopes,
+				"token_type":    accessTokenType,
 			},
 		},
 		"authorization_code fails for an invalid code": {
@@ -392,6 +393,7 @@ type mockParams struct {
 				"sub":           fakeUserID,
 				"auth_provider": fakeAuthProvider,
 				"scope":         fakeScopesOfflineAccess,
+				"token_type":    accessTokenType,
 			},
 			wantRefreshTokenClaims: &jwt.MapClaims{
 				"aud":                []interface{}{fakeClientID},
@@ -401,6 +403,8 @@ type mockParams struct {
 				"auth_provider":      fakeAuthProvider,
 				"scope":              fakeScopesOfflineAccess,
 				"rancher_token_hash": rancherTokenHash,
+				"token_type":         refreshTokenType,
+				"rancher_iat_ns":     float64(fakeTime().UnixNano()),
 			},
 		},
 		"refresh_token returns new refresh token": {
@@ -443,6 +447,7 @@ type mockParams struct {
 				"sub":           fakeUserID,
 				"auth_provider": fakeAuthProvider,
 				"scope":         fakeScopesOfflineAccess,
+				"token_type":    accessTokenType,
 			},
 			wantRefreshTokenClaims: &jwt.MapClaims{
 				"aud":                []interface{}{fakeClientID},
@@ -452,6 +457,8 @@ type mockParams struct {
 				"auth_provider":      fakeAuthProvider,
 				"scope":              fakeScopesOfflineAccess,
 				"rancher_token_hash": rancherTokenHash,
+				"token_type":         refreshTokenType,
+				"rancher_iat_ns":     float64(fakeTime().UnixNano()),
 			},
 		},
 		"refresh_token fails to validate signature": {
@@ -491,6 +498,30 @@ type mockParams struct {
 			},
 			wantError: `{"error":"access_denied","error_description":"Rancher token no longer present."}`,
 		},
+		"refresh_token fails when it has been revoked": {
+			req: func() *http.Request {
+				data := url.Values{}
+				data.Set("grant_type", "refresh_token")
+				data.Set("refresh_token", fakeRefreshTokenString)
+				req, _ := http.NewRequest("POST", "https://rancher.com", bytes.NewBufferString(data.Encode()))
+				req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+				req.Header.Add("Authorization", fmt.Sprintf("Basic %s", base64.StdEncoding.EncodeToString([]byte(fakeClientID+":"+fakeClientSecret))))
+
+				return req
+			},
+			mockSetup: func(m mockParams) {
+				revokedToken := fakeToken.DeepCopy()
+				revokedToken.Annotations = map[string]string{
+					revokedAtAnnotation(fakeClientName): fmt.Sprintf("%d", fakeTime().UnixNano()),
+				}
+				m.signingKeyGetter.EXPECT().GetPublicKey(fakeSigningKey).Return(&privateKey.PublicKey, nil)
+				m.tokenCache.EXPECT().List(labels.SelectorFromSet(map[string]string{
+					tokens.UserIDLabel: fakeUserID,
+				})).Return([]*v3.Token{revokedToken}, nil)
+				m.oidcClientCache.EXPECT().GetByIndex("oidc.management.cattle.io/oidcclient-by-id", fakeClientID).Return([]*v3.OIDCClient{fakeOIDCClient}, nil)
+			},
+			wantError: `{"error":"access_denied","error_description":"refresh token has been revoked"}`,
+		},
 		"refresh_token fails when the associated Rancher token has expired": {
 			req: func() *http.Request {
 				data := url.Values{}
@@ -583,7 +614,7 @@ type mockParams struct {
 			if test.mockSetup != nil {
 				test.mockSetup(m)
 			}
-			h := newTokenHandler(m.tokenCache, m.userLister, m.useAttributeLister, m.sessionClient, m.signingKeyGetter, m.oidcClientCache, m.oidcClient, m.secretCache, m.tokenClient)
+			h := newTokenHandler(m.tokenCache, m.userLister, m.useAttributeLister, m.sessionClient, m.signingKeyGetter, m.oidcClientCache, m.oidcClient, m.secretCache, m.tokenClient, nil, nil)
 			h.now = fakeTime
 			rec := httptest.NewRecorder()
 
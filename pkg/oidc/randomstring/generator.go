@@ -13,11 +13,19 @@
 	clientIDPrefix     = "client-"
 	codePrefix         = "code-"
 	clientSecretPrefix = "secret-"
+	deviceCodePrefix   = "device-"
+	// userCharacters are the characters used in user codes. They are uppercase consonants, so user codes are easy to
+	// type and can't form words, as recommended in RFC 8628.
+	userCharacters = "BCDFGHJKLMNPQRSTVWXZ"
+	userCodeLength = 8
 )
 
 type Generator struct{}
 
-var charsLength = big.NewInt(int64(len(characters)))
+var (
+	charsLength     = big.NewInt(int64(len(characters)))
+	userCharsLength = big.NewInt(int64(len(userCharacters)))
+)
 
 // GenerateClientID generates an OIDC Client ID. It has 'client-' as a prefix and 10 random characters.
 func (r *Generator) GenerateClientID() (string, error) {
@@ -34,6 +42,25 @@ func (r *Generator) GenerateCode() (string, error) {
 	return r.generateRandomString(codePrefix, codeLength)
 }
 
+// GenerateDeviceCode generates an OAuth 2.0 device code. It has 'device-' as a prefix and 56 random characters.
+func (r *Generator) GenerateDeviceCode() (string, error) {
+	return r.generateRandomString(deviceCodePrefix, codeLength)
+}
+
+// GenerateUserCode generates the user code of an OAuth 2.0 device authorization. It has 8 random uppercase consonants
+// separated by a dash, e.g. 'BCDF-GHJK'.
+func (r *Generator) GenerateUserCode() (string, error) {
+	code := make([]byte, userCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, userCharsLength)
+		if err != nil {
+			return "", err
+		}
+		code[i] = userCharacters[n.Int64()]
+	}
+	return string(code[:userCodeLength/2]) + "-" + string(code[userCodeLength/2:]), nil
+}
+
 func (r *Generator) generateRandomString(prefix string, length int) (string, error) {
 	token := make([]byte, length)
 	for i := range token {
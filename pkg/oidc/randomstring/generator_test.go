@@ -36,3 +36,22 @@ func TestGenerateCode(t *testing.T) {
 	assert.True(t, len(code) == 61)
 	assert.True(t, strings.HasPrefix(code, codePrefix))
 }
+
+func TestGenerateDeviceCode(t *testing.T) {
+	g := Generator{}
+
+	code, err := g.GenerateDeviceCode()
+
+	assert.NoError(t, err)
+	assert.True(t, len(code) == 63)
+	assert.True(t, strings.HasPrefix(code, deviceCodePrefix))
+}
+
+func TestGenerateUserCode(t *testing.T) {
+	g := Generator{}
+
+	code, err := g.GenerateUserCode()
+
+	assert.NoError(t, err)
+	assert.Regexp(t, "^[BCDFGHJKLMNPQRSTVWXZ]{4}-[BCDFGHJKLMNPQRSTVWXZ]{4}$", code)
+}
@@ -12,4 +12,6 @@ type PeerManager interface {
 	Leader()
 	AddListener(l chan<- Peers)
 	RemoveListener(l chan<- Peers)
+	// Peers returns the current state of this server and its peers.
+	Peers() Peers
 }
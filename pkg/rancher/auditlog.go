@@ -0,0 +1,105 @@
+package rancher
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/rancher/rancher/pkg/auth/audit"
+)
+
+const (
+	auditLogSyslogSinkName  = "syslog"
+	auditLogWebhookSinkName = "webhook"
+)
+
+// auditLogSinks builds the audit log sinks configured through the command line. The rotating file sink is always
+// present, syslog and webhook sinks are only added when an address is given.
+func auditLogSinks(opts *Options) ([]audit.Sink, error) {
+	// The file sink keeps the name of the single output of the audit log from before sinks could be configured, so
+	// that existing policies selecting it still apply.
+	fileSink, err := audit.NewFileSink(audit.DefaultSinkName, audit.FileSinkOptions{
+		Path:       opts.AuditLogPath,
+		MaxAge:     opts.AuditLogMaxage,
+		MaxBackups: opts.AuditLogMaxbackup,
+		MaxSize:    opts.AuditLogMaxsize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sinks := []audit.Sink{fileSink}
+
+	if opts.AuditLogSyslogAddress != "" {
+		var tlsConfig *tls.Config
+		if opts.AuditLogSyslogTLS {
+			if tlsConfig, err = tlsConfigForCA(opts.AuditLogSyslogCACert); err != nil {
+				return nil, fmt.Errorf("failed to configure syslog tls: %w", err)
+			}
+		}
+
+		syslogSink, err := audit.NewSyslogSink(auditLogSyslogSinkName, audit.SyslogSinkOptions{
+			Address:   opts.AuditLogSyslogAddress,
+			TLSConfig: tlsConfig,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		sinks = append(sinks, syslogSink)
+	}
+
+	if opts.AuditLogWebhookURL != "" {
+		tlsConfig, err := tlsConfigForCA(opts.AuditLogWebhookCACert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure webhook tls: %w", err)
+		}
+
+		headers := http.Header{}
+		if opts.AuditLogWebhookAuthorization != "" {
+			headers.Set("Authorization", opts.AuditLogWebhookAuthorization)
+		}
+
+		webhookSink, err := audit.NewWebhookSink(auditLogWebhookSinkName, audit.WebhookSinkOptions{
+			URL:          opts.AuditLogWebhookURL,
+			Headers:      headers,
+			TLSConfig:    tlsConfig,
+			BufferPath:   opts.AuditLogWebhookBufferPath,
+			RejectedPath: opts.AuditLogWebhookRejectedPath,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		sinks = append(sinks, webhookSink)
+	}
+
+	return sinks, nil
+}
+
+// tlsConfigForCA returns a tls config trusting the PEM encoded certificates in caFile, or the system roots if caFile
+// is empty.
+func tlsConfigForCA(caFile string) (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if caFile == "" {
+		return config, nil
+	}
+
+	data, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ca file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in '%s'", caFile)
+	}
+	config.RootCAs = pool
+
+	return config, nil
+}
@@ -29,6 +29,7 @@
 	"github.com/rancher/rancher/pkg/auth/requests"
 	"github.com/rancher/rancher/pkg/clusterrouter"
 	auditlogcontroller "github.com/rancher/rancher/pkg/controllers/auditlog/auditpolicy"
+	auditlogchainkey "github.com/rancher/rancher/pkg/controllers/auditlog/chainkey"
 	"github.com/rancher/rancher/pkg/controllers/dashboard"
 	"github.com/rancher/rancher/pkg/controllers/dashboard/apiservice"
 	"github.com/rancher/rancher/pkg/controllers/dashboard/plugin"
@@ -62,7 +63,6 @@
 	"github.com/rancher/wrangler/v3/pkg/unstructured"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
-	"gopkg.in/natefinch/lumberjack.v2"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -100,6 +100,19 @@ type Options struct {
 	AuditLogMaxbackup              int
 	AuditLogLevel                  int
 	AuditLogEnabled                bool
+	AuditLogSyslogAddress          string
+	AuditLogSyslogTLS              bool
+	AuditLogSyslogCACert           string
+	AuditLogWebhookURL             string
+	AuditLogWebhookCACert          string
+	AuditLogWebhookAuthorization   string
+	AuditLogWebhookBufferPath      string
+	AuditLogWebhookRejectedPath    string
+	AuditLogQueueSize              int
+	AuditLogWorkers                int
+	AuditLogOverflowPolicy         string
+	AuditLogChainEnabled           bool
+	AuditLogTrustedProxies         cli.StringSlice
 	Features                       string
 	ClusterRegistry                string
 	AggregationRegistrationTimeout time.Duration
@@ -302,21 +315,32 @@ func New(ctx context.Context, clientConfg clientcmd.ClientConfig, opts *Options)
 	var auditLogWriter *audit.Writer
 
 	if opts.AuditLogEnabled {
-		out := &lumberjack.Logger{
-			Filename:   opts.AuditLogPath,
-			MaxAge:     opts.AuditLogMaxage,
-			MaxBackups: opts.AuditLogMaxbackup,
-			MaxSize:    opts.AuditLogMaxsize,
+		sinks, err := auditLogSinks(opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create audit log sinks: %w", err)
 		}
-		defer out.Close()
 
-		auditLogWriter, err = audit.NewWriter(out, audit.WriterOptions{
+		auditLogWriter, err = audit.NewWriter(nil, audit.WriterOptions{
 			DefaultPolicyLevel:     auditlogv1.Level(opts.AuditLogLevel),
 			DisableDefaultPolicies: !opts.AuditLogEnabled,
+			Sinks:                  sinks,
+			QueueSize:              opts.AuditLogQueueSize,
+			Workers:                opts.AuditLogWorkers,
+			OverflowPolicy:         audit.OverflowPolicy(opts.AuditLogOverflowPolicy),
+			EnableChain:            opts.AuditLogChainEnabled,
+			TrustedProxies:         opts.AuditLogTrustedProxies,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to create audit log writer: %w", err)
 		}
+
+		if opts.AuditLogChainEnabled {
+			if err := auditlogchainkey.Ensure(wranglerContext.Core.Secret(), auditLogWriter); err != nil {
+				return nil, fmt.Errorf("failed to set audit log chain key: %w", err)
+			}
+		}
+
+		audit.SetEventWriter(auditLogWriter)
 	}
 
 	if opts.AuditLogEnabled {
@@ -324,6 +348,10 @@ func New(ctx context.Context, clientConfg clientcmd.ClientConfig, opts *Options)
 		if err := auditlogcontroller.Register(ctx, auditLogWriter, auditController); err != nil {
 			return nil, fmt.Errorf("failed to register audit log controller: %w", err)
 		}
+
+		if opts.AuditLogChainEnabled {
+			auditlogchainkey.Register(ctx, auditLogWriter, wranglerContext.Core.Secret())
+		}
 	}
 
 	auditFilter := audit.NewAuditLogMiddleware(auditLogWriter)
@@ -4,6 +4,7 @@
 	"crypto/sha256"
 	"encoding/base32"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -229,37 +230,62 @@ func getBindingHash(namespace string, role rbacv1.RoleRef, subject rbacv1.Subjec
 	return strings.ToLower(digest[:10])
 }
 
+// TemplateRule is a rule of a role template along with the role templates it was inherited through.
+type TemplateRule struct {
+	Rule rbacv1.PolicyRule
+
+	// RoleTemplateNames is the chain of role templates the rule was gathered through, starting with the template the
+	// rules were requested for and ending with the template defining the rule.
+	RoleTemplateNames []string
+}
+
 // RulesFromTemplate gets all rules from the template and all referenced templates
 func RulesFromTemplate(clusterRoles k8srbacv1.ClusterRoleCache, roleTemplates v32.RoleTemplateCache, rt *v3.RoleTemplate) ([]rbacv1.PolicyRule, error) {
+	templateRules, err := TemplateRulesFromTemplate(clusterRoles, roleTemplates, rt)
+	if err != nil {
+		return nil, err
+	}
+
 	var rules []rbacv1.PolicyRule
-	var err error
+	for _, templateRule := range templateRules {
+		rules = append(rules, templateRule.Rule)
+	}
+	return rules, nil
+}
+
+// TemplateRulesFromTemplate gets all rules from the template and all referenced templates, along with the templates
+// each rule was inherited through.
+func TemplateRulesFromTemplate(clusterRoles k8srbacv1.ClusterRoleCache, roleTemplates v32.RoleTemplateCache, rt *v3.RoleTemplate) ([]TemplateRule, error) {
 	templatesSeen := make(map[string]bool)
 
 	// Kickoff gathering rules
-	rules, err = gatherRules(clusterRoles, roleTemplates, rt, rules, templatesSeen)
-	if err != nil {
-		return rules, err
-	}
-	return rules, nil
+	return gatherRules(clusterRoles, roleTemplates, rt, nil, nil, templatesSeen)
 }
 
 // gatherRules appends the rules from current template and does a recursive call to get all inherited roles referenced
-func gatherRules(clusterRoles k8srbacv1.ClusterRoleCache, roleTemplates v32.RoleTemplateCache, rt *v3.RoleTemplate, rules []rbacv1.PolicyRule, seen map[string]bool) ([]rbacv1.PolicyRule, error) {
+func gatherRules(clusterRoles k8srbacv1.ClusterRoleCache, roleTemplates v32.RoleTemplateCache, rt *v3.RoleTemplate, chain []string, rules []TemplateRule, seen map[string]bool) ([]TemplateRule, error) {
 	seen[rt.Name] = true
+	chain = append(slices.Clip(chain), rt.Name)
+
+	appendRules := func(policyRules []rbacv1.PolicyRule) {
+		for _, rule := range policyRules {
+			rules = append(rules, TemplateRule{Rule: rule, RoleTemplateNames: chain})
+		}
+	}
 
 	if rt.External {
 		if rt.ExternalRules != nil {
-			rules = append(rules, rt.ExternalRules...)
+			appendRules(rt.ExternalRules)
 		} else if rt.Context == "cluster" {
 			cr, err := clusterRoles.Get(rt.Name)
 			if err != nil {
 				return nil, err
 			}
-			rules = append(rules, cr.Rules...)
+			appendRules(cr.Rules)
 		}
 	}
 
-	rules = append(rules, rt.Rules...)
+	appendRules(rt.Rules)
 
 	for _, r := range rt.RoleTemplateNames {
 		// If we have already seen the roleTemplate, skip it
@@ -270,7 +296,7 @@ func gatherRules(clusterRoles k8srbacv1.ClusterRoleCache, roleTemplates v32.Role
 		if err != nil {
 			return nil, err
 		}
-		rules, err = gatherRules(clusterRoles, roleTemplates, next, rules, seen)
+		rules, err = gatherRules(clusterRoles, roleTemplates, next, chain, rules, seen)
 		if err != nil {
 			return nil, err
 		}
@@ -11,7 +11,9 @@
 	mgmt "github.com/rancher/rancher/pkg/apis/management.cattle.io"
 	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3/fakes"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -687,3 +689,40 @@ func TestBuildAggregatingClusterRole(t *testing.T) {
 		})
 	}
 }
+
+func TestTemplateRulesFromTemplate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	clusterRoles := fake.NewMockNonNamespacedCacheInterface[*rbacv1.ClusterRole](ctrl)
+	roleTemplates := fake.NewMockNonNamespacedCacheInterface[*v3.RoleTemplate](ctrl)
+
+	readRule := rbacv1.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}}
+	writeRule := rbacv1.PolicyRule{Verbs: []string{"create"}, APIGroups: []string{""}, Resources: []string{"pods"}}
+	adminRule := rbacv1.PolicyRule{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}}
+
+	roleTemplates.EXPECT().Get("read").Return(&v3.RoleTemplate{
+		ObjectMeta:        metav1.ObjectMeta{Name: "read"},
+		Rules:             []rbacv1.PolicyRule{readRule},
+		RoleTemplateNames: []string{"admin"},
+	}, nil)
+	roleTemplates.EXPECT().Get("admin").Return(&v3.RoleTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "admin"},
+		Context:    "cluster",
+		External:   true,
+	}, nil)
+	clusterRoles.EXPECT().Get("admin").Return(&rbacv1.ClusterRole{Rules: []rbacv1.PolicyRule{adminRule}}, nil)
+
+	rt := &v3.RoleTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "write"},
+		Rules:      []rbacv1.PolicyRule{writeRule},
+		// read is referenced twice but only gathered once.
+		RoleTemplateNames: []string{"read", "read"},
+	}
+
+	got, err := TemplateRulesFromTemplate(clusterRoles, roleTemplates, rt)
+	assert.NoError(t, err)
+	assert.Equal(t, []TemplateRule{
+		{Rule: writeRule, RoleTemplateNames: []string{"write"}},
+		{Rule: readRule, RoleTemplateNames: []string{"write", "read"}},
+		{Rule: adminRule, RoleTemplateNames: []string{"write", "read", "admin"}},
+	}, got)
+}
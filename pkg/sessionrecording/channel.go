@@ -0,0 +1,210 @@
+package sessionrecording
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// Channels of the streaming protocol of kubectl exec.
+const (
+	stdinChannel  = 0
+	stdoutChannel = 1
+	stderrChannel = 2
+	resizeChannel = 4
+)
+
+// maxFrameSize is the size of the largest websocket frame recorded. Recording stops for a stream with larger frames,
+// which interactive sessions don't send.
+const maxFrameSize = 16 << 20
+
+// Transport wraps the transport of a proxied kubectl exec request, so that the websocket stream of the upgraded
+// connection is recorded. It returns the transport unchanged for a nil recording.
+//
+// The websocket frames are parsed as they pass through, so the request must not negotiate compression.
+func Transport(rt http.RoundTripper, recording *Recording) http.RoundTripper {
+	if recording == nil {
+		return rt
+	}
+	return &transport{
+		next:      rt,
+		recording: recording,
+	}
+}
+
+type transport struct {
+	next      http.RoundTripper
+	recording *Recording
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Del("Sec-WebSocket-Extensions")
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusSwitchingProtocols {
+		return resp, err
+	}
+	if conn, ok := resp.Body.(io.ReadWriteCloser); ok {
+		resp.Body = newChannelConn(conn, t.recording)
+	}
+	return resp, nil
+}
+
+// channelConn records the frames read from and written to a kubectl exec websocket connection.
+type channelConn struct {
+	io.ReadWriteCloser
+	input  frameParser
+	output frameParser
+}
+
+func newChannelConn(conn io.ReadWriteCloser, recording *Recording) *channelConn {
+	onMessage := func(message []byte) {
+		recordMessage(recording, message)
+	}
+	return &channelConn{
+		ReadWriteCloser: conn,
+		input:           frameParser{onMessage: onMessage},
+		output:          frameParser{onMessage: onMessage},
+	}
+}
+
+func (c *channelConn) Read(p []byte) (int, error) {
+	n, err := c.ReadWriteCloser.Read(p)
+	c.output.write(p[:n])
+	return n, err
+}
+
+func (c *channelConn) Write(p []byte) (int, error) {
+	n, err := c.ReadWriteCloser.Write(p)
+	c.input.write(p[:n])
+	return n, err
+}
+
+// recordMessage records a message of the channel.k8s.io or base64.channel.k8s.io protocols. The first byte of a
+// message is its channel, sent as an ASCII digit by the base64 protocol.
+func recordMessage(recording *Recording, message []byte) {
+	if len(message) == 0 {
+		return
+	}
+	channel, data := message[0], message[1:]
+	if channel >= '0' && channel <= '9' {
+		channel -= '0'
+		decoded, err := base64.StdEncoding.DecodeString(string(data))
+		if err != nil {
+			return
+		}
+		data = decoded
+	}
+
+	switch channel {
+	case stdinChannel:
+		recording.Input(data)
+	case stdoutChannel, stderrChannel:
+		recording.Output(data)
+	case resizeChannel:
+		size := struct {
+			Width  int
+			Height int
+		}{}
+		if err := json.Unmarshal(data, &size); err == nil {
+			recording.Resize(size.Width, size.Height)
+		}
+	}
+}
+
+// frameParser reassembles the websocket messages of one direction of a connection.
+type frameParser struct {
+	onMessage func([]byte)
+
+	buf     []byte
+	message []byte
+	broken  bool
+}
+
+func (f *frameParser) write(p []byte) {
+	if f.broken || len(p) == 0 {
+		return
+	}
+	f.buf = append(f.buf, p...)
+
+	for {
+		payload, opcode, fin, n := parseFrame(f.buf)
+		if n < 0 {
+			f.broken, f.buf, f.message = true, nil, nil
+			return
+		}
+		if n == 0 {
+			return
+		}
+		f.buf = f.buf[n:]
+
+		switch {
+		case opcode >= 0x8:
+			// control frames can be interleaved with the fragments of a message
+			continue
+		case opcode == 0x0:
+			f.message = append(f.message, payload...)
+		default:
+			f.message = payload
+		}
+		if fin {
+			f.onMessage(f.message)
+			f.message = nil
+		}
+	}
+}
+
+// parseFrame parses the websocket frame at the start of buf and returns the unmasked payload, opcode, fin bit and the
+// length of the frame. The length is 0 if buf doesn't hold a complete frame, and -1 if the frame is too large.
+func parseFrame(buf []byte) (payload []byte, opcode byte, fin bool, n int) {
+	if len(buf) < 2 {
+		return nil, 0, false, 0
+	}
+	fin = buf[0]&0x80 != 0
+	opcode = buf[0] & 0x0f
+	masked := buf[1]&0x80 != 0
+	length := uint64(buf[1] & 0x7f)
+	offset := 2
+
+	switch length {
+	case 126:
+		if len(buf) < offset+2 {
+			return nil, 0, false, 0
+		}
+		length = uint64(binary.BigEndian.Uint16(buf[offset:]))
+		offset += 2
+	case 127:
+		if len(buf) < offset+8 {
+			return nil, 0, false, 0
+		}
+		length = binary.BigEndian.Uint64(buf[offset:])
+		offset += 8
+	}
+	if length > maxFrameSize {
+		return nil, 0, false, -1
+	}
+
+	var mask []byte
+	if masked {
+		if len(buf) < offset+4 {
+			return nil, 0, false, 0
+		}
+		mask = buf[offset : offset+4]
+		offset += 4
+	}
+	if uint64(len(buf)-offset) < length {
+		return nil, 0, false, 0
+	}
+
+	payload = make([]byte, length)
+	copy(payload, buf[offset:])
+	if mask != nil {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return payload, opcode, fin, offset + int(length)
+}
@@ -0,0 +1,166 @@
+package sessionrecording
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// frame returns a websocket frame of the payload, masked if mask is set as the frames sent by clients are.
+func frame(opcode byte, fin bool, payload []byte, mask []byte) []byte {
+	b0 := opcode
+	if fin {
+		b0 |= 0x80
+	}
+	out := []byte{b0}
+
+	var b1 byte
+	if mask != nil {
+		b1 = 0x80
+	}
+	switch {
+	case len(payload) < 126:
+		out = append(out, b1|byte(len(payload)))
+	case len(payload) <= 0xffff:
+		out = append(out, b1|126)
+		out = binary.BigEndian.AppendUint16(out, uint16(len(payload)))
+	default:
+		out = append(out, b1|127)
+		out = binary.BigEndian.AppendUint64(out, uint64(len(payload)))
+	}
+
+	if mask == nil {
+		return append(out, payload...)
+	}
+	out = append(out, mask...)
+	for i, b := range payload {
+		out = append(out, b^mask[i%4])
+	}
+	return out
+}
+
+func TestFrameParser(t *testing.T) {
+	large := bytes.Repeat([]byte("x"), 300)
+	mask := []byte{1, 2, 3, 4}
+
+	tests := []struct {
+		name   string
+		chunks [][]byte
+		want   [][]byte
+	}{
+		{
+			name:   "unmasked frame",
+			chunks: [][]byte{frame(0x2, true, []byte("hello"), nil)},
+			want:   [][]byte{[]byte("hello")},
+		},
+		{
+			name:   "masked frame",
+			chunks: [][]byte{frame(0x2, true, []byte("hello"), mask)},
+			want:   [][]byte{[]byte("hello")},
+		},
+		{
+			name:   "frame with 16 bit length split across writes",
+			chunks: splitEvery(frame(0x1, true, large, mask), 7),
+			want:   [][]byte{large},
+		},
+		{
+			name: "fragmented message with interleaved ping",
+			chunks: [][]byte{
+				frame(0x2, false, []byte("hel"), nil),
+				frame(0x9, true, []byte("ping"), nil),
+				frame(0x0, true, []byte("lo"), nil),
+			},
+			want: [][]byte{[]byte("hello")},
+		},
+		{
+			name: "several frames in one write",
+			chunks: [][]byte{append(
+				frame(0x2, true, []byte("a"), nil),
+				frame(0x2, true, []byte("b"), nil)...,
+			)},
+			want: [][]byte{[]byte("a"), []byte("b")},
+		},
+		{
+			name: "too large frame stops parsing",
+			chunks: [][]byte{
+				{0x82, 127, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+				frame(0x2, true, []byte("a"), nil),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][]byte
+			parser := frameParser{onMessage: func(message []byte) {
+				got = append(got, message)
+			}}
+			for _, chunk := range tt.chunks {
+				parser.write(chunk)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func splitEvery(data []byte, n int) [][]byte {
+	var chunks [][]byte
+	for len(data) > n {
+		chunks = append(chunks, data[:n])
+		data = data[n:]
+	}
+	return append(chunks, data)
+}
+
+type fakeConn struct {
+	io.Reader
+	written bytes.Buffer
+}
+
+func (f *fakeConn) Write(p []byte) (int, error) {
+	return f.written.Write(p)
+}
+
+func (f *fakeConn) Close() error {
+	return nil
+}
+
+func TestChannelConn(t *testing.T) {
+	recorder, dir := newTestRecorder(t)
+	recording, err := recorder.Start(context.Background(), Metadata{Kind: KindShell}, 80, 24)
+	if !assert.NoError(t, err) {
+		return
+	}
+	recording.onClose = nil
+
+	base64Message := func(channel byte, data string) []byte {
+		return append([]byte{'0' + channel}, base64.StdEncoding.EncodeToString([]byte(data))...)
+	}
+
+	server := bytes.Buffer{}
+	server.Write(frame(0x1, true, base64Message(stdoutChannel, "$ "), nil))
+	server.Write(frame(0x2, true, append([]byte{stderrChannel}, "error\r\n"...), nil))
+	conn := newChannelConn(&fakeConn{Reader: &server}, recording)
+
+	_, err = io.ReadAll(conn)
+	assert.NoError(t, err)
+	_, err = conn.Write(frame(0x1, true, base64Message(stdinChannel, "ls\r"), []byte{9, 8, 7, 6}))
+	assert.NoError(t, err)
+	_, err = conn.Write(frame(0x1, true, base64Message(resizeChannel, `{"Width":100,"Height":30}`), []byte{9, 8, 7, 6}))
+	assert.NoError(t, err)
+
+	assert.NoError(t, recording.Close())
+	lines := readLines(t, &Store{backend: newDirectoryBackend(dir)}, recording.ID)
+	if !assert.Len(t, lines, 5) {
+		return
+	}
+	assert.Equal(t, `[1,"o","$ "]`, string(lines[1]))
+	assert.Equal(t, `[2,"o","error\r\n"]`, string(lines[2]))
+	assert.Equal(t, `[3,"i","ls\r"]`, string(lines[3]))
+	assert.Equal(t, `[4,"r","100x30"]`, string(lines[4]))
+}
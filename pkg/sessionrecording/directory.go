@@ -0,0 +1,77 @@
+package sessionrecording
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// directoryBackend stores recordings in a directory, usually a persistent volume mounted in all Rancher replicas.
+type directoryBackend struct {
+	dir string
+}
+
+func newDirectoryBackend(dir string) *directoryBackend {
+	return &directoryBackend{
+		dir: dir,
+	}
+}
+
+func (d *directoryBackend) put(_ context.Context, name string, body io.ReadSeeker, _ int64) error {
+	if err := os.MkdirAll(d.dir, 0700); err != nil {
+		return err
+	}
+
+	// write to a temporary file first so that partial objects are never listed
+	tmp, err := os.CreateTemp(d.dir, ".tmp-"+name+"-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(d.dir, name))
+}
+
+func (d *directoryBackend) get(_ context.Context, name string) (io.ReadCloser, error) {
+	f, err := os.Open(filepath.Join(d.dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (d *directoryBackend) list(_ context.Context) ([]string, error) {
+	entries, err := os.ReadDir(d.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to list session recordings: %w", err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Type().IsRegular() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+func (d *directoryBackend) delete(_ context.Context, name string) error {
+	err := os.Remove(filepath.Join(d.dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,116 @@
+package sessionrecording
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rancher/rancher/pkg/auth/requests/sar"
+	"github.com/rancher/rancher/pkg/auth/util"
+	"github.com/sirupsen/logrus"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+)
+
+const (
+	// Endpoint is the path recordings are listed at. A recording is replayed at Endpoint/<id>.
+	Endpoint = "/v1/sessionrecordings"
+
+	// resource is the management.cattle.io resource users need to get or list to replay recordings.
+	resource = "sessionrecordings"
+
+	contentTypeAsciicast = "application/x-asciicast"
+)
+
+// NewAuthorizationHandler configures an HTTP middleware that verifies that the user making the request is allowed to
+// perform verb on session recordings, which is granted by the session-recordings-replay global role.
+func NewAuthorizationHandler(sars authorizationv1client.SubjectAccessReviewInterface, verb string) func(http.Handler) http.Handler {
+	return sar.NewSubjectAccessReviewHandler(sars, &authorizationv1.ResourceAttributes{
+		Verb:     verb,
+		Resource: resource,
+		Group:    "management.cattle.io",
+	})
+}
+
+// Handler lists and replays recordings. Requests must be authorized with NewAuthorizationHandler.
+type Handler struct {
+	recorder *Recorder
+}
+
+// NewHandler returns a handler of the recordings of the given recorder.
+func NewHandler(recorder *Recorder) *Handler {
+	return &Handler{
+		recorder: recorder,
+	}
+}
+
+// List responds with the metadata of the recordings, filtered by the user, cluster and machine query parameters.
+func (h *Handler) List(rw http.ResponseWriter, req *http.Request) {
+	store, ok := h.store(rw, req)
+	if !ok {
+		return
+	}
+
+	query := req.URL.Query()
+	recordings, err := store.List(req.Context(), Filter{
+		User:    query.Get("user"),
+		Cluster: query.Get("cluster"),
+		Machine: query.Get("machine"),
+	})
+	if err != nil {
+		logrus.Errorf("Failed to list session recordings: %v", err)
+		util.ReturnHTTPError(rw, req, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	if recordings == nil {
+		recordings = []Metadata{}
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(map[string]any{"data": recordings}); err != nil {
+		logrus.Debugf("Failed to write session recordings: %v", err)
+	}
+}
+
+// Replay responds with the asciicast v2 content of the recording of the id path variable.
+func (h *Handler) Replay(rw http.ResponseWriter, req *http.Request) {
+	store, ok := h.store(rw, req)
+	if !ok {
+		return
+	}
+
+	id := mux.Vars(req)["id"]
+	recording, err := store.Open(req.Context(), id)
+	if errors.Is(err, ErrNotFound) {
+		util.ReturnHTTPError(rw, req, http.StatusNotFound, fmt.Sprintf("session recording %s not found", id))
+		return
+	} else if err != nil {
+		logrus.Errorf("Failed to open session recording %s: %v", id, err)
+		util.ReturnHTTPError(rw, req, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	defer recording.Close()
+
+	rw.Header().Set("Content-Type", contentTypeAsciicast)
+	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", id+recordingSuffix))
+	if _, err := io.Copy(rw, recording); err != nil {
+		logrus.Debugf("Failed to write session recording %s: %v", id, err)
+	}
+}
+
+func (h *Handler) store(rw http.ResponseWriter, req *http.Request) (*Store, bool) {
+	if !h.recorder.Enabled() {
+		util.ReturnHTTPError(rw, req, http.StatusNotFound, "session recording is disabled")
+		return nil, false
+	}
+	store, err := h.recorder.Store(req.Context())
+	if err != nil {
+		logrus.Errorf("Failed to get the session recording store: %v", err)
+		util.ReturnHTTPError(rw, req, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return nil, false
+	}
+	return store, true
+}
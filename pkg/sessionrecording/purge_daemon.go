@@ -0,0 +1,18 @@
+package sessionrecording
+
+import (
+	"context"
+	"time"
+
+	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const purgeInterval = time.Hour
+
+// StartPurgeDaemon periodically deletes the recordings older than the session-recording-retention setting until the
+// context is done.
+func StartPurgeDaemon(ctx context.Context, secrets corecontrollers.SecretClient) {
+	r := NewRecorder(secrets)
+	go wait.JitterUntilWithContext(ctx, r.purge, purgeInterval, .1, true)
+}
@@ -0,0 +1,309 @@
+// Package sessionrecording records the cluster kubectl shell and machine SSH sessions proxied by Rancher in the
+// asciicast v2 format, which can be replayed with asciinema, and stores them in the configured backend.
+package sessionrecording
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rancher/rancher/pkg/auth/audit/event"
+	"github.com/sirupsen/logrus"
+)
+
+// Header is the response header of a recorded session holding the ID of the recording, so that the audit log entry of
+// the session links to it.
+const Header = "X-Rancher-Session-Recording"
+
+const (
+	// partInterval is how often the data recorded since the last upload is uploaded as a part of the recording, so
+	// that little of a session is lost if Rancher stops before the session ends.
+	partInterval = time.Minute
+	// partSize is the size of recorded data which is uploaded as a part right away instead of waiting for the next
+	// partInterval, so that busy sessions don't hold much data in memory.
+	partSize = 1 << 20
+)
+
+// Kind is the kind of session a recording was taken of.
+type Kind string
+
+const (
+	// KindShell is a kubectl shell of a cluster.
+	KindShell Kind = "shell"
+	// KindSSH is an SSH session to a machine.
+	KindSSH Kind = "ssh"
+)
+
+// Metadata describes a recording. It is stored next to the recording and used to find recordings by user, cluster and
+// machine.
+type Metadata struct {
+	ID   string `json:"id"`
+	Kind Kind   `json:"kind"`
+	// User is the name of the user who opened the session.
+	User string `json:"user"`
+	// Cluster is the name of the cluster of the session.
+	Cluster string `json:"cluster,omitempty"`
+	// Machine is the namespace/name of the machine of an SSH session.
+	Machine   string    `json:"machine,omitempty"`
+	StartTime time.Time `json:"startTime"`
+	// EndTime is the time the session ended, or the time of the last upload of the recording while it is in progress.
+	EndTime time.Time `json:"endTime"`
+	// Size is the size of the recording in bytes.
+	Size int64 `json:"size"`
+	// Parts is the number of parts the recording is stored in.
+	Parts int `json:"parts"`
+}
+
+// header is the first line of an asciicast v2 recording.
+type header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Recording records a session and uploads it to the store in parts while the session goes on, the last part being
+// uploaded when the recording is closed. A nil recording records nothing, so that sessions can be proxied the same way
+// whether they are recorded or not.
+type Recording struct {
+	Metadata
+
+	store *Store
+	now   func() time.Time
+
+	lock sync.Mutex
+	// buffer holds the data recorded since the last upload.
+	buffer  bytes.Buffer
+	input   []byte
+	output  []byte
+	err     error
+	closed  bool
+	onClose func(Metadata)
+
+	// pending holds the data of a part which failed to upload, it is only used by the uploader.
+	pending []byte
+	// flush is signaled when the buffer is full, done is closed when the recording is closed and stopped is closed
+	// once the uploader stopped.
+	flush   chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func newRecording(store *Store, metadata Metadata, width, height int, now func() time.Time) (*Recording, error) {
+	r := &Recording{
+		Metadata: metadata,
+		store:    store,
+		now:      now,
+		onClose:  recordAuditEvent,
+		flush:    make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+
+	title := metadata.Cluster
+	if metadata.Machine != "" {
+		title = metadata.Machine
+	}
+	r.writeLine(header{
+		Version:   2,
+		Width:     width,
+		Height:    height,
+		Timestamp: metadata.StartTime.Unix(),
+		Title:     fmt.Sprintf("%s %s by %s", metadata.Kind, title, metadata.User),
+		Env:       map[string]string{"TERM": "xterm"},
+	})
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	// the header is uploaded right away so that the session isn't started if it can't be recorded
+	if err := r.upload(metadata.StartTime); err != nil {
+		return nil, err
+	}
+	go r.run()
+
+	return r, nil
+}
+
+// run uploads the recorded data every partInterval, or as soon as the buffer is full, until the recording is closed.
+// The metadata is uploaded even without new data so that the end time of an idle session keeps up.
+func (r *Recording) run() {
+	defer close(r.stopped)
+	ticker := time.NewTicker(partInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+		case <-r.flush:
+		}
+		if err := r.upload(r.now()); err != nil {
+			logrus.Warnf("Failed to upload session recording %s, retrying: %v", r.ID, err)
+		}
+	}
+}
+
+// upload uploads the data recorded since the last upload as the next part of the recording, followed by the metadata
+// with the given end time. Data which fails to upload is uploaded again with the next part.
+func (r *Recording) upload(end time.Time) error {
+	r.lock.Lock()
+	r.pending = append(r.pending, r.buffer.Bytes()...)
+	r.buffer.Reset()
+	metadata := r.Metadata
+	r.lock.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	metadata.EndTime = end
+	if len(r.pending) > 0 {
+		if err := r.store.savePart(ctx, metadata.ID, metadata.Parts, r.pending); err != nil {
+			return err
+		}
+		metadata.Parts++
+		metadata.Size += int64(len(r.pending))
+	}
+	if err := r.store.saveMetadata(ctx, metadata); err != nil {
+		return err
+	}
+	r.pending = nil
+
+	r.lock.Lock()
+	r.EndTime = metadata.EndTime
+	r.Parts = metadata.Parts
+	r.Size = metadata.Size
+	r.lock.Unlock()
+	return nil
+}
+
+// Input records data sent to the session.
+func (r *Recording) Input(data []byte) {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.input = r.writeData("i", r.input, data)
+}
+
+// Output records data received from the session.
+func (r *Recording) Output(data []byte) {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.output = r.writeData("o", r.output, data)
+}
+
+// Resize records a change of the size of the terminal.
+func (r *Recording) Resize(width, height int) {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.writeEvent("r", fmt.Sprintf("%dx%d", width, height))
+}
+
+// writeData writes data prefixed by the pending bytes of the stream and returns the new pending bytes. Events must be
+// valid UTF-8, so an incomplete character at the end of the data is held back until the rest of it arrives.
+func (r *Recording) writeData(code string, pending, data []byte) []byte {
+	data = append(pending, data...)
+	complete := len(data)
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				complete = i
+			}
+			break
+		}
+	}
+	if complete > 0 {
+		r.writeEvent(code, string(data[:complete]))
+	}
+	return data[complete:]
+}
+
+func (r *Recording) writeEvent(code, data string) {
+	if r.closed {
+		return
+	}
+	elapsed := r.now().Sub(r.StartTime).Seconds()
+	r.writeLine([]any{elapsed, code, data})
+}
+
+func (r *Recording) writeLine(v any) {
+	if r.err != nil {
+		return
+	}
+	line, err := json.Marshal(v)
+	if err != nil {
+		r.err = err
+		return
+	}
+	r.buffer.Write(append(line, '\n'))
+	if r.buffer.Len() >= partSize {
+		select {
+		case r.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Close ends the recording and uploads the rest of it to the store. Closing a recording again does nothing.
+func (r *Recording) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.lock.Lock()
+	if r.closed {
+		r.lock.Unlock()
+		return nil
+	}
+	if len(r.input) > 0 {
+		r.writeEvent("i", string(r.input))
+	}
+	if len(r.output) > 0 {
+		r.writeEvent("o", string(r.output))
+	}
+	r.closed = true
+	err := r.err
+	r.lock.Unlock()
+
+	close(r.done)
+	<-r.stopped
+	if err != nil {
+		return err
+	}
+
+	if err := r.upload(r.now()); err != nil {
+		return err
+	}
+	if r.onClose != nil {
+		r.onClose(r.Metadata)
+	}
+	return nil
+}
+
+// recordAuditEvent logs the stored recording in the audit log, next to the entry of the session itself which has the
+// ID of the recording in its response headers.
+func recordAuditEvent(metadata Metadata) {
+	err := event.Record(event.Event{
+		Method:     http.MethodPost,
+		RequestURI: Endpoint + "/" + metadata.ID,
+		UserName:   metadata.User,
+		Body:       metadata,
+	})
+	if err != nil {
+		logrus.Warnf("Failed to record session recording %s in the audit log: %v", metadata.ID, err)
+	}
+}
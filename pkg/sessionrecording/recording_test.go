@@ -0,0 +1,175 @@
+package sessionrecording
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rancher/rancher/pkg/auth/audit/event"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRecorder returns a recorder storing recordings in a temporary directory, with a clock advancing by a second
+// each time it is read.
+func newTestRecorder(t *testing.T) (*Recorder, string) {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, settings.SessionRecordingBackend.Set(backendDirectory))
+	require.NoError(t, settings.SessionRecordingDirectory.Set(dir))
+	t.Cleanup(func() {
+		_ = settings.SessionRecordingBackend.Set("")
+		_ = settings.SessionRecordingDirectory.Set("")
+	})
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	return &Recorder{
+		now: func() time.Time {
+			t := now
+			now = now.Add(time.Second)
+			return t
+		},
+	}, dir
+}
+
+// readLines returns the lines of a recording, read from all its parts.
+func readLines(t *testing.T, store *Store, id string) [][]byte {
+	t.Helper()
+	f, err := store.Open(context.Background(), id)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 2*partSize)
+	for scanner.Scan() {
+		lines = append(lines, append([]byte(nil), scanner.Bytes()...))
+	}
+	require.NoError(t, scanner.Err())
+	return lines
+}
+
+func TestRecording(t *testing.T) {
+	recorder, dir := newTestRecorder(t)
+
+	var events []event.Event
+	event.SetRecorder(func(e event.Event) error {
+		events = append(events, e)
+		return nil
+	})
+	t.Cleanup(func() { event.SetRecorder(nil) })
+
+	recording, err := recorder.Start(context.Background(), Metadata{
+		Kind:    KindShell,
+		User:    "u-abcde",
+		Cluster: "c-m-12345",
+	}, 80, 24)
+	require.NoError(t, err)
+	require.NotNil(t, recording)
+	assert.Regexp(t, idRegexp, recording.ID)
+
+	// the header is uploaded when the recording starts, so the session is listed while it is in progress
+	store := &Store{backend: newDirectoryBackend(dir)}
+	metadata, err := store.Get(context.Background(), recording.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, metadata.Parts)
+	assert.Equal(t, recording.StartTime, metadata.EndTime)
+	require.Len(t, readLines(t, store, recording.ID), 1)
+
+	recording.Output([]byte("$ "))
+	recording.Input([]byte("ls\r"))
+	recording.Resize(120, 40)
+	// an incomplete UTF-8 character is held back until the rest of it arrives
+	recording.Output([]byte("caf\xc3"))
+	recording.Output([]byte("\xa9\r\n"))
+	require.NoError(t, recording.Close())
+	// closing again and recording after closing does nothing
+	recording.Output([]byte("ignored"))
+	require.NoError(t, recording.Close())
+
+	lines := readLines(t, store, recording.ID)
+	require.Len(t, lines, 6)
+
+	var h header
+	require.NoError(t, json.Unmarshal(lines[0], &h))
+	assert.Equal(t, header{
+		Version:   2,
+		Width:     80,
+		Height:    24,
+		Timestamp: recording.StartTime.Unix(),
+		Title:     "shell c-m-12345 by u-abcde",
+		Env:       map[string]string{"TERM": "xterm"},
+	}, h)
+
+	wantEvents := [][]any{
+		{1.0, "o", "$ "},
+		{2.0, "i", "ls\r"},
+		{3.0, "r", "120x40"},
+		{4.0, "o", "caf"},
+		{5.0, "o", "é\r\n"},
+	}
+	for i, want := range wantEvents {
+		var got []any
+		require.NoError(t, json.Unmarshal(lines[i+1], &got))
+		assert.Equal(t, want, got)
+	}
+
+	metadata, err = store.Get(context.Background(), recording.ID)
+	require.NoError(t, err)
+	assert.Equal(t, recording.Metadata, metadata)
+	assert.Equal(t, "u-abcde", metadata.User)
+	assert.Equal(t, 2, metadata.Parts)
+	assert.Positive(t, metadata.Size)
+
+	require.Len(t, events, 1)
+	assert.Equal(t, Endpoint+"/"+recording.ID, events[0].RequestURI)
+	assert.Equal(t, "u-abcde", events[0].UserName)
+	assert.Equal(t, recording.Metadata, events[0].Body)
+}
+
+func TestRecordingUploadsParts(t *testing.T) {
+	recorder, dir := newTestRecorder(t)
+	store := &Store{backend: newDirectoryBackend(dir)}
+
+	recording, err := recorder.Start(context.Background(), Metadata{Kind: KindSSH, User: "u-1"}, 80, 20)
+	require.NoError(t, err)
+	recording.onClose = nil
+
+	// a full buffer is uploaded as a part without waiting for the next interval
+	recording.Output([]byte(strings.Repeat("a", partSize)))
+	assert.Eventually(t, func() bool {
+		metadata, err := store.Get(context.Background(), recording.ID)
+		return err == nil && metadata.Parts == 2
+	}, 10*time.Second, 10*time.Millisecond)
+
+	recording.Output([]byte("b"))
+	require.NoError(t, recording.Close())
+
+	metadata, err := store.Get(context.Background(), recording.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, metadata.Parts)
+	lines := readLines(t, store, recording.ID)
+	require.Len(t, lines, 3)
+	assert.Contains(t, string(lines[2]), `"o","b"]`)
+	assert.Equal(t, int64(len(bytes.Join(lines, []byte("\n")))+1), metadata.Size)
+}
+
+func TestRecordingDisabled(t *testing.T) {
+	recorder := NewRecorder(nil)
+	require.NoError(t, settings.SessionRecordingBackend.Set(""))
+
+	recording, err := recorder.Start(context.Background(), Metadata{Kind: KindSSH}, 80, 20)
+	require.NoError(t, err)
+	assert.Nil(t, recording)
+
+	// a nil recording records nothing
+	recording.Input([]byte("ls\r"))
+	recording.Output([]byte("file\r\n"))
+	recording.Resize(100, 30)
+	assert.NoError(t, recording.Close())
+}
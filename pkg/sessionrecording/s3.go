@@ -0,0 +1,127 @@
+package sessionrecording
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/rancher/rancher/pkg/settings"
+	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	accessKeyField = "accessKey"
+	secretKeyField = "secretKey"
+)
+
+// s3Backend stores recordings in a bucket of AWS S3 or an S3 compatible object store.
+type s3Backend struct {
+	client *s3.S3
+	bucket string
+	folder string
+}
+
+func newS3Backend(ctx context.Context, secrets corecontrollers.SecretClient) (*s3Backend, error) {
+	bucket := settings.SessionRecordingS3Bucket.Get()
+	if bucket == "" {
+		return nil, fmt.Errorf("session-recording-s3-bucket must be set to store session recordings in s3")
+	}
+
+	config := &aws.Config{
+		Region: aws.String(settings.SessionRecordingS3Region.Get()),
+	}
+	if endpoint := settings.SessionRecordingS3Endpoint.Get(); endpoint != "" {
+		if !strings.Contains(endpoint, "://") {
+			endpoint = "https://" + endpoint
+		}
+		config.Endpoint = aws.String(endpoint)
+		// S3 compatible object stores rarely support virtual hosted buckets
+		config.S3ForcePathStyle = aws.Bool(true)
+	}
+	if name := settings.SessionRecordingS3CredentialSecret.Get(); name != "" {
+		secret, err := secrets.Get(settings.Namespace.Get(), name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the session recording s3 credentials: %w", err)
+		}
+		config.Credentials = credentials.NewStaticCredentials(string(secret.Data[accessKeyField]), string(secret.Data[secretKeyField]), "")
+	}
+
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return nil, fmt.Errorf("error getting new aws session: %w", err)
+	}
+
+	return &s3Backend{
+		client: s3.New(sess),
+		bucket: bucket,
+		folder: strings.Trim(settings.SessionRecordingS3Folder.Get(), "/"),
+	}, nil
+}
+
+func (b *s3Backend) key(name string) string {
+	return path.Join(b.folder, name)
+}
+
+func (b *s3Backend) put(ctx context.Context, name string, body io.ReadSeeker, size int64) error {
+	_, err := b.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(b.bucket),
+		Key:           aws.String(b.key(name)),
+		Body:          body,
+		ContentLength: aws.Int64(size),
+	})
+	return err
+}
+
+func (b *s3Backend) get(ctx context.Context, name string) (io.ReadCloser, error) {
+	out, err := b.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(b.key(name)),
+	})
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsErr.Code() == s3.ErrCodeNoSuchKey {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+func (b *s3Backend) list(ctx context.Context) ([]string, error) {
+	prefix := ""
+	if b.folder != "" {
+		prefix = b.folder + "/"
+	}
+
+	var names []string
+	err := b.client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket:    aws.String(b.bucket),
+		Prefix:    aws.String(prefix),
+		Delimiter: aws.String("/"),
+	}, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, object := range page.Contents {
+			names = append(names, strings.TrimPrefix(aws.StringValue(object.Key), prefix))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list session recordings: %w", err)
+	}
+	return names, nil
+}
+
+func (b *s3Backend) delete(ctx context.Context, name string) error {
+	_, err := b.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(b.key(name)),
+	})
+	return err
+}
@@ -0,0 +1,311 @@
+package sessionrecording
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rancher/rancher/pkg/settings"
+	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/rand"
+)
+
+const (
+	backendDirectory = "directory"
+	backendS3        = "s3"
+
+	recordingSuffix = ".cast"
+	metadataSuffix  = ".json"
+)
+
+var (
+	// ErrNotFound is returned for recordings that don't exist.
+	ErrNotFound = errors.New("session recording not found")
+
+	idRegexp = regexp.MustCompile(`^[0-9tz]+-[0-9a-z]+$`)
+)
+
+// backend stores the recordings and their metadata as objects.
+type backend interface {
+	put(ctx context.Context, name string, body io.ReadSeeker, size int64) error
+	get(ctx context.Context, name string) (io.ReadCloser, error)
+	list(ctx context.Context) ([]string, error)
+	delete(ctx context.Context, name string) error
+}
+
+// Filter selects recordings. Empty fields match all recordings.
+type Filter struct {
+	User    string
+	Cluster string
+	Machine string
+}
+
+func (f Filter) matches(m Metadata) bool {
+	return (f.User == "" || f.User == m.User) &&
+		(f.Cluster == "" || f.Cluster == m.Cluster) &&
+		(f.Machine == "" || f.Machine == m.Machine)
+}
+
+// Store stores recordings in a backend. Each recording is stored in parts as <id>.<part>.cast, which are uploaded while
+// the session goes on, next to its metadata stored as <id>.json.
+type Store struct {
+	backend backend
+}
+
+// partName returns the name of a part of a recording.
+func partName(id string, part int) string {
+	return fmt.Sprintf("%s.%d%s", id, part, recordingSuffix)
+}
+
+func (s *Store) savePart(ctx context.Context, id string, part int, data []byte) error {
+	if err := s.backend.put(ctx, partName(id, part), bytes.NewReader(data), int64(len(data))); err != nil {
+		return fmt.Errorf("failed to store part %d of session recording %s: %w", part, id, err)
+	}
+	return nil
+}
+
+func (s *Store) saveMetadata(ctx context.Context, metadata Metadata) error {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	if err := s.backend.put(ctx, metadata.ID+metadataSuffix, bytes.NewReader(data), int64(len(data))); err != nil {
+		return fmt.Errorf("failed to store the metadata of session recording %s: %w", metadata.ID, err)
+	}
+	return nil
+}
+
+// List returns the metadata of the recordings matching the filter, most recent first.
+func (s *Store) List(ctx context.Context, filter Filter) ([]Metadata, error) {
+	names, err := s.backend.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Metadata
+	for _, name := range names {
+		id, ok := strings.CutSuffix(name, metadataSuffix)
+		if !ok || !idRegexp.MatchString(id) {
+			continue
+		}
+		metadata, err := s.Get(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			// deleted while listing
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if filter.matches(metadata) {
+			result = append(result, metadata)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].StartTime.After(result[j].StartTime)
+	})
+	return result, nil
+}
+
+// Get returns the metadata of a recording.
+func (s *Store) Get(ctx context.Context, id string) (Metadata, error) {
+	var metadata Metadata
+	if !idRegexp.MatchString(id) {
+		return metadata, ErrNotFound
+	}
+	body, err := s.backend.get(ctx, id+metadataSuffix)
+	if err != nil {
+		return metadata, err
+	}
+	defer body.Close()
+	if err := json.NewDecoder(body).Decode(&metadata); err != nil {
+		return metadata, fmt.Errorf("failed to read the metadata of session recording %s: %w", id, err)
+	}
+	return metadata, nil
+}
+
+// Open returns the asciicast v2 content of a recording, the content of its parts one after the other. The recording
+// of a session in progress ends with the last part which was uploaded.
+func (s *Store) Open(ctx context.Context, id string) (io.ReadCloser, error) {
+	metadata, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &partsReader{ctx: ctx, store: s, id: id, parts: metadata.Parts}, nil
+}
+
+// partsReader reads the parts of a recording one after the other, getting each part once the previous one is read.
+type partsReader struct {
+	ctx     context.Context
+	store   *Store
+	id      string
+	parts   int
+	next    int
+	current io.ReadCloser
+}
+
+func (p *partsReader) Read(b []byte) (int, error) {
+	for {
+		if p.current == nil {
+			if p.next >= p.parts {
+				return 0, io.EOF
+			}
+			body, err := p.store.backend.get(p.ctx, partName(p.id, p.next))
+			if err != nil {
+				return 0, fmt.Errorf("failed to read part %d of session recording %s: %w", p.next, p.id, err)
+			}
+			p.current = body
+			p.next++
+		}
+		n, err := p.current.Read(b)
+		if err == io.EOF {
+			p.current.Close()
+			p.current = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (p *partsReader) Close() error {
+	if p.current == nil {
+		return nil
+	}
+	return p.current.Close()
+}
+
+// Purge deletes the recordings which ended before the given time and returns how many were deleted.
+func (s *Store) Purge(ctx context.Context, before time.Time) (int, error) {
+	recordings, err := s.List(ctx, Filter{})
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	for _, recording := range recordings {
+		if !recording.EndTime.Before(before) {
+			continue
+		}
+		// delete the parts first, so that a failure leaves the metadata behind for the next purge. The part after the
+		// last one is deleted too, in case Rancher stopped after uploading it but before updating the metadata.
+		for part := 0; part <= recording.Parts; part++ {
+			if err := s.backend.delete(ctx, partName(recording.ID, part)); err != nil {
+				return count, err
+			}
+		}
+		if err := s.backend.delete(ctx, recording.ID+metadataSuffix); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Recorder starts recordings of sessions in the store configured by the session-recording settings.
+type Recorder struct {
+	secrets corecontrollers.SecretClient
+	now     func() time.Time
+}
+
+// NewRecorder returns a recorder reading the credentials of the s3 backend with the given client.
+func NewRecorder(secrets corecontrollers.SecretClient) *Recorder {
+	return &Recorder{
+		secrets: secrets,
+		now:     time.Now,
+	}
+}
+
+// Enabled returns whether sessions are recorded.
+func (r *Recorder) Enabled() bool {
+	return settings.SessionRecordingBackend.Get() != ""
+}
+
+// Start starts recording a session of the given terminal size. It returns a nil recording if sessions aren't
+// recorded. The ID and start time of the metadata are set by Start.
+func (r *Recorder) Start(ctx context.Context, metadata Metadata, width, height int) (*Recording, error) {
+	if !r.Enabled() {
+		return nil, nil
+	}
+	store, err := r.Store(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata.StartTime = r.now()
+	metadata.ID = newID(metadata.StartTime)
+	return newRecording(store, metadata, width, height, r.now)
+}
+
+// Store returns the store configured by the session-recording settings.
+func (r *Recorder) Store(ctx context.Context) (*Store, error) {
+	var b backend
+	switch name := settings.SessionRecordingBackend.Get(); name {
+	case backendDirectory:
+		b = newDirectoryBackend(settings.SessionRecordingDirectory.Get())
+	case backendS3:
+		var err error
+		if b, err = newS3Backend(ctx, r.secrets); err != nil {
+			return nil, err
+		}
+	case "":
+		return nil, fmt.Errorf("session recording is disabled")
+	default:
+		return nil, fmt.Errorf("unknown session recording backend %q", name)
+	}
+	return &Store{backend: b}, nil
+}
+
+// newID returns a random ID which sorts by the start time of the recording.
+func newID(start time.Time) string {
+	return strings.ToLower(start.UTC().Format("20060102T150405Z")) + "-" + rand.String(8)
+}
+
+// retention returns how long recordings are kept, 0 meaning forever.
+func retention() (time.Duration, error) {
+	value := settings.SessionRecordingRetention.Get()
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid session-recording-retention %q: %w", value, err)
+	}
+	return d, nil
+}
+
+func (r *Recorder) purge(ctx context.Context) {
+	if !r.Enabled() {
+		return
+	}
+	keep, err := retention()
+	if err != nil {
+		logrus.Errorf("Failed to purge session recordings: %v", err)
+		return
+	}
+	if keep <= 0 {
+		return
+	}
+
+	store, err := r.Store(ctx)
+	if err != nil {
+		logrus.Errorf("Failed to purge session recordings: %v", err)
+		return
+	}
+	count, err := store.Purge(ctx, r.now().Add(-keep))
+	if err != nil {
+		logrus.Errorf("Failed to purge session recordings: %v", err)
+	}
+	if count > 0 {
+		logrus.Infof("Purged %d session recordings older than %s", count, keep)
+	}
+}
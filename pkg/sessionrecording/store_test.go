@@ -0,0 +1,125 @@
+package sessionrecording
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store := &Store{backend: newDirectoryBackend(dir)}
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	recordings := []Metadata{
+		{ID: "20240501t120000z-aaaaaaaa", Kind: KindShell, User: "u-1", Cluster: "c-1", StartTime: start, EndTime: start.Add(time.Minute)},
+		{ID: "20240502t120000z-bbbbbbbb", Kind: KindSSH, User: "u-2", Cluster: "c-1", Machine: "fleet-default/m-1", StartTime: start.Add(24 * time.Hour), EndTime: start.Add(25 * time.Hour)},
+		{ID: "20240503t120000z-cccccccc", Kind: KindShell, User: "u-1", Cluster: "c-2", StartTime: start.Add(48 * time.Hour), EndTime: start.Add(49 * time.Hour)},
+	}
+	for _, metadata := range recordings {
+		// the recordings are stored in two parts
+		require.NoError(t, store.savePart(ctx, metadata.ID, 0, []byte(`{"version":2}`+"\n")))
+		require.NoError(t, store.savePart(ctx, metadata.ID, 1, []byte(`[1.0,"o","$ "]`+"\n")))
+		metadata.Parts = 2
+		require.NoError(t, store.saveMetadata(ctx, metadata))
+	}
+	// files which aren't recordings are ignored
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.json"), []byte("{}"), 0600))
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{
+			name: "all, most recent first",
+			want: []string{recordings[2].ID, recordings[1].ID, recordings[0].ID},
+		},
+		{
+			name:   "by user",
+			filter: Filter{User: "u-1"},
+			want:   []string{recordings[2].ID, recordings[0].ID},
+		},
+		{
+			name:   "by cluster",
+			filter: Filter{Cluster: "c-1"},
+			want:   []string{recordings[1].ID, recordings[0].ID},
+		},
+		{
+			name:   "by machine",
+			filter: Filter{Machine: "fleet-default/m-1"},
+			want:   []string{recordings[1].ID},
+		},
+		{
+			name:   "no match",
+			filter: Filter{User: "u-1", Machine: "fleet-default/m-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := store.List(ctx, tt.filter)
+			require.NoError(t, err)
+			var ids []string
+			for _, metadata := range list {
+				ids = append(ids, metadata.ID)
+			}
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+
+	body, err := store.Open(ctx, recordings[0].ID)
+	require.NoError(t, err)
+	content, err := io.ReadAll(body)
+	require.NoError(t, err)
+	require.NoError(t, body.Close())
+	assert.Equal(t, `{"version":2}`+"\n"+`[1.0,"o","$ "]`+"\n", string(content))
+
+	_, err = store.Open(ctx, "20240504t120000z-dddddddd")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = store.Open(ctx, "../etc/passwd")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = store.Get(ctx, "../notes")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	count, err := store.Purge(ctx, start.Add(30*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	list, err := store.List(ctx, Filter{})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, recordings[2].ID, list[0].ID)
+	for part := 0; part < 2; part++ {
+		_, err = os.Stat(filepath.Join(dir, partName(recordings[0].ID, part)))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	}
+}
+
+func TestRecorderPurge(t *testing.T) {
+	recorder, dir := newTestRecorder(t)
+	t.Cleanup(func() { _ = settings.SessionRecordingRetention.Set("2160h") })
+
+	recording, err := recorder.Start(context.Background(), Metadata{Kind: KindShell, User: "u-1"}, 80, 24)
+	require.NoError(t, err)
+	recording.onClose = nil
+	require.NoError(t, recording.Close())
+
+	// recordings are kept forever without retention
+	require.NoError(t, settings.SessionRecordingRetention.Set("0"))
+	recorder.purge(context.Background())
+	_, err = os.Stat(filepath.Join(dir, partName(recording.ID, 0)))
+	assert.NoError(t, err)
+
+	// the clock advances by a second on each read, so the recording ended a second ago
+	require.NoError(t, settings.SessionRecordingRetention.Set("1ms"))
+	recorder.purge(context.Background())
+	_, err = os.Stat(filepath.Join(dir, partName(recording.ID, 0)))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -56,8 +56,12 @@
 		"cattle-elemental-system",
 	}
 
-	AgentImage          = NewSetting("agent-image", "rancher/rancher-agent:head")
-	AgentRolloutTimeout = NewSetting("agent-rollout-timeout", "300s")
+	// AgentFailoverEndpoints is translated to the environment variable CATTLE_SERVER_FAILOVER_ENDPOINTS when rendering
+	// the cluster agent manifest. It is a comma separated list of Rancher URLs, each optionally followed by the checksum
+	// of its CA certificates as fragment, the cluster agent connects to when the server URL is unreachable.
+	AgentFailoverEndpoints = NewSetting("agent-failover-endpoints", "")
+	AgentImage             = NewSetting("agent-image", "rancher/rancher-agent:head")
+	AgentRolloutTimeout    = NewSetting("agent-rollout-timeout", "300s")
 	// AgentTLSMode is translated to the environment variable STRICT_VERIFY when rendering the cluster/node agent manifests and should not be specified as a default agent setting as it has no direct effect on the agent itself.
 	AgentTLSMode                        = NewSetting("agent-tls-mode", AgentTLSModeStrict).WithDefaultOnUpgrade(AgentTLSModeSystemStore)
 	AuthImage                           = NewSetting("auth-image", v32.ToolsSystemImages.AuthSystemImages.KubeAPIAuth)
@@ -73,6 +77,7 @@
 	EngineISOURL                        = NewSetting("engine-iso-url", "https://releases.rancher.com/os/latest/rancheros-vmware.iso")
 	EngineNewestVersion                 = NewSetting("engine-newest-version", "v17.12.0")
 	EngineSupportedRange                = NewSetting("engine-supported-range", "~v1.11.2 || ~v1.12.0 || ~v1.13.0 || ~v17.03.0 || ~v17.06.0 || ~v17.09.0 || ~v18.06.0 || ~v18.09.0 || ~v19.03.0 || ~v20.10.0 || ~v23.0.0 || ~v24.0.0 || ~v25.0.0 || ~v26.0.0 || ~v26.1.0|| ~v27.0.0|| ~v27.1.0|| ~v27.2.0|| ~v27.3.0|| ~v27.4.0|| ~v27.5.0|| ~v28.0.0|| ~v28.1.0")
+	EtcdSnapshotVerifyImage             = NewSetting("etcd-snapshot-verify-image", "rancher/hardened-etcd:v3.5.21-k3s1-build20250612")
 	FirstLogin                          = NewSetting("first-login", "true")
 	GlobalRegistryEnabled               = NewSetting("global-registry-enabled", "false")
 	GithubProxyAPIURL                   = NewSetting("github-proxy-api-url", "https://api.github.com")
@@ -243,6 +248,35 @@
 	// in the go duration string format.
 	S3BucketCheckTimeout = NewSetting("s3-bucket-check-timeout", "30s")
 
+	// SessionRecordingBackend is where recordings of cluster kubectl shell and machine SSH sessions are stored,
+	// either "directory" or "s3". Sessions are not recorded if it is empty.
+	SessionRecordingBackend = NewSetting("session-recording-backend", "")
+
+	// SessionRecordingDirectory is the directory recordings are stored in by the directory backend. It should be a
+	// volume shared by all Rancher replicas.
+	SessionRecordingDirectory = NewSetting("session-recording-directory", "/var/lib/rancher/session-recordings")
+
+	// SessionRecordingRetention is how long recordings are kept, in the go duration string format. Recordings are
+	// kept forever if it is empty or zero.
+	SessionRecordingRetention = NewSetting("session-recording-retention", "2160h") // 90 days
+
+	// SessionRecordingS3Bucket is the bucket recordings are stored in by the s3 backend.
+	SessionRecordingS3Bucket = NewSetting("session-recording-s3-bucket", "")
+
+	// SessionRecordingS3CredentialSecret is the name of a secret in the Rancher namespace holding the accessKey and
+	// secretKey of the s3 backend. The default AWS credential chain is used if it is empty.
+	SessionRecordingS3CredentialSecret = NewSetting("session-recording-s3-credential-secret", "")
+
+	// SessionRecordingS3Endpoint is the endpoint of an S3 compatible object store used by the s3 backend. AWS S3
+	// is used if it is empty.
+	SessionRecordingS3Endpoint = NewSetting("session-recording-s3-endpoint", "")
+
+	// SessionRecordingS3Folder is the folder of the bucket recordings are stored in by the s3 backend.
+	SessionRecordingS3Folder = NewSetting("session-recording-s3-folder", "")
+
+	// SessionRecordingS3Region is the region of the bucket of the s3 backend.
+	SessionRecordingS3Region = NewSetting("session-recording-s3-region", "us-east-1")
+
 	// SystemDefaultRegistry is the default container registry used for images.
 	// The environmental variable "CATTLE_BASE_REGISTRY" controls the default value of this setting.
 	SystemDefaultRegistry = NewSetting("system-default-registry", os.Getenv("CATTLE_BASE_REGISTRY"))
@@ -179,6 +179,22 @@ func SystemTemplate(resp io.Writer, agentImage, authImage, namespace, token, url
 		}
 	}
 
+	// Merge the env vars with the AgentFailoverEndpoints
+	if failoverEndpoints := settings.AgentFailoverEndpoints.Get(); failoverEndpoints != "" {
+		found := false
+		for _, ev := range envVars {
+			if ev.Name == "CATTLE_SERVER_FAILOVER_ENDPOINTS" {
+				found = true // The failover endpoints were specified for this cluster, they take precedence.
+			}
+		}
+		if !found {
+			envVars = append(envVars, corev1.EnvVar{
+				Name:  "CATTLE_SERVER_FAILOVER_ENDPOINTS",
+				Value: failoverEndpoints,
+			})
+		}
+	}
+
 	agentEnvVars = toYAML(envVars)
 
 	if appendTolerations := util.GetClusterAgentTolerations(cluster); appendTolerations != nil {
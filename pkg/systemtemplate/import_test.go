@@ -16,7 +16,9 @@
 	apimgmtv3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	corefakes "github.com/rancher/rancher/pkg/generated/norman/core/v1/fakes"
 	"github.com/rancher/rancher/pkg/image"
+	"github.com/rancher/rancher/pkg/settings"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1"
 	apierror "k8s.io/apimachinery/pkg/api/errors"
@@ -373,3 +375,69 @@ func getHash(b []byte) string {
 	sum := sha256.Sum256(b)
 	return hex.EncodeToString(sum[:])
 }
+
+func TestSystemTemplate_failoverEndpoints(t *testing.T) {
+	secretLister := &corefakes.SecretListerMock{
+		GetFunc: func(namespace string, name string) (*corev1.Secret, error) {
+			return nil, apierror.NewNotFound(schema.GroupResource{}, name)
+		},
+	}
+	require.NoError(t, settings.AgentFailoverEndpoints.Set("https://rancher-dr.example.com"))
+	t.Cleanup(func() {
+		_ = settings.AgentFailoverEndpoints.Set("")
+	})
+
+	tests := []struct {
+		name         string
+		agentEnvVars []corev1.EnvVar
+		expected     string
+	}{
+		{
+			name:     "failover endpoints from the setting",
+			expected: "https://rancher-dr.example.com",
+		},
+		{
+			name:         "failover endpoints of the cluster take precedence",
+			agentEnvVars: []corev1.EnvVar{{Name: "CATTLE_SERVER_FAILOVER_ENDPOINTS", Value: "https://rancher-other.example.com"}},
+			expected:     "https://rancher-other.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := &apimgmtv3.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-prov"},
+				Spec: apimgmtv3.ClusterSpec{
+					ImportedConfig: &apimgmtv3.ImportedConfig{},
+					ClusterSpecBase: apimgmtv3.ClusterSpecBase{
+						AgentEnvVars: tt.agentEnvVars,
+					},
+				},
+				Status: apimgmtv3.ClusterStatus{Driver: "imported"},
+			}
+
+			var b bytes.Buffer
+			err := SystemTemplate(&b, "", "", "", "", "", false, cluster, nil, nil, secretLister, false)
+			require.NoError(t, err)
+
+			var found []string
+			decoder := scheme.Codecs.UniversalDeserializer()
+			for _, r := range strings.Split(b.String(), "---") {
+				obj, _, err := decoder.Decode([]byte(r), nil, nil)
+				if err != nil {
+					continue
+				}
+				deployment, ok := obj.(*appsv1.Deployment)
+				if !ok {
+					continue
+				}
+				for _, ev := range deployment.Spec.Template.Spec.Containers[0].Env {
+					if ev.Name == "CATTLE_SERVER_FAILOVER_ENDPOINTS" {
+						found = append(found, ev.Value)
+					}
+				}
+			}
+			assert.Equal(t, []string{tt.expected}, found)
+		})
+	}
+}
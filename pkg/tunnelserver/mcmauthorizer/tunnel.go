@@ -53,9 +53,17 @@ type cluster struct {
 	CACert  string `json:"caCert"`
 }
 
+// tunnel is the Rancher endpoint a cluster agent connects to, out of the endpoints it is configured with.
+type tunnel struct {
+	Endpoint      string `json:"endpoint"`
+	EndpointIndex int    `json:"endpointIndex"`
+	EndpointCount int    `json:"endpointCount"`
+}
+
 type input struct {
 	Node        *client.Node `json:"node"`
 	Cluster     *cluster     `json:"cluster"`
+	Tunnel      *tunnel      `json:"tunnel,omitempty"`
 	NodeVersion int          `json:"nodeVersion"`
 }
 
@@ -146,7 +154,7 @@ func (t *Authorizer) Authorize(req *http.Request) (*Client, bool, error) {
 	}
 
 	if input.Cluster != nil {
-		cluster, ok, err := t.authorizeCluster(cluster, input.Cluster, req)
+		cluster, ok, err := t.authorizeCluster(cluster, input.Cluster, input.Tunnel, req)
 		return &Client{
 			Cluster: cluster,
 			Token:   token,
@@ -274,16 +282,22 @@ func (t *Authorizer) updateNode(machine *v3.Node, inNode *client.Node, cluster *
 	return machine, nil
 }
 
-func (t *Authorizer) authorizeCluster(cluster *v3.Cluster, inCluster *cluster, req *http.Request) (*v3.Cluster, bool, error) {
+func (t *Authorizer) authorizeCluster(cluster *v3.Cluster, inCluster *cluster, inTunnel *tunnel, req *http.Request) (*v3.Cluster, bool, error) {
 	var (
 		err error
 	)
 
 	if !importDrivers[cluster.Status.Driver] && cluster.Status.Driver != "" {
+		newCluster := cluster.DeepCopy()
+		if setTunnelCondition(newCluster, inTunnel) {
+			if _, err := t.clusters.Update(newCluster); err != nil {
+				logrus.Warnf("failed to update the agent tunnel condition of cluster %s: %v", cluster.Name, err)
+			}
+		}
 		return cluster, true, nil
 	}
 
-	changed := false
+	changed := setTunnelCondition(cluster, inTunnel)
 
 	if cluster.Status.Driver == "" {
 		driver, err := kontainerdriver.GetDriver(cluster, t.KontainerDriverLister)
@@ -334,6 +348,28 @@ func (t *Authorizer) authorizeCluster(cluster *v3.Cluster, inCluster *cluster, r
 	return cluster, true, err
 }
 
+// setTunnelCondition records the Rancher endpoint the cluster agent is connecting to in the AgentTunnel condition of
+// the cluster. The condition is true when the agent uses its primary endpoint. It returns whether the cluster changed.
+func setTunnelCondition(cluster *v3.Cluster, inTunnel *tunnel) bool {
+	if inTunnel == nil {
+		// older agents don't report the tunnel
+		return false
+	}
+
+	before := cluster.DeepCopy()
+	if inTunnel.EndpointIndex == 0 {
+		v32.ClusterConditionAgentTunnel.True(cluster)
+		v32.ClusterConditionAgentTunnel.Reason(cluster, "Primary")
+	} else {
+		v32.ClusterConditionAgentTunnel.False(cluster)
+		v32.ClusterConditionAgentTunnel.Reason(cluster, "Failover")
+	}
+	v32.ClusterConditionAgentTunnel.Message(cluster, fmt.Sprintf("agent connected to %s (endpoint %d of %d)",
+		inTunnel.Endpoint, inTunnel.EndpointIndex+1, inTunnel.EndpointCount))
+
+	return !reflect.DeepEqual(before.Status.Conditions, cluster.Status.Conditions)
+}
+
 func tokenChanged(secret *corev1.Secret, token string) bool {
 	return secret != nil && string(secret.Data[secretmigrator.SecretKey]) != token
 }
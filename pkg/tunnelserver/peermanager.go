@@ -7,6 +7,7 @@
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 
@@ -183,7 +184,7 @@ func (p *peerManager) addRemovePeers(endpoints *v1.Endpoints) {
 	p.notify()
 }
 
-func (p *peerManager) notify() {
+func (p *peerManager) peersLocked() peermanager.Peers {
 	peers := peermanager.Peers{
 		Leader: p.leader,
 		Ready:  p.ready,
@@ -193,7 +194,12 @@ func (p *peerManager) notify() {
 	for id := range p.peers {
 		peers.IDs = append(peers.IDs, id)
 	}
+	sort.Strings(peers.IDs)
+	return peers
+}
 
+func (p *peerManager) notify() {
+	peers := p.peersLocked()
 	for c := range p.listeners {
 		c <- peers
 	}
@@ -215,6 +221,12 @@ func (p *peerManager) RemoveListener(c chan<- peermanager.Peers) {
 	}
 }
 
+func (p *peerManager) Peers() peermanager.Peers {
+	p.Lock()
+	defer p.Unlock()
+	return p.peersLocked()
+}
+
 func (p *peerManager) IsLeader() bool {
 	p.Lock()
 	defer p.Unlock()